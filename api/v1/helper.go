@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -30,6 +31,9 @@ const (
 	ESwithModeLegacy        = "legacy"
 	ESwithModeSwitchDev     = "switchdev"
 
+	EswitchEncapModeEnable  = "enable"
+	EswitchEncapModeDisable = "disable"
+
 	SriovCniStateEnable  = "enable"
 	SriovCniStateDisable = "disable"
 	SriovCniStateAuto    = "auto"
@@ -37,10 +41,26 @@ const (
 	SriovCniStateOn      = "on"
 	SriovCniIpam         = "\"ipam\""
 	SriovCniIpamEmpty    = SriovCniIpam + ":{}"
+
+	VlanProto8021q  = "802.1q"
+	VlanProto8021ad = "802.1ad"
+
+	GUIDGenerationRandom        = "random"
+	GUIDGenerationDeterministic = "deterministic"
+
+	ResetPolicyReset = "Reset"
+	ResetPolicyKeep  = "Keep"
 )
 
 const invalidVfIndex = -1
 
+const (
+	// IbPkeyFullMembership is the bit of the InfiniBand partition key set for the full members of the partition
+	IbPkeyFullMembership = 0x8000
+	// IbDefaultPkey is the base value of the InfiniBand partition key of the default partition
+	IbDefaultPkey = 0x7fff
+)
+
 var ManifestsPath = "./bindata/manifests/cni-config"
 var log = logf.Log.WithName("sriovnetwork")
 
@@ -254,28 +274,123 @@ func GetEswitchModeFromStatus(ifaceStatus *InterfaceExt) string {
 	return ifaceStatus.EswitchMode
 }
 
+// desiredRingSize returns the ring size the PF is expected to have, the requested size
+// is clamped to the maximum reported by the PF if requested
+func desiredRingSize(requested, maximum int, clamp bool) int {
+	if clamp && maximum > 0 && requested > maximum {
+		return maximum
+	}
+	return requested
+}
+
+// PfcPrioritiesMask returns the bitmask of the given priorities with PFC enabled
+func PfcPrioritiesMask(priorities []int) uint8 {
+	var mask uint8
+	for _, prio := range priorities {
+		if prio >= 0 && prio < 8 {
+			mask |= 1 << prio
+		}
+	}
+	return mask
+}
+
+// PriorityToTcArray returns the traffic class of each of the 8 priorities,
+// priorities missing from the map are mapped to traffic class 0
+func PriorityToTcArray(priorityToTc []int) [8]uint8 {
+	var tcs [8]uint8
+	for prio := 0; prio < len(priorityToTc) && prio < len(tcs); prio++ {
+		tcs[prio] = uint8(priorityToTc[prio])
+	}
+	return tcs
+}
+
+// NeedToUpdateSriov returns true if the status of the PF doesn't match its configuration,
+// see DiffInterface for the reasons
 func NeedToUpdateSriov(ifaceSpec *Interface, ifaceStatus *InterfaceExt) bool {
+	diff := DiffInterface(ifaceSpec, ifaceStatus)
+	for _, reason := range diff {
+		log.V(2).Info("NeedToUpdateSriov(): interface needs update", "device", ifaceStatus.PciAddress, "reason", reason)
+	}
+	return len(diff) > 0
+}
+
+// BestEffortSkippedVfs returns the number of VFs requested for an externally managed PF in best effort mode
+// which are not available on the PF, 0 for the other PFs
+func BestEffortSkippedVfs(ifaceSpec *Interface, ifaceStatus *InterfaceExt) int {
+	if !ifaceSpec.ExternallyManaged || !ifaceSpec.ExternallyManagedBestEffort || ifaceStatus.NumVfs >= ifaceSpec.NumVfs {
+		return 0
+	}
+	return ifaceSpec.NumVfs - ifaceStatus.NumVfs
+}
+
+// DiffInterface returns the reasons why the status of the PF doesn't match its configuration,
+// an empty list means the PF doesn't need to be updated
+func DiffInterface(ifaceSpec *Interface, ifaceStatus *InterfaceExt) []string {
+	return diffInterface(ifaceSpec, ifaceStatus, true)
+}
+
+// DriftInterface returns the settings of the configuration of the PF its status doesn't match, unlike
+// DiffInterface it leaves out the reasons to update the PF that don't come from a mismatch
+func DriftInterface(ifaceSpec *Interface, ifaceStatus *InterfaceExt) []string {
+	return diffInterface(ifaceSpec, ifaceStatus, false)
+}
+
+// diffInterface returns the reasons why the status of the PF doesn't match its configuration, the netdevice
+// VFs of the externally managed PFs are always updated when forceUpdate is set
+func diffInterface(ifaceSpec *Interface, ifaceStatus *InterfaceExt, forceUpdate bool) []string {
+	var diff []string
 	if ifaceSpec.Mtu > 0 {
 		mtu := ifaceSpec.Mtu
 		if mtu > ifaceStatus.Mtu {
-			log.V(2).Info("NeedToUpdateSriov(): MTU needs update", "desired", mtu, "current", ifaceStatus.Mtu)
-			return true
+			diff = append(diff, fmt.Sprintf("MTU needs update: desired %d, current %d", mtu, ifaceStatus.Mtu))
 		}
 	}
 	currentEswitchMode := GetEswitchModeFromStatus(ifaceStatus)
 	desiredEswitchMode := GetEswitchModeFromSpec(ifaceSpec)
 	if currentEswitchMode != desiredEswitchMode {
-		log.V(2).Info("NeedToUpdateSriov(): EswitchMode needs update", "desired", desiredEswitchMode, "current", currentEswitchMode)
-		return true
+		diff = append(diff, fmt.Sprintf("EswitchMode needs update: desired %s, current %s", desiredEswitchMode, currentEswitchMode))
 	}
-	if ifaceSpec.NumVfs != ifaceStatus.NumVfs {
-		log.V(2).Info("NeedToUpdateSriov(): NumVfs needs update", "desired", ifaceSpec.NumVfs, "current", ifaceStatus.NumVfs)
-		return true
+	// in best effort mode the externally managed PF keeps the VFs created externally
+	if ifaceSpec.NumVfs != ifaceStatus.NumVfs && BestEffortSkippedVfs(ifaceSpec, ifaceStatus) == 0 {
+		diff = append(diff, fmt.Sprintf("NumVfs needs update: desired %d, current %d", ifaceSpec.NumVfs, ifaceStatus.NumVfs))
 	}
 
-	if ifaceStatus.LinkAdminState == consts.LinkAdminStateDown {
-		log.V(2).Info("NeedToUpdateSriov(): PF link status needs update", "desired to include", "up", "current", ifaceStatus.LinkAdminState)
-		return true
+	// the PF which was down is left down when its link is managed while the number of VFs changes
+	if ifaceStatus.LinkAdminState == consts.LinkAdminStateDown && !ifaceSpec.PfLinkDownOnVfChange {
+		diff = append(diff, fmt.Sprintf("PF link status needs update: desired up, current %s", ifaceStatus.LinkAdminState))
+	}
+
+	if GetEswitchModeFromSpec(ifaceSpec) == ESwithModeSwitchDev {
+		if ifaceSpec.EswitchInlineMode != "" && ifaceSpec.EswitchInlineMode != ifaceStatus.EswitchInlineMode {
+			diff = append(diff, fmt.Sprintf("eswitch inline mode needs update: desired %s, current %s",
+				ifaceSpec.EswitchInlineMode, ifaceStatus.EswitchInlineMode))
+		}
+		if ifaceSpec.EswitchEncapMode != "" && ifaceSpec.EswitchEncapMode != ifaceStatus.EswitchEncapMode {
+			diff = append(diff, fmt.Sprintf("eswitch encap mode needs update: desired %s, current %s",
+				ifaceSpec.EswitchEncapMode, ifaceStatus.EswitchEncapMode))
+		}
+	}
+
+	if ifaceSpec.CombinedChannels > 0 && ifaceSpec.CombinedChannels != ifaceStatus.CombinedChannels {
+		diff = append(diff, fmt.Sprintf("combined channels needs update: desired %d, current %d",
+			ifaceSpec.CombinedChannels, ifaceStatus.CombinedChannels))
+	}
+
+	if desired := desiredRingSize(ifaceSpec.RingRx, ifaceStatus.RingRxMax, ifaceSpec.RingClamp); desired > 0 &&
+		desired != ifaceStatus.RingRx {
+		diff = append(diff, fmt.Sprintf("RX ring size needs update: desired %d, current %d", desired, ifaceStatus.RingRx))
+	}
+	if desired := desiredRingSize(ifaceSpec.RingTx, ifaceStatus.RingTxMax, ifaceSpec.RingClamp); desired > 0 &&
+		desired != ifaceStatus.RingTx {
+		diff = append(diff, fmt.Sprintf("TX ring size needs update: desired %d, current %d", desired, ifaceStatus.RingTx))
+	}
+
+	if len(ifaceSpec.PfcEnabled) > 0 && PfcPrioritiesMask(ifaceSpec.PfcEnabled) != PfcPrioritiesMask(ifaceStatus.PfcEnabled) {
+		diff = append(diff, fmt.Sprintf("PFC needs update: desired %v, current %v", ifaceSpec.PfcEnabled, ifaceStatus.PfcEnabled))
+	}
+	if len(ifaceSpec.PriorityToTcMap) > 0 && PriorityToTcArray(ifaceSpec.PriorityToTcMap) != PriorityToTcArray(ifaceStatus.PriorityToTcMap) {
+		diff = append(diff, fmt.Sprintf("priority to traffic class map needs update: desired %v, current %v",
+			ifaceSpec.PriorityToTcMap, ifaceStatus.PriorityToTcMap))
 	}
 
 	if ifaceSpec.NumVfs > 0 {
@@ -284,63 +399,170 @@ func NeedToUpdateSriov(ifaceSpec *Interface, ifaceStatus *InterfaceExt) bool {
 			for _, groupSpec := range ifaceSpec.VfGroups {
 				if IndexInRange(vfStatus.VfID, groupSpec.VfRange) {
 					ingroup = true
-					if vfStatus.Driver == "" {
-						log.V(2).Info("NeedToUpdateSriov(): Driver needs update - has no driver",
-							"desired", groupSpec.DeviceType)
-						return true
-					}
-					if groupSpec.DeviceType != "" && groupSpec.DeviceType != consts.DeviceTypeNetDevice {
-						if groupSpec.DeviceType != vfStatus.Driver {
-							log.V(2).Info("NeedToUpdateSriov(): Driver needs update",
-								"desired", groupSpec.DeviceType, "current", vfStatus.Driver)
-							return true
-						}
-					} else {
-						if StringInArray(vfStatus.Driver, vars.DpdkDrivers) {
-							log.V(2).Info("NeedToUpdateSriov(): Driver needs update",
-								"desired", groupSpec.DeviceType, "current", vfStatus.Driver)
-							return true
-						}
-						if vfStatus.Mtu != 0 && groupSpec.Mtu != 0 && vfStatus.Mtu != groupSpec.Mtu {
-							log.V(2).Info("NeedToUpdateSriov(): VF MTU needs update",
-								"vf", vfStatus.VfID, "desired", groupSpec.Mtu, "current", vfStatus.Mtu)
-							return true
-						}
-
-						if (strings.EqualFold(ifaceStatus.LinkType, consts.LinkTypeETH) && groupSpec.IsRdma) || strings.EqualFold(ifaceStatus.LinkType, consts.LinkTypeIB) {
-							// We do this check only if a Node GUID is set to ensure that we were able to read the
-							// Node GUID. We intentionally skip empty Node GUID in vfStatus because this may happen
-							// when the VF is allocated to a workload.
-							if vfStatus.GUID == consts.UninitializedNodeGUID {
-								log.V(2).Info("NeedToUpdateSriov(): VF GUID needs update",
-									"vf", vfStatus.VfID, "current", vfStatus.GUID)
-								return true
-							}
-						}
-						// this is needed to be sure the admin mac address is configured as expected
-						if ifaceSpec.ExternallyManaged {
-							log.V(2).Info("NeedToUpdateSriov(): need to update the device as it's externally manage",
-								"device", ifaceStatus.PciAddress)
-							return true
-						}
-					}
-					if groupSpec.VdpaType != vfStatus.VdpaType {
-						log.V(2).Info("NeedToUpdateSriov(): VF VdpaType mismatch",
-							"desired", groupSpec.VdpaType, "current", vfStatus.VdpaType)
-						return true
-					}
+					diff = append(diff, diffVf(ifaceSpec, ifaceStatus, &groupSpec, &vfStatus, forceUpdate)...)
 					break
 				}
 			}
-			if !ingroup && (StringInArray(vfStatus.Driver, vars.DpdkDrivers) || vfStatus.VdpaType != "") {
-				// need to reset VF if it is not a part of a group and:
-				// a. has DPDK driver loaded
-				// b. has VDPA device
-				return true
+			if !ingroup && StringInArray(vfStatus.Driver, vars.DpdkDrivers) {
+				// need to reset VF if it is not a part of a group and has DPDK driver loaded
+				diff = append(diff, fmt.Sprintf("VF %d needs reset: not in a VF group and has DPDK driver %s", vfStatus.VfID, vfStatus.Driver))
+			} else if !ingroup && vfStatus.VdpaType != "" {
+				// need to reset VF if it is not a part of a group and has VDPA device
+				diff = append(diff, fmt.Sprintf("VF %d needs reset: not in a VF group and has VDPA device %s", vfStatus.VfID, vfStatus.VdpaType))
 			}
 		}
 	}
-	return false
+	return diff
+}
+
+// diffVf returns the reasons why the status of the VF doesn't match the configuration of its VF group
+func diffVf(ifaceSpec *Interface, ifaceStatus *InterfaceExt, groupSpec *VfGroup, vfStatus *VirtualFunction, forceUpdate bool) []string {
+	var diff []string
+	if vfStatus.Driver == "" {
+		diff = append(diff, fmt.Sprintf("VF %d driver needs update: desired %s, has no driver", vfStatus.VfID, groupSpec.DeviceType))
+	}
+	if groupSpec.DeviceType != "" && groupSpec.DeviceType != consts.DeviceTypeNetDevice {
+		if vfStatus.Driver != "" && groupSpec.DeviceType != vfStatus.Driver {
+			diff = append(diff, fmt.Sprintf("VF %d driver needs update: desired %s, current %s", vfStatus.VfID, groupSpec.DeviceType, vfStatus.Driver))
+		}
+	} else {
+		if StringInArray(vfStatus.Driver, vars.DpdkDrivers) {
+			diff = append(diff, fmt.Sprintf("VF %d driver needs update: desired %s, current %s", vfStatus.VfID, groupSpec.DeviceType, vfStatus.Driver))
+		}
+		if vfStatus.Mtu != 0 && groupSpec.Mtu != 0 && vfStatus.Mtu != groupSpec.Mtu {
+			diff = append(diff, fmt.Sprintf("VF %d MTU needs update: desired %d, current %d", vfStatus.VfID, groupSpec.Mtu, vfStatus.Mtu))
+		}
+
+		if (strings.EqualFold(ifaceStatus.LinkType, consts.LinkTypeETH) && groupSpec.IsRdma) || strings.EqualFold(ifaceStatus.LinkType, consts.LinkTypeIB) {
+			// We do this check only if a Node GUID is set to ensure that we were able to read the
+			// Node GUID. We intentionally skip empty Node GUID in vfStatus because this may happen
+			// when the VF is allocated to a workload.
+			if vfStatus.GUID == consts.UninitializedNodeGUID {
+				diff = append(diff, fmt.Sprintf("VF %d GUID needs update: current %s", vfStatus.VfID, vfStatus.GUID))
+			}
+		}
+		// this is needed to be sure the admin mac address is configured as expected
+		if ifaceSpec.ExternallyManaged && forceUpdate {
+			diff = append(diff, fmt.Sprintf("VF %d needs update: the PF is externally managed", vfStatus.VfID))
+		}
+	}
+	if groupSpec.VlanID != nil && !vfVlanMatches(groupSpec, vfStatus) {
+		diff = append(diff, fmt.Sprintf("VF %d VLAN needs update: desired %d, current %d", vfStatus.VfID, *groupSpec.VlanID, vfStatus.Vlan))
+	}
+	if groupSpec.VlanTrunk != "" && !vfVlanTrunkMatches(groupSpec.VlanTrunk, vfStatus.VlanTrunk) {
+		diff = append(diff, fmt.Sprintf("VF %d VLAN trunk needs update: desired %s, current %s", vfStatus.VfID, groupSpec.VlanTrunk, vfStatus.VlanTrunk))
+	}
+	// the trunk of the VF is cleared when the group doesn't set it
+	if groupSpec.VlanTrunk == "" && vfStatus.VlanTrunk != "" {
+		diff = append(diff, fmt.Sprintf("VF %d VLAN trunk needs update: desired none, current %s", vfStatus.VfID, vfStatus.VlanTrunk))
+	}
+	if groupSpec.SpoofChk != "" && groupSpec.SpoofChk != vfStatus.SpoofChk {
+		diff = append(diff, fmt.Sprintf("VF %d spoof check needs update: desired %s, current %s", vfStatus.VfID, groupSpec.SpoofChk, vfStatus.SpoofChk))
+	}
+	if priority := groupSpec.GetQosPriority(); priority != nil && *priority != vfStatus.VlanQoS {
+		diff = append(diff, fmt.Sprintf("VF %d QoS priority needs update: desired %d, current %d", vfStatus.VfID, *priority, vfStatus.VlanQoS))
+	}
+	if groupSpec.Qos != nil && groupSpec.Qos.Group != "" && vfStatus.RepresentorName != "" && groupSpec.Qos.Group != vfStatus.QosGroup {
+		diff = append(diff, fmt.Sprintf("VF %d QoS group needs update: desired %s, current %s", vfStatus.VfID, groupSpec.Qos.Group, vfStatus.QosGroup))
+	}
+	if groupSpec.Trust != "" && groupSpec.Trust != vfStatus.Trust {
+		diff = append(diff, fmt.Sprintf("VF %d trust mode needs update: desired %s, current %s", vfStatus.VfID, groupSpec.Trust, vfStatus.Trust))
+	}
+	if groupSpec.MinTxRate != nil && *groupSpec.MinTxRate != vfStatus.MinTxRate {
+		diff = append(diff, fmt.Sprintf("VF %d min tx rate needs update: desired %d, current %d", vfStatus.VfID, *groupSpec.MinTxRate, vfStatus.MinTxRate))
+	}
+	if groupSpec.MaxTxRate != nil && *groupSpec.MaxTxRate != vfStatus.MaxTxRate {
+		diff = append(diff, fmt.Sprintf("VF %d max tx rate needs update: desired %d, current %d", vfStatus.VfID, *groupSpec.MaxTxRate, vfStatus.MaxTxRate))
+	}
+	if groupSpec.LinkState != "" && groupSpec.LinkState != vfStatus.LinkState {
+		diff = append(diff, fmt.Sprintf("VF %d link state needs update: desired %s, current %s", vfStatus.VfID, groupSpec.LinkState, vfStatus.LinkState))
+	}
+	if groupSpec.IbPkey != "" && !vfIbPkeyMatches(groupSpec.IbPkey, vfStatus.IbPkey) {
+		diff = append(diff, fmt.Sprintf("VF %d partition key needs update: desired %s, current %s", vfStatus.VfID, groupSpec.IbPkey, vfStatus.IbPkey))
+	}
+	if groupSpec.Mac != "" && !vfMacMatches(groupSpec.Mac, vfStatus.AdminMac) {
+		diff = append(diff, fmt.Sprintf("VF %d MAC needs update: desired %s, current %s", vfStatus.VfID, groupSpec.Mac, vfStatus.AdminMac))
+	}
+	if groupSpec.VdpaType != vfStatus.VdpaType {
+		diff = append(diff, fmt.Sprintf("VF %d VdpaType needs update: desired %s, current %s", vfStatus.VfID, groupSpec.VdpaType, vfStatus.VdpaType))
+	}
+	return diff
+}
+
+// vfIbPkeyMatches checks if the partition key of the VF is the one requested by the VF group
+func vfIbPkeyMatches(desired, current string) bool {
+	desiredPkey, err := ParseIbPkey(desired)
+	if err != nil {
+		return false
+	}
+	currentPkey, err := ParseIbPkey(current)
+	if err != nil {
+		return false
+	}
+	return IbPkeyMatches(desiredPkey, currentPkey)
+}
+
+// vfMacMatches checks if the administrative MAC of the VF is the one requested by the VF group
+func vfMacMatches(desired, current string) bool {
+	desiredMac, err := net.ParseMAC(desired)
+	if err != nil {
+		return false
+	}
+	currentMac, err := net.ParseMAC(current)
+	if err != nil {
+		return false
+	}
+	return desiredMac.String() == currentMac.String()
+}
+
+// vfVlanMatches checks if the VLAN configured on the VF is the one requested by the VF group
+func vfVlanMatches(groupSpec *VfGroup, vfStatus *VirtualFunction) bool {
+	if *groupSpec.VlanID != vfStatus.Vlan {
+		return false
+	}
+	// QoS and protocol are meaningless when the VLAN is cleared
+	if vfStatus.Vlan == 0 {
+		return true
+	}
+	// the QoS priority of the group takes precedence over the VLAN QoS and is compared separately
+	if groupSpec.GetQosPriority() == nil && groupSpec.VlanQoS != vfStatus.VlanQoS {
+		return false
+	}
+	return GetVlanProto(groupSpec.VlanProto) == GetVlanProto(vfStatus.VlanProto)
+}
+
+// UnsupportedVfGroupSettings returns the settings of the VF group the capabilities of the PF don't allow
+func (c *InterfaceCapabilities) UnsupportedVfGroupSettings(group *VfGroup) []string {
+	unsupported := []string{}
+	if group.Trust == SriovCniStateOn && !c.VfTrust {
+		unsupported = append(unsupported, "trust mode")
+	}
+	if group.SpoofChk == SriovCniStateOn && !c.VfSpoofChk {
+		unsupported = append(unsupported, "spoof check")
+	}
+	if group.VlanID != nil && GetVlanProto(group.VlanProto) == VlanProto8021ad && !c.VfVlanProto8021ad {
+		unsupported = append(unsupported, "802.1ad VLAN protocol")
+	}
+	if group.MaxTxRate != nil && c.MaxTxRate > 0 && *group.MaxTxRate > c.MaxTxRate {
+		unsupported = append(unsupported, fmt.Sprintf("max TX rate %d Mbps above the link speed %d Mbps", *group.MaxTxRate, c.MaxTxRate))
+	}
+	return unsupported
+}
+
+// GetQosPriority returns the 802.1p priority requested for the VFs of the group, nil if it isn't managed
+func (g *VfGroup) GetQosPriority() *int {
+	if g.Qos == nil {
+		return nil
+	}
+	return g.Qos.Priority
+}
+
+// GetVlanProto returns the normalized VLAN protocol, 802.1q is returned if the protocol is not set
+func GetVlanProto(proto string) string {
+	if proto == "" {
+		return VlanProto8021q
+	}
+	return strings.ToLower(proto)
 }
 
 type ByPriority []SriovNetworkNodePolicy
@@ -371,6 +593,115 @@ func (p *SriovNetworkNodePolicy) Selected(node *corev1.Node) bool {
 	return true
 }
 
+var pciAddressRe = regexp.MustCompile(`^(?:([0-9a-fA-F]{1,8}):)?([0-9a-fA-F]{1,2}):([0-9a-fA-F]{1,2})\.([0-7])$`)
+
+// NormalizePciAddress validates the PCI address in the [domain:]bus:slot.func form and returns it
+// in the canonical lower case form with at least a 16-bit domain, e.g. "3:0.0" becomes "0000:03:00.0",
+// the wider domains of the VMD devices, e.g. "10000:03:00.0", are kept
+func NormalizePciAddress(addr string) (string, error) {
+	m := pciAddressRe.FindStringSubmatch(strings.TrimSpace(addr))
+	if m == nil {
+		return "", fmt.Errorf("invalid PCI address %q, expected [domain:]bus:slot.func", addr)
+	}
+	domain, bus, slot := m[1], m[2], m[3]
+	if domain == "" {
+		domain = "0"
+	}
+	d, _ := strconv.ParseUint(domain, 16, 32)
+	b, _ := strconv.ParseUint(bus, 16, 8)
+	sl, _ := strconv.ParseUint(slot, 16, 8)
+	if sl > 0x1f {
+		return "", fmt.Errorf("invalid PCI address %q, slot %#x out of range", addr, sl)
+	}
+	return fmt.Sprintf("%04x:%02x:%02x.%s", d, b, sl, m[4]), nil
+}
+
+// IsValidPciAddress returns true if the address is a PCI address in the [domain:]bus:slot.func form
+func IsValidPciAddress(addr string) bool {
+	_, err := NormalizePciAddress(addr)
+	return err == nil
+}
+
+// PciAddressEqual returns true if the two addresses refer to the same PCI device,
+// invalid addresses are compared as plain strings
+func PciAddressEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	na, errA := NormalizePciAddress(a)
+	nb, errB := NormalizePciAddress(b)
+	return errA == nil && errB == nil && na == nb
+}
+
+// Kinds of the entries of the discovery allow and deny lists
+const (
+	DiscoveryFilterVendor = "vendor"
+	DiscoveryFilterDevice = "device"
+	DiscoveryFilterPci    = "pci"
+)
+
+// ParseDiscoveryFilterEntry parses an entry of the discovery allow and deny lists in the kind:value form,
+// e.g. "vendor:15b3", "device:101d" or "pci:0000:3b:*", the value of the pci kind is a glob matched
+// against the canonical PCI address of the device
+func ParseDiscoveryFilterEntry(entry string) (string, string, error) {
+	kind, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+	if !found || value == "" {
+		return "", "", fmt.Errorf("invalid discovery filter %q, expected vendor:<id>, device:<id> or pci:<address glob>", entry)
+	}
+	switch kind {
+	case DiscoveryFilterVendor, DiscoveryFilterDevice:
+		if _, err := strconv.ParseUint(value, 16, 16); err != nil {
+			return "", "", fmt.Errorf("invalid discovery filter %q, %s must be a 4 digit hexadecimal ID", entry, kind)
+		}
+		return kind, strings.ToLower(value), nil
+	case DiscoveryFilterPci:
+		if _, err := filepath.Match(value, ""); err != nil {
+			return "", "", fmt.Errorf("invalid discovery filter %q: %v", entry, err)
+		}
+		return kind, strings.ToLower(value), nil
+	}
+	return "", "", fmt.Errorf("invalid discovery filter %q, unknown kind %q", entry, kind)
+}
+
+// DiscoveryFilterMatch returns true if the PCI device with the provided vendor, device ID and address
+// matches one of the entries of the discovery filter, the invalid entries never match
+func DiscoveryFilterMatch(entries []string, vendor, deviceID, pciAddr string) bool {
+	if address, err := NormalizePciAddress(pciAddr); err == nil {
+		pciAddr = address
+	}
+	for _, entry := range entries {
+		kind, value, err := ParseDiscoveryFilterEntry(entry)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case DiscoveryFilterVendor:
+			if strings.EqualFold(vendor, value) {
+				return true
+			}
+		case DiscoveryFilterDevice:
+			if strings.EqualFold(deviceID, value) {
+				return true
+			}
+		case DiscoveryFilterPci:
+			if matched, _ := filepath.Match(value, strings.ToLower(pciAddr)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PciAddressInArray returns true if the PCI address is in the array, see PciAddressEqual
+func PciAddressInArray(addr string, array []string) bool {
+	for _, a := range array {
+		if PciAddressEqual(addr, a) {
+			return true
+		}
+	}
+	return false
+}
+
 func StringInArray(val string, array []string) bool {
 	for i := range array {
 		if array[i] == val {
@@ -402,8 +733,31 @@ func UniqueAppend(inSlice []string, strings ...string) []string {
 	return inSlice
 }
 
+// mergeHugepages adds the hugepages requested by a policy to the hugepages of the node, the policies requesting
+// hugepages of the same size are merged and the largest count wins
+func mergeHugepages(hugepages []Hugepages, requested Hugepages) []Hugepages {
+	for i := range hugepages {
+		if hugepages[i].Size == requested.Size {
+			if requested.Count > hugepages[i].Count {
+				hugepages[i].Count = requested.Count
+			}
+			return hugepages
+		}
+	}
+	return append(hugepages, requested)
+}
+
 // Apply policy to SriovNetworkNodeState CR
 func (p *SriovNetworkNodePolicy) Apply(state *SriovNetworkNodeState, equalPriority bool) error {
+	// the RDMA subsystem mode is a node setting, the last policy requesting it wins
+	if p.Spec.RdmaMode != "" {
+		state.Spec.RdmaMode = p.Spec.RdmaMode
+	}
+	// the required kernel modules are collected from all the policies selecting the node
+	state.Spec.RequiredKernelModules = UniqueAppend(state.Spec.RequiredKernelModules, p.Spec.RequiredKernelModules...)
+	if p.Spec.HugepageSize != "" && p.Spec.HugepageCount > 0 {
+		state.Spec.Hugepages = mergeHugepages(state.Spec.Hugepages, Hugepages{Size: p.Spec.HugepageSize, Count: p.Spec.HugepageCount})
+	}
 	s := p.Spec.NicSelector
 	if s.Vendor == "" && s.DeviceID == "" && len(s.RootDevices) == 0 && len(s.PfNames) == 0 &&
 		len(s.NetFilter) == 0 {
@@ -414,13 +768,26 @@ func (p *SriovNetworkNodePolicy) Apply(state *SriovNetworkNodeState, equalPriori
 		if s.Selected(&iface) {
 			log.Info("Update interface", "name:", iface.Name)
 			result := Interface{
-				PciAddress:        iface.PciAddress,
-				Mtu:               p.Spec.Mtu,
-				Name:              iface.Name,
-				LinkType:          p.Spec.LinkType,
-				EswitchMode:       p.Spec.EswitchMode,
-				NumVfs:            p.Spec.NumVfs,
-				ExternallyManaged: p.Spec.ExternallyManaged,
+				PciAddress:                  iface.PciAddress,
+				Mtu:                         p.Spec.Mtu,
+				Name:                        iface.Name,
+				LinkType:                    p.Spec.LinkType,
+				EswitchMode:                 p.Spec.EswitchMode,
+				NumVfs:                      p.Spec.NumVfs,
+				ExternallyManaged:           p.Spec.ExternallyManaged,
+				ExternallyManagedBestEffort: p.Spec.ExternallyManagedBestEffort,
+				IncrementalVfs:              p.Spec.IncrementalVfs,
+				AdoptExisting:               p.Spec.AdoptExisting,
+				PfLinkDownOnVfChange:        p.Spec.PfLinkDownOnVfChange,
+				ResetPolicy:                 p.Spec.ResetPolicy,
+				PfcEnabled:                  p.Spec.PfcEnabled,
+				PriorityToTcMap:             p.Spec.PriorityToTcMap,
+				EswitchInlineMode:           p.Spec.EswitchInlineMode,
+				EswitchEncapMode:            p.Spec.EswitchEncapMode,
+				CombinedChannels:            p.Spec.PfCombinedChannels,
+				RingRx:                      p.Spec.RingRx,
+				RingTx:                      p.Spec.RingTx,
+				RingClamp:                   p.Spec.RingClamp,
 			}
 			if p.Spec.NumVfs > 0 {
 				group, err := p.generatePfNameVfGroup(&iface)
@@ -430,8 +797,10 @@ func (p *SriovNetworkNodePolicy) Apply(state *SriovNetworkNodeState, equalPriori
 				result.VfGroups = []VfGroup{*group}
 				found := false
 				for i := range state.Spec.Interfaces {
-					if state.Spec.Interfaces[i].PciAddress == result.PciAddress {
+					if PciAddressEqual(state.Spec.Interfaces[i].PciAddress, result.PciAddress) {
 						found = true
+						// the PF is adopted as soon as one of the policies selecting it requests it
+						result.AdoptExisting = result.AdoptExisting || state.Spec.Interfaces[i].AdoptExisting
 						state.Spec.Interfaces[i].mergeConfigs(&result, equalPriority)
 						state.Spec.Interfaces[i] = result
 						break
@@ -474,6 +843,28 @@ func (iface Interface) mergeConfigs(input *Interface, equalPriority bool) {
 	if input.NumVfs < iface.NumVfs {
 		input.NumVfs = iface.NumVfs
 	}
+	// keep the VFs if any of the merged policies asks for it
+	if iface.ResetPolicy == ResetPolicyKeep {
+		input.ResetPolicy = ResetPolicyKeep
+	}
+	// DCB settings are taken from the highest priority policy setting them
+	if len(input.PfcEnabled) == 0 {
+		input.PfcEnabled = iface.PfcEnabled
+	}
+	if len(input.PriorityToTcMap) == 0 {
+		input.PriorityToTcMap = iface.PriorityToTcMap
+	}
+	// the channels and the ring sizes are taken from the highest priority policy setting them
+	if input.CombinedChannels == 0 {
+		input.CombinedChannels = iface.CombinedChannels
+	}
+	if input.RingRx == 0 {
+		input.RingRx = iface.RingRx
+	}
+	if input.RingTx == 0 {
+		input.RingTx = iface.RingTx
+	}
+	input.RingClamp = input.RingClamp || iface.RingClamp
 }
 
 func (gr VfGroup) isVFRangeOverlapping(group VfGroup) bool {
@@ -516,17 +907,380 @@ func (p *SriovNetworkNodePolicy) generatePfNameVfGroup(iface *InterfaceExt) (*Vf
 		rngStart, rngEnd = 0, p.Spec.NumVfs-1
 	}
 	rng := strconv.Itoa(rngStart) + "-" + strconv.Itoa(rngEnd)
+	var vfGUIDs map[string]string
+	for vf, guid := range p.Spec.VfGUIDs {
+		if vfID, err := strconv.Atoi(vf); err == nil && vfID >= rngStart && vfID <= rngEnd {
+			if vfGUIDs == nil {
+				vfGUIDs = map[string]string{}
+			}
+			vfGUIDs[vf] = guid
+		}
+	}
 	return &VfGroup{
-		ResourceName: p.Spec.ResourceName,
-		DeviceType:   p.Spec.DeviceType,
-		VfRange:      rng,
-		PolicyName:   p.GetName(),
-		Mtu:          p.Spec.Mtu,
-		IsRdma:       p.Spec.IsRdma,
-		VdpaType:     p.Spec.VdpaType,
+		ResourceName:     p.Spec.ResourceName,
+		DeviceType:       p.Spec.DeviceType,
+		VfRange:          rng,
+		PolicyName:       p.GetName(),
+		Mtu:              p.Spec.Mtu,
+		IsRdma:           p.Spec.IsRdma,
+		VdpaType:         p.Spec.VdpaType,
+		SpoofChk:         p.Spec.SpoofChk,
+		Trust:            p.Spec.Trust,
+		VfGUIDs:          vfGUIDs,
+		GUIDGeneration:   p.Spec.GUIDGeneration,
+		MacPool:          p.Spec.MacPool,
+		MacBase:          p.Spec.MacBase,
+		Mac:              p.Spec.Mac,
+		IbPkey:           p.Spec.IbPkey,
+		Qos:              p.Spec.Qos,
+		VlanID:           p.Spec.VlanID,
+		VlanQoS:          p.Spec.VlanQoS,
+		VlanProto:        p.Spec.VlanProto,
+		VlanTrunk:        p.Spec.VlanTrunk,
+		MinTxRate:        p.Spec.MinTxRate,
+		MaxTxRate:        p.Spec.MaxTxRate,
+		LinkState:        p.Spec.LinkState,
+		CombinedChannels: p.Spec.CombinedChannels,
+		HostNamespace:    p.Spec.HostNamespace,
+		TxQueueLen:       p.Spec.TxQueueLen,
 	}, nil
 }
 
+// ParseMacPool parses a MAC pool in the "<first MAC>-<last MAC>" format and returns
+// the first MAC of the pool together with the number of addresses in the pool
+func ParseMacPool(pool string) (net.HardwareAddr, uint64, error) {
+	bounds := strings.Split(pool, "-")
+	if len(bounds) != 2 {
+		return nil, 0, fmt.Errorf("invalid MAC pool %q, expected <first MAC>-<last MAC>", pool)
+	}
+	first, err := net.ParseMAC(strings.TrimSpace(bounds[0]))
+	if err != nil || len(first) != 6 {
+		return nil, 0, fmt.Errorf("invalid first MAC address %q in MAC pool %q", bounds[0], pool)
+	}
+	last, err := net.ParseMAC(strings.TrimSpace(bounds[1]))
+	if err != nil || len(last) != 6 {
+		return nil, 0, fmt.Errorf("invalid last MAC address %q in MAC pool %q", bounds[1], pool)
+	}
+	if first[0]&0x01 != 0 {
+		return nil, 0, fmt.Errorf("invalid MAC pool %q, the addresses must be unicast", pool)
+	}
+	start, end := MacToUint64(first), MacToUint64(last)
+	if end < start {
+		return nil, 0, fmt.Errorf("invalid MAC pool %q, the last MAC address is lower than the first one", pool)
+	}
+	// keep the whole pool within unicast addresses
+	if start>>40 != end>>40 {
+		return nil, 0, fmt.Errorf("invalid MAC pool %q, the addresses must share the same first octet", pool)
+	}
+	return first, end - start + 1, nil
+}
+
+// macBlock is a block of consecutive MAC addresses of a MAC pool
+type macBlock struct {
+	first, size uint64
+}
+
+func (b macBlock) overlaps(o macBlock) bool {
+	return b.first < o.first+o.size && o.first < b.first+b.size
+}
+
+func (b macBlock) String() string {
+	return Uint64ToMac(b.first).String() + "-" + Uint64ToMac(b.first+b.size-1).String()
+}
+
+// MacPoolSplit splits the MAC pools of the policies into disjoint blocks, one block for the VF group of
+// each PF of each node, so the nodes sharing a pool never assign the same address to their VFs. The block
+// of a group is recorded as the MAC pool of the group in the node state and is kept as long as it remains
+// part of the pool of the policy and matches the number of VFs of the group.
+type MacPoolSplit struct {
+	// the blocks recorded in the node states, by "<policy>/<node>/<pf pci address>"
+	held map[string]macBlock
+	// the blocks assigned during the current sync, by "<policy>/<node>/<pf pci address>"
+	assigned map[string]macBlock
+}
+
+// NewMacPoolSplit returns a MacPoolSplit keeping the blocks recorded in the provided node states
+func NewMacPoolSplit(states []SriovNetworkNodeState) *MacPoolSplit {
+	m := &MacPoolSplit{held: map[string]macBlock{}, assigned: map[string]macBlock{}}
+	for _, state := range states {
+		for _, iface := range state.Spec.Interfaces {
+			for _, group := range iface.VfGroups {
+				if group.MacPool == "" {
+					continue
+				}
+				first, size, err := ParseMacPool(group.MacPool)
+				if err != nil {
+					continue
+				}
+				// the groups which were not split, e.g. recorded by a previous version of the operator,
+				// don't hold a block
+				if numVfs, err := vfRangeSize(group.VfRange); err != nil || uint64(numVfs) != size {
+					continue
+				}
+				m.held[macPoolSplitKey(group.PolicyName, state.Name, iface.PciAddress)] = macBlock{MacToUint64(first), size}
+			}
+		}
+	}
+	return m
+}
+
+func macPoolSplitKey(policy, node, pciAddress string) string {
+	return policy + "/" + node + "/" + pciAddress
+}
+
+func vfRangeSize(vfRange string) (int, error) {
+	rngSt, rngEnd, err := parseRange(vfRange)
+	if err != nil {
+		return 0, err
+	}
+	return rngEnd - rngSt + 1, nil
+}
+
+// Assign replaces the MAC pool of the policies set on the VF groups of the node state by the block of
+// the pool assigned to the group, an error is returned when a pool is exhausted
+func (m *MacPoolSplit) Assign(state *SriovNetworkNodeState) error {
+	for i := range state.Spec.Interfaces {
+		iface := &state.Spec.Interfaces[i]
+		for j := range iface.VfGroups {
+			group := &iface.VfGroups[j]
+			if group.MacPool == "" {
+				continue
+			}
+			block, err := m.assign(macPoolSplitKey(group.PolicyName, state.Name, iface.PciAddress), group)
+			if err != nil {
+				return fmt.Errorf("failed to assign MAC addresses of pool %s of policy %s to device %s of node %s: %v",
+					group.MacPool, group.PolicyName, iface.PciAddress, state.Name, err)
+			}
+			group.MacPool = block.String()
+		}
+	}
+	return nil
+}
+
+func (m *MacPoolSplit) assign(key string, group *VfGroup) (macBlock, error) {
+	first, poolSize, err := ParseMacPool(group.MacPool)
+	if err != nil {
+		return macBlock{}, err
+	}
+	pool := macBlock{MacToUint64(first), poolSize}
+	numVfs, err := vfRangeSize(group.VfRange)
+	if err != nil {
+		return macBlock{}, err
+	}
+	size := uint64(numVfs)
+	if block, ok := m.assigned[key]; ok && block.size == size && pool.first <= block.first &&
+		block.first+block.size <= pool.first+pool.size {
+		return block, nil
+	}
+	// the first overlapping block in use by another group when the block starting at the provided address is used
+	inUse := func(block macBlock) (macBlock, bool) {
+		for _, blocks := range []map[string]macBlock{m.assigned, m.held} {
+			for k, b := range blocks {
+				if k != key && b.overlaps(block) {
+					return b, true
+				}
+			}
+		}
+		return macBlock{}, false
+	}
+	if block, ok := m.held[key]; ok && block.size == size && pool.first <= block.first &&
+		block.first+block.size <= pool.first+pool.size {
+		if _, used := inUse(block); !used {
+			m.assigned[key] = block
+			return block, nil
+		}
+	}
+	for block := (macBlock{pool.first, size}); block.first+block.size <= pool.first+pool.size; {
+		b, used := inUse(block)
+		if !used {
+			m.assigned[key] = block
+			delete(m.held, key)
+			return block, nil
+		}
+		block.first = b.first + b.size
+	}
+	return macBlock{}, fmt.Errorf("the pool is exhausted, no block of %d free addresses left", size)
+}
+
+// ParseMacBase parses the base MAC address of the VFs and checks the addresses assigned
+// to the provided number of VFs, base + VF index, are locally administered unicast addresses
+func ParseMacBase(base string, numVfs int) (net.HardwareAddr, error) {
+	mac, err := net.ParseMAC(strings.TrimSpace(base))
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid base MAC address %q", base)
+	}
+	if mac[0]&0x01 != 0 {
+		return nil, fmt.Errorf("invalid base MAC address %q, the addresses must be unicast", base)
+	}
+	if mac[0]&0x02 == 0 {
+		return nil, fmt.Errorf("invalid base MAC address %q, the addresses must be locally administered", base)
+	}
+	// the first octet holds the unicast and locally administered bits, it must be the same for all the VFs
+	start := MacToUint64(mac)
+	if numVfs > 0 && (start+uint64(numVfs)-1)>>40 != start>>40 {
+		return nil, fmt.Errorf("base MAC address %q leaves room for %d addresses, fewer than the %d requested VFs",
+			base, (start>>40+1)<<40-start, numVfs)
+	}
+	return mac, nil
+}
+
+// ParseVfMac parses the administrative MAC address of a VF, the address must be a non zero unicast address
+func ParseVfMac(mac string) (net.HardwareAddr, error) {
+	hwAddr, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil || len(hwAddr) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q", mac)
+	}
+	if hwAddr[0]&0x01 != 0 || MacToUint64(hwAddr) == 0 {
+		return nil, fmt.Errorf("invalid MAC address %q, the address must be a non zero unicast address", mac)
+	}
+	return hwAddr, nil
+}
+
+// ParseIbPkey parses an InfiniBand partition key in the "0x<hex>" format, the keys with a zero
+// base value are invalid
+func ParseIbPkey(pkey string) (uint16, error) {
+	hex, found := strings.CutPrefix(strings.ToLower(strings.TrimSpace(pkey)), "0x")
+	value, err := strconv.ParseUint(hex, 16, 16)
+	if !found || err != nil {
+		return 0, fmt.Errorf("invalid InfiniBand partition key %q, expected 0x<hex>", pkey)
+	}
+	if value&^IbPkeyFullMembership == 0 {
+		return 0, fmt.Errorf("invalid InfiniBand partition key %q, the base value must not be zero", pkey)
+	}
+	return uint16(value), nil
+}
+
+// IbPkeyMatches checks if the partition key of the VF matches the requested one. The membership
+// of the VFs in the default partition is set by the subnet manager, so the default partition
+// matches whatever the membership bit.
+func IbPkeyMatches(requested, current uint16) bool {
+	if requested&^IbPkeyFullMembership == IbDefaultPkey {
+		return current&^IbPkeyFullMembership == IbDefaultPkey
+	}
+	return requested == current
+}
+
+// MaxVlanID is the highest VLAN ID that can be programmed on a VF
+const MaxVlanID = 4094
+
+// VlanRange is an inclusive range of VLAN IDs
+type VlanRange struct {
+	Start int
+	End   int
+}
+
+// ParseVlanTrunk parses a VLAN trunk in the "<vlan>[-<vlan>][,...]" format, e.g. "100-200,300", into
+// sorted and merged ranges of VLAN IDs
+func ParseVlanTrunk(trunk string) ([]VlanRange, error) {
+	var set [MaxVlanID + 1]bool
+	for _, part := range strings.Split(trunk, ",") {
+		start, end, found := strings.Cut(strings.TrimSpace(part), "-")
+		if !found {
+			end = start
+		}
+		first, err := strconv.Atoi(start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VLAN trunk %q: invalid VLAN %q", trunk, start)
+		}
+		last, err := strconv.Atoi(end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VLAN trunk %q: invalid VLAN %q", trunk, end)
+		}
+		if first < 1 || last > MaxVlanID || first > last {
+			return nil, fmt.Errorf("invalid VLAN trunk %q: invalid range %d-%d, the VLANs must be between 1 and %d",
+				trunk, first, last, MaxVlanID)
+		}
+		for vlan := first; vlan <= last; vlan++ {
+			set[vlan] = true
+		}
+	}
+	return vlanRangesFromSet(&set), nil
+}
+
+// FormatVlanTrunk returns the VLAN trunk in the format parsed by ParseVlanTrunk
+func FormatVlanTrunk(ranges []VlanRange) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start == r.End {
+			parts = append(parts, strconv.Itoa(r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// VlanTrunkDiff returns the ranges of VLAN IDs to add to and to remove from the current trunk to get the desired one
+func VlanTrunkDiff(current, desired []VlanRange) (add, remove []VlanRange) {
+	var toAdd, toRemove [MaxVlanID + 1]bool
+	for _, r := range desired {
+		for vlan := r.Start; vlan <= r.End; vlan++ {
+			toAdd[vlan] = true
+		}
+	}
+	for _, r := range current {
+		for vlan := r.Start; vlan <= r.End; vlan++ {
+			if toAdd[vlan] {
+				toAdd[vlan] = false
+			} else {
+				toRemove[vlan] = true
+			}
+		}
+	}
+	return vlanRangesFromSet(&toAdd), vlanRangesFromSet(&toRemove)
+}
+
+// vlanRangesFromSet returns the sorted ranges of the VLAN IDs of the set
+func vlanRangesFromSet(set *[MaxVlanID + 1]bool) []VlanRange {
+	ranges := []VlanRange{}
+	for vlan := 1; vlan <= MaxVlanID; vlan++ {
+		if !set[vlan] {
+			continue
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].End == vlan-1 {
+			ranges[n-1].End = vlan
+		} else {
+			ranges = append(ranges, VlanRange{Start: vlan, End: vlan})
+		}
+	}
+	return ranges
+}
+
+// vfVlanTrunkMatches checks if the VLAN trunk of the VF is the one requested by the VF group
+func vfVlanTrunkMatches(desired, current string) bool {
+	desiredRanges, err := ParseVlanTrunk(desired)
+	if err != nil {
+		return false
+	}
+	if current == "" {
+		return false
+	}
+	currentRanges, err := ParseVlanTrunk(current)
+	if err != nil {
+		return false
+	}
+	return FormatVlanTrunk(desiredRanges) == FormatVlanTrunk(currentRanges)
+}
+
+// MacToUint64 returns the numeric value of a 6 bytes MAC address
+func MacToUint64(mac net.HardwareAddr) uint64 {
+	var value uint64
+	for _, b := range mac {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// Uint64ToMac returns the 6 bytes MAC address with the provided numeric value
+func Uint64ToMac(value uint64) net.HardwareAddr {
+	mac := make(net.HardwareAddr, 6)
+	for i := 5; i >= 0; i-- {
+		mac[i] = byte(value)
+		value >>= 8
+	}
+	return mac
+}
+
 func IndexInRange(i int, r string) bool {
 	rngSt, rngEnd, err := parseRange(r)
 	if err != nil {
@@ -583,7 +1337,7 @@ func (selector *SriovNetworkNicSelector) Selected(iface *InterfaceExt) bool {
 	if selector.DeviceID != "" && selector.DeviceID != iface.DeviceID {
 		return false
 	}
-	if len(selector.RootDevices) > 0 && !StringInArray(iface.PciAddress, selector.RootDevices) {
+	if len(selector.RootDevices) > 0 && !PciAddressInArray(iface.PciAddress, selector.RootDevices) {
 		return false
 	}
 	if len(selector.PfNames) > 0 {
@@ -607,6 +1361,17 @@ func (selector *SriovNetworkNicSelector) Selected(iface *InterfaceExt) bool {
 	return true
 }
 
+// CountSelected returns the number of PFs of the node state selected by the NIC selector
+func (selector *SriovNetworkNicSelector) CountSelected(state *SriovNetworkNodeState) int {
+	count := 0
+	for i := range state.Status.Interfaces {
+		if selector.Selected(&state.Status.Interfaces[i]) {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *SriovNetworkNodeState) GetInterfaceStateByPciAddress(addr string) *InterfaceExt {
 	for _, iface := range s.Status.Interfaces {
 		if addr == iface.PciAddress {
@@ -811,6 +1576,102 @@ func (cr *SriovNetwork) NetworkNamespace() string {
 	return cr.Spec.NetworkNamespace
 }
 
+// RequestedMtu returns the MTU the network sets on the interface through a chained
+// tuning metaplugin, or 0 if the network doesn't request one.
+func (cr *SriovNetwork) RequestedMtu() (int, error) {
+	if cr.Spec.MetaPluginsConfig == "" {
+		return 0, nil
+	}
+	// metaplugins are rendered as the tail of the CNI "plugins" list
+	plugins := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte("["+cr.Spec.MetaPluginsConfig+"]"), &plugins); err != nil {
+		return 0, fmt.Errorf("failed to parse metaPlugins of SriovNetwork %s/%s: %v", cr.Namespace, cr.Name, err)
+	}
+	for _, plugin := range plugins {
+		if plugin["type"] != "tuning" {
+			continue
+		}
+		mtu, ok := plugin["mtu"].(float64)
+		if !ok {
+			continue
+		}
+		return int(mtu), nil
+	}
+	return 0, nil
+}
+
+// ResolveVfGroupMtu derives the MTU of the VF group from the SriovNetworks referencing
+// the group resource. If the group doesn't request an MTU the one requested by the
+// networks is used, otherwise the group MTU is kept. The MTU is not derived when the
+// networks request different MTUs. The returned warnings describe mismatches between
+// the group and the networks.
+func ResolveVfGroupMtu(group *VfGroup, networks []SriovNetwork) (int, []string) {
+	mtu := group.Mtu
+	warnings := []string{}
+	// the network the derived MTU comes from
+	var source *SriovNetwork
+	conflict := false
+	for i := range networks {
+		network := &networks[i]
+		if network.Spec.ResourceName != group.ResourceName {
+			continue
+		}
+		networkMtu, err := network.RequestedMtu()
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if networkMtu == 0 {
+			continue
+		}
+		if mtu == 0 {
+			mtu = networkMtu
+			source = network
+			continue
+		}
+		if networkMtu == mtu {
+			continue
+		}
+		if source == nil {
+			warnings = append(warnings, fmt.Sprintf("SriovNetwork %s/%s requests MTU %d for resource %s but the VF group MTU is %d",
+				network.Namespace, network.Name, networkMtu, group.ResourceName, mtu))
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("SriovNetworks %s/%s and %s/%s request different MTUs %d and %d for resource %s, "+
+			"the MTU of the VFs is not derived from the networks", source.Namespace, source.Name, network.Namespace, network.Name,
+			mtu, networkMtu, group.ResourceName))
+		conflict = true
+	}
+	if conflict {
+		return group.Mtu, warnings
+	}
+	return mtu, warnings
+}
+
+// ApplyNetworksMtu sets the MTU of the VF groups of the node state to the MTU derived from the SriovNetworks
+// referencing their resource and raises the MTU of the PF requested by the policies when the MTU of its netdevice
+// VFs is above it, most drivers refuse a VF MTU above the PF MTU. Returns the warnings of ResolveVfGroupMtu.
+func ApplyNetworksMtu(state *SriovNetworkNodeState, networks []SriovNetwork) []string {
+	warnings := []string{}
+	for i := range state.Spec.Interfaces {
+		iface := &state.Spec.Interfaces[i]
+		for j := range iface.VfGroups {
+			group := &iface.VfGroups[j]
+			mtu, groupWarnings := ResolveVfGroupMtu(group, networks)
+			warnings = append(warnings, groupWarnings...)
+			group.Mtu = mtu
+			// the MTU of the VFs bound to a userspace driver is not applied
+			if group.DeviceType != "" && group.DeviceType != consts.DeviceTypeNetDevice {
+				continue
+			}
+			if mtu > iface.Mtu {
+				iface.Mtu = mtu
+			}
+		}
+	}
+	return warnings
+}
+
 // RenderNetAttDef renders a net-att-def for sriov CNI
 func (cr *OVSNetwork) RenderNetAttDef() (*uns.Unstructured, error) {
 	logger := log.WithName("RenderNetAttDef")
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 
 	v1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
@@ -372,6 +374,220 @@ func TestSriovNetworkNodePolicyApply(t *testing.T) {
 				},
 			},
 		},
+		{
+			tname:        "spoof check and trust",
+			currentState: newNodeState(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Spec.SpoofChk = v1.SriovCniStateOff
+				p.Spec.Trust = v1.SriovCniStateOn
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:       "ens803f1",
+					NumVfs:     2,
+					PciAddress: "0000:86:00.1",
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-1",
+							PolicyName:   "p1",
+							SpoofChk:     v1.SriovCniStateOff,
+							Trust:        v1.SriovCniStateOn,
+						},
+					},
+				},
+			},
+		},
+		{
+			tname:        "vf and pf settings",
+			currentState: newNodeState(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Spec.VlanID = pointer.Int(100)
+				p.Spec.VlanQoS = 2
+				p.Spec.VlanProto = "802.1ad"
+				p.Spec.VlanTrunk = "200-300"
+				p.Spec.MinTxRate = pointer.Int(10)
+				p.Spec.MaxTxRate = pointer.Int(100)
+				p.Spec.LinkState = "enable"
+				p.Spec.CombinedChannels = 4
+				p.Spec.HostNamespace = "vfs"
+				p.Spec.TxQueueLen = 2000
+				p.Spec.PfCombinedChannels = 16
+				p.Spec.RingRx = 4096
+				p.Spec.RingTx = 2048
+				p.Spec.RingClamp = true
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:             "ens803f1",
+					NumVfs:           2,
+					PciAddress:       "0000:86:00.1",
+					CombinedChannels: 16,
+					RingRx:           4096,
+					RingTx:           2048,
+					RingClamp:        true,
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:       consts.DeviceTypeNetDevice,
+							ResourceName:     "p1res",
+							VfRange:          "0-1",
+							PolicyName:       "p1",
+							VlanID:           pointer.Int(100),
+							VlanQoS:          2,
+							VlanProto:        "802.1ad",
+							VlanTrunk:        "200-300",
+							MinTxRate:        pointer.Int(10),
+							MaxTxRate:        pointer.Int(100),
+							LinkState:        "enable",
+							CombinedChannels: 4,
+							HostNamespace:    "vfs",
+							TxQueueLen:       2000,
+						},
+					},
+				},
+			},
+		},
+		{
+			tname: "pf settings merged with the previous policy",
+			currentState: func() *v1.SriovNetworkNodeState {
+				st := newNodeState()
+				st.Spec.Interfaces = []v1.Interface{{
+					Name:             "ens803f1",
+					NumVfs:           2,
+					PciAddress:       "0000:86:00.1",
+					CombinedChannels: 8,
+					RingRx:           1024,
+					RingTx:           1024,
+					VfGroups: []v1.VfGroup{{
+						DeviceType:   consts.DeviceTypeNetDevice,
+						ResourceName: "p1res",
+						VfRange:      "0-0",
+						PolicyName:   "p1",
+					}},
+				}}
+				return st
+			}(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Name = "p2"
+				p.Spec.ResourceName = "p2res"
+				p.Spec.NicSelector.PfNames = []string{"ens803f1#1-1"}
+				p.Spec.RingRx = 4096
+				p.Spec.RingClamp = true
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:             "ens803f1",
+					NumVfs:           2,
+					PciAddress:       "0000:86:00.1",
+					CombinedChannels: 8,
+					RingRx:           4096,
+					RingTx:           1024,
+					RingClamp:        true,
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p2res",
+							VfRange:      "1-1",
+							PolicyName:   "p2",
+						},
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-0",
+							PolicyName:   "p1",
+						},
+					},
+				},
+			},
+		},
+		{
+			tname:        "vf GUIDs in the VF range",
+			currentState: newNodeState(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Spec.VfGUIDs = map[string]string{"1": "00:11:22:33:44:55:66:77", "5": "00:11:22:33:44:55:66:88"}
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:       "ens803f1",
+					NumVfs:     2,
+					PciAddress: "0000:86:00.1",
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-1",
+							PolicyName:   "p1",
+							VfGUIDs:      map[string]string{"1": "00:11:22:33:44:55:66:77"},
+						},
+					},
+				},
+			},
+		},
+		{
+			tname:        "mac pool",
+			currentState: newNodeState(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Spec.MacPool = "02:00:00:00:00:00-02:00:00:00:00:ff"
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:       "ens803f1",
+					NumVfs:     2,
+					PciAddress: "0000:86:00.1",
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-1",
+							PolicyName:   "p1",
+							MacPool:      "02:00:00:00:00:00-02:00:00:00:00:ff",
+						},
+					},
+				},
+			},
+		},
+		{
+			tname:        "mac base",
+			currentState: newNodeState(),
+			policy: func() *v1.SriovNetworkNodePolicy {
+				p := newNodePolicy()
+				p.Spec.MacBase = "02:00:00:00:10:00"
+				return p
+			}(),
+			equalP: false,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:       "ens803f1",
+					NumVfs:     2,
+					PciAddress: "0000:86:00.1",
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-1",
+							PolicyName:   "p1",
+							MacBase:      "02:00:00:00:10:00",
+						},
+					},
+				},
+			},
+		},
 		{
 			tname: "one policy present different pf",
 			currentState: func() *v1.SriovNetworkNodeState {
@@ -554,6 +770,53 @@ func TestSriovNetworkNodePolicyApply(t *testing.T) {
 				},
 			},
 		},
+		{
+			tname: "one policy present same pf same priority partitioning keep reset policy",
+			currentState: func() *v1.SriovNetworkNodeState {
+				st := newNodeState()
+				st.Spec.Interfaces = []v1.Interface{
+					{
+						Name:        "ens803f1",
+						NumVfs:      5,
+						PciAddress:  "0000:86:00.1",
+						ResetPolicy: v1.ResetPolicyKeep,
+						VfGroups: []v1.VfGroup{
+							{
+								DeviceType:   consts.DeviceTypeVfioPci,
+								ResourceName: "vfiores",
+								VfRange:      "2-4",
+								PolicyName:   "p2",
+							},
+						},
+					},
+				}
+				return st
+			}(),
+			policy: newNodePolicy(),
+			equalP: true,
+			expectedInterfaces: []v1.Interface{
+				{
+					Name:        "ens803f1",
+					NumVfs:      5,
+					PciAddress:  "0000:86:00.1",
+					ResetPolicy: v1.ResetPolicyKeep,
+					VfGroups: []v1.VfGroup{
+						{
+							DeviceType:   consts.DeviceTypeNetDevice,
+							ResourceName: "p1res",
+							VfRange:      "0-1",
+							PolicyName:   "p1",
+						},
+						{
+							DeviceType:   consts.DeviceTypeVfioPci,
+							ResourceName: "vfiores",
+							VfRange:      "2-4",
+							PolicyName:   "p2",
+						},
+					},
+				},
+			},
+		},
 		{
 			// vdpa policy with same priority (both virtio and vhost), VfRange's do not overlap so all is merged
 			tname: "one vdpa policy present same pf same priority partitioning",
@@ -803,6 +1066,98 @@ func TestSriovNetworkNodePolicyApply(t *testing.T) {
 	}
 }
 
+func TestSriovNetworkNodePolicyApplyRdmaMode(t *testing.T) {
+	state := newNodeState()
+	shared := newNodePolicy()
+	shared.Spec.RdmaMode = "shared"
+	exclusive := newNodePolicy()
+	exclusive.Name = "p2"
+	exclusive.Spec.RdmaMode = "exclusive"
+	unset := newNodePolicy()
+	unset.Name = "p3"
+
+	for _, p := range []*v1.SriovNetworkNodePolicy{shared, exclusive, unset} {
+		if err := p.Apply(state, false); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	if state.Spec.RdmaMode != "exclusive" {
+		t.Errorf("Apply RDMA mode got %q, expected %q", state.Spec.RdmaMode, "exclusive")
+	}
+}
+
+func TestSriovNetworkNodePolicyApplyRequiredKernelModules(t *testing.T) {
+	state := newNodeState()
+	p1 := newNodePolicy()
+	p1.Spec.RequiredKernelModules = []string{"mlx5_core", "vfio_pci"}
+	p2 := newNodePolicy()
+	p2.Name = "p2"
+	p2.Spec.RequiredKernelModules = []string{"vfio_pci", "vfio_iommu_type1"}
+
+	for _, p := range []*v1.SriovNetworkNodePolicy{p1, p2} {
+		if err := p.Apply(state, false); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	expected := []string{"mlx5_core", "vfio_pci", "vfio_iommu_type1"}
+	if diff := cmp.Diff(expected, state.Spec.RequiredKernelModules); diff != "" {
+		t.Errorf("Apply required kernel modules diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSriovNetworkNodePolicyApplyHugepages(t *testing.T) {
+	state := newNodeState()
+	p1 := newNodePolicy()
+	p1.Spec.HugepageSize = "1G"
+	p1.Spec.HugepageCount = 8
+	p2 := newNodePolicy()
+	p2.Name = "p2"
+	p2.Spec.HugepageSize = "2M"
+	p2.Spec.HugepageCount = 1024
+	p3 := newNodePolicy()
+	p3.Name = "p3"
+	p3.Spec.HugepageSize = "1G"
+	p3.Spec.HugepageCount = 16
+	p4 := newNodePolicy()
+	p4.Name = "p4"
+
+	for _, p := range []*v1.SriovNetworkNodePolicy{p1, p2, p3, p4} {
+		if err := p.Apply(state, false); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	expected := []v1.Hugepages{{Size: "1G", Count: 16}, {Size: "2M", Count: 1024}}
+	if diff := cmp.Diff(expected, state.Spec.Hugepages); diff != "" {
+		t.Errorf("Apply hugepages diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSriovNetworkNodePolicyApplyAdoptExisting(t *testing.T) {
+	state := newNodeState()
+	adopt := newNodePolicy()
+	adopt.Spec.AdoptExisting = true
+	unset := newNodePolicy()
+	unset.Name = "p2"
+	other := newNodePolicy()
+	other.Name = "p3"
+	other.Spec.NicSelector.PfNames = []string{"ens803f0"}
+	other.Spec.NicSelector.RootDevices = []string{"0000:86:00.0"}
+
+	for _, p := range []*v1.SriovNetworkNodePolicy{adopt, unset, other} {
+		if err := p.Apply(state, false); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	adopted := map[string]bool{}
+	for _, iface := range state.Spec.Interfaces {
+		adopted[iface.PciAddress] = iface.AdoptExisting
+	}
+	expected := map[string]bool{"0000:86:00.0": false, "0000:86:00.1": true}
+	if diff := cmp.Diff(expected, adopted); diff != "" {
+		t.Errorf("Apply adopt existing diff (-want +got):\n%s", diff)
+	}
+}
+
 func TestVirtioVdpaNodePolicyApply(t *testing.T) {
 	testtable := []struct {
 		tname              string
@@ -1054,3 +1409,1060 @@ func TestSriovNetworkPoolConfig_MaxUnavailable(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveVfGroupMtu(t *testing.T) {
+	newNetwork := func(name, resourceName, metaPlugins string) v1.SriovNetwork {
+		return v1.SriovNetwork{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.SriovNetworkSpec{
+				ResourceName:      resourceName,
+				MetaPluginsConfig: metaPlugins,
+			},
+		}
+	}
+	testtable := []struct {
+		tname            string
+		group            *v1.VfGroup
+		networks         []v1.SriovNetwork
+		expectedMtu      int
+		expectedWarnings int
+	}{
+		{
+			tname: "no referencing network",
+			group: &v1.VfGroup{ResourceName: "resource1", Mtu: 1500},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource2", `{"type": "tuning", "mtu": 9000}`),
+			},
+			expectedMtu: 1500,
+		},
+		{
+			tname: "derive mtu from network",
+			group: &v1.VfGroup{ResourceName: "resource1"},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning", "mtu": 9000}`),
+			},
+			expectedMtu: 9000,
+		},
+		{
+			tname: "network without tuning mtu",
+			group: &v1.VfGroup{ResourceName: "resource1"},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning", "sysctl": {"net.ipv4.conf.IFNAME.arp_notify": "1"}}`),
+				newNetwork("net2", "resource1", ""),
+			},
+			expectedMtu: 0,
+		},
+		{
+			tname: "matching mtu",
+			group: &v1.VfGroup{ResourceName: "resource1", Mtu: 9000},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "vrf", "vrfname": "blue"}, {"type": "tuning", "mtu": 9000}`),
+			},
+			expectedMtu: 9000,
+		},
+		{
+			tname: "mismatching mtu",
+			group: &v1.VfGroup{ResourceName: "resource1", Mtu: 1500},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning", "mtu": 9000}`),
+			},
+			expectedMtu:      1500,
+			expectedWarnings: 1,
+		},
+		{
+			tname: "networks requesting different mtu",
+			group: &v1.VfGroup{ResourceName: "resource1"},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning", "mtu": 9000}`),
+				newNetwork("net2", "resource1", `{"type": "tuning", "mtu": 1500}`),
+			},
+			expectedMtu:      0,
+			expectedWarnings: 1,
+		},
+		{
+			tname: "networks requesting different mtu than the group",
+			group: &v1.VfGroup{ResourceName: "resource1", Mtu: 1500},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning", "mtu": 9000}`),
+				newNetwork("net2", "resource1", `{"type": "tuning", "mtu": 4000}`),
+			},
+			expectedMtu:      1500,
+			expectedWarnings: 2,
+		},
+		{
+			tname: "invalid metaplugins",
+			group: &v1.VfGroup{ResourceName: "resource1", Mtu: 1500},
+			networks: []v1.SriovNetwork{
+				newNetwork("net1", "resource1", `{"type": "tuning"`),
+			},
+			expectedMtu:      1500,
+			expectedWarnings: 1,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			mtu, warnings := v1.ResolveVfGroupMtu(tc.group, tc.networks)
+			if diff := cmp.Diff(tc.expectedMtu, mtu); diff != "" {
+				t.Errorf("unexpected mtu (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedWarnings, len(warnings)); diff != "" {
+				t.Errorf("unexpected number of warnings %v (-want +got):\n%s", warnings, diff)
+			}
+		})
+	}
+}
+
+func TestApplyNetworksMtu(t *testing.T) {
+	networks := []v1.SriovNetwork{{
+		ObjectMeta: metav1.ObjectMeta{Name: "net1", Namespace: "default"},
+		Spec:       v1.SriovNetworkSpec{ResourceName: "resource1", MetaPluginsConfig: `{"type": "tuning", "mtu": 9000}`},
+	}}
+	testtable := []struct {
+		tname       string
+		pfMtu       int
+		statusMtu   int
+		deviceType  string
+		expectedMtu int
+	}{
+		{
+			tname:       "PF MTU not requested",
+			statusMtu:   1500,
+			expectedMtu: 9000,
+		},
+		{
+			tname:       "PF MTU not requested and current PF MTU above the VF MTU",
+			statusMtu:   9216,
+			expectedMtu: 9000,
+		},
+		{
+			tname:       "PF MTU requested below the VF MTU",
+			pfMtu:       1500,
+			statusMtu:   9216,
+			expectedMtu: 9000,
+		},
+		{
+			tname:       "PF MTU requested above the VF MTU",
+			pfMtu:       9216,
+			statusMtu:   1500,
+			expectedMtu: 9216,
+		},
+		{
+			tname:       "PF MTU requested below the MTU of DPDK VFs",
+			pfMtu:       1500,
+			statusMtu:   1500,
+			deviceType:  "vfio-pci",
+			expectedMtu: 1500,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			state := &v1.SriovNetworkNodeState{
+				Spec: v1.SriovNetworkNodeStateSpec{Interfaces: v1.Interfaces{{
+					PciAddress: "0000:86:00.0",
+					Mtu:        tc.pfMtu,
+					VfGroups:   []v1.VfGroup{{ResourceName: "resource1", VfRange: "0-1", DeviceType: tc.deviceType}},
+				}}},
+				Status: v1.SriovNetworkNodeStateStatus{Interfaces: v1.InterfaceExts{{
+					PciAddress: "0000:86:00.0",
+					Mtu:        tc.statusMtu,
+				}}},
+			}
+			warnings := v1.ApplyNetworksMtu(state, networks)
+			if len(warnings) != 0 {
+				t.Errorf("unexpected warnings %v", warnings)
+			}
+			if diff := cmp.Diff(9000, state.Spec.Interfaces[0].VfGroups[0].Mtu); diff != "" {
+				t.Errorf("unexpected VF group mtu (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedMtu, state.Spec.Interfaces[0].Mtu); diff != "" {
+				t.Errorf("unexpected PF mtu (-want +got):\n%s", diff)
+			}
+			// the spec doesn't depend on the MTU of the PF reported once the spec is applied
+			state.Status.Interfaces[0].Mtu = state.Spec.Interfaces[0].Mtu
+			v1.ApplyNetworksMtu(state, networks)
+			if diff := cmp.Diff(tc.expectedMtu, state.Spec.Interfaces[0].Mtu); diff != "" {
+				t.Errorf("unexpected PF mtu after the spec is applied (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovVlan(t *testing.T) {
+	newIfaces := func(group v1.VfGroup, vf v1.VirtualFunction) (*v1.Interface, *v1.InterfaceExt) {
+		group.VfRange = "0-0"
+		vf.VfID = 0
+		vf.Driver = "mlx5_core"
+		return &v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, VfGroups: []v1.VfGroup{group}},
+			&v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1, VFs: []v1.VirtualFunction{vf}}
+	}
+	testtable := []struct {
+		tname          string
+		group          v1.VfGroup
+		vf             v1.VirtualFunction
+		expectedResult bool
+	}{
+		{
+			tname:          "vlan not managed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{Vlan: 100},
+			expectedResult: false,
+		},
+		{
+			tname:          "vlan matches",
+			group:          v1.VfGroup{VlanID: pointer.Int(100), VlanQoS: 1},
+			vf:             v1.VirtualFunction{Vlan: 100, VlanQoS: 1, VlanProto: "802.1q"},
+			expectedResult: false,
+		},
+		{
+			tname:          "vlan differs",
+			group:          v1.VfGroup{VlanID: pointer.Int(100)},
+			vf:             v1.VirtualFunction{Vlan: 200},
+			expectedResult: true,
+		},
+		{
+			tname:          "vlan qos differs",
+			group:          v1.VfGroup{VlanID: pointer.Int(100), VlanQoS: 2},
+			vf:             v1.VirtualFunction{Vlan: 100, VlanQoS: 1},
+			expectedResult: true,
+		},
+		{
+			tname:          "vlan proto differs",
+			group:          v1.VfGroup{VlanID: pointer.Int(100), VlanProto: "802.1AD"},
+			vf:             v1.VirtualFunction{Vlan: 100, VlanProto: "802.1q"},
+			expectedResult: true,
+		},
+		{
+			tname:          "vlan cleared",
+			group:          v1.VfGroup{VlanID: pointer.Int(0), VlanQoS: 2},
+			vf:             v1.VirtualFunction{VlanProto: "802.1q"},
+			expectedResult: false,
+		},
+		{
+			tname:          "vlan needs to be cleared",
+			group:          v1.VfGroup{VlanID: pointer.Int(0)},
+			vf:             v1.VirtualFunction{Vlan: 100},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			spec, status := newIfaces(tc.group, tc.vf)
+			result := v1.NeedToUpdateSriov(spec, status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovVlanTrunk(t *testing.T) {
+	testtable := []struct {
+		tname          string
+		group          v1.VfGroup
+		vf             v1.VirtualFunction
+		expectedResult bool
+	}{
+		{
+			tname:          "not set and not programmed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{},
+			expectedResult: false,
+		},
+		{
+			tname:          "not set and programmed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{VlanTrunk: "100-200"},
+			expectedResult: true,
+		},
+		{
+			tname:          "matches",
+			group:          v1.VfGroup{VlanTrunk: "300,100-199,200"},
+			vf:             v1.VirtualFunction{VlanTrunk: "100-200,300"},
+			expectedResult: false,
+		},
+		{
+			tname:          "differs",
+			group:          v1.VfGroup{VlanTrunk: "100-200"},
+			vf:             v1.VirtualFunction{VlanTrunk: "100-150"},
+			expectedResult: true,
+		},
+		{
+			tname:          "not programmed",
+			group:          v1.VfGroup{VlanTrunk: "100"},
+			vf:             v1.VirtualFunction{},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.group.VfRange = "0-0"
+			tc.vf.Driver = "iavf"
+			spec := &v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, VfGroups: []v1.VfGroup{tc.group}}
+			status := &v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1, VFs: []v1.VirtualFunction{tc.vf}}
+			result := v1.NeedToUpdateSriov(spec, status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovSpoofChkAndTrust(t *testing.T) {
+	testtable := []struct {
+		tname          string
+		group          v1.VfGroup
+		vf             v1.VirtualFunction
+		expectedResult bool
+	}{
+		{
+			tname:          "not managed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{SpoofChk: "on", Trust: "off"},
+			expectedResult: false,
+		},
+		{
+			tname:          "matches",
+			group:          v1.VfGroup{SpoofChk: "off", Trust: "on"},
+			vf:             v1.VirtualFunction{SpoofChk: "off", Trust: "on"},
+			expectedResult: false,
+		},
+		{
+			tname:          "spoof check differs",
+			group:          v1.VfGroup{SpoofChk: "off"},
+			vf:             v1.VirtualFunction{SpoofChk: "on", Trust: "on"},
+			expectedResult: true,
+		},
+		{
+			tname:          "trust differs",
+			group:          v1.VfGroup{Trust: "on"},
+			vf:             v1.VirtualFunction{SpoofChk: "on", Trust: "off"},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.group.VfRange = "0-0"
+			tc.vf.Driver = "mlx5_core"
+			spec := &v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, VfGroups: []v1.VfGroup{tc.group}}
+			status := &v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1, VFs: []v1.VirtualFunction{tc.vf}}
+			result := v1.NeedToUpdateSriov(spec, status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovTxRate(t *testing.T) {
+	testtable := []struct {
+		tname          string
+		group          v1.VfGroup
+		vf             v1.VirtualFunction
+		expectedResult bool
+	}{
+		{
+			tname:          "not managed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{MinTxRate: 10, MaxTxRate: 100},
+			expectedResult: false,
+		},
+		{
+			tname:          "matches",
+			group:          v1.VfGroup{MinTxRate: pointer.Int(10), MaxTxRate: pointer.Int(100)},
+			vf:             v1.VirtualFunction{MinTxRate: 10, MaxTxRate: 100},
+			expectedResult: false,
+		},
+		{
+			tname:          "min rate differs",
+			group:          v1.VfGroup{MinTxRate: pointer.Int(20)},
+			vf:             v1.VirtualFunction{MinTxRate: 10, MaxTxRate: 100},
+			expectedResult: true,
+		},
+		{
+			tname:          "max rate needs to be unlimited",
+			group:          v1.VfGroup{MaxTxRate: pointer.Int(0)},
+			vf:             v1.VirtualFunction{MinTxRate: 10, MaxTxRate: 100},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.group.VfRange = "0-0"
+			tc.vf.Driver = "mlx5_core"
+			spec := &v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, VfGroups: []v1.VfGroup{tc.group}}
+			status := &v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1, VFs: []v1.VirtualFunction{tc.vf}}
+			result := v1.NeedToUpdateSriov(spec, status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovLinkState(t *testing.T) {
+	testtable := []struct {
+		tname          string
+		group          v1.VfGroup
+		vf             v1.VirtualFunction
+		expectedResult bool
+	}{
+		{
+			tname:          "not managed",
+			group:          v1.VfGroup{},
+			vf:             v1.VirtualFunction{LinkState: "disable"},
+			expectedResult: false,
+		},
+		{
+			tname:          "matches",
+			group:          v1.VfGroup{LinkState: "enable"},
+			vf:             v1.VirtualFunction{LinkState: "enable"},
+			expectedResult: false,
+		},
+		{
+			tname:          "differs",
+			group:          v1.VfGroup{LinkState: "enable"},
+			vf:             v1.VirtualFunction{LinkState: "auto"},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.group.VfRange = "0-0"
+			tc.vf.Driver = "mlx5_core"
+			spec := &v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, VfGroups: []v1.VfGroup{tc.group}}
+			status := &v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1, VFs: []v1.VirtualFunction{tc.vf}}
+			result := v1.NeedToUpdateSriov(spec, status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedToUpdateSriovDcb(t *testing.T) {
+	testtable := []struct {
+		tname          string
+		spec           v1.Interface
+		status         v1.InterfaceExt
+		expectedResult bool
+	}{
+		{
+			tname:          "not managed",
+			spec:           v1.Interface{},
+			status:         v1.InterfaceExt{PfcEnabled: []int{3}, PriorityToTcMap: []int{0, 0, 0, 1, 0, 0, 0, 0}},
+			expectedResult: false,
+		},
+		{
+			tname:          "matches",
+			spec:           v1.Interface{PfcEnabled: []int{4, 3}, PriorityToTcMap: []int{0, 0, 0, 1}},
+			status:         v1.InterfaceExt{PfcEnabled: []int{3, 4}, PriorityToTcMap: []int{0, 0, 0, 1, 0, 0, 0, 0}},
+			expectedResult: false,
+		},
+		{
+			tname:          "PFC differs",
+			spec:           v1.Interface{PfcEnabled: []int{3}},
+			status:         v1.InterfaceExt{PriorityToTcMap: []int{0, 0, 0, 0, 0, 0, 0, 0}},
+			expectedResult: true,
+		},
+		{
+			tname:          "priority to traffic class map differs",
+			spec:           v1.Interface{PriorityToTcMap: []int{0, 0, 0, 1}},
+			status:         v1.InterfaceExt{PriorityToTcMap: []int{0, 0, 0, 2, 0, 0, 0, 0}},
+			expectedResult: true,
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.spec.PciAddress = "0000:86:00.0"
+			tc.status.PciAddress = "0000:86:00.0"
+			result := v1.NeedToUpdateSriov(&tc.spec, &tc.status)
+			if diff := cmp.Diff(tc.expectedResult, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffInterface(t *testing.T) {
+	testtable := []struct {
+		tname        string
+		spec         v1.Interface
+		status       v1.InterfaceExt
+		expectedDiff []string
+	}{
+		{
+			tname: "up to date",
+			spec: v1.Interface{NumVfs: 1, Mtu: 1500,
+				VfGroups: []v1.VfGroup{{VfRange: "0-0", DeviceType: "netdevice", Mtu: 1500}}},
+			status: v1.InterfaceExt{NumVfs: 1, Mtu: 9000,
+				VFs: []v1.VirtualFunction{{VfID: 0, Driver: "iavf", Mtu: 1500}}},
+			expectedDiff: nil,
+		},
+		{
+			tname: "PF and VFs differ",
+			spec: v1.Interface{NumVfs: 2, Mtu: 9000,
+				VfGroups: []v1.VfGroup{
+					{VfRange: "0-0", DeviceType: "vfio-pci"},
+					{VfRange: "1-1", DeviceType: "netdevice", Mtu: 9000}}},
+			status: v1.InterfaceExt{NumVfs: 2, Mtu: 1500,
+				VFs: []v1.VirtualFunction{{VfID: 0, Driver: "iavf"}, {VfID: 1, Driver: "iavf", Mtu: 1500}}},
+			expectedDiff: []string{
+				"MTU needs update: desired 9000, current 1500",
+				"VF 0 driver needs update: desired vfio-pci, current iavf",
+				"VF 1 MTU needs update: desired 9000, current 1500",
+			},
+		},
+		{
+			tname:  "numVfs and externally managed",
+			spec:   v1.Interface{NumVfs: 2, ExternallyManaged: true, VfGroups: []v1.VfGroup{{VfRange: "0-1"}}},
+			status: v1.InterfaceExt{NumVfs: 1, VFs: []v1.VirtualFunction{{VfID: 0, Driver: "iavf"}}},
+			expectedDiff: []string{
+				"NumVfs needs update: desired 2, current 1",
+				"VF 0 needs update: the PF is externally managed",
+			},
+		},
+		{
+			tname: "fewer VFs in best effort mode",
+			spec: v1.Interface{NumVfs: 4, ExternallyManaged: true, ExternallyManagedBestEffort: true,
+				VfGroups: []v1.VfGroup{{VfRange: "0-3"}}},
+			status: v1.InterfaceExt{NumVfs: 1, VFs: []v1.VirtualFunction{{VfID: 0, Driver: "iavf"}}},
+			expectedDiff: []string{
+				"VF 0 needs update: the PF is externally managed",
+			},
+		},
+		{
+			tname:        "more VFs in best effort mode",
+			spec:         v1.Interface{NumVfs: 1, ExternallyManaged: true, ExternallyManagedBestEffort: true},
+			status:       v1.InterfaceExt{NumVfs: 2},
+			expectedDiff: []string{"NumVfs needs update: desired 1, current 2"},
+		},
+		{
+			tname:        "PF link down",
+			spec:         v1.Interface{},
+			status:       v1.InterfaceExt{LinkAdminState: "down"},
+			expectedDiff: []string{"PF link status needs update: desired up, current down"},
+		},
+		{
+			tname:        "PF link left down when requested",
+			spec:         v1.Interface{PfLinkDownOnVfChange: true},
+			status:       v1.InterfaceExt{LinkAdminState: "down"},
+			expectedDiff: nil,
+		},
+		{
+			tname:        "combined channels",
+			spec:         v1.Interface{CombinedChannels: 16},
+			status:       v1.InterfaceExt{CombinedChannels: 8},
+			expectedDiff: []string{"combined channels needs update: desired 16, current 8"},
+		},
+		{
+			tname:  "eswitch offload modes",
+			spec:   v1.Interface{EswitchMode: "switchdev", EswitchInlineMode: "transport", EswitchEncapMode: "enable"},
+			status: v1.InterfaceExt{EswitchMode: "switchdev", EswitchInlineMode: "link", EswitchEncapMode: "disable"},
+			expectedDiff: []string{
+				"eswitch inline mode needs update: desired transport, current link",
+				"eswitch encap mode needs update: desired enable, current disable",
+			},
+		},
+		{
+			tname:        "ring sizes",
+			spec:         v1.Interface{RingRx: 4096, RingTx: 2048},
+			status:       v1.InterfaceExt{RingRx: 1024, RingTx: 2048, RingRxMax: 8192, RingTxMax: 8192},
+			expectedDiff: []string{"RX ring size needs update: desired 4096, current 1024"},
+		},
+		{
+			tname:        "clamped ring sizes",
+			spec:         v1.Interface{RingRx: 16384, RingTx: 16384, RingClamp: true},
+			status:       v1.InterfaceExt{RingRx: 8192, RingTx: 1024, RingRxMax: 8192, RingTxMax: 8192},
+			expectedDiff: []string{"TX ring size needs update: desired 8192, current 1024"},
+		},
+		{
+			tname:  "VF without driver",
+			spec:   v1.Interface{NumVfs: 1, VfGroups: []v1.VfGroup{{VfRange: "0-0", DeviceType: "vfio-pci"}}},
+			status: v1.InterfaceExt{NumVfs: 1, VFs: []v1.VirtualFunction{{VfID: 0}}},
+			expectedDiff: []string{
+				"VF 0 driver needs update: desired vfio-pci, has no driver",
+			},
+		},
+		{
+			tname: "VF QoS",
+			spec: v1.Interface{NumVfs: 2, VfGroups: []v1.VfGroup{
+				{VfRange: "0-1", Qos: &v1.VfQos{Priority: pointer.Int(5), Group: "rt"}}}},
+			status: v1.InterfaceExt{NumVfs: 2, VFs: []v1.VirtualFunction{
+				{VfID: 0, Driver: "mlx5_core", VlanQoS: 5, RepresentorName: "eth0_0", QosGroup: "rt"},
+				{VfID: 1, Driver: "mlx5_core", RepresentorName: "eth0_1"}}},
+			expectedDiff: []string{
+				"VF 1 QoS priority needs update: desired 5, current 0",
+				"VF 1 QoS group needs update: desired rt, current ",
+			},
+		},
+		{
+			tname: "VF MAC of DPDK VFs",
+			spec: v1.Interface{NumVfs: 3, VfGroups: []v1.VfGroup{
+				{VfRange: "0-0", DeviceType: "vfio-pci", Mac: "02:00:00:00:00:10"},
+				{VfRange: "1-1", DeviceType: "vfio-pci", Mac: "02:00:00:00:00:11"},
+				{VfRange: "2-2", DeviceType: "vfio-pci"}}},
+			status: v1.InterfaceExt{NumVfs: 3, VFs: []v1.VirtualFunction{
+				{VfID: 0, Driver: "vfio-pci", AdminMac: "02:00:00:00:00:10"},
+				{VfID: 1, Driver: "vfio-pci", AdminMac: "02:00:00:00:00:99"},
+				{VfID: 2, Driver: "vfio-pci", AdminMac: "02:00:00:00:00:99"}}},
+			expectedDiff: []string{
+				"VF 1 MAC needs update: desired 02:00:00:00:00:11, current 02:00:00:00:00:99",
+			},
+		},
+		{
+			tname: "VF partition key",
+			spec: v1.Interface{NumVfs: 3, LinkType: "IB", VfGroups: []v1.VfGroup{
+				{VfRange: "0-0", IbPkey: "0x8001"},
+				{VfRange: "1-2", IbPkey: "0x7fff"}}},
+			status: v1.InterfaceExt{NumVfs: 3, VFs: []v1.VirtualFunction{
+				{VfID: 0, Driver: "mlx5_core", IbPkey: "0x0001"},
+				{VfID: 1, Driver: "mlx5_core", IbPkey: "0xffff"},
+				{VfID: 2, Driver: "mlx5_core"}}},
+			expectedDiff: []string{
+				"VF 0 partition key needs update: desired 0x8001, current 0x0001",
+				"VF 2 partition key needs update: desired 0x7fff, current ",
+			},
+		},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			tc.spec.PciAddress = "0000:86:00.0"
+			tc.status.PciAddress = "0000:86:00.0"
+			result := v1.DiffInterface(&tc.spec, &tc.status)
+			if diff := cmp.Diff(tc.expectedDiff, result); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+			if v1.NeedToUpdateSriov(&tc.spec, &tc.status) != (len(tc.expectedDiff) > 0) {
+				t.Errorf("NeedToUpdateSriov doesn't match the diff")
+			}
+		})
+	}
+}
+
+func TestDriftInterface(t *testing.T) {
+	spec := v1.Interface{PciAddress: "0000:86:00.0", NumVfs: 1, ExternallyManaged: true,
+		VfGroups: []v1.VfGroup{{DeviceType: consts.DeviceTypeNetDevice, VfRange: "0-0", Mtu: 9000}}}
+	status := v1.InterfaceExt{PciAddress: "0000:86:00.0", NumVfs: 1,
+		VFs: []v1.VirtualFunction{{VfID: 0, Driver: "iavf", Mtu: 9000}}}
+	if diff := cmp.Diff([]string{"VF 0 needs update: the PF is externally managed"}, v1.DiffInterface(&spec, &status)); diff != "" {
+		t.Errorf("unexpected DiffInterface result (-want +got):\n%s", diff)
+	}
+	if drift := v1.DriftInterface(&spec, &status); len(drift) > 0 {
+		t.Errorf("unexpected drift of the externally managed PF: %v", drift)
+	}
+	status.VFs[0].Mtu = 1500
+	if diff := cmp.Diff([]string{"VF 0 MTU needs update: desired 9000, current 1500"}, v1.DriftInterface(&spec, &status)); diff != "" {
+		t.Errorf("unexpected DriftInterface result (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseVfMac(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		mac         string
+		expectedMac string
+		expectedErr bool
+	}{
+		{tname: "locally administered", mac: "02:00:00:00:00:10", expectedMac: "02:00:00:00:00:10"},
+		{tname: "universally administered", mac: "B8:3F:D2:00:00:10", expectedMac: "b8:3f:d2:00:00:10"},
+		{tname: "multicast", mac: "01:00:5e:00:00:10", expectedErr: true},
+		{tname: "zero", mac: "00:00:00:00:00:00", expectedErr: true},
+		{tname: "infiniband address", mac: "00:11:22:33:44:55:66:77", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			mac, err := v1.ParseVfMac(tc.mac)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseVfMac expected an error for %q", tc.mac)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVfMac unexpected error: %v", err)
+			}
+			if mac.String() != tc.expectedMac {
+				t.Errorf("ParseVfMac expected %s, got %s", tc.expectedMac, mac.String())
+			}
+		})
+	}
+}
+
+func TestParseIbPkey(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		pkey        string
+		expected    uint16
+		expectedErr bool
+	}{
+		{tname: "full membership", pkey: "0x8001", expected: 0x8001},
+		{tname: "limited membership", pkey: "0X1", expected: 0x0001},
+		{tname: "default partition", pkey: "0x7FFF", expected: 0x7fff},
+		{tname: "missing prefix", pkey: "8001", expectedErr: true},
+		{tname: "too large", pkey: "0x18001", expectedErr: true},
+		{tname: "zero base value", pkey: "0x8000", expectedErr: true},
+		{tname: "zero", pkey: "0x0", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			pkey, err := v1.ParseIbPkey(tc.pkey)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseIbPkey expected an error for %q", tc.pkey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIbPkey unexpected error: %v", err)
+			}
+			if pkey != tc.expected {
+				t.Errorf("ParseIbPkey expected %#x, got %#x", tc.expected, pkey)
+			}
+		})
+	}
+}
+
+func TestIbPkeyMatches(t *testing.T) {
+	testtable := []struct {
+		tname     string
+		requested uint16
+		current   uint16
+		expected  bool
+	}{
+		{tname: "same key", requested: 0x8001, current: 0x8001, expected: true},
+		{tname: "other membership", requested: 0x8001, current: 0x0001, expected: false},
+		{tname: "default partition with full membership", requested: 0x7fff, current: 0xffff, expected: true},
+		{tname: "full member of the default partition", requested: 0xffff, current: 0x7fff, expected: true},
+		{tname: "default partition and other key", requested: 0x7fff, current: 0x8001, expected: false},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			if v1.IbPkeyMatches(tc.requested, tc.current) != tc.expected {
+				t.Errorf("IbPkeyMatches(%#x, %#x) expected %t", tc.requested, tc.current, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseVlanTrunk(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		trunk       string
+		expected    []v1.VlanRange
+		expectedErr bool
+	}{
+		{tname: "single vlan", trunk: "100", expected: []v1.VlanRange{{Start: 100, End: 100}}},
+		{tname: "ranges", trunk: "100-200,300", expected: []v1.VlanRange{{Start: 100, End: 200}, {Start: 300, End: 300}}},
+		{tname: "unsorted and overlapping", trunk: "300,150-200,100-160,201", expected: []v1.VlanRange{{Start: 100, End: 201}, {Start: 300, End: 300}}},
+		{tname: "full range", trunk: "1-4094", expected: []v1.VlanRange{{Start: 1, End: 4094}}},
+		{tname: "empty", trunk: "", expectedErr: true},
+		{tname: "vlan zero", trunk: "0-10", expectedErr: true},
+		{tname: "reserved vlan", trunk: "4095", expectedErr: true},
+		{tname: "reversed range", trunk: "200-100", expectedErr: true},
+		{tname: "not a number", trunk: "100,abc", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			ranges, err := v1.ParseVlanTrunk(tc.trunk)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseVlanTrunk expected an error for %q", tc.trunk)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVlanTrunk unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, ranges); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatVlanTrunk(t *testing.T) {
+	ranges := []v1.VlanRange{{Start: 100, End: 200}, {Start: 300, End: 300}}
+	if trunk := v1.FormatVlanTrunk(ranges); trunk != "100-200,300" {
+		t.Errorf("FormatVlanTrunk expected 100-200,300, got %s", trunk)
+	}
+}
+
+func TestVlanTrunkDiff(t *testing.T) {
+	current := []v1.VlanRange{{Start: 100, End: 200}, {Start: 300, End: 300}}
+	desired := []v1.VlanRange{{Start: 150, End: 250}}
+	add, remove := v1.VlanTrunkDiff(current, desired)
+	if diff := cmp.Diff([]v1.VlanRange{{Start: 201, End: 250}}, add); diff != "" {
+		t.Errorf("unexpected VLANs to add (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]v1.VlanRange{{Start: 100, End: 149}, {Start: 300, End: 300}}, remove); diff != "" {
+		t.Errorf("unexpected VLANs to remove (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseMacPool(t *testing.T) {
+	testtable := []struct {
+		tname         string
+		pool          string
+		expectedFirst string
+		expectedSize  uint64
+		expectedErr   bool
+	}{
+		{tname: "single address", pool: "02:00:00:00:00:01-02:00:00:00:00:01", expectedFirst: "02:00:00:00:00:01", expectedSize: 1},
+		{tname: "range", pool: "02:00:00:00:00:00-02:00:00:00:01:ff", expectedFirst: "02:00:00:00:00:00", expectedSize: 512},
+		{tname: "missing last address", pool: "02:00:00:00:00:00", expectedErr: true},
+		{tname: "invalid address", pool: "02:00:00:00:00-02:00:00:00:00:ff", expectedErr: true},
+		{tname: "reversed", pool: "02:00:00:00:00:ff-02:00:00:00:00:00", expectedErr: true},
+		{tname: "multicast", pool: "01:00:00:00:00:00-01:00:00:00:00:ff", expectedErr: true},
+		{tname: "crossing the first octet", pool: "02:00:00:00:00:00-04:00:00:00:00:00", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			first, size, err := v1.ParseMacPool(tc.pool)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseMacPool expected an error for %q", tc.pool)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMacPool unexpected error: %v", err)
+			}
+			if first.String() != tc.expectedFirst || size != tc.expectedSize {
+				t.Errorf("ParseMacPool got %s/%d, expected %s/%d", first, size, tc.expectedFirst, tc.expectedSize)
+			}
+		})
+	}
+}
+
+func macPoolNodeState(node, pool string, vfRanges ...string) *v1.SriovNetworkNodeState {
+	state := &v1.SriovNetworkNodeState{ObjectMeta: metav1.ObjectMeta{Name: node}}
+	for i, vfRange := range vfRanges {
+		state.Spec.Interfaces = append(state.Spec.Interfaces, v1.Interface{
+			PciAddress: fmt.Sprintf("0000:86:00.%d", i),
+			VfGroups:   []v1.VfGroup{{PolicyName: "p1", ResourceName: "res", VfRange: vfRange, MacPool: pool}},
+		})
+	}
+	return state
+}
+
+func macPoolsOf(state *v1.SriovNetworkNodeState) []string {
+	pools := []string{}
+	for _, iface := range state.Spec.Interfaces {
+		pools = append(pools, iface.VfGroups[0].MacPool)
+	}
+	return pools
+}
+
+func TestMacPoolSplit(t *testing.T) {
+	const pool = "02:00:00:00:00:00-02:00:00:00:00:0f"
+	split := v1.NewMacPoolSplit(nil)
+	worker0 := macPoolNodeState("worker-0", pool, "0-3", "0-1")
+	worker1 := macPoolNodeState("worker-1", pool, "0-3")
+	for _, state := range []*v1.SriovNetworkNodeState{worker0, worker1} {
+		if err := split.Assign(state); err != nil {
+			t.Fatalf("Assign failed: %v", err)
+		}
+	}
+	expected := []string{"02:00:00:00:00:00-02:00:00:00:00:03", "02:00:00:00:00:04-02:00:00:00:00:05"}
+	if diff := cmp.Diff(expected, macPoolsOf(worker0)); diff != "" {
+		t.Errorf("Assign worker-0 diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"02:00:00:00:00:06-02:00:00:00:00:09"}, macPoolsOf(worker1)); diff != "" {
+		t.Errorf("Assign worker-1 diff (-want +got):\n%s", diff)
+	}
+
+	// the blocks recorded in the node states are kept whatever the order of the nodes, the block
+	// of the group whose number of VFs changed is replaced
+	split = v1.NewMacPoolSplit([]v1.SriovNetworkNodeState{*worker0, *worker1})
+	worker1 = macPoolNodeState("worker-1", pool, "0-3")
+	worker0 = macPoolNodeState("worker-0", pool, "0-3", "0-2")
+	for _, state := range []*v1.SriovNetworkNodeState{worker1, worker0} {
+		if err := split.Assign(state); err != nil {
+			t.Fatalf("Assign failed: %v", err)
+		}
+	}
+	if diff := cmp.Diff([]string{"02:00:00:00:00:06-02:00:00:00:00:09"}, macPoolsOf(worker1)); diff != "" {
+		t.Errorf("Assign worker-1 diff (-want +got):\n%s", diff)
+	}
+	expected = []string{"02:00:00:00:00:00-02:00:00:00:00:03", "02:00:00:00:00:0a-02:00:00:00:00:0c"}
+	if diff := cmp.Diff(expected, macPoolsOf(worker0)); diff != "" {
+		t.Errorf("Assign worker-0 diff (-want +got):\n%s", diff)
+	}
+
+	// the pool can't hold a block for each PF
+	if err := v1.NewMacPoolSplit(nil).Assign(macPoolNodeState("worker-0", pool, "0-7", "0-7", "0-0")); err == nil {
+		t.Errorf("Assign expected an error for the exhausted pool")
+	}
+}
+
+func TestParseMacBase(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		base        string
+		numVfs      int
+		expectedErr bool
+	}{
+		{tname: "valid", base: "02:00:00:00:00:00", numVfs: 128},
+		{tname: "last address of the first octet", base: "02:ff:ff:ff:ff:fe", numVfs: 2},
+		{tname: "invalid address", base: "02:00:00:00:00", numVfs: 1, expectedErr: true},
+		{tname: "multicast", base: "03:00:00:00:00:00", numVfs: 1, expectedErr: true},
+		{tname: "universally administered", base: "b8:3f:d2:00:00:00", numVfs: 1, expectedErr: true},
+		{tname: "too few addresses", base: "02:ff:ff:ff:ff:fe", numVfs: 3, expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			mac, err := v1.ParseMacBase(tc.base, tc.numVfs)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseMacBase expected an error for %q", tc.base)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMacBase unexpected error: %v", err)
+			}
+			if mac.String() != tc.base {
+				t.Errorf("ParseMacBase got %s, expected %s", mac, tc.base)
+			}
+		})
+	}
+}
+
+func TestNormalizePciAddress(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		addr        string
+		expected    string
+		expectedErr bool
+	}{
+		{tname: "canonical", addr: "0000:d8:00.1", expected: "0000:d8:00.1"},
+		{tname: "upper case", addr: "0000:D8:00.1", expected: "0000:d8:00.1"},
+		{tname: "no domain", addr: "d8:00.1", expected: "0000:d8:00.1"},
+		{tname: "short domain and bus", addr: "1:3:0.0", expected: "0001:03:00.0"},
+		{tname: "non-zero domain", addr: "0001:03:00.0", expected: "0001:03:00.0"},
+		{tname: "VMD domain", addr: "10000:03:00.0", expected: "10000:03:00.0"},
+		{tname: "invalid function", addr: "0000:03:00.8", expectedErr: true},
+		{tname: "slot out of range", addr: "0000:03:20.0", expectedErr: true},
+		{tname: "interface name", addr: "ens1f0", expectedErr: true},
+		{tname: "empty", addr: "", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			got, err := v1.NormalizePciAddress(tc.addr)
+			if tc.expectedErr {
+				if err == nil || v1.IsValidPciAddress(tc.addr) {
+					t.Errorf("NormalizePciAddress expected an error for %q", tc.addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizePciAddress unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("NormalizePciAddress got %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseDiscoveryFilterEntry(t *testing.T) {
+	testtable := []struct {
+		tname         string
+		entry         string
+		expectedKind  string
+		expectedValue string
+		expectedErr   bool
+	}{
+		{tname: "vendor", entry: "vendor:15B3", expectedKind: "vendor", expectedValue: "15b3"},
+		{tname: "device", entry: "device:101d", expectedKind: "device", expectedValue: "101d"},
+		{tname: "pci glob", entry: "pci:0000:3b:*", expectedKind: "pci", expectedValue: "0000:3b:*"},
+		{tname: "no kind", entry: "15b3", expectedErr: true},
+		{tname: "unknown kind", entry: "driver:mlx5_core", expectedErr: true},
+		{tname: "invalid ID", entry: "vendor:mellanox", expectedErr: true},
+		{tname: "invalid glob", entry: "pci:0000:[", expectedErr: true},
+		{tname: "empty value", entry: "pci:", expectedErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			kind, value, err := v1.ParseDiscoveryFilterEntry(tc.entry)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("ParseDiscoveryFilterEntry expected an error for %q", tc.entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDiscoveryFilterEntry unexpected error: %v", err)
+			}
+			if kind != tc.expectedKind || value != tc.expectedValue {
+				t.Errorf("ParseDiscoveryFilterEntry got %q %q, expected %q %q", kind, value, tc.expectedKind, tc.expectedValue)
+			}
+		})
+	}
+}
+
+func TestDiscoveryFilterMatch(t *testing.T) {
+	testtable := []struct {
+		tname    string
+		entries  []string
+		expected bool
+	}{
+		{tname: "empty", entries: nil, expected: false},
+		{tname: "vendor", entries: []string{"vendor:8086", "vendor:15b3"}, expected: true},
+		{tname: "other vendor", entries: []string{"vendor:8086"}, expected: false},
+		{tname: "device", entries: []string{"device:101D"}, expected: true},
+		{tname: "pci glob", entries: []string{"pci:0000:d8:*"}, expected: true},
+		{tname: "other pci glob", entries: []string{"pci:0000:3b:*"}, expected: false},
+		{tname: "invalid entry", entries: []string{"15b3"}, expected: false},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			if got := v1.DiscoveryFilterMatch(tc.entries, "15b3", "101d", "d8:00.0"); got != tc.expected {
+				t.Errorf("DiscoveryFilterMatch got %t, expected %t", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNicSelectorRootDevicesNonZeroDomain(t *testing.T) {
+	iface := &v1.InterfaceExt{PciAddress: "10000:03:00.0"}
+	for _, rootDevice := range []string{"10000:03:00.0", "10000:3:0.0", "10000:03:00.0 "} {
+		selector := v1.SriovNetworkNicSelector{RootDevices: []string{rootDevice}}
+		if !selector.Selected(iface) {
+			t.Errorf("root device %q expected to select %s", rootDevice, iface.PciAddress)
+		}
+	}
+	selector := v1.SriovNetworkNicSelector{RootDevices: []string{"0000:03:00.0"}}
+	if selector.Selected(iface) {
+		t.Errorf("root device 0000:03:00.0 expected not to select %s", iface.PciAddress)
+	}
+}
+
+func TestNicSelectorCountSelected(t *testing.T) {
+	state := &v1.SriovNetworkNodeState{
+		Status: v1.SriovNetworkNodeStateStatus{
+			Interfaces: []v1.InterfaceExt{
+				{Name: "ens803f0", PciAddress: "0000:86:00.0", Vendor: "8086", DeviceID: "158b"},
+				{Name: "ens803f1", PciAddress: "0000:86:00.1", Vendor: "8086", DeviceID: "158b"},
+				{Name: "ens785f0", PciAddress: "0000:3b:00.0", Vendor: "15b3", DeviceID: "1017"},
+			},
+		},
+	}
+	testtable := []struct {
+		tname    string
+		selector v1.SriovNetworkNicSelector
+		expected int
+	}{
+		{tname: "none", selector: v1.SriovNetworkNicSelector{Vendor: "14e4"}, expected: 0},
+		{tname: "one", selector: v1.SriovNetworkNicSelector{Vendor: "15b3"}, expected: 1},
+		{tname: "many", selector: v1.SriovNetworkNicSelector{Vendor: "8086"}, expected: 2},
+		{tname: "one by name", selector: v1.SriovNetworkNicSelector{Vendor: "8086", PfNames: []string{"ens803f1#0-3"}}, expected: 1},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			if count := tc.selector.CountSelected(state); count != tc.expected {
+				t.Errorf("expected %d selected PFs, got %d", tc.expected, count)
+			}
+		})
+	}
+}
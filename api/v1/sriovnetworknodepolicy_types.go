@@ -41,9 +41,10 @@ type SriovNetworkNodePolicySpec struct {
 	NumVfs int `json:"numVfs"`
 	// NicSelector selects the NICs to be configured
 	NicSelector SriovNetworkNicSelector `json:"nicSelector"`
-	// +kubebuilder:validation:Enum=netdevice;vfio-pci
+	// +kubebuilder:validation:Enum=netdevice;vfio-pci;igb_uio;uio_pci_generic
 	// +kubebuilder:default=netdevice
-	// The driver type for configured VFs. Allowed value "netdevice", "vfio-pci". Defaults to netdevice.
+	// The driver type for configured VFs. Allowed value "netdevice", "vfio-pci", "igb_uio", "uio_pci_generic".
+	// igb_uio and uio_pci_generic must be allowed in the dpdkDriverAllowlist of the SriovOperatorConfig. Defaults to netdevice.
 	DeviceType string `json:"deviceType,omitempty"`
 	// RDMA mode. Defaults to false.
 	IsRdma bool `json:"isRdma,omitempty"`
@@ -55,6 +56,15 @@ type SriovNetworkNodePolicySpec struct {
 	// +kubebuilder:validation:Enum=legacy;switchdev
 	// NIC Device Mode. Allowed value "legacy","switchdev".
 	EswitchMode string `json:"eSwitchMode,omitempty"`
+	// +kubebuilder:validation:Enum=none;link;network;transport
+	// Eswitch inline mode, the minimal packet headers the VFs must copy inline for the hardware offload.
+	// Allowed value "none", "link", "network", "transport". Valid only for eSwitchMode==switchdev.
+	// Left unchanged if not set.
+	EswitchInlineMode string `json:"eSwitchInlineMode,omitempty"`
+	// +kubebuilder:validation:Enum=enable;disable
+	// Eswitch encapsulation offload mode. Allowed value "enable", "disable".
+	// Valid only for eSwitchMode==switchdev. Left unchanged if not set.
+	EswitchEncapMode string `json:"eSwitchEncapMode,omitempty"`
 	// +kubebuilder:validation:Enum=virtio;vhost
 	// VDPA device type. Allowed value "virtio", "vhost"
 	VdpaType string `json:"vdpaType,omitempty"`
@@ -62,6 +72,143 @@ type SriovNetworkNodePolicySpec struct {
 	ExcludeTopology bool `json:"excludeTopology,omitempty"`
 	// don't create the virtual function only allocated them to the device plugin. Defaults to false.
 	ExternallyManaged bool `json:"externallyManaged,omitempty"`
+	// configure the virtual functions created externally even if there are fewer of them than numVfs,
+	// instead of failing the configuration, the missing virtual functions are skipped.
+	// Valid only with externallyManaged. Defaults to false.
+	ExternallyManagedBestEffort bool `json:"externallyManagedBestEffort,omitempty"`
+	// increase the number of virtual functions without removing the existing ones first,
+	// requires the driver to support incremental VF creation. Defaults to false.
+	IncrementalVfs bool `json:"incrementalVfs,omitempty"`
+	// set the PF link down while the number of virtual functions changes and restore its original
+	// state afterwards, avoids the link flaps of the PF when the VFs are created. A PF which was down
+	// is left down only when enabled. Enabled by default for the vendors whose PFs are known to flap,
+	// without leaving their PFs down. Defaults to false.
+	PfLinkDownOnVfChange bool `json:"pfLinkDownOnVfChange,omitempty"`
+	// +kubebuilder:validation:Enum=Reset;Keep
+	// What to do with the virtual functions of the matching PFs when the policy is deleted.
+	// Allowed value "Reset", "Keep". "Keep" leaves the virtual functions in place to be handed over
+	// to another manager. Defaults to "Reset".
+	ResetPolicy string `json:"resetPolicy,omitempty"`
+	// +kubebuilder:validation:Enum=on;off
+	// VF spoof check. Allowed value "on", "off". Left unchanged if not set.
+	SpoofChk string `json:"spoofChk,omitempty"`
+	// +kubebuilder:validation:Enum=on;off
+	// VF trust mode. Allowed value "on", "off". Left unchanged if not set.
+	Trust string `json:"trust,omitempty"`
+	// QoS of the virtual functions: the 802.1p priority of their traffic and, in switchdev mode,
+	// the devlink rate group of the PF they are attached to. Left unchanged if not set.
+	Qos *VfQos `json:"qos,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	// VLAN ID of the virtual functions, 0 clears the VLAN. Left unchanged if not set.
+	VlanID *int `json:"vlanId,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=7
+	// VLAN QoS of the virtual functions. Requires vlanId.
+	VlanQoS int `json:"vlanQoS,omitempty"`
+	// +kubebuilder:validation:Enum={"802.1q","802.1Q","802.1ad","802.1AD"}
+	// VLAN protocol of the virtual functions. Allowed value "802.1q", "802.1ad". Requires vlanId. Defaults to 802.1q.
+	VlanProto string `json:"vlanProto,omitempty"`
+	// +kubebuilder:validation:Pattern=`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`
+	// Ranges of VLAN IDs trunked by the virtual functions, e.g. "100-200,300". In switchdev mode the VLANs are
+	// tagged on the port of the VF representor in a Linux bridge. In legacy mode they require an out-of-tree vendor
+	// driver (Intel out-of-tree drivers, MLNX_OFED), combined with an 802.1ad vlanId the trunk then carries the inner
+	// 802.1q VLANs. The trunk of the virtual functions is cleared if not set.
+	VlanTrunk string `json:"vlanTrunk,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// Minimum tx rate of the virtual functions, in Mbps, 0 means no rate limiting. Left unchanged if not set.
+	MinTxRate *int `json:"minTxRate,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// Maximum tx rate of the virtual functions, in Mbps, 0 means no rate limiting. Left unchanged if not set.
+	MaxTxRate *int `json:"maxTxRate,omitempty"`
+	// +kubebuilder:validation:Enum=auto;enable;disable
+	// VF administrative link state. Allowed value "auto", "enable", "disable". Left unchanged if not set.
+	LinkState string `json:"linkState,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// Number of combined channels of the virtual function netdevs, configured before the virtual functions
+	// are bound to a DPDK driver and clamped to the maximum supported by the virtual functions.
+	// Left unchanged if not set.
+	CombinedChannels int `json:"combinedChannels,omitempty"`
+	// Name of the host network namespace the virtual function netdevs are moved to once configured,
+	// the namespace must exist. The virtual functions are left in the default namespace if not set.
+	HostNamespace string `json:"hostNamespace,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// Transmit queue length of the virtual function netdevs. Left unchanged if not set.
+	TxQueueLen int `json:"txQueueLen,omitempty"`
+	// GUIDs to assign to the virtual functions of InfiniBand devices, keyed by VF index.
+	// GUIDs not listed are generated on the node and persisted across reboots.
+	// The policy should select a single PF to avoid duplicated GUIDs.
+	VfGUIDs map[string]string `json:"vfGUIDs,omitempty"`
+	// +kubebuilder:validation:Enum=random;deterministic
+	// How the GUIDs of the virtual functions of InfiniBand devices not listed in vfGUIDs are generated.
+	// Allowed value "random", "deterministic". "random" GUIDs are persisted on the node, "deterministic"
+	// GUIDs are derived from the node name, the PF PCI address and the VF index. Defaults to "random".
+	GUIDGeneration string `json:"guidGeneration,omitempty"`
+	// +kubebuilder:validation:Pattern=`^0[xX][0-9a-fA-F]{1,4}$`
+	// InfiniBand partition key the virtual functions are mapped to, in the "0x<hex>" format, e.g. "0x8001".
+	// The key must be in the partition table of the PF. The default partition, "0x7fff" or "0xffff", maps
+	// the VFs to the default partition entry of the PF whatever its membership. Left unchanged if not set.
+	// Only supported by the mlx4_core driver, the subnet manager assigns the partition keys of the VFs of mlx5_core.
+	IbPkey string `json:"ibPkey,omitempty"`
+	// Range of administrative MAC addresses assigned to the virtual functions of Ethernet devices,
+	// in the "<first MAC>-<last MAC>" format. The address of each VF is picked from the range
+	// on the node and persisted across reboots. The kernel MAC is used if not set.
+	// The range is split into disjoint blocks, one for the VFs of each PF selected by the policy on each node.
+	MacPool string `json:"macPool,omitempty"`
+	// Base administrative MAC address of the virtual functions of Ethernet devices, the VF with index N
+	// is assigned the address base + N. The addresses must be locally administered unicast addresses.
+	// The assignment is persisted on the node. The policy must select a single PF on a single node, use macPool
+	// for policies selecting more PFs. Mutually exclusive with macPool.
+	MacBase string `json:"macBase,omitempty"`
+	// Administrative MAC address of the virtual function of Ethernet devices, programmed before the VF is
+	// bound to a DPDK driver so the userspace application gets a stable source MAC. The address is not split
+	// between the PFs and the nodes, so the policy must select a single VF of a single PF on a single node.
+	// The MAC the VF has is kept if not set. Mutually exclusive with macPool and macBase.
+	Mac string `json:"mac,omitempty"`
+	// +kubebuilder:validation:Enum=shared;exclusive
+	// Network namespace mode of the RDMA subsystem of the selected nodes. Allowed value "shared", "exclusive".
+	// The mode can be changed only while no RDMA devices are in use in other network namespaces.
+	// Left unchanged if not set.
+	RdmaMode string `json:"rdmaMode,omitempty"`
+	// Kernel modules the configuration of the selected nodes depends on, e.g. "mlx5_core" or "vfio_iommu_type1".
+	// The config daemon validates the modules are loaded before applying the configuration when
+	// the validation is enabled on the daemon.
+	RequiredKernelModules []string `json:"requiredKernelModules,omitempty"`
+	// Size of the hugepages to allocate on the kernel cmd line of the selected nodes, e.g. "2M" or "1G".
+	// Requires hugepageCount.
+	HugepageSize string `json:"hugepageSize,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// Number of hugepages of hugepageSize to allocate on the kernel cmd line of the selected nodes. The hugepage
+	// args already on the kernel cmd line are kept, the node is rebooted when the args are added.
+	// The hugepages are not managed if not set.
+	HugepageCount int `json:"hugepageCount,omitempty"`
+	// Take ownership of the PFs selected by the policy with VFs which were not created by the operator,
+	// e.g. after the operator state on the node was lost. Adopted PFs are reset once no policy selects them.
+	// PFs requested as externally managed are never adopted. Defaults to false.
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:items:Minimum=0
+	// +kubebuilder:validation:items:Maximum=7
+	// Priorities with Priority Flow Control (DCB) enabled on the matching PFs. Left unchanged if not set.
+	PfcEnabled []int `json:"pfcEnabled,omitempty"`
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:items:Minimum=0
+	// +kubebuilder:validation:items:Maximum=7
+	// Traffic class (DCB) of each priority of the matching PFs, indexed by priority. Left unchanged if not set.
+	PriorityToTcMap []int `json:"priorityToTcMap,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// Number of combined channels of the matching PFs, configured after the virtual functions are created.
+	// Left unchanged if not set.
+	PfCombinedChannels int `json:"pfCombinedChannels,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// RX ring size of the matching PFs. Left unchanged if not set.
+	RingRx int `json:"ringRx,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// TX ring size of the matching PFs. Left unchanged if not set.
+	RingTx int `json:"ringTx,omitempty"`
+	// Clamp the ring sizes exceeding the maximum supported by the matching PFs to that maximum
+	// instead of failing the configuration. Defaults to false.
+	RingClamp bool `json:"ringClamp,omitempty"`
 	// contains bridge configuration for matching PFs,
 	// valid only for eSwitchMode==switchdev
 	Bridge Bridge `json:"bridge,omitempty"`
@@ -27,19 +27,69 @@ import (
 type SriovNetworkNodeStateSpec struct {
 	Interfaces Interfaces `json:"interfaces,omitempty"`
 	Bridges    Bridges    `json:"bridges,omitempty"`
+	// network namespace mode of the RDMA subsystem of the node, the mode is not managed when unset
+	// +kubebuilder:validation:Enum=shared;exclusive
+	RdmaMode string `json:"rdmaMode,omitempty"`
+	// kernel modules the configuration of the node depends on, collected from all the policies selecting the node
+	RequiredKernelModules []string `json:"requiredKernelModules,omitempty"`
+	// hugepages to allocate on the kernel cmd line of the node, one entry per size
+	Hugepages []Hugepages `json:"hugepages,omitempty"`
+}
+
+// Hugepages is the number of hugepages of a size to allocate on the kernel cmd line
+type Hugepages struct {
+	// size of the hugepages in the format of the kernel cmd line, e.g. 2M or 1G
+	Size string `json:"size"`
+	// number of hugepages of the size
+	Count int `json:"count"`
 }
 
 type Interfaces []Interface
 
 type Interface struct {
-	PciAddress        string    `json:"pciAddress"`
-	NumVfs            int       `json:"numVfs,omitempty"`
-	Mtu               int       `json:"mtu,omitempty"`
-	Name              string    `json:"name,omitempty"`
-	LinkType          string    `json:"linkType,omitempty"`
-	EswitchMode       string    `json:"eSwitchMode,omitempty"`
+	PciAddress  string `json:"pciAddress"`
+	NumVfs      int    `json:"numVfs,omitempty"`
+	Mtu         int    `json:"mtu,omitempty"`
+	Name        string `json:"name,omitempty"`
+	LinkType    string `json:"linkType,omitempty"`
+	EswitchMode string `json:"eSwitchMode,omitempty"`
+	// eswitch inline mode to set before switching the PF to switchdev, not managed when unset
+	// +kubebuilder:validation:Enum=none;link;network;transport
+	EswitchInlineMode string `json:"eSwitchInlineMode,omitempty"`
+	// eswitch encapsulation offload mode to set before switching the PF to switchdev, not managed when unset
+	// +kubebuilder:validation:Enum=enable;disable
+	EswitchEncapMode  string    `json:"eSwitchEncapMode,omitempty"`
 	VfGroups          []VfGroup `json:"vfGroups,omitempty"`
 	ExternallyManaged bool      `json:"externallyManaged,omitempty"`
+	// Configure the VFs of the externally managed PF even if there are fewer of them than NumVfs,
+	// the missing VFs are skipped instead of failing the configuration
+	ExternallyManagedBestEffort bool `json:"externallyManagedBestEffort,omitempty"`
+	IncrementalVfs              bool `json:"incrementalVfs,omitempty"`
+	// take ownership of the PF when its VFs were not created by the operator, set if a policy
+	// selecting the PF requests it
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// what to do with the VFs of the PF when it is no longer configured, Reset or Keep
+	ResetPolicy string `json:"resetPolicy,omitempty"`
+	// Set the PF link down while the number of VFs changes and restore its original state afterwards,
+	// the PF is also left down at the end of the configuration if it was down
+	PfLinkDownOnVfChange bool `json:"pfLinkDownOnVfChange,omitempty"`
+	// priorities with Priority Flow Control enabled on the PF, PFC is not managed when unset
+	PfcEnabled []int `json:"pfcEnabled,omitempty"`
+	// traffic class of each priority of the PF, indexed by priority, the mapping is not managed when unset
+	PriorityToTcMap []int `json:"priorityToTcMap,omitempty"`
+	// Number of combined channels to configure on the PF netdev after the VFs are created,
+	// the channels are not managed when unset
+	// +kubebuilder:validation:Minimum=0
+	CombinedChannels int `json:"combinedChannels,omitempty"`
+	// RX ring size to configure on the PF netdev, the RX ring is not managed when unset
+	// +kubebuilder:validation:Minimum=0
+	RingRx int `json:"ringRx,omitempty"`
+	// TX ring size to configure on the PF netdev, the TX ring is not managed when unset
+	// +kubebuilder:validation:Minimum=0
+	RingTx int `json:"ringTx,omitempty"`
+	// Clamp the ring sizes exceeding the maximum supported by the PF to that maximum,
+	// instead of failing the configuration
+	RingClamp bool `json:"ringClamp,omitempty"`
 }
 
 type VfGroup struct {
@@ -50,6 +100,79 @@ type VfGroup struct {
 	Mtu          int    `json:"mtu,omitempty"`
 	IsRdma       bool   `json:"isRdma,omitempty"`
 	VdpaType     string `json:"vdpaType,omitempty"`
+	// VLAN ID to program on the VFs of the group, 0 clears the VLAN.
+	// The VLAN is not managed when unset.
+	VlanID *int `json:"vlanId,omitempty"`
+	// VLAN QoS to program on the VFs of the group
+	VlanQoS int `json:"vlanQoS,omitempty"`
+	// VLAN protocol to program on the VFs of the group, defaults to 802.1q
+	// +kubebuilder:validation:Enum={"802.1q","802.1Q","802.1ad","802.1AD"}
+	VlanProto string `json:"vlanProto,omitempty"`
+	// Ranges of VLAN IDs the VFs of the group trunk, e.g. "100-200,300", through the trunk interface of
+	// the PF driver. Combined with an 802.1ad VlanID the trunk carries the inner 802.1q VLANs (QinQ).
+	// The trunk is not managed when unset
+	// +kubebuilder:validation:Pattern=`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`
+	VlanTrunk string `json:"vlanTrunk,omitempty"`
+	// VF spoof check to program on the VFs of the group
+	// +kubebuilder:validation:Enum=on;off
+	SpoofChk string `json:"spoofChk,omitempty"`
+	// VF trust mode to program on the VFs of the group
+	// +kubebuilder:validation:Enum=on;off
+	Trust string `json:"trust,omitempty"`
+	// Minimum tx rate, in Mbps, to program on the VFs of the group, 0 means no rate limiting.
+	// The rate is not managed when unset.
+	// +kubebuilder:validation:Minimum=0
+	MinTxRate *int `json:"minTxRate,omitempty"`
+	// Maximum tx rate, in Mbps, to program on the VFs of the group, 0 means no rate limiting.
+	// The rate is not managed when unset.
+	// +kubebuilder:validation:Minimum=0
+	MaxTxRate *int `json:"maxTxRate,omitempty"`
+	// VF administrative link state to program on the VFs of the group
+	// +kubebuilder:validation:Enum=auto;enable;disable
+	LinkState string `json:"linkState,omitempty"`
+	// Number of combined channels to configure on the VF netdev before binding the VF to
+	// a DPDK driver, the number is clamped to the maximum supported by the VF
+	// +kubebuilder:validation:Minimum=0
+	CombinedChannels int `json:"combinedChannels,omitempty"`
+	// Name of the host network namespace the netdevs of the VFs of the group are moved to
+	// after they are configured, the VFs are left in the default namespace when unset
+	HostNamespace string `json:"hostNamespace,omitempty"`
+	// Transmit queue length to configure on the VF netdevs of the group, the length is left
+	// untouched when unset
+	// +kubebuilder:validation:Minimum=1
+	TxQueueLen int `json:"txQueueLen,omitempty"`
+	// GUIDs to assign to the InfiniBand VFs of the group, keyed by VF index
+	VfGUIDs map[string]string `json:"vfGUIDs,omitempty"`
+	// How the GUIDs of the InfiniBand VFs of the group not listed in VfGUIDs are generated
+	// +kubebuilder:validation:Enum=random;deterministic
+	GUIDGeneration string `json:"guidGeneration,omitempty"`
+	// InfiniBand partition key the VFs of the group are mapped to, in the "0x<hex>" format,
+	// the partition of the VFs is not managed when unset
+	// +kubebuilder:validation:Pattern=`^0[xX][0-9a-fA-F]{1,4}$`
+	IbPkey string `json:"ibPkey,omitempty"`
+	// Range of administrative MAC addresses assigned to the VFs of the group, in the
+	// "<first MAC>-<last MAC>" format, the kernel MAC is used when unset. The block of the MAC pool
+	// of the policy assigned to the PF of the node.
+	MacPool string `json:"macPool,omitempty"`
+	// Base administrative MAC address of the VFs of the group, the VF with index N is assigned base + N
+	MacBase string `json:"macBase,omitempty"`
+	// Administrative MAC address of the VFs of the group, programmed through the PF before the VFs are
+	// bound to a DPDK driver, the MAC the VF has is kept when unset
+	Mac string `json:"mac,omitempty"`
+	// QoS configuration of the VFs of the group, the QoS is not managed when unset
+	Qos *VfQos `json:"qos,omitempty"`
+}
+
+// VfQos is the QoS configuration of the VFs of a group
+type VfQos struct {
+	// 802.1p priority of the traffic of the VFs, programmed as the VLAN QoS of the VFs,
+	// it takes precedence over VlanQoS. The priority is not managed when unset
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=7
+	Priority *int `json:"priority,omitempty"`
+	// Name of the devlink rate group of the PF the VFs are attached to, the group is created if it
+	// doesn't exist. Rate groups are supported only in switchdev mode, the group is not managed when unset
+	Group string `json:"group,omitempty"`
 }
 
 type InterfaceExt struct {
@@ -65,27 +188,76 @@ type InterfaceExt struct {
 	LinkSpeed         string            `json:"linkSpeed,omitempty"`
 	LinkType          string            `json:"linkType,omitempty"`
 	LinkAdminState    string            `json:"linkAdminState,omitempty"`
+	FirmwareVersion   string            `json:"firmwareVersion,omitempty"`
+	CombinedChannels  int               `json:"combinedChannels,omitempty"`
+	RingRx            int               `json:"ringRx,omitempty"`
+	RingTx            int               `json:"ringTx,omitempty"`
+	RingRxMax         int               `json:"ringRxMax,omitempty"`
+	RingTxMax         int               `json:"ringTxMax,omitempty"`
 	EswitchMode       string            `json:"eSwitchMode,omitempty"`
+	EswitchInlineMode string            `json:"eSwitchInlineMode,omitempty"`
+	EswitchEncapMode  string            `json:"eSwitchEncapMode,omitempty"`
 	ExternallyManaged bool              `json:"externallyManaged,omitempty"`
 	TotalVfs          int               `json:"totalvfs,omitempty"`
+	OffloadVfLimit    int               `json:"offloadVfLimit,omitempty"`
+	NumaNode          int               `json:"numaNode,omitempty"`
+	PfcEnabled        []int             `json:"pfcEnabled,omitempty"`
+	PriorityToTcMap   []int             `json:"priorityToTcMap,omitempty"`
 	VFs               []VirtualFunction `json:"Vfs,omitempty"`
+
+	// Mode of the BlueField-2/3 cards, dpu (embedded) or nic (separated), empty for the other cards
+	BlueFieldMode string `json:"blueFieldMode,omitempty"`
+	// SR-IOV features and limits of the PF
+	Capabilities *InterfaceCapabilities `json:"capabilities,omitempty"`
+	// Number of VFs requested for an externally managed PF in best effort mode which were not
+	// created externally, the VF groups above the available VFs are not configured
+	SkippedVfs int `json:"skippedVfs,omitempty"`
 }
 type InterfaceExts []InterfaceExt
 
+// InterfaceCapabilities are the SR-IOV features and limits of a PF, the configurations they don't
+// allow are rejected instead of being silently ignored by the driver
+type InterfaceCapabilities struct {
+	// Maximum TX rate of the VFs in Mbps, the link speed of the PF, 0 when unknown
+	MaxTxRate int `json:"maxTxRate,omitempty"`
+	// The driver of the PF supports the trust mode of the VFs
+	VfTrust bool `json:"vfTrust"`
+	// The driver of the PF supports the spoof check of the VFs
+	VfSpoofChk bool `json:"vfSpoofChk"`
+	// The driver of the PF supports the 802.1ad VLAN protocol on the VFs
+	VfVlanProto8021ad bool `json:"vfVlanProto8021ad"`
+}
+
 type VirtualFunction struct {
 	Name            string `json:"name,omitempty"`
 	Mac             string `json:"mac,omitempty"`
+	AdminMac        string `json:"adminMac,omitempty"`
 	Assigned        string `json:"assigned,omitempty"`
 	Driver          string `json:"driver,omitempty"`
 	PciAddress      string `json:"pciAddress"`
 	Vendor          string `json:"vendor,omitempty"`
 	DeviceID        string `json:"deviceID,omitempty"`
 	Vlan            int    `json:"Vlan,omitempty"`
+	VlanQoS         int    `json:"vlanQoS,omitempty"`
+	VlanProto       string `json:"vlanProto,omitempty"`
+	VlanTrunk       string `json:"vlanTrunk,omitempty"`
+	SpoofChk        string `json:"spoofChk,omitempty"`
+	Trust           string `json:"trust,omitempty"`
+	MinTxRate       int    `json:"minTxRate,omitempty"`
+	MaxTxRate       int    `json:"maxTxRate,omitempty"`
+	LinkState       string `json:"linkState,omitempty"`
+	LinkAdminState  string `json:"linkAdminState,omitempty"`
+	Carrier         string `json:"carrier,omitempty"`
 	Mtu             int    `json:"mtu,omitempty"`
 	VfID            int    `json:"vfID"`
+	NumaNode        int    `json:"numaNode,omitempty"`
+	ParentPf        string `json:"parentPf,omitempty"`
 	VdpaType        string `json:"vdpaType,omitempty"`
 	RepresentorName string `json:"representorName,omitempty"`
 	GUID            string `json:"guid,omitempty"`
+	IbPkey          string `json:"ibPkey,omitempty"`
+	// devlink rate group the VF is attached to, reported only in switchdev mode
+	QosGroup string `json:"qosGroup,omitempty"`
 }
 
 // Bridges contains list of bridges
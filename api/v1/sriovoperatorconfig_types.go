@@ -39,6 +39,22 @@ func (pns PluginNameSlice) ToStringSlice() []string {
 	return ss
 }
 
+// PluginOrderValue defines the name of a plugin in the plugin order
+// +kubebuilder:validation:Enum=generic;k8s;virtual;mellanox;intel
+type PluginOrderValue string
+
+// PluginOrderSlice defines a slice of PluginOrderValue
+type PluginOrderSlice []PluginOrderValue
+
+// ToStringSlice converts PluginOrderSlice to string slice
+func (pos PluginOrderSlice) ToStringSlice() []string {
+	ss := make([]string, 0, len(pos))
+	for _, v := range pos {
+		ss = append(ss, string(v))
+	}
+	return ss
+}
+
 // SriovOperatorConfigSpec defines the desired state of SriovOperatorConfig
 type SriovOperatorConfigSpec struct {
 	// NodeSelector selects the nodes to be configured
@@ -63,8 +79,18 @@ type SriovOperatorConfigSpec struct {
 	UseCDI bool `json:"useCDI,omitempty"`
 	// DisablePlugins is a list of sriov-network-config-daemon plugins to disable
 	DisablePlugins PluginNameSlice `json:"disablePlugins,omitempty"`
+	// PluginOrder is the order in which the sriov-network-config-daemon plugins run,
+	// the plugins not listed run after the listed ones
+	PluginOrder PluginOrderSlice `json:"pluginOrder,omitempty"`
+	// DpdkDriverAllowlist lists the DPDK drivers, in addition to vfio-pci, the policies are allowed to select as deviceType,
+	// e.g. igb_uio or uio_pci_generic
+	DpdkDriverAllowlist []string `json:"dpdkDriverAllowlist,omitempty"`
 	// FeatureGates to enable experimental features
 	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+	// Failure policy of the operator admission controller webhook
+	// Default policy: Fail
+	// +kubebuilder:validation:Enum=Fail;Ignore
+	WebhookFailurePolicy string `json:"webhookFailurePolicy,omitempty"`
 }
 
 // SriovOperatorConfigStatus defines the observed state of SriovOperatorConfig
@@ -89,12 +89,39 @@ func (in ByPriority) DeepCopy() ByPriority {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hugepages) DeepCopyInto(out *Hugepages) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hugepages.
+func (in *Hugepages) DeepCopy() *Hugepages {
+	if in == nil {
+		return nil
+	}
+	out := new(Hugepages)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Interface) DeepCopyInto(out *Interface) {
 	*out = *in
 	if in.VfGroups != nil {
 		in, out := &in.VfGroups, &out.VfGroups
 		*out = make([]VfGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PfcEnabled != nil {
+		in, out := &in.PfcEnabled, &out.PfcEnabled
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.PriorityToTcMap != nil {
+		in, out := &in.PriorityToTcMap, &out.PriorityToTcMap
+		*out = make([]int, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -109,14 +136,44 @@ func (in *Interface) DeepCopy() *Interface {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InterfaceCapabilities) DeepCopyInto(out *InterfaceCapabilities) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterfaceCapabilities.
+func (in *InterfaceCapabilities) DeepCopy() *InterfaceCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(InterfaceCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InterfaceExt) DeepCopyInto(out *InterfaceExt) {
 	*out = *in
+	if in.PfcEnabled != nil {
+		in, out := &in.PfcEnabled, &out.PfcEnabled
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.PriorityToTcMap != nil {
+		in, out := &in.PriorityToTcMap, &out.PriorityToTcMap
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
 	if in.VFs != nil {
 		in, out := &in.VFs, &out.VFs
 		*out = make([]VirtualFunction, len(*in))
 		copy(*out, *in)
 	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(InterfaceCapabilities)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InterfaceExt.
@@ -442,6 +499,25 @@ func (in PluginNameSlice) DeepCopy() PluginNameSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in PluginOrderSlice) DeepCopyInto(out *PluginOrderSlice) {
+	{
+		in := &in
+		*out = make(PluginOrderSlice, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginOrderSlice.
+func (in PluginOrderSlice) DeepCopy() PluginOrderSlice {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginOrderSlice)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SriovIBNetwork) DeepCopyInto(out *SriovIBNetwork) {
 	*out = *in
@@ -685,6 +761,48 @@ func (in *SriovNetworkNodePolicySpec) DeepCopyInto(out *SriovNetworkNodePolicySp
 		}
 	}
 	in.NicSelector.DeepCopyInto(&out.NicSelector)
+	if in.VfGUIDs != nil {
+		in, out := &in.VfGUIDs, &out.VfGUIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Qos != nil {
+		in, out := &in.Qos, &out.Qos
+		*out = new(VfQos)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VlanID != nil {
+		in, out := &in.VlanID, &out.VlanID
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinTxRate != nil {
+		in, out := &in.MinTxRate, &out.MinTxRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxTxRate != nil {
+		in, out := &in.MaxTxRate, &out.MaxTxRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.PfcEnabled != nil {
+		in, out := &in.PfcEnabled, &out.PfcEnabled
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.PriorityToTcMap != nil {
+		in, out := &in.PriorityToTcMap, &out.PriorityToTcMap
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredKernelModules != nil {
+		in, out := &in.RequiredKernelModules, &out.RequiredKernelModules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Bridge.DeepCopyInto(&out.Bridge)
 }
 
@@ -783,6 +901,16 @@ func (in *SriovNetworkNodeStateSpec) DeepCopyInto(out *SriovNetworkNodeStateSpec
 		}
 	}
 	in.Bridges.DeepCopyInto(&out.Bridges)
+	if in.RequiredKernelModules != nil {
+		in, out := &in.RequiredKernelModules, &out.RequiredKernelModules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hugepages != nil {
+		in, out := &in.Hugepages, &out.Hugepages
+		*out = make([]Hugepages, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SriovNetworkNodeStateSpec.
@@ -1032,6 +1160,16 @@ func (in *SriovOperatorConfigSpec) DeepCopyInto(out *SriovOperatorConfigSpec) {
 		*out = make(PluginNameSlice, len(*in))
 		copy(*out, *in)
 	}
+	if in.PluginOrder != nil {
+		in, out := &in.PluginOrder, &out.PluginOrder
+		*out = make(PluginOrderSlice, len(*in))
+		copy(*out, *in)
+	}
+	if in.DpdkDriverAllowlist != nil {
+		in, out := &in.DpdkDriverAllowlist, &out.DpdkDriverAllowlist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.FeatureGates != nil {
 		in, out := &in.FeatureGates, &out.FeatureGates
 		*out = make(map[string]bool, len(*in))
@@ -1099,6 +1237,33 @@ func (in *TrunkConfig) DeepCopy() *TrunkConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VfGroup) DeepCopyInto(out *VfGroup) {
 	*out = *in
+	if in.VlanID != nil {
+		in, out := &in.VlanID, &out.VlanID
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinTxRate != nil {
+		in, out := &in.MinTxRate, &out.MinTxRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxTxRate != nil {
+		in, out := &in.MaxTxRate, &out.MaxTxRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.VfGUIDs != nil {
+		in, out := &in.VfGUIDs, &out.VfGUIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Qos != nil {
+		in, out := &in.Qos, &out.Qos
+		*out = new(VfQos)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VfGroup.
@@ -1111,6 +1276,26 @@ func (in *VfGroup) DeepCopy() *VfGroup {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VfQos) DeepCopyInto(out *VfQos) {
+	*out = *in
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VfQos.
+func (in *VfQos) DeepCopy() *VfQos {
+	if in == nil {
+		return nil
+	}
+	out := new(VfQos)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualFunction) DeepCopyInto(out *VirtualFunction) {
 	*out = *in
@@ -5,11 +5,65 @@ declare -a kargs=( "$@" )
 ret=0
 args=$(chroot /host/ cat /proc/cmdline)
 
+# keys of the kernel args which can be repeated on the kernel cmd line, the value of
+# hugepages applies to the size of the hugepagesz preceding it
+repeatable_keys=" hugepagesz hugepages "
+
+# has_arg succeeds if the kernel args, separated by spaces, are consecutive whole args of the kernel cmd line
+has_arg() {
+    [[ " $1 " == *" $2 "* ]]
+}
+
+# hugepages_of_size prints the hugepages arg following the hugepagesz arg of the kernel args
+hugepages_of_size() {
+    local size="" a
+    for a in $1; do
+        case $a in
+        hugepagesz=*)
+            size=$a
+            ;;
+        hugepages=*)
+            if [[ $size == "$2" ]]; then
+                echo "$a"
+                return
+            fi
+            ;;
+        esac
+    done
+}
+
+# a hugepagesz arg followed by a hugepages arg is checked and added as a single unit, the count
+# of hugepages found on the kernel cmd line after another size doesn't apply to the requested size
+declare -a units=()
+for ((i = 0; i < ${#kargs[@]}; i++)); do
+    t=${kargs[$i]}
+    next=${kargs[$((i + 1))]:-}
+    if [[ $t == hugepagesz=* && $next == hugepages=* ]]; then
+        units+=( "$t $next" )
+        i=$((i + 1))
+        continue
+    fi
+    units+=( "$t" )
+done
+
 if chroot /host/ test -f /run/ostree-booted ; then
-    for t in "${kargs[@]}";do
-        if [[ $args != *${t}* ]];then
-            if chroot /host/ rpm-ostree kargs | grep -vq ${t}; then
-                chroot /host/ rpm-ostree kargs --append ${t} > /dev/null 2>&1
+    for t in "${units[@]}";do
+        if ! has_arg "$args" "$t";then
+            # the appended args are added after the existing ones with the same key
+            ostree_args=$(chroot /host/ rpm-ostree kargs)
+            if ! has_arg "$ostree_args" "$t"; then
+                declare -a append=()
+                # the hugepages count already set for the size is replaced
+                if [[ $t == hugepagesz=* ]]; then
+                    old=$(hugepages_of_size "$ostree_args" "${t%% *}")
+                    if [ -n "$old" ]; then
+                        append+=( --delete "${t%% *}" --delete "$old" )
+                    fi
+                fi
+                for a in $t; do
+                    append+=( --append "$a" )
+                done
+                chroot /host/ rpm-ostree kargs "${append[@]}" > /dev/null 2>&1
             fi
             let ret++
         fi
@@ -20,14 +74,47 @@ else
     if [ $? -ne 0 ]; then
         exit 127
     fi
-    for t in "${kargs[@]}";do
-        if [[ $args != *${t}* ]];then
-            if chroot /host/ grubby --info=DEFAULT | grep args | grep -vq ${t}; then
-                chroot /host/ grubby --update-kernel=DEFAULT --args=${t} > /dev/null 2>&1
+    grub_args=$(chroot /host/ grubby --info=DEFAULT | grep '^args=' | sed -e 's/^args="//' -e 's/"$//')
+    declare -a missing=()
+    for t in "${units[@]}";do
+        if ! has_arg "$args" "$t";then
+            if ! has_arg "$grub_args" "$t"; then
+                missing+=( $t )
             fi
             let ret++
         fi
     done
+    if [ ${#missing[@]} -gt 0 ]; then
+        # grubby replaces the args with the same key as the added ones, the existing args with a
+        # repeatable key are added again first so they are kept in order with the missing ones,
+        # except the hugepagesz and hugepages args of the sizes whose count is replaced
+        declare -a update=()
+        replaced=0
+        for a in $grub_args; do
+            key=${a%%=*}
+            if [[ $repeatable_keys != *" $key "* ]]; then
+                continue
+            fi
+            if [[ $key == hugepagesz ]]; then
+                replaced=0
+                if [[ " ${missing[*]} " == *" $a hugepages="* ]]; then
+                    replaced=1
+                    continue
+                fi
+            fi
+            if [[ $key == hugepages && $replaced == 1 ]]; then
+                replaced=0
+                continue
+            fi
+            for t in "${missing[@]}"; do
+                if [[ ${t%%=*} == "$key" ]]; then
+                    update+=( "$a" )
+                    break
+                fi
+            done
+        done
+        chroot /host/ grubby --update-kernel=DEFAULT --args="${update[*]} ${missing[*]}" > /dev/null 2>&1
+    fi
 fi
 
 echo $ret
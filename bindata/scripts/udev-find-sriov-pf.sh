@@ -11,6 +11,7 @@ pf_pci_address=${pf_path##*../}
 
 if [ "$2" == "$pf_pci_address" ]; then
     echo "NM_UNMANAGED=1"
+    echo "SRIOV_PF=$pf_pci_address"
 fi
 EOF
 
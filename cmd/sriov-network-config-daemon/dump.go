@@ -0,0 +1,56 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper"
+)
+
+var (
+	dumpStateArg       bool
+	dumpUnsupportedArg bool
+
+	dumpNodeStateFunc = helper.DumpNodeState
+)
+
+func init() {
+	rootCmd.RunE = runRootCmd
+	rootCmd.Flags().BoolVar(&dumpStateArg, "dump-state", false, "print the SR-IOV devices discovered on the host as JSON and exit")
+	rootCmd.Flags().BoolVar(&dumpUnsupportedArg, "dump-with-unsupported", false,
+		"include the devices missing from the list of supported NICs in the output of --dump-state")
+}
+
+func runRootCmd(cmd *cobra.Command, args []string) error {
+	if !dumpStateArg {
+		return cmd.Help()
+	}
+	// the list of supported NICs is written on the host by the config daemon
+	if !dumpUnsupportedArg {
+		if err := initSupportedNics(); err != nil {
+			return fmt.Errorf("failed to initialize list of supported NIC ids, use --dump-with-unsupported to skip it: %v", err)
+		}
+	}
+	data, err := dumpNodeStateFunc(dumpUnsupportedArg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return nil
+}
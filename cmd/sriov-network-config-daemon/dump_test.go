@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	testHelpers "github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("Dump state", func() {
+	var (
+		out             *bytes.Buffer
+		cmd             *cobra.Command
+		withUnsupported *bool
+	)
+
+	BeforeEach(func() {
+		origDumpNodeStateFunc := dumpNodeStateFunc
+		origDumpStateArg, origDumpUnsupportedArg := dumpStateArg, dumpUnsupportedArg
+		origInChroot := vars.InChroot
+		DeferCleanup(func() {
+			dumpNodeStateFunc = origDumpNodeStateFunc
+			dumpStateArg, dumpUnsupportedArg = origDumpStateArg, origDumpUnsupportedArg
+			vars.InChroot = origInChroot
+		})
+		vars.InChroot = true
+		withUnsupported = nil
+		dumpNodeStateFunc = func(unsupported bool) ([]byte, error) {
+			withUnsupported = &unsupported
+			return []byte(`[{"pciAddress": "0000:d8:00.0"}]`), nil
+		}
+		out = &bytes.Buffer{}
+		cmd = &cobra.Command{}
+		cmd.SetOut(out)
+		dumpStateArg = true
+		dumpUnsupportedArg = false
+	})
+
+	It("should print the node state", func() {
+		testHelpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+			Dirs: []string{"/etc/sriov-operator"},
+			Files: map[string][]byte{
+				"/etc/sriov-operator/sriov-supported-nics-ids.yaml": []byte(testSriovSupportedNicIDs),
+			},
+		})
+		Expect(runRootCmd(cmd, []string{})).To(Succeed())
+		Expect(*withUnsupported).To(BeFalse())
+		Expect(out.String()).To(Equal(`[{"pciAddress": "0000:d8:00.0"}]` + "\n"))
+	})
+
+	It("should fail without the list of supported NICs", func() {
+		testHelpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/etc/sriov-operator"}})
+		Expect(runRootCmd(cmd, []string{})).To(MatchError(ContainSubstring("use --dump-with-unsupported")))
+		Expect(withUnsupported).To(BeNil())
+	})
+
+	It("should not need the list of supported NICs with the unsupported devices", func() {
+		testHelpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/etc/sriov-operator"}})
+		dumpUnsupportedArg = true
+		Expect(runRootCmd(cmd, []string{})).To(Succeed())
+		Expect(*withUnsupported).To(BeTrue())
+	})
+
+	It("should report the discovery failure", func() {
+		dumpUnsupportedArg = true
+		dumpNodeStateFunc = func(bool) ([]byte, error) { return nil, fmt.Errorf("test") }
+		Expect(runRootCmd(cmd, []string{})).To(MatchError("test"))
+		Expect(out.String()).To(BeEmpty())
+	})
+})
@@ -16,6 +16,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -189,7 +190,7 @@ func callPlugin(setupLog logr.Logger, phase string, conf *systemd.SriovConfig, h
 		return fmt.Errorf("failed to run OnNodeStateChange to update the plugin status %v", err)
 	}
 
-	if err = configPlugin.Apply(); err != nil {
+	if err = configPlugin.Apply(context.Background()); err != nil {
 		return fmt.Errorf("failed to apply configuration: %v", err)
 	}
 	setupLog.V(0).Info("plugin call succeed")
@@ -165,7 +165,7 @@ var _ = Describe("Service", func() {
 			Name: "enp216s0f0np0",
 		}}, nil)
 		genericPlugin.EXPECT().OnNodeStateChange(newNodeStateContainsDeviceMatcher("enp216s0f0np0")).Return(true, false, nil)
-		genericPlugin.EXPECT().Apply().Return(nil)
+		genericPlugin.EXPECT().Apply(gomock.Any()).Return(nil)
 
 		Expect(runServiceCmd(&cobra.Command{}, []string{})).NotTo(HaveOccurred())
 
@@ -193,7 +193,7 @@ var _ = Describe("Service", func() {
 		}}, nil)
 
 		virtualPlugin.EXPECT().OnNodeStateChange(newNodeStateContainsDeviceMatcher("enp216s0f0np0")).Return(true, false, nil)
-		virtualPlugin.EXPECT().Apply().Return(nil)
+		virtualPlugin.EXPECT().Apply(gomock.Any()).Return(nil)
 
 		Expect(runServiceCmd(&cobra.Command{}, []string{})).NotTo(HaveOccurred())
 
@@ -218,7 +218,7 @@ var _ = Describe("Service", func() {
 			Name: "enp216s0f0np0",
 		}}, nil)
 		genericPlugin.EXPECT().OnNodeStateChange(newNodeStateContainsDeviceMatcher("enp216s0f0np0")).Return(true, false, nil)
-		genericPlugin.EXPECT().Apply().Return(testError)
+		genericPlugin.EXPECT().Apply(gomock.Any()).Return(testError)
 
 		Expect(runServiceCmd(&cobra.Command{}, []string{})).To(MatchError(ContainSubstring("test")))
 
@@ -240,7 +240,7 @@ var _ = Describe("Service", func() {
 			Name: "enp216s0f0np0",
 		}}, nil)
 		genericPlugin.EXPECT().OnNodeStateChange(newNodeStateContainsDeviceMatcher("enp216s0f0np0")).Return(true, false, nil)
-		genericPlugin.EXPECT().Apply().Return(nil)
+		genericPlugin.EXPECT().Apply(gomock.Any()).Return(nil)
 		Expect(runServiceCmd(&cobra.Command{}, []string{})).NotTo(HaveOccurred())
 		testHelpers.GinkgoAssertFileContentsEquals("/etc/sriov-operator/sriov-interface-result.yaml",
 			string(getTestResultFileContent("Succeeded", "")))
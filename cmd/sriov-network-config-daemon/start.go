@@ -21,7 +21,9 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -79,11 +81,33 @@ var (
 	}
 
 	startOpts struct {
-		kubeconfig        string
-		nodeName          string
-		systemd           bool
-		disabledPlugins   stringList
-		parallelNicConfig bool
+		kubeconfig          string
+		nodeName            string
+		systemd             bool
+		disabledPlugins     stringList
+		pluginOrder         stringList
+		parallelNicConfig   bool
+		vfConfigConcurrency int
+		vfBindStaggerDelay  time.Duration
+		mtuRetryInterval    time.Duration
+		mtuRetryCount       int
+		vfReadyPollInterval time.Duration
+		vfReadyTimeout      time.Duration
+		configRetryCount    int
+		configRetryInterval time.Duration
+		verifyConfig        bool
+		verboseDiscovery    bool
+		allowVfioNoIommu    bool
+		nodeStateAPIPort    int
+		metricsPort         int
+		hostInterfaces      stringList
+		discoveryAllowList  stringList
+		discoveryDenyList   stringList
+
+		nicConfigConcurrency            int
+		ignoreExternallyManagedMismatch bool
+		validateKernelModules           bool
+		loadMissingKernelModules        bool
 	}
 )
 
@@ -93,7 +117,48 @@ func init() {
 	startCmd.PersistentFlags().StringVar(&startOpts.nodeName, "node-name", "", "kubernetes node name daemon is managing")
 	startCmd.PersistentFlags().BoolVar(&startOpts.systemd, "use-systemd-service", false, "use config daemon in systemd mode")
 	startCmd.PersistentFlags().VarP(&startOpts.disabledPlugins, "disable-plugins", "", "comma-separated list of plugins to disable")
+	startCmd.PersistentFlags().VarP(&startOpts.pluginOrder, "plugin-order", "",
+		"comma-separated list of plugins in the order they run, the plugins not listed run after them")
 	startCmd.PersistentFlags().BoolVar(&startOpts.parallelNicConfig, "parallel-nic-config", false, "perform NIC configuration in parallel")
+	startCmd.PersistentFlags().IntVar(&startOpts.nicConfigConcurrency, "nic-config-concurrency", vars.NicConfigConcurrency,
+		"number of NICs configured in parallel when parallel-nic-config is set")
+	startCmd.PersistentFlags().IntVar(&startOpts.vfConfigConcurrency, "vf-config-concurrency", vars.VfConfigConcurrency, "number of VFs of a NIC configured in parallel")
+	startCmd.PersistentFlags().DurationVar(&startOpts.vfBindStaggerDelay, "vf-bind-stagger-delay", 0,
+		"delay between the default driver binds of the VFs of a NIC, the VFs are configured one at a time in VF index order when greater than 0")
+	startCmd.PersistentFlags().DurationVar(&startOpts.mtuRetryInterval, "mtu-retry-interval", vars.NetdevMTURetryInterval,
+		"interval between the attempts to set the MTU of a network device that is not available yet")
+	startCmd.PersistentFlags().IntVar(&startOpts.mtuRetryCount, "mtu-retry-count", vars.NetdevMTURetryCount,
+		"number of retries to set the MTU of a network device that is not available yet")
+	startCmd.PersistentFlags().DurationVar(&startOpts.vfReadyPollInterval, "vf-ready-poll-interval", vars.VfReadyPollInterval,
+		"interval between the checks of the netdev of a VF bound to its default driver")
+	startCmd.PersistentFlags().DurationVar(&startOpts.vfReadyTimeout, "vf-ready-timeout", vars.VfReadyPollTimeout,
+		"time to wait for the netdev of a VF bound to its default driver before rebinding the VF")
+	startCmd.PersistentFlags().IntVar(&startOpts.configRetryCount, "config-retry-count", vars.ConfigRetryCount,
+		"number of retries of a full SR-IOV configuration pass after a transient failure, disabled when 0")
+	startCmd.PersistentFlags().DurationVar(&startOpts.configRetryInterval, "config-retry-interval", vars.ConfigRetryInterval,
+		"initial interval between the retries of a full SR-IOV configuration pass, increased exponentially")
+	startCmd.PersistentFlags().BoolVar(&startOpts.verifyConfig, "verify-config", false,
+		"read back the live state of the configured NICs at the end of a configuration pass and fail the pass on a mismatch")
+	startCmd.PersistentFlags().BoolVar(&startOpts.verboseDiscovery, "verbose-discovery", false,
+		"log the PCI devices excluded from the SR-IOV discovery with the reason why")
+	startCmd.PersistentFlags().BoolVar(&startOpts.allowVfioNoIommu, "allow-vfio-noiommu", false,
+		"enable the unsafe no-IOMMU mode of vfio to bind devices to vfio-pci on hosts without IOMMU")
+	startCmd.PersistentFlags().BoolVar(&startOpts.validateKernelModules, "validate-kernel-modules", false,
+		"check the kernel modules required by the policies are loaded before applying the configuration")
+	startCmd.PersistentFlags().BoolVar(&startOpts.loadMissingKernelModules, "load-missing-kernel-modules", false,
+		"load the missing required kernel modules with modprobe, requires validate-kernel-modules")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeStateAPIPort, "node-state-api-port", 0,
+		"port of the read-only HTTP API serving the discovered SR-IOV state of the node, disabled when 0")
+	startCmd.PersistentFlags().IntVar(&startOpts.metricsPort, "metrics-port", 0,
+		"port serving the unauthenticated metrics of the daemon on /metrics of all the host interfaces, disabled when 0")
+	startCmd.PersistentFlags().VarP(&startOpts.hostInterfaces, "allow-host-interfaces", "",
+		"comma-separated list of PF names or PCI addresses carrying the default route of the host that can be configured")
+	startCmd.PersistentFlags().VarP(&startOpts.discoveryAllowList, "discovery-allow-list", "",
+		"comma-separated list of vendor:<id>, device:<id> or pci:<address glob> entries the NICs must match to be discovered")
+	startCmd.PersistentFlags().VarP(&startOpts.discoveryDenyList, "discovery-deny-list", "",
+		"comma-separated list of vendor:<id>, device:<id> or pci:<address glob> entries of the NICs which are never discovered")
+	startCmd.PersistentFlags().BoolVar(&startOpts.ignoreExternallyManagedMismatch, "ignore-externally-managed-mismatch", false,
+		"configure PFs even if the requested externallyManaged flag doesn't match the one their VFs were created with")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) error {
@@ -108,6 +173,68 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	vars.ParallelNicConfig = startOpts.parallelNicConfig
+	if startOpts.nicConfigConcurrency < 1 {
+		return fmt.Errorf("nic-config-concurrency must be greater than 0, got %d", startOpts.nicConfigConcurrency)
+	}
+	vars.NicConfigConcurrency = startOpts.nicConfigConcurrency
+	if startOpts.vfConfigConcurrency < 1 {
+		return fmt.Errorf("vf-config-concurrency must be greater than 0, got %d", startOpts.vfConfigConcurrency)
+	}
+	vars.VfConfigConcurrency = startOpts.vfConfigConcurrency
+	if startOpts.vfBindStaggerDelay < 0 {
+		return fmt.Errorf("vf-bind-stagger-delay must not be negative, got %s", startOpts.vfBindStaggerDelay)
+	}
+	vars.VfBindStaggerDelay = startOpts.vfBindStaggerDelay
+	if startOpts.mtuRetryInterval < 0 {
+		return fmt.Errorf("mtu-retry-interval must not be negative, got %s", startOpts.mtuRetryInterval)
+	}
+	if startOpts.mtuRetryCount < 0 {
+		return fmt.Errorf("mtu-retry-count must not be negative, got %d", startOpts.mtuRetryCount)
+	}
+	vars.NetdevMTURetryInterval = startOpts.mtuRetryInterval
+	vars.NetdevMTURetryCount = startOpts.mtuRetryCount
+	if startOpts.vfReadyPollInterval <= 0 {
+		return fmt.Errorf("vf-ready-poll-interval must be greater than 0, got %s", startOpts.vfReadyPollInterval)
+	}
+	if startOpts.vfReadyTimeout < 0 {
+		return fmt.Errorf("vf-ready-timeout must not be negative, got %s", startOpts.vfReadyTimeout)
+	}
+	vars.VfReadyPollInterval = startOpts.vfReadyPollInterval
+	vars.VfReadyPollTimeout = startOpts.vfReadyTimeout
+	if startOpts.configRetryCount < 0 {
+		return fmt.Errorf("config-retry-count must not be negative, got %d", startOpts.configRetryCount)
+	}
+	if startOpts.configRetryInterval < 0 {
+		return fmt.Errorf("config-retry-interval must not be negative, got %s", startOpts.configRetryInterval)
+	}
+	vars.ConfigRetryCount = startOpts.configRetryCount
+	vars.ConfigRetryInterval = startOpts.configRetryInterval
+	vars.VerifyConfig = startOpts.verifyConfig
+	vars.VerboseDiscovery = startOpts.verboseDiscovery
+	vars.AllowVfioNoIommu = startOpts.allowVfioNoIommu
+	if startOpts.loadMissingKernelModules && !startOpts.validateKernelModules {
+		return fmt.Errorf("load-missing-kernel-modules requires validate-kernel-modules")
+	}
+	vars.ValidateKernelModules = startOpts.validateKernelModules
+	vars.LoadMissingKernelModules = startOpts.loadMissingKernelModules
+	if startOpts.nodeStateAPIPort < 0 || startOpts.nodeStateAPIPort > 65535 {
+		return fmt.Errorf("node-state-api-port must be between 0 and 65535, got %d", startOpts.nodeStateAPIPort)
+	}
+	if startOpts.metricsPort < 0 || startOpts.metricsPort > 65535 {
+		return fmt.Errorf("metrics-port must be between 0 and 65535, got %d", startOpts.metricsPort)
+	}
+	if startOpts.metricsPort > 0 && startOpts.metricsPort == startOpts.nodeStateAPIPort {
+		return fmt.Errorf("metrics-port and node-state-api-port must be different, got %d", startOpts.metricsPort)
+	}
+	vars.HostSystemInterfacesAllowList = startOpts.hostInterfaces
+	for _, entry := range append(startOpts.discoveryAllowList, startOpts.discoveryDenyList...) {
+		if _, _, err := sriovnetworkv1.ParseDiscoveryFilterEntry(entry); err != nil {
+			return err
+		}
+	}
+	vars.DiscoveryAllowList = startOpts.discoveryAllowList
+	vars.DiscoveryDenyList = startOpts.discoveryDenyList
+	vars.IgnoreExternallyManagedMismatch = startOpts.ignoreExternallyManagedMismatch
 
 	if startOpts.nodeName == "" {
 		name, ok := os.LookupEnv("NODE_NAME")
@@ -123,10 +250,15 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("%s plugin cannot be disabled", p)
 		}
 	}
+	if err := daemon.ValidatePluginOrder(startOpts.pluginOrder, startOpts.disabledPlugins); err != nil {
+		return err
+	}
 
-	// This channel is used to ensure all spawned goroutines exit when we exit.
-	stopCh := make(chan struct{})
-	defer close(stopCh)
+	// This channel is used to ensure all spawned goroutines exit when we exit, it is also closed
+	// on SIGINT/SIGTERM to interrupt the configuration in progress before the daemon exits.
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stopCh := stopCtx.Done()
 
 	// This channel is used to signal Run() something failed and to jump ship.
 	// It's purely a chan<- in the Daemon struct for goroutines to write to, and
@@ -265,6 +397,13 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 
 	eventRecorder.SendEvent("ConfigDaemonStart", "Config Daemon starting")
 
+	// the node state API serves the interfaces recorded by the discoveries of the writer
+	var nodeStateServer *daemon.NodeStateServer
+	if startOpts.nodeStateAPIPort > 0 {
+		nodeStateServer = daemon.NewNodeStateServer(hostHelpers)
+		nodeWriter.OnDiscovery = nodeStateServer.RecordDiscovery
+	}
+
 	// block the deamon process until nodeWriter finish first its run
 	err = nodeWriter.RunOnce()
 	if err != nil {
@@ -273,6 +412,21 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 	}
 	go nodeWriter.Run(stopCh, refreshCh, syncCh)
 
+	if startOpts.nodeStateAPIPort > 0 {
+		go func() {
+			if err := nodeStateServer.Run(stopCh, startOpts.nodeStateAPIPort); err != nil {
+				setupLog.Error(err, "failed to run the node state API")
+			}
+		}()
+	}
+	if startOpts.metricsPort > 0 {
+		go func() {
+			if err := daemon.RunMetricsServer(stopCh, startOpts.metricsPort); err != nil {
+				setupLog.Error(err, "failed to run the metrics server")
+			}
+		}()
+	}
+
 	setupLog.V(0).Info("Starting SriovNetworkConfigDaemon")
 	err = daemon.New(
 		kClient,
@@ -286,6 +440,7 @@ func runStartCmd(cmd *cobra.Command, args []string) error {
 		refreshCh,
 		eventRecorder,
 		startOpts.disabledPlugins,
+		startOpts.pluginOrder,
 	).Run(stopCh, exitCh)
 	if err != nil {
 		setupLog.Error(err, "failed to run daemon")
@@ -199,6 +199,7 @@ func (r *SriovNetworkNodePolicyReconciler) SetupWithManager(mgr ctrl.Manager) er
 		For(&sriovnetworkv1.SriovNetworkNodePolicy{}).
 		Watches(&corev1.Node{}, nodeEvenHandler).
 		Watches(&sriovnetworkv1.SriovNetworkNodePolicy{}, delayedEventHandler).
+		Watches(&sriovnetworkv1.SriovNetwork{}, delayedEventHandler).
 		WatchesRawSource(&source.Channel{Source: eventChan}, delayedEventHandler).
 		Complete(r)
 }
@@ -266,6 +267,19 @@ func (r *SriovNetworkNodePolicyReconciler) syncAllSriovNetworkNodeStates(ctx con
 	if err := r.Get(ctx, types.NamespacedName{Namespace: vars.Namespace, Name: constants.ConfigMapName}, found); err != nil {
 		logger.V(1).Info("Fail to get", "ConfigMap", constants.ConfigMapName)
 	}
+	nsList := &sriovnetworkv1.SriovNetworkNodeStateList{}
+	err := r.List(ctx, nsList, &client.ListOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Fail to list SriovNetworkNodeState CRs")
+		return err
+	}
+	// the MAC pools are split between the nodes, the blocks already assigned to the nodes are kept
+	macPools := sriovnetworkv1.NewMacPoolSplit(nsList.Items)
+	// the MTU of the VFs is kept consistent with the networks consuming the resources
+	snl := &sriovnetworkv1.SriovNetworkList{}
+	if err := r.List(ctx, snl, &client.ListOptions{Namespace: vars.Namespace}); err != nil {
+		return fmt.Errorf("failed to list SriovNetworks: %v", err)
+	}
 	for _, node := range nl.Items {
 		logger.V(1).Info("Sync SriovNetworkNodeState CR", "name", node.Name)
 		ns := &sriovnetworkv1.SriovNetworkNodeState{}
@@ -273,20 +287,13 @@ func (r *SriovNetworkNodePolicyReconciler) syncAllSriovNetworkNodeStates(ctx con
 		ns.Namespace = vars.Namespace
 		j, _ := json.Marshal(ns)
 		logger.V(2).Info("SriovNetworkNodeState CR", "content", j)
-		if err := r.syncSriovNetworkNodeState(ctx, dc, npl, ns, &node); err != nil {
+		if err := r.syncSriovNetworkNodeState(ctx, dc, npl, ns, &node, macPools, snl.Items); err != nil {
 			logger.Error(err, "Fail to sync", "SriovNetworkNodeState", ns.Name)
 			return err
 		}
 	}
 	logger.V(1).Info("Remove SriovNetworkNodeState custom resource for unselected node")
-	nsList := &sriovnetworkv1.SriovNetworkNodeStateList{}
-	err := r.List(ctx, nsList, &client.ListOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			logger.Error(err, "Fail to list SriovNetworkNodeState CRs")
-			return err
-		}
-	} else {
+	if err == nil {
 		for _, ns := range nsList.Items {
 			found := false
 			for _, node := range nl.Items {
@@ -312,7 +319,9 @@ func (r *SriovNetworkNodePolicyReconciler) syncSriovNetworkNodeState(ctx context
 	dc *sriovnetworkv1.SriovOperatorConfig,
 	npl *sriovnetworkv1.SriovNetworkNodePolicyList,
 	ns *sriovnetworkv1.SriovNetworkNodeState,
-	node *corev1.Node) error {
+	node *corev1.Node,
+	macPools *sriovnetworkv1.MacPoolSplit,
+	networks []sriovnetworkv1.SriovNetwork) error {
 	logger := log.Log.WithName("syncSriovNetworkNodeState")
 	logger.V(1).Info("Start to sync SriovNetworkNodeState", "Name", ns.Name)
 
@@ -368,6 +377,14 @@ func (r *SriovNetworkNodePolicyReconciler) syncSriovNetworkNodeState(ctx context
 				ppp = p.Spec.Priority
 			}
 		}
+		if err := macPools.Assign(newVersion); err != nil {
+			return err
+		}
+
+		// keep the VF MTU consistent with the networks consuming the resources
+		for _, warning := range sriovnetworkv1.ApplyNetworksMtu(newVersion, networks) {
+			logger.Info("VF group MTU mismatch", "node", node.Name, "warning", warning)
+		}
 
 		// Note(adrianc): we check same ownerReferences since SriovNetworkNodeState
 		// was owned by a default SriovNetworkNodePolicy. if we encounter a descripancy
@@ -466,8 +483,8 @@ func createDevicePluginResource(
 	if len(p.Spec.NicSelector.PfNames) > 0 {
 		netDeviceSelectors.PfNames = append(netDeviceSelectors.PfNames, p.Spec.NicSelector.PfNames...)
 	}
-	// vfio-pci device link type is not detectable
-	if p.Spec.DeviceType != constants.DeviceTypeVfioPci {
+	// DPDK device link type is not detectable
+	if !sriovnetworkv1.StringInArray(p.Spec.DeviceType, vars.DpdkDrivers) {
 		if p.Spec.LinkType != "" {
 			linkType := constants.LinkTypeEthernet
 			if strings.EqualFold(p.Spec.LinkType, constants.LinkTypeIB) {
@@ -480,7 +497,7 @@ func createDevicePluginResource(
 		netDeviceSelectors.RootDevices = append(netDeviceSelectors.RootDevices, p.Spec.NicSelector.RootDevices...)
 	}
 	// Removed driver constraint for "netdevice" DeviceType
-	if p.Spec.DeviceType == constants.DeviceTypeVfioPci {
+	if sriovnetworkv1.StringInArray(p.Spec.DeviceType, vars.DpdkDrivers) {
 		netDeviceSelectors.Drivers = append(netDeviceSelectors.Drivers, p.Spec.DeviceType)
 	}
 	// Enable the selection of devices using NetFilter
@@ -538,8 +555,8 @@ func updateDevicePluginResource(
 	if len(p.Spec.NicSelector.PfNames) > 0 {
 		netDeviceSelectors.PfNames = sriovnetworkv1.UniqueAppend(netDeviceSelectors.PfNames, p.Spec.NicSelector.PfNames...)
 	}
-	// vfio-pci device link type is not detectable
-	if p.Spec.DeviceType != constants.DeviceTypeVfioPci {
+	// DPDK device link type is not detectable
+	if !sriovnetworkv1.StringInArray(p.Spec.DeviceType, vars.DpdkDrivers) {
 		if p.Spec.LinkType != "" {
 			linkType := constants.LinkTypeEthernet
 			if strings.EqualFold(p.Spec.LinkType, constants.LinkTypeIB) {
@@ -554,7 +571,7 @@ func updateDevicePluginResource(
 		netDeviceSelectors.RootDevices = sriovnetworkv1.UniqueAppend(netDeviceSelectors.RootDevices, p.Spec.NicSelector.RootDevices...)
 	}
 	// Removed driver constraint for "netdevice" DeviceType
-	if p.Spec.DeviceType == constants.DeviceTypeVfioPci {
+	if sriovnetworkv1.StringInArray(p.Spec.DeviceType, vars.DpdkDrivers) {
 		netDeviceSelectors.Drivers = sriovnetworkv1.UniqueAppend(netDeviceSelectors.Drivers, p.Spec.DeviceType)
 	}
 	// Enable the selection of devices using NetFilter
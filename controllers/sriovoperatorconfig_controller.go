@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 
+	admv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -201,6 +202,10 @@ func (r *SriovOperatorConfigReconciler) syncConfigDaemonSet(ctx context.Context,
 		logger.V(1).Info("DisablePlugins provided", "DisablePlugins", dc.Spec.DisablePlugins)
 		data.Data["DisablePlugins"] = strings.Join(dc.Spec.DisablePlugins.ToStringSlice(), ",")
 	}
+	if len(dc.Spec.PluginOrder) > 0 {
+		logger.V(1).Info("PluginOrder provided", "PluginOrder", dc.Spec.PluginOrder)
+		data.Data["PluginOrder"] = strings.Join(dc.Spec.PluginOrder.ToStringSlice(), ",")
+	}
 
 	objs, err := render.RenderDir(consts.ConfigDaemonPath, &data)
 	if err != nil {
@@ -297,6 +302,11 @@ func (r *SriovOperatorConfigReconciler) syncWebhookObjs(ctx context.Context, dc
 			data.Data["ExternalControlPlane"] = external
 		}
 
+		data.Data["OperatorWebhookFailurePolicy"] = string(admv1.Fail)
+		if dc.Spec.WebhookFailurePolicy != "" {
+			data.Data["OperatorWebhookFailurePolicy"] = dc.Spec.WebhookFailurePolicy
+		}
+
 		// check for ResourceInjectorMatchConditionFeatureGate feature gate
 		data.Data[consts.ResourceInjectorMatchConditionFeatureGate] = r.FeatureGate.IsEnabled(consts.ResourceInjectorMatchConditionFeatureGate)
 
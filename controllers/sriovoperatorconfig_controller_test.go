@@ -221,6 +221,54 @@ var _ = Describe("SriovOperatorConfig controller", Ordered, func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("should render the operator webhook failurePolicy", func() {
+			operatorWebhookFailurePolicyIs := func(policy admv1.FailurePolicyType) func(context.Context) (bool, error) {
+				return func(ctx context.Context) (bool, error) {
+					mutateCfg := &admv1.MutatingWebhookConfiguration{}
+					err := k8sClient.Get(ctx, types.NamespacedName{Name: "sriov-operator-webhook-config"}, mutateCfg)
+					if err != nil {
+						if errors.IsNotFound(err) {
+							return false, nil
+						}
+						return false, err
+					}
+					validateCfg := &admv1.ValidatingWebhookConfiguration{}
+					err = k8sClient.Get(ctx, types.NamespacedName{Name: "sriov-operator-webhook-config"}, validateCfg)
+					if err != nil {
+						if errors.IsNotFound(err) {
+							return false, nil
+						}
+						return false, err
+					}
+					if len(mutateCfg.Webhooks) != 1 || len(validateCfg.Webhooks) != 1 {
+						return false, nil
+					}
+					return *mutateCfg.Webhooks[0].FailurePolicy == policy && *validateCfg.Webhooks[0].FailurePolicy == policy, nil
+				}
+			}
+
+			By("checking the default failurePolicy")
+			err := wait.PollUntilContextTimeout(ctx, util.RetryInterval, util.APITimeout, true, operatorWebhookFailurePolicyIs(admv1.Fail))
+			Expect(err).NotTo(HaveOccurred())
+
+			By("set the failurePolicy to Ignore")
+			config := &sriovnetworkv1.SriovOperatorConfig{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "default"}, config)).NotTo(HaveOccurred())
+			config.Spec.WebhookFailurePolicy = string(admv1.Ignore)
+			Expect(k8sClient.Update(ctx, config)).NotTo(HaveOccurred())
+
+			err = wait.PollUntilContextTimeout(ctx, util.RetryInterval, util.APITimeout, true, operatorWebhookFailurePolicyIs(admv1.Ignore))
+			Expect(err).NotTo(HaveOccurred())
+
+			By("unset the failurePolicy")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "default"}, config)).NotTo(HaveOccurred())
+			config.Spec.WebhookFailurePolicy = ""
+			Expect(k8sClient.Update(ctx, config)).NotTo(HaveOccurred())
+
+			err = wait.PollUntilContextTimeout(ctx, util.RetryInterval, util.APITimeout, true, operatorWebhookFailurePolicyIs(admv1.Fail))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		It("should be able to update the node selector of sriov-network-config-daemon", func() {
 			By("specify the configDaemonNodeSelector")
 			config := &sriovnetworkv1.SriovOperatorConfig{}
@@ -294,6 +342,24 @@ var _ = Describe("SriovOperatorConfig controller", Ordered, func() {
 			}, util.APITimeout*10, util.RetryInterval).Should(ContainSubstring("disable-plugins=mellanox"))
 		})
 
+		It("should render plugin-order cmdline flag of sriov-network-config-daemon if pluginOrder provided in spec", func() {
+			config := &sriovnetworkv1.SriovOperatorConfig{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "default"}, config)).NotTo(HaveOccurred())
+
+			config.Spec.PluginOrder = sriovnetworkv1.PluginOrderSlice{"intel", "generic"}
+			err := k8sClient.Update(ctx, config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() string {
+				daemonSet := &appsv1.DaemonSet{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "sriov-network-config-daemon", Namespace: testNamespace}, daemonSet)
+				if err != nil {
+					return ""
+				}
+				return strings.Join(daemonSet.Spec.Template.Spec.Containers[0].Args, " ")
+			}, util.APITimeout*10, util.RetryInterval).Should(ContainSubstring("plugin-order=intel,generic"))
+		})
+
 		It("should render the resourceInjectorMatchCondition in the mutation if feature flag is enabled and block only pods with the networks annotation", func() {
 			By("set the feature flag")
 			config := &sriovnetworkv1.SriovOperatorConfig{}
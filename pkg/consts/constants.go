@@ -44,10 +44,22 @@ const (
 	LinkTypeIB  = "IB"
 	LinkTypeETH = "ETH"
 
+	RdmaSubsystemModeShared    = "shared"
+	RdmaSubsystemModeExclusive = "exclusive"
+
 	LinkAdminStateUp   = "up"
 	LinkAdminStateDown = "down"
 
+	LinkCarrierUp   = "up"
+	LinkCarrierDown = "down"
+
+	// DefaultTxQueueLen is the transmit queue length the kernel assigns to ethernet netdevs
+	DefaultTxQueueLen = 1000
+
 	UninitializedNodeGUID = "0000:0000:0000:0000"
+	// IbPkeyVfDriver is the only driver exposing the partition key table of the VFs in sysfs,
+	// the partition keys of the VFs of the other drivers, e.g. mlx5_core, are assigned by the subnet manager
+	IbPkeyVfDriver = "mlx4_core"
 
 	DeviceTypeVfioPci   = "vfio-pci"
 	DeviceTypeNetDevice = "netdevice"
@@ -59,6 +71,10 @@ const (
 
 	SriovConfBasePath          = "/etc/sriov-operator"
 	PfAppliedConfig            = SriovConfBasePath + "/pci"
+	VfGUIDConfig               = SriovConfBasePath + "/guid"
+	VfMacConfig                = SriovConfBasePath + "/mac"
+	RejectedTotalVfsConfig     = SriovConfBasePath + "/totalvfs"
+	DeviceLockPath             = SriovConfBasePath + "/lock"
 	SriovSwitchDevConfPath     = SriovConfBasePath + "/sriov_config.json"
 	SriovHostSwitchDevConfPath = Host + SriovSwitchDevConfPath
 
@@ -93,18 +109,31 @@ const (
 	SysBusPciDrivers      = SysBus + "/pci/drivers"
 	SysBusPciDriversProbe = SysBus + "/pci/drivers_probe"
 	SysClassNet           = "/sys/class/net"
+	SysClassInfiniband    = "/sys/class/infiniband"
 	ProcKernelCmdLine     = "/proc/cmdline"
+	ProcCpuInfo           = "/proc/cpuinfo"
+	ProcModules           = "/proc/modules"
+	SysModule             = "/sys/module"
+	SysKernelIommuGroups  = "/sys/kernel/iommu_groups"
+	SysVfioNoIommuMode    = "/sys/module/vfio/parameters/enable_unsafe_noiommu_mode"
 	NetClass              = 0x02
 	NumVfsFile            = "sriov_numvfs"
+	NumaNodeFile          = "numa_node"
+	VirtFnLinkPrefix      = "virtfn"
 	BusPci                = "pci"
 	BusVdpa               = "vdpa"
 
+	// DevlinkResourceOffloadVfs is the name of the devlink resource reporting how many VFs
+	// can be offloaded to the hardware by the device
+	DevlinkResourceOffloadVfs = "offload_vfs"
+
 	UdevFolder          = "/etc/udev"
 	HostUdevFolder      = Host + UdevFolder
 	UdevRulesFolder     = UdevFolder + "/rules.d"
 	HostUdevRulesFolder = Host + UdevRulesFolder
 	UdevDisableNM       = "/bindata/scripts/udev-find-sriov-pf.sh"
 	UdevRepName         = "/bindata/scripts/switchdev-vf-link-name.sh"
+	NetworkdConfFolder  = "/etc/systemd/network"
 	// nolint:goconst
 	PFNameUdevRule = `SUBSYSTEM=="net", ACTION=="add", DRIVERS=="?*", KERNELS=="%s", NAME="%s"`
 	// nolint:goconst
@@ -112,6 +141,9 @@ const (
 		`ACTION=="add|change|move", ` +
 		`ATTRS{device}=="%s", ` +
 		`IMPORT{program}="/etc/udev/disable-nm-sriov.sh $env{INTERFACE} %s"`
+	// NetworkdUnmanagedConf is the systemd-networkd configuration leaving the VFs of the PF alone,
+	// the SRIOV_PF property is set on the VFs by the NMUdevRule
+	NetworkdUnmanagedConf = "[Match]\nProperty=SRIOV_PF=%s\n\n[Link]\nUnmanaged=yes\n"
 	// nolint:goconst
 	SwitchdevUdevRule = `SUBSYSTEM=="net", ` +
 		`ACTION=="add|move", ` +
@@ -122,8 +154,17 @@ const (
 
 	KernelArgPciRealloc = "pci=realloc"
 	KernelArgIntelIommu = "intel_iommu=on"
+	KernelArgAmdIommu   = "amd_iommu=on"
 	KernelArgIommuPt    = "iommu=pt"
 
+	// keys of the hugepage kernel args
+	KernelArgDefaultHugepageSize = "default_hugepagesz"
+	KernelArgHugepageSize        = "hugepagesz"
+	KernelArgHugepages           = "hugepages"
+
+	CpuVendorIntel = "GenuineIntel"
+	CpuVendorAmd   = "AuthenticAMD"
+
 	// Feature gates
 	// ParallelNicConfigFeatureGate: allow to configure nics in parallel
 	ParallelNicConfigFeatureGate = "parallelNicConfig"
@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper"
+)
+
+const (
+	// NodeStateAPIInterfacesPath is the path serving the discovered interfaces of the node
+	NodeStateAPIInterfacesPath = "/interfaces"
+	// MetricsPath is the path serving the metrics of the config daemon in the Prometheus format
+	MetricsPath = "/metrics"
+)
+
+// InterfaceState is the state of a PF served by the node state API
+type InterfaceState struct {
+	sriovnetworkv1.InterfaceExt
+	// AppliedConfig is the last configuration applied to the PF by the daemon, nil if the PF was never configured
+	AppliedConfig *sriovnetworkv1.Interface `json:"appliedConfig,omitempty"`
+	// Drifted is true if the current state of the PF doesn't match the applied configuration
+	Drifted bool `json:"drifted"`
+	// Diff lists the reasons why the PF drifted from the applied configuration
+	Diff []string `json:"diff,omitempty"`
+}
+
+// NodeStateServer serves the discovered SR-IOV state of the node over HTTP+JSON.
+// The server is read-only, it never writes to the host or to the store. The handlers never access the host either:
+// the daemon may be in a chroot at any time, they serve the snapshot recorded after the last discovery of the daemon.
+type NodeStateServer struct {
+	hostHelper helper.HostHelpersInterface
+
+	// mu protects the snapshot of the interfaces
+	mu         sync.RWMutex
+	interfaces []InterfaceState
+	recorded   bool
+}
+
+// NewNodeStateServer Create a new NodeStateServer
+func NewNodeStateServer(hostHelper helper.HostHelpersInterface) *NodeStateServer {
+	return &NodeStateServer{
+		hostHelper: hostHelper,
+	}
+}
+
+// RecordDiscovery compares the discovered PFs with the configuration applied by the daemon and records the result
+// as the snapshot served until the next discovery. It runs from the goroutine of the discovery, the previous snapshot
+// is kept when the applied configuration can't be loaded.
+func (s *NodeStateServer) RecordDiscovery(discovered []sriovnetworkv1.InterfaceExt) {
+	interfaces, err := s.getInterfaces(discovered)
+	if err != nil {
+		log.Log.Error(err, "RecordDiscovery(): failed to record the node interfaces")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interfaces = interfaces
+	s.recorded = true
+}
+
+// Handler returns the HTTP handler of the node state API
+func (s *NodeStateServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(NodeStateAPIInterfacesPath, s.serveInterfaces)
+	return mux
+}
+
+// metricsHandler returns the HTTP handler of the metrics server, serving only the metrics of the daemon
+func metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// RunMetricsServer serves the metrics of the daemon on the given port until the stop channel is closed,
+// independently of the node state API
+func RunMetricsServer(stop <-chan struct{}, port int) error {
+	return runHTTPServer(stop, port, metricsHandler(), "metrics")
+}
+
+// Run serves the node state API on the given port until the stop channel is closed
+func (s *NodeStateServer) Run(stop <-chan struct{}, port int) error {
+	return runHTTPServer(stop, port, s.Handler(), "node state API")
+}
+
+// runHTTPServer serves the handler on the given port until the stop channel is closed
+func runHTTPServer(stop <-chan struct{}, port int, handler http.Handler, name string) error {
+	server := &http.Server{
+		Addr:              net.JoinHostPort("", strconv.Itoa(port)),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Log.Error(err, "runHTTPServer(): failed to shutdown the server", "server", name)
+		}
+	}()
+
+	log.Log.V(0).Info("runHTTPServer(): start serving", "server", name, "port", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve the %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *NodeStateServer) serveInterfaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the snapshot is replaced, never modified, by the discoveries
+	s.mu.RLock()
+	interfaces, recorded := s.interfaces, s.recorded
+	s.mu.RUnlock()
+	if !recorded {
+		http.Error(w, "the interfaces of the node were not discovered yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(interfaces); err != nil {
+		log.Log.Error(err, "serveInterfaces(): failed to encode the node interfaces")
+	}
+}
+
+// getInterfaces compares the discovered PFs with the configuration applied by the daemon
+func (s *NodeStateServer) getInterfaces(discovered []sriovnetworkv1.InterfaceExt) ([]InterfaceState, error) {
+	interfaces := make([]InterfaceState, 0, len(discovered))
+	for i := range discovered {
+		state := InterfaceState{InterfaceExt: discovered[i]}
+		applied, exist, err := s.hostHelper.LoadPfsStatus(discovered[i].PciAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load applied configuration for PF %s: %v", discovered[i].PciAddress, err)
+		}
+		if exist {
+			state.AppliedConfig = applied
+			state.Diff = sriovnetworkv1.DriftInterface(applied, &discovered[i])
+			state.Drifted = len(state.Diff) > 0
+		}
+		interfaces = append(interfaces, state)
+	}
+	return interfaces, nil
+}
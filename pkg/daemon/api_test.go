@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	mock_helper "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper/mock"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/metrics"
+)
+
+var _ = Describe("NodeStateServer", func() {
+	var (
+		hostHelper      *mock_helper.MockHostHelpersInterface
+		nodeStateServer *NodeStateServer
+		server          *httptest.Server
+	)
+
+	BeforeEach(func() {
+		hostHelper = mock_helper.NewMockHostHelpersInterface(gomock.NewController(GinkgoT()))
+		nodeStateServer = NewNodeStateServer(hostHelper)
+		server = httptest.NewServer(nodeStateServer.Handler())
+		DeferCleanup(server.Close)
+	})
+
+	It("should serve the discovered interfaces with their applied configuration", func() {
+		interfaces := []sriovnetworkv1.InterfaceExt{{
+			Name:       "eno1",
+			PciAddress: "0000:d8:00.0",
+			Driver:     "ice",
+			Mtu:        1500,
+			NumVfs:     2,
+			TotalVfs:   64,
+			VFs: []sriovnetworkv1.VirtualFunction{
+				{Name: "eno1v0", PciAddress: "0000:d8:01.0", VfID: 0, Driver: "iavf", Mtu: 1500},
+				{Name: "eno1v1", PciAddress: "0000:d8:01.1", VfID: 1, Driver: "iavf", Mtu: 1500}},
+		}, {
+			Name:       "eno2",
+			PciAddress: "0000:d8:00.1",
+			Driver:     "ice",
+			Mtu:        1500,
+			NumVfs:     3,
+			TotalVfs:   64,
+			VFs: []sriovnetworkv1.VirtualFunction{
+				{PciAddress: "0000:d8:02.0", VfID: 0, Driver: "vfio-pci"},
+				{PciAddress: "0000:d8:02.1", VfID: 1, Driver: "vfio-pci"},
+				{PciAddress: "0000:d8:02.2", VfID: 2, Driver: "vfio-pci"}},
+		}}
+		applied := &sriovnetworkv1.Interface{
+			Name:       "eno1",
+			PciAddress: "0000:d8:00.0",
+			NumVfs:     4,
+			VfGroups: []sriovnetworkv1.VfGroup{{
+				PolicyName: "policy-1", ResourceName: "resource-1", DeviceType: "netdevice", VfRange: "0-3"}},
+		}
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(applied, true, nil)
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(nil, false, nil)
+		nodeStateServer.RecordDiscovery(interfaces)
+
+		resp, err := http.Get(server.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var served []InterfaceState
+		Expect(json.NewDecoder(resp.Body).Decode(&served)).To(Succeed())
+		Expect(served).To(Equal([]InterfaceState{
+			{InterfaceExt: interfaces[0], AppliedConfig: applied, Drifted: true,
+				Diff: []string{"NumVfs needs update: desired 4, current 2"}},
+			{InterfaceExt: interfaces[1]}}))
+	})
+
+	It("should not report the externally managed PFs matching their applied configuration as drifted", func() {
+		interfaces := []sriovnetworkv1.InterfaceExt{{
+			Name:       "eno1",
+			PciAddress: "0000:d8:00.0",
+			Driver:     "ice",
+			Mtu:        1500,
+			NumVfs:     2,
+			TotalVfs:   64,
+			VFs: []sriovnetworkv1.VirtualFunction{
+				{Name: "eno1v0", PciAddress: "0000:d8:01.0", VfID: 0, Driver: "iavf", Mtu: 1500},
+				{Name: "eno1v1", PciAddress: "0000:d8:01.1", VfID: 1, Driver: "iavf", Mtu: 1500}},
+		}}
+		applied := &sriovnetworkv1.Interface{
+			Name:              "eno1",
+			PciAddress:        "0000:d8:00.0",
+			NumVfs:            2,
+			ExternallyManaged: true,
+			VfGroups: []sriovnetworkv1.VfGroup{{
+				PolicyName: "policy-1", ResourceName: "resource-1", DeviceType: "netdevice", VfRange: "0-1"}},
+		}
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(applied, true, nil)
+		nodeStateServer.RecordDiscovery(interfaces)
+
+		resp, err := http.Get(server.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var served []InterfaceState
+		Expect(json.NewDecoder(resp.Body).Decode(&served)).To(Succeed())
+		Expect(served).To(Equal([]InterfaceState{{InterfaceExt: interfaces[0], AppliedConfig: applied}}))
+	})
+
+	It("should flatten the interface fields in the served JSON", func() {
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+			Name: "eno1", PciAddress: "0000:d8:00.0", NumVfs: 1}, true, nil)
+		nodeStateServer.RecordDiscovery([]sriovnetworkv1.InterfaceExt{{
+			Name: "eno1", PciAddress: "0000:d8:00.0", NumVfs: 1, TotalVfs: 8,
+			VFs: []sriovnetworkv1.VirtualFunction{{PciAddress: "0000:d8:01.0", VfID: 0, Driver: "iavf"}},
+		}})
+
+		resp, err := http.Get(server.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		var served []map[string]interface{}
+		Expect(json.NewDecoder(resp.Body).Decode(&served)).To(Succeed())
+		Expect(served).To(HaveLen(1))
+		Expect(served[0]).To(HaveKeyWithValue("pciAddress", "0000:d8:00.0"))
+		Expect(served[0]).To(HaveKeyWithValue("numVfs", BeNumerically("==", 1)))
+		Expect(served[0]).To(HaveKeyWithValue("drifted", false))
+		Expect(served[0]).To(HaveKeyWithValue("appliedConfig", HaveKeyWithValue("pciAddress", "0000:d8:00.0")))
+		Expect(served[0]).To(HaveKeyWithValue("Vfs", HaveLen(1)))
+	})
+
+	It("should be unavailable until the interfaces are discovered", func() {
+		resp, err := http.Get(server.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should keep the previous snapshot when the applied configuration can't be loaded", func() {
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil)
+		nodeStateServer.RecordDiscovery([]sriovnetworkv1.InterfaceExt{{Name: "eno1", PciAddress: "0000:d8:00.0"}})
+		hostHelper.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, errors.New("test"))
+		nodeStateServer.RecordDiscovery([]sriovnetworkv1.InterfaceExt{{Name: "eno1", PciAddress: "0000:d8:00.0", NumVfs: 4}})
+
+		resp, err := http.Get(server.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		var served []InterfaceState
+		Expect(json.NewDecoder(resp.Body).Decode(&served)).To(Succeed())
+		Expect(served).To(Equal([]InterfaceState{{InterfaceExt: sriovnetworkv1.InterfaceExt{Name: "eno1", PciAddress: "0000:d8:00.0"}}}))
+	})
+
+	It("should serve the metrics of the daemon on the metrics server only", func() {
+		metrics.ObservePfConfig("0000:d8:00.0", "8086", 3*time.Second, errors.New("test"))
+		metrics.ObserveNodeStateSync(time.Second, nil)
+		metricsServer := httptest.NewServer(metricsHandler())
+		DeferCleanup(metricsServer.Close)
+
+		resp, err := http.Get(metricsServer.URL + MetricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring(`sriov_config_daemon_pf_config_duration_seconds_count{pf="0000:d8:00.0",vendor="8086"}`))
+		Expect(string(body)).To(ContainSubstring(`sriov_config_daemon_pf_config_failures_total{pf="0000:d8:00.0",vendor="8086"}`))
+		Expect(string(body)).To(ContainSubstring(`sriov_config_daemon_node_state_syncs_total{result="success"}`))
+		Expect(string(body)).To(ContainSubstring(`sriov_config_daemon_node_state_sync_duration_seconds_count`))
+
+		resp, err = http.Get(metricsServer.URL + NodeStateAPIInterfacesPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+		resp, err = http.Get(server.URL + MetricsPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should reject write requests", func() {
+		resp, err := http.Post(server.URL+NodeStateAPIInterfacesPath, "application/json", nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -25,6 +25,7 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper"
 	snolog "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/log"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/metrics"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/platforms"
 	plugin "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/plugins"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/systemd"
@@ -59,6 +60,9 @@ type Daemon struct {
 	// list of disabled plugins
 	disabledPlugins []string
 
+	// order in which the plugins run, the plugins not listed run after the listed ones
+	pluginOrder []string
+
 	loadedPlugins map[string]plugin.VendorPlugin
 
 	HostHelpers helper.HostHelpersInterface
@@ -77,6 +81,10 @@ type Daemon struct {
 
 	mu *sync.Mutex
 
+	// applyMu is held while the plugins are applied, on stop the daemon waits for it so the
+	// interrupted configuration is rolled back before exiting
+	applyMu sync.Mutex
+
 	disableDrain bool
 
 	workqueue workqueue.RateLimitingInterface
@@ -96,6 +104,7 @@ func New(
 	refreshCh chan<- Message,
 	er *EventRecorder,
 	disabledPlugins []string,
+	pluginOrder []string,
 ) *Daemon {
 	return &Daemon{
 		client:           client,
@@ -114,6 +123,7 @@ func New(
 			workqueue.NewItemExponentialFailureRateLimiter(1*time.Second, maxUpdateBackoff)), "SriovNetworkNodeState"),
 		eventRecorder:   er,
 		disabledPlugins: disabledPlugins,
+		pluginOrder:     pluginOrder,
 	}
 }
 
@@ -201,7 +211,9 @@ func (dn *Daemon) Run(stopCh <-chan struct{}, exitCh <-chan error) error {
 	for {
 		select {
 		case <-stopCh:
-			log.Log.V(0).Info("Run(): stop daemon")
+			log.Log.V(0).Info("Run(): stop daemon, waiting for the configuration in progress to be interrupted")
+			dn.applyMu.Lock()
+			defer dn.applyMu.Unlock()
 			return nil
 		case err, more := <-exitCh:
 			log.Log.Error(err, "got an error")
@@ -256,7 +268,9 @@ func (dn *Daemon) processNextWorkItem() bool {
 			return nil
 		}
 
+		start := time.Now()
 		err := dn.nodeStateSyncHandler()
+		metrics.ObserveNodeStateSync(time.Since(start), err)
 		if err != nil {
 			// Ereport error message, and put the item back to work queue for retry.
 			dn.refreshCh <- Message{
@@ -398,7 +412,8 @@ func (dn *Daemon) nodeStateSyncHandler() error {
 	reqDrain := false
 
 	// check if any of the plugins required to drain or reboot the node
-	for k, p := range dn.loadedPlugins {
+	for _, k := range orderPlugins(dn.loadedPlugins, dn.pluginOrder) {
+		p := dn.loadedPlugins[k]
 		d, r := false, false
 		if dn.currentNodeState.GetName() == "" {
 			log.Log.V(0).Info("nodeStateSyncHandler(): calling OnNodeStateChange for a new node state")
@@ -464,42 +479,9 @@ func (dn *Daemon) nodeStateSyncHandler() error {
 		}
 	}
 
-	// apply the vendor plugins after we are done with drain if needed
-	for k, p := range dn.loadedPlugins {
-		// Skip both the general and virtual plugin apply them last
-		if k != GenericPluginName && k != VirtualPluginName {
-			err := p.Apply()
-			if err != nil {
-				log.Log.Error(err, "nodeStateSyncHandler(): plugin Apply failed", "plugin-name", k)
-				return err
-			}
-		}
-	}
-
-	// if we don't need to reboot, or we are not doing the configuration in systemd
-	// we apply the generic plugin
-	if !reqReboot && !vars.UsingSystemdMode {
-		// For BareMetal machines apply the generic plugin
-		selectedPlugin, ok := dn.loadedPlugins[GenericPluginName]
-		if ok {
-			// Apply generic plugin last
-			err = selectedPlugin.Apply()
-			if err != nil {
-				log.Log.Error(err, "nodeStateSyncHandler(): generic plugin fail to apply")
-				return err
-			}
-		}
-
-		// For Virtual machines apply the virtual plugin
-		selectedPlugin, ok = dn.loadedPlugins[VirtualPluginName]
-		if ok {
-			// Apply virtual plugin last
-			err = selectedPlugin.Apply()
-			if err != nil {
-				log.Log.Error(err, "nodeStateSyncHandler(): virtual plugin failed to apply")
-				return err
-			}
-		}
+	// apply the plugins after we are done with drain if needed
+	if err := dn.applyPlugins(reqReboot); err != nil {
+		return err
 	}
 
 	if reqReboot {
@@ -548,6 +530,27 @@ func (dn *Daemon) nodeStateSyncHandler() error {
 	return nil
 }
 
+// applyPlugins applies the loaded plugins in the plugin order, the generic and virtual plugins
+// run last unless the plugin order lists them
+func (dn *Daemon) applyPlugins(reqReboot bool) error {
+	dn.applyMu.Lock()
+	defer dn.applyMu.Unlock()
+	// the configuration in progress is interrupted when the daemon stops
+	ctx := wait.ContextForChannel(dn.stopCh)
+	for _, k := range orderPlugins(dn.loadedPlugins, dn.pluginOrder) {
+		// if we need to reboot, or we are doing the configuration in systemd
+		// we don't apply the generic and virtual plugins
+		if (k == GenericPluginName || k == VirtualPluginName) && (reqReboot || vars.UsingSystemdMode) {
+			continue
+		}
+		if err := dn.loadedPlugins[k].Apply(ctx); err != nil {
+			log.Log.Error(err, "nodeStateSyncHandler(): plugin Apply failed", "plugin-name", k)
+			return err
+		}
+	}
+	return nil
+}
+
 func (dn *Daemon) shouldSkipReconciliation(latestState *sriovnetworkv1.SriovNetworkNodeState) (bool, error) {
 	log.Log.V(0).Info("shouldSkipReconciliation()")
 	var err error
@@ -163,6 +163,7 @@ var _ = Describe("Config Daemon", func() {
 			refreshCh,
 			er,
 			nil,
+			nil,
 		)
 
 		sut.loadedPlugins = map[string]plugin.VendorPlugin{generic.PluginName: &fake.FakePlugin{PluginName: "fake"}}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"fmt"
+	"sort"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -105,6 +106,63 @@ func loadVendorPlugins(ns *sriovnetworkv1.SriovNetworkNodeState, helpers helper.
 	return vendorPlugins, nil
 }
 
+// ValidatePluginOrder checks that the plugins of the plugin order are known, listed once and not disabled
+func ValidatePluginOrder(pluginOrder, disabledPlugins []string) error {
+	knownPlugins := map[string]struct{}{
+		GenericPluginName:         {},
+		VirtualPluginName:         {},
+		k8splugin.PluginName:      {},
+		mellanoxplugin.PluginName: {},
+		intelplugin.PluginName:    {},
+	}
+	listed := map[string]struct{}{}
+	for _, p := range pluginOrder {
+		if _, ok := knownPlugins[p]; !ok {
+			return fmt.Errorf("unknown plugin %s in plugin order", p)
+		}
+		if _, ok := listed[p]; ok {
+			return fmt.Errorf("plugin %s is listed more than once in plugin order", p)
+		}
+		listed[p] = struct{}{}
+		for _, d := range disabledPlugins {
+			if d == p {
+				return fmt.Errorf("plugin %s is both disabled and listed in plugin order", p)
+			}
+		}
+	}
+	return nil
+}
+
+// orderPlugins returns the names of the loaded plugins in the order they run: the plugins listed in
+// the plugin order first, then the other vendor plugins by name, then the generic and virtual plugins
+func orderPlugins(loadedPlugins map[string]plugin.VendorPlugin, pluginOrder []string) []string {
+	ordered := make([]string, 0, len(loadedPlugins))
+	listed := map[string]struct{}{}
+	for _, p := range pluginOrder {
+		if _, ok := loadedPlugins[p]; ok {
+			ordered = append(ordered, p)
+			listed[p] = struct{}{}
+		}
+	}
+	var others []string
+	for p := range loadedPlugins {
+		if _, ok := listed[p]; !ok && p != GenericPluginName && p != VirtualPluginName {
+			others = append(others, p)
+		}
+	}
+	sort.Strings(others)
+	ordered = append(ordered, others...)
+	for _, p := range []string{GenericPluginName, VirtualPluginName} {
+		if _, ok := listed[p]; ok {
+			continue
+		}
+		if _, ok := loadedPlugins[p]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered
+}
+
 func isPluginDisabled(pluginName string, disabledPlugins []string) bool {
 	for _, p := range disabledPlugins {
 		if p == pluginName {
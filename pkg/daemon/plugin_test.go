@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"context"
+
 	gomock "github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -133,4 +135,54 @@ var _ = Describe("config daemon plugin loading tests", func() {
 			validateVendorPlugins(vendorPlugins, []string{"intel", "k8s", "mellanox"})
 		})
 	})
+
+	Context("plugin order", func() {
+		var (
+			applied []string
+			dn      *Daemon
+		)
+
+		BeforeEach(func() {
+			applied = nil
+			dn = &Daemon{loadedPlugins: map[string]plugin.VendorPlugin{}}
+			for _, name := range []string{"generic", "k8s", "mellanox", "intel"} {
+				dn.loadedPlugins[name] = &recordingPlugin{FakePlugin: fakePlugin.FakePlugin{PluginName: name}, applied: &applied}
+			}
+		})
+
+		It("applies the vendor plugins by name then the generic plugin by default", func() {
+			Expect(dn.applyPlugins(false)).To(Succeed())
+			Expect(applied).To(Equal([]string{"intel", "k8s", "mellanox", "generic"}))
+		})
+
+		It("applies the plugins in the specified order", func() {
+			dn.pluginOrder = []string{"mellanox", "generic", "virtual"}
+			Expect(dn.applyPlugins(false)).To(Succeed())
+			Expect(applied).To(Equal([]string{"mellanox", "generic", "intel", "k8s"}))
+		})
+
+		It("doesn't apply the generic plugin when a reboot is required", func() {
+			dn.pluginOrder = []string{"generic", "mellanox"}
+			Expect(dn.applyPlugins(true)).To(Succeed())
+			Expect(applied).To(Equal([]string{"mellanox", "intel", "k8s"}))
+		})
+
+		It("validates the plugin order", func() {
+			Expect(ValidatePluginOrder([]string{"mellanox", "generic"}, nil)).To(Succeed())
+			Expect(ValidatePluginOrder([]string{"firmware"}, nil)).To(MatchError(ContainSubstring("unknown plugin firmware")))
+			Expect(ValidatePluginOrder([]string{"generic", "generic"}, nil)).To(MatchError(ContainSubstring("more than once")))
+			Expect(ValidatePluginOrder([]string{"mellanox"}, []string{"mellanox"})).To(MatchError(ContainSubstring("both disabled")))
+		})
+	})
 })
+
+// recordingPlugin records the order in which the plugins are applied
+type recordingPlugin struct {
+	fakePlugin.FakePlugin
+	applied *[]string
+}
+
+func (r *recordingPlugin) Apply(_ context.Context) error {
+	*r.applied = append(*r.applied, r.PluginName)
+	return nil
+}
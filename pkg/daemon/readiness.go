@@ -0,0 +1,38 @@
+package daemon
+
+import (
+	"fmt"
+	"reflect"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store"
+)
+
+// NodeSriovReady returns true if every PF requested in the node state spec is configured
+// and its current state didn't drift from the applied configuration.
+// The returned reasons describe why the PFs are not ready.
+// The node state status has no conditions yet, so setting a node-level readiness condition
+// from it is left to the consumers of the helper.
+func NodeSriovReady(state *sriovnetworkv1.SriovNetworkNodeState, storeManager store.ManagerInterface) (bool, []string, error) {
+	reasons := []string{}
+	for i := range state.Spec.Interfaces {
+		iface := &state.Spec.Interfaces[i]
+		ifaceStatus := state.GetInterfaceStateByPciAddress(iface.PciAddress)
+		if ifaceStatus == nil {
+			reasons = append(reasons, fmt.Sprintf("PF %s is not discovered on the node", iface.PciAddress))
+			continue
+		}
+		applied, exist, err := storeManager.LoadPfsStatus(iface.PciAddress)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to load applied configuration for PF %s: %v", iface.PciAddress, err)
+		}
+		if !exist || !reflect.DeepEqual(applied, iface) {
+			reasons = append(reasons, fmt.Sprintf("PF %s configuration is pending", iface.PciAddress))
+			continue
+		}
+		if drift := sriovnetworkv1.DriftInterface(iface, ifaceStatus); len(drift) > 0 {
+			reasons = append(reasons, fmt.Sprintf("PF %s drifted from the applied configuration", iface.PciAddress))
+		}
+	}
+	return len(reasons) == 0, reasons, nil
+}
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	mock_store "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store/mock"
+)
+
+var _ = Describe("NodeSriovReady", func() {
+	var (
+		testCtrl         *gomock.Controller
+		storeManagerMock *mock_store.MockManagerInterface
+		state            *sriovnetworkv1.SriovNetworkNodeState
+	)
+
+	BeforeEach(func() {
+		testCtrl = gomock.NewController(GinkgoT())
+		storeManagerMock = mock_store.NewMockManagerInterface(testCtrl)
+		state = &sriovnetworkv1.SriovNetworkNodeState{
+			Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
+				Interfaces: sriovnetworkv1.Interfaces{
+					{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 2},
+					{PciAddress: "0000:d8:00.1", Name: "enp216s0f1np1", NumVfs: 4},
+				},
+			},
+			Status: sriovnetworkv1.SriovNetworkNodeStateStatus{
+				Interfaces: sriovnetworkv1.InterfaceExts{
+					{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 2},
+					{PciAddress: "0000:d8:00.1", Name: "enp216s0f1np1", NumVfs: 4},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		testCtrl.Finish()
+	})
+
+	It("all PFs ready", func() {
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(state.Spec.Interfaces[0].DeepCopy(), true, nil)
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(state.Spec.Interfaces[1].DeepCopy(), true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeTrue())
+		Expect(reasons).To(BeEmpty())
+	})
+
+	It("one PF pending", func() {
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(state.Spec.Interfaces[0].DeepCopy(), true, nil)
+		applied := state.Spec.Interfaces[1].DeepCopy()
+		applied.NumVfs = 2
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(applied, true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(reasons).To(ConsistOf("PF 0000:d8:00.1 configuration is pending"))
+	})
+
+	It("one PF never configured", func() {
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil)
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(state.Spec.Interfaces[1].DeepCopy(), true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(reasons).To(ConsistOf("PF 0000:d8:00.0 configuration is pending"))
+	})
+
+	It("one PF drifted", func() {
+		state.Status.Interfaces[0].NumVfs = 0
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(state.Spec.Interfaces[0].DeepCopy(), true, nil)
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(state.Spec.Interfaces[1].DeepCopy(), true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(reasons).To(ConsistOf("PF 0000:d8:00.0 drifted from the applied configuration"))
+	})
+
+	It("externally managed PF ready", func() {
+		state.Spec.Interfaces[0].ExternallyManaged = true
+		state.Spec.Interfaces[0].VfGroups = []sriovnetworkv1.VfGroup{
+			{ResourceName: "resource", PolicyName: "policy", VfRange: "0-1", DeviceType: "netdevice"}}
+		state.Status.Interfaces[0].VFs = []sriovnetworkv1.VirtualFunction{
+			{VfID: 0, PciAddress: "0000:d8:00.2", Driver: "mlx5_core"},
+			{VfID: 1, PciAddress: "0000:d8:00.3", Driver: "mlx5_core"}}
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(state.Spec.Interfaces[0].DeepCopy(), true, nil)
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(state.Spec.Interfaces[1].DeepCopy(), true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeTrue())
+		Expect(reasons).To(BeEmpty())
+	})
+
+	It("PF not discovered", func() {
+		state.Status.Interfaces = state.Status.Interfaces[1:]
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(state.Spec.Interfaces[1].DeepCopy(), true, nil)
+		ready, reasons, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(reasons).To(ConsistOf("PF 0000:d8:00.0 is not discovered on the node"))
+	})
+
+	It("fail to load applied configuration", func() {
+		storeManagerMock.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, fmt.Errorf("test"))
+		_, _, err := NodeSriovReady(state, storeManagerMock)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"time"
@@ -34,6 +35,9 @@ type NodeStateStatusWriter struct {
 	platformHelper     platforms.Interface
 	hostHelper         helper.HostHelpersInterface
 	eventRecorder      *EventRecorder
+
+	// OnDiscovery is called with the discovered interfaces after each successful discovery, if set
+	OnDiscovery func([]sriovnetworkv1.InterfaceExt)
 }
 
 // NewNodeStateStatusWriter Create a new NodeStateStatusWriter
@@ -128,6 +132,9 @@ func (w *NodeStateStatusWriter) pollNicStatus() error {
 		return err
 	}
 	w.status.Interfaces = iface
+	if w.OnDiscovery != nil {
+		w.OnDiscovery(iface)
+	}
 
 	return nil
 }
@@ -142,6 +149,10 @@ func (w *NodeStateStatusWriter) updateNodeStateStatusRetry(f func(*sriovnetworkv
 			return getErr
 		}
 		oldStatus = n.Status.SyncStatus
+		oldHash, err := hashNodeStateStatus(&n.Status)
+		if err != nil {
+			return err
+		}
 
 		// Call the status modifier.
 		f(n)
@@ -149,7 +160,16 @@ func (w *NodeStateStatusWriter) updateNodeStateStatusRetry(f func(*sriovnetworkv
 		newStatus = n.Status.SyncStatus
 		lastError = n.Status.LastSyncError
 
-		var err error
+		newHash, err := hashNodeStateStatus(&n.Status)
+		if err != nil {
+			return err
+		}
+		if newHash == oldHash {
+			log.Log.V(2).Info("updateNodeStateStatusRetry(): status unchanged, skip update")
+			nodeState = n
+			return nil
+		}
+
 		nodeState, err = w.client.SriovnetworkV1().SriovNetworkNodeStates(vars.Namespace).UpdateStatus(context.Background(), n, metav1.UpdateOptions{})
 		if err != nil {
 			log.Log.V(0).Error(err, "updateNodeStateStatusRetry(): fail to update the node status")
@@ -168,7 +188,7 @@ func (w *NodeStateStatusWriter) updateNodeStateStatusRetry(f func(*sriovnetworkv
 
 func (w *NodeStateStatusWriter) setNodeStateStatus(msg Message) (*sriovnetworkv1.SriovNetworkNodeState, error) {
 	nodeState, err := w.updateNodeStateStatusRetry(func(nodeState *sriovnetworkv1.SriovNetworkNodeState) {
-		nodeState.Status.Interfaces = w.status.Interfaces
+		nodeState.Status.Interfaces = interfacesWithSkippedVfs(nodeState.Spec.Interfaces, w.status.Interfaces)
 		if msg.lastSyncError != "" || msg.syncStatus == consts.SyncStatusSucceeded {
 			// clear lastSyncError when sync Succeeded
 			nodeState.Status.LastSyncError = msg.lastSyncError
@@ -185,6 +205,37 @@ func (w *NodeStateStatusWriter) setNodeStateStatus(msg Message) (*sriovnetworkv1
 	return nodeState, nil
 }
 
+// interfacesWithSkippedVfs returns a copy of the discovered interfaces reporting the VFs requested for
+// the externally managed PFs in best effort mode which are not available
+func interfacesWithSkippedVfs(specs sriovnetworkv1.Interfaces, ifaces []sriovnetworkv1.InterfaceExt) []sriovnetworkv1.InterfaceExt {
+	if ifaces == nil {
+		return nil
+	}
+	result := make([]sriovnetworkv1.InterfaceExt, len(ifaces))
+	for i := range ifaces {
+		result[i] = ifaces[i]
+		result[i].SkippedVfs = 0
+		for j := range specs {
+			if sriovnetworkv1.PciAddressEqual(specs[j].PciAddress, ifaces[i].PciAddress) {
+				result[i].SkippedVfs = sriovnetworkv1.BestEffortSkippedVfs(&specs[j], &ifaces[i])
+				break
+			}
+		}
+	}
+	return result
+}
+
+// hashNodeStateStatus returns a hash of the node state status used to detect status changes
+func hashNodeStateStatus(status *sriovnetworkv1.SriovNetworkNodeStateStatus) (uint64, error) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64(), nil
+}
+
 // recordStatusChangeEvent sends event in case oldStatus differs from newStatus
 func (w *NodeStateStatusWriter) recordStatusChangeEvent(oldStatus, newStatus, lastError string) {
 	if oldStatus != newStatus {
@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	snclientset "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/client/clientset/versioned/fake"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	mock_helper "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper/mock"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+)
+
+var _ = Describe("NodeStateStatusWriter", func() {
+	var (
+		client     *snclientset.Clientset
+		hostHelper *mock_helper.MockHostHelpersInterface
+		writer     *NodeStateStatusWriter
+		interfaces []sriovnetworkv1.InterfaceExt
+	)
+
+	statusUpdates := func() []k8stesting.UpdateAction {
+		var updates []k8stesting.UpdateAction
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+				updates = append(updates, action.(k8stesting.UpdateAction))
+			}
+		}
+		return updates
+	}
+
+	BeforeEach(func() {
+		vars.NodeName = "test-node"
+		vars.Namespace = "sriov-network-operator"
+		vars.PlatformType = consts.Baremetal
+
+		interfaces = []sriovnetworkv1.InterfaceExt{{Name: "eno1", PciAddress: "0000:d8:00.0", NumVfs: 2}}
+		client = snclientset.NewSimpleClientset(&sriovnetworkv1.SriovNetworkNodeState{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-node", Namespace: vars.Namespace},
+			Status: sriovnetworkv1.SriovNetworkNodeStateStatus{
+				Interfaces: interfaces,
+				SyncStatus: consts.SyncStatusSucceeded,
+			},
+		})
+		hostHelper = mock_helper.NewMockHostHelpersInterface(gomock.NewController(GinkgoT()))
+		hostHelper.EXPECT().DiscoverSriovDevices(hostHelper).Return(interfaces, nil).AnyTimes()
+		writer = NewNodeStateStatusWriter(client, func() {}, NewEventRecorder(client, fakek8s.NewSimpleClientset()),
+			hostHelper, nil)
+	})
+
+	It("should not write the status when it is unchanged", func() {
+		Expect(writer.pollNicStatus()).To(Succeed())
+		_, err := writer.setNodeStateStatus(Message{syncStatus: consts.SyncStatusSucceeded})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusUpdates()).To(BeEmpty())
+	})
+
+	It("should pass the discovered interfaces to the discovery callback", func() {
+		var discovered []sriovnetworkv1.InterfaceExt
+		writer.OnDiscovery = func(ifaces []sriovnetworkv1.InterfaceExt) {
+			discovered = ifaces
+		}
+		Expect(writer.pollNicStatus()).To(Succeed())
+		Expect(discovered).To(Equal(interfaces))
+	})
+
+	It("should write the status when it changed", func() {
+		Expect(writer.pollNicStatus()).To(Succeed())
+		_, err := writer.setNodeStateStatus(Message{syncStatus: consts.SyncStatusInProgress})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusUpdates()).To(HaveLen(1))
+	})
+
+	It("should report the VFs skipped by the externally managed PFs in best effort mode", func() {
+		ns, err := client.SriovnetworkV1().SriovNetworkNodeStates(vars.Namespace).Get(context.Background(), "test-node", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		ns.Spec.Interfaces = sriovnetworkv1.Interfaces{{PciAddress: "0000:d8:00.0", NumVfs: 8,
+			ExternallyManaged: true, ExternallyManagedBestEffort: true}}
+		_, err = client.SriovnetworkV1().SriovNetworkNodeStates(vars.Namespace).Update(context.Background(), ns, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(writer.pollNicStatus()).To(Succeed())
+		ns, err = writer.setNodeStateStatus(Message{syncStatus: consts.SyncStatusSucceeded})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Status.Interfaces[0].SkippedVfs).To(Equal(6))
+		Expect(interfaces[0].SkippedVfs).To(BeZero())
+	})
+
+	It("should match the externally managed PFs whatever the notation of their PCI address", func() {
+		ns, err := client.SriovnetworkV1().SriovNetworkNodeStates(vars.Namespace).Get(context.Background(), "test-node", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		ns.Spec.Interfaces = sriovnetworkv1.Interfaces{{PciAddress: "0000:D8:00.0", NumVfs: 8,
+			ExternallyManaged: true, ExternallyManagedBestEffort: true}}
+		_, err = client.SriovnetworkV1().SriovNetworkNodeStates(vars.Namespace).Update(context.Background(), ns, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(writer.pollNicStatus()).To(Succeed())
+		ns, err = writer.setNodeStateStatus(Message{syncStatus: consts.SyncStatusSucceeded})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Status.Interfaces[0].SkippedVfs).To(Equal(6))
+	})
+
+	It("should write the status only for the refreshes changing it", func() {
+		stopCh := make(chan struct{})
+		refreshCh := make(chan Message)
+		syncCh := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			Expect(writer.Run(stopCh, refreshCh, syncCh)).To(Succeed())
+		}()
+		DeferCleanup(func() {
+			close(stopCh)
+			Eventually(done).Should(BeClosed())
+		})
+
+		// refresh the status the way the daemon does, waiting for each refresh to be acknowledged
+		refresh := func(msg Message) {
+			refreshCh <- msg
+			Eventually(syncCh, "1s").Should(Receive())
+		}
+
+		refresh(Message{syncStatus: consts.SyncStatusSucceeded})
+		Expect(statusUpdates()).To(BeEmpty())
+		refresh(Message{syncStatus: consts.SyncStatusInProgress})
+		Expect(statusUpdates()).To(HaveLen(1))
+		refresh(Message{syncStatus: consts.SyncStatusInProgress})
+		Expect(statusUpdates()).To(HaveLen(1))
+		refresh(Message{syncStatus: consts.SyncStatusFailed, lastSyncError: "test"})
+		Expect(statusUpdates()).To(HaveLen(2))
+		ns := statusUpdates()[1].GetObject().(*sriovnetworkv1.SriovNetworkNodeState)
+		Expect(ns.Status.SyncStatus).To(Equal(consts.SyncStatusFailed))
+		Expect(ns.Status.LastSyncError).To(Equal("test"))
+	})
+})
@@ -0,0 +1,27 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+)
+
+// DumpNodeState discovers the SR-IOV devices of the host, together with their VFs, and returns them as indented JSON.
+// The info stored on the host is only read, nothing is written on the host.
+// When withUnsupported is true the devices missing from the list of supported NICs are reported too.
+func DumpNodeState(withUnsupported bool) ([]byte, error) {
+	origDevMode := vars.DevMode
+	vars.DevMode = withUnsupported
+	defer func() { vars.DevMode = origDevMode }()
+
+	hostManager := host.NewHostManager(utils.New())
+	ifaces, err := hostManager.DiscoverSriovDevices(store.NewReadOnlyManager())
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the SR-IOV devices: %v", err)
+	}
+	return json.MarshalIndent(ifaces, "", "  ")
+}
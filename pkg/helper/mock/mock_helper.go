@@ -5,7 +5,10 @@
 package mock_helper
 
 import (
+	context "context"
+	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	v1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
@@ -122,6 +125,20 @@ func (mr *MockHostHelpersInterfaceMockRecorder) BindDriverByBusAndDevice(bus, de
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindDriverByBusAndDevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).BindDriverByBusAndDevice), bus, device, driver)
 }
 
+// CheckSriovPrerequisites mocks base method.
+func (m *MockHostHelpersInterface) CheckSriovPrerequisites(pciAddr string) []types.PrereqResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSriovPrerequisites", pciAddr)
+	ret0, _ := ret[0].([]types.PrereqResult)
+	return ret0
+}
+
+// CheckSriovPrerequisites indicates an expected call of CheckSriovPrerequisites.
+func (mr *MockHostHelpersInterfaceMockRecorder) CheckSriovPrerequisites(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSriovPrerequisites", reflect.TypeOf((*MockHostHelpersInterface)(nil).CheckSriovPrerequisites), pciAddr)
+}
+
 // Chroot mocks base method.
 func (m *MockHostHelpersInterface) Chroot(arg0 string) (func() error, error) {
 	m.ctrl.T.Helper()
@@ -181,17 +198,32 @@ func (mr *MockHostHelpersInterfaceMockRecorder) ConfigSriovDeviceVirtual(iface i
 }
 
 // ConfigSriovInterfaces mocks base method.
-func (m *MockHostHelpersInterface) ConfigSriovInterfaces(storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt, skipVFConfiguration bool) error {
+func (m *MockHostHelpersInterface) ConfigSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt, skipVFConfiguration bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ConfigSriovInterfaces", storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+	ret := m.ctrl.Call(m, "ConfigSriovInterfaces", ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ConfigSriovInterfaces indicates an expected call of ConfigSriovInterfaces.
-func (mr *MockHostHelpersInterfaceMockRecorder) ConfigSriovInterfaces(storeManager, interfaces, ifaceStatuses, skipVFConfiguration interface{}) *gomock.Call {
+func (mr *MockHostHelpersInterfaceMockRecorder) ConfigSriovInterfaces(ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfaces", reflect.TypeOf((*MockHostHelpersInterface)(nil).ConfigSriovInterfaces), ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+}
+
+// ConfigSriovInterfacesDryRun mocks base method.
+func (m *MockHostHelpersInterface) ConfigSriovInterfacesDryRun(storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt) ([]types.PlannedChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigSriovInterfacesDryRun", storeManager, interfaces, ifaceStatuses)
+	ret0, _ := ret[0].([]types.PlannedChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigSriovInterfacesDryRun indicates an expected call of ConfigSriovInterfacesDryRun.
+func (mr *MockHostHelpersInterfaceMockRecorder) ConfigSriovInterfacesDryRun(storeManager, interfaces, ifaceStatuses interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfaces", reflect.TypeOf((*MockHostHelpersInterface)(nil).ConfigSriovInterfaces), storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfacesDryRun", reflect.TypeOf((*MockHostHelpersInterface)(nil).ConfigSriovInterfacesDryRun), storeManager, interfaces, ifaceStatuses)
 }
 
 // CreateVDPADevice mocks base method.
@@ -222,6 +254,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) DeleteVDPADevice(pciAddr interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVDPADevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).DeleteVDPADevice), pciAddr)
 }
 
+// DiscoverSriovDevice mocks base method.
+func (m *MockHostHelpersInterface) DiscoverSriovDevice(storeManager store.ManagerInterface, pciAddr string) (*v1.InterfaceExt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverSriovDevice", storeManager, pciAddr)
+	ret0, _ := ret[0].(*v1.InterfaceExt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverSriovDevice indicates an expected call of DiscoverSriovDevice.
+func (mr *MockHostHelpersInterfaceMockRecorder) DiscoverSriovDevice(storeManager, pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).DiscoverSriovDevice), storeManager, pciAddr)
+}
+
 // DiscoverSriovDevices mocks base method.
 func (m *MockHostHelpersInterface) DiscoverSriovDevices(storeManager store.ManagerInterface) ([]v1.InterfaceExt, error) {
 	m.ctrl.T.Helper()
@@ -237,6 +284,22 @@ func (mr *MockHostHelpersInterfaceMockRecorder) DiscoverSriovDevices(storeManage
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevices", reflect.TypeOf((*MockHostHelpersInterface)(nil).DiscoverSriovDevices), storeManager)
 }
 
+// DiscoverSriovDevicesVerbose mocks base method.
+func (m *MockHostHelpersInterface) DiscoverSriovDevicesVerbose(storeManager store.ManagerInterface) ([]v1.InterfaceExt, []types.FilteredDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverSriovDevicesVerbose", storeManager)
+	ret0, _ := ret[0].([]v1.InterfaceExt)
+	ret1, _ := ret[1].([]types.FilteredDevice)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DiscoverSriovDevicesVerbose indicates an expected call of DiscoverSriovDevicesVerbose.
+func (mr *MockHostHelpersInterfaceMockRecorder) DiscoverSriovDevicesVerbose(storeManager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevicesVerbose", reflect.TypeOf((*MockHostHelpersInterface)(nil).DiscoverSriovDevicesVerbose), storeManager)
+}
+
 // DiscoverVDPAType mocks base method.
 func (m *MockHostHelpersInterface) DiscoverVDPAType(pciAddr string) string {
 	m.ctrl.T.Helper()
@@ -309,6 +372,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) EnableService(service interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableService", reflect.TypeOf((*MockHostHelpersInterface)(nil).EnableService), service)
 }
 
+// ExportConfigScript mocks base method.
+func (m *MockHostHelpersInterface) ExportConfigScript(state *v1.SriovNetworkNodeState) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportConfigScript", state)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportConfigScript indicates an expected call of ExportConfigScript.
+func (mr *MockHostHelpersInterfaceMockRecorder) ExportConfigScript(state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportConfigScript", reflect.TypeOf((*MockHostHelpersInterface)(nil).ExportConfigScript), state)
+}
+
 // GetCheckPointNodeState mocks base method.
 func (m *MockHostHelpersInterface) GetCheckPointNodeState() (*v1.SriovNetworkNodeState, error) {
 	m.ctrl.T.Helper()
@@ -354,6 +432,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetDevlinkDeviceParam(pciAddr, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevlinkDeviceParam", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetDevlinkDeviceParam), pciAddr, paramName)
 }
 
+// GetDevlinkOffloadVfLimit mocks base method.
+func (m *MockHostHelpersInterface) GetDevlinkOffloadVfLimit(pciAddr string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevlinkOffloadVfLimit", pciAddr)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDevlinkOffloadVfLimit indicates an expected call of GetDevlinkOffloadVfLimit.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetDevlinkOffloadVfLimit(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevlinkOffloadVfLimit", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetDevlinkOffloadVfLimit), pciAddr)
+}
+
 // GetDriverByBusAndDevice mocks base method.
 func (m *MockHostHelpersInterface) GetDriverByBusAndDevice(bus, device string) (string, error) {
 	m.ctrl.T.Helper()
@@ -369,6 +462,36 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetDriverByBusAndDevice(bus, dev
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverByBusAndDevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetDriverByBusAndDevice), bus, device)
 }
 
+// GetInterfaceCapabilities mocks base method.
+func (m *MockHostHelpersInterface) GetInterfaceCapabilities(pciAddr string) (v1.InterfaceCapabilities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInterfaceCapabilities", pciAddr)
+	ret0, _ := ret[0].(v1.InterfaceCapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInterfaceCapabilities indicates an expected call of GetInterfaceCapabilities.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetInterfaceCapabilities(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterfaceCapabilities", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetInterfaceCapabilities), pciAddr)
+}
+
+// GetKernelArgValue mocks base method.
+func (m *MockHostHelpersInterface) GetKernelArgValue(cmdLine, key string) (string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKernelArgValue", cmdLine, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetKernelArgValue indicates an expected call of GetKernelArgValue.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetKernelArgValue(cmdLine, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKernelArgValue", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetKernelArgValue), cmdLine, key)
+}
+
 // GetLinkType mocks base method.
 func (m *MockHostHelpersInterface) GetLinkType(name string) string {
 	m.ctrl.T.Helper()
@@ -414,6 +537,20 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetMlxNicFwData(pciAddress inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMlxNicFwData", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetMlxNicFwData), pciAddress)
 }
 
+// GetNetDevFirmwareVersion mocks base method.
+func (m *MockHostHelpersInterface) GetNetDevFirmwareVersion(ifaceName string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetDevFirmwareVersion", ifaceName)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetNetDevFirmwareVersion indicates an expected call of GetNetDevFirmwareVersion.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetNetDevFirmwareVersion(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetDevFirmwareVersion", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNetDevFirmwareVersion), ifaceName)
+}
+
 // GetNetDevLinkAdminState mocks base method.
 func (m *MockHostHelpersInterface) GetNetDevLinkAdminState(ifaceName string) string {
 	m.ctrl.T.Helper()
@@ -470,6 +607,22 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetNetDevNodeGUID(pciAddr interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetDevNodeGUID", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNetDevNodeGUID), pciAddr)
 }
 
+// GetNetdevCombinedChannels mocks base method.
+func (m *MockHostHelpersInterface) GetNetdevCombinedChannels(ifaceName string) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetdevCombinedChannels", ifaceName)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetNetdevCombinedChannels indicates an expected call of GetNetdevCombinedChannels.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetNetdevCombinedChannels(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevCombinedChannels", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNetdevCombinedChannels), ifaceName)
+}
+
 // GetNetdevMTU mocks base method.
 func (m *MockHostHelpersInterface) GetNetdevMTU(pciAddr string) int {
 	m.ctrl.T.Helper()
@@ -484,6 +637,36 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetNetdevMTU(pciAddr interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevMTU", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNetdevMTU), pciAddr)
 }
 
+// GetNetdevRingSizes mocks base method.
+func (m *MockHostHelpersInterface) GetNetdevRingSizes(ifaceName string) (*types.RingSizes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetdevRingSizes", ifaceName)
+	ret0, _ := ret[0].(*types.RingSizes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNetdevRingSizes indicates an expected call of GetNetdevRingSizes.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetNetdevRingSizes(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevRingSizes", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNetdevRingSizes), ifaceName)
+}
+
+// GetNicEswitchConfig mocks base method.
+func (m *MockHostHelpersInterface) GetNicEswitchConfig(pciAddr string) (types.EswitchConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNicEswitchConfig", pciAddr)
+	ret0, _ := ret[0].(types.EswitchConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNicEswitchConfig indicates an expected call of GetNicEswitchConfig.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetNicEswitchConfig(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNicEswitchConfig", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetNicEswitchConfig), pciAddr)
+}
+
 // GetNicSriovMode mocks base method.
 func (m *MockHostHelpersInterface) GetNicSriovMode(pciAddr string) string {
 	m.ctrl.T.Helper()
@@ -543,6 +726,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) GetPhysSwitchID(name interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhysSwitchID", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetPhysSwitchID), name)
 }
 
+// GetRdmaSubsystemMode mocks base method.
+func (m *MockHostHelpersInterface) GetRdmaSubsystemMode() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRdmaSubsystemMode")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRdmaSubsystemMode indicates an expected call of GetRdmaSubsystemMode.
+func (mr *MockHostHelpersInterfaceMockRecorder) GetRdmaSubsystemMode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRdmaSubsystemMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).GetRdmaSubsystemMode))
+}
+
 // HasDriver mocks base method.
 func (m *MockHostHelpersInterface) HasDriver(pciAddr string) (bool, string) {
 	m.ctrl.T.Helper()
@@ -558,6 +756,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) HasDriver(pciAddr interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDriver", reflect.TypeOf((*MockHostHelpersInterface)(nil).HasDriver), pciAddr)
 }
 
+// HugepageKernelArgs mocks base method.
+func (m *MockHostHelpersInterface) HugepageKernelArgs(cmdLine, size string, count int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HugepageKernelArgs", cmdLine, size, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HugepageKernelArgs indicates an expected call of HugepageKernelArgs.
+func (mr *MockHostHelpersInterfaceMockRecorder) HugepageKernelArgs(cmdLine, size, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HugepageKernelArgs", reflect.TypeOf((*MockHostHelpersInterface)(nil).HugepageKernelArgs), cmdLine, size, count)
+}
+
 // InstallRDMA mocks base method.
 func (m *MockHostHelpersInterface) InstallRDMA(packageManager string) error {
 	m.ctrl.T.Helper()
@@ -572,6 +785,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) InstallRDMA(packageManager inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallRDMA", reflect.TypeOf((*MockHostHelpersInterface)(nil).InstallRDMA), packageManager)
 }
 
+// IommuKernelArgForHost mocks base method.
+func (m *MockHostHelpersInterface) IommuKernelArgForHost() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IommuKernelArgForHost")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IommuKernelArgForHost indicates an expected call of IommuKernelArgForHost.
+func (mr *MockHostHelpersInterfaceMockRecorder) IommuKernelArgForHost() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IommuKernelArgForHost", reflect.TypeOf((*MockHostHelpersInterface)(nil).IommuKernelArgForHost))
+}
+
 // IsCoreOS mocks base method.
 func (m *MockHostHelpersInterface) IsCoreOS() (bool, error) {
 	m.ctrl.T.Helper()
@@ -587,6 +815,34 @@ func (mr *MockHostHelpersInterfaceMockRecorder) IsCoreOS() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCoreOS", reflect.TypeOf((*MockHostHelpersInterface)(nil).IsCoreOS))
 }
 
+// IsIommuEnabled mocks base method.
+func (m *MockHostHelpersInterface) IsIommuEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsIommuEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsIommuEnabled indicates an expected call of IsIommuEnabled.
+func (mr *MockHostHelpersInterfaceMockRecorder) IsIommuEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIommuEnabled", reflect.TypeOf((*MockHostHelpersInterface)(nil).IsIommuEnabled))
+}
+
+// IsKernelArgKeySet mocks base method.
+func (m *MockHostHelpersInterface) IsKernelArgKeySet(cmdLine, key string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsKernelArgKeySet", cmdLine, key)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsKernelArgKeySet indicates an expected call of IsKernelArgKeySet.
+func (mr *MockHostHelpersInterfaceMockRecorder) IsKernelArgKeySet(cmdLine, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsKernelArgKeySet", reflect.TypeOf((*MockHostHelpersInterface)(nil).IsKernelArgKeySet), cmdLine, key)
+}
+
 // IsKernelArgsSet mocks base method.
 func (m *MockHostHelpersInterface) IsKernelArgsSet(cmdLine, karg string) bool {
 	m.ctrl.T.Helper()
@@ -704,6 +960,21 @@ func (mr *MockHostHelpersInterfaceMockRecorder) IsUbuntuSystem() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUbuntuSystem", reflect.TypeOf((*MockHostHelpersInterface)(nil).IsUbuntuSystem))
 }
 
+// ListVfMacs mocks base method.
+func (m *MockHostHelpersInterface) ListVfMacs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVfMacs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVfMacs indicates an expected call of ListVfMacs.
+func (mr *MockHostHelpersInterfaceMockRecorder) ListVfMacs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVfMacs", reflect.TypeOf((*MockHostHelpersInterface)(nil).ListVfMacs))
+}
+
 // LoadKernelModule mocks base method.
 func (m *MockHostHelpersInterface) LoadKernelModule(name string, args ...string) error {
 	m.ctrl.T.Helper()
@@ -723,6 +994,22 @@ func (mr *MockHostHelpersInterfaceMockRecorder) LoadKernelModule(name interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadKernelModule", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadKernelModule), varargs...)
 }
 
+// LoadPfOriginalMtu mocks base method.
+func (m *MockHostHelpersInterface) LoadPfOriginalMtu(pciAddress string) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadPfOriginalMtu", pciAddress)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadPfOriginalMtu indicates an expected call of LoadPfOriginalMtu.
+func (mr *MockHostHelpersInterfaceMockRecorder) LoadPfOriginalMtu(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPfOriginalMtu", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadPfOriginalMtu), pciAddress)
+}
+
 // LoadPfsStatus mocks base method.
 func (m *MockHostHelpersInterface) LoadPfsStatus(pciAddress string) (*v1.Interface, bool, error) {
 	m.ctrl.T.Helper()
@@ -739,6 +1026,22 @@ func (mr *MockHostHelpersInterfaceMockRecorder) LoadPfsStatus(pciAddress interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPfsStatus", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadPfsStatus), pciAddress)
 }
 
+// LoadRejectedTotalVfs mocks base method.
+func (m *MockHostHelpersInterface) LoadRejectedTotalVfs(pciAddress string) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadRejectedTotalVfs", pciAddress)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadRejectedTotalVfs indicates an expected call of LoadRejectedTotalVfs.
+func (mr *MockHostHelpersInterfaceMockRecorder) LoadRejectedTotalVfs(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadRejectedTotalVfs", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadRejectedTotalVfs), pciAddress)
+}
+
 // LoadUdevRules mocks base method.
 func (m *MockHostHelpersInterface) LoadUdevRules() error {
 	m.ctrl.T.Helper()
@@ -753,6 +1056,53 @@ func (mr *MockHostHelpersInterfaceMockRecorder) LoadUdevRules() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadUdevRules", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadUdevRules))
 }
 
+// LoadVfGUID mocks base method.
+func (m *MockHostHelpersInterface) LoadVfGUID(pfPciAddress string, vfID int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadVfGUID", pfPciAddress, vfID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadVfGUID indicates an expected call of LoadVfGUID.
+func (mr *MockHostHelpersInterfaceMockRecorder) LoadVfGUID(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVfGUID", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadVfGUID), pfPciAddress, vfID)
+}
+
+// LoadVfMac mocks base method.
+func (m *MockHostHelpersInterface) LoadVfMac(pfPciAddress string, vfID int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadVfMac", pfPciAddress, vfID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadVfMac indicates an expected call of LoadVfMac.
+func (mr *MockHostHelpersInterfaceMockRecorder) LoadVfMac(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVfMac", reflect.TypeOf((*MockHostHelpersInterface)(nil).LoadVfMac), pfPciAddress, vfID)
+}
+
+// MaxMTU mocks base method.
+func (m *MockHostHelpersInterface) MaxMTU(iface string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxMTU", iface)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MaxMTU indicates an expected call of MaxMTU.
+func (mr *MockHostHelpersInterfaceMockRecorder) MaxMTU(iface interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxMTU", reflect.TypeOf((*MockHostHelpersInterface)(nil).MaxMTU), iface)
+}
+
 // MlxConfigFW mocks base method.
 func (m *MockHostHelpersInterface) MlxConfigFW(attributesToChange map[string]mlxutils.MlxNic) error {
 	m.ctrl.T.Helper()
@@ -899,6 +1249,20 @@ func (mr *MockHostHelpersInterfaceMockRecorder) ReloadDriver(driver interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadDriver", reflect.TypeOf((*MockHostHelpersInterface)(nil).ReloadDriver), driver)
 }
 
+// ReloadSupportedVfIds mocks base method.
+func (m *MockHostHelpersInterface) ReloadSupportedVfIds(supportedVfIds []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReloadSupportedVfIds", supportedVfIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReloadSupportedVfIds indicates an expected call of ReloadSupportedVfIds.
+func (mr *MockHostHelpersInterfaceMockRecorder) ReloadSupportedVfIds(supportedVfIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadSupportedVfIds", reflect.TypeOf((*MockHostHelpersInterface)(nil).ReloadSupportedVfIds), supportedVfIds)
+}
+
 // RemoveDisableNMUdevRule mocks base method.
 func (m *MockHostHelpersInterface) RemoveDisableNMUdevRule(pfPciAddress string) error {
 	m.ctrl.T.Helper()
@@ -927,6 +1291,34 @@ func (mr *MockHostHelpersInterfaceMockRecorder) RemovePersistPFNameUdevRule(pfPc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePersistPFNameUdevRule", reflect.TypeOf((*MockHostHelpersInterface)(nil).RemovePersistPFNameUdevRule), pfPciAddress)
 }
 
+// RemoveRejectedTotalVfs mocks base method.
+func (m *MockHostHelpersInterface) RemoveRejectedTotalVfs(pciAddress string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRejectedTotalVfs", pciAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRejectedTotalVfs indicates an expected call of RemoveRejectedTotalVfs.
+func (mr *MockHostHelpersInterfaceMockRecorder) RemoveRejectedTotalVfs(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRejectedTotalVfs", reflect.TypeOf((*MockHostHelpersInterface)(nil).RemoveRejectedTotalVfs), pciAddress)
+}
+
+// RemoveVfMac mocks base method.
+func (m *MockHostHelpersInterface) RemoveVfMac(pfPciAddress string, vfID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveVfMac", pfPciAddress, vfID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVfMac indicates an expected call of RemoveVfMac.
+func (mr *MockHostHelpersInterfaceMockRecorder) RemoveVfMac(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVfMac", reflect.TypeOf((*MockHostHelpersInterface)(nil).RemoveVfMac), pfPciAddress, vfID)
+}
+
 // RemoveVfRepresentorUdevRule mocks base method.
 func (m *MockHostHelpersInterface) RemoveVfRepresentorUdevRule(pfPciAddress string) error {
 	m.ctrl.T.Helper()
@@ -942,17 +1334,17 @@ func (mr *MockHostHelpersInterfaceMockRecorder) RemoveVfRepresentorUdevRule(pfPc
 }
 
 // ResetSriovDevice mocks base method.
-func (m *MockHostHelpersInterface) ResetSriovDevice(ifaceStatus v1.InterfaceExt) error {
+func (m *MockHostHelpersInterface) ResetSriovDevice(storeManager store.ManagerInterface, ifaceStatus v1.InterfaceExt) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ResetSriovDevice", ifaceStatus)
+	ret := m.ctrl.Call(m, "ResetSriovDevice", storeManager, ifaceStatus)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ResetSriovDevice indicates an expected call of ResetSriovDevice.
-func (mr *MockHostHelpersInterfaceMockRecorder) ResetSriovDevice(ifaceStatus interface{}) *gomock.Call {
+func (mr *MockHostHelpersInterfaceMockRecorder) ResetSriovDevice(storeManager, ifaceStatus interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetSriovDevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).ResetSriovDevice), ifaceStatus)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetSriovDevice", reflect.TypeOf((*MockHostHelpersInterface)(nil).ResetSriovDevice), storeManager, ifaceStatus)
 }
 
 // RunCommand mocks base method.
@@ -976,6 +1368,47 @@ func (mr *MockHostHelpersInterfaceMockRecorder) RunCommand(arg0 interface{}, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommand", reflect.TypeOf((*MockHostHelpersInterface)(nil).RunCommand), varargs...)
 }
 
+// RunCommandCombined mocks base method.
+func (m *MockHostHelpersInterface) RunCommandCombined(arg0 string, arg1 ...string) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunCommandCombined", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunCommandCombined indicates an expected call of RunCommandCombined.
+func (mr *MockHostHelpersInterfaceMockRecorder) RunCommandCombined(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommandCombined", reflect.TypeOf((*MockHostHelpersInterface)(nil).RunCommandCombined), varargs...)
+}
+
+// RunCommandContext mocks base method.
+func (m *MockHostHelpersInterface) RunCommandContext(arg0 context.Context, arg1 string, arg2 ...string) (string, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunCommandContext", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RunCommandContext indicates an expected call of RunCommandContext.
+func (mr *MockHostHelpersInterfaceMockRecorder) RunCommandContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommandContext", reflect.TypeOf((*MockHostHelpersInterface)(nil).RunCommandContext), varargs...)
+}
+
 // SaveLastPfAppliedStatus mocks base method.
 func (m *MockHostHelpersInterface) SaveLastPfAppliedStatus(PfInfo *v1.Interface) error {
 	m.ctrl.T.Helper()
@@ -990,6 +1423,62 @@ func (mr *MockHostHelpersInterfaceMockRecorder) SaveLastPfAppliedStatus(PfInfo i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLastPfAppliedStatus", reflect.TypeOf((*MockHostHelpersInterface)(nil).SaveLastPfAppliedStatus), PfInfo)
 }
 
+// SavePfOriginalMtu mocks base method.
+func (m *MockHostHelpersInterface) SavePfOriginalMtu(pciAddress string, mtu int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePfOriginalMtu", pciAddress, mtu)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePfOriginalMtu indicates an expected call of SavePfOriginalMtu.
+func (mr *MockHostHelpersInterfaceMockRecorder) SavePfOriginalMtu(pciAddress, mtu interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePfOriginalMtu", reflect.TypeOf((*MockHostHelpersInterface)(nil).SavePfOriginalMtu), pciAddress, mtu)
+}
+
+// SaveRejectedTotalVfs mocks base method.
+func (m *MockHostHelpersInterface) SaveRejectedTotalVfs(pciAddress string, totalVfs int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRejectedTotalVfs", pciAddress, totalVfs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRejectedTotalVfs indicates an expected call of SaveRejectedTotalVfs.
+func (mr *MockHostHelpersInterfaceMockRecorder) SaveRejectedTotalVfs(pciAddress, totalVfs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRejectedTotalVfs", reflect.TypeOf((*MockHostHelpersInterface)(nil).SaveRejectedTotalVfs), pciAddress, totalVfs)
+}
+
+// SaveVfGUID mocks base method.
+func (m *MockHostHelpersInterface) SaveVfGUID(pfPciAddress string, vfID int, guid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVfGUID", pfPciAddress, vfID, guid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVfGUID indicates an expected call of SaveVfGUID.
+func (mr *MockHostHelpersInterfaceMockRecorder) SaveVfGUID(pfPciAddress, vfID, guid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVfGUID", reflect.TypeOf((*MockHostHelpersInterface)(nil).SaveVfGUID), pfPciAddress, vfID, guid)
+}
+
+// SaveVfMac mocks base method.
+func (m *MockHostHelpersInterface) SaveVfMac(pfPciAddress string, vfID int, mac string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVfMac", pfPciAddress, vfID, mac)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVfMac indicates an expected call of SaveVfMac.
+func (mr *MockHostHelpersInterfaceMockRecorder) SaveVfMac(pfPciAddress, vfID, mac interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVfMac", reflect.TypeOf((*MockHostHelpersInterface)(nil).SaveVfMac), pfPciAddress, vfID, mac)
+}
+
 // SetDevlinkDeviceParam mocks base method.
 func (m *MockHostHelpersInterface) SetDevlinkDeviceParam(pciAddr, paramName, value string) error {
 	m.ctrl.T.Helper()
@@ -1004,18 +1493,103 @@ func (mr *MockHostHelpersInterfaceMockRecorder) SetDevlinkDeviceParam(pciAddr, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDevlinkDeviceParam", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetDevlinkDeviceParam), pciAddr, paramName, value)
 }
 
+// SetDevlinkVfRate mocks base method.
+func (m *MockHostHelpersInterface) SetDevlinkVfRate(pciAddr string, vfID int, minRate, maxRate uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDevlinkVfRate", pciAddr, vfID, minRate, maxRate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDevlinkVfRate indicates an expected call of SetDevlinkVfRate.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetDevlinkVfRate(pciAddr, vfID, minRate, maxRate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDevlinkVfRate", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetDevlinkVfRate), pciAddr, vfID, minRate, maxRate)
+}
+
+// SetMellanoxBlueFieldMode mocks base method.
+func (m *MockHostHelpersInterface) SetMellanoxBlueFieldMode(pciAddr, mode string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMellanoxBlueFieldMode", pciAddr, mode)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMellanoxBlueFieldMode indicates an expected call of SetMellanoxBlueFieldMode.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetMellanoxBlueFieldMode(pciAddr, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMellanoxBlueFieldMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetMellanoxBlueFieldMode), pciAddr, mode)
+}
+
+// SetNetdevCombinedChannels mocks base method.
+func (m *MockHostHelpersInterface) SetNetdevCombinedChannels(ifaceName string, channels int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNetdevCombinedChannels", ifaceName, channels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNetdevCombinedChannels indicates an expected call of SetNetdevCombinedChannels.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetNetdevCombinedChannels(ifaceName, channels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevCombinedChannels", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNetdevCombinedChannels), ifaceName, channels)
+}
+
 // SetNetdevMTU mocks base method.
-func (m *MockHostHelpersInterface) SetNetdevMTU(pciAddr string, mtu int) error {
+func (m *MockHostHelpersInterface) SetNetdevMTU(ctx context.Context, pciAddr string, mtu int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetNetdevMTU", pciAddr, mtu)
+	ret := m.ctrl.Call(m, "SetNetdevMTU", ctx, pciAddr, mtu)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetNetdevMTU indicates an expected call of SetNetdevMTU.
-func (mr *MockHostHelpersInterfaceMockRecorder) SetNetdevMTU(pciAddr, mtu interface{}) *gomock.Call {
+func (mr *MockHostHelpersInterfaceMockRecorder) SetNetdevMTU(ctx, pciAddr, mtu interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevMTU", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNetdevMTU), ctx, pciAddr, mtu)
+}
+
+// SetNetdevRingSizes mocks base method.
+func (m *MockHostHelpersInterface) SetNetdevRingSizes(ifaceName string, rx, tx int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNetdevRingSizes", ifaceName, rx, tx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNetdevRingSizes indicates an expected call of SetNetdevRingSizes.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetNetdevRingSizes(ifaceName, rx, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevRingSizes", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNetdevRingSizes), ifaceName, rx, tx)
+}
+
+// SetNicEswitchEncapMode mocks base method.
+func (m *MockHostHelpersInterface) SetNicEswitchEncapMode(pciAddr string, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNicEswitchEncapMode", pciAddr, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNicEswitchEncapMode indicates an expected call of SetNicEswitchEncapMode.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetNicEswitchEncapMode(pciAddr, enabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicEswitchEncapMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNicEswitchEncapMode), pciAddr, enabled)
+}
+
+// SetNicEswitchInlineMode mocks base method.
+func (m *MockHostHelpersInterface) SetNicEswitchInlineMode(pciAddr, mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNicEswitchInlineMode", pciAddr, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNicEswitchInlineMode indicates an expected call of SetNicEswitchInlineMode.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetNicEswitchInlineMode(pciAddr, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevMTU", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNetdevMTU), pciAddr, mtu)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicEswitchInlineMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNicEswitchInlineMode), pciAddr, mode)
 }
 
 // SetNicSriovMode mocks base method.
@@ -1032,6 +1606,20 @@ func (mr *MockHostHelpersInterfaceMockRecorder) SetNicSriovMode(pciAddr, mode in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicSriovMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetNicSriovMode), pciAddr, mode)
 }
 
+// SetRdmaSubsystemMode mocks base method.
+func (m *MockHostHelpersInterface) SetRdmaSubsystemMode(mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRdmaSubsystemMode", mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRdmaSubsystemMode indicates an expected call of SetRdmaSubsystemMode.
+func (mr *MockHostHelpersInterfaceMockRecorder) SetRdmaSubsystemMode(mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRdmaSubsystemMode", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetRdmaSubsystemMode), mode)
+}
+
 // SetSriovNumVfs mocks base method.
 func (m *MockHostHelpersInterface) SetSriovNumVfs(pciAddr string, numVfs int) error {
 	m.ctrl.T.Helper()
@@ -1061,17 +1649,17 @@ func (mr *MockHostHelpersInterfaceMockRecorder) SetVfAdminMac(vfAddr, pfLink, vf
 }
 
 // SetVfGUID mocks base method.
-func (m *MockHostHelpersInterface) SetVfGUID(vfAddr string, pfLink netlink.Link) error {
+func (m *MockHostHelpersInterface) SetVfGUID(vfAddr string, pfLink netlink.Link, guid net.HardwareAddr) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetVfGUID", vfAddr, pfLink)
+	ret := m.ctrl.Call(m, "SetVfGUID", vfAddr, pfLink, guid)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetVfGUID indicates an expected call of SetVfGUID.
-func (mr *MockHostHelpersInterfaceMockRecorder) SetVfGUID(vfAddr, pfLink interface{}) *gomock.Call {
+func (mr *MockHostHelpersInterfaceMockRecorder) SetVfGUID(vfAddr, pfLink, guid interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVfGUID", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetVfGUID), vfAddr, pfLink)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVfGUID", reflect.TypeOf((*MockHostHelpersInterface)(nil).SetVfGUID), vfAddr, pfLink, guid)
 }
 
 // TriggerUdevEvent mocks base method.
@@ -1212,18 +1800,75 @@ func (mr *MockHostHelpersInterfaceMockRecorder) UpdateSystemService(serviceObj i
 }
 
 // VFIsReady mocks base method.
-func (m *MockHostHelpersInterface) VFIsReady(pciAddr string) (netlink.Link, error) {
+func (m *MockHostHelpersInterface) VFIsReady(ctx context.Context, pciAddr string, interval, timeout time.Duration) (netlink.Link, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "VFIsReady", pciAddr)
+	ret := m.ctrl.Call(m, "VFIsReady", ctx, pciAddr, interval, timeout)
 	ret0, _ := ret[0].(netlink.Link)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // VFIsReady indicates an expected call of VFIsReady.
-func (mr *MockHostHelpersInterfaceMockRecorder) VFIsReady(pciAddr interface{}) *gomock.Call {
+func (mr *MockHostHelpersInterfaceMockRecorder) VFIsReady(ctx, pciAddr, interval, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VFIsReady", reflect.TypeOf((*MockHostHelpersInterface)(nil).VFIsReady), ctx, pciAddr, interval, timeout)
+}
+
+// ValidateRequiredModules mocks base method.
+func (m *MockHostHelpersInterface) ValidateRequiredModules(modules []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRequiredModules", modules)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateRequiredModules indicates an expected call of ValidateRequiredModules.
+func (mr *MockHostHelpersInterfaceMockRecorder) ValidateRequiredModules(modules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRequiredModules", reflect.TypeOf((*MockHostHelpersInterface)(nil).ValidateRequiredModules), modules)
+}
+
+// VerifyInterfaceConfig mocks base method.
+func (m *MockHostHelpersInterface) VerifyInterfaceConfig(storeManager store.ManagerInterface, iface *v1.Interface) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyInterfaceConfig", storeManager, iface)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyInterfaceConfig indicates an expected call of VerifyInterfaceConfig.
+func (mr *MockHostHelpersInterfaceMockRecorder) VerifyInterfaceConfig(storeManager, iface interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyInterfaceConfig", reflect.TypeOf((*MockHostHelpersInterface)(nil).VerifyInterfaceConfig), storeManager, iface)
+}
+
+// WaitForVFs mocks base method.
+func (m *MockHostHelpersInterface) WaitForVFs(pfPciAddr string, count int, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForVFs", pfPciAddr, count, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForVFs indicates an expected call of WaitForVFs.
+func (mr *MockHostHelpersInterfaceMockRecorder) WaitForVFs(pfPciAddr, count, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVFs", reflect.TypeOf((*MockHostHelpersInterface)(nil).WaitForVFs), pfPciAddr, count, timeout)
+}
+
+// WithPhysPortCache mocks base method.
+func (m *MockHostHelpersInterface) WithPhysPortCache() types.NetworkInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithPhysPortCache")
+	ret0, _ := ret[0].(types.NetworkInterface)
+	return ret0
+}
+
+// WithPhysPortCache indicates an expected call of WithPhysPortCache.
+func (mr *MockHostHelpersInterfaceMockRecorder) WithPhysPortCache() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VFIsReady", reflect.TypeOf((*MockHostHelpersInterface)(nil).VFIsReady), pciAddr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPhysPortCache", reflect.TypeOf((*MockHostHelpersInterface)(nil).WithPhysPortCache))
 }
 
 // WriteCheckpointFile mocks base method.
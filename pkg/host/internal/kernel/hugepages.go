@@ -0,0 +1,97 @@
+package kernel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+)
+
+// parseHugepageSize converts a hugepage size in the format of the kernel cmd line, e.g. 2M or 1G, to bytes
+func parseHugepageSize(size string) (uint64, error) {
+	if size == "" {
+		return 0, fmt.Errorf("empty hugepage size")
+	}
+	multiplier := uint64(1)
+	number := size
+	switch strings.ToUpper(size[len(size)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		number = size[:len(size)-1]
+	}
+	value, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || value == 0 {
+		return 0, fmt.Errorf("invalid hugepage size %q", size)
+	}
+	return value * multiplier, nil
+}
+
+// HugepageKernelArgs returns the kernel args to append to the kernel cmd line to allocate count hugepages of the size.
+// The hugepage args already on the kernel cmd line are kept: the default hugepage size is only set when the kernel
+// cmd line doesn't set it and doesn't allocate hugepages of the default size. When hugepages of the size are already
+// allocated with another count the hugepagesz and hugepages args are returned to update the count, unless the count
+// is set without size for the default size, which is left unchanged.
+func (k *kernel) HugepageKernelArgs(cmdLine string, size string, count int) ([]string, error) {
+	requested, err := parseHugepageSize(size)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid hugepage count %d", count)
+	}
+
+	// hugepages applies to the size of the preceding hugepagesz, or to the default size without one
+	var defaultSize, currentSize uint64
+	defaultSizeSet, defaultCount := false, -1
+	counts := map[uint64]int{}
+	for _, element := range strings.Fields(cmdLine) {
+		key, value, _ := strings.Cut(element, "=")
+		switch key {
+		case consts.KernelArgDefaultHugepageSize:
+			if parsed, err := parseHugepageSize(value); err == nil {
+				defaultSize, defaultSizeSet = parsed, true
+			}
+		case consts.KernelArgHugepageSize:
+			currentSize, _ = parseHugepageSize(value)
+		case consts.KernelArgHugepages:
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			if currentSize == 0 {
+				defaultCount = parsed
+			} else {
+				counts[currentSize] = parsed
+			}
+		}
+	}
+	kargs := []string{}
+	if !defaultSizeSet && defaultCount < 0 {
+		kargs = append(kargs, fmt.Sprintf("%s=%s", consts.KernelArgDefaultHugepageSize, size))
+	}
+	if current, ok := counts[requested]; ok && current == count {
+		return kargs, nil
+	}
+	if _, ok := counts[requested]; !ok && defaultSizeSet && defaultSize == requested && defaultCount >= 0 {
+		if defaultCount != count {
+			// the size can't be set again for the hugepages of the default size
+			log.Log.Info("HugepageKernelArgs(): hugepages of the default size allocated without size on the kernel cmd line, skipping",
+				"size", size, "count", defaultCount, "requested-count", count)
+		}
+		return kargs, nil
+	}
+	// the hugepages arg following the hugepagesz arg of the size is replaced when the count changed
+	kargs = append(kargs,
+		fmt.Sprintf("%s=%s", consts.KernelArgHugepageSize, size),
+		fmt.Sprintf("%s=%d", consts.KernelArgHugepages, count))
+	return kargs, nil
+}
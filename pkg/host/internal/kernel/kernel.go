@@ -1,12 +1,16 @@
 package kernel
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/cenkalti/backoff"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
@@ -17,6 +21,20 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 )
 
+var (
+	// timeout of the read of the kernel lockdown file, the read is known to hang on some
+	// locked-down kernels
+	kernelLockdownCheckTimeout = 5 * time.Second
+
+	// initial interval and number of retries of the driver bind, unbind and probe writes failing
+	// because the kernel is still tearing down the previous binding of the device
+	driverBindRetryInterval = 100 * time.Millisecond
+	driverBindRetryCount    = 5
+
+	// writes the driver bind, unbind and probe files of the sysfs
+	writeDriverFile = os.WriteFile
+)
+
 type kernel struct {
 	utilsHelper utils.CmdInterface
 }
@@ -72,6 +90,46 @@ func (k *kernel) IsKernelModuleLoaded(kernelModuleName string) (bool, error) {
 	return false, nil
 }
 
+// ValidateRequiredModules checks the kernel modules are listed in /proc/modules or
+// have an entry in /sys/module (built-in modules), returns the missing ones
+func (k *kernel) ValidateRequiredModules(modules []string) ([]string, error) {
+	log.Log.V(2).Info("ValidateRequiredModules(): check required kernel modules", "modules", modules)
+	if len(modules) == 0 {
+		return nil, nil
+	}
+	procModules, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.ProcModules))
+	if err != nil {
+		log.Log.Error(err, "ValidateRequiredModules(): failed to read loaded kernel modules")
+		return nil, err
+	}
+	loaded := map[string]bool{}
+	for _, line := range strings.Split(string(procModules), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			loaded[fields[0]] = true
+		}
+	}
+
+	var missing []string
+	for _, module := range modules {
+		// the kernel reports the module names with underscores, modprobe accepts both
+		name := strings.ReplaceAll(module, "-", "_")
+		if loaded[name] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(vars.FilesystemRoot, consts.SysModule, name)); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			log.Log.Error(err, "ValidateRequiredModules(): failed to check built-in kernel module", "name", name)
+			return nil, err
+		}
+		missing = append(missing, module)
+	}
+	if len(missing) > 0 {
+		log.Log.Info("ValidateRequiredModules(): required kernel modules are not loaded", "missing", missing)
+	}
+	return missing, nil
+}
+
 func (k *kernel) TryEnableTun() {
 	if err := k.LoadKernelModule("tun"); err != nil {
 		log.Log.Error(err, "tryEnableTun(): TUN kernel module not loaded")
@@ -99,16 +157,80 @@ func (k *kernel) GetCurrentKernelArgs() (string, error) {
 	return string(cmdLine), nil
 }
 
+// IommuKernelArgForHost returns the kernel argument enabling IOMMU for the CPU vendor of the host.
+// Returns an error if the vendor is unknown or if the host has CPUs from several vendors.
+func (k *kernel) IommuKernelArgForHost() (string, error) {
+	path := consts.ProcCpuInfo
+	if !vars.UsingSystemdMode {
+		path = filepath.Join(consts.Host, path)
+	}
+
+	path = filepath.Join(vars.FilesystemRoot, path)
+	cpuInfo, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("IommuKernelArgForHost(): Error reading %s: %v", path, err)
+	}
+
+	vendor := ""
+	for _, line := range strings.Split(string(cpuInfo), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) != "vendor_id" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if vendor != "" && vendor != value {
+			return "", fmt.Errorf("IommuKernelArgForHost(): mixed CPU vendors %s and %s", vendor, value)
+		}
+		vendor = value
+	}
+
+	switch vendor {
+	case consts.CpuVendorIntel:
+		return consts.KernelArgIntelIommu, nil
+	case consts.CpuVendorAmd:
+		return consts.KernelArgAmdIommu, nil
+	}
+	return "", fmt.Errorf("IommuKernelArgForHost(): unknown CPU vendor %q", vendor)
+}
+
+// IsIommuEnabled returns true if the IOMMU groups of the host are populated
+func (k *kernel) IsIommuEnabled() bool {
+	return isIommuEnabled()
+}
+
 // IsKernelArgsSet This checks if the kernel cmd line is set properly. Please note that the same key could be repeated
-// several times in the kernel cmd line. We can only ensure that the kernel cmd line has the key/val kernel arg that we set.
+// several times in the kernel cmd line, for a key=value kernel arg the last occurrence of the key wins as it overrides the previous ones.
 func (k *kernel) IsKernelArgsSet(cmdLine string, karg string) bool {
-	elements := strings.Fields(cmdLine)
-	for _, element := range elements {
-		if element == karg {
-			return true
+	key, value, hasValue := strings.Cut(karg, "=")
+	if !hasValue {
+		for _, element := range strings.Fields(cmdLine) {
+			if element == karg {
+				return true
+			}
+		}
+		return false
+	}
+	current, present := k.GetKernelArgValue(cmdLine, key)
+	return present && current == value
+}
+
+// IsKernelArgKeySet checks if the key is set in the kernel cmd line whatever its value
+func (k *kernel) IsKernelArgKeySet(cmdLine string, key string) bool {
+	_, present := k.GetKernelArgValue(cmdLine, key)
+	return present
+}
+
+// GetKernelArgValue returns the value of the last occurrence of the key in the kernel cmd line,
+// the value is empty for a key set without value. Returns false if the key is not set.
+func (k *kernel) GetKernelArgValue(cmdLine string, key string) (string, bool) {
+	value, present := "", false
+	for _, element := range strings.Fields(cmdLine) {
+		elementKey, elementValue, _ := strings.Cut(element, "=")
+		if elementKey == key {
+			value, present = elementValue, true
 		}
 	}
-	return false
+	return value, present
 }
 
 // Unbind unbind driver for one device
@@ -122,10 +244,16 @@ func (k *kernel) Unbind(pciAddr string) error {
 func (k *kernel) BindDpdkDriver(pciAddr, driver string) error {
 	log.Log.V(2).Info("BindDpdkDriver(): bind device to driver",
 		"device", pciAddr, "driver", driver)
+	if err := checkDpdkDriverLoaded(pciAddr, driver); err != nil {
+		return err
+	}
 	if err := k.BindDriverByBusAndDevice(consts.BusPci, pciAddr, driver); err != nil {
 		_, innerErr := os.Readlink(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, "iommu_group"))
 		if innerErr != nil {
 			log.Log.Error(err, "Could not read IOMMU group for device", "device", pciAddr)
+			if driver == consts.DeviceTypeVfioPci && !isIommuEnabled() {
+				return k.bindVfioNoIommu(pciAddr)
+			}
 			return fmt.Errorf(
 				"cannot bind driver %s to device %s, make sure IOMMU is enabled in BIOS. %w", driver, pciAddr, innerErr)
 		}
@@ -134,6 +262,47 @@ func (k *kernel) BindDpdkDriver(pciAddr, driver string) error {
 	return nil
 }
 
+// checkDpdkDriverLoaded returns an error if the device is not bound to the DPDK driver yet
+// and the kernel module providing the driver is not loaded on the host
+func checkDpdkDriverLoaded(pciAddr, driver string) error {
+	curDriver, err := getDriverByBusAndDevice(consts.BusPci, pciAddr)
+	if err != nil {
+		return err
+	}
+	if curDriver == driver {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDrivers, driver)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("cannot bind driver %s to device %s, the driver is not available: make sure the %s kernel module is loaded on the host",
+				driver, pciAddr, strings.ReplaceAll(driver, "-", "_"))
+		}
+		return err
+	}
+	return nil
+}
+
+// bindVfioNoIommu binds the device to vfio-pci in the unsafe no-IOMMU mode of vfio if it is allowed,
+// returns an error explaining how to enable IOMMU on the host otherwise
+func (k *kernel) bindVfioNoIommu(pciAddr string) error {
+	if !vars.AllowVfioNoIommu {
+		cmdLine, err := k.GetCurrentKernelArgs()
+		if err == nil && !k.IsKernelArgsSet(cmdLine, consts.KernelArgIntelIommu) && !k.IsKernelArgsSet(cmdLine, consts.KernelArgAmdIommu) {
+			return fmt.Errorf("cannot bind driver %s to device %s, IOMMU is not enabled on the host: add %s or %s to the kernel "+
+				"command line or allow the vfio no-IOMMU mode", consts.DeviceTypeVfioPci, pciAddr, consts.KernelArgIntelIommu, consts.KernelArgAmdIommu)
+		}
+		return fmt.Errorf("cannot bind driver %s to device %s, IOMMU is not enabled on the host: make sure IOMMU is enabled in BIOS "+
+			"or allow the vfio no-IOMMU mode", consts.DeviceTypeVfioPci, pciAddr)
+	}
+	log.Log.Info("bindVfioNoIommu(): IOMMU is not enabled on the host, enabling vfio no-IOMMU mode", "device", pciAddr)
+	noIommuPath := filepath.Join(vars.FilesystemRoot, consts.SysVfioNoIommuMode)
+	if err := os.WriteFile(noIommuPath, []byte("Y"), os.ModeAppend); err != nil {
+		log.Log.Error(err, "bindVfioNoIommu(): failed to enable vfio no-IOMMU mode", "path", noIommuPath)
+		return fmt.Errorf("failed to enable vfio no-IOMMU mode: %w", err)
+	}
+	return k.BindDriverByBusAndDevice(consts.BusPci, pciAddr, consts.DeviceTypeVfioPci)
+}
+
 // BindDefaultDriver bind driver for one device
 // Bind the device given by "pciAddr" to the default driver
 func (k *kernel) BindDefaultDriver(pciAddr string) error {
@@ -579,7 +748,9 @@ func (k *kernel) IsKernelLockdownMode() bool {
 	path := utils.GetHostExtension()
 	path = filepath.Join(path, "/sys/kernel/security/lockdown")
 
-	stdout, stderr, err := k.utilsHelper.RunCommand("cat", path)
+	ctx, cancel := context.WithTimeout(context.Background(), kernelLockdownCheckTimeout)
+	defer cancel()
+	stdout, stderr, err := k.utilsHelper.RunCommandContext(ctx, "cat", path)
 	log.Log.V(2).Info("IsKernelLockdownMode()", "output", stdout, "error", err)
 	if err != nil {
 		log.Log.Error(err, "IsKernelLockdownMode(): failed to check for lockdown file", "stderr", stderr)
@@ -588,6 +759,12 @@ func (k *kernel) IsKernelLockdownMode() bool {
 	return strings.Contains(stdout, "[integrity]") || strings.Contains(stdout, "[confidentiality]")
 }
 
+// returns true if the IOMMU groups of the host are populated
+func isIommuEnabled() bool {
+	groups, err := os.ReadDir(filepath.Join(vars.FilesystemRoot, consts.SysKernelIommuGroups))
+	return err == nil && len(groups) > 0
+}
+
 // returns driver for device on the bus
 func getDriverByBusAndDevice(bus, device string) (string, error) {
 	driverLink := filepath.Join(vars.FilesystemRoot, consts.SysBus, bus, "devices", device, "driver")
@@ -608,7 +785,7 @@ func getDriverByBusAndDevice(bus, device string) (string, error) {
 func bindDriver(bus, device, driver string) error {
 	log.Log.V(2).Info("bindDriver(): bind to driver", "bus", bus, "device", device, "driver", driver)
 	bindPath := filepath.Join(vars.FilesystemRoot, consts.SysBus, bus, "drivers", driver, "bind")
-	err := os.WriteFile(bindPath, []byte(device), os.ModeAppend)
+	err := writeDriverFileWithRetry(bindPath, device)
 	if err != nil {
 		log.Log.Error(err, "bindDriver(): failed to bind driver", "bus", bus, "device", device, "driver", driver)
 		return err
@@ -620,7 +797,7 @@ func bindDriver(bus, device, driver string) error {
 func unbindDriver(bus, device, driver string) error {
 	log.Log.V(2).Info("unbindDriver(): unbind from driver", "bus", bus, "device", device, "driver", driver)
 	unbindPath := filepath.Join(vars.FilesystemRoot, consts.SysBus, bus, "drivers", driver, "unbind")
-	err := os.WriteFile(unbindPath, []byte(device), os.ModeAppend)
+	err := writeDriverFileWithRetry(unbindPath, device)
 	if err != nil {
 		log.Log.Error(err, "unbindDriver(): failed to unbind driver", "bus", bus, "device", device, "driver", driver)
 		return err
@@ -632,7 +809,7 @@ func unbindDriver(bus, device, driver string) error {
 func probeDriver(bus, device string) error {
 	log.Log.V(2).Info("probeDriver(): drivers probe", "bus", bus, "device", device)
 	probePath := filepath.Join(vars.FilesystemRoot, consts.SysBus, bus, "drivers_probe")
-	err := os.WriteFile(probePath, []byte(device), os.ModeAppend)
+	err := writeDriverFileWithRetry(probePath, device)
 	if err != nil {
 		log.Log.Error(err, "probeDriver(): failed to trigger driver probe", "bus", bus, "device", device)
 		return err
@@ -640,6 +817,25 @@ func probeDriver(bus, device string) error {
 	return nil
 }
 
+// writes the device to the driver bind, unbind or probe file, the write is retried with a
+// backoff while the kernel reports the device as busy
+func writeDriverFileWithRetry(path, device string) error {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = driverBindRetryInterval
+	b.MaxElapsedTime = 0
+	return backoff.Retry(func() error {
+		err := writeDriverFile(path, []byte(device), os.ModeAppend)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EAGAIN) {
+			log.Log.V(2).Info("writeDriverFileWithRetry(): device busy, retrying", "path", path, "device", device, "error", err)
+			return err
+		}
+		return backoff.Permanent(err)
+	}, backoff.WithMaxRetries(b, uint64(driverBindRetryCount)))
+}
+
 // set driver override for the bus/device,
 // resets override if override arg is "",
 // if device doesn't support overriding (has no driver_override path), does nothing
@@ -1,12 +1,20 @@
 package kernel
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
 )
@@ -118,6 +126,54 @@ var _ = Describe("Kernel", func() {
 				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers_probe", "0000:d8:00.0")
 			})
 		})
+		Context("driver bind retry", func() {
+			var writes int
+			// fails the first busyWrites writes of the driver files with err
+			failDriverWrites := func(busyWrites int, err error) {
+				writes = 0
+				origWrite, origInterval := writeDriverFile, driverBindRetryInterval
+				driverBindRetryInterval = time.Millisecond
+				writeDriverFile = func(name string, data []byte, perm os.FileMode) error {
+					writes++
+					if writes <= busyWrites {
+						return &os.PathError{Op: "write", Path: name, Err: err}
+					}
+					return os.WriteFile(name, data, perm)
+				}
+				DeferCleanup(func() {
+					writeDriverFile, driverBindRetryInterval = origWrite, origInterval
+				})
+			}
+			BeforeEach(func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs: []string{
+						"/sys/bus/pci/devices/0000:d8:00.0",
+						"/sys/bus/pci/drivers/vfio-pci"},
+					Symlinks: map[string]string{
+						"/sys/bus/pci/devices/0000:d8:00.0/driver": "../../../../bus/pci/drivers/vfio-pci"},
+					Files: map[string][]byte{
+						"/sys/bus/pci/drivers_probe":           {},
+						"/sys/bus/pci/drivers/vfio-pci/unbind": {}},
+				})
+			})
+			It("device busy, then succeed", func() {
+				failDriverWrites(3, syscall.EBUSY)
+				Expect(k.BindDefaultDriver("0000:d8:00.0")).NotTo(HaveOccurred())
+				Expect(writes).To(Equal(5))
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers/vfio-pci/unbind", "0000:d8:00.0")
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers_probe", "0000:d8:00.0")
+			})
+			It("device busy for too long", func() {
+				failDriverWrites(100, syscall.EAGAIN)
+				Expect(k.Unbind("0000:d8:00.0")).To(MatchError(syscall.EAGAIN))
+				Expect(writes).To(Equal(driverBindRetryCount + 1))
+			})
+			It("permanent error", func() {
+				failDriverWrites(100, syscall.EINVAL)
+				Expect(k.Unbind("0000:d8:00.0")).To(MatchError(syscall.EINVAL))
+				Expect(writes).To(Equal(1))
+			})
+		})
 		Context("BindDpdkDriver", func() {
 			It("unknown device", func() {
 				Expect(k.BindDpdkDriver("unknown-dev", "vfio-pci")).To(HaveOccurred())
@@ -175,6 +231,77 @@ var _ = Describe("Kernel", func() {
 				})
 				Expect(k.BindDpdkDriver("0000:d8:00.0", "vfio-pci")).To(HaveOccurred())
 			})
+			It("bind to igb_uio", func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs: []string{
+						"/sys/bus/pci/devices/0000:d8:00.0",
+						"/sys/bus/pci/drivers/igb_uio"},
+					Files: map[string][]byte{
+						"/sys/bus/pci/drivers/igb_uio/bind":                 {},
+						"/sys/bus/pci/devices/0000:d8:00.0/driver_override": {}},
+				})
+				Expect(k.BindDpdkDriver("0000:d8:00.0", "igb_uio")).NotTo(HaveOccurred())
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers/igb_uio/bind", "0000:d8:00.0")
+			})
+			It("driver module not loaded", func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs: []string{
+						"/sys/bus/pci/devices/0000:d8:00.0",
+						"/sys/bus/pci/drivers/test-driver"},
+					Symlinks: map[string]string{
+						"/sys/bus/pci/devices/0000:d8:00.0/driver": "../../../../bus/pci/drivers/test-driver"},
+					Files: map[string][]byte{
+						"/sys/bus/pci/drivers/test-driver/unbind":           {},
+						"/sys/bus/pci/devices/0000:d8:00.0/driver_override": {}},
+				})
+				err := k.BindDpdkDriver("0000:d8:00.0", "uio_pci_generic")
+				Expect(err).To(MatchError(ContainSubstring("make sure the uio_pci_generic kernel module is loaded")))
+				// should keep the device bound to its current driver
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers/test-driver/unbind", "")
+			})
+			Context("no IOMMU", func() {
+				BeforeEach(func() {
+					helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+						Dirs: []string{
+							"/sys/bus/pci/devices/0000:d8:00.0",
+							"/sys/bus/pci/drivers/test-driver",
+							"/sys/bus/pci/drivers/vfio-pci",
+							"/sys/module/vfio/parameters",
+							"/host/proc"},
+						Symlinks: map[string]string{
+							"/sys/bus/pci/devices/0000:d8:00.0/driver": "../../../../bus/pci/drivers/test-driver"},
+						Files: map[string][]byte{
+							"/host/proc/cmdline":                                []byte("BOOT_IMAGE=/vmlinuz root=/dev/sda1"),
+							"/sys/bus/pci/drivers/test-driver/unbind":           {},
+							"/sys/bus/pci/drivers/vfio-pci/bind":                {},
+							"/sys/bus/pci/devices/0000:d8:00.0/driver_override": {}},
+					})
+					origWrite, origAllow := writeDriverFile, vars.AllowVfioNoIommu
+					// vfio-pci rejects the device until the no-IOMMU mode is enabled
+					writeDriverFile = func(name string, data []byte, perm os.FileMode) error {
+						noIommu, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.SysVfioNoIommuMode))
+						if strings.HasSuffix(name, "vfio-pci/bind") && (err != nil || string(noIommu) != "Y") {
+							return &os.PathError{Op: "write", Path: name, Err: syscall.EINVAL}
+						}
+						return os.WriteFile(name, data, perm)
+					}
+					DeferCleanup(func() {
+						writeDriverFile, vars.AllowVfioNoIommu = origWrite, origAllow
+					})
+				})
+				It("kernel args not set", func() {
+					vars.AllowVfioNoIommu = false
+					err := k.BindDpdkDriver("0000:d8:00.0", "vfio-pci")
+					Expect(err).To(MatchError(ContainSubstring(consts.KernelArgIntelIommu)))
+					Expect(err).To(MatchError(ContainSubstring(consts.KernelArgAmdIommu)))
+				})
+				It("no-IOMMU mode allowed", func() {
+					vars.AllowVfioNoIommu = true
+					Expect(k.BindDpdkDriver("0000:d8:00.0", "vfio-pci")).NotTo(HaveOccurred())
+					helpers.GinkgoAssertFileContentsEquals(consts.SysVfioNoIommuMode, "Y")
+					helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/drivers/vfio-pci/bind", "0000:d8:00.0")
+				})
+			})
 		})
 		Context("BindDriverByBusAndDevice", func() {
 			It("device doesn't support driver_override", func() {
@@ -240,5 +367,122 @@ var _ = Describe("Kernel", func() {
 				Expect(k.IsKernelLockdownMode()).To(BeFalse())
 			})
 		})
+
+		Context("ValidateRequiredModules", func() {
+			BeforeEach(func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs: []string{"/proc", "/sys/module/vfio_iommu_type1"},
+					Files: map[string][]byte{"/proc/modules": []byte(
+						"mlx5_core 2314240 1 mlx5_ib, Live 0x0000000000000000\n" +
+							"vfio_pci 16384 0 - Live 0x0000000000000000\n")},
+				})
+			})
+			It("all modules loaded", func() {
+				Expect(k.ValidateRequiredModules([]string{"mlx5_core", "vfio-pci", "vfio_iommu_type1"})).To(BeEmpty())
+			})
+			It("some modules missing", func() {
+				Expect(k.ValidateRequiredModules([]string{"mlx5_core", "vhost_vdpa", "ice"})).To(Equal([]string{"vhost_vdpa", "ice"}))
+			})
+			It("no modules required", func() {
+				Expect(k.ValidateRequiredModules(nil)).To(BeEmpty())
+			})
+			It("fail to read the loaded modules", func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+				_, err := k.ValidateRequiredModules([]string{"mlx5_core"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("IommuKernelArgForHost", func() {
+			configureCpuInfo := func(vendors ...string) {
+				cpuInfo := ""
+				for i, vendor := range vendors {
+					cpuInfo += fmt.Sprintf("processor\t: %d\nvendor_id\t: %s\ncpu family\t: 6\n\n", i, vendor)
+				}
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs:  []string{"/host/proc"},
+					Files: map[string][]byte{"/host/proc/cpuinfo": []byte(cpuInfo)},
+				})
+			}
+			It("intel", func() {
+				configureCpuInfo(consts.CpuVendorIntel, consts.CpuVendorIntel)
+				Expect(k.IommuKernelArgForHost()).To(Equal(consts.KernelArgIntelIommu))
+			})
+			It("amd", func() {
+				configureCpuInfo(consts.CpuVendorAmd, consts.CpuVendorAmd)
+				Expect(k.IommuKernelArgForHost()).To(Equal(consts.KernelArgAmdIommu))
+			})
+			It("mixed vendors", func() {
+				configureCpuInfo(consts.CpuVendorIntel, consts.CpuVendorAmd)
+				_, err := k.IommuKernelArgForHost()
+				Expect(err).To(MatchError(ContainSubstring("mixed CPU vendors")))
+			})
+			It("unknown vendor", func() {
+				configureCpuInfo("HygonGenuine")
+				_, err := k.IommuKernelArgForHost()
+				Expect(err).To(MatchError(ContainSubstring("unknown CPU vendor")))
+			})
+		})
+	})
+	Context("Kernel args", func() {
+		var (
+			k types.KernelInterface
+		)
+		BeforeEach(func() {
+			k = New(utils.New())
+		})
+		It("should return the value of the last occurrence of the key", func() {
+			cmdLine := "ro hugepagesz=2M hugepages=16 hugepagesz=1G intel_iommu=on quiet\n"
+			value, present := k.GetKernelArgValue(cmdLine, "hugepagesz")
+			Expect(present).To(BeTrue())
+			Expect(value).To(Equal("1G"))
+			value, present = k.GetKernelArgValue(cmdLine, "quiet")
+			Expect(present).To(BeTrue())
+			Expect(value).To(BeEmpty())
+			_, present = k.GetKernelArgValue(cmdLine, "iommu")
+			Expect(present).To(BeFalse())
+		})
+		It("should match the key whatever its value", func() {
+			cmdLine := "ro hugepagesz=1G iommu.passthrough=1"
+			Expect(k.IsKernelArgKeySet(cmdLine, "hugepagesz")).To(BeTrue())
+			Expect(k.IsKernelArgKeySet(cmdLine, "ro")).To(BeTrue())
+			Expect(k.IsKernelArgKeySet(cmdLine, "hugepages")).To(BeFalse())
+			Expect(k.IsKernelArgKeySet(cmdLine, "iommu")).To(BeFalse())
+		})
+		It("should take the last occurrence of a duplicated key", func() {
+			Expect(k.IsKernelArgsSet("intel_iommu=on intel_iommu=off", consts.KernelArgIntelIommu)).To(BeFalse())
+			Expect(k.IsKernelArgsSet("intel_iommu=off intel_iommu=on", consts.KernelArgIntelIommu)).To(BeTrue())
+			Expect(k.IsKernelArgsSet("ro intel_iommu=on iommu=pt", consts.KernelArgIommuPt)).To(BeTrue())
+			Expect(k.IsKernelArgsSet("ro intel_iommu=on", consts.KernelArgIommuPt)).To(BeFalse())
+		})
+		It("should compute the hugepage kernel args", func() {
+			Expect(k.HugepageKernelArgs("ro quiet", "1G", 16)).To(
+				Equal([]string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=16"}))
+		})
+		It("should keep the hugepage kernel args already set", func() {
+			cmdLine := "ro default_hugepagesz=2M hugepagesz=2M hugepages=1024"
+			Expect(k.HugepageKernelArgs(cmdLine, "1G", 16)).To(Equal([]string{"hugepagesz=1G", "hugepages=16"}))
+			Expect(k.HugepageKernelArgs(cmdLine, "2048K", 1024)).To(BeEmpty())
+			Expect(k.HugepageKernelArgs(cmdLine+" hugepagesz=1G hugepages=16", "1G", 16)).To(BeEmpty())
+		})
+		It("should not change the default size of the hugepages allocated without size", func() {
+			Expect(k.HugepageKernelArgs("ro hugepages=1024", "1G", 16)).To(Equal([]string{"hugepagesz=1G", "hugepages=16"}))
+			Expect(k.HugepageKernelArgs("default_hugepagesz=1G hugepages=16", "1G", 16)).To(BeEmpty())
+		})
+		It("should update the count of the hugepages of the size allocated with another count", func() {
+			Expect(k.HugepageKernelArgs("default_hugepagesz=2M hugepagesz=1G hugepages=8 hugepagesz=2M hugepages=1024", "1G", 16)).To(
+				Equal([]string{"hugepagesz=1G", "hugepages=16"}))
+			Expect(k.HugepageKernelArgs("default_hugepagesz=1G hugepagesz=1G hugepages=8", "1G", 16)).To(
+				Equal([]string{"hugepagesz=1G", "hugepages=16"}))
+		})
+		It("should skip the hugepages of the default size allocated without size with another count", func() {
+			Expect(k.HugepageKernelArgs("default_hugepagesz=1G hugepages=8", "1G", 16)).To(BeEmpty())
+		})
+		It("should fail with an invalid size or count", func() {
+			_, err := k.HugepageKernelArgs("", "1X", 16)
+			Expect(err).To(MatchError(`invalid hugepage size "1X"`))
+			_, err = k.HugepageKernelArgs("", "2M", 0)
+			Expect(err).To(MatchError("invalid hugepage count 0"))
+		})
 	})
 })
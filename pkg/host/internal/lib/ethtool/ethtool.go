@@ -1,9 +1,42 @@
 package ethtool
 
 import (
+	"syscall"
+	"unsafe"
+
 	"github.com/safchain/ethtool"
 )
 
+type Channels = ethtool.Channels
+
+type DrvInfo = ethtool.DrvInfo
+
+// ring parameters ioctl commands from uapi/linux/ethtool.h, not provided by the ethtool library
+const (
+	ethtoolGRingParam = 0x00000010
+	ethtoolSRingParam = 0x00000011
+)
+
+// Ring contains the RX/TX ring parameters of an interface, the layout matches struct ethtool_ringparam
+type Ring struct {
+	Cmd               uint32
+	RxMaxPending      uint32
+	RxMiniMaxPending  uint32
+	RxJumboMaxPending uint32
+	TxMaxPending      uint32
+	RxPending         uint32
+	RxMiniPending     uint32
+	RxJumboPending    uint32
+	TxPending         uint32
+}
+
+// ifreq matches the layout of struct ifreq used by the SIOCETHTOOL ioctl
+type ifreq struct {
+	name [ethtool.IFNAMSIZ]byte
+	data uintptr
+	_    [16]byte
+}
+
 func New() EthtoolLib {
 	return &libWrapper{}
 }
@@ -16,6 +49,16 @@ type EthtoolLib interface {
 	FeatureNames(ifaceName string) (map[string]uint, error)
 	// Change requests a change in the given device's features.
 	Change(ifaceName string, config map[string]bool) error
+	// GetChannels retrieves the channels configuration of the given interface name.
+	GetChannels(ifaceName string) (Channels, error)
+	// SetChannels sets the channels configuration of the given interface name.
+	SetChannels(ifaceName string, channels Channels) (Channels, error)
+	// DriverInfo retrieves the driver information, including the firmware version, of the given interface name.
+	DriverInfo(ifaceName string) (DrvInfo, error)
+	// GetRing retrieves the RX/TX ring parameters of the given interface name.
+	GetRing(ifaceName string) (Ring, error)
+	// SetRing sets the RX/TX ring parameters of the given interface name.
+	SetRing(ifaceName string, ring Ring) error
 }
 
 type libWrapper struct{}
@@ -49,3 +92,65 @@ func (w *libWrapper) Change(ifaceName string, config map[string]bool) error {
 	defer e.Close()
 	return e.Change(ifaceName, config)
 }
+
+// GetChannels retrieves the channels configuration of the given interface name.
+func (w *libWrapper) GetChannels(ifaceName string) (Channels, error) {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return Channels{}, err
+	}
+	defer e.Close()
+	return e.GetChannels(ifaceName)
+}
+
+// SetChannels sets the channels configuration of the given interface name.
+func (w *libWrapper) SetChannels(ifaceName string, channels Channels) (Channels, error) {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return Channels{}, err
+	}
+	defer e.Close()
+	return e.SetChannels(ifaceName, channels)
+}
+
+// DriverInfo retrieves the driver information, including the firmware version, of the given interface name.
+func (w *libWrapper) DriverInfo(ifaceName string) (DrvInfo, error) {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return DrvInfo{}, err
+	}
+	defer e.Close()
+	return e.DriverInfo(ifaceName)
+}
+
+// GetRing retrieves the RX/TX ring parameters of the given interface name.
+func (w *libWrapper) GetRing(ifaceName string) (Ring, error) {
+	ring := Ring{Cmd: ethtoolGRingParam}
+	if err := ethtoolIoctl(ifaceName, uintptr(unsafe.Pointer(&ring))); err != nil {
+		return Ring{}, err
+	}
+	return ring, nil
+}
+
+// SetRing sets the RX/TX ring parameters of the given interface name.
+func (w *libWrapper) SetRing(ifaceName string, ring Ring) error {
+	ring.Cmd = ethtoolSRingParam
+	return ethtoolIoctl(ifaceName, uintptr(unsafe.Pointer(&ring)))
+}
+
+// ethtoolIoctl runs the SIOCETHTOOL ioctl for the given interface name with the provided command data
+func ethtoolIoctl(ifaceName string, data uintptr) error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	ifr := ifreq{data: data}
+	copy(ifr.name[:ethtool.IFNAMSIZ-1], ifaceName)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ethtool.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
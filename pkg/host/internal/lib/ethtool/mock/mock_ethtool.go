@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	ethtool "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/ethtool"
 )
 
 // MockEthtoolLib is a mock of EthtoolLib interface.
@@ -47,6 +48,21 @@ func (mr *MockEthtoolLibMockRecorder) Change(ifaceName, config interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Change", reflect.TypeOf((*MockEthtoolLib)(nil).Change), ifaceName, config)
 }
 
+// DriverInfo mocks base method.
+func (m *MockEthtoolLib) DriverInfo(ifaceName string) (ethtool.DrvInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DriverInfo", ifaceName)
+	ret0, _ := ret[0].(ethtool.DrvInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DriverInfo indicates an expected call of DriverInfo.
+func (mr *MockEthtoolLibMockRecorder) DriverInfo(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DriverInfo", reflect.TypeOf((*MockEthtoolLib)(nil).DriverInfo), ifaceName)
+}
+
 // FeatureNames mocks base method.
 func (m *MockEthtoolLib) FeatureNames(ifaceName string) (map[string]uint, error) {
 	m.ctrl.T.Helper()
@@ -76,3 +92,62 @@ func (mr *MockEthtoolLibMockRecorder) Features(ifaceName interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Features", reflect.TypeOf((*MockEthtoolLib)(nil).Features), ifaceName)
 }
+
+// GetChannels mocks base method.
+func (m *MockEthtoolLib) GetChannels(ifaceName string) (ethtool.Channels, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannels", ifaceName)
+	ret0, _ := ret[0].(ethtool.Channels)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChannels indicates an expected call of GetChannels.
+func (mr *MockEthtoolLibMockRecorder) GetChannels(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannels", reflect.TypeOf((*MockEthtoolLib)(nil).GetChannels), ifaceName)
+}
+
+// GetRing mocks base method.
+func (m *MockEthtoolLib) GetRing(ifaceName string) (ethtool.Ring, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRing", ifaceName)
+	ret0, _ := ret[0].(ethtool.Ring)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRing indicates an expected call of GetRing.
+func (mr *MockEthtoolLibMockRecorder) GetRing(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRing", reflect.TypeOf((*MockEthtoolLib)(nil).GetRing), ifaceName)
+}
+
+// SetChannels mocks base method.
+func (m *MockEthtoolLib) SetChannels(ifaceName string, channels ethtool.Channels) (ethtool.Channels, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChannels", ifaceName, channels)
+	ret0, _ := ret[0].(ethtool.Channels)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetChannels indicates an expected call of SetChannels.
+func (mr *MockEthtoolLibMockRecorder) SetChannels(ifaceName, channels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannels", reflect.TypeOf((*MockEthtoolLib)(nil).SetChannels), ifaceName, channels)
+}
+
+// SetRing mocks base method.
+func (m *MockEthtoolLib) SetRing(ifaceName string, ring ethtool.Ring) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRing", ifaceName, ring)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRing indicates an expected call of SetRing.
+func (mr *MockEthtoolLibMockRecorder) SetRing(ifaceName, ring interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRing", reflect.TypeOf((*MockEthtoolLib)(nil).SetRing), ifaceName, ring)
+}
@@ -19,6 +19,9 @@ type Info interface {
 	// ListDevices returns a list of pointers to Device structs present on the
 	// host system
 	ListDevices() []*ghw.PCIDevice
+	// GetDevice returns a pointer to a Device struct that describes the PCI
+	// device at the requested address, nil if the device is not found
+	GetDevice(address string) *ghw.PCIDevice
 }
 
 type libWrapper struct{}
@@ -73,6 +73,20 @@ func (m *MockInfo) EXPECT() *MockInfoMockRecorder {
 	return m.recorder
 }
 
+// GetDevice mocks base method.
+func (m *MockInfo) GetDevice(address string) *ghw.PCIDevice {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevice", address)
+	ret0, _ := ret[0].(*ghw.PCIDevice)
+	return ret0
+}
+
+// GetDevice indicates an expected call of GetDevice.
+func (mr *MockInfoMockRecorder) GetDevice(address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevice", reflect.TypeOf((*MockInfo)(nil).GetDevice), address)
+}
+
 // ListDevices mocks base method.
 func (m *MockInfo) ListDevices() []*ghw.PCIDevice {
 	m.ctrl.T.Helper()
@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	netlink "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/netlink"
 	netlink0 "github.com/vishvananda/netlink"
+	nl "github.com/vishvananda/netlink/nl"
 )
 
 // MockLink is a mock of Link interface.
@@ -87,6 +88,93 @@ func (m *MockNetlinkLib) EXPECT() *MockNetlinkLibMockRecorder {
 	return m.recorder
 }
 
+// BridgeVlanAdd mocks base method.
+func (m *MockNetlinkLib) BridgeVlanAdd(link netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BridgeVlanAdd", link, vid, pvid, untagged, self, master)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BridgeVlanAdd indicates an expected call of BridgeVlanAdd.
+func (mr *MockNetlinkLibMockRecorder) BridgeVlanAdd(link, vid, pvid, untagged, self, master interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BridgeVlanAdd", reflect.TypeOf((*MockNetlinkLib)(nil).BridgeVlanAdd), link, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanDel mocks base method.
+func (m *MockNetlinkLib) BridgeVlanDel(link netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BridgeVlanDel", link, vid, pvid, untagged, self, master)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BridgeVlanDel indicates an expected call of BridgeVlanDel.
+func (mr *MockNetlinkLibMockRecorder) BridgeVlanDel(link, vid, pvid, untagged, self, master interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BridgeVlanDel", reflect.TypeOf((*MockNetlinkLib)(nil).BridgeVlanDel), link, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanList mocks base method.
+func (m *MockNetlinkLib) BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BridgeVlanList")
+	ret0, _ := ret[0].(map[int32][]*nl.BridgeVlanInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BridgeVlanList indicates an expected call of BridgeVlanList.
+func (mr *MockNetlinkLibMockRecorder) BridgeVlanList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BridgeVlanList", reflect.TypeOf((*MockNetlinkLib)(nil).BridgeVlanList))
+}
+
+// DcbIeeeGet mocks base method.
+func (m *MockNetlinkLib) DcbIeeeGet(linkName string) (*netlink.DcbIeee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DcbIeeeGet", linkName)
+	ret0, _ := ret[0].(*netlink.DcbIeee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DcbIeeeGet indicates an expected call of DcbIeeeGet.
+func (mr *MockNetlinkLibMockRecorder) DcbIeeeGet(linkName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DcbIeeeGet", reflect.TypeOf((*MockNetlinkLib)(nil).DcbIeeeGet), linkName)
+}
+
+// DcbIeeeSet mocks base method.
+func (m *MockNetlinkLib) DcbIeeeSet(linkName string, dcb *netlink.DcbIeee) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DcbIeeeSet", linkName, dcb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DcbIeeeSet indicates an expected call of DcbIeeeSet.
+func (mr *MockNetlinkLibMockRecorder) DcbIeeeSet(linkName, dcb interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DcbIeeeSet", reflect.TypeOf((*MockNetlinkLib)(nil).DcbIeeeSet), linkName, dcb)
+}
+
+// DevLinkGetAllPortList mocks base method.
+func (m *MockNetlinkLib) DevLinkGetAllPortList() ([]*netlink0.DevlinkPort, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevLinkGetAllPortList")
+	ret0, _ := ret[0].([]*netlink0.DevlinkPort)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DevLinkGetAllPortList indicates an expected call of DevLinkGetAllPortList.
+func (mr *MockNetlinkLibMockRecorder) DevLinkGetAllPortList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevLinkGetAllPortList", reflect.TypeOf((*MockNetlinkLib)(nil).DevLinkGetAllPortList))
+}
+
 // DevLinkGetDeviceByName mocks base method.
 func (m *MockNetlinkLib) DevLinkGetDeviceByName(bus, device string) (*netlink0.DevlinkDevice, error) {
 	m.ctrl.T.Helper()
@@ -102,6 +190,34 @@ func (mr *MockNetlinkLibMockRecorder) DevLinkGetDeviceByName(bus, device interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevLinkGetDeviceByName", reflect.TypeOf((*MockNetlinkLib)(nil).DevLinkGetDeviceByName), bus, device)
 }
 
+// DevLinkSetEswitchEncapMode mocks base method.
+func (m *MockNetlinkLib) DevLinkSetEswitchEncapMode(dev *netlink0.DevlinkDevice, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevLinkSetEswitchEncapMode", dev, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevLinkSetEswitchEncapMode indicates an expected call of DevLinkSetEswitchEncapMode.
+func (mr *MockNetlinkLibMockRecorder) DevLinkSetEswitchEncapMode(dev, enabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevLinkSetEswitchEncapMode", reflect.TypeOf((*MockNetlinkLib)(nil).DevLinkSetEswitchEncapMode), dev, enabled)
+}
+
+// DevLinkSetEswitchInlineMode mocks base method.
+func (m *MockNetlinkLib) DevLinkSetEswitchInlineMode(dev *netlink0.DevlinkDevice, mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevLinkSetEswitchInlineMode", dev, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevLinkSetEswitchInlineMode indicates an expected call of DevLinkSetEswitchInlineMode.
+func (mr *MockNetlinkLibMockRecorder) DevLinkSetEswitchInlineMode(dev, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevLinkSetEswitchInlineMode", reflect.TypeOf((*MockNetlinkLib)(nil).DevLinkSetEswitchInlineMode), dev, mode)
+}
+
 // DevLinkSetEswitchMode mocks base method.
 func (m *MockNetlinkLib) DevLinkSetEswitchMode(dev *netlink0.DevlinkDevice, newMode string) error {
 	m.ctrl.T.Helper()
@@ -131,6 +247,78 @@ func (mr *MockNetlinkLibMockRecorder) DevlinkGetDeviceParamByName(bus, device, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkGetDeviceParamByName", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkGetDeviceParamByName), bus, device, param)
 }
 
+// DevlinkGetDeviceResources mocks base method.
+func (m *MockNetlinkLib) DevlinkGetDeviceResources(bus, device string) (*netlink0.DevlinkResources, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkGetDeviceResources", bus, device)
+	ret0, _ := ret[0].(*netlink0.DevlinkResources)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DevlinkGetDeviceResources indicates an expected call of DevlinkGetDeviceResources.
+func (mr *MockNetlinkLibMockRecorder) DevlinkGetDeviceResources(bus, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkGetDeviceResources", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkGetDeviceResources), bus, device)
+}
+
+// DevlinkPortFnRateParentGet mocks base method.
+func (m *MockNetlinkLib) DevlinkPortFnRateParentGet(bus, device string, portIndex uint32) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkPortFnRateParentGet", bus, device, portIndex)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DevlinkPortFnRateParentGet indicates an expected call of DevlinkPortFnRateParentGet.
+func (mr *MockNetlinkLibMockRecorder) DevlinkPortFnRateParentGet(bus, device, portIndex interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkPortFnRateParentGet", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkPortFnRateParentGet), bus, device, portIndex)
+}
+
+// DevlinkPortFnRateParentSet mocks base method.
+func (m *MockNetlinkLib) DevlinkPortFnRateParentSet(bus, device string, portIndex uint32, parent string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkPortFnRateParentSet", bus, device, portIndex, parent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevlinkPortFnRateParentSet indicates an expected call of DevlinkPortFnRateParentSet.
+func (mr *MockNetlinkLibMockRecorder) DevlinkPortFnRateParentSet(bus, device, portIndex, parent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkPortFnRateParentSet", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkPortFnRateParentSet), bus, device, portIndex, parent)
+}
+
+// DevlinkPortFnRateSet mocks base method.
+func (m *MockNetlinkLib) DevlinkPortFnRateSet(bus, device string, portIndex uint32, txShare, txMax uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkPortFnRateSet", bus, device, portIndex, txShare, txMax)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevlinkPortFnRateSet indicates an expected call of DevlinkPortFnRateSet.
+func (mr *MockNetlinkLibMockRecorder) DevlinkPortFnRateSet(bus, device, portIndex, txShare, txMax interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkPortFnRateSet", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkPortFnRateSet), bus, device, portIndex, txShare, txMax)
+}
+
+// DevlinkRateNodeNew mocks base method.
+func (m *MockNetlinkLib) DevlinkRateNodeNew(bus, device, nodeName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkRateNodeNew", bus, device, nodeName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevlinkRateNodeNew indicates an expected call of DevlinkRateNodeNew.
+func (mr *MockNetlinkLibMockRecorder) DevlinkRateNodeNew(bus, device, nodeName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkRateNodeNew", reflect.TypeOf((*MockNetlinkLib)(nil).DevlinkRateNodeNew), bus, device, nodeName)
+}
+
 // DevlinkSetDeviceParam mocks base method.
 func (m *MockNetlinkLib) DevlinkSetDeviceParam(bus, device, param string, cmode uint8, value interface{}) error {
 	m.ctrl.T.Helper()
@@ -159,6 +347,21 @@ func (mr *MockNetlinkLibMockRecorder) IsLinkAdminStateUp(link interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLinkAdminStateUp", reflect.TypeOf((*MockNetlinkLib)(nil).IsLinkAdminStateUp), link)
 }
 
+// LinkByIndex mocks base method.
+func (m *MockNetlinkLib) LinkByIndex(index int) (netlink.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkByIndex", index)
+	ret0, _ := ret[0].(netlink.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkByIndex indicates an expected call of LinkByIndex.
+func (mr *MockNetlinkLibMockRecorder) LinkByIndex(index interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkByIndex", reflect.TypeOf((*MockNetlinkLib)(nil).LinkByIndex), index)
+}
+
 // LinkByName mocks base method.
 func (m *MockNetlinkLib) LinkByName(name string) (netlink.Link, error) {
 	m.ctrl.T.Helper()
@@ -174,6 +377,35 @@ func (mr *MockNetlinkLibMockRecorder) LinkByName(name interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkByName", reflect.TypeOf((*MockNetlinkLib)(nil).LinkByName), name)
 }
 
+// LinkGetMaxMTU mocks base method.
+func (m *MockNetlinkLib) LinkGetMaxMTU(link netlink.Link) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkGetMaxMTU", link)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkGetMaxMTU indicates an expected call of LinkGetMaxMTU.
+func (mr *MockNetlinkLibMockRecorder) LinkGetMaxMTU(link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkGetMaxMTU", reflect.TypeOf((*MockNetlinkLib)(nil).LinkGetMaxMTU), link)
+}
+
+// LinkSetDown mocks base method.
+func (m *MockNetlinkLib) LinkSetDown(link netlink.Link) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetDown", link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetDown indicates an expected call of LinkSetDown.
+func (mr *MockNetlinkLibMockRecorder) LinkSetDown(link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetDown", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetDown), link)
+}
+
 // LinkSetMTU mocks base method.
 func (m *MockNetlinkLib) LinkSetMTU(link netlink.Link, mtu int) error {
 	m.ctrl.T.Helper()
@@ -188,6 +420,34 @@ func (mr *MockNetlinkLibMockRecorder) LinkSetMTU(link, mtu interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetMTU", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetMTU), link, mtu)
 }
 
+// LinkSetNsByName mocks base method.
+func (m *MockNetlinkLib) LinkSetNsByName(link netlink.Link, nsName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetNsByName", link, nsName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetNsByName indicates an expected call of LinkSetNsByName.
+func (mr *MockNetlinkLibMockRecorder) LinkSetNsByName(link, nsName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetNsByName", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetNsByName), link, nsName)
+}
+
+// LinkSetTxQLen mocks base method.
+func (m *MockNetlinkLib) LinkSetTxQLen(link netlink.Link, qlen int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetTxQLen", link, qlen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetTxQLen indicates an expected call of LinkSetTxQLen.
+func (mr *MockNetlinkLibMockRecorder) LinkSetTxQLen(link, qlen interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetTxQLen", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetTxQLen), link, qlen)
+}
+
 // LinkSetUp mocks base method.
 func (m *MockNetlinkLib) LinkSetUp(link netlink.Link) error {
 	m.ctrl.T.Helper()
@@ -244,6 +504,76 @@ func (mr *MockNetlinkLibMockRecorder) LinkSetVfPortGUID(link, vf, portguid inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfPortGUID", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfPortGUID), link, vf, portguid)
 }
 
+// LinkSetVfRate mocks base method.
+func (m *MockNetlinkLib) LinkSetVfRate(link netlink.Link, vf, minRate, maxRate int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetVfRate", link, vf, minRate, maxRate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetVfRate indicates an expected call of LinkSetVfRate.
+func (mr *MockNetlinkLibMockRecorder) LinkSetVfRate(link, vf, minRate, maxRate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfRate", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfRate), link, vf, minRate, maxRate)
+}
+
+// LinkSetVfSpoofchk mocks base method.
+func (m *MockNetlinkLib) LinkSetVfSpoofchk(link netlink.Link, vf int, check bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetVfSpoofchk", link, vf, check)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetVfSpoofchk indicates an expected call of LinkSetVfSpoofchk.
+func (mr *MockNetlinkLibMockRecorder) LinkSetVfSpoofchk(link, vf, check interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfSpoofchk", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfSpoofchk), link, vf, check)
+}
+
+// LinkSetVfState mocks base method.
+func (m *MockNetlinkLib) LinkSetVfState(link netlink.Link, vf int, state uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetVfState", link, vf, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetVfState indicates an expected call of LinkSetVfState.
+func (mr *MockNetlinkLibMockRecorder) LinkSetVfState(link, vf, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfState", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfState), link, vf, state)
+}
+
+// LinkSetVfTrust mocks base method.
+func (m *MockNetlinkLib) LinkSetVfTrust(link netlink.Link, vf int, state bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetVfTrust", link, vf, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetVfTrust indicates an expected call of LinkSetVfTrust.
+func (mr *MockNetlinkLibMockRecorder) LinkSetVfTrust(link, vf, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfTrust", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfTrust), link, vf, state)
+}
+
+// LinkSetVfVlanQosProto mocks base method.
+func (m *MockNetlinkLib) LinkSetVfVlanQosProto(link netlink.Link, vf, vlan, qos, proto int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetVfVlanQosProto", link, vf, vlan, qos, proto)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetVfVlanQosProto indicates an expected call of LinkSetVfVlanQosProto.
+func (mr *MockNetlinkLibMockRecorder) LinkSetVfVlanQosProto(link, vf, vlan, qos, proto interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfVlanQosProto", reflect.TypeOf((*MockNetlinkLib)(nil).LinkSetVfVlanQosProto), link, vf, vlan, qos, proto)
+}
+
 // RdmaLinkByName mocks base method.
 func (m *MockNetlinkLib) RdmaLinkByName(name string) (*netlink0.RdmaLink, error) {
 	m.ctrl.T.Helper()
@@ -259,6 +589,50 @@ func (mr *MockNetlinkLibMockRecorder) RdmaLinkByName(name interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RdmaLinkByName", reflect.TypeOf((*MockNetlinkLib)(nil).RdmaLinkByName), name)
 }
 
+// RdmaSystemGetNetnsMode mocks base method.
+func (m *MockNetlinkLib) RdmaSystemGetNetnsMode() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RdmaSystemGetNetnsMode")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RdmaSystemGetNetnsMode indicates an expected call of RdmaSystemGetNetnsMode.
+func (mr *MockNetlinkLibMockRecorder) RdmaSystemGetNetnsMode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RdmaSystemGetNetnsMode", reflect.TypeOf((*MockNetlinkLib)(nil).RdmaSystemGetNetnsMode))
+}
+
+// RdmaSystemSetNetnsMode mocks base method.
+func (m *MockNetlinkLib) RdmaSystemSetNetnsMode(mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RdmaSystemSetNetnsMode", mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RdmaSystemSetNetnsMode indicates an expected call of RdmaSystemSetNetnsMode.
+func (mr *MockNetlinkLibMockRecorder) RdmaSystemSetNetnsMode(mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RdmaSystemSetNetnsMode", reflect.TypeOf((*MockNetlinkLib)(nil).RdmaSystemSetNetnsMode), mode)
+}
+
+// RouteList mocks base method.
+func (m *MockNetlinkLib) RouteList(link netlink.Link, family int) ([]netlink0.Route, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RouteList", link, family)
+	ret0, _ := ret[0].([]netlink0.Route)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RouteList indicates an expected call of RouteList.
+func (mr *MockNetlinkLibMockRecorder) RouteList(link, family interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RouteList", reflect.TypeOf((*MockNetlinkLib)(nil).RouteList), link, family)
+}
+
 // VDPADelDev mocks base method.
 func (m *MockNetlinkLib) VDPADelDev(name string) error {
 	m.ctrl.T.Helper()
@@ -1,11 +1,57 @@
 package netlink
 
 import (
+	"fmt"
 	"net"
+	"strings"
+	"syscall"
 
 	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
 )
 
+// devlink rate constants which are not defined by the netlink library, see include/uapi/linux/devlink.h
+const (
+	devlinkCmdRateGet             = 74
+	devlinkCmdRateSet             = 75
+	devlinkCmdRateNew             = 76
+	devlinkAttrRateTxShare        = 166
+	devlinkAttrRateTxMax          = 167
+	devlinkAttrRateNodeName       = 168
+	devlinkAttrRateParentNodeName = 169
+)
+
+// link attribute not parsed by the netlink library, see include/uapi/linux/if_link.h
+const iflaMaxMtu = 51
+
+// DCB netlink constants which are not defined by the netlink library, see include/uapi/linux/dcbnl.h
+const (
+	dcbCmdIeeeSet  = 20
+	dcbCmdIeeeGet  = 21
+	dcbAttrIfname  = 1
+	dcbAttrIeee    = 13
+	dcbAttrIeeeEts = 1
+	dcbAttrIeeePfc = 2
+	// struct dcbmsg, ieee_ets and ieee_pfc sizes and field offsets
+	dcbMsgLen        = 4
+	ieeeEtsLen       = 59
+	ieeeEtsCapOff    = 1
+	ieeeEtsPrioTcOff = 27
+	ieeePfcLen       = 136
+	ieeePfcEnOff     = 1
+)
+
+// DcbIeee is the IEEE 802.1Qaz/802.1Qbb DCB configuration of a link
+type DcbIeee struct {
+	// EtsCap is the number of traffic classes supported by the link
+	EtsCap uint8
+	// PfcEnabled is the bitmask of the priorities with PFC enabled
+	PfcEnabled uint8
+	// PrioTc is the traffic class of each priority
+	PrioTc [8]uint8
+}
+
 func New() NetlinkLib {
 	return &libWrapper{}
 }
@@ -24,15 +70,56 @@ type NetlinkLib interface {
 	LinkSetVfPortGUID(link Link, vf int, portguid net.HardwareAddr) error
 	// LinkByName finds a link by name and returns a pointer to the object.
 	LinkByName(name string) (Link, error)
+	// LinkByIndex finds a link by index and returns a pointer to the object.
+	LinkByIndex(index int) (Link, error)
+	// RouteList gets a list of routes in the system, the list can be filtered by link and ip family.
+	// Equivalent to: `ip route show`
+	RouteList(link Link, family int) ([]netlink.Route, error)
 	// LinkSetVfHardwareAddr sets the hardware address of a vf for the link.
 	// Equivalent to: `ip link set $link vf $vf mac $hwaddr`
 	LinkSetVfHardwareAddr(link Link, vf int, hwaddr net.HardwareAddr) error
+	// LinkSetVfVlanQosProto sets the vlan, qos and protocol of a vf for the link.
+	// Equivalent to: `ip link set $link vf $vf vlan $vlan qos $qos proto $proto`
+	LinkSetVfVlanQosProto(link Link, vf, vlan, qos, proto int) error
+	// LinkSetVfSpoofchk enables/disables spoof check on a vf for the link.
+	// Equivalent to: `ip link set $link vf $vf spoofchk $check`
+	LinkSetVfSpoofchk(link Link, vf int, check bool) error
+	// LinkSetVfTrust enables/disables trust state on a vf for the link.
+	// Equivalent to: `ip link set $link vf $vf trust $state`
+	LinkSetVfTrust(link Link, vf int, state bool) error
+	// LinkSetVfRate sets the min and max tx rate of a vf for the link.
+	// Equivalent to: `ip link set $link vf $vf min_tx_rate $min_rate max_tx_rate $max_rate`
+	LinkSetVfRate(link Link, vf int, minRate int, maxRate int) error
+	// LinkSetVfState sets the administrative link state of a vf for the link.
+	// Equivalent to: `ip link set $link vf $vf state $state`
+	LinkSetVfState(link Link, vf int, state uint32) error
+	// BridgeVlanList gets a map of device id to bridge vlan infos.
+	// Equivalent to: `bridge vlan show`
+	BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error)
+	// BridgeVlanAdd adds a new vlan filter entry
+	// Equivalent to: `bridge vlan add dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+	BridgeVlanAdd(link Link, vid uint16, pvid, untagged, self, master bool) error
+	// BridgeVlanDel removes a vlan filter entry
+	// Equivalent to: `bridge vlan del dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+	BridgeVlanDel(link Link, vid uint16, pvid, untagged, self, master bool) error
+	// LinkSetNsByName moves the link device to the named network namespace.
+	// Equivalent to: `ip link set $link netns $name`
+	LinkSetNsByName(link Link, nsName string) error
 	// LinkSetUp enables the link device.
 	// Equivalent to: `ip link set $link up`
 	LinkSetUp(link Link) error
+	// LinkSetDown disables the link device.
+	// Equivalent to: `ip link set $link down`
+	LinkSetDown(link Link) error
 	// LinkSetMTU sets the mtu of the link device.
 	// Equivalent to: `ip link set $link mtu $mtu`
 	LinkSetMTU(link Link, mtu int) error
+	// LinkGetMaxMTU returns the maximum MTU supported by the link device, 0 if the kernel doesn't report it.
+	// Equivalent to: `ip -d link show $link` (maxmtu)
+	LinkGetMaxMTU(link Link) (int, error)
+	// LinkSetTxQLen sets the transaction queue length of the link device.
+	// Equivalent to: `ip link set $link txqueuelen $qlen`
+	LinkSetTxQLen(link Link, qlen int) error
 	// DevlinkGetDeviceByName provides a pointer to devlink device and nil error,
 	// otherwise returns an error code.
 	DevLinkGetDeviceByName(bus string, device string) (*netlink.DevlinkDevice, error)
@@ -41,6 +128,38 @@ type NetlinkLib interface {
 	// Equivalent to: `devlink dev eswitch set $dev mode switchdev`
 	// Equivalent to: `devlink dev eswitch set $dev mode legacy`
 	DevLinkSetEswitchMode(dev *netlink.DevlinkDevice, newMode string) error
+	// DevLinkSetEswitchInlineMode sets the eswitch inline mode of the device,
+	// allowed modes are "none", "link", "network" and "transport"
+	// Equivalent to: `devlink dev eswitch set $dev inline-mode <mode>`
+	DevLinkSetEswitchInlineMode(dev *netlink.DevlinkDevice, mode string) error
+	// DevLinkSetEswitchEncapMode enables or disables the eswitch encapsulation offload of the device
+	// Equivalent to: `devlink dev eswitch set $dev encap-mode basic|none`
+	DevLinkSetEswitchEncapMode(dev *netlink.DevlinkDevice, enabled bool) error
+	// DevLinkGetAllPortList returns the list of all the devlink ports of the host
+	// Equivalent to: `devlink port show`
+	DevLinkGetAllPortList() ([]*netlink.DevlinkPort, error)
+	// DevlinkPortFnRateSet sets the tx rate limits of the devlink rate object of the port,
+	// txShare and txMax are in bytes per second, 0 means no limit
+	// Equivalent to: `devlink port function rate set <bus>/<device>/<portIndex> tx_share <txShare> tx_max <txMax>`
+	DevlinkPortFnRateSet(bus string, device string, portIndex uint32, txShare, txMax uint64) error
+	// DevlinkRateNodeNew creates a devlink rate node, i.e. a rate group, on the device
+	// Equivalent to: `devlink port function rate add <bus>/<device>/<nodeName>`
+	DevlinkRateNodeNew(bus string, device string, nodeName string) error
+	// DevlinkPortFnRateParentSet attaches the devlink rate object of the port to the rate node, an empty
+	// parent detaches it
+	// Equivalent to: `devlink port function rate set <bus>/<device>/<portIndex> parent <parent>`
+	DevlinkPortFnRateParentSet(bus string, device string, portIndex uint32, parent string) error
+	// DevlinkPortFnRateParentGet returns the rate node the devlink rate object of the port is attached to,
+	// an empty string if it isn't attached to a node
+	// Equivalent to: `devlink port function rate show <bus>/<device>/<portIndex>`
+	DevlinkPortFnRateParentGet(bus string, device string, portIndex uint32) (string, error)
+	// DcbIeeeGet returns the IEEE DCB configuration of the link, fails with EOPNOTSUPP if the link doesn't support DCB
+	// Equivalent to: `dcb ets show dev $link` and `dcb pfc show dev $link`
+	DcbIeeeGet(linkName string) (*DcbIeee, error)
+	// DcbIeeeSet sets the PFC priorities and the priority to traffic class map of the link,
+	// the other ETS and PFC settings of the link are left unchanged
+	// Equivalent to: `dcb ets set dev $link prio-tc <prio>:<tc>...` and `dcb pfc set dev $link prio-pfc <prio>:on|off...`
+	DcbIeeeSet(linkName string, dcb *DcbIeee) error
 	// VDPAGetDevByName returns VDPA device selected by name
 	// Equivalent to: `vdpa dev show <name>`
 	VDPAGetDevByName(name string) (*netlink.VDPADev, error)
@@ -53,6 +172,9 @@ type NetlinkLib interface {
 	// DevlinkGetDeviceParamByName returns specific parameter for devlink device
 	// Equivalent to: `devlink dev param show <bus>/<device> name <param>`
 	DevlinkGetDeviceParamByName(bus string, device string, param string) (*netlink.DevlinkParam, error)
+	// DevlinkGetDeviceResources returns devlink device resources
+	// Equivalent to: `devlink resource show $dev`
+	DevlinkGetDeviceResources(bus string, device string) (*netlink.DevlinkResources, error)
 	// DevlinkSetDeviceParam set specific parameter for devlink device
 	// Equivalent to: `devlink dev param set <bus>/<device> name <param> cmode <cmode> value <value>`
 	// cmode argument should contain valid cmode value as uint8, modes are define in nl.DEVLINK_PARAM_CMODE_* constants
@@ -61,6 +183,12 @@ type NetlinkLib interface {
 	// RdmaLinkByName finds a link by name and returns a pointer to the object if
 	// found and nil error, otherwise returns error code.
 	RdmaLinkByName(name string) (*netlink.RdmaLink, error)
+	// RdmaSystemGetNetnsMode gets the network namespace mode of the RDMA subsystem
+	// Equivalent to: `rdma system show netns`
+	RdmaSystemGetNetnsMode() (string, error)
+	// RdmaSystemSetNetnsMode sets the network namespace mode of the RDMA subsystem
+	// Equivalent to: `rdma system set netns <mode>`
+	RdmaSystemSetNetnsMode(mode string) error
 	// IsLinkAdminStateUp checks if the admin state of a link is up
 	IsLinkAdminStateUp(link Link) bool
 }
@@ -84,24 +212,132 @@ func (w *libWrapper) LinkByName(name string) (Link, error) {
 	return netlink.LinkByName(name)
 }
 
+// LinkByIndex finds a link by index and returns a pointer to the object.
+func (w *libWrapper) LinkByIndex(index int) (Link, error) {
+	return netlink.LinkByIndex(index)
+}
+
+// RouteList gets a list of routes in the system, the list can be filtered by link and ip family.
+// Equivalent to: `ip route show`
+func (w *libWrapper) RouteList(link Link, family int) ([]netlink.Route, error) {
+	return netlink.RouteList(link, family)
+}
+
 // LinkSetVfHardwareAddr sets the hardware address of a vf for the link.
 // Equivalent to: `ip link set $link vf $vf mac $hwaddr`
 func (w *libWrapper) LinkSetVfHardwareAddr(link Link, vf int, hwaddr net.HardwareAddr) error {
 	return netlink.LinkSetVfHardwareAddr(link, vf, hwaddr)
 }
 
+// LinkSetVfVlanQosProto sets the vlan, qos and protocol of a vf for the link.
+// Equivalent to: `ip link set $link vf $vf vlan $vlan qos $qos proto $proto`
+func (w *libWrapper) LinkSetVfVlanQosProto(link Link, vf, vlan, qos, proto int) error {
+	return netlink.LinkSetVfVlanQosProto(link, vf, vlan, qos, proto)
+}
+
+// LinkSetVfSpoofchk enables/disables spoof check on a vf for the link.
+// Equivalent to: `ip link set $link vf $vf spoofchk $check`
+func (w *libWrapper) LinkSetVfSpoofchk(link Link, vf int, check bool) error {
+	return netlink.LinkSetVfSpoofchk(link, vf, check)
+}
+
+// LinkSetVfTrust enables/disables trust state on a vf for the link.
+// Equivalent to: `ip link set $link vf $vf trust $state`
+func (w *libWrapper) LinkSetVfTrust(link Link, vf int, state bool) error {
+	return netlink.LinkSetVfTrust(link, vf, state)
+}
+
+// LinkSetVfRate sets the min and max tx rate of a vf for the link.
+// Equivalent to: `ip link set $link vf $vf min_tx_rate $min_rate max_tx_rate $max_rate`
+func (w *libWrapper) LinkSetVfRate(link Link, vf int, minRate int, maxRate int) error {
+	return netlink.LinkSetVfRate(link, vf, minRate, maxRate)
+}
+
+// LinkSetVfState sets the administrative link state of a vf for the link.
+// Equivalent to: `ip link set $link vf $vf state $state`
+func (w *libWrapper) LinkSetVfState(link Link, vf int, state uint32) error {
+	return netlink.LinkSetVfState(link, vf, state)
+}
+
+// BridgeVlanList gets a map of device id to bridge vlan infos.
+// Equivalent to: `bridge vlan show`
+func (w *libWrapper) BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
+	return netlink.BridgeVlanList()
+}
+
+// BridgeVlanAdd adds a new vlan filter entry
+// Equivalent to: `bridge vlan add dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func (w *libWrapper) BridgeVlanAdd(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return netlink.BridgeVlanAdd(link, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanDel removes a vlan filter entry
+// Equivalent to: `bridge vlan del dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func (w *libWrapper) BridgeVlanDel(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return netlink.BridgeVlanDel(link, vid, pvid, untagged, self, master)
+}
+
+// LinkSetNsByName moves the link device to the named network namespace.
+// Equivalent to: `ip link set $link netns $name`
+func (w *libWrapper) LinkSetNsByName(link Link, nsName string) error {
+	ns, err := netns.GetFromName(nsName)
+	if err != nil {
+		return fmt.Errorf("network namespace %s not found: %w", nsName, err)
+	}
+	defer ns.Close()
+	return netlink.LinkSetNsFd(link, int(ns))
+}
+
 // LinkSetUp enables the link device.
 // Equivalent to: `ip link set $link up`
 func (w *libWrapper) LinkSetUp(link Link) error {
 	return netlink.LinkSetUp(link)
 }
 
+// LinkSetDown disables the link device.
+// Equivalent to: `ip link set $link down`
+func (w *libWrapper) LinkSetDown(link Link) error {
+	return netlink.LinkSetDown(link)
+}
+
 // LinkSetMTU sets the mtu of the link device.
 // Equivalent to: `ip link set $link mtu $mtu`
 func (w *libWrapper) LinkSetMTU(link Link, mtu int) error {
 	return netlink.LinkSetMTU(link, mtu)
 }
 
+// LinkGetMaxMTU returns the maximum MTU supported by the link device, 0 if the kernel doesn't report it.
+// Equivalent to: `ip -d link show $link` (maxmtu)
+func (w *libWrapper) LinkGetMaxMTU(link Link) (int, error) {
+	req := nl.NewNetlinkRequest(syscall.RTM_GETLINK, syscall.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(syscall.AF_UNSPEC)
+	msg.Index = int32(link.Attrs().Index)
+	req.AddData(msg)
+	msgs, err := req.Execute(syscall.NETLINK_ROUTE, syscall.RTM_NEWLINK)
+	if err != nil {
+		return 0, err
+	}
+	if len(msgs) == 0 || len(msgs[0]) < syscall.SizeofIfInfomsg {
+		return 0, fmt.Errorf("invalid link reply for link %s", link.Attrs().Name)
+	}
+	attrs, err := nl.ParseRouteAttr(msgs[0][syscall.SizeofIfInfomsg:])
+	if err != nil {
+		return 0, err
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == iflaMaxMtu && len(attr.Value) >= 4 {
+			return int(nl.NativeEndian().Uint32(attr.Value[:4])), nil
+		}
+	}
+	return 0, nil
+}
+
+// LinkSetTxQLen sets the transaction queue length of the link device.
+// Equivalent to: `ip link set $link txqueuelen $qlen`
+func (w *libWrapper) LinkSetTxQLen(link Link, qlen int) error {
+	return netlink.LinkSetTxQLen(link, qlen)
+}
+
 // DevlinkGetDeviceByName provides a pointer to devlink device and nil error,
 // otherwise returns an error code.
 func (w *libWrapper) DevLinkGetDeviceByName(bus string, device string) (*netlink.DevlinkDevice, error) {
@@ -116,6 +352,214 @@ func (w *libWrapper) DevLinkSetEswitchMode(dev *netlink.DevlinkDevice, newMode s
 	return netlink.DevLinkSetEswitchMode(dev, newMode)
 }
 
+// DevLinkSetEswitchInlineMode sets the eswitch inline mode of the device,
+// allowed modes are "none", "link", "network" and "transport"
+// Equivalent to: `devlink dev eswitch set $dev inline-mode <mode>`
+func (w *libWrapper) DevLinkSetEswitchInlineMode(dev *netlink.DevlinkDevice, mode string) error {
+	inlineModes := map[string]uint8{
+		"none":      nl.DEVLINK_ESWITCH_INLINE_MODE_NONE,
+		"link":      nl.DEVLINK_ESWITCH_INLINE_MODE_LINK,
+		"network":   nl.DEVLINK_ESWITCH_INLINE_MODE_NETWORK,
+		"transport": nl.DEVLINK_ESWITCH_INLINE_MODE_TRANSPORT,
+	}
+	value, ok := inlineModes[mode]
+	if !ok {
+		return fmt.Errorf("invalid eswitch inline mode %q", mode)
+	}
+	return devlinkEswitchSet(dev, nl.DEVLINK_ATTR_ESWITCH_INLINE_MODE, value)
+}
+
+// DevLinkSetEswitchEncapMode enables or disables the eswitch encapsulation offload of the device
+// Equivalent to: `devlink dev eswitch set $dev encap-mode basic|none`
+func (w *libWrapper) DevLinkSetEswitchEncapMode(dev *netlink.DevlinkDevice, enabled bool) error {
+	var value uint8 = nl.DEVLINK_ESWITCH_ENCAP_MODE_NONE
+	if enabled {
+		value = nl.DEVLINK_ESWITCH_ENCAP_MODE_BASIC
+	}
+	return devlinkEswitchSet(dev, nl.DEVLINK_ATTR_ESWITCH_ENCAP_MODE, value)
+}
+
+// devlinkEswitchSet sets a single u8 eswitch attribute of the devlink device
+func devlinkEswitchSet(dev *netlink.DevlinkDevice, attrType int, value uint8) error {
+	family, err := netlink.GenlFamilyGet(nl.GENL_DEVLINK_NAME)
+	if err != nil {
+		return err
+	}
+	req := nl.NewNetlinkRequest(int(family.ID), syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	req.AddData(&nl.Genlmsg{Command: nl.DEVLINK_CMD_ESWITCH_SET, Version: nl.GENL_DEVLINK_VERSION})
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_BUS_NAME, nl.ZeroTerminated(dev.BusName)))
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_DEV_NAME, nl.ZeroTerminated(dev.DeviceName)))
+	req.AddData(nl.NewRtAttr(attrType, nl.Uint8Attr(value)))
+	_, err = req.Execute(syscall.NETLINK_GENERIC, 0)
+	return err
+}
+
+// DevLinkGetAllPortList returns the list of all the devlink ports of the host
+// Equivalent to: `devlink port show`
+func (w *libWrapper) DevLinkGetAllPortList() ([]*netlink.DevlinkPort, error) {
+	return netlink.DevLinkGetAllPortList()
+}
+
+// DevlinkPortFnRateSet sets the tx rate limits of the devlink rate object of the port,
+// txShare and txMax are in bytes per second, 0 means no limit
+// Equivalent to: `devlink port function rate set <bus>/<device>/<portIndex> tx_share <txShare> tx_max <txMax>`
+func (w *libWrapper) DevlinkPortFnRateSet(bus string, device string, portIndex uint32, txShare, txMax uint64) error {
+	req, err := newDevlinkRateRequest(devlinkCmdRateSet, bus, device)
+	if err != nil {
+		return err
+	}
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_INDEX, nl.Uint32Attr(portIndex)))
+	req.AddData(nl.NewRtAttr(devlinkAttrRateTxShare, nl.Uint64Attr(txShare)))
+	req.AddData(nl.NewRtAttr(devlinkAttrRateTxMax, nl.Uint64Attr(txMax)))
+	_, err = req.Execute(syscall.NETLINK_GENERIC, 0)
+	return err
+}
+
+// DevlinkRateNodeNew creates a devlink rate node, i.e. a rate group, on the device
+// Equivalent to: `devlink port function rate add <bus>/<device>/<nodeName>`
+func (w *libWrapper) DevlinkRateNodeNew(bus string, device string, nodeName string) error {
+	req, err := newDevlinkRateRequest(devlinkCmdRateNew, bus, device)
+	if err != nil {
+		return err
+	}
+	req.AddData(nl.NewRtAttr(devlinkAttrRateNodeName, nl.ZeroTerminated(nodeName)))
+	_, err = req.Execute(syscall.NETLINK_GENERIC, 0)
+	return err
+}
+
+// DevlinkPortFnRateParentSet attaches the devlink rate object of the port to the rate node, an empty
+// parent detaches it
+// Equivalent to: `devlink port function rate set <bus>/<device>/<portIndex> parent <parent>`
+func (w *libWrapper) DevlinkPortFnRateParentSet(bus string, device string, portIndex uint32, parent string) error {
+	req, err := newDevlinkRateRequest(devlinkCmdRateSet, bus, device)
+	if err != nil {
+		return err
+	}
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_INDEX, nl.Uint32Attr(portIndex)))
+	req.AddData(nl.NewRtAttr(devlinkAttrRateParentNodeName, nl.ZeroTerminated(parent)))
+	_, err = req.Execute(syscall.NETLINK_GENERIC, 0)
+	return err
+}
+
+// DevlinkPortFnRateParentGet returns the rate node the devlink rate object of the port is attached to,
+// an empty string if it isn't attached to a node
+// Equivalent to: `devlink port function rate show <bus>/<device>/<portIndex>`
+func (w *libWrapper) DevlinkPortFnRateParentGet(bus string, device string, portIndex uint32) (string, error) {
+	req, err := newDevlinkRateRequest(devlinkCmdRateGet, bus, device)
+	if err != nil {
+		return "", err
+	}
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_INDEX, nl.Uint32Attr(portIndex)))
+	msgs, err := req.Execute(syscall.NETLINK_GENERIC, 0)
+	if err != nil {
+		return "", err
+	}
+	for _, msg := range msgs {
+		if len(msg) < nl.SizeofGenlmsg {
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(msg[nl.SizeofGenlmsg:])
+		if err != nil {
+			return "", err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type == devlinkAttrRateParentNodeName {
+				return strings.TrimRight(string(attr.Value), "\x00"), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// newDevlinkRateRequest returns a devlink rate request for the device
+func newDevlinkRateRequest(cmd uint8, bus string, device string) (*nl.NetlinkRequest, error) {
+	family, err := netlink.GenlFamilyGet(nl.GENL_DEVLINK_NAME)
+	if err != nil {
+		return nil, err
+	}
+	req := nl.NewNetlinkRequest(int(family.ID), syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	req.AddData(&nl.Genlmsg{Command: cmd, Version: nl.GENL_DEVLINK_VERSION})
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_BUS_NAME, nl.ZeroTerminated(bus)))
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_DEV_NAME, nl.ZeroTerminated(device)))
+	return req, nil
+}
+
+// DcbIeeeGet returns the IEEE DCB configuration of the link, fails with EOPNOTSUPP if the link doesn't support DCB
+// Equivalent to: `dcb ets show dev $link` and `dcb pfc show dev $link`
+func (w *libWrapper) DcbIeeeGet(linkName string) (*DcbIeee, error) {
+	ets, pfc, err := dcbIeeeGetRaw(linkName)
+	if err != nil {
+		return nil, err
+	}
+	dcb := &DcbIeee{EtsCap: ets[ieeeEtsCapOff], PfcEnabled: pfc[ieeePfcEnOff]}
+	copy(dcb.PrioTc[:], ets[ieeeEtsPrioTcOff:ieeeEtsPrioTcOff+len(dcb.PrioTc)])
+	return dcb, nil
+}
+
+// DcbIeeeSet sets the PFC priorities and the priority to traffic class map of the link,
+// the other ETS and PFC settings of the link are left unchanged
+// Equivalent to: `dcb ets set dev $link prio-tc <prio>:<tc>...` and `dcb pfc set dev $link prio-pfc <prio>:on|off...`
+func (w *libWrapper) DcbIeeeSet(linkName string, dcb *DcbIeee) error {
+	// the kernel expects the complete ETS and PFC structures, read them first to preserve
+	// the settings we don't manage
+	ets, pfc, err := dcbIeeeGetRaw(linkName)
+	if err != nil {
+		return err
+	}
+	copy(ets[ieeeEtsPrioTcOff:], dcb.PrioTc[:])
+	pfc[ieeePfcEnOff] = dcb.PfcEnabled
+
+	req := nl.NewNetlinkRequest(syscall.RTM_SETDCB, syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	req.AddRawData([]byte{syscall.AF_UNSPEC, dcbCmdIeeeSet, 0, 0})
+	req.AddData(nl.NewRtAttr(dcbAttrIfname, nl.ZeroTerminated(linkName)))
+	ieee := nl.NewRtAttr(dcbAttrIeee, nil)
+	ieee.AddRtAttr(dcbAttrIeeeEts, ets)
+	ieee.AddRtAttr(dcbAttrIeeePfc, pfc)
+	req.AddData(ieee)
+	_, err = req.Execute(syscall.NETLINK_ROUTE, 0)
+	return err
+}
+
+// dcbIeeeGetRaw returns the raw ieee_ets and ieee_pfc structures of the link
+func dcbIeeeGetRaw(linkName string) ([]byte, []byte, error) {
+	req := nl.NewNetlinkRequest(syscall.RTM_GETDCB, syscall.NLM_F_REQUEST)
+	req.AddRawData([]byte{syscall.AF_UNSPEC, dcbCmdIeeeGet, 0, 0})
+	req.AddData(nl.NewRtAttr(dcbAttrIfname, nl.ZeroTerminated(linkName)))
+	msgs, err := req.Execute(syscall.NETLINK_ROUTE, syscall.RTM_GETDCB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(msgs) == 0 || len(msgs[0]) < dcbMsgLen {
+		return nil, nil, fmt.Errorf("invalid DCB reply for link %s", linkName)
+	}
+	attrs, err := nl.ParseRouteAttr(msgs[0][dcbMsgLen:])
+	if err != nil {
+		return nil, nil, err
+	}
+	var ets, pfc []byte
+	for _, attr := range attrs {
+		if attr.Attr.Type != dcbAttrIeee {
+			continue
+		}
+		nested, err := nl.ParseRouteAttr(attr.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, n := range nested {
+			switch n.Attr.Type {
+			case dcbAttrIeeeEts:
+				ets = n.Value
+			case dcbAttrIeeePfc:
+				pfc = n.Value
+			}
+		}
+	}
+	if len(ets) < ieeeEtsLen || len(pfc) < ieeePfcLen {
+		return nil, nil, fmt.Errorf("link %s doesn't report IEEE ETS and PFC configuration: %w", linkName, syscall.EOPNOTSUPP)
+	}
+	return ets[:ieeeEtsLen], pfc[:ieeePfcLen], nil
+}
+
 // VDPAGetDevByName returns VDPA device selected by name
 // Equivalent to: `vdpa dev show <name>`
 func (w *libWrapper) VDPAGetDevByName(name string) (*netlink.VDPADev, error) {
@@ -140,6 +584,12 @@ func (w *libWrapper) DevlinkGetDeviceParamByName(bus string, device string, para
 	return netlink.DevlinkGetDeviceParamByName(bus, device, param)
 }
 
+// DevlinkGetDeviceResources returns devlink device resources
+// Equivalent to: `devlink resource show $dev`
+func (w *libWrapper) DevlinkGetDeviceResources(bus string, device string) (*netlink.DevlinkResources, error) {
+	return netlink.DevlinkGetDeviceResources(bus, device)
+}
+
 // DevlinkSetDeviceParam set specific parameter for devlink device
 // Equivalent to: `devlink dev param set <bus>/<device> name <param> cmode <cmode> value <value>`
 // cmode argument should contain valid cmode value as uint8, modes are define in nl.DEVLINK_PARAM_CMODE_* constants
@@ -154,6 +604,18 @@ func (w *libWrapper) RdmaLinkByName(name string) (*netlink.RdmaLink, error) {
 	return netlink.RdmaLinkByName(name)
 }
 
+// RdmaSystemGetNetnsMode gets the network namespace mode of the RDMA subsystem
+// Equivalent to: `rdma system show netns`
+func (w *libWrapper) RdmaSystemGetNetnsMode() (string, error) {
+	return netlink.RdmaSystemGetNetnsMode()
+}
+
+// RdmaSystemSetNetnsMode sets the network namespace mode of the RDMA subsystem
+// Equivalent to: `rdma system set netns <mode>`
+func (w *libWrapper) RdmaSystemSetNetnsMode(mode string) error {
+	return netlink.RdmaSystemSetNetnsMode(mode)
+}
+
 // IsLinkAdminStateUp checks if the admin state of a link is up
 func (w *libWrapper) IsLinkAdminStateUp(link Link) bool {
 	return link.Attrs().Flags&net.FlagUp == 1
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -8,9 +9,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cenkalti/backoff"
+	"github.com/vishvananda/netlink"
 	"github.com/vishvananda/netlink/nl"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -23,11 +27,28 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 )
 
+// reads the phys_port_name and phys_switch_id files of the sysfs
+var readPhysPortFile = os.ReadFile
+
 type network struct {
 	utilsHelper utils.CmdInterface
 	dputilsLib  dputilsPkg.DPUtilsLib
 	netlinkLib  netlinkPkg.NetlinkLib
 	ethtoolLib  ethtoolPkg.EthtoolLib
+	// physPortCache is set only on the copies returned by WithPhysPortCache
+	physPortCache *physPortCache
+}
+
+// physPortCache caches the content of the phys_port_name and phys_switch_id files
+// of the netdevs, keyed by file path
+type physPortCache struct {
+	mu      sync.Mutex
+	entries map[string]physPortCacheEntry
+}
+
+type physPortCacheEntry struct {
+	value []byte
+	err   error
 }
 
 func New(utilsHelper utils.CmdInterface, dputilsLib dputilsPkg.DPUtilsLib, netlinkLib netlinkPkg.NetlinkLib, ethtoolLib ethtoolPkg.EthtoolLib) types.NetworkInterface {
@@ -80,28 +101,58 @@ func (n *network) TryGetInterfaceName(pciAddr string) string {
 	netDevName := names[0]
 
 	// Switchdev PF and their VFs representors are existing under the same PCI address since kernel 5.8
-	// if device is switchdev then return PF name
+	// if device is switchdev then return the uplink name, phys_port_name p<port-num> e.g p0,p1,p2 ...etc.,
+	// or the PF representor, phys_port_name pf<pf-num> e.g pf0, when there is no uplink.
+	// VF representors, phys_port_name pf<pf-num>vf<vf-num> e.g pf0vf0, are never returned.
+	pfRepName := ""
 	for _, name := range names {
 		if !n.IsSwitchdev(name) {
 			continue
 		}
 		// Try to get the phys port name, if not exists then fallback to check without it
-		// phys_port_name should be in formant p<port-num> e.g p0,p1,p2 ...etc.
-		if physPortName, err := n.GetPhysPortName(name); err == nil {
-			if !vars.PfPhysPortNameRe.MatchString(physPortName) {
-				continue
-			}
+		physPortName, err := n.GetPhysPortName(name)
+		if err != nil || vars.PfPhysPortNameRe.MatchString(physPortName) {
+			return name
 		}
-		return name
+		if pfRepName == "" && vars.PfRepPhysPortNameRe.MatchString(physPortName) {
+			pfRepName = name
+		}
+	}
+	if pfRepName != "" {
+		log.Log.V(2).Info("tryGetInterfaceName()", "name", pfRepName)
+		return pfRepName
 	}
 
 	log.Log.V(2).Info("tryGetInterfaceName()", "name", netDevName)
 	return netDevName
 }
 
+// WithPhysPortCache returns a copy of the helper caching the phys_port_name and phys_switch_id reads,
+// the cache is dropped with the copy so the values don't leak across discovery passes
+func (n *network) WithPhysPortCache() types.NetworkInterface {
+	c := *n
+	c.physPortCache = &physPortCache{entries: map[string]physPortCacheEntry{}}
+	return &c
+}
+
+// readPhysPortFile reads the sysfs file, through the cache if the helper has one
+func (n *network) readPhysPortFile(path string) ([]byte, error) {
+	if n.physPortCache == nil {
+		return readPhysPortFile(path)
+	}
+	n.physPortCache.mu.Lock()
+	defer n.physPortCache.mu.Unlock()
+	if entry, ok := n.physPortCache.entries[path]; ok {
+		return entry.value, entry.err
+	}
+	value, err := readPhysPortFile(path)
+	n.physPortCache.entries[path] = physPortCacheEntry{value: value, err: err}
+	return value, err
+}
+
 func (n *network) GetPhysSwitchID(name string) (string, error) {
 	swIDFile := filepath.Join(vars.FilesystemRoot, consts.SysClassNet, name, "phys_switch_id")
-	physSwitchID, err := os.ReadFile(swIDFile)
+	physSwitchID, err := n.readPhysPortFile(swIDFile)
 	if err != nil {
 		return "", err
 	}
@@ -113,7 +164,7 @@ func (n *network) GetPhysSwitchID(name string) (string, error) {
 
 func (n *network) GetPhysPortName(name string) (string, error) {
 	devicePortNameFile := filepath.Join(vars.FilesystemRoot, consts.SysClassNet, name, "phys_port_name")
-	physPortName, err := os.ReadFile(devicePortNameFile)
+	physPortName, err := n.readPhysPortFile(devicePortNameFile)
 	if err != nil {
 		return "", err
 	}
@@ -148,13 +199,14 @@ func (n *network) GetNetdevMTU(pciAddr string) int {
 	return link.Attrs().MTU
 }
 
-func (n *network) SetNetdevMTU(pciAddr string, mtu int) error {
+func (n *network) SetNetdevMTU(ctx context.Context, pciAddr string, mtu int) error {
 	log.Log.V(2).Info("SetNetdevMTU(): set MTU", "device", pciAddr, "mtu", mtu)
 	if mtu <= 0 {
 		log.Log.V(2).Info("SetNetdevMTU(): refusing to set MTU", "mtu", mtu)
 		return nil
 	}
-	b := backoff.NewConstantBackOff(1 * time.Second)
+	start := time.Now()
+	b := backoff.NewConstantBackOff(vars.NetdevMTURetryInterval)
 	err := backoff.Retry(func() error {
 		ifaceName := n.TryGetInterfaceName(pciAddr)
 		if ifaceName == "" {
@@ -168,15 +220,31 @@ func (n *network) SetNetdevMTU(pciAddr string, mtu int) error {
 			return err
 		}
 		return n.netlinkLib.LinkSetMTU(link, mtu)
-	}, backoff.WithMaxRetries(b, 10))
+	}, backoff.WithContext(backoff.WithMaxRetries(b, uint64(vars.NetdevMTURetryCount)), ctx))
 
 	if err != nil {
-		log.Log.Error(err, "SetNetdevMTU(): fail to set mtu after retrying")
-		return err
+		waited := time.Since(start).Round(time.Millisecond)
+		log.Log.Error(err, "SetNetdevMTU(): fail to set mtu after retrying", "device", pciAddr, "waited", waited)
+		return fmt.Errorf("failed to set MTU %d for device %s after waiting %s: %w", mtu, pciAddr, waited, err)
 	}
 	return nil
 }
 
+// MaxMTU returns the maximum MTU supported by the interface, 0 if the driver doesn't report it
+func (n *network) MaxMTU(iface string) (int, error) {
+	link, err := n.netlinkLib.LinkByName(iface)
+	if err != nil {
+		log.Log.Error(err, "MaxMTU(): fail to get Link ", "device", iface)
+		return 0, err
+	}
+	maxMtu, err := n.netlinkLib.LinkGetMaxMTU(link)
+	if err != nil {
+		log.Log.Error(err, "MaxMTU(): fail to get max mtu", "device", iface)
+		return 0, err
+	}
+	return maxMtu, nil
+}
+
 // GetNetDevMac returns network device MAC address or empty string if address cannot be
 // retrieved.
 func (n *network) GetNetDevMac(ifaceName string) string {
@@ -272,6 +340,34 @@ func (n *network) GetDevlinkDeviceParam(pciAddr, paramName string) (string, erro
 	return value, nil
 }
 
+// GetDevlinkOffloadVfLimit returns the number of VFs that can be offloaded to the hardware
+// as reported by the devlink resources of the device, 0 means the device reports no limit
+func (n *network) GetDevlinkOffloadVfLimit(pciAddr string) (int, error) {
+	log.Log.V(2).Info("GetDevlinkOffloadVfLimit(): get offload VF limit", "device", pciAddr)
+	resources, err := n.netlinkLib.DevlinkGetDeviceResources(consts.BusPci, pciAddr)
+	if err != nil {
+		log.Log.Error(err, "GetDevlinkOffloadVfLimit(): fail to get devlink device resources", "device", pciAddr)
+		return 0, err
+	}
+	resource := findDevlinkResource(resources.Resources, consts.DevlinkResourceOffloadVfs)
+	if resource == nil {
+		return 0, nil
+	}
+	return int(resource.Size), nil
+}
+
+func findDevlinkResource(resources []netlink.DevlinkResource, name string) *netlink.DevlinkResource {
+	for i := range resources {
+		if resources[i].Name == name {
+			return &resources[i]
+		}
+		if r := findDevlinkResource(resources[i].Children, name); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
 // SetDevlinkDeviceParam set devlink parameter for the device, accepts paramName and value
 // as a string. Automatically set CMODE for the parameter and converts the value to the right
 // type before submitting it.
@@ -361,6 +457,110 @@ func (n *network) EnableHwTcOffload(ifaceName string) error {
 	return nil
 }
 
+// SetNetdevCombinedChannels sets the number of combined channels of the interface,
+// the number is clamped to the maximum supported by the device
+func (n *network) SetNetdevCombinedChannels(ifaceName string, channels int) error {
+	log.Log.V(2).Info("SetNetdevCombinedChannels(): set combined channels", "device", ifaceName, "channels", channels)
+	if channels <= 0 {
+		log.Log.V(2).Info("SetNetdevCombinedChannels(): refusing to set combined channels", "channels", channels)
+		return nil
+	}
+	current, err := n.ethtoolLib.GetChannels(ifaceName)
+	if err != nil {
+		log.Log.Error(err, "SetNetdevCombinedChannels(): can't read channels for device", "device", ifaceName)
+		return err
+	}
+	requested := uint32(channels)
+	if current.MaxCombined > 0 && requested > current.MaxCombined {
+		log.Log.V(0).Info("SetNetdevCombinedChannels(): requested combined channels exceed device maximum, clamping",
+			"device", ifaceName, "requested", requested, "max", current.MaxCombined)
+		requested = current.MaxCombined
+	}
+	if current.CombinedCount == requested {
+		log.Log.V(2).Info("SetNetdevCombinedChannels(): already configured", "device", ifaceName)
+		return nil
+	}
+	current.CombinedCount = requested
+	if _, err := n.ethtoolLib.SetChannels(ifaceName, current); err != nil {
+		log.Log.Error(err, "SetNetdevCombinedChannels(): can't set channels for device", "device", ifaceName)
+		return err
+	}
+	return nil
+}
+
+// GetNetdevCombinedChannels returns the current and the maximum number of combined channels of the interface
+func (n *network) GetNetdevCombinedChannels(ifaceName string) (int, int, error) {
+	log.Log.V(2).Info("GetNetdevCombinedChannels(): get combined channels", "device", ifaceName)
+	channels, err := n.ethtoolLib.GetChannels(ifaceName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(channels.CombinedCount), int(channels.MaxCombined), nil
+}
+
+// GetNetdevRingSizes returns the current and the maximum RX/TX ring sizes of the interface
+func (n *network) GetNetdevRingSizes(ifaceName string) (*types.RingSizes, error) {
+	log.Log.V(2).Info("GetNetdevRingSizes(): get ring sizes", "device", ifaceName)
+	ring, err := n.ethtoolLib.GetRing(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RingSizes{
+		Rx:    int(ring.RxPending),
+		Tx:    int(ring.TxPending),
+		MaxRx: int(ring.RxMaxPending),
+		MaxTx: int(ring.TxMaxPending),
+	}, nil
+}
+
+// SetNetdevRingSizes sets the RX/TX ring sizes of the interface, a zero size is left unchanged
+func (n *network) SetNetdevRingSizes(ifaceName string, rx, tx int) error {
+	log.Log.V(2).Info("SetNetdevRingSizes(): set ring sizes", "device", ifaceName, "rx", rx, "tx", tx)
+	ring, err := n.ethtoolLib.GetRing(ifaceName)
+	if err != nil {
+		log.Log.Error(err, "SetNetdevRingSizes(): can't read ring sizes for device", "device", ifaceName)
+		return err
+	}
+	if rx > 0 {
+		ring.RxPending = uint32(rx)
+	}
+	if tx > 0 {
+		ring.TxPending = uint32(tx)
+	}
+	if err := n.ethtoolLib.SetRing(ifaceName, ring); err != nil {
+		log.Log.Error(err, "SetNetdevRingSizes(): can't set ring sizes for device", "device", ifaceName)
+		return err
+	}
+	return nil
+}
+
+// GetRdmaSubsystemMode returns the network namespace mode of the RDMA subsystem, shared or exclusive
+func (n *network) GetRdmaSubsystemMode() (string, error) {
+	mode, err := n.netlinkLib.RdmaSystemGetNetnsMode()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the RDMA subsystem mode: %w", err)
+	}
+	return mode, nil
+}
+
+// SetRdmaSubsystemMode sets the network namespace mode of the RDMA subsystem, shared or exclusive.
+// The kernel refuses to change the mode while RDMA devices are in use in non default network namespaces.
+func (n *network) SetRdmaSubsystemMode(mode string) error {
+	log.Log.V(2).Info("SetRdmaSubsystemMode(): set RDMA subsystem mode", "mode", mode)
+	if mode != consts.RdmaSubsystemModeShared && mode != consts.RdmaSubsystemModeExclusive {
+		return fmt.Errorf("unknown RDMA subsystem mode %q, expected %q or %q",
+			mode, consts.RdmaSubsystemModeShared, consts.RdmaSubsystemModeExclusive)
+	}
+	if err := n.netlinkLib.RdmaSystemSetNetnsMode(mode); err != nil {
+		if errors.Is(err, syscall.EBUSY) {
+			return fmt.Errorf("can't switch the RDMA subsystem to %s mode while RDMA devices are in use "+
+				"in other network namespaces, the mode can be changed only when no RDMA devices are active: %w", mode, err)
+		}
+		return fmt.Errorf("failed to set the RDMA subsystem to %s mode: %w", mode, err)
+	}
+	return nil
+}
+
 // GetNetDevLinkAdminState returns the admin state of the interface.
 func (n *network) GetNetDevLinkAdminState(ifaceName string) string {
 	log.Log.V(2).Info("GetNetDevLinkAdminState(): get LinkAdminState", "device", ifaceName)
@@ -380,3 +580,23 @@ func (n *network) GetNetDevLinkAdminState(ifaceName string) string {
 
 	return consts.LinkAdminStateDown
 }
+
+// GetNetDevFirmwareVersion returns the firmware version of the network interface as reported by
+// `ethtool -i`, an empty string is returned if the device doesn't report it
+func (n *network) GetNetDevFirmwareVersion(ifaceName string) string {
+	log.Log.V(2).Info("GetNetDevFirmwareVersion(): get firmware version", "device", ifaceName)
+	if len(ifaceName) == 0 {
+		return ""
+	}
+	info, err := n.ethtoolLib.DriverInfo(ifaceName)
+	if err != nil {
+		log.Log.V(2).Info("GetNetDevFirmwareVersion(): unable to read driver info", "device", ifaceName, "error", err)
+		return ""
+	}
+	fwVersion := strings.TrimSpace(info.FwVersion)
+	// some drivers report N/A instead of leaving the firmware version empty
+	if fwVersion == "N/A" {
+		return ""
+	}
+	return fwVersion
+}
@@ -1,7 +1,11 @@
 package network
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"syscall"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -12,9 +16,11 @@ import (
 
 	hostMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper/mock"
 	dputilsMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/dputils/mock"
+	ethtoolPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/ethtool"
 	ethtoolMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/ethtool/mock"
 	netlinkMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/netlink/mock"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
 )
@@ -157,6 +163,31 @@ var _ = Describe("Network", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+	Context("GetDevlinkOffloadVfLimit", func() {
+		It("limit reported", func() {
+			netlinkLibMock.EXPECT().DevlinkGetDeviceResources("pci", "0000:d8:00.1").Return(&netlink.DevlinkResources{
+				Resources: []netlink.DevlinkResource{{
+					Name: "eswitch",
+					Children: []netlink.DevlinkResource{
+						{Name: "fdb", Size: 1024},
+						{Name: "offload_vfs", Size: 8},
+					},
+				}},
+			}, nil)
+			Expect(n.GetDevlinkOffloadVfLimit("0000:d8:00.1")).To(Equal(8))
+		})
+		It("limit not reported", func() {
+			netlinkLibMock.EXPECT().DevlinkGetDeviceResources("pci", "0000:d8:00.1").Return(&netlink.DevlinkResources{
+				Resources: []netlink.DevlinkResource{{Name: "fdb", Size: 1024}},
+			}, nil)
+			Expect(n.GetDevlinkOffloadVfLimit("0000:d8:00.1")).To(Equal(0))
+		})
+		It("failed", func() {
+			netlinkLibMock.EXPECT().DevlinkGetDeviceResources("pci", "0000:d8:00.1").Return(nil, testErr)
+			_, err := n.GetDevlinkOffloadVfLimit("0000:d8:00.1")
+			Expect(err).To(MatchError(testErr))
+		})
+	})
 	Context("EnableHwTcOffload", func() {
 		It("Enabled", func() {
 			ethtoolLibMock.EXPECT().FeatureNames("enp216s0f0np0").Return(map[string]uint{"hw-tc-offload": 42}, nil)
@@ -204,6 +235,247 @@ var _ = Describe("Network", func() {
 			Expect(n.EnableHwTcOffload("enp216s0f0np0")).To(MatchError(testErr))
 		})
 	})
+	Context("MaxMTU", func() {
+		It("reported", func() {
+			linkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(linkMock, nil)
+			netlinkLibMock.EXPECT().LinkGetMaxMTU(linkMock).Return(9978, nil)
+			Expect(n.MaxMTU("enp216s0f0np0")).To(Equal(9978))
+		})
+		It("fail - can't get link", func() {
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(nil, testErr)
+			_, err := n.MaxMTU("enp216s0f0np0")
+			Expect(err).To(MatchError(testErr))
+		})
+		It("fail - can't get max mtu", func() {
+			linkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(linkMock, nil)
+			netlinkLibMock.EXPECT().LinkGetMaxMTU(linkMock).Return(0, testErr)
+			_, err := n.MaxMTU("enp216s0f0np0")
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+	Context("SetNetdevCombinedChannels", func() {
+		It("Set", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0v0").Return(ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 1}, nil)
+			ethtoolLibMock.EXPECT().SetChannels("enp216s0f0v0", ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 4}).Return(
+				ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 4}, nil)
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 4)).NotTo(HaveOccurred())
+		})
+		It("Clamped to device max", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0v0").Return(ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 1}, nil)
+			ethtoolLibMock.EXPECT().SetChannels("enp216s0f0v0", ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 8}).Return(
+				ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 8}, nil)
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 16)).NotTo(HaveOccurred())
+		})
+		It("Already configured", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0v0").Return(ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 4}, nil)
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 4)).NotTo(HaveOccurred())
+		})
+		It("Not requested", func() {
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 0)).NotTo(HaveOccurred())
+		})
+		It("fail - can't read channels", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0v0").Return(ethtoolPkg.Channels{}, testErr)
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 4)).To(MatchError(testErr))
+		})
+		It("fail - can't set channels", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0v0").Return(ethtoolPkg.Channels{MaxCombined: 8, CombinedCount: 1}, nil)
+			ethtoolLibMock.EXPECT().SetChannels("enp216s0f0v0", gomock.Any()).Return(ethtoolPkg.Channels{}, testErr)
+			Expect(n.SetNetdevCombinedChannels("enp216s0f0v0", 4)).To(MatchError(testErr))
+		})
+	})
+	Context("GetNetdevCombinedChannels", func() {
+		It("Get", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0np0").Return(ethtoolPkg.Channels{MaxCombined: 63, CombinedCount: 8}, nil)
+			current, maximum, err := n.GetNetdevCombinedChannels("enp216s0f0np0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current).To(Equal(8))
+			Expect(maximum).To(Equal(63))
+		})
+		It("fail - can't read channels", func() {
+			ethtoolLibMock.EXPECT().GetChannels("enp216s0f0np0").Return(ethtoolPkg.Channels{}, testErr)
+			_, _, err := n.GetNetdevCombinedChannels("enp216s0f0np0")
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+	Context("GetNetdevRingSizes", func() {
+		It("Get", func() {
+			ethtoolLibMock.EXPECT().GetRing("enp216s0f0np0").Return(ethtoolPkg.Ring{
+				RxMaxPending: 8192, TxMaxPending: 4096, RxPending: 1024, TxPending: 512}, nil)
+			Expect(n.GetNetdevRingSizes("enp216s0f0np0")).To(Equal(&types.RingSizes{Rx: 1024, Tx: 512, MaxRx: 8192, MaxTx: 4096}))
+		})
+		It("fail - can't read ring", func() {
+			ethtoolLibMock.EXPECT().GetRing("enp216s0f0np0").Return(ethtoolPkg.Ring{}, testErr)
+			_, err := n.GetNetdevRingSizes("enp216s0f0np0")
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+	Context("SetNetdevRingSizes", func() {
+		It("Set", func() {
+			ethtoolLibMock.EXPECT().GetRing("enp216s0f0np0").Return(ethtoolPkg.Ring{
+				RxMaxPending: 8192, TxMaxPending: 4096, RxPending: 1024, TxPending: 512}, nil)
+			ethtoolLibMock.EXPECT().SetRing("enp216s0f0np0", ethtoolPkg.Ring{
+				RxMaxPending: 8192, TxMaxPending: 4096, RxPending: 4096, TxPending: 512}).Return(nil)
+			Expect(n.SetNetdevRingSizes("enp216s0f0np0", 4096, 0)).NotTo(HaveOccurred())
+		})
+		It("fail - can't set ring", func() {
+			ethtoolLibMock.EXPECT().GetRing("enp216s0f0np0").Return(ethtoolPkg.Ring{}, nil)
+			ethtoolLibMock.EXPECT().SetRing("enp216s0f0np0", gomock.Any()).Return(testErr)
+			Expect(n.SetNetdevRingSizes("enp216s0f0np0", 4096, 4096)).To(MatchError(testErr))
+		})
+	})
+	Context("GetRdmaSubsystemMode", func() {
+		It("Get", func() {
+			netlinkLibMock.EXPECT().RdmaSystemGetNetnsMode().Return("exclusive", nil)
+			Expect(n.GetRdmaSubsystemMode()).To(Equal("exclusive"))
+		})
+		It("fail - can't read mode", func() {
+			netlinkLibMock.EXPECT().RdmaSystemGetNetnsMode().Return("", testErr)
+			_, err := n.GetRdmaSubsystemMode()
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+	Context("SetRdmaSubsystemMode", func() {
+		It("Shared", func() {
+			netlinkLibMock.EXPECT().RdmaSystemSetNetnsMode("shared").Return(nil)
+			Expect(n.SetRdmaSubsystemMode("shared")).NotTo(HaveOccurred())
+		})
+		It("Exclusive", func() {
+			netlinkLibMock.EXPECT().RdmaSystemSetNetnsMode("exclusive").Return(nil)
+			Expect(n.SetRdmaSubsystemMode("exclusive")).NotTo(HaveOccurred())
+		})
+		It("fail - unknown mode", func() {
+			Expect(n.SetRdmaSubsystemMode("private")).To(MatchError(ContainSubstring("unknown RDMA subsystem mode")))
+		})
+		It("fail - RDMA devices in use", func() {
+			netlinkLibMock.EXPECT().RdmaSystemSetNetnsMode("exclusive").Return(syscall.EBUSY)
+			err := n.SetRdmaSubsystemMode("exclusive")
+			Expect(err).To(MatchError(syscall.EBUSY))
+			Expect(err).To(MatchError(ContainSubstring("while RDMA devices are in use")))
+		})
+	})
+	Context("TryGetInterfaceName", func() {
+		configureNetdevs := func(netdevs map[string]string) []string {
+			fs := &fakefilesystem.FS{Files: map[string][]byte{}}
+			names := make([]string, 0, len(netdevs))
+			for name, portName := range netdevs {
+				names = append(names, name)
+				fs.Dirs = append(fs.Dirs, "/sys/class/net/"+name)
+				if portName != "" {
+					fs.Files["/sys/class/net/"+name+"/phys_switch_id"] = []byte("7cfe90ff2cc0")
+					fs.Files["/sys/class/net/"+name+"/phys_port_name"] = []byte(portName)
+				}
+			}
+			helpers.GinkgoConfigureFakeFS(fs)
+			return names
+		}
+		It("legacy device with a single netdev", func() {
+			names := configureNetdevs(map[string]string{"enp216s0f0np0": ""})
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return(names, nil)
+			Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("enp216s0f0np0"))
+		})
+		It("switchdev uplink p0 with VF representors", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return([]string{"eth0", "eth1", "enp216s0f0np0"}, nil)
+			configureNetdevs(map[string]string{"eth0": "pf0vf0", "eth1": "pf0vf1", "enp216s0f0np0": "p0"})
+			Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("enp216s0f0np0"))
+		})
+		It("switchdev PF representor pf0 with VF representors", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return([]string{"eth0", "pf0hpf", "eth1"}, nil)
+			configureNetdevs(map[string]string{"eth0": "pf0vf0", "pf0hpf": "pf0", "eth1": "pf0vf1"})
+			Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("pf0hpf"))
+		})
+		It("switchdev uplink p0 preferred over the PF representor pf0", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return([]string{"pf0hpf", "eth0", "p0"}, nil)
+			configureNetdevs(map[string]string{"pf0hpf": "pf0", "eth0": "pf0vf0", "p0": "p0"})
+			Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("p0"))
+		})
+		Context("phys port cache", func() {
+			var reads map[string]int
+			BeforeEach(func() {
+				reads = map[string]int{}
+				origRead := readPhysPortFile
+				readPhysPortFile = func(path string) ([]byte, error) {
+					reads[strings.TrimPrefix(path, vars.FilesystemRoot)]++
+					return origRead(path)
+				}
+				DeferCleanup(func() { readPhysPortFile = origRead })
+				configureNetdevs(map[string]string{"eth0": "pf0vf0", "eth1": "pf0vf1", "enp216s0f0np0": "p0"})
+				dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return(
+					[]string{"eth0", "eth1", "enp216s0f0np0"}, nil).Times(3)
+			})
+			It("reads the sysfs files on every lookup without the cache", func() {
+				for i := 0; i < 3; i++ {
+					Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("enp216s0f0np0"))
+				}
+				Expect(reads["/sys/class/net/enp216s0f0np0/phys_port_name"]).To(Equal(3))
+				Expect(reads["/sys/class/net/enp216s0f0np0/phys_switch_id"]).To(Equal(3))
+			})
+			It("reads the sysfs files once per pass with the cache", func() {
+				cached := n.WithPhysPortCache()
+				for i := 0; i < 2; i++ {
+					Expect(cached.TryGetInterfaceName("0000:d8:00.0")).To(Equal("enp216s0f0np0"))
+				}
+				for path, count := range reads {
+					Expect(count).To(Equal(1), path)
+				}
+				Expect(reads).To(HaveLen(6))
+				// a new pass reads the files again
+				Expect(n.WithPhysPortCache().TryGetInterfaceName("0000:d8:00.0")).To(Equal("enp216s0f0np0"))
+				Expect(reads["/sys/class/net/enp216s0f0np0/phys_port_name"]).To(Equal(2))
+			})
+		})
+		It("only VF representors pf0vf0", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.0").Return([]string{"eth0", "eth1"}, nil)
+			configureNetdevs(map[string]string{"eth0": "pf0vf0", "eth1": "pf0vf1"})
+			Expect(n.TryGetInterfaceName("0000:d8:00.0")).To(Equal("eth0"))
+		})
+	})
+	Context("GetNetDevFirmwareVersion", func() {
+		It("Reported", func() {
+			ethtoolLibMock.EXPECT().DriverInfo("enp216s0f0np0").Return(ethtoolPkg.DrvInfo{
+				Driver: "mlx5_core", FwVersion: "22.39.1002 (MT_0000000359)"}, nil)
+			Expect(n.GetNetDevFirmwareVersion("enp216s0f0np0")).To(Equal("22.39.1002 (MT_0000000359)"))
+		})
+		It("Not reported", func() {
+			ethtoolLibMock.EXPECT().DriverInfo("enp216s0f0np0").Return(ethtoolPkg.DrvInfo{Driver: "virtio_net", FwVersion: "N/A"}, nil)
+			Expect(n.GetNetDevFirmwareVersion("enp216s0f0np0")).To(BeEmpty())
+		})
+		It("fail - can't read driver info", func() {
+			ethtoolLibMock.EXPECT().DriverInfo("enp216s0f0np0").Return(ethtoolPkg.DrvInfo{}, testErr)
+			Expect(n.GetNetDevFirmwareVersion("enp216s0f0np0")).To(BeEmpty())
+		})
+	})
+	Context("SetNetdevMTU", func() {
+		BeforeEach(func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+			origInterval, origCount := vars.NetdevMTURetryInterval, vars.NetdevMTURetryCount
+			vars.NetdevMTURetryInterval = 10 * time.Millisecond
+			vars.NetdevMTURetryCount = 2
+			DeferCleanup(func() {
+				vars.NetdevMTURetryInterval, vars.NetdevMTURetryCount = origInterval, origCount
+			})
+		})
+		It("Set after the netdev appears", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.2").Return(nil, testErr)
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.2").Return([]string{"enp216s0f0v0"}, nil)
+			linkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(linkMock, nil)
+			netlinkLibMock.EXPECT().LinkSetMTU(linkMock, 9000).Return(nil)
+			Expect(n.SetNetdevMTU(context.Background(), "0000:d8:00.2", 9000)).NotTo(HaveOccurred())
+		})
+		It("Not requested", func() {
+			Expect(n.SetNetdevMTU(context.Background(), "0000:d8:00.2", 0)).NotTo(HaveOccurred())
+		})
+		It("fail - retries exhausted", func() {
+			dputilsLibMock.EXPECT().GetNetNames("0000:d8:00.2").Return(nil, testErr).Times(3)
+			start := time.Now()
+			err := n.SetNetdevMTU(context.Background(), "0000:d8:00.2", 9000)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("after waiting"))
+			Expect(time.Since(start)).To(BeNumerically(">=", 2*vars.NetdevMTURetryInterval))
+		})
+	})
 	Context("GetNetDevNodeGUID", func() {
 		It("Returns empty when pciAddr is empty", func() {
 			Expect(n.GetNetDevNodeGUID("")).To(Equal(""))
@@ -0,0 +1,61 @@
+package sriov
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+)
+
+// deviceLockPollInterval is the interval between two attempts to take the lock of a PF held by another actor
+const deviceLockPollInterval = 100 * time.Millisecond
+
+// lockDevice takes the lock of the PF shared on the host by all the actors configuring the PFs, the config daemon
+// and the systemd service, waiting up to vars.DeviceLockTimeout for the lock to be released by another actor.
+// Returns the function releasing the lock.
+func lockDevice(ctx context.Context, pciAddr string) (func(), error) {
+	lockDir := utils.GetHostExtensionPath(consts.DeviceLockPath)
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the device lock folder %s: %v", lockDir, err)
+	}
+	file, err := os.OpenFile(filepath.Join(lockDir, pciAddr), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the lock file of device %s: %v", pciAddr, err)
+	}
+
+	// the lock is owned by the open file, the goroutines of the same process exclude each other too
+	fd := int(file.Fd())
+	waiting := false
+	err = wait.PollUntilContextTimeout(ctx, deviceLockPollInterval, vars.DeviceLockTimeout, true, func(context.Context) (bool, error) {
+		err := syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB)
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			if !waiting {
+				log.Log.Info("lockDevice(): device locked by another actor, waiting", "device", pciAddr)
+				waiting = true
+			}
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock device %s: %w", pciAddr, err)
+	}
+
+	return func() {
+		if err := syscall.Flock(fd, syscall.LOCK_UN); err != nil {
+			log.Log.Error(err, "lockDevice(): failed to unlock device", "device", pciAddr)
+		}
+		file.Close()
+	}, nil
+}
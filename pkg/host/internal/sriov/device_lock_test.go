@@ -0,0 +1,64 @@
+package sriov
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("Device lock", func() {
+	BeforeEach(func() {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+		origTimeout := vars.DeviceLockTimeout
+		vars.DeviceLockTimeout = 300 * time.Millisecond
+		DeferCleanup(func() {
+			vars.DeviceLockTimeout = origTimeout
+		})
+	})
+
+	It("should fail when the device stays locked by another actor", func() {
+		unlock, err := lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).NotTo(HaveOccurred())
+		defer unlock()
+		_, err = lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).To(MatchError(ContainSubstring("failed to lock device 0000:d8:00.0")))
+	})
+
+	It("should not block the other devices", func() {
+		unlock, err := lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).NotTo(HaveOccurred())
+		defer unlock()
+		unlockOther, err := lockDevice(context.Background(), "0000:d8:00.1")
+		Expect(err).NotTo(HaveOccurred())
+		unlockOther()
+	})
+
+	It("should take the lock once released by the other actor", func() {
+		unlock, err := lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(100 * time.Millisecond)
+			unlock()
+		}()
+		unlockWaiting, err := lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).NotTo(HaveOccurred())
+		unlockWaiting()
+	})
+
+	It("should stop waiting when the configuration is canceled", func() {
+		unlock, err := lockDevice(context.Background(), "0000:d8:00.0")
+		Expect(err).NotTo(HaveOccurred())
+		defer unlock()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = lockDevice(ctx, "0000:d8:00.0")
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,151 @@
+package sriov
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+)
+
+// ibVfPort is the port of the InfiniBand device the partition keys of the VFs are mapped on
+const ibVfPort = 1
+
+// getIbDevice returns the name of the InfiniBand device of the PCI device
+func getIbDevice(pciAddr string) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, "infiniband"))
+	if err != nil {
+		return "", err
+	}
+	if len(entries) != 1 {
+		return "", fmt.Errorf("expected one InfiniBand device for device %s, found %d", pciAddr, len(entries))
+	}
+	return entries[0].Name(), nil
+}
+
+// getPciDriver returns the name of the driver bound to the PCI device, an empty string if it can't be read
+func getPciDriver(pciAddr string) string {
+	driver, err := os.Readlink(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(driver)
+}
+
+// ibPfPkeysPath returns the directory holding the partition table of the port of the InfiniBand device of the PF
+func ibPfPkeysPath(ibDev string) string {
+	return filepath.Join(vars.FilesystemRoot, consts.SysClassInfiniband, ibDev, "ports", strconv.Itoa(ibVfPort), "pkeys")
+}
+
+// ibVfPkeyIndexPath returns the file mapping the first partition key of the VF to an entry of the partition table of the PF,
+// only the mlx4 driver exposes it
+func ibVfPkeyIndexPath(ibDev, vfAddr string) string {
+	return filepath.Join(vars.FilesystemRoot, consts.SysClassInfiniband, ibDev, "iov", vfAddr, "ports",
+		strconv.Itoa(ibVfPort), "pkey_idx", "0")
+}
+
+// readIbPkey reads a partition key from the partition table of the PF, the empty entries read as "none"
+func readIbPkey(path string) (uint16, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || value == "none" {
+		return 0, false, nil
+	}
+	pkey, err := sriovnetworkv1.ParseIbPkey(value)
+	if err != nil {
+		return 0, false, nil
+	}
+	return pkey, true, nil
+}
+
+// findIbPkeyIndex returns the index of the entry of the partition table of the PF holding the partition key,
+// the key of the default partition matches the default partition entry whatever its membership
+func findIbPkeyIndex(ibDev string, pkey uint16) (int, error) {
+	entries, err := os.ReadDir(ibPfPkeysPath(ibDev))
+	if err != nil {
+		return 0, err
+	}
+	match := -1
+	for _, entry := range entries {
+		idx, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		value, ok, err := readIbPkey(filepath.Join(ibPfPkeysPath(ibDev), entry.Name()))
+		if err != nil || !ok {
+			continue
+		}
+		// an exact match is preferred over a match of the default partition with another membership
+		if value == pkey {
+			return idx, nil
+		}
+		if match < 0 && sriovnetworkv1.IbPkeyMatches(pkey, value) {
+			match = idx
+		}
+	}
+	if match < 0 {
+		return 0, fmt.Errorf("partition key 0x%04x is not in the partition table of port %d of device %s", pkey, ibVfPort, ibDev)
+	}
+	return match, nil
+}
+
+// setVfIbPkey maps the first partition key of the VF to the entry of the partition table of the PF
+// holding the partition key requested by the VF group.
+// The drivers which don't expose the partition key table of the VFs, e.g. mlx5_core, are rejected,
+// the partition keys of their VFs are assigned by the subnet manager.
+func setVfIbPkey(pfAddr, vfAddr string, group *sriovnetworkv1.VfGroup) error {
+	if group.IbPkey == "" {
+		return nil
+	}
+	pkey, err := sriovnetworkv1.ParseIbPkey(group.IbPkey)
+	if err != nil {
+		return err
+	}
+	ibDev, err := getIbDevice(pfAddr)
+	if err != nil {
+		return fmt.Errorf("failed to get the InfiniBand device of device %s: %w", pfAddr, err)
+	}
+	pkeyIdxPath := ibVfPkeyIndexPath(ibDev, vfAddr)
+	if _, err := os.Stat(pkeyIdxPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("'ibPkey' is unsupported by driver %q of device %s, the partition keys of its VFs "+
+			"are assigned by the subnet manager", getPciDriver(pfAddr), pfAddr)
+	}
+	idx, err := findIbPkeyIndex(ibDev, pkey)
+	if err != nil {
+		return err
+	}
+	log.Log.V(2).Info("setVfIbPkey(): set VF partition key", "device", vfAddr, "pkey", fmt.Sprintf("0x%04x", pkey), "index", idx)
+	return os.WriteFile(pkeyIdxPath, []byte(strconv.Itoa(idx)), os.ModeAppend)
+}
+
+// getVfIbPkey returns the partition key the first partition key of the VF is mapped to,
+// an empty string is returned when the partition key can't be read
+func getVfIbPkey(pfAddr, vfAddr string) string {
+	ibDev, err := getIbDevice(pfAddr)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(ibVfPkeyIndexPath(ibDev, vfAddr))
+	if err != nil {
+		return ""
+	}
+	idx := strings.TrimSpace(string(data))
+	if _, err := strconv.Atoi(idx); err != nil {
+		return ""
+	}
+	pkey, ok, err := readIbPkey(filepath.Join(ibPfPkeysPath(ibDev), idx))
+	if err != nil || !ok {
+		return ""
+	}
+	return fmt.Sprintf("0x%04x", pkey)
+}
@@ -0,0 +1,95 @@
+package sriov
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("IB partition key", func() {
+	const pkeyIdxPath = "/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx/0"
+
+	configureFS := func(pkeys map[string]string) {
+		files := map[string][]byte{pkeyIdxPath: []byte("0")}
+		for idx, pkey := range pkeys {
+			files["/sys/class/infiniband/mlx4_0/ports/1/pkeys/"+idx] = []byte(pkey)
+		}
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+			Dirs: []string{
+				"/sys/bus/pci/devices/0000:d8:00.0/infiniband/mlx4_0",
+				"/sys/class/infiniband/mlx4_0/ports/1/pkeys",
+				"/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx",
+			},
+			Files: files,
+		})
+	}
+
+	It("should map the VF to the entry holding the partition key", func() {
+		configureFS(map[string]string{"0": "0xffff", "1": "0x8001", "2": "0x0001", "3": "none"})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x0001"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(pkeyIdxPath, "2")
+		Expect(getVfIbPkey("0000:d8:00.0", "0000:d8:00.2")).To(Equal("0x0001"))
+	})
+
+	It("should map the default partition whatever its membership", func() {
+		configureFS(map[string]string{"0": "0x8001", "1": "0xffff"})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x7fff"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(pkeyIdxPath, "1")
+		Expect(getVfIbPkey("0000:d8:00.0", "0000:d8:00.2")).To(Equal("0xffff"))
+	})
+
+	It("should prefer the exact default partition key", func() {
+		configureFS(map[string]string{"0": "0xffff", "1": "0x7fff"})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x7FFF"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(pkeyIdxPath, "1")
+	})
+
+	It("should fail when the partition key is not in the partition table of the PF", func() {
+		configureFS(map[string]string{"0": "0xffff", "1": "0x0001"})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x8001"})).To(
+			MatchError("partition key 0x8001 is not in the partition table of port 1 of device mlx4_0"))
+		helpers.GinkgoAssertFileContentsEquals(pkeyIdxPath, "0")
+	})
+
+	It("should leave the partition key untouched when unset", func() {
+		configureFS(map[string]string{"0": "0xffff", "1": "0x8001"})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(pkeyIdxPath, "0")
+	})
+
+	It("should reject the drivers which don't expose the partition key table of the VFs", func() {
+		// mlx5 exposes the GUIDs and the policy of the VFs but not their partition keys
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+			Dirs: []string{
+				"/sys/bus/pci/devices/0000:d8:00.0/infiniband/mlx5_0",
+				"/sys/bus/pci/drivers/mlx5_core",
+				"/sys/class/infiniband/mlx5_0/ports/1/pkeys",
+				"/sys/class/infiniband/mlx5_0/device/sriov/0",
+			},
+			Files: map[string][]byte{
+				"/sys/class/infiniband/mlx5_0/ports/1/pkeys/0":       []byte("0xffff"),
+				"/sys/class/infiniband/mlx5_0/ports/1/pkeys/1":       []byte("0x8001"),
+				"/sys/class/infiniband/mlx5_0/device/sriov/0/node":   []byte("00:00:00:00:00:00:00:00"),
+				"/sys/class/infiniband/mlx5_0/device/sriov/0/port":   []byte("00:00:00:00:00:00:00:00"),
+				"/sys/class/infiniband/mlx5_0/device/sriov/0/policy": []byte("Follow"),
+			},
+			Symlinks: map[string]string{
+				"/sys/bus/pci/devices/0000:d8:00.0/driver": "../../../bus/pci/drivers/mlx5_core",
+			},
+		})
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x8001"})).To(
+			MatchError(`'ibPkey' is unsupported by driver "mlx5_core" of device 0000:d8:00.0, ` +
+				"the partition keys of its VFs are assigned by the subnet manager"))
+		Expect(getVfIbPkey("0000:d8:00.0", "0000:d8:00.2")).To(BeEmpty())
+	})
+
+	It("should report no partition key without InfiniBand device", func() {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/sys/bus/pci/devices/0000:d8:00.0"}})
+		Expect(getVfIbPkey("0000:d8:00.0", "0000:d8:00.2")).To(BeEmpty())
+		Expect(setVfIbPkey("0000:d8:00.0", "0000:d8:00.2", &sriovnetworkv1.VfGroup{IbPkey: "0x8001"})).To(
+			MatchError(ContainSubstring("failed to get the InfiniBand device of device 0000:d8:00.0")))
+	})
+})
@@ -0,0 +1,262 @@
+package sriov
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+)
+
+// MacAllocator selects the administrative MAC address of the VFs
+type MacAllocator interface {
+	// Allocate returns the MAC address to assign to the VF with the provided index of the PF
+	Allocate(pfAddr string, vfID int, vfLink netlink.Link) (net.HardwareAddr, error)
+}
+
+// kernelMacAllocator keeps the MAC address assigned to the VF netdev by the kernel
+type kernelMacAllocator struct{}
+
+func (a *kernelMacAllocator) Allocate(_ string, _ int, vfLink netlink.Link) (net.HardwareAddr, error) {
+	return vfLink.Attrs().HardwareAddr, nil
+}
+
+// macPoolLock serializes the allocations from the pools, the VFs and the PFs are configured in parallel and
+// picking the first address not listed in the store and saving it must not be interleaved
+var macPoolLock sync.Mutex
+
+// poolMacAllocator assigns MAC addresses from a pool, the pool of the group is the block of the pool
+// of the policy the operator assigned to the PF of the node so the nodes never share an address,
+// the addresses already assigned on the node are skipped and the assignments are persisted
+// on the host to keep the MAC of the VF stable across reboots
+type poolMacAllocator struct {
+	storeManager store.ManagerInterface
+	pool         string
+	first        uint64
+	size         uint64
+}
+
+func newPoolMacAllocator(storeManager store.ManagerInterface, pool string) (*poolMacAllocator, error) {
+	first, size, err := sriovnetworkv1.ParseMacPool(pool)
+	if err != nil {
+		return nil, err
+	}
+	return &poolMacAllocator{
+		storeManager: storeManager,
+		pool:         pool,
+		first:        sriovnetworkv1.MacToUint64(first),
+		size:         size,
+	}, nil
+}
+
+func (a *poolMacAllocator) inPool(mac net.HardwareAddr) bool {
+	value := sriovnetworkv1.MacToUint64(mac)
+	return len(mac) == 6 && value >= a.first && value-a.first < a.size
+}
+
+func (a *poolMacAllocator) Allocate(pfAddr string, vfID int, _ netlink.Link) (net.HardwareAddr, error) {
+	macPoolLock.Lock()
+	defer macPoolLock.Unlock()
+
+	stored, exist, err := a.storeManager.LoadVfMac(pfAddr, vfID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MAC of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	if exist {
+		mac, err := net.ParseMAC(stored)
+		if err == nil && a.inPool(mac) {
+			return mac, nil
+		}
+		log.Log.V(2).Info("Allocate(): stored MAC is not part of the pool, allocating a new one",
+			"device", pfAddr, "vf", vfID, "mac", stored, "pool", a.pool)
+	}
+
+	assigned, err := a.storeManager.ListVfMacs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the assigned VF MACs: %w", err)
+	}
+	used := make(map[string]struct{}, len(assigned))
+	for _, m := range assigned {
+		used[strings.ToLower(m)] = struct{}{}
+	}
+
+	for i := uint64(0); i < a.size; i++ {
+		mac := sriovnetworkv1.Uint64ToMac(a.first + i)
+		if _, ok := used[mac.String()]; ok {
+			continue
+		}
+		if err := a.storeManager.SaveVfMac(pfAddr, vfID, mac.String()); err != nil {
+			return nil, fmt.Errorf("failed to persist MAC of VF %d of device %s: %w", vfID, pfAddr, err)
+		}
+		return mac, nil
+	}
+	return nil, fmt.Errorf("MAC pool %s is exhausted, can't allocate a MAC for VF %d of device %s", a.pool, vfID, pfAddr)
+}
+
+// baseMacAllocator assigns the base MAC address plus the VF index, the assignments are persisted
+// on the host like the ones of the pool so the pools of the other groups don't hand them out
+type baseMacAllocator struct {
+	storeManager store.ManagerInterface
+	base         string
+	first        uint64
+}
+
+func newBaseMacAllocator(storeManager store.ManagerInterface, base string) (*baseMacAllocator, error) {
+	first, err := sriovnetworkv1.ParseMacBase(base, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &baseMacAllocator{
+		storeManager: storeManager,
+		base:         base,
+		first:        sriovnetworkv1.MacToUint64(first),
+	}, nil
+}
+
+func (a *baseMacAllocator) Allocate(pfAddr string, vfID int, _ netlink.Link) (net.HardwareAddr, error) {
+	value := a.first + uint64(vfID)
+	if value>>40 != a.first>>40 {
+		return nil, fmt.Errorf("base MAC address %s leaves no room for VF %d of device %s", a.base, vfID, pfAddr)
+	}
+	mac := sriovnetworkv1.Uint64ToMac(value)
+	stored, exist, err := a.storeManager.LoadVfMac(pfAddr, vfID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MAC of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	if exist && strings.EqualFold(stored, mac.String()) {
+		return mac, nil
+	}
+	if err := a.storeManager.SaveVfMac(pfAddr, vfID, mac.String()); err != nil {
+		return nil, fmt.Errorf("failed to persist MAC of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	return mac, nil
+}
+
+// getMacAllocator returns the MAC allocator of the VF group
+func getMacAllocator(storeManager store.ManagerInterface, group *sriovnetworkv1.VfGroup) (MacAllocator, error) {
+	switch {
+	case group.MacPool != "":
+		return newPoolMacAllocator(storeManager, group.MacPool)
+	case group.MacBase != "":
+		return newBaseMacAllocator(storeManager, group.MacBase)
+	default:
+		return &kernelMacAllocator{}, nil
+	}
+}
+
+// releaseVfMacs removes the MACs persisted for the VFs of the PF which don't get their MAC from a pool or
+// a base MAC anymore, the VFs removed by a decrease of the number of VFs and the VFs whose group switched to
+// another source of MAC, so the pools can hand the MACs out again
+func releaseVfMacs(storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt) error {
+	for vfID := 0; vfID < ifaceStatus.NumVfs; vfID++ {
+		if vfID < iface.NumVfs {
+			if group := getVfGroup(iface, vfID); group != nil && (group.MacPool != "" || group.MacBase != "") {
+				continue
+			}
+		}
+		if err := storeManager.RemoveVfMac(iface.PciAddress, vfID); err != nil {
+			return fmt.Errorf("failed to remove MAC of VF %d of device %s: %w", vfID, iface.PciAddress, err)
+		}
+	}
+	return nil
+}
+
+// checkMacConflicts returns a MacAddressConflictError if the administrative MAC addresses the configuration would
+// assign to the VFs of the interfaces to configure are used by more than one VF, or by a VF and a PF of the node.
+// The check relies on the known addresses: the MAC requested by the group, the MACs derived from the base MAC of
+// the group, the MACs persisted for the VFs using a MAC pool and the kernel MACs of the existing VFs, the VFs created
+// again by the configuration get a new kernel MAC which is unknown in advance.
+func checkMacConflicts(storeManager store.ManagerInterface, toBeConfigured []interfaceToConfigure,
+	ifaceStatuses []sriovnetworkv1.InterfaceExt) error {
+	// the MAC of each VF indexed by "<pf pci address>/<vf id>", the VFs of the PFs which are not configured keep their MAC
+	vfMacs := map[string]string{}
+	for _, ifaceStatus := range ifaceStatuses {
+		for _, vf := range ifaceStatus.VFs {
+			vfMacs[vfMacKey(ifaceStatus.PciAddress, vf.VfID)] = vf.Mac
+		}
+	}
+	for _, c := range toBeConfigured {
+		iface, ifaceStatus := &c.iface, &c.ifaceStatus
+		for _, vf := range ifaceStatus.VFs {
+			delete(vfMacs, vfMacKey(ifaceStatus.PciAddress, vf.VfID))
+		}
+		linkType := iface.LinkType
+		if linkType == "" {
+			linkType = ifaceStatus.LinkType
+		}
+		if strings.EqualFold(linkType, consts.LinkTypeIB) {
+			continue
+		}
+		recreateVFs := ifaceStatus.NumVfs != iface.NumVfs ||
+			sriovnetworkv1.GetEswitchModeFromStatus(ifaceStatus) != sriovnetworkv1.GetEswitchModeFromSpec(iface)
+		for vfID := 0; vfID < iface.NumVfs; vfID++ {
+			group := getVfGroup(iface, vfID)
+			if group == nil {
+				continue
+			}
+			mac := ""
+			if group.Mac != "" {
+				mac = group.Mac
+			} else if group.MacBase != "" {
+				if base, err := sriovnetworkv1.ParseMacBase(group.MacBase, vfID+1); err == nil {
+					mac = sriovnetworkv1.Uint64ToMac(sriovnetworkv1.MacToUint64(base) + uint64(vfID)).String()
+				}
+			} else if group.MacPool != "" {
+				stored, exist, err := storeManager.LoadVfMac(iface.PciAddress, vfID)
+				if err != nil {
+					return fmt.Errorf("failed to load MAC of VF %d of device %s: %w", vfID, iface.PciAddress, err)
+				}
+				if exist {
+					mac = stored
+				}
+			} else if !recreateVFs {
+				for _, vf := range ifaceStatus.VFs {
+					if vf.VfID == vfID {
+						mac = vf.Mac
+						break
+					}
+				}
+			}
+			vfMacs[vfMacKey(iface.PciAddress, vfID)] = mac
+		}
+	}
+
+	owners := map[string][]string{}
+	addOwner := func(mac, owner string) {
+		hwAddr, err := net.ParseMAC(mac)
+		if err != nil || sriovnetworkv1.MacToUint64(hwAddr) == 0 {
+			return
+		}
+		owners[hwAddr.String()] = append(owners[hwAddr.String()], owner)
+	}
+	for _, ifaceStatus := range ifaceStatuses {
+		addOwner(ifaceStatus.Mac, fmt.Sprintf("PF %s", ifaceStatus.PciAddress))
+	}
+	for key, mac := range vfMacs {
+		pfAddr, vfID, _ := strings.Cut(key, "/")
+		addOwner(mac, fmt.Sprintf("VF %s of PF %s", vfID, pfAddr))
+	}
+	conflicts := map[string][]string{}
+	for mac, devices := range owners {
+		if len(devices) > 1 {
+			sort.Strings(devices)
+			conflicts[mac] = devices
+		}
+	}
+	if len(conflicts) > 0 {
+		return &types.MacAddressConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+func vfMacKey(pfAddr string, vfID int) string {
+	return fmt.Sprintf("%s/%d", pfAddr, vfID)
+}
@@ -0,0 +1,299 @@
+package sriov
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("MacAllocator", func() {
+	var storeManager store.ManagerInterface
+
+	BeforeEach(func() {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/etc/sriov-operator"}})
+		origInChroot := vars.InChroot
+		vars.InChroot = true
+		DeferCleanup(func() {
+			vars.InChroot = origInChroot
+		})
+		var err error
+		storeManager, err = store.NewManager()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	allocateAll := func(pool string, pfAddrs []string, numVfs int) map[string]string {
+		allocator, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacPool: pool})
+		Expect(err).NotTo(HaveOccurred())
+		macs := map[string]string{}
+		for _, pfAddr := range pfAddrs {
+			for vfID := 0; vfID < numVfs; vfID++ {
+				mac, err := allocator.Allocate(pfAddr, vfID, nil)
+				Expect(err).NotTo(HaveOccurred())
+				macs[pfAddr+"/"+strconv.Itoa(vfID)] = mac.String()
+			}
+		}
+		return macs
+	}
+
+	It("should keep the kernel MAC when the group has no pool", func() {
+		allocator, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{})
+		Expect(err).NotTo(HaveOccurred())
+		kernelMac, _ := net.ParseMAC("02:42:19:51:2f:af")
+		vfLink := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{HardwareAddr: kernelMac}}
+		mac, err := allocator.Allocate("0000:d8:00.0", 0, vfLink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mac).To(Equal(kernelMac))
+	})
+
+	It("should assign stable MACs from the pool across config passes", func() {
+		pfs := []string{"0000:d8:00.0", "0000:d8:00.1"}
+		first := allocateAll("02:00:00:00:00:00-02:00:00:00:00:ff", pfs, 4)
+		second := allocateAll("02:00:00:00:00:00-02:00:00:00:00:ff", pfs, 4)
+		Expect(second).To(Equal(first))
+		for _, mac := range first {
+			Expect(mac).To(HavePrefix("02:00:00:00:00:"))
+		}
+	})
+
+	It("should not assign the same MAC twice", func() {
+		// a pool with exactly as many addresses as VFs forces the allocator to skip the used ones
+		pfs := []string{"0000:d8:00.0", "0000:d8:00.1"}
+		first := allocateAll("02:00:00:00:00:00-02:00:00:00:00:07", pfs, 4)
+		second := allocateAll("02:00:00:00:00:00-02:00:00:00:00:07", pfs, 4)
+		Expect(second).To(Equal(first))
+		unique := map[string]struct{}{}
+		for _, mac := range first {
+			unique[mac] = struct{}{}
+		}
+		Expect(unique).To(HaveLen(8))
+	})
+
+	It("should assign the addresses of the pool in order", func() {
+		macs := allocateAll("02:00:00:00:00:10-02:00:00:00:00:13", []string{"0000:d8:00.0"}, 4)
+		Expect(macs).To(Equal(map[string]string{
+			"0000:d8:00.0/0": "02:00:00:00:00:10",
+			"0000:d8:00.0/1": "02:00:00:00:00:11",
+			"0000:d8:00.0/2": "02:00:00:00:00:12",
+			"0000:d8:00.0/3": "02:00:00:00:00:13",
+		}))
+	})
+
+	It("should not assign the same MAC to VFs configured in parallel", func() {
+		pfs := []string{"0000:d8:00.0", "0000:d8:00.1", "0000:3b:00.0", "0000:3b:00.1"}
+		numVfs := 8
+		macs := make([]string, len(pfs)*numVfs)
+		errs := make([]error, len(pfs)*numVfs)
+		// the store pauses after listing the assigned MACs so unsynchronized allocations would pick the same address
+		slowStore := &slowListStore{ManagerInterface: storeManager}
+		var wg sync.WaitGroup
+		for i, pfAddr := range pfs {
+			for vfID := 0; vfID < numVfs; vfID++ {
+				wg.Add(1)
+				go func(idx int, pfAddr string, vfID int) {
+					defer wg.Done()
+					// each VF gets its own allocator like in configSriovVFDevice
+					allocator, err := getMacAllocator(slowStore, &sriovnetworkv1.VfGroup{MacPool: "02:00:00:00:00:00-02:00:00:00:00:ff"})
+					if err != nil {
+						errs[idx] = err
+						return
+					}
+					mac, err := allocator.Allocate(pfAddr, vfID, nil)
+					if err != nil {
+						errs[idx] = err
+						return
+					}
+					macs[idx] = mac.String()
+				}(i*numVfs+vfID, pfAddr, vfID)
+			}
+		}
+		wg.Wait()
+		for _, err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		unique := map[string]struct{}{}
+		for _, mac := range macs {
+			unique[mac] = struct{}{}
+		}
+		Expect(unique).To(HaveLen(len(pfs) * numVfs))
+	})
+
+	It("should release the MACs of the removed VFs and of the VFs not using a pool anymore", func() {
+		allocateAll("02:00:00:00:00:00-02:00:00:00:00:ff", []string{"0000:d8:00.0"}, 4)
+		iface := &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", NumVfs: 3, VfGroups: []sriovnetworkv1.VfGroup{
+			{ResourceName: "pool", VfRange: "0-0", MacPool: "02:00:00:00:00:00-02:00:00:00:00:ff"},
+			{ResourceName: "kernel", VfRange: "1-2"},
+		}}
+		Expect(releaseVfMacs(storeManager, iface, &sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.0", NumVfs: 4})).To(Succeed())
+		Expect(storeManager.ListVfMacs()).To(ConsistOf("02:00:00:00:00:00"))
+
+		// the reset of the PF releases all the MACs
+		Expect(releaseVfMacs(storeManager, &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0"},
+			&sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.0", NumVfs: 3})).To(Succeed())
+		Expect(storeManager.ListVfMacs()).To(BeEmpty())
+	})
+
+	It("should fail when the pool is exhausted", func() {
+		allocator, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacPool: "02:00:00:00:00:00-02:00:00:00:00:01"})
+		Expect(err).NotTo(HaveOccurred())
+		for vfID := 0; vfID < 2; vfID++ {
+			_, err = allocator.Allocate("0000:d8:00.0", vfID, nil)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = allocator.Allocate("0000:d8:00.0", 2, nil)
+		Expect(err).To(MatchError(ContainSubstring("is exhausted")))
+	})
+
+	It("should reallocate the MAC when the pool changes", func() {
+		first := allocateAll("02:00:00:00:00:00-02:00:00:00:00:ff", []string{"0000:d8:00.0"}, 1)
+		second := allocateAll("02:00:00:00:01:00-02:00:00:00:01:ff", []string{"0000:d8:00.0"}, 1)
+		Expect(first["0000:d8:00.0/0"]).To(HavePrefix("02:00:00:00:00:"))
+		Expect(second["0000:d8:00.0/0"]).To(HavePrefix("02:00:00:00:01:"))
+	})
+
+	It("should assign the base MAC plus the VF index and persist it", func() {
+		allocator, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacBase: "02:00:00:00:10:fe"})
+		Expect(err).NotTo(HaveOccurred())
+		for vfID, expected := range []string{"02:00:00:00:10:fe", "02:00:00:00:10:ff", "02:00:00:00:11:00"} {
+			mac, err := allocator.Allocate("0000:d8:00.0", vfID, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mac.String()).To(Equal(expected))
+			stored, exist, err := storeManager.LoadVfMac("0000:d8:00.0", vfID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(stored).To(Equal(expected))
+		}
+	})
+
+	It("should not hand out the MACs assigned from a base MAC from a pool", func() {
+		base, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacBase: "02:00:00:00:00:00"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = base.Allocate("0000:d8:00.0", 0, nil)
+		Expect(err).NotTo(HaveOccurred())
+		macs := allocateAll("02:00:00:00:00:00-02:00:00:00:00:01", []string{"0000:d8:00.1"}, 1)
+		Expect(macs["0000:d8:00.1/0"]).To(Equal("02:00:00:00:00:01"))
+	})
+
+	It("should fail when the base MAC leaves no room for the VF", func() {
+		allocator, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacBase: "02:ff:ff:ff:ff:ff"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = allocator.Allocate("0000:d8:00.0", 1, nil)
+		Expect(err).To(MatchError(ContainSubstring("leaves no room for VF 1")))
+	})
+
+	It("should reject a universally administered base MAC", func() {
+		_, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacBase: "b8:3f:d2:00:00:00"})
+		Expect(err).To(MatchError(ContainSubstring("locally administered")))
+	})
+
+	It("should reject an invalid pool", func() {
+		_, err := getMacAllocator(storeManager, &sriovnetworkv1.VfGroup{MacPool: "02:00:00:00:00:ff-02:00:00:00:00:00"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("checkMacConflicts", func() {
+		var statuses []sriovnetworkv1.InterfaceExt
+
+		BeforeEach(func() {
+			statuses = []sriovnetworkv1.InterfaceExt{{
+				PciAddress: "0000:d8:00.0", Mac: "b8:3f:d2:00:00:01", NumVfs: 2, LinkType: "ETH",
+				VFs: []sriovnetworkv1.VirtualFunction{
+					{VfID: 0, Mac: "02:00:00:00:00:10"},
+					{VfID: 1, Mac: "02:00:00:00:00:11"},
+				},
+			}, {
+				PciAddress: "0000:d8:00.1", Mac: "b8:3f:d2:00:00:02", NumVfs: 1, LinkType: "ETH",
+				VFs: []sriovnetworkv1.VirtualFunction{{VfID: 0, Mac: "02:00:00:00:00:20"}},
+			}}
+		})
+
+		toConfigure := func(status sriovnetworkv1.InterfaceExt, numVfs int, pool string) interfaceToConfigure {
+			return interfaceToConfigure{
+				iface: sriovnetworkv1.Interface{
+					PciAddress: status.PciAddress, NumVfs: numVfs,
+					VfGroups: []sriovnetworkv1.VfGroup{{ResourceName: "res", VfRange: "0-" + strconv.Itoa(numVfs-1), MacPool: pool}},
+				},
+				ifaceStatus: status,
+			}
+		}
+
+		It("should not report a conflict for distinct MACs", func() {
+			Expect(checkMacConflicts(storeManager, []interfaceToConfigure{toConfigure(statuses[0], 2, "")}, statuses)).To(Succeed())
+		})
+
+		It("should report the VFs sharing the same MAC", func() {
+			statuses[1].VFs[0].Mac = "02:00:00:00:00:11"
+			err := checkMacConflicts(storeManager, []interfaceToConfigure{toConfigure(statuses[0], 2, "")}, statuses)
+			conflictErr := &types.MacAddressConflictError{}
+			Expect(errors.As(err, &conflictErr)).To(BeTrue())
+			Expect(conflictErr.Conflicts).To(Equal(map[string][]string{
+				"02:00:00:00:00:11": {"VF 0 of PF 0000:d8:00.1", "VF 1 of PF 0000:d8:00.0"},
+			}))
+		})
+
+		It("should report a VF using the MAC of a PF", func() {
+			statuses[0].VFs[0].Mac = "B8:3F:D2:00:00:02"
+			err := checkMacConflicts(storeManager, []interfaceToConfigure{toConfigure(statuses[0], 2, "")}, statuses)
+			Expect(err).To(MatchError("MAC address conflicts: b8:3f:d2:00:00:02 used by PF 0000:d8:00.1, VF 0 of PF 0000:d8:00.0"))
+		})
+
+		It("should check the MACs stored for the VFs using a pool", func() {
+			Expect(storeManager.SaveVfMac("0000:d8:00.0", 1, "02:00:00:00:00:20")).To(Succeed())
+			err := checkMacConflicts(storeManager, []interfaceToConfigure{toConfigure(statuses[0], 2, "02:00:00:00:00:00-02:00:00:00:00:ff")}, statuses)
+			Expect(err).To(MatchError(ContainSubstring("02:00:00:00:00:20 used by VF 0 of PF 0000:d8:00.1, VF 1 of PF 0000:d8:00.0")))
+		})
+
+		It("should check the MACs derived from the base MAC", func() {
+			c := toConfigure(statuses[0], 4, "")
+			c.iface.VfGroups[0].MacBase = "02:00:00:00:00:1e"
+			err := checkMacConflicts(storeManager, []interfaceToConfigure{c}, statuses)
+			Expect(err).To(MatchError(ContainSubstring("02:00:00:00:00:20 used by VF 0 of PF 0000:d8:00.1, VF 2 of PF 0000:d8:00.0")))
+		})
+
+		It("should check the MAC requested by the group", func() {
+			c := toConfigure(statuses[0], 2, "")
+			c.iface.VfGroups[0].Mac = "02:00:00:00:00:20"
+			err := checkMacConflicts(storeManager, []interfaceToConfigure{c}, statuses)
+			Expect(err).To(MatchError(ContainSubstring(
+				"02:00:00:00:00:20 used by VF 0 of PF 0000:d8:00.0, VF 0 of PF 0000:d8:00.1, VF 1 of PF 0000:d8:00.0")))
+		})
+
+		It("should ignore the kernel MACs of the VFs created again", func() {
+			statuses[1].VFs[0].Mac = "02:00:00:00:00:11"
+			Expect(checkMacConflicts(storeManager, []interfaceToConfigure{toConfigure(statuses[0], 4, "")}, statuses)).To(Succeed())
+		})
+
+		It("should ignore the VFs not belonging to a group and the zero MACs", func() {
+			statuses[1].VFs[0].Mac = "02:00:00:00:00:11"
+			statuses[0].VFs[0].Mac = "00:00:00:00:00:00"
+			statuses[1].Mac = "00:00:00:00:00:00"
+			c := toConfigure(statuses[0], 2, "")
+			c.iface.VfGroups[0].VfRange = "0-0"
+			Expect(checkMacConflicts(storeManager, []interfaceToConfigure{c}, statuses)).To(Succeed())
+		})
+	})
+})
+
+// slowListStore pauses after listing the VF MACs to widen the window between the list and the save of an allocation
+type slowListStore struct {
+	store.ManagerInterface
+}
+
+func (s *slowListStore) ListVfMacs() ([]string, error) {
+	macs, err := s.ManagerInterface.ListVfMacs()
+	time.Sleep(10 * time.Millisecond)
+	return macs, err
+}
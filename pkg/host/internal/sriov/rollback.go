@@ -0,0 +1,54 @@
+package sriov
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// rollbackAction restores a single setting changed while configuring a device
+type rollbackAction struct {
+	description   string
+	keysAndValues []interface{}
+	undo          func() error
+}
+
+// configRollback records the changes applied while configuring a device, so they can be
+// reverted to their previous values if the configuration fails midway.
+// It is safe for concurrent use, the VFs of a device are configured in parallel.
+// All the methods are no-op on a nil configRollback.
+type configRollback struct {
+	mu      sync.Mutex
+	actions []rollbackAction
+}
+
+// push records the action restoring a change, the actions are unwound in the reverse order
+func (r *configRollback) push(undo func() error, description string, keysAndValues ...interface{}) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, rollbackAction{description: description, keysAndValues: keysAndValues, undo: undo})
+}
+
+// unwind reverts the recorded changes starting from the last one, a failed action is logged
+// and the remaining actions are still executed. Returns the number of actions which failed.
+func (r *configRollback) unwind() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed := 0
+	for i := len(r.actions) - 1; i >= 0; i-- {
+		action := r.actions[i]
+		log.Log.V(2).Info("unwind(): rollback "+action.description, action.keysAndValues...)
+		if err := action.undo(); err != nil {
+			log.Log.Error(err, "unwind(): failed to rollback "+action.description, action.keysAndValues...)
+			failed++
+		}
+	}
+	r.actions = nil
+	return failed
+}
@@ -1,15 +1,21 @@
 package sriov
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/jaypipes/ghw"
 	"github.com/vishvananda/netlink"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -23,10 +29,39 @@ import (
 	sriovnetPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/sriovnet"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/metrics"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	mlx "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vendors/mellanox"
 )
 
+var (
+	// interval and timeout of the wait for the PCI devices of the VFs to appear after NumVfs is set
+	vfDevicesPollInterval = 100 * time.Millisecond
+	vfDevicesPollTimeout  = 5 * time.Second
+	// writes the sriov_numvfs file, replaced in tests to simulate the driver rejecting a value
+	writeNumVfsFile = os.WriteFile
+)
+
+// index and number of the SR-IOV BARs in the sysfs resource file of a PF
+const (
+	pciIovResourcesStart = 7
+	pciNumIovResources   = 6
+)
+
+// vendors of the PFs whose link is set down while the number of VFs changes even if it isn't requested
+// in the spec, the VF creation flaps the link of the Broadcom PFs and disrupts their neighbors
+var pfLinkDownOnVfChangeVendors = []string{"14e4"}
+
+// PF drivers known to reject VF settings, the kernel doesn't report whether a driver supports them
+var (
+	vfVlanProto8021adUnsupportedDrivers = []string{"i40e", "ixgbe", "igb"}
+	vfSpoofChkUnsupportedDrivers        = []string{"sfc"}
+)
+
+// mbpsToBytesPerSecond converts the VF rates expressed in Mbps to the bytes per second used by devlink
+const mbpsToBytesPerSecond = 1000 * 1000 / 8
+
 type interfaceToConfigure struct {
 	iface       sriovnetworkv1.Interface
 	ifaceStatus sriovnetworkv1.InterfaceExt
@@ -42,6 +77,7 @@ type sriov struct {
 	dputilsLib    dputilsPkg.DPUtilsLib
 	sriovnetLib   sriovnetPkg.SriovnetLib
 	ghwLib        ghwPkg.GHWLib
+	mlxHelper     mlx.MellanoxInterface
 }
 
 func New(utilsHelper utils.CmdInterface,
@@ -52,7 +88,8 @@ func New(utilsHelper utils.CmdInterface,
 	netlinkLib netlinkPkg.NetlinkLib,
 	dputilsLib dputilsPkg.DPUtilsLib,
 	sriovnetLib sriovnetPkg.SriovnetLib,
-	ghwLib ghwPkg.GHWLib) types.SriovInterface {
+	ghwLib ghwPkg.GHWLib,
+	mlxHelper mlx.MellanoxInterface) types.SriovInterface {
 	return &sriov{utilsHelper: utilsHelper,
 		kernelHelper:  kernelHelper,
 		networkHelper: networkHelper,
@@ -62,61 +99,319 @@ func New(utilsHelper utils.CmdInterface,
 		dputilsLib:    dputilsLib,
 		sriovnetLib:   sriovnetLib,
 		ghwLib:        ghwLib,
+		mlxHelper:     mlxHelper,
 	}
 }
 
 func (s *sriov) SetSriovNumVfs(pciAddr string, numVfs int) error {
 	log.Log.V(2).Info("SetSriovNumVfs(): set NumVfs", "device", pciAddr, "numVfs", numVfs)
 	numVfsFilePath := filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, consts.NumVfsFile)
-	bs := []byte(strconv.Itoa(numVfs))
-	err := os.WriteFile(numVfsFilePath, []byte("0"), os.ModeAppend)
+	// the previous number of VFs is restored if the driver rejects the new one, -1 when it can't be read
+	prevNumVfs, err := s.readSriovNumVfs(pciAddr)
 	if err != nil {
-		log.Log.Error(err, "SetSriovNumVfs(): fail to reset NumVfs file", "path", numVfsFilePath)
-		return err
+		log.Log.V(2).Info("SetSriovNumVfs(): fail to read the current NumVfs", "device", pciAddr, "error", err)
+		prevNumVfs = -1
+	}
+	if prevNumVfs != 0 {
+		err = writeNumVfsFile(numVfsFilePath, []byte("0"), os.ModeAppend)
+		if err != nil {
+			log.Log.Error(err, "SetSriovNumVfs(): fail to reset NumVfs file", "path", numVfsFilePath)
+			return err
+		}
 	}
 	if numVfs == 0 {
 		return nil
 	}
-	err = os.WriteFile(numVfsFilePath, bs, os.ModeAppend)
+	err = writeNumVfsFile(numVfsFilePath, []byte(strconv.Itoa(numVfs)), os.ModeAppend)
 	if err != nil {
 		log.Log.Error(err, "SetSriovNumVfs(): fail to set NumVfs file", "path", numVfsFilePath)
+		if prevNumVfs > 0 {
+			log.Log.Info("SetSriovNumVfs(): restore the previous NumVfs", "device", pciAddr, "numVfs", prevNumVfs)
+			if restoreErr := writeNumVfsFile(numVfsFilePath, []byte(strconv.Itoa(prevNumVfs)), os.ModeAppend); restoreErr != nil {
+				log.Log.Error(restoreErr, "SetSriovNumVfs(): fail to restore NumVfs", "device", pciAddr, "numVfs", prevNumVfs)
+				return errors.Join(err, fmt.Errorf("failed to restore NumVfs %d for device %s: %w", prevNumVfs, pciAddr, restoreErr))
+			}
+		}
+		return err
+	}
+	if err := s.verifySriovNumVfs(pciAddr, numVfs); err != nil {
+		log.Log.Error(err, "SetSriovNumVfs(): NumVfs not applied", "device", pciAddr)
+		return err
+	}
+	return nil
+}
+
+// readSriovNumVfs returns the number of VFs currently configured on the device
+func (s *sriov) readSriovNumVfs(pciAddr string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, consts.NumVfsFile))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read back NumVfs for device %s: %w", pciAddr, err)
+	}
+	current, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse NumVfs for device %s: %w", pciAddr, err)
+	}
+	return current, nil
+}
+
+// verifySriovNumVfs reads back the number of VFs configured on the device, the driver may
+// silently clamp the requested value, and waits for the VFs to appear
+func (s *sriov) verifySriovNumVfs(pciAddr string, numVfs int) error {
+	current, err := s.readSriovNumVfs(pciAddr)
+	if err != nil {
 		return err
 	}
+	if current != numVfs {
+		return fmt.Errorf("NumVfs not applied for device %s: requested %d, got %d", pciAddr, numVfs, current)
+	}
+
+	return s.WaitForVFs(pciAddr, numVfs, vfDevicesPollTimeout)
+}
+
+// GetInterfaceCapabilities returns the SR-IOV features and limits of the PF
+func (s *sriov) GetInterfaceCapabilities(pciAddr string) (sriovnetworkv1.InterfaceCapabilities, error) {
+	log.Log.V(2).Info("GetInterfaceCapabilities(): get device capabilities", "device", pciAddr)
+	driver, err := s.dputilsLib.GetDriverName(pciAddr)
+	if err != nil {
+		return sriovnetworkv1.InterfaceCapabilities{}, fmt.Errorf("failed to get the driver of device %s: %w", pciAddr, err)
+	}
+	pfName := s.networkHelper.TryGetInterfaceName(pciAddr)
+	if pfName == "" {
+		return sriovnetworkv1.InterfaceCapabilities{}, fmt.Errorf("failed to get interface name for device %s", pciAddr)
+	}
+	link, err := s.netlinkLib.LinkByName(pfName)
+	if err != nil {
+		return sriovnetworkv1.InterfaceCapabilities{}, fmt.Errorf("failed to get the link of device %s: %w", pciAddr, err)
+	}
+	return interfaceCapabilities(driver, s.networkHelper.GetNetDevLinkSpeed(pfName), link), nil
+}
+
+// interfaceCapabilities returns the capabilities of the PF from its driver, its link speed as
+// reported by GetNetDevLinkSpeed and the VFs of its link
+func interfaceCapabilities(driver, linkSpeed string, link netlink.Link) sriovnetworkv1.InterfaceCapabilities {
+	caps := sriovnetworkv1.InterfaceCapabilities{
+		VfTrust:           true,
+		VfSpoofChk:        !sriovnetworkv1.StringInArray(driver, vfSpoofChkUnsupportedDrivers),
+		VfVlanProto8021ad: !sriovnetworkv1.StringInArray(driver, vfVlanProto8021adUnsupportedDrivers),
+	}
+	// the speed is -1 while the link is down
+	if fields := strings.Fields(linkSpeed); len(fields) > 0 {
+		if speed, err := strconv.Atoi(fields[0]); err == nil && speed > 0 {
+			caps.MaxTxRate = speed
+		}
+	}
+	// the kernel reports the trust mode of the VFs as -1 when the driver doesn't support it
+	for _, vf := range link.Attrs().Vfs {
+		if vf.Trust == math.MaxUint32 {
+			caps.VfTrust = false
+			break
+		}
+	}
+	return caps
+}
+
+// checkInterfaceCapabilities returns an UnsupportedConfigError if the VF groups request settings
+// the capabilities of the PF don't allow, the check is skipped if the capabilities can't be read
+func (s *sriov) checkInterfaceCapabilities(iface *sriovnetworkv1.Interface) error {
+	// the capabilities are read only when a group requests a setting a PF may not support
+	needed := false
+	for _, group := range iface.VfGroups {
+		if group.Trust == sriovnetworkv1.SriovCniStateOn || group.SpoofChk == sriovnetworkv1.SriovCniStateOn ||
+			sriovnetworkv1.GetVlanProto(group.VlanProto) == sriovnetworkv1.VlanProto8021ad || group.MaxTxRate != nil {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil
+	}
+	caps, err := s.GetInterfaceCapabilities(iface.PciAddress)
+	if err != nil {
+		log.Log.V(2).Info("checkInterfaceCapabilities(): unable to read the capabilities, skipping the check",
+			"device", iface.PciAddress, "error", err)
+		return nil
+	}
+	unsupported := []string{}
+	for i := range iface.VfGroups {
+		for _, setting := range caps.UnsupportedVfGroupSettings(&iface.VfGroups[i]) {
+			if !sriovnetworkv1.StringInArray(setting, unsupported) {
+				unsupported = append(unsupported, setting)
+			}
+		}
+	}
+	if len(unsupported) > 0 {
+		return &types.UnsupportedConfigError{PciAddress: iface.PciAddress, Settings: unsupported}
+	}
+	return nil
+}
+
+// WaitForVFs waits for the first count VFs of the PF to be fully materialized: the PCI device of the VF
+// exists and, when the VF is bound to a kernel network driver, its netdev is registered
+func (s *sriov) WaitForVFs(pfPciAddr string, count int, timeout time.Duration) error {
+	log.Log.V(2).Info("WaitForVFs(): wait for VFs", "device", pfPciAddr, "count", count, "timeout", timeout)
+	var missing []int
+	var listErr error
+	err := wait.PollImmediate(vfDevicesPollInterval, timeout, func() (bool, error) {
+		missing, listErr = s.getMissingVFs(pfPciAddr, count)
+		return listErr == nil && len(missing) == 0, nil
+	})
+	if err != nil {
+		if listErr != nil {
+			return fmt.Errorf("VFs of device %s not ready after %s: %v: %w", pfPciAddr, timeout, listErr, err)
+		}
+		return fmt.Errorf("VFs of device %s not ready after %s, missing VFs %v: %w", pfPciAddr, timeout, missing, err)
+	}
 	return nil
 }
 
-func (s *sriov) ResetSriovDevice(ifaceStatus sriovnetworkv1.InterfaceExt) error {
+// getMissingVFs returns the indices of the first count VFs of the PF that are not materialized yet
+func (s *sriov) getMissingVFs(pfPciAddr string, count int) ([]int, error) {
+	vfAddrs, err := s.dputilsLib.GetVFList(pfPciAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VFs: %w", err)
+	}
+	ready := make(map[int]bool, len(vfAddrs))
+	for _, addr := range vfAddrs {
+		vfID, err := s.dputilsLib.GetVFID(addr)
+		if err != nil {
+			continue
+		}
+		// VFs without a driver or bound to a DPDK driver have no netdev
+		if hasDriver, driver := s.kernelHelper.HasDriver(addr); hasDriver &&
+			!sriovnetworkv1.StringInArray(driver, vars.DpdkDrivers) && s.networkHelper.TryGetInterfaceName(addr) == "" {
+			continue
+		}
+		ready[vfID] = true
+	}
+	var missing []int
+	for i := 0; i < count; i++ {
+		if !ready[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing, nil
+}
+
+func (s *sriov) ResetSriovDevice(storeManager store.ManagerInterface, ifaceStatus sriovnetworkv1.InterfaceExt) (err error) {
 	log.Log.V(2).Info("ResetSriovDevice(): reset SRIOV device", "address", ifaceStatus.PciAddress)
+	defer func() { metrics.ObservePfReset(ifaceStatus.PciAddress, ifaceStatus.Vendor, err) }()
+	unlock, err := lockDevice(context.Background(), ifaceStatus.PciAddress)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	if ifaceStatus.LinkType == consts.LinkTypeETH {
 		var mtu int
+		var inlineMode, encapMode string
 		eswitchMode := sriovnetworkv1.ESwithModeLegacy
 		is := sriovnetworkv1.InitialState.GetInterfaceStateByPciAddress(ifaceStatus.PciAddress)
 		if is != nil {
 			mtu = is.Mtu
 			eswitchMode = sriovnetworkv1.GetEswitchModeFromStatus(is)
-		} else {
+			inlineMode, encapMode = is.EswitchInlineMode, is.EswitchEncapMode
+		}
+		// the MTU captured when the PF was first managed survives the restarts of the daemon, unlike the initial state
+		originalMtu, exist, err := storeManager.LoadPfOriginalMtu(ifaceStatus.PciAddress)
+		if err != nil {
+			log.Log.Error(err, "ResetSriovDevice(): failed to load the original MTU of the PF", "address", ifaceStatus.PciAddress)
+		} else if exist {
+			mtu = originalMtu
+		}
+		if mtu <= 0 {
 			mtu = 1500
 		}
+		// the VFs are restored while they still exist, before the number of VFs is reset
+		s.restoreVfsInitialState(&ifaceStatus, is)
 		log.Log.V(2).Info("ResetSriovDevice(): reset mtu", "value", mtu)
-		if err := s.networkHelper.SetNetdevMTU(ifaceStatus.PciAddress, mtu); err != nil {
+		if err := s.networkHelper.SetNetdevMTU(context.Background(), ifaceStatus.PciAddress, mtu); err != nil {
 			return err
 		}
-		log.Log.V(2).Info("ResetSriovDevice(): reset eswitch mode and number of VFs", "mode", eswitchMode)
-		if err := s.setEswitchModeAndNumVFs(ifaceStatus.PciAddress, eswitchMode, 0); err != nil {
+		log.Log.V(2).Info("ResetSriovDevice(): reset eswitch mode and number of VFs", "mode", eswitchMode,
+			"inlineMode", inlineMode, "encapMode", encapMode)
+		if err := s.setEswitchModeAndNumVFs(ifaceStatus.PciAddress, eswitchMode, 0, inlineMode, encapMode); err != nil {
 			return err
 		}
+		if sriovnetworkv1.PfcPrioritiesMask(ifaceStatus.PfcEnabled) != 0 ||
+			sriovnetworkv1.PriorityToTcArray(ifaceStatus.PriorityToTcMap) != [8]uint8{} {
+			log.Log.V(2).Info("ResetSriovDevice(): reset DCB configuration", "device", ifaceStatus.PciAddress)
+			if err := s.netlinkLib.DcbIeeeSet(ifaceStatus.Name, &netlinkPkg.DcbIeee{}); err != nil {
+				return err
+			}
+		}
 	} else if ifaceStatus.LinkType == consts.LinkTypeIB {
 		if err := s.SetSriovNumVfs(ifaceStatus.PciAddress, 0); err != nil {
 			return err
 		}
-		if err := s.networkHelper.SetNetdevMTU(ifaceStatus.PciAddress, 2048); err != nil {
+		if err := s.networkHelper.SetNetdevMTU(context.Background(), ifaceStatus.PciAddress, 2048); err != nil {
 			return err
 		}
 	}
-	return nil
+	// the PF has no VF anymore, the MACs assigned to its VFs are released
+	return releaseVfMacs(storeManager, &sriovnetworkv1.Interface{PciAddress: ifaceStatus.PciAddress}, &ifaceStatus)
+}
+
+// restoreVfsInitialState returns the VFs of the PF to the state captured in the initial state of the PF:
+// the VFs bound to a DPDK driver are bound back to their default driver, the default transmit queue length
+// is restored, the admin MAC is cleared and the VLAN, its QoS and its protocol are set back to the initial values
+// of the VF, or cleared if the VF didn't exist initially.
+// The restore is best effort, failures are logged and don't prevent the reset of the device.
+func (s *sriov) restoreVfsInitialState(ifaceStatus *sriovnetworkv1.InterfaceExt, initialState *sriovnetworkv1.InterfaceExt) {
+	if len(ifaceStatus.VFs) == 0 {
+		return
+	}
+	pfLink, err := s.netlinkLib.LinkByName(ifaceStatus.Name)
+	if err != nil {
+		log.Log.Error(err, "restoreVfsInitialState(): unable to get PF link, VF admin MAC and VLAN are not restored",
+			"device", ifaceStatus.PciAddress)
+		pfLink = nil
+	}
+	for _, vf := range ifaceStatus.VFs {
+		initialVf, hasInitialVf := getInitialVf(initialState, vf.PciAddress)
+		if sriovnetworkv1.StringInArray(vf.Driver, vars.DpdkDrivers) &&
+			!(hasInitialVf && initialVf.Driver == vf.Driver) {
+			log.Log.V(2).Info("restoreVfsInitialState(): bind VF to default driver", "device", vf.PciAddress, "driver", vf.Driver)
+			if err := s.kernelHelper.BindDefaultDriver(vf.PciAddress); err != nil {
+				log.Log.Error(err, "restoreVfsInitialState(): fail to bind default driver for VF", "device", vf.PciAddress)
+			}
+		}
+		if err := s.resetVfTxQueueLen(vf.PciAddress); err != nil {
+			log.Log.Error(err, "restoreVfsInitialState(): fail to restore VF transmit queue length", "device", vf.PciAddress)
+		}
+		if pfLink == nil {
+			continue
+		}
+		// a zero admin MAC clears the MAC configured on the VF
+		if err := s.netlinkLib.LinkSetVfHardwareAddr(pfLink, vf.VfID, make(net.HardwareAddr, 6)); err != nil {
+			log.Log.Error(err, "restoreVfsInitialState(): fail to restore VF admin MAC", "device", vf.PciAddress)
+		}
+		// the protocol of the initial VLAN is recorded with it by the discovery, 802.1q if it wasn't reported
+		vlan, qos, proto := 0, 0, sriovnetworkv1.VlanProto8021q
+		if hasInitialVf {
+			vlan, qos, proto = initialVf.Vlan, initialVf.VlanQoS, sriovnetworkv1.GetVlanProto(initialVf.VlanProto)
+		}
+		if vf.Vlan != vlan || vf.VlanQoS != qos || (vlan != 0 && sriovnetworkv1.GetVlanProto(vf.VlanProto) != proto) {
+			if err := s.netlinkLib.LinkSetVfVlanQosProto(pfLink, vf.VfID, vlan, qos, int(netlink.StringToVlanProtocol(proto))); err != nil {
+				log.Log.Error(err, "restoreVfsInitialState(): fail to restore VF VLAN", "device", vf.PciAddress)
+			}
+		}
+	}
+}
+
+// getInitialVf returns the initial state of the VF with the provided PCI address
+func getInitialVf(initialState *sriovnetworkv1.InterfaceExt, vfAddr string) (sriovnetworkv1.VirtualFunction, bool) {
+	if initialState == nil {
+		return sriovnetworkv1.VirtualFunction{}, false
+	}
+	for _, vf := range initialState.VFs {
+		if sriovnetworkv1.PciAddressEqual(vf.PciAddress, vfAddr) {
+			return vf, true
+		}
+	}
+	return sriovnetworkv1.VirtualFunction{}, false
 }
 
-func (s *sriov) getVfInfo(vfAddr string, pfName string, eswitchMode string, devices []*ghw.PCIDevice) sriovnetworkv1.VirtualFunction {
+func (s *sriov) getVfInfo(vfAddr string, pfName string, eswitchMode string, numaNode int, pfLink netlink.Link,
+	getDevice func(address string) *ghw.PCIDevice) sriovnetworkv1.VirtualFunction {
 	driver, err := s.dputilsLib.GetDriverName(vfAddr)
 	if err != nil {
 		log.Log.Error(err, "getVfInfo(): unable to parse device driver", "device", vfAddr)
@@ -129,15 +424,33 @@ func (s *sriov) getVfInfo(vfAddr string, pfName string, eswitchMode string, devi
 		PciAddress: vfAddr,
 		Driver:     driver,
 		VfID:       id,
+		NumaNode:   numaNode,
 		VdpaType:   s.vdpaHelper.DiscoverVDPAType(vfAddr),
 	}
 
+	if vfInfo := getVfNetlinkInfo(pfLink, id); vfInfo != nil {
+		vf.Vlan = vfInfo.Vlan
+		vf.VlanQoS = vfInfo.Qos
+		if vfInfo.VlanProto != 0 {
+			vf.VlanProto = netlink.VlanProtocol(vfInfo.VlanProto).String()
+		}
+		vf.SpoofChk = boolToState(vfInfo.Spoofchk)
+		vf.Trust = boolToState(vfInfo.Trust == 1)
+		vf.MinTxRate = int(vfInfo.MinTxRate)
+		vf.MaxTxRate = int(vfInfo.MaxTxRate)
+		vf.LinkState = vfLinkStateToString(vfInfo.LinkState)
+		if vfInfo.Mac != nil {
+			vf.AdminMac = vfInfo.Mac.String()
+		}
+	}
+
 	if eswitchMode == sriovnetworkv1.ESwithModeSwitchDev {
 		repName, err := s.sriovnetLib.GetVfRepresentor(pfName, id)
 		if err != nil {
 			log.Log.Error(err, "getVfInfo(): failed to get VF representor name", "device", vfAddr)
 		} else {
 			vf.RepresentorName = repName
+			vf.QosGroup = s.getVfQosGroup(repName)
 		}
 	}
 
@@ -149,28 +462,34 @@ func (s *sriov) getVfInfo(vfAddr string, pfName string, eswitchMode string, devi
 			vf.Name = name
 			vf.Mtu = link.Attrs().MTU
 			vf.Mac = link.Attrs().HardwareAddr.String()
+			vf.LinkAdminState = consts.LinkAdminStateDown
+			if link.Attrs().Flags&net.FlagUp != 0 {
+				vf.LinkAdminState = consts.LinkAdminStateUp
+			}
+			vf.Carrier = consts.LinkCarrierDown
+			if link.Attrs().OperState == netlink.OperUp {
+				vf.Carrier = consts.LinkCarrierUp
+			}
 		}
 	}
 	vf.GUID = s.networkHelper.GetNetDevNodeGUID(vfAddr)
 
-	for _, device := range devices {
-		if vfAddr == device.Address {
+	if getDevice != nil {
+		if device := getDevice(vfAddr); device != nil {
 			vf.Vendor = device.Vendor.ID
 			vf.DeviceID = device.Product.ID
-			break
 		}
 	}
 	return vf
 }
 
-func (s *sriov) SetVfGUID(vfAddr string, pfLink netlink.Link) error {
-	log.Log.Info("SetVfGUID()", "vf", vfAddr)
+func (s *sriov) SetVfGUID(vfAddr string, pfLink netlink.Link, guid net.HardwareAddr) error {
+	log.Log.Info("SetVfGUID()", "vf", vfAddr, "guid", guid)
 	vfID, err := s.dputilsLib.GetVFID(vfAddr)
 	if err != nil {
 		log.Log.Error(err, "SetVfGUID(): unable to get VF id", "address", vfAddr)
 		return err
 	}
-	guid := utils.GenerateRandomGUID()
 	if err := s.netlinkLib.LinkSetVfNodeGUID(pfLink, vfID, guid); err != nil {
 		return err
 	}
@@ -184,11 +503,51 @@ func (s *sriov) SetVfGUID(vfAddr string, pfLink netlink.Link) error {
 	return nil
 }
 
-func (s *sriov) VFIsReady(pciAddr string) (netlink.Link, error) {
-	log.Log.Info("VFIsReady()", "device", pciAddr)
+// getVfGUID returns the GUID to assign to the VF: the one requested by the VF group,
+// otherwise the one derived from the node name, the PF address and the VF index if the VF group requests
+// deterministic GUIDs, otherwise the one persisted on the host, otherwise a new random GUID
+// that is persisted on the host to keep the GUID of the VF stable across reboots
+func (s *sriov) getVfGUID(storeManager store.ManagerInterface, pfAddr string, vfID int, group *sriovnetworkv1.VfGroup) (net.HardwareAddr, error) {
+	if requested, ok := group.VfGUIDs[strconv.Itoa(vfID)]; ok {
+		guid, err := net.ParseMAC(requested)
+		if err != nil || len(guid) != 8 {
+			return nil, fmt.Errorf("invalid GUID %q requested for VF %d of device %s", requested, vfID, pfAddr)
+		}
+		if err := storeManager.SaveVfGUID(pfAddr, vfID, guid.String()); err != nil {
+			return nil, fmt.Errorf("failed to persist GUID of VF %d of device %s: %w", vfID, pfAddr, err)
+		}
+		return guid, nil
+	}
+
+	if group.GUIDGeneration == sriovnetworkv1.GUIDGenerationDeterministic {
+		return utils.GenerateDeterministicGUID(vars.NodeName, pfAddr, vfID), nil
+	}
+
+	stored, exist, err := storeManager.LoadVfGUID(pfAddr, vfID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GUID of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	if exist {
+		guid, err := net.ParseMAC(stored)
+		if err == nil && len(guid) == 8 {
+			return guid, nil
+		}
+		log.Log.Error(err, "getVfGUID(): invalid stored GUID, generating a new one", "device", pfAddr, "vf", vfID, "guid", stored)
+	}
+
+	guid := utils.GenerateRandomGUID()
+	if err := storeManager.SaveVfGUID(pfAddr, vfID, guid.String()); err != nil {
+		return nil, fmt.Errorf("failed to persist GUID of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	return guid, nil
+}
+
+func (s *sriov) VFIsReady(ctx context.Context, pciAddr string, interval, timeout time.Duration) (netlink.Link, error) {
+	log.Log.Info("VFIsReady()", "device", pciAddr, "interval", interval, "timeout", timeout)
 	var err error
 	var vfLink netlink.Link
-	err = wait.PollImmediate(time.Second, 10*time.Second, func() (bool, error) {
+	start := time.Now()
+	pollErr := wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(context.Context) (bool, error) {
 		vfName := s.networkHelper.TryGetInterfaceName(pciAddr)
 		vfLink, err = s.netlinkLib.LinkByName(vfName)
 		if err != nil {
@@ -196,8 +555,12 @@ func (s *sriov) VFIsReady(pciAddr string) (netlink.Link, error) {
 		}
 		return err == nil, nil
 	})
-	if err != nil {
-		return vfLink, err
+	if pollErr != nil {
+		if ctx.Err() != nil {
+			return vfLink, fmt.Errorf("wait for VF %s link canceled: %w", pciAddr, ctx.Err())
+		}
+		waited := time.Since(start).Round(time.Millisecond)
+		return vfLink, fmt.Errorf("VF %s link not ready after waiting %s: %w", pciAddr, waited, err)
 	}
 	return vfLink, nil
 }
@@ -218,353 +581,1562 @@ func (s *sriov) SetVfAdminMac(vfAddr string, pfLink, vfLink netlink.Link) error
 	return nil
 }
 
-func (s *sriov) DiscoverSriovDevices(storeManager store.ManagerInterface) ([]sriovnetworkv1.InterfaceExt, error) {
-	log.Log.V(2).Info("DiscoverSriovDevices")
-	pfList := []sriovnetworkv1.InterfaceExt{}
-
-	pci, err := s.ghwLib.PCI()
+// setVfAllocatedMac sets the VF administrative mac address selected by the MAC allocator of the VF group
+func (s *sriov) setVfAllocatedMac(storeManager store.ManagerInterface, pfAddr string, pfLink netlink.Link, vfID int,
+	vfLink netlink.Link, group *sriovnetworkv1.VfGroup) error {
+	allocator, err := getMacAllocator(storeManager, group)
 	if err != nil {
-		return nil, fmt.Errorf("DiscoverSriovDevices(): error getting PCI info: %v", err)
+		return err
 	}
-
-	devices := pci.ListDevices()
-	if len(devices) == 0 {
-		return nil, fmt.Errorf("DiscoverSriovDevices(): could not retrieve PCI devices")
+	mac, err := allocator.Allocate(pfAddr, vfID, vfLink)
+	if err != nil {
+		return err
 	}
+	log.Log.V(2).Info("setVfAllocatedMac(): set VF admin mac", "device", pfAddr, "vf", vfID, "mac", mac.String())
+	return s.netlinkLib.LinkSetVfHardwareAddr(pfLink, vfID, mac)
+}
 
-	for _, device := range devices {
-		devClass, err := strconv.ParseInt(device.Class.ID, 16, 64)
-		if err != nil {
-			log.Log.Error(err, "DiscoverSriovDevices(): unable to parse device class, skipping",
-				"device", device)
-			continue
-		}
-		if devClass != consts.NetClass {
-			// Not network device
-			continue
-		}
-
-		// TODO: exclude devices used by host system
-
-		if s.dputilsLib.IsSriovVF(device.Address) {
-			continue
-		}
-
-		if !vars.DevMode {
-			if !sriovnetworkv1.IsSupportedModel(device.Vendor.ID, device.Product.ID) {
-				log.Log.Info("DiscoverSriovDevices(): unsupported device", "device", device)
-				continue
-			}
-		}
-
-		driver, err := s.dputilsLib.GetDriverName(device.Address)
-		if err != nil {
-			log.Log.Error(err, "DiscoverSriovDevices(): unable to parse device driver for device, skipping", "device", device)
-			continue
-		}
-
-		pfNetName := s.networkHelper.TryGetInterfaceName(device.Address)
-
-		if pfNetName == "" {
-			log.Log.Error(err, "DiscoverSriovDevices(): unable to get device name for device, skipping", "device", device.Address)
-			continue
-		}
+// setVfMac programs the administrative MAC requested by the VF group on the VF, the MAC is left untouched
+// if the group doesn't set it
+func (s *sriov) setVfMac(pfLink netlink.Link, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.Mac == "" {
+		return nil
+	}
+	mac, err := sriovnetworkv1.ParseVfMac(group.Mac)
+	if err != nil {
+		return err
+	}
+	log.Log.V(2).Info("setVfMac(): set VF admin mac", "vf", vfID, "mac", mac.String())
+	return s.netlinkLib.LinkSetVfHardwareAddr(pfLink, vfID, mac)
+}
 
-		link, err := s.netlinkLib.LinkByName(pfNetName)
-		if err != nil {
-			log.Log.Error(err, "DiscoverSriovDevices(): unable to get Link for device, skipping", "device", device.Address)
-			continue
+// getVfNetlinkInfo returns the VF information reported by the PF link for the VF with the provided index
+func getVfNetlinkInfo(pfLink netlink.Link, vfID int) *netlink.VfInfo {
+	vfs := pfLink.Attrs().Vfs
+	for i := range vfs {
+		if vfs[i].ID == vfID {
+			return &vfs[i]
 		}
+	}
+	return nil
+}
 
-		iface := sriovnetworkv1.InterfaceExt{
-			Name:           pfNetName,
-			PciAddress:     device.Address,
-			Driver:         driver,
-			Vendor:         device.Vendor.ID,
-			DeviceID:       device.Product.ID,
-			Mtu:            link.Attrs().MTU,
-			Mac:            link.Attrs().HardwareAddr.String(),
-			LinkType:       s.encapTypeToLinkType(link.Attrs().EncapType),
-			LinkSpeed:      s.networkHelper.GetNetDevLinkSpeed(pfNetName),
-			LinkAdminState: s.networkHelper.GetNetDevLinkAdminState(pfNetName),
+// setVfVlan programs the VLAN and the QoS priority requested by the VF group on the VF, the VLAN is
+// left untouched if the group doesn't set it, the VFs without VLAN are priority tagged
+func (s *sriov) setVfVlan(pfLink netlink.Link, vfID int, group *sriovnetworkv1.VfGroup) error {
+	priority := group.GetQosPriority()
+	if group.VlanID == nil && priority == nil {
+		return nil
+	}
+	vlan, qos, proto := 0, group.VlanQoS, netlink.StringToVlanProtocol(sriovnetworkv1.GetVlanProto(group.VlanProto))
+	if group.VlanID != nil {
+		vlan = *group.VlanID
+	}
+	if priority != nil {
+		qos = *priority
+	}
+	if vlan == 0 {
+		// clear the VLAN, keeping the priority tag if requested
+		if priority == nil {
+			qos = 0
 		}
+		proto = netlink.VLAN_PROTOCOL_8021Q
+	}
+	log.Log.V(2).Info("setVfVlan(): set VF vlan", "vf", vfID, "vlan", vlan, "qos", qos, "proto", proto.String())
+	return s.netlinkLib.LinkSetVfVlanQosProto(pfLink, vfID, vlan, qos, int(proto))
+}
 
-		pfStatus, exist, err := storeManager.LoadPfsStatus(iface.PciAddress)
-		if err != nil {
-			log.Log.Error(err, "DiscoverSriovDevices(): failed to load PF status from disk")
-		} else {
-			if exist {
-				iface.ExternallyManaged = pfStatus.ExternallyManaged
-			}
+// setVfSpoofChkAndTrust programs the spoof check and trust mode requested by the VF group
+// on the VF, the settings that are not set in the group are left untouched
+func (s *sriov) setVfSpoofChkAndTrust(pfLink netlink.Link, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.SpoofChk != "" {
+		log.Log.V(2).Info("setVfSpoofChkAndTrust(): set VF spoof check", "vf", vfID, "spoofChk", group.SpoofChk)
+		if err := s.netlinkLib.LinkSetVfSpoofchk(pfLink, vfID, group.SpoofChk == sriovnetworkv1.SriovCniStateOn); err != nil {
+			return err
 		}
-
-		if s.dputilsLib.IsSriovPF(device.Address) {
-			iface.TotalVfs = s.dputilsLib.GetSriovVFcapacity(device.Address)
-			iface.NumVfs = s.dputilsLib.GetVFconfigured(device.Address)
-			iface.EswitchMode = s.GetNicSriovMode(device.Address)
-			if s.dputilsLib.SriovConfigured(device.Address) {
-				vfs, err := s.dputilsLib.GetVFList(device.Address)
-				if err != nil {
-					log.Log.Error(err, "DiscoverSriovDevices(): unable to parse VFs for device, skipping",
-						"device", device)
-					continue
-				}
-				for _, vf := range vfs {
-					instance := s.getVfInfo(vf, pfNetName, iface.EswitchMode, devices)
-					iface.VFs = append(iface.VFs, instance)
-				}
-			}
+	}
+	if group.Trust != "" {
+		log.Log.V(2).Info("setVfSpoofChkAndTrust(): set VF trust mode", "vf", vfID, "trust", group.Trust)
+		if err := s.netlinkLib.LinkSetVfTrust(pfLink, vfID, group.Trust == sriovnetworkv1.SriovCniStateOn); err != nil {
+			return err
 		}
-		pfList = append(pfList, iface)
 	}
-
-	return pfList, nil
+	return nil
 }
 
-func (s *sriov) configSriovPFDevice(iface *sriovnetworkv1.Interface) error {
-	log.Log.V(2).Info("configSriovPFDevice(): configure PF sriov device",
-		"device", iface.PciAddress)
-	totalVfs := s.dputilsLib.GetSriovVFcapacity(iface.PciAddress)
-	if iface.NumVfs > totalVfs {
-		err := fmt.Errorf("cannot config SRIOV device: NumVfs (%d) is larger than TotalVfs (%d)", iface.NumVfs, totalVfs)
-		log.Log.Error(err, "configSriovPFDevice(): fail to set NumVfs for device", "device", iface.PciAddress)
-		return err
-	}
-	if err := s.configureHWOptionsForSwitchdev(iface); err != nil {
-		return err
+// setVfTxRate programs the tx rate limits requested by the VF group on the VF, a limit
+// that is not set in the group is kept as currently configured on the VF.
+// The devlink rate API is used when the PF is in switchdev mode.
+func (s *sriov) setVfTxRate(iface *sriovnetworkv1.Interface, pfLink netlink.Link, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.MinTxRate == nil && group.MaxTxRate == nil {
+		return nil
 	}
-	// remove all UDEV rules for the PF before adding new rules to
-	// make sure that rules are always in a consistent state, e.g. there is no
-	// switchdev-related rules for PF in legacy mode
-	if err := s.removeUdevRules(iface.PciAddress); err != nil {
-		log.Log.Error(err, "configSriovPFDevice(): fail to remove udev rules", "device", iface.PciAddress)
-		return err
+	var minRate, maxRate int
+	if group.MinTxRate == nil || group.MaxTxRate == nil {
+		if vfInfo := getVfNetlinkInfo(pfLink, vfID); vfInfo != nil {
+			minRate, maxRate = int(vfInfo.MinTxRate), int(vfInfo.MaxTxRate)
+		}
 	}
-	err := s.addUdevRules(iface)
-	if err != nil {
-		log.Log.Error(err, "configSriovPFDevice(): fail to add udev rules", "device", iface.PciAddress)
-		return err
+	if group.MinTxRate != nil {
+		minRate = *group.MinTxRate
 	}
-	err = s.createVFs(iface)
-	if err != nil {
-		log.Log.Error(err, "configSriovPFDevice(): fail to set NumVfs for device", "device", iface.PciAddress)
-		return err
+	if group.MaxTxRate != nil {
+		maxRate = *group.MaxTxRate
 	}
-	if err := s.addVfRepresentorUdevRule(iface); err != nil {
-		log.Log.Error(err, "configSriovPFDevice(): fail to add VR representor udev rule", "device", iface.PciAddress)
-		return err
+	// 0 means no rate limiting
+	if maxRate != 0 && minRate > maxRate {
+		return fmt.Errorf("min tx rate %d is greater than max tx rate %d for VF %d", minRate, maxRate, vfID)
 	}
-	// set PF mtu
-	if iface.Mtu > 0 && iface.Mtu > s.networkHelper.GetNetdevMTU(iface.PciAddress) {
-		err = s.networkHelper.SetNetdevMTU(iface.PciAddress, iface.Mtu)
-		if err != nil {
-			log.Log.Error(err, "configSriovPFDevice(): fail to set mtu for PF", "device", iface.PciAddress)
-			return err
-		}
+	log.Log.V(2).Info("setVfTxRate(): set VF tx rate", "vf", vfID, "minTxRate", minRate, "maxTxRate", maxRate)
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev {
+		return s.SetDevlinkVfRate(iface.PciAddress, vfID, uint64(minRate), uint64(maxRate))
 	}
-	return nil
+	return s.netlinkLib.LinkSetVfRate(pfLink, vfID, minRate, maxRate)
 }
 
-func (s *sriov) configureHWOptionsForSwitchdev(iface *sriovnetworkv1.Interface) error {
-	log.Log.V(2).Info("configureHWOptionsForSwitchdev(): configure HW options for device",
-		"device", iface.PciAddress)
-	if sriovnetworkv1.GetEswitchModeFromSpec(iface) != sriovnetworkv1.ESwithModeSwitchDev {
-		// we need to configure HW options only for PFs for which switchdev is a target mode
+// setVfQosGroup attaches the VF to the devlink rate group of the PF requested by the VF group, the group is
+// created if it doesn't exist. Rate groups are supported only in switchdev mode, the group is skipped otherwise
+func (s *sriov) setVfQosGroup(iface *sriovnetworkv1.Interface, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.Qos == nil || group.Qos.Group == "" {
 		return nil
 	}
-	if err := s.networkHelper.EnableHwTcOffload(iface.Name); err != nil {
-		return err
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) != sriovnetworkv1.ESwithModeSwitchDev {
+		log.Log.Info("setVfQosGroup(): QoS groups are supported only in switchdev mode, skipping",
+			"device", iface.PciAddress, "vf", vfID, "group", group.Qos.Group)
+		return nil
 	}
-	desiredFlowSteeringMode := "smfs"
-	currentFlowSteeringMode, err := s.networkHelper.GetDevlinkDeviceParam(iface.PciAddress, "flow_steering_mode")
+	port, err := s.getVfDevlinkPort(iface.PciAddress, vfID)
 	if err != nil {
-		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENODEV) {
-			log.Log.V(2).Info("configureHWOptionsForSwitchdev(): device has no flow_steering_mode parameter, skip",
-				"device", iface.PciAddress)
-			return nil
-		}
-		log.Log.Error(err, "configureHWOptionsForSwitchdev(): fail to read current flow steering mode for the device", "device", iface.PciAddress)
 		return err
 	}
-	if currentFlowSteeringMode == desiredFlowSteeringMode {
-		return nil
+	log.Log.V(2).Info("setVfQosGroup(): attach VF to QoS group", "device", iface.PciAddress, "vf", vfID, "group", group.Qos.Group)
+	if err := s.netlinkLib.DevlinkRateNodeNew(port.BusName, port.DeviceName, group.Qos.Group); err != nil &&
+		!errors.Is(err, syscall.EEXIST) {
+		return fmt.Errorf("failed to create QoS group %s on device %s: %w", group.Qos.Group, iface.PciAddress, err)
 	}
-	// flow steering mode can be changed only when NIC is in legacy mode
-	if s.GetNicSriovMode(iface.PciAddress) != sriovnetworkv1.ESwithModeLegacy {
-		s.setEswitchModeAndNumVFs(iface.PciAddress, sriovnetworkv1.ESwithModeLegacy, 0)
+	if err := s.netlinkLib.DevlinkPortFnRateParentSet(port.BusName, port.DeviceName, port.PortIndex, group.Qos.Group); err != nil {
+		return fmt.Errorf("failed to attach VF %d of device %s to QoS group %s: %w", vfID, iface.PciAddress, group.Qos.Group, err)
 	}
-	if err := s.networkHelper.SetDevlinkDeviceParam(iface.PciAddress, "flow_steering_mode", desiredFlowSteeringMode); err != nil {
-		if errors.Is(err, syscall.ENOTSUP) {
-			log.Log.V(2).Info("configureHWOptionsForSwitchdev(): device doesn't support changing of flow_steering_mode, skip", "device", iface.PciAddress)
+	return nil
+}
+
+// getVfQosGroup returns the devlink rate group the VF of the representor is attached to,
+// an empty string if the VF is not attached to a group or the group can't be read
+func (s *sriov) getVfQosGroup(repName string) string {
+	port, err := s.getRepresentorDevlinkPort(repName)
+	if err != nil || port == nil {
+		log.Log.V(2).Info("getVfQosGroup(): devlink port of the representor not found", "representor", repName, "error", err)
+		return ""
+	}
+	group, err := s.netlinkLib.DevlinkPortFnRateParentGet(port.BusName, port.DeviceName, port.PortIndex)
+	if err != nil {
+		log.Log.V(2).Info("getVfQosGroup(): failed to read the rate of the devlink port", "representor", repName, "error", err)
+		return ""
+	}
+	return group
+}
+
+// setVfLinkState programs the administrative link state requested by the VF group on the VF
+func (s *sriov) setVfLinkState(pfLink netlink.Link, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.LinkState == "" {
+		return nil
+	}
+	var state uint32
+	switch group.LinkState {
+	case sriovnetworkv1.SriovCniStateAuto:
+		state = netlink.VF_LINK_STATE_AUTO
+	case sriovnetworkv1.SriovCniStateEnable:
+		state = netlink.VF_LINK_STATE_ENABLE
+	case sriovnetworkv1.SriovCniStateDisable:
+		state = netlink.VF_LINK_STATE_DISABLE
+	default:
+		return fmt.Errorf("unsupported link state %q for VF %d", group.LinkState, vfID)
+	}
+	log.Log.V(2).Info("setVfLinkState(): set VF link state", "vf", vfID, "state", group.LinkState)
+	return s.netlinkLib.LinkSetVfState(pfLink, vfID, state)
+}
+
+// setVfCombinedChannels configures the number of combined channels on the VF netdev
+func (s *sriov) setVfCombinedChannels(vfAddr string, channels int) error {
+	vfName := s.networkHelper.TryGetInterfaceName(vfAddr)
+	if vfName == "" {
+		return fmt.Errorf("failed to get netdevice for VF %s", vfAddr)
+	}
+	return s.networkHelper.SetNetdevCombinedChannels(vfName, channels)
+}
+
+// setVfHostNamespace moves the VF netdev to the host network namespace requested by the VF group
+func (s *sriov) setVfHostNamespace(vfAddr string, group *sriovnetworkv1.VfGroup) error {
+	if group.HostNamespace == "" {
+		return nil
+	}
+	vfName := s.networkHelper.TryGetInterfaceName(vfAddr)
+	if vfName == "" {
+		// the netdev is not visible from the default namespace once moved
+		log.Log.V(2).Info("setVfHostNamespace(): VF netdev not found, assuming it is already moved",
+			"device", vfAddr, "namespace", group.HostNamespace)
+		return nil
+	}
+	vfLink, err := s.netlinkLib.LinkByName(vfName)
+	if err != nil {
+		return err
+	}
+	log.Log.V(2).Info("setVfHostNamespace(): move VF netdev to host namespace",
+		"device", vfAddr, "name", vfName, "namespace", group.HostNamespace)
+	return s.netlinkLib.LinkSetNsByName(vfLink, group.HostNamespace)
+}
+
+// setVfTxQueueLen configures the transmit queue length requested by the VF group on the VF netdev,
+// the length is left untouched if the group doesn't set it
+func (s *sriov) setVfTxQueueLen(vfAddr string, group *sriovnetworkv1.VfGroup) error {
+	qlen := group.TxQueueLen
+	if qlen < 0 {
+		return fmt.Errorf("invalid transmit queue length %d for VF %s", qlen, vfAddr)
+	}
+	if qlen == 0 {
+		return nil
+	}
+	vfName := s.networkHelper.TryGetInterfaceName(vfAddr)
+	if vfName == "" {
+		return fmt.Errorf("failed to get netdevice for VF %s", vfAddr)
+	}
+	vfLink, err := s.netlinkLib.LinkByName(vfName)
+	if err != nil {
+		return err
+	}
+	if vfLink.Attrs().TxQLen == qlen {
+		return nil
+	}
+	log.Log.V(2).Info("setVfTxQueueLen(): set VF transmit queue length", "device", vfAddr, "name", vfName, "qlen", qlen)
+	return s.netlinkLib.LinkSetTxQLen(vfLink, qlen)
+}
+
+// resetVfTxQueueLen restores the kernel default transmit queue length of the ethernet VF netdev,
+// the default of the other link types, e.g. IPoIB, depends on the driver
+func (s *sriov) resetVfTxQueueLen(vfAddr string) error {
+	vfName := s.networkHelper.TryGetInterfaceName(vfAddr)
+	if vfName == "" {
+		return nil
+	}
+	vfLink, err := s.netlinkLib.LinkByName(vfName)
+	if err != nil {
+		return err
+	}
+	if vfLink.Attrs().EncapType != consts.LinkTypeEthernet || vfLink.Attrs().TxQLen == consts.DefaultTxQueueLen {
+		return nil
+	}
+	log.Log.V(2).Info("resetVfTxQueueLen(): reset VF transmit queue length", "device", vfAddr, "name", vfName)
+	return s.netlinkLib.LinkSetTxQLen(vfLink, consts.DefaultTxQueueLen)
+}
+
+func vfLinkStateToString(state uint32) string {
+	switch state {
+	case netlink.VF_LINK_STATE_ENABLE:
+		return sriovnetworkv1.SriovCniStateEnable
+	case netlink.VF_LINK_STATE_DISABLE:
+		return sriovnetworkv1.SriovCniStateDisable
+	default:
+		return sriovnetworkv1.SriovCniStateAuto
+	}
+}
+
+func boolToState(enabled bool) string {
+	if enabled {
+		return sriovnetworkv1.SriovCniStateOn
+	}
+	return sriovnetworkv1.SriovCniStateOff
+}
+
+// getNumaNode returns the NUMA node of the PCI device, -1 is returned when the NUMA node is unknown
+func getNumaNode(pciAddr string) int {
+	numaNodeFilePath := filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, consts.NumaNodeFile)
+	data, err := os.ReadFile(numaNodeFilePath)
+	if err != nil {
+		log.Log.V(2).Info("getNumaNode(): unable to read NUMA node", "device", pciAddr, "error", err)
+		return -1
+	}
+	numaNode, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		log.Log.Error(err, "getNumaNode(): unable to parse NUMA node", "device", pciAddr)
+		return -1
+	}
+	return numaNode
+}
+
+// getDefaultRouteLinks returns the indexes of the links used by the default routes of the host,
+// including the parent links of VLAN or MACVLAN interfaces carrying a default route
+func (s *sriov) getDefaultRouteLinks() (map[int]bool, error) {
+	routes, err := s.netlinkLib.RouteList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, err
+	}
+	links := map[int]bool{}
+	for _, route := range routes {
+		if route.Dst != nil {
+			if ones, _ := route.Dst.Mask.Size(); ones != 0 || !route.Dst.IP.IsUnspecified() {
+				continue
+			}
+		}
+		indexes := []int{route.LinkIndex}
+		for _, nh := range route.MultiPath {
+			indexes = append(indexes, nh.LinkIndex)
+		}
+		for _, index := range indexes {
+			if index == 0 || links[index] {
+				continue
+			}
+			links[index] = true
+			link, err := s.netlinkLib.LinkByIndex(index)
+			if err != nil {
+				log.Log.Error(err, "getDefaultRouteLinks(): unable to get link", "index", index)
+				continue
+			}
+			if parent := link.Attrs().ParentIndex; parent != 0 {
+				links[parent] = true
+			}
+		}
+	}
+	return links, nil
+}
+
+// isUsedByHostSystem returns true if the link carries a default route of the host,
+// directly or through the bridge or bond it is part of
+func (s *sriov) isUsedByHostSystem(link netlink.Link, defaultRouteLinks map[int]bool) bool {
+	if len(defaultRouteLinks) == 0 {
+		return false
+	}
+	// limit the depth to protect against loops, e.g. bond -> bridge -> ...
+	for depth := 0; link != nil && depth < 8; depth++ {
+		if defaultRouteLinks[link.Attrs().Index] {
+			return true
+		}
+		master := link.Attrs().MasterIndex
+		if master == 0 {
+			return false
+		}
+		var err error
+		link, err = s.netlinkLib.LinkByIndex(master)
+		if err != nil {
+			log.Log.Error(err, "isUsedByHostSystem(): unable to get master link", "index", master)
+			return false
+		}
+	}
+	return false
+}
+
+func (s *sriov) DiscoverSriovDevices(storeManager store.ManagerInterface) ([]sriovnetworkv1.InterfaceExt, error) {
+	pfList, filtered, err := s.discoverSriovDevices(storeManager, vars.VerboseDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filtered {
+		log.Log.Info("DiscoverSriovDevices(): device excluded from discovery", "device", f.Address, "reason", f.Reason)
+	}
+	return pfList, nil
+}
+
+// withNetworkHelper returns a copy of the helper using the provided network helper
+func (s *sriov) withNetworkHelper(networkHelper types.NetworkInterface) *sriov {
+	c := *s
+	c.networkHelper = networkHelper
+	return &c
+}
+
+func (s *sriov) DiscoverSriovDevicesVerbose(storeManager store.ManagerInterface) ([]sriovnetworkv1.InterfaceExt, []types.FilteredDevice, error) {
+	return s.discoverSriovDevices(storeManager, true)
+}
+
+// discoverSriovDevices discovers the SR-IOV capable network interfaces, if verbose is set
+// the PCI devices excluded from the discovery are returned with the reason why
+func (s *sriov) discoverSriovDevices(storeManager store.ManagerInterface, verbose bool) (
+	[]sriovnetworkv1.InterfaceExt, []types.FilteredDevice, error) {
+	log.Log.V(2).Info("DiscoverSriovDevices")
+	// cache the phys_port_name and phys_switch_id reads of the netdevs for this pass only
+	s = s.withNetworkHelper(s.networkHelper.WithPhysPortCache())
+	pfList := []sriovnetworkv1.InterfaceExt{}
+	var filtered []types.FilteredDevice
+	filter := func(address, reason string) {
+		if verbose {
+			filtered = append(filtered, types.FilteredDevice{Address: address, Reason: reason})
+		}
+	}
+
+	pci, err := s.ghwLib.PCI()
+	if err != nil {
+		return nil, nil, fmt.Errorf("DiscoverSriovDevices(): error getting PCI info: %v", err)
+	}
+
+	devices := pci.ListDevices()
+	if len(devices) == 0 {
+		return nil, nil, fmt.Errorf("DiscoverSriovDevices(): could not retrieve PCI devices")
+	}
+
+	defaultRouteLinks, err := s.getDefaultRouteLinks()
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to list default routes, devices used by host system are not excluded")
+	}
+
+	devicesByAddress := make(map[string]*ghw.PCIDevice, len(devices))
+	for _, device := range devices {
+		devicesByAddress[device.Address] = device
+	}
+	getDevice := func(address string) *ghw.PCIDevice { return devicesByAddress[address] }
+
+	for _, device := range devices {
+		iface, reason := s.discoverSriovDevice(storeManager, device, getDevice, defaultRouteLinks)
+		if reason != "" {
+			filter(device.Address, reason)
+			continue
+		}
+		pfList = append(pfList, *iface)
+	}
+
+	return pfList, filtered, nil
+}
+
+// DiscoverSriovDevice discovers the SR-IOV capable network interface with the provided PCI address only
+func (s *sriov) DiscoverSriovDevice(storeManager store.ManagerInterface, pciAddr string) (*sriovnetworkv1.InterfaceExt, error) {
+	log.Log.V(2).Info("DiscoverSriovDevice()", "device", pciAddr)
+	s = s.withNetworkHelper(s.networkHelper.WithPhysPortCache())
+	address, err := sriovnetworkv1.NormalizePciAddress(pciAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	pci, err := s.ghwLib.PCI()
+	if err != nil {
+		return nil, fmt.Errorf("DiscoverSriovDevice(): error getting PCI info: %v", err)
+	}
+	device := pci.GetDevice(address)
+	if device == nil {
+		return nil, &types.DeviceNotFoundError{PciAddress: address, Reason: "PCI device not found"}
+	}
+
+	defaultRouteLinks, err := s.getDefaultRouteLinks()
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevice(): unable to list default routes, devices used by host system are not excluded")
+	}
+
+	iface, reason := s.discoverSriovDevice(storeManager, device, pci.GetDevice, defaultRouteLinks)
+	if reason != "" {
+		return nil, &types.DeviceNotFoundError{PciAddress: address, Reason: reason}
+	}
+	return iface, nil
+}
+
+// allowedByDiscoveryFilters returns false if the PCI device doesn't match the discovery allow list
+// or matches the discovery deny list
+func allowedByDiscoveryFilters(device *ghw.PCIDevice) bool {
+	if len(vars.DiscoveryAllowList) == 0 && len(vars.DiscoveryDenyList) == 0 {
+		return true
+	}
+	var vendor, deviceID string
+	if device.Vendor != nil {
+		vendor = device.Vendor.ID
+	}
+	if device.Product != nil {
+		deviceID = device.Product.ID
+	}
+	if len(vars.DiscoveryAllowList) > 0 &&
+		!sriovnetworkv1.DiscoveryFilterMatch(vars.DiscoveryAllowList, vendor, deviceID, device.Address) {
+		return false
+	}
+	return !sriovnetworkv1.DiscoveryFilterMatch(vars.DiscoveryDenyList, vendor, deviceID, device.Address)
+}
+
+// discoverSriovDevice builds the interface of the SR-IOV capable PCI device, if the device is excluded
+// from the discovery the reason why is returned instead, getDevice returns the PCI device of the VFs
+func (s *sriov) discoverSriovDevice(storeManager store.ManagerInterface, device *ghw.PCIDevice,
+	getDevice func(address string) *ghw.PCIDevice, defaultRouteLinks map[int]bool) (*sriovnetworkv1.InterfaceExt, string) {
+	if !allowedByDiscoveryFilters(device) {
+		log.Log.V(2).Info("DiscoverSriovDevices(): device excluded by the discovery filters", "device", device.Address)
+		return nil, types.FilteredReasonDiscoveryFilter
+	}
+	devClass, err := strconv.ParseInt(device.Class.ID, 16, 64)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to parse device class, skipping",
+			"device", device)
+		return nil, types.FilteredReasonInvalidClass
+	}
+	if devClass != consts.NetClass {
+		// Not network device
+		return nil, types.FilteredReasonNotNetwork
+	}
+
+	if s.dputilsLib.IsSriovVF(device.Address) {
+		return nil, types.FilteredReasonSriovVF
+	}
+
+	if !vars.DevMode {
+		if !sriovnetworkv1.IsSupportedModel(device.Vendor.ID, device.Product.ID) {
+			log.Log.Info("DiscoverSriovDevices(): unsupported device", "device", device)
+			return nil, types.FilteredReasonUnsupportedModel
+		}
+	}
+
+	driver, err := s.dputilsLib.GetDriverName(device.Address)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to parse device driver for device, skipping", "device", device)
+		return nil, types.FilteredReasonNoDriver
+	}
+
+	pfNetName := s.networkHelper.TryGetInterfaceName(device.Address)
+
+	if pfNetName == "" {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to get device name for device, skipping", "device", device.Address)
+		return nil, types.FilteredReasonNoNetdev
+	}
+
+	link, err := s.netlinkLib.LinkByName(pfNetName)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to get Link for device, skipping", "device", device.Address)
+		return nil, types.FilteredReasonNoLink
+	}
+
+	if s.isUsedByHostSystem(link, defaultRouteLinks) {
+		if !sriovnetworkv1.StringInArray(pfNetName, vars.HostSystemInterfacesAllowList) &&
+			!sriovnetworkv1.PciAddressInArray(device.Address, vars.HostSystemInterfacesAllowList) {
+			log.Log.Info("DiscoverSriovDevices(): device carries the default route of the host, skipping",
+				"device", device.Address, "name", pfNetName)
+			return nil, types.FilteredReasonHostManaged
+		}
+		log.Log.Info("DiscoverSriovDevices(): device carries the default route of the host but it is allow-listed",
+			"device", device.Address, "name", pfNetName)
+	}
+
+	// report the address in the canonical form to match the addresses of the spec
+	pciAddress, err := sriovnetworkv1.NormalizePciAddress(device.Address)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unexpected PCI address format, keeping it as is", "device", device.Address)
+		pciAddress = device.Address
+	}
+
+	iface := sriovnetworkv1.InterfaceExt{
+		Name:            pfNetName,
+		PciAddress:      pciAddress,
+		Driver:          driver,
+		Vendor:          device.Vendor.ID,
+		DeviceID:        device.Product.ID,
+		Mtu:             link.Attrs().MTU,
+		Mac:             link.Attrs().HardwareAddr.String(),
+		LinkType:        s.encapTypeToLinkType(link.Attrs().EncapType),
+		LinkSpeed:       s.networkHelper.GetNetDevLinkSpeed(pfNetName),
+		LinkAdminState:  s.networkHelper.GetNetDevLinkAdminState(pfNetName),
+		FirmwareVersion: s.networkHelper.GetNetDevFirmwareVersion(pfNetName),
+		NumaNode:        getNumaNode(device.Address),
+	}
+	caps := interfaceCapabilities(driver, iface.LinkSpeed, link)
+	iface.Capabilities = &caps
+
+	pfStatus, exist, err := storeManager.LoadPfsStatus(iface.PciAddress)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): failed to load PF status from disk")
+	} else {
+		if exist {
+			iface.ExternallyManaged = pfStatus.ExternallyManaged
+		}
+	}
+
+	if iface.LinkType == consts.LinkTypeETH {
+		s.discoverPfDcb(&iface)
+	}
+	s.discoverBlueFieldMode(&iface)
+	if combined, _, err := s.networkHelper.GetNetdevCombinedChannels(pfNetName); err != nil {
+		log.Log.V(2).Info("DiscoverSriovDevices(): unable to read combined channels for device", "device", device.Address, "error", err)
+	} else {
+		iface.CombinedChannels = combined
+	}
+	if ring, err := s.networkHelper.GetNetdevRingSizes(pfNetName); err != nil {
+		log.Log.V(2).Info("DiscoverSriovDevices(): unable to read ring sizes for device", "device", device.Address, "error", err)
+	} else {
+		iface.RingRx, iface.RingTx, iface.RingRxMax, iface.RingTxMax = ring.Rx, ring.Tx, ring.MaxRx, ring.MaxTx
+	}
+
+	if s.dputilsLib.IsSriovPF(device.Address) {
+		iface.TotalVfs = s.dputilsLib.GetSriovVFcapacity(device.Address)
+		iface.NumVfs = s.dputilsLib.GetVFconfigured(device.Address)
+		eswitchAttrs := s.getNicEswitchAttrs(device.Address)
+		iface.EswitchMode = eswitchAttrs.Mode
+		if iface.EswitchMode == sriovnetworkv1.ESwithModeSwitchDev {
+			iface.EswitchInlineMode, iface.EswitchEncapMode = eswitchAttrs.InlineMode, eswitchAttrs.EncapMode
+		}
+		if limit, err := s.networkHelper.GetDevlinkOffloadVfLimit(device.Address); err != nil {
+			log.Log.V(2).Info("DiscoverSriovDevices(): unable to read offload VF limit for device", "device", device.Address, "error", err)
+		} else {
+			iface.OffloadVfLimit = limit
+		}
+		if s.dputilsLib.SriovConfigured(device.Address) {
+			vfs, err := s.dputilsLib.GetVFList(device.Address)
+			if err != nil {
+				log.Log.Error(err, "DiscoverSriovDevices(): unable to parse VFs for device, skipping",
+					"device", device)
+				return nil, types.FilteredReasonNoVFList
+			}
+			for _, vf := range vfs {
+				instance := s.getVfInfo(vf, pfNetName, iface.EswitchMode, iface.NumaNode, link, getDevice)
+				instance.ParentPf = device.Address
+				if iface.LinkType == consts.LinkTypeIB {
+					instance.IbPkey = getVfIbPkey(device.Address, vf)
+				} else {
+					instance.VlanTrunk = s.getVfVlanTrunk(device.Address, instance.VfID, instance.RepresentorName)
+				}
+				iface.VFs = append(iface.VFs, instance)
+			}
+		}
+	}
+	return &iface, ""
+}
+
+func (s *sriov) configSriovPFDevice(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface, rb *configRollback) error {
+	log.Log.V(2).Info("configSriovPFDevice(): configure PF sriov device",
+		"device", iface.PciAddress)
+	totalVfs := s.dputilsLib.GetSriovVFcapacity(iface.PciAddress)
+	if iface.NumVfs > totalVfs {
+		err := &types.NumVfsExceedTotalVfsError{PciAddress: iface.PciAddress, NumVfs: iface.NumVfs, TotalVfs: totalVfs}
+		log.Log.Error(err, "configSriovPFDevice(): fail to set NumVfs for device", "device", iface.PciAddress)
+		// saved to retry the configuration when the TotalVfs increases, e.g. after a firmware change
+		if saveErr := storeManager.SaveRejectedTotalVfs(iface.PciAddress, totalVfs); saveErr != nil {
+			log.Log.Error(saveErr, "configSriovPFDevice(): fail to save the TotalVfs of the device", "device", iface.PciAddress)
+		}
+		return err
+	}
+	if err := s.configureHWOptionsForSwitchdev(iface); err != nil {
+		return err
+	}
+	// remove all UDEV rules for the PF before adding new rules to
+	// make sure that rules are always in a consistent state, e.g. there is no
+	// switchdev-related rules for PF in legacy mode
+	if err := s.removeUdevRules(iface.PciAddress); err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to remove udev rules", "device", iface.PciAddress)
+		return err
+	}
+	err := s.addUdevRules(iface)
+	if err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to add udev rules", "device", iface.PciAddress)
+		return err
+	}
+	err = s.createVFs(iface, rb)
+	if err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to set NumVfs for device", "device", iface.PciAddress)
+		return err
+	}
+	if err := s.addVfRepresentorUdevRule(iface); err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to add VR representor udev rule", "device", iface.PciAddress)
+		return err
+	}
+	// set PF mtu
+	if iface.Mtu > 0 {
+		if prevMtu := s.networkHelper.GetNetdevMTU(iface.PciAddress); iface.Mtu > prevMtu {
+			err = s.networkHelper.SetNetdevMTU(ctx, iface.PciAddress, iface.Mtu)
+			if err != nil {
+				log.Log.Error(err, "configSriovPFDevice(): fail to set mtu for PF", "device", iface.PciAddress)
+				return err
+			}
+			rb.push(func() error { return s.networkHelper.SetNetdevMTU(context.Background(), iface.PciAddress, prevMtu) },
+				"PF mtu", "device", iface.PciAddress, "mtu", prevMtu)
+		}
+	}
+	if err := s.configPfCombinedChannels(iface); err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to set combined channels for PF", "device", iface.PciAddress)
+		return err
+	}
+	if err := s.configPfRingSizes(iface); err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to set ring sizes for PF", "device", iface.PciAddress)
+		return err
+	}
+	if err := s.configPfDcb(iface); err != nil {
+		log.Log.Error(err, "configSriovPFDevice(): fail to configure DCB for PF", "device", iface.PciAddress)
+		return err
+	}
+	return nil
+}
+
+// configPfCombinedChannels sets the number of combined channels of the PF netdev if it differs from the current one
+func (s *sriov) configPfCombinedChannels(iface *sriovnetworkv1.Interface) error {
+	if iface.CombinedChannels <= 0 {
+		return nil
+	}
+	pfName := s.networkHelper.TryGetInterfaceName(iface.PciAddress)
+	current, maximum, err := s.networkHelper.GetNetdevCombinedChannels(pfName)
+	if err != nil {
+		return fmt.Errorf("failed to read combined channels of device %s: %v", iface.PciAddress, err)
+	}
+	if iface.CombinedChannels > maximum {
+		return fmt.Errorf("requested %d combined channels for device %s exceed the device maximum of %d",
+			iface.CombinedChannels, iface.PciAddress, maximum)
+	}
+	if current == iface.CombinedChannels {
+		return nil
+	}
+	return s.networkHelper.SetNetdevCombinedChannels(pfName, iface.CombinedChannels)
+}
+
+// configPfRingSizes sets the RX/TX ring sizes of the PF netdev if they differ from the current ones,
+// the sizes exceeding the maximum of the device are clamped or rejected depending on the RingClamp field
+func (s *sriov) configPfRingSizes(iface *sriovnetworkv1.Interface) error {
+	if iface.RingRx <= 0 && iface.RingTx <= 0 {
+		return nil
+	}
+	pfName := s.networkHelper.TryGetInterfaceName(iface.PciAddress)
+	ring, err := s.networkHelper.GetNetdevRingSizes(pfName)
+	if err != nil {
+		return fmt.Errorf("failed to read ring sizes of device %s: %v", iface.PciAddress, err)
+	}
+	rx, err := ringSize("RX", iface.RingRx, ring.MaxRx, iface)
+	if err != nil {
+		return err
+	}
+	tx, err := ringSize("TX", iface.RingTx, ring.MaxTx, iface)
+	if err != nil {
+		return err
+	}
+	if (rx == 0 || rx == ring.Rx) && (tx == 0 || tx == ring.Tx) {
+		return nil
+	}
+	return s.networkHelper.SetNetdevRingSizes(pfName, rx, tx)
+}
+
+// ringSize returns the ring size to configure on the PF for the requested one
+func ringSize(direction string, requested, maximum int, iface *sriovnetworkv1.Interface) (int, error) {
+	if requested <= maximum {
+		return requested, nil
+	}
+	if !iface.RingClamp {
+		return 0, fmt.Errorf("requested %s ring size %d for device %s exceeds the device maximum of %d",
+			direction, requested, iface.PciAddress, maximum)
+	}
+	log.Log.Info("configPfRingSizes(): requested ring size exceeds the device maximum, clamping",
+		"device", iface.PciAddress, "direction", direction, "requested", requested, "max", maximum)
+	return maximum, nil
+}
+
+// discoverPfDcb reports the PFC priorities and the priority to traffic class map of the PF,
+// nothing is reported if the PF doesn't support DCB
+func (s *sriov) discoverPfDcb(iface *sriovnetworkv1.InterfaceExt) {
+	dcb, err := s.netlinkLib.DcbIeeeGet(iface.Name)
+	if err != nil {
+		log.Log.V(2).Info("DiscoverSriovDevices(): unable to read DCB configuration for device", "device", iface.PciAddress, "error", err)
+		return
+	}
+	for prio := 0; prio < len(dcb.PrioTc); prio++ {
+		if dcb.PfcEnabled&(1<<prio) != 0 {
+			iface.PfcEnabled = append(iface.PfcEnabled, prio)
+		}
+		iface.PriorityToTcMap = append(iface.PriorityToTcMap, int(dcb.PrioTc[prio]))
+	}
+}
+
+// discoverBlueFieldMode reports the mode of the BlueField-2/3 cards, the mode is left empty for the other cards
+func (s *sriov) discoverBlueFieldMode(iface *sriovnetworkv1.InterfaceExt) {
+	if iface.Vendor != mlx.VendorMellanox || (iface.DeviceID != mlx.DeviceBF2 && iface.DeviceID != mlx.DeviceBF3) {
+		return
+	}
+	mode, err := s.mlxHelper.GetMellanoxBlueFieldMode(iface.PciAddress)
+	if err != nil {
+		log.Log.Error(err, "DiscoverSriovDevices(): unable to read BlueField mode for device", "device", iface.PciAddress)
+		return
+	}
+	iface.BlueFieldMode = mode.String()
+}
+
+// configPfDcb applies the PFC priorities and the priority to traffic class map of the PF,
+// the DCB configuration of the PF is left unchanged when the interface doesn't set them
+func (s *sriov) configPfDcb(iface *sriovnetworkv1.Interface) error {
+	if len(iface.PfcEnabled) == 0 && len(iface.PriorityToTcMap) == 0 {
+		return nil
+	}
+	pfName := s.networkHelper.TryGetInterfaceName(iface.PciAddress)
+	current, err := s.netlinkLib.DcbIeeeGet(pfName)
+	if err != nil {
+		if errors.Is(err, syscall.EOPNOTSUPP) {
+			return fmt.Errorf("device %s doesn't support DCB", iface.PciAddress)
+		}
+		return fmt.Errorf("failed to read DCB configuration of device %s: %v", iface.PciAddress, err)
+	}
+	desired := *current
+	if len(iface.PfcEnabled) > 0 {
+		desired.PfcEnabled = sriovnetworkv1.PfcPrioritiesMask(iface.PfcEnabled)
+	}
+	if len(iface.PriorityToTcMap) > 0 {
+		desired.PrioTc = sriovnetworkv1.PriorityToTcArray(iface.PriorityToTcMap)
+		for prio, tc := range desired.PrioTc {
+			if current.EtsCap > 0 && tc >= current.EtsCap {
+				return fmt.Errorf("traffic class %d of priority %d exceeds the %d traffic classes supported by device %s",
+					tc, prio, current.EtsCap, iface.PciAddress)
+			}
+		}
+	}
+	if desired == *current {
+		return nil
+	}
+	log.Log.V(2).Info("configPfDcb(): configure DCB", "device", iface.PciAddress,
+		"pfc", iface.PfcEnabled, "prioTc", iface.PriorityToTcMap)
+	return s.netlinkLib.DcbIeeeSet(pfName, &desired)
+}
+
+func (s *sriov) configureHWOptionsForSwitchdev(iface *sriovnetworkv1.Interface) error {
+	log.Log.V(2).Info("configureHWOptionsForSwitchdev(): configure HW options for device",
+		"device", iface.PciAddress)
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) != sriovnetworkv1.ESwithModeSwitchDev {
+		// we need to configure HW options only for PFs for which switchdev is a target mode
+		return nil
+	}
+	if err := s.networkHelper.EnableHwTcOffload(iface.Name); err != nil {
+		return err
+	}
+	desiredFlowSteeringMode := "smfs"
+	currentFlowSteeringMode, err := s.networkHelper.GetDevlinkDeviceParam(iface.PciAddress, "flow_steering_mode")
+	if err != nil {
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENODEV) {
+			log.Log.V(2).Info("configureHWOptionsForSwitchdev(): device has no flow_steering_mode parameter, skip",
+				"device", iface.PciAddress)
+			return nil
+		}
+		log.Log.Error(err, "configureHWOptionsForSwitchdev(): fail to read current flow steering mode for the device", "device", iface.PciAddress)
+		return err
+	}
+	if currentFlowSteeringMode == desiredFlowSteeringMode {
+		return nil
+	}
+	// flow steering mode can be changed only when NIC is in legacy mode
+	if s.GetNicSriovMode(iface.PciAddress) != sriovnetworkv1.ESwithModeLegacy {
+		s.setEswitchModeAndNumVFs(iface.PciAddress, sriovnetworkv1.ESwithModeLegacy, 0, "", "")
+	}
+	if err := s.networkHelper.SetDevlinkDeviceParam(iface.PciAddress, "flow_steering_mode", desiredFlowSteeringMode); err != nil {
+		if errors.Is(err, syscall.ENOTSUP) {
+			log.Log.V(2).Info("configureHWOptionsForSwitchdev(): device doesn't support changing of flow_steering_mode, skip", "device", iface.PciAddress)
 			return nil
 		}
-		log.Log.Error(err, "configureHWOptionsForSwitchdev(): fail to configure flow steering mode for the device", "device", iface.PciAddress)
+		log.Log.Error(err, "configureHWOptionsForSwitchdev(): fail to configure flow steering mode for the device", "device", iface.PciAddress)
+		return err
+	}
+	return nil
+}
+
+func (s *sriov) checkExternallyManagedPF(iface *sriovnetworkv1.Interface) error {
+	log.Log.V(2).Info("checkExternallyManagedPF(): configure PF sriov device",
+		"device", iface.PciAddress)
+	currentNumVfs := s.dputilsLib.GetVFconfigured(iface.PciAddress)
+	if iface.NumVfs > currentNumVfs && iface.ExternallyManagedBestEffort {
+		// only the VFs created externally are configured, the VF groups ranges above them are skipped
+		log.Log.Info("checkExternallyManagedPF(): fewer virtual functions than requested, configuring the available ones",
+			"device", iface.PciAddress, "requested", iface.NumVfs, "available", currentNumVfs,
+			"skipped", iface.NumVfs-currentNumVfs)
+	} else if iface.NumVfs > currentNumVfs {
+		errMsg := fmt.Sprintf("checkExternallyManagedPF(): number of request virtual functions %d is not equal to configured virtual "+
+			"functions %d but the policy is configured as ExternallyManaged for device %s",
+			iface.NumVfs, currentNumVfs, iface.PciAddress)
+		log.Log.Error(nil, errMsg)
+		return fmt.Errorf(errMsg)
+	}
+	currentEswitchMode, err := s.getNicEswitchMode(iface.PciAddress)
+	if err != nil {
+		log.Log.Error(err, "checkExternallyManagedPF(): unable to get the eswitch mode", "device", iface.PciAddress)
+		return err
+	}
+	expectedEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
+	if currentEswitchMode != expectedEswitchMode {
+		errMsg := fmt.Sprintf("checkExternallyManagedPF(): requested ESwitchMode mode \"%s\" is not equal to configured \"%s\" "+
+			"but the policy is configured as ExternallyManaged for device %s", expectedEswitchMode, currentEswitchMode, iface.PciAddress)
+		log.Log.Error(nil, errMsg)
+		return fmt.Errorf(errMsg)
+	}
+	currentMtu := s.networkHelper.GetNetdevMTU(iface.PciAddress)
+	if iface.Mtu > 0 && iface.Mtu > currentMtu {
+		err := fmt.Errorf("checkExternallyManagedPF(): requested MTU(%d) is greater than configured MTU(%d) for device %s. cannot change MTU as policy is configured as ExternallyManaged",
+			iface.Mtu, currentMtu, iface.PciAddress)
+		log.Log.Error(nil, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *sriov) configSriovVFDevices(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface, rb *configRollback) error {
+	log.Log.V(2).Info("configSriovVFDevices(): configure PF sriov device",
+		"device", iface.PciAddress)
+	if iface.NumVfs > 0 {
+		vfAddrs, err := s.dputilsLib.GetVFList(iface.PciAddress)
+		if err != nil {
+			log.Log.Error(err, "configSriovVFDevices(): unable to parse VFs for device", "device", iface.PciAddress)
+		}
+		pfLink, err := s.netlinkLib.LinkByName(iface.Name)
+		if err != nil {
+			log.Log.Error(err, "configSriovVFDevices(): unable to get PF link for device", "device", iface)
+			return err
+		}
+		if err := s.checkVfRepresentors(iface, vfAddrs); err != nil {
+			log.Log.Error(err, "configSriovVFDevices(): VF representors are not ready", "device", iface.PciAddress)
+			return err
+		}
+
+		var binder *vfBinder
+		if vars.VfBindStaggerDelay > 0 {
+			// the VFs are configured one at a time in index order to stagger the binds of the VFs
+			// rebound after they were unbound too, e.g. the RDMA VFs
+			vfAddrs, err = s.sortVfsByID(vfAddrs)
+			if err != nil {
+				return err
+			}
+			binder = &vfBinder{delay: vars.VfBindStaggerDelay}
+			for _, addr := range vfAddrs {
+				if err := binder.bind(s.kernelHelper, addr); err != nil {
+					log.Log.Error(err, "configSriovVFDevices(): fail to bind default driver for VFs", "device", iface.PciAddress)
+					return err
+				}
+			}
+		}
+		return s.configSriovVFDevicesInParallel(ctx, storeManager, iface, pfLink, vfAddrs, binder, rb)
+	}
+	return nil
+}
+
+// checkVfRepresentors verifies that the representors of all the VFs of a switchdev PF are present.
+// Representors can be transiently removed, e.g. while OVS restarts, and configuring the VFs against
+// an incomplete set of representors would mis-detect the VF layout.
+// Only the VFs which exist on the PF are checked, an externally managed PF in best effort mode
+// may have less VFs than requested.
+func (s *sriov) checkVfRepresentors(iface *sriovnetworkv1.Interface, vfAddrs []string) error {
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) != sriovnetworkv1.ESwithModeSwitchDev {
+		return nil
+	}
+	var missing []int
+	for _, addr := range vfAddrs {
+		vfID, err := s.dputilsLib.GetVFID(addr)
+		if err != nil {
+			log.Log.Error(err, "checkVfRepresentors(): unable to get VF id", "device", addr)
+			return err
+		}
+		if _, err := s.sriovnetLib.GetVfRepresentor(iface.Name, vfID); err != nil {
+			log.Log.V(2).Info("checkVfRepresentors(): VF representor not found",
+				"device", iface.PciAddress, "vf", vfID, "error", err)
+			missing = append(missing, vfID)
+		}
+	}
+	if len(missing) > 0 {
+		return &types.VfRepresentorsNotReadyError{PciAddress: iface.PciAddress, MissingVfs: missing}
+	}
+	return nil
+}
+
+// sortVfsByID returns the VF addresses sorted by VF index
+func (s *sriov) sortVfsByID(vfAddrs []string) ([]string, error) {
+	vfIDs := make(map[string]int, len(vfAddrs))
+	for _, addr := range vfAddrs {
+		vfID, err := s.dputilsLib.GetVFID(addr)
+		if err != nil {
+			log.Log.Error(err, "sortVfsByID(): unable to get VF id", "device", addr)
+			return nil, err
+		}
+		vfIDs[addr] = vfID
+	}
+	sorted := make([]string, len(vfAddrs))
+	copy(sorted, vfAddrs)
+	sort.SliceStable(sorted, func(i, j int) bool { return vfIDs[sorted[i]] < vfIDs[sorted[j]] })
+	return sorted, nil
+}
+
+// vfBinder binds the VFs without a driver to their default driver one at a time, waiting for
+// the stagger delay between two binds. This spreads the IRQ allocation done by the kernel driver
+// instead of probing all the VFs at the same time. A nil vfBinder binds the VFs right away.
+type vfBinder struct {
+	delay time.Duration
+	mu    sync.Mutex
+	bound bool
+}
+
+// bind binds the VF to its default driver if it has no driver
+func (b *vfBinder) bind(kernelHelper types.KernelInterface, addr string) error {
+	if b == nil {
+		return kernelHelper.BindDefaultDriver(addr)
+	}
+	if hasDriver, _ := kernelHelper.HasDriver(addr); hasDriver {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bound {
+		time.Sleep(b.delay)
+	}
+	log.Log.V(2).Info("vfBinder.bind(): bind VF to default driver", "device", addr)
+	if err := kernelHelper.BindDefaultDriver(addr); err != nil {
+		log.Log.Error(err, "vfBinder.bind(): fail to bind default driver for device", "device", addr)
+		return err
+	}
+	b.bound = true
+	return nil
+}
+
+// configSriovVFDevicesInParallel configures the VFs using a bounded pool of workers.
+// Every VF is handled by a single worker, so the driver bind/unbind operations on the same
+// device remain serialized. After the first failure no new VF is configured, the VFs already
+// in progress are completed and the first error is returned.
+func (s *sriov) configSriovVFDevicesInParallel(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface,
+	pfLink netlink.Link, vfAddrs []string, binder *vfBinder, rb *configRollback) error {
+	workers := vars.VfConfigConcurrency
+	if workers > len(vfAddrs) {
+		workers = len(vfAddrs)
+	}
+	// the staggered binds are done in the order of the VFs
+	if workers < 1 || binder != nil {
+		workers = 1
+	}
+	log.Log.V(2).Info("configSriovVFDevicesInParallel(): configure VFs", "device", iface.PciAddress, "workers", workers)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	addrChannel := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range addrChannel {
+				if err := s.configSriovVFDevice(ctx, storeManager, iface, pfLink, addr, binder, rb); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, addr := range vfAddrs {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = configCanceled(ctx, addr)
+		}
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		addrChannel <- addr
+	}
+	close(addrChannel)
+	wg.Wait()
+	return firstErr
+}
+
+// getVfGroup returns the VF group of the interface the VF with the provided index belongs to, nil if none
+func getVfGroup(iface *sriovnetworkv1.Interface, vfID int) *sriovnetworkv1.VfGroup {
+	for i := range iface.VfGroups {
+		if sriovnetworkv1.IndexInRange(vfID, iface.VfGroups[i].VfRange) {
+			return &iface.VfGroups[i]
+		}
+	}
+	return nil
+}
+
+// configSriovVFDevice configures a single VF of the PF according to the VF group it belongs to
+func (s *sriov) configSriovVFDevice(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface,
+	pfLink netlink.Link, addr string, binder *vfBinder, rb *configRollback) error {
+	hasDriver, prevDriver := s.kernelHelper.HasDriver(addr)
+	if !hasDriver {
+		if err := binder.bind(s.kernelHelper, addr); err != nil {
+			log.Log.Error(err, "configSriovVFDevice(): fail to bind default driver for device", "device", addr)
+			return err
+		}
+	}
+	var group *sriovnetworkv1.VfGroup
+
+	vfID, err := s.dputilsLib.GetVFID(addr)
+	if err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): unable to get VF id", "device", iface.PciAddress)
+		return err
+	}
+
+	group = getVfGroup(iface, vfID)
+
+	// VF group not found.
+	if group == nil {
+		return nil
+	}
+
+	if err := s.setVfVlan(pfLink, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF vlan", "device", addr)
+		return err
+	}
+	if err := s.setVfVlanTrunk(iface, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF VLAN trunk", "device", addr)
+		return err
+	}
+	if err := s.setVfSpoofChkAndTrust(pfLink, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF spoof check and trust mode", "device", addr)
+		return err
+	}
+	if err := s.setVfTxRate(iface, pfLink, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF tx rate", "device", addr)
+		return err
+	}
+	if err := s.setVfQosGroup(iface, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF QoS group", "device", addr)
+		return err
+	}
+	if err := s.setVfLinkState(pfLink, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF link state", "device", addr)
+		return err
+	}
+	// the MAC requested by the group is programmed through the PF whatever the driver of the VF,
+	// before the VF is bound to the DPDK driver
+	if err := s.setVfMac(pfLink, vfID, group); err != nil {
+		log.Log.Error(err, "configSriovVFDevice(): fail to configure VF admin mac", "device", addr)
+		return err
+	}
+
+	// only set GUID and MAC for VF with default driver
+	// for userspace drivers like vfio we configure the vf mac using the kernel nic mac address
+	// before we switch to the userspace driver, unless the group requests a MAC
+	if yes, d := s.kernelHelper.HasDriver(addr); yes && !sriovnetworkv1.StringInArray(d, vars.DpdkDrivers) {
+		// LinkType is an optional field. Let's fallback to current link type
+		// if nothing is specified in the SriovNodePolicy
+		linkType := iface.LinkType
+		if linkType == "" {
+			linkType = s.GetLinkType(iface.Name)
+		}
+		if strings.EqualFold(linkType, consts.LinkTypeIB) {
+			guid, err := s.getVfGUID(storeManager, iface.PciAddress, vfID, group)
+			if err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to get VF GUID", "device", addr)
+				return err
+			}
+			if err = s.SetVfGUID(addr, pfLink, guid); err != nil {
+				return err
+			}
+		} else if group.Mac == "" {
+			vfLink, err := s.VFIsReady(ctx, addr, vars.VfReadyPollInterval, vars.VfReadyPollTimeout)
+			if err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): VF link is not ready", "address", addr)
+				metrics.ObserveVfDriverRebind(iface.PciAddress)
+				err = s.kernelHelper.RebindVfToDefaultDriver(addr)
+				if err != nil {
+					log.Log.Error(err, "configSriovVFDevice(): failed to rebind VF", "address", addr)
+					return err
+				}
+
+				// Try to check the VF status again
+				vfLink, err = s.VFIsReady(ctx, addr, vars.VfReadyPollInterval, vars.VfReadyPollTimeout)
+				if err != nil {
+					log.Log.Error(err, "configSriovVFDevice(): VF link is not ready", "address", addr)
+					return err
+				}
+			}
+			if err = s.setVfAllocatedMac(storeManager, iface.PciAddress, pfLink, vfID, vfLink, group); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to configure VF admin mac", "device", addr)
+				return err
+			}
+		}
+		// the number of queues exposed by the VF to the userspace driver
+		// can be configured only while the VF is bound to the kernel driver
+		if group.CombinedChannels > 0 && sriovnetworkv1.StringInArray(group.DeviceType, vars.DpdkDrivers) {
+			if err := s.setVfCombinedChannels(addr, group.CombinedChannels); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to configure VF combined channels", "device", addr)
+				return err
+			}
+		}
+	}
+
+	s.pushVfDriverRollback(rb, addr, prevDriver)
+	if err = s.kernelHelper.UnbindDriverIfNeeded(addr, group.IsRdma); err != nil {
+		return err
+	}
+	// we set eswitch mode before this point and if the desired mode (and current at this point)
+	// is legacy, then VDPA device is already automatically disappeared,
+	// so we don't need to check it
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev && group.VdpaType == "" {
+		if err := s.vdpaHelper.DeleteVDPADevice(addr); err != nil {
+			log.Log.Error(err, "configSriovVFDevice(): fail to delete VDPA device",
+				"device", addr)
+			return err
+		}
+	}
+	if !sriovnetworkv1.StringInArray(group.DeviceType, vars.DpdkDrivers) {
+		if err := binder.bind(s.kernelHelper, addr); err != nil {
+			log.Log.Error(err, "configSriovVFDevice(): fail to bind default driver for device", "device", addr)
+			return err
+		}
+		// the partition key is mapped once the VF is bound again after its GUID is set
+		if err := setVfIbPkey(iface.PciAddress, addr, group); err != nil {
+			log.Log.Error(err, "configSriovVFDevice(): fail to set partition key for VF", "device", addr)
+			return err
+		}
+		// only set MTU for VF with default driver
+		if group.Mtu > 0 {
+			if vfName := s.networkHelper.TryGetInterfaceName(addr); vfName != "" {
+				if err := s.checkMaxMtu(vfName, addr, group.Mtu); err != nil {
+					log.Log.Error(err, "configSriovVFDevice(): invalid mtu for VF", "address", addr)
+					return err
+				}
+			}
+			prevMtu := s.networkHelper.GetNetdevMTU(addr)
+			if err := s.networkHelper.SetNetdevMTU(ctx, addr, group.Mtu); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to set mtu for VF", "address", addr)
+				return err
+			}
+			if prevMtu > 0 && prevMtu != group.Mtu {
+				rb.push(func() error { return s.networkHelper.SetNetdevMTU(context.Background(), addr, prevMtu) },
+					"VF mtu", "address", addr, "mtu", prevMtu)
+			}
+		}
+		if group.VdpaType == "" {
+			if err := s.setVfTxQueueLen(addr, group); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to set transmit queue length for VF", "address", addr)
+				return err
+			}
+			if err := s.setVfHostNamespace(addr, group); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to move VF to host namespace", "address", addr)
+				return err
+			}
+		}
+		if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev && group.VdpaType != "" {
+			if err := s.vdpaHelper.CreateVDPADevice(addr, group.VdpaType); err != nil {
+				log.Log.Error(err, "configSriovVFDevice(): fail to create VDPA device",
+					"vdpaType", group.VdpaType, "device", addr)
+				return err
+			}
+		}
+	} else {
+		if err := s.kernelHelper.BindDpdkDriver(addr, group.DeviceType); err != nil {
+			log.Log.Error(err, "configSriovVFDevice(): fail to bind driver for device",
+				"driver", group.DeviceType, "device", addr)
+			return err
+		}
+	}
+	return nil
+}
+
+// configSriovDevice configures the PF and its VFs holding the lock of the PF, on failure the NumVfs, VF drivers
+// and MTUs changed by the call are rolled back to their previous values before returning the error
+func (s *sriov) configSriovDevice(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface,
+	skipVFConfiguration bool) error {
+	unlock, err := lockDevice(ctx, iface.PciAddress)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	rb := &configRollback{}
+	err = s.configSriovDeviceWithRollback(ctx, storeManager, iface, skipVFConfiguration, rb)
+	if err != nil {
+		if failed := rb.unwind(); failed > 0 {
+			log.Log.Info("configSriovDevice(): partial configuration not fully rolled back",
+				"device", iface.PciAddress, "failedActions", failed)
+		}
+	}
+	return err
+}
+
+// VerifyInterfaceConfig reads back the number of VFs of the PF and the drivers, MTUs and administrative MACs
+// of its VFs and returns a ConfigDriftError if they don't match the desired configuration
+func (s *sriov) VerifyInterfaceConfig(storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface) error {
+	log.Log.V(2).Info("VerifyInterfaceConfig(): verify sriov device configuration", "device", iface.PciAddress)
+	drifts := []types.PlannedChange{}
+	pfDrift := func(kind, current, desired string) {
+		drifts = append(drifts, types.PlannedChange{PciAddress: iface.PciAddress, Kind: kind, Current: current, Desired: desired})
+	}
+	vfDrift := func(vfID int, kind, current, desired string) {
+		drifts = append(drifts, types.PlannedChange{PciAddress: iface.PciAddress, VfID: &vfID, Kind: kind, Current: current, Desired: desired})
+	}
+
+	numVfs := s.dputilsLib.GetVFconfigured(iface.PciAddress)
+	// externally managed PFs may have more VFs than the ones requested by the policies,
+	// or fewer in best effort mode
+	if (numVfs < iface.NumVfs && !iface.ExternallyManagedBestEffort) || (numVfs > iface.NumVfs && !iface.ExternallyManaged) {
+		pfDrift(types.PlannedChangeNumVfs, strconv.Itoa(numVfs), strconv.Itoa(iface.NumVfs))
+	}
+	// the MTU of the PF is only raised
+	if !iface.ExternallyManaged && iface.Mtu > 0 {
+		if mtu := s.networkHelper.GetNetdevMTU(iface.PciAddress); mtu > 0 && mtu < iface.Mtu {
+			pfDrift(types.PlannedChangeMtu, strconv.Itoa(mtu), strconv.Itoa(iface.Mtu))
+		}
+	}
+
+	vfAddrs, err := s.dputilsLib.GetVFList(iface.PciAddress)
+	if err != nil {
+		return fmt.Errorf("failed to list the VFs of device %s: %w", iface.PciAddress, err)
+	}
+	var pfLink netlink.Link
+	currentVfMac := func(vfID int) (string, error) {
+		if pfLink == nil {
+			link, err := s.netlinkLib.LinkByName(iface.Name)
+			if err != nil {
+				return "", fmt.Errorf("failed to get the link of device %s: %w", iface.PciAddress, err)
+			}
+			pfLink = link
+		}
+		if vfInfo := getVfNetlinkInfo(pfLink, vfID); vfInfo != nil && vfInfo.Mac != nil {
+			return vfInfo.Mac.String(), nil
+		}
+		return "", nil
+	}
+	for _, addr := range vfAddrs {
+		vfID, err := s.dputilsLib.GetVFID(addr)
+		if err != nil {
+			return fmt.Errorf("failed to get the VF id of device %s: %w", addr, err)
+		}
+		group := getVfGroup(iface, vfID)
+		if group == nil {
+			continue
+		}
+		// the MAC requested by the group is checked whatever the driver of the VF
+		if group.Mac != "" {
+			current, err := currentVfMac(vfID)
+			if err != nil {
+				return err
+			}
+			if desired, err := sriovnetworkv1.ParseVfMac(group.Mac); err == nil && !strings.EqualFold(current, desired.String()) {
+				vfDrift(vfID, types.PlannedChangeMac, current, desired.String())
+			}
+		}
+		_, driver := s.kernelHelper.HasDriver(addr)
+		if sriovnetworkv1.StringInArray(group.DeviceType, vars.DpdkDrivers) {
+			if driver != group.DeviceType {
+				vfDrift(vfID, types.PlannedChangeDriver, driver, group.DeviceType)
+			}
+			continue
+		}
+		if driver == "" || sriovnetworkv1.StringInArray(driver, vars.DpdkDrivers) {
+			vfDrift(vfID, types.PlannedChangeDriver, driver, consts.DeviceTypeNetDevice)
+			continue
+		}
+		if group.Mtu > 0 {
+			if mtu := s.networkHelper.GetNetdevMTU(addr); mtu > 0 && mtu != group.Mtu {
+				vfDrift(vfID, types.PlannedChangeMtu, strconv.Itoa(mtu), strconv.Itoa(group.Mtu))
+			}
+		}
+		// only the MACs allocated from a pool or a base address are part of the desired configuration
+		if group.MacPool == "" && group.MacBase == "" {
+			continue
+		}
+		desired, exist, err := storeManager.LoadVfMac(iface.PciAddress, vfID)
+		if err != nil {
+			return fmt.Errorf("failed to load MAC of VF %d of device %s: %w", vfID, iface.PciAddress, err)
+		}
+		if !exist {
+			continue
+		}
+		current, err := currentVfMac(vfID)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(current, desired) {
+			vfDrift(vfID, types.PlannedChangeMac, current, desired)
+		}
+	}
+
+	if len(drifts) > 0 {
+		err := &types.ConfigDriftError{PciAddress: iface.PciAddress, Drifts: drifts}
+		log.Log.Error(err, "VerifyInterfaceConfig(): live configuration doesn't match the desired one", "device", iface.PciAddress)
 		return err
 	}
 	return nil
 }
 
-func (s *sriov) checkExternallyManagedPF(iface *sriovnetworkv1.Interface) error {
-	log.Log.V(2).Info("checkExternallyManagedPF(): configure PF sriov device",
-		"device", iface.PciAddress)
-	currentNumVfs := s.dputilsLib.GetVFconfigured(iface.PciAddress)
-	if iface.NumVfs > currentNumVfs {
-		errMsg := fmt.Sprintf("checkExternallyManagedPF(): number of request virtual functions %d is not equal to configured virtual "+
-			"functions %d but the policy is configured as ExternallyManaged for device %s",
-			iface.NumVfs, currentNumVfs, iface.PciAddress)
-		log.Log.Error(nil, errMsg)
-		return fmt.Errorf(errMsg)
+// CheckSriovPrerequisites checks the prerequisites of the VF creation on the PF, the results of all the checks
+// are returned so the failed ones can be reported with their remediation hint
+func (s *sriov) CheckSriovPrerequisites(pciAddr string) []types.PrereqResult {
+	log.Log.V(2).Info("CheckSriovPrerequisites(): check sriov prerequisites", "device", pciAddr)
+	results := []types.PrereqResult{}
+
+	totalVfs := s.dputilsLib.GetSriovVFcapacity(pciAddr)
+	if totalVfs > 0 {
+		results = append(results, types.PrereqResult{Name: types.PrereqSriovTotalVfs, Passed: true,
+			Message: fmt.Sprintf("device %s supports %d VFs", pciAddr, totalVfs)})
+	} else {
+		results = append(results, types.PrereqResult{Name: types.PrereqSriovTotalVfs,
+			Message:     fmt.Sprintf("device %s reports sriov_totalvfs 0", pciAddr),
+			Remediation: "enable SR-IOV for the device in the BIOS and in the firmware of the NIC"})
 	}
-	currentEswitchMode := s.GetNicSriovMode(iface.PciAddress)
-	expectedEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
-	if currentEswitchMode != expectedEswitchMode {
-		errMsg := fmt.Sprintf("checkExternallyManagedPF(): requested ESwitchMode mode \"%s\" is not equal to configured \"%s\" "+
-			"but the policy is configured as ExternallyManaged for device %s", expectedEswitchMode, currentEswitchMode, iface.PciAddress)
-		log.Log.Error(nil, errMsg)
-		return fmt.Errorf(errMsg)
+
+	if s.kernelHelper.IsIommuEnabled() {
+		results = append(results, types.PrereqResult{Name: types.PrereqIommuEnabled, Passed: true,
+			Message: "the IOMMU groups of the host are populated"})
+	} else {
+		results = append(results, types.PrereqResult{Name: types.PrereqIommuEnabled,
+			Message:     "the host has no IOMMU group",
+			Remediation: "enable VT-d (Intel) or AMD-Vi (AMD) in the BIOS"})
 	}
-	currentMtu := s.networkHelper.GetNetdevMTU(iface.PciAddress)
-	if iface.Mtu > 0 && iface.Mtu > currentMtu {
-		err := fmt.Errorf("checkExternallyManagedPF(): requested MTU(%d) is greater than configured MTU(%d) for device %s. cannot change MTU as policy is configured as ExternallyManaged",
-			iface.Mtu, currentMtu, iface.PciAddress)
-		log.Log.Error(nil, err.Error())
-		return err
+
+	cmdLine, err := s.kernelHelper.GetCurrentKernelArgs()
+	if err != nil {
+		results = append(results, types.PrereqResult{Name: types.PrereqIommuKernelArg,
+			Message:     fmt.Sprintf("failed to read the kernel command line: %v", err),
+			Remediation: "check the kernel command line of the host is readable by the config daemon"})
+		return results
 	}
-	return nil
+	iommuArg, err := s.kernelHelper.IommuKernelArgForHost()
+	if err != nil {
+		log.Log.Error(err, "CheckSriovPrerequisites(): failed to get the IOMMU kernel argument for the host")
+		iommuArg = consts.KernelArgIntelIommu
+		if s.kernelHelper.IsKernelArgsSet(cmdLine, consts.KernelArgAmdIommu) {
+			iommuArg = consts.KernelArgAmdIommu
+		}
+	}
+	if s.kernelHelper.IsKernelArgsSet(cmdLine, iommuArg) {
+		results = append(results, types.PrereqResult{Name: types.PrereqIommuKernelArg, Passed: true,
+			Message: fmt.Sprintf("%s is set on the kernel command line", iommuArg)})
+	} else {
+		results = append(results, types.PrereqResult{Name: types.PrereqIommuKernelArg,
+			Message:     fmt.Sprintf("%s is not set on the kernel command line", iommuArg),
+			Remediation: fmt.Sprintf("add %s to the kernel command line and reboot the host", iommuArg)})
+	}
+
+	if s.kernelHelper.IsKernelArgsSet(cmdLine, consts.KernelArgPciRealloc) {
+		results = append(results, types.PrereqResult{Name: types.PrereqPciRealloc, Passed: true,
+			Message: fmt.Sprintf("%s is set on the kernel command line", consts.KernelArgPciRealloc)})
+		return results
+	}
+	assigned, err := vfBarsAssigned(pciAddr)
+	switch {
+	case err != nil:
+		results = append(results, types.PrereqResult{Name: types.PrereqPciRealloc,
+			Message:     fmt.Sprintf("failed to read the resources of device %s: %v", pciAddr, err),
+			Remediation: fmt.Sprintf("add %s to the kernel command line if the VF creation fails with ENOMEM", consts.KernelArgPciRealloc)})
+	case !assigned && totalVfs > 0:
+		results = append(results, types.PrereqResult{Name: types.PrereqPciRealloc,
+			Message:     fmt.Sprintf("the firmware didn't assign the VF BARs of device %s", pciAddr),
+			Remediation: fmt.Sprintf("add %s to the kernel command line and reboot the host", consts.KernelArgPciRealloc)})
+	default:
+		results = append(results, types.PrereqResult{Name: types.PrereqPciRealloc, Passed: true,
+			Message: fmt.Sprintf("%s is not needed for device %s", consts.KernelArgPciRealloc, pciAddr)})
+	}
+	return results
 }
 
-func (s *sriov) configSriovVFDevices(iface *sriovnetworkv1.Interface) error {
-	log.Log.V(2).Info("configSriovVFDevices(): configure PF sriov device",
-		"device", iface.PciAddress)
-	if iface.NumVfs > 0 {
-		vfAddrs, err := s.dputilsLib.GetVFList(iface.PciAddress)
+// vfBarsAssigned returns true if one of the SR-IOV BARs of the PF listed in its sysfs resource file,
+// lines 7 to 12 after the 6 standard BARs and the ROM, has a memory range assigned
+func vfBarsAssigned(pciAddr string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, "resource"))
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i := pciIovResourcesStart; i < len(lines) && i < pciIovResourcesStart+pciNumIovResources; i++ {
+		fields := strings.Fields(lines[i])
+		if len(fields) < 2 {
+			continue
+		}
+		start, err := strconv.ParseUint(fields[0], 0, 64)
 		if err != nil {
-			log.Log.Error(err, "configSriovVFDevices(): unable to parse VFs for device", "device", iface.PciAddress)
+			return false, fmt.Errorf("failed to parse resource %q: %w", lines[i], err)
 		}
-		pfLink, err := s.netlinkLib.LinkByName(iface.Name)
+		end, err := strconv.ParseUint(fields[1], 0, 64)
 		if err != nil {
-			log.Log.Error(err, "configSriovVFDevices(): unable to get PF link for device", "device", iface)
-			return err
+			return false, fmt.Errorf("failed to parse resource %q: %w", lines[i], err)
 		}
+		if end > start {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-		for _, addr := range vfAddrs {
-			hasDriver, _ := s.kernelHelper.HasDriver(addr)
-			if !hasDriver {
-				if err := s.kernelHelper.BindDefaultDriver(addr); err != nil {
-					log.Log.Error(err, "configSriovVFDevices(): fail to bind default driver for device", "device", addr)
-					return err
-				}
-			}
-			var group *sriovnetworkv1.VfGroup
-
-			vfID, err := s.dputilsLib.GetVFID(addr)
-			if err != nil {
-				log.Log.Error(err, "configSriovVFDevices(): unable to get VF id", "device", iface.PciAddress)
-				return err
-			}
-
-			for i := range iface.VfGroups {
-				if sriovnetworkv1.IndexInRange(vfID, iface.VfGroups[i].VfRange) {
-					group = &iface.VfGroups[i]
-					break
-				}
-			}
-
-			// VF group not found.
-			if group == nil {
-				continue
-			}
-
-			// only set GUID and MAC for VF with default driver
-			// for userspace drivers like vfio we configure the vf mac using the kernel nic mac address
-			// before we switch to the userspace driver
-			if yes, d := s.kernelHelper.HasDriver(addr); yes && !sriovnetworkv1.StringInArray(d, vars.DpdkDrivers) {
-				// LinkType is an optional field. Let's fallback to current link type
-				// if nothing is specified in the SriovNodePolicy
-				linkType := iface.LinkType
-				if linkType == "" {
-					linkType = s.GetLinkType(iface.Name)
-				}
-				if strings.EqualFold(linkType, consts.LinkTypeIB) {
-					if err = s.SetVfGUID(addr, pfLink); err != nil {
-						return err
-					}
-				} else {
-					vfLink, err := s.VFIsReady(addr)
-					if err != nil {
-						log.Log.Error(err, "configSriovVFDevices(): VF link is not ready", "address", addr)
-						err = s.kernelHelper.RebindVfToDefaultDriver(addr)
-						if err != nil {
-							log.Log.Error(err, "configSriovVFDevices(): failed to rebind VF", "address", addr)
-							return err
-						}
-
-						// Try to check the VF status again
-						vfLink, err = s.VFIsReady(addr)
-						if err != nil {
-							log.Log.Error(err, "configSriovVFDevices(): VF link is not ready", "address", addr)
-							return err
-						}
-					}
-					if err = s.SetVfAdminMac(addr, pfLink, vfLink); err != nil {
-						log.Log.Error(err, "configSriovVFDevices(): fail to configure VF admin mac", "device", addr)
-						return err
-					}
-				}
-			}
+// configObservedSriovDevice configures the device recording the duration and the result of the configuration
+func (s *sriov) configObservedSriovDevice(ctx context.Context, storeManager store.ManagerInterface, iface *interfaceToConfigure,
+	skipVFConfiguration bool) error {
+	start := time.Now()
+	err := s.configSriovDevice(ctx, storeManager, &iface.iface, skipVFConfiguration)
+	metrics.ObservePfConfig(iface.iface.PciAddress, iface.ifaceStatus.Vendor, time.Since(start), err)
+	if err != nil {
+		return err
+	}
+	return releaseVfMacs(storeManager, &iface.iface, &iface.ifaceStatus)
+}
 
-			if err = s.kernelHelper.UnbindDriverIfNeeded(addr, group.IsRdma); err != nil {
-				return err
-			}
-			// we set eswitch mode before this point and if the desired mode (and current at this point)
-			// is legacy, then VDPA device is already automatically disappeared,
-			// so we don't need to check it
-			if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev && group.VdpaType == "" {
-				if err := s.vdpaHelper.DeleteVDPADevice(addr); err != nil {
-					log.Log.Error(err, "configSriovVFDevices(): fail to delete VDPA device",
-						"device", addr)
-					return err
-				}
-			}
-			if !sriovnetworkv1.StringInArray(group.DeviceType, vars.DpdkDrivers) {
-				if err := s.kernelHelper.BindDefaultDriver(addr); err != nil {
-					log.Log.Error(err, "configSriovVFDevices(): fail to bind default driver for device", "device", addr)
-					return err
-				}
-				// only set MTU for VF with default driver
-				if group.Mtu > 0 {
-					if err := s.networkHelper.SetNetdevMTU(addr, group.Mtu); err != nil {
-						log.Log.Error(err, "configSriovVFDevices(): fail to set mtu for VF", "address", addr)
-						return err
-					}
-				}
-				if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev && group.VdpaType != "" {
-					if err := s.vdpaHelper.CreateVDPADevice(addr, group.VdpaType); err != nil {
-						log.Log.Error(err, "configSriovVFDevices(): fail to create VDPA device",
-							"vdpaType", group.VdpaType, "device", addr)
-						return err
-					}
-				}
-			} else {
-				if err := s.kernelHelper.BindDpdkDriver(addr, group.DeviceType); err != nil {
-					log.Log.Error(err, "configSriovVFDevices(): fail to bind driver for device",
-						"driver", group.DeviceType, "device", addr)
-					return err
-				}
-			}
+// warnVfMtuExceedsPfMtu warns about the VF groups requesting an MTU larger than the one of the PF
+func (s *sriov) warnVfMtuExceedsPfMtu(iface *sriovnetworkv1.Interface) {
+	// the PF MTU is only raised, the current one is read when a group requests more than the PF MTU in the spec
+	currentMtu := -1
+	for _, group := range iface.VfGroups {
+		if group.Mtu <= iface.Mtu {
+			continue
+		}
+		if currentMtu < 0 {
+			currentMtu = s.networkHelper.GetNetdevMTU(iface.PciAddress)
+		}
+		if currentMtu > 0 && group.Mtu > currentMtu {
+			log.Log.Info("WARNING: the VF mtu exceeds the PF mtu, the VFs won't be able to use it",
+				"device", iface.PciAddress, "vfGroup", group.ResourceName, "vfMtu", group.Mtu,
+				"pfMtu", max(iface.Mtu, currentMtu))
 		}
 	}
+}
+
+// checkMaxMtu returns a MtuExceedsMaxError if the MTU is larger than the maximum MTU of the interface,
+// the check is skipped if the maximum MTU can't be read
+func (s *sriov) checkMaxMtu(ifaceName, device string, mtu int) error {
+	if ifaceName == "" {
+		return nil
+	}
+	maxMtu, err := s.networkHelper.MaxMTU(ifaceName)
+	if err != nil {
+		log.Log.V(2).Info("checkMaxMtu(): unable to get max mtu, skipping the check", "device", device, "error", err)
+		return nil
+	}
+	if maxMtu > 0 && mtu > maxMtu {
+		return &types.MtuExceedsMaxError{Device: device, Mtu: mtu, MaxMtu: maxMtu}
+	}
 	return nil
 }
 
-func (s *sriov) configSriovDevice(iface *sriovnetworkv1.Interface, skipVFConfiguration bool) error {
+// resolvePfName returns the name of the PF, resolved from its PCI address when the spec doesn't provide it,
+// e.g. for a PF freshly bound to its driver, and fails if the PF has no netdev
+func (s *sriov) resolvePfName(iface *sriovnetworkv1.Interface) (string, error) {
+	if iface.Name != "" {
+		return iface.Name, nil
+	}
+	name := s.networkHelper.TryGetInterfaceName(iface.PciAddress)
+	if name == "" {
+		return "", fmt.Errorf("no netdev found for device %s, the PF must be bound to its kernel driver", iface.PciAddress)
+	}
+	log.Log.V(2).Info("resolvePfName(): resolved the name of the PF", "device", iface.PciAddress, "name", name)
+	return name, nil
+}
+
+func (s *sriov) configSriovDeviceWithRollback(ctx context.Context, storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface,
+	skipVFConfiguration bool, rb *configRollback) error {
 	log.Log.V(2).Info("configSriovDevice(): configure sriov device",
 		"device", iface.PciAddress, "config", iface, "skipVFConfiguration", skipVFConfiguration)
+	pfName, err := s.resolvePfName(iface)
+	if err != nil {
+		log.Log.Error(err, "configSriovDevice(): unable to get the name of the PF", "device", iface.PciAddress)
+		return err
+	}
+	if pfName != iface.Name {
+		// the PF is configured with the resolved name, the spec saved as the last applied status is left unchanged
+		resolved := *iface
+		resolved.Name = pfName
+		iface = &resolved
+	}
+	if err := s.checkInterfaceCapabilities(iface); err != nil {
+		log.Log.Error(err, "configSriovDevice(): unsupported configuration", "device", iface.PciAddress)
+		return err
+	}
+	// the original admin state is captured first so a PF intentionally down isn't set up when requested
+	// in the spec, the PFs whose link is managed only by default for their vendor are always set up
+	keepPfDown := false
+	if !iface.ExternallyManaged && iface.PfLinkDownOnVfChange {
+		pfLink, err := s.netlinkLib.LinkByName(iface.Name)
+		if err != nil {
+			return err
+		}
+		keepPfDown = !s.netlinkLib.IsLinkAdminStateUp(pfLink)
+	}
 	if !iface.ExternallyManaged {
-		if err := s.configSriovPFDevice(iface); err != nil {
+		if iface.Mtu > 0 {
+			if err := s.checkMaxMtu(iface.Name, iface.PciAddress, iface.Mtu); err != nil {
+				log.Log.Error(err, "configSriovDevice(): invalid mtu for PF", "device", iface.PciAddress)
+				return err
+			}
+		}
+		if err := s.configSriovPFDevice(ctx, storeManager, iface, rb); err != nil {
 			return err
 		}
 	}
+	if err := configCanceled(ctx, iface.PciAddress); err != nil {
+		return err
+	}
 	if skipVFConfiguration {
 		if iface.ExternallyManaged {
 			return nil
@@ -573,85 +2145,416 @@ func (s *sriov) configSriovDevice(iface *sriovnetworkv1.Interface, skipVFConfigu
 			"device", iface.PciAddress)
 		return s.unbindAllVFsOnPF(iface.PciAddress)
 	}
-	// we don't need to validate externally managed PFs when skipVFConfiguration is true.
-	// The function usually called with skipVFConfiguration true when running in the systemd mode and configuration is
-	// in pre phase. Externally managed PFs may not be configured at this stage yet (preConfig stage is executed before NetworkManager, netplan)
+	// we don't need to validate externally managed PFs when skipVFConfiguration is true.
+	// The function usually called with skipVFConfiguration true when running in the systemd mode and configuration is
+	// in pre phase. Externally managed PFs may not be configured at this stage yet (preConfig stage is executed before NetworkManager, netplan)
+
+	if iface.ExternallyManaged {
+		if err := s.checkExternallyManagedPF(iface); err != nil {
+			return err
+		}
+	}
+	s.warnVfMtuExceedsPfMtu(iface)
+	if err := s.configSriovVFDevices(ctx, storeManager, iface, rb); err != nil {
+		return err
+	}
+	// Set PF link up
+	pfLink, err := s.netlinkLib.LinkByName(iface.Name)
+	if err != nil {
+		return err
+	}
+	if !s.netlinkLib.IsLinkAdminStateUp(pfLink) {
+		if keepPfDown {
+			log.Log.V(2).Info("configSriovDevice(): PF link was down before the configuration, leaving it down",
+				"device", iface.PciAddress)
+			return nil
+		}
+		err = s.netlinkLib.LinkSetUp(pfLink)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sriov) ConfigSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface,
+	interfaces []sriovnetworkv1.Interface, ifaceStatuses []sriovnetworkv1.InterfaceExt, skipVFConfiguration bool) (err error) {
+	defer func() { metrics.ObserveConfigRun(err) }()
+	if vars.ConfigRetryCount == 0 {
+		return s.configSriovInterfacesPass(ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+	}
+	// transient failures, e.g. udev races or drivers which are still settling, are retried
+	// with a full configuration pass until the retry budget is exhausted
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = vars.ConfigRetryInterval
+	b.MaxElapsedTime = 0
+	pass := 0
+	return backoff.Retry(func() error {
+		pass++
+		// the failed pass changed the devices before its changes were rolled back, the retry
+		// plans the configuration against their current status
+		if pass > 1 {
+			statuses, err := s.DiscoverSriovDevices(storeManager)
+			if err != nil {
+				log.Log.Error(err, "ConfigSriovInterfaces(): failed to discover the devices before retrying", "pass", pass)
+				return err
+			}
+			ifaceStatuses = statuses
+		}
+		err := s.configSriovInterfacesPass(ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+		if err == nil {
+			return nil
+		}
+		if isPermanentConfigError(err) {
+			return backoff.Permanent(err)
+		}
+		if pass <= vars.ConfigRetryCount {
+			log.Log.Error(err, "ConfigSriovInterfaces(): configuration pass failed, retrying",
+				"pass", pass, "retries", vars.ConfigRetryCount)
+		}
+		return err
+	}, backoff.WithContext(backoff.WithMaxRetries(b, uint64(vars.ConfigRetryCount)), ctx))
+}
+
+// isPermanentConfigError returns true if retrying the configuration can't fix the error
+func isPermanentConfigError(err error) bool {
+	var numVfsErr *types.NumVfsExceedTotalVfsError
+	var externallyManagedErr *types.ExternallyManagedMismatchError
+	var unsupportedErr *types.UnsupportedConfigError
+	// the PCI realloc kernel argument is needed to allocate the VFs
+	return errors.As(err, &numVfsErr) || errors.As(err, &externallyManagedErr) || errors.As(err, &unsupportedErr) ||
+		errors.Is(err, syscall.ENOMEM) || isConfigCanceled(err)
+}
+
+// configCanceled returns a wrapped context error if the context of the configuration is done
+func configCanceled(ctx context.Context, device string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("configuration of device %s canceled: %w", device, err)
+	}
+	return nil
+}
+
+// isConfigCanceled returns true if the configuration failed because its context is done
+func isConfigCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// configSriovInterfacesPass does a full pass of the configuration of the SR-IOV interfaces
+func (s *sriov) configSriovInterfacesPass(ctx context.Context, storeManager store.ManagerInterface,
+	interfaces []sriovnetworkv1.Interface, ifaceStatuses []sriovnetworkv1.InterfaceExt, skipVFConfiguration bool) error {
+	toBeConfigured, toBeResetted, err := s.getConfigureAndReset(storeManager, interfaces, ifaceStatuses, false)
+	if err != nil && !isExternallyManagedMismatch(err) {
+		log.Log.Error(err, "cannot get a list of interfaces to configure")
+		return fmt.Errorf("cannot get a list of interfaces to configure: %w", err)
+	}
+	// the PFs with an externally managed mismatch are skipped, the error is reported once the other PFs are configured
+	skippedErr := err
+	// nothing is applied if the VFs would end up with conflicting MAC addresses
+	if err := checkMacConflicts(storeManager, toBeConfigured, ifaceStatuses); err != nil {
+		log.Log.Error(err, "cannot configure sriov interfaces")
+		return err
+	}
+
+	if vars.ParallelNicConfig {
+		err = s.configSriovInterfacesInParallel(ctx, storeManager, toBeConfigured, skipVFConfiguration)
+	} else {
+		err = s.configSriovInterfaces(ctx, storeManager, toBeConfigured, skipVFConfiguration)
+	}
+	if err != nil {
+		log.Log.Error(err, "cannot configure sriov interfaces")
+		return fmt.Errorf("cannot configure sriov interfaces: %w", err)
+	}
+	if sriovnetworkv1.ContainsSwitchdevInterface(interfaces) && len(toBeConfigured) > 0 {
+		// for switchdev devices we create udev rule that renames VF representors
+		// after VFs are created. Reload rules to update interfaces
+		if err := s.udevHelper.LoadUdevRules(); err != nil {
+			log.Log.Error(err, "cannot reload udev rules")
+			return fmt.Errorf("failed to reload udev rules: %v", err)
+		}
+	}
+
+	// a drift fails the pass so it is retried, or reported if the retries are disabled or exhausted
+	if vars.VerifyConfig && !skipVFConfiguration {
+		for i := range toBeConfigured {
+			err = errors.Join(err, s.VerifyInterfaceConfig(storeManager, &toBeConfigured[i].iface))
+		}
+		if err != nil {
+			return fmt.Errorf("sriov interfaces configuration not applied: %w", err)
+		}
+	}
+
+	if vars.ParallelNicConfig {
+		err = s.resetSriovInterfacesInParallel(ctx, storeManager, toBeResetted)
+	} else {
+		err = s.resetSriovInterfaces(ctx, storeManager, toBeResetted)
+	}
+	if err != nil {
+		log.Log.Error(err, "cannot reset sriov interfaces")
+		return fmt.Errorf("cannot reset sriov interfaces: %w", err)
+	}
+	if skippedErr != nil {
+		return fmt.Errorf("sriov interfaces skipped: %w", skippedErr)
+	}
+	return nil
+}
+
+func (s *sriov) ConfigSriovInterfacesDryRun(storeManager store.ManagerInterface,
+	interfaces []sriovnetworkv1.Interface, ifaceStatuses []sriovnetworkv1.InterfaceExt) ([]types.PlannedChange, error) {
+	toBeConfigured, toBeResetted, err := s.getConfigureAndReset(storeManager, interfaces, ifaceStatuses, true)
+	if err != nil && !isExternallyManagedMismatch(err) {
+		log.Log.Error(err, "cannot get a list of interfaces to configure")
+		return nil, fmt.Errorf("cannot get a list of interfaces to configure: %w", err)
+	}
+	skippedErr := err
+
+	changes := []types.PlannedChange{}
+	for i := range toBeConfigured {
+		pfChanges, err := planSriovDevice(&toBeConfigured[i].iface, &toBeConfigured[i].ifaceStatus)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, pfChanges...)
+	}
+	for i := range toBeResetted {
+		reset, err := needResetSriovDevice(&toBeResetted[i], storeManager)
+		if err != nil {
+			return nil, err
+		}
+		if reset {
+			changes = append(changes, types.PlannedChange{PciAddress: toBeResetted[i].PciAddress,
+				Kind: types.PlannedChangeReset, Current: strconv.Itoa(toBeResetted[i].NumVfs), Desired: "0"})
+		}
+	}
+	for _, change := range changes {
+		log.Log.Info("ConfigSriovInterfacesDryRun(): planned change", "device", change.PciAddress, "vf", change.VfID,
+			"kind", change.Kind, "current", change.Current, "desired", change.Desired)
+	}
+	if skippedErr != nil {
+		return changes, fmt.Errorf("sriov interfaces skipped: %w", skippedErr)
+	}
+	return changes, nil
+}
+
+// planSriovDevice returns the changes configSriovDevice would apply to the PF and its VFs
+func planSriovDevice(iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt) ([]types.PlannedChange, error) {
+	changes := []types.PlannedChange{}
+	pfChange := func(kind, current, desired string) {
+		changes = append(changes, types.PlannedChange{PciAddress: iface.PciAddress, Kind: kind, Current: current, Desired: desired})
+	}
+	vfChange := func(vfID int, kind, current, desired string) {
+		changes = append(changes, types.PlannedChange{PciAddress: iface.PciAddress, VfID: &vfID, Kind: kind, Current: current, Desired: desired})
+	}
+
+	currentEswitchMode := sriovnetworkv1.GetEswitchModeFromStatus(ifaceStatus)
+	desiredEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
+	// the VFs are created again when their number or the eswitch mode changes
+	recreateVFs := false
+	if !iface.ExternallyManaged {
+		if iface.NumVfs > ifaceStatus.TotalVfs {
+			return nil, &types.NumVfsExceedTotalVfsError{PciAddress: iface.PciAddress, NumVfs: iface.NumVfs, TotalVfs: ifaceStatus.TotalVfs}
+		}
+		rules := []string{"disable-nm"}
+		if desiredEswitchMode == sriovnetworkv1.ESwithModeSwitchDev {
+			rules = append(rules, "persist-pf-name", "vf-representor")
+		}
+		pfChange(types.PlannedChangeUdevRules, "", strings.Join(rules, ","))
+		if currentEswitchMode != desiredEswitchMode {
+			pfChange(types.PlannedChangeEswitchMode, currentEswitchMode, desiredEswitchMode)
+			recreateVFs = true
+		}
+		if ifaceStatus.NumVfs != iface.NumVfs {
+			pfChange(types.PlannedChangeNumVfs, strconv.Itoa(ifaceStatus.NumVfs), strconv.Itoa(iface.NumVfs))
+			recreateVFs = true
+		}
+		if iface.Mtu > 0 && iface.Mtu > ifaceStatus.Mtu {
+			pfChange(types.PlannedChangeMtu, strconv.Itoa(ifaceStatus.Mtu), strconv.Itoa(iface.Mtu))
+		}
+	}
+
+	for vfID := 0; vfID < iface.NumVfs; vfID++ {
+		group := getVfGroup(iface, vfID)
+		if group == nil || group.VdpaType != "" {
+			continue
+		}
+		// the current state of the VFs which are created again is unknown
+		vfStatus := &sriovnetworkv1.VirtualFunction{}
+		if !recreateVFs {
+			for i := range ifaceStatus.VFs {
+				if ifaceStatus.VFs[i].VfID == vfID {
+					vfStatus = &ifaceStatus.VFs[i]
+					break
+				}
+			}
+		}
+		isDpdkVf := sriovnetworkv1.StringInArray(vfStatus.Driver, vars.DpdkDrivers)
+		if group.Mac != "" {
+			if mac, err := sriovnetworkv1.ParseVfMac(group.Mac); err == nil && !strings.EqualFold(vfStatus.AdminMac, mac.String()) {
+				vfChange(vfID, types.PlannedChangeMac, vfStatus.AdminMac, mac.String())
+			}
+		}
+		if sriovnetworkv1.StringInArray(group.DeviceType, vars.DpdkDrivers) {
+			if vfStatus.Driver != group.DeviceType {
+				// the admin MAC is set to the MAC of the VF before the VF is bound to the DPDK driver
+				if group.Mac == "" && !isDpdkVf && vfStatus.Mac != "" {
+					vfChange(vfID, types.PlannedChangeMac, "", vfStatus.Mac)
+				}
+				vfChange(vfID, types.PlannedChangeDriver, vfStatus.Driver, group.DeviceType)
+			}
+			continue
+		}
+		if vfStatus.Driver == "" || isDpdkVf {
+			vfChange(vfID, types.PlannedChangeDriver, vfStatus.Driver, consts.DeviceTypeNetDevice)
+		}
+		if group.Mtu > 0 && group.Mtu > vfStatus.Mtu {
+			current := ""
+			if vfStatus.Mtu > 0 {
+				current = strconv.Itoa(vfStatus.Mtu)
+			}
+			vfChange(vfID, types.PlannedChangeMtu, current, strconv.Itoa(group.Mtu))
+		}
+	}
+	return changes, nil
+}
 
-	if iface.ExternallyManaged {
-		if err := s.checkExternallyManagedPF(iface); err != nil {
-			return err
+// ExportConfigScript returns a shell script doing the sysfs writes, driver binds and ip/devlink commands
+// the configuration of the node state would apply to the host, the script is built from the changes planned
+// against the status of the node state, the PFs which are not configured anymore are not reset by the script
+func (s *sriov) ExportConfigScript(state *sriovnetworkv1.SriovNetworkNodeState) (string, error) {
+	var script strings.Builder
+	script.WriteString("#!/bin/bash\n")
+	fmt.Fprintf(&script, "# SR-IOV configuration of node %s\n", state.GetName())
+	script.WriteString("set -e\n")
+	for i := range state.Spec.Interfaces {
+		iface := &state.Spec.Interfaces[i]
+		ifaceStatus := state.GetInterfaceStateByPciAddress(iface.PciAddress)
+		if ifaceStatus == nil {
+			log.Log.V(2).Info("ExportConfigScript(): no status for the interface, skipping", "device", iface.PciAddress)
+			continue
 		}
-	}
-	if err := s.configSriovVFDevices(iface); err != nil {
-		return err
-	}
-	// Set PF link up
-	pfLink, err := s.netlinkLib.LinkByName(iface.Name)
-	if err != nil {
-		return err
-	}
-	if !s.netlinkLib.IsLinkAdminStateUp(pfLink) {
-		err = s.netlinkLib.LinkSetUp(pfLink)
+		if !sriovnetworkv1.NeedToUpdateSriov(iface, ifaceStatus) {
+			continue
+		}
+		changes, err := planSriovDevice(iface, ifaceStatus)
 		if err != nil {
-			return err
+			return "", err
 		}
+		fmt.Fprintf(&script, "\n# PF %s (%s)\n", iface.PciAddress, ifaceStatus.Name)
+		writeScriptChanges(&script, iface, ifaceStatus, changes)
 	}
-	return nil
+	return script.String(), nil
 }
 
-func (s *sriov) ConfigSriovInterfaces(storeManager store.ManagerInterface,
-	interfaces []sriovnetworkv1.Interface, ifaceStatuses []sriovnetworkv1.InterfaceExt, skipVFConfiguration bool) error {
-	toBeConfigured, toBeResetted, err := s.getConfigureAndReset(storeManager, interfaces, ifaceStatuses)
-	if err != nil {
-		log.Log.Error(err, "cannot get a list of interfaces to configure")
-		return fmt.Errorf("cannot get a list of interfaces to configure")
-	}
-
-	if vars.ParallelNicConfig {
-		err = s.configSriovInterfacesInParallel(storeManager, toBeConfigured, skipVFConfiguration)
-	} else {
-		err = s.configSriovInterfaces(storeManager, toBeConfigured, skipVFConfiguration)
-	}
-	if err != nil {
-		log.Log.Error(err, "cannot configure sriov interfaces")
-		return fmt.Errorf("cannot configure sriov interfaces")
-	}
-	if sriovnetworkv1.ContainsSwitchdevInterface(interfaces) && len(toBeConfigured) > 0 {
-		// for switchdev devices we create udev rule that renames VF representors
-		// after VFs are created. Reload rules to update interfaces
-		if err := s.udevHelper.LoadUdevRules(); err != nil {
-			log.Log.Error(err, "cannot reload udev rules")
-			return fmt.Errorf("failed to reload udev rules: %v", err)
+// writeScriptChanges writes the commands applying the planned changes of the PF and its VFs to the script
+func writeScriptChanges(script *strings.Builder, iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt,
+	changes []types.PlannedChange) {
+	pfPath := filepath.Join(consts.SysBusPciDevices, iface.PciAddress)
+	numVfsPath := filepath.Join(pfPath, consts.NumVfsFile)
+	vfsCreated := false
+	lastVf := -1
+	for _, change := range changes {
+		if change.VfID != nil && *change.VfID != lastVf {
+			lastVf = *change.VfID
+			fmt.Fprintf(script, "vf=$(basename \"$(readlink %s/virtfn%d)\")\n", pfPath, lastVf)
+		}
+		switch {
+		case change.Kind == types.PlannedChangeUdevRules:
+			fmt.Fprintf(script, "# udev rules: %s\n", change.Desired)
+		case change.Kind == types.PlannedChangeEswitchMode || change.Kind == types.PlannedChangeNumVfs:
+			// the eswitch mode and the number of VFs are set together by createVFs
+			if vfsCreated {
+				continue
+			}
+			vfsCreated = true
+			writeScriptCreateVFs(script, iface, ifaceStatus, pfPath, numVfsPath)
+		case change.Kind == types.PlannedChangeMtu && change.VfID == nil:
+			fmt.Fprintf(script, "ip link set dev %s mtu %s\n", ifaceStatus.Name, change.Desired)
+		case change.Kind == types.PlannedChangeMtu:
+			fmt.Fprintf(script, "ip link set dev \"$(ls %s/$vf/net)\" mtu %s\n", consts.SysBusPciDevices, change.Desired)
+		case change.Kind == types.PlannedChangeMac:
+			fmt.Fprintf(script, "ip link set dev %s vf %d mac %s\n", ifaceStatus.Name, *change.VfID, change.Desired)
+		case change.Kind == types.PlannedChangeDriver:
+			writeScriptBindVf(script, change.Current, change.Desired)
 		}
 	}
+}
 
-	if vars.ParallelNicConfig {
-		err = s.resetSriovInterfacesInParallel(storeManager, toBeResetted)
-	} else {
-		err = s.resetSriovInterfaces(storeManager, toBeResetted)
+// writeScriptCreateVFs writes the commands of createVFs setting the eswitch mode and the number of VFs of the PF
+func writeScriptCreateVFs(script *strings.Builder, iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt,
+	pfPath, numVfsPath string) {
+	currentEswitchMode := sriovnetworkv1.GetEswitchModeFromStatus(ifaceStatus)
+	desiredEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
+	if iface.IncrementalVfs && ifaceStatus.NumVfs > 0 && iface.NumVfs > ifaceStatus.NumVfs && currentEswitchMode == desiredEswitchMode {
+		fmt.Fprintf(script, "echo %d > %s\n", iface.NumVfs, numVfsPath)
+		return
+	}
+	unbindVFs := fmt.Sprintf("for vf in %s/virtfn*; do [ -e $vf/driver ] && echo $(basename $(readlink $vf)) > $vf/driver/unbind; done\n", pfPath)
+	if currentEswitchMode != sriovnetworkv1.ESwithModeLegacy {
+		script.WriteString(unbindVFs)
+		fmt.Fprintf(script, "devlink dev eswitch set pci/%s mode %s\n", iface.PciAddress, sriovnetworkv1.ESwithModeLegacy)
+	}
+	fmt.Fprintf(script, "echo 0 > %s\n", numVfsPath)
+	if iface.NumVfs > 0 {
+		fmt.Fprintf(script, "echo %d > %s\n", iface.NumVfs, numVfsPath)
 	}
-	if err != nil {
-		log.Log.Error(err, "cannot reset sriov interfaces")
-		return fmt.Errorf("cannot reset sriov interfaces")
+	if desiredEswitchMode == sriovnetworkv1.ESwithModeSwitchDev {
+		script.WriteString(unbindVFs)
+		fmt.Fprintf(script, "devlink dev eswitch set pci/%s mode %s\n", iface.PciAddress, sriovnetworkv1.ESwithModeSwitchDev)
 	}
-	return nil
 }
 
+// writeScriptBindVf writes the driver binds of the VF selected by the vf variable of the script,
+// the desired driver is a DPDK driver or netdevice for the default kernel driver
+func writeScriptBindVf(script *strings.Builder, currentDriver, desiredDriver string) {
+	vfPath := consts.SysBusPciDevices + "/$vf"
+	if desiredDriver == consts.DeviceTypeNetDevice {
+		// the VF is unbound only from a DPDK driver
+		if currentDriver != "" {
+			fmt.Fprintf(script, "echo $vf > %s/%s/unbind\n", consts.SysBusPciDrivers, currentDriver)
+		}
+		fmt.Fprintf(script, "echo > %s/driver_override\n", vfPath)
+		fmt.Fprintf(script, "echo $vf > %s\n", consts.SysBusPciDriversProbe)
+		return
+	}
+	// the current driver of the VFs created by the script is not known in advance
+	fmt.Fprintf(script, "[ -e %s/driver ] && echo $vf > %s/driver/unbind\n", vfPath, vfPath)
+	fmt.Fprintf(script, "echo %s > %s/driver_override\n", desiredDriver, vfPath)
+	fmt.Fprintf(script, "echo $vf > %s/%s/bind\n", consts.SysBusPciDrivers, desiredDriver)
+	fmt.Fprintf(script, "echo > %s/driver_override\n", vfPath)
+}
+
+// getConfigureAndReset returns the PFs to configure and the PFs to reset, the store is not modified when dryRun is set.
+// The PFs with an externally managed mismatch are neither configured nor reset, their errors are returned
+// joined together with the lists of the other PFs
 func (s *sriov) getConfigureAndReset(storeManager store.ManagerInterface, interfaces []sriovnetworkv1.Interface,
-	ifaceStatuses []sriovnetworkv1.InterfaceExt) ([]interfaceToConfigure, []sriovnetworkv1.InterfaceExt, error) {
+	ifaceStatuses []sriovnetworkv1.InterfaceExt, dryRun bool) ([]interfaceToConfigure, []sriovnetworkv1.InterfaceExt, error) {
 	toBeConfigured := []interfaceToConfigure{}
 	toBeResetted := []sriovnetworkv1.InterfaceExt{}
+	var mismatchErrs error
 	for _, ifaceStatus := range ifaceStatuses {
 		configured := false
 		for _, iface := range interfaces {
-			if iface.PciAddress == ifaceStatus.PciAddress {
+			if sriovnetworkv1.PciAddressEqual(iface.PciAddress, ifaceStatus.PciAddress) {
 				configured = true
-				skip, err := skipSriovConfig(&iface, &ifaceStatus, storeManager)
+				if err := checkExternallyManagedMismatch(&iface, &ifaceStatus, storeManager); err != nil {
+					if !isExternallyManagedMismatch(err) {
+						return nil, nil, err
+					}
+					log.Log.Error(err, "getConfigureAndReset(): inconsistent externally managed configuration, skipping the PF",
+						"address", iface.PciAddress)
+					mismatchErrs = errors.Join(mismatchErrs, err)
+					break
+				}
+				increased, err := totalVfsIncreased(&ifaceStatus, storeManager, dryRun)
+				if err != nil {
+					log.Log.Error(err, "getConfigureAndReset(): failed to check TotalVfs of interface")
+					return nil, nil, err
+				}
+				skip, err := skipSriovConfig(&iface, &ifaceStatus, storeManager, dryRun)
 				if err != nil {
 					log.Log.Error(err, "getConfigureAndReset(): failed to check interface")
 					return nil, nil, err
 				}
-				if skip {
+				if skip && !increased {
 					break
 				}
 				iface := iface
@@ -664,44 +2567,46 @@ func (s *sriov) getConfigureAndReset(storeManager store.ManagerInterface, interf
 			toBeResetted = append(toBeResetted, ifaceStatus)
 		}
 	}
-	return toBeConfigured, toBeResetted, nil
+	return toBeConfigured, toBeResetted, mismatchErrs
 }
 
-func (s *sriov) configSriovInterfacesInParallel(storeManager store.ManagerInterface, interfaces []interfaceToConfigure, skipVFConfiguration bool) error {
-	log.Log.V(2).Info("configSriovInterfacesInParallel(): start sriov configuration")
+func (s *sriov) configSriovInterfacesInParallel(ctx context.Context, storeManager store.ManagerInterface, interfaces []interfaceToConfigure,
+	skipVFConfiguration bool) error {
+	log.Log.V(2).Info("configSriovInterfacesInParallel(): start sriov configuration", "concurrency", vars.NicConfigConcurrency)
 
-	var result error
-	errChannel := make(chan error)
-	interfacesToConfigure := 0
-	for ifaceIndex, iface := range interfaces {
-		interfacesToConfigure += 1
-		go func(iface *interfaceToConfigure) {
-			var err error
-			if err = s.configSriovDevice(&iface.iface, skipVFConfiguration); err != nil {
-				log.Log.Error(err, "configSriovInterfacesInParallel(): fail to configure sriov interface. resetting interface.", "address", iface.iface.PciAddress)
-				if iface.iface.ExternallyManaged {
-					log.Log.V(2).Info("configSriovInterfacesInParallel(): skipping device reset as the nic is marked as externally created")
-				} else {
-					if resetErr := s.ResetSriovDevice(iface.ifaceStatus); resetErr != nil {
-						log.Log.Error(resetErr, "configSriovInterfacesInParallel(): failed to reset on error SR-IOV interface")
-						err = resetErr
-					}
+	pciAddrs := make([]string, len(interfaces))
+	for i := range interfaces {
+		pciAddrs[i] = interfaces[i].iface.PciAddress
+	}
+	result := forEachDeviceInParallel(pciAddrs, func(i int) error {
+		iface := &interfaces[i]
+		if err := configCanceled(ctx, iface.iface.PciAddress); err != nil {
+			return err
+		}
+		if err := s.configObservedSriovDevice(ctx, storeManager, iface, skipVFConfiguration); err != nil {
+			log.Log.Error(err, "configSriovInterfacesInParallel(): fail to configure sriov interface. resetting interface.", "address", iface.iface.PciAddress)
+			if isConfigCanceled(err) {
+				log.Log.V(2).Info("configSriovInterfacesInParallel(): skipping device reset as the configuration was canceled")
+			} else if iface.iface.ExternallyManaged {
+				log.Log.V(2).Info("configSriovInterfacesInParallel(): skipping device reset as the nic is marked as externally created")
+			} else {
+				if resetErr := s.ResetSriovDevice(storeManager, iface.ifaceStatus); resetErr != nil {
+					log.Log.Error(resetErr, "configSriovInterfacesInParallel(): failed to reset on error SR-IOV interface")
 				}
 			}
-			errChannel <- err
-		}(&interfaces[ifaceIndex])
+			return err
+		}
 		// Save the PF status to the host
-		err := storeManager.SaveLastPfAppliedStatus(&iface.iface)
-		if err != nil {
+		if err := storeManager.SaveLastPfAppliedStatus(&iface.iface); err != nil {
 			log.Log.Error(err, "configSriovInterfacesInParallel(): failed to save PF applied config to host")
 			return err
 		}
-	}
-
-	for i := 0; i < interfacesToConfigure; i++ {
-		errMsg := <-errChannel
-		result = errors.Join(result, errMsg)
-	}
+		if err := savePfOriginalMtu(storeManager, &iface.ifaceStatus); err != nil {
+			log.Log.Error(err, "configSriovInterfacesInParallel(): failed to save the original MTU of the PF to host")
+			return err
+		}
+		return nil
+	})
 	if result != nil {
 		log.Log.Error(result, "configSriovInterfacesInParallel(): fail to configure sriov interfaces")
 		return result
@@ -710,25 +2615,22 @@ func (s *sriov) configSriovInterfacesInParallel(storeManager store.ManagerInterf
 	return nil
 }
 
-func (s *sriov) resetSriovInterfacesInParallel(storeManager store.ManagerInterface, interfaces []sriovnetworkv1.InterfaceExt) error {
-	var result error
-	errChannel := make(chan error, len(interfaces))
-	interfacesToReset := 0
-	for ifaceIndex := range interfaces {
-		interfacesToReset += 1
-		go func(iface *sriovnetworkv1.InterfaceExt) {
-			var err error
-			if err = s.checkForConfigAndReset(*iface, storeManager); err != nil {
-				log.Log.Error(err, "resetSriovInterfacesInParallel(): fail to reset sriov interface. resetting interface.", "address", iface.PciAddress)
-			}
-			errChannel <- err
-		}(&interfaces[ifaceIndex])
-	}
-
-	for i := 0; i < interfacesToReset; i++ {
-		errMsg := <-errChannel
-		result = errors.Join(result, errMsg)
+func (s *sriov) resetSriovInterfacesInParallel(ctx context.Context, storeManager store.ManagerInterface, interfaces []sriovnetworkv1.InterfaceExt) error {
+	pciAddrs := make([]string, len(interfaces))
+	for i := range interfaces {
+		pciAddrs[i] = interfaces[i].PciAddress
 	}
+	result := forEachDeviceInParallel(pciAddrs, func(i int) error {
+		iface := &interfaces[i]
+		err := configCanceled(ctx, iface.PciAddress)
+		if err == nil {
+			err = s.checkForConfigAndReset(*iface, storeManager)
+		}
+		if err != nil {
+			log.Log.Error(err, "resetSriovInterfacesInParallel(): fail to reset sriov interface. resetting interface.", "address", iface.PciAddress)
+		}
+		return err
+	})
 	if result != nil {
 		log.Log.Error(result, "resetSriovInterfacesInParallel(): fail to reset sriov interface")
 		return result
@@ -738,15 +2640,74 @@ func (s *sriov) resetSriovInterfacesInParallel(storeManager store.ManagerInterfa
 	return nil
 }
 
-func (s *sriov) configSriovInterfaces(storeManager store.ManagerInterface, interfaces []interfaceToConfigure, skipVFConfiguration bool) error {
+// forEachDeviceInParallel calls fn for the index of every device with at most vars.NicConfigConcurrency
+// devices handled at the same time. The entries with the same PCI address are handled in order by the
+// same worker, so the operations on a device remain serialized. A failing device doesn't stop the
+// others, the errors of all the devices are joined.
+func forEachDeviceInParallel(pciAddrs []string, fn func(i int) error) error {
+	groups := [][]int{}
+	groupByAddr := map[string]int{}
+	for i, addr := range pciAddrs {
+		g, ok := groupByAddr[addr]
+		if !ok {
+			g = len(groups)
+			groupByAddr[addr] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	workers := vars.NicConfigConcurrency
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result error
+	)
+	groupChannel := make(chan []int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groupChannel {
+				for _, i := range group {
+					if err := fn(i); err != nil {
+						mu.Lock()
+						result = errors.Join(result, err)
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		groupChannel <- group
+	}
+	close(groupChannel)
+	wg.Wait()
+	return result
+}
+
+func (s *sriov) configSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface, interfaces []interfaceToConfigure,
+	skipVFConfiguration bool) error {
 	log.Log.V(2).Info("configSriovInterfaces(): start sriov configuration")
 	for _, iface := range interfaces {
-		if err := s.configSriovDevice(&iface.iface, skipVFConfiguration); err != nil {
+		if err := configCanceled(ctx, iface.iface.PciAddress); err != nil {
+			return err
+		}
+		if err := s.configObservedSriovDevice(ctx, storeManager, &iface, skipVFConfiguration); err != nil {
 			log.Log.Error(err, "configSriovInterfaces(): fail to configure sriov interface. resetting interface.", "address", iface.iface.PciAddress)
-			if iface.iface.ExternallyManaged {
+			if isConfigCanceled(err) {
+				log.Log.V(2).Info("configSriovInterfaces(): skipping device reset as the configuration was canceled")
+			} else if iface.iface.ExternallyManaged {
 				log.Log.V(2).Info("configSriovInterfaces(): skipping device reset as the nic is marked as externally created")
 			} else {
-				if resetErr := s.ResetSriovDevice(iface.ifaceStatus); resetErr != nil {
+				if resetErr := s.ResetSriovDevice(storeManager, iface.ifaceStatus); resetErr != nil {
 					log.Log.Error(resetErr, "configSriovInterfaces(): failed to reset on error SR-IOV interface")
 				}
 			}
@@ -759,13 +2720,20 @@ func (s *sriov) configSriovInterfaces(storeManager store.ManagerInterface, inter
 			log.Log.Error(err, "configSriovInterfaces(): failed to save PF applied config to host")
 			return err
 		}
+		if err := savePfOriginalMtu(storeManager, &iface.ifaceStatus); err != nil {
+			log.Log.Error(err, "configSriovInterfaces(): failed to save the original MTU of the PF to host")
+			return err
+		}
 	}
 	log.Log.V(2).Info("configSriovInterfaces(): sriov configuration finished")
 	return nil
 }
 
-func (s *sriov) resetSriovInterfaces(storeManager store.ManagerInterface, interfaces []sriovnetworkv1.InterfaceExt) error {
+func (s *sriov) resetSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface, interfaces []sriovnetworkv1.InterfaceExt) error {
 	for _, iface := range interfaces {
+		if err := configCanceled(ctx, iface.PciAddress); err != nil {
+			return err
+		}
 		if err := s.checkForConfigAndReset(iface, storeManager); err != nil {
 			log.Log.Error(err, "resetSriovInterfaces(): failed to reset sriov interface. resetting interface.", "address", iface.PciAddress)
 			return err
@@ -775,51 +2743,125 @@ func (s *sriov) resetSriovInterfaces(storeManager store.ManagerInterface, interf
 	return nil
 }
 
-// / skipSriovConfig checks if we need to apply SR-IOV configuration specified specific interface
-func skipSriovConfig(iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface) (bool, error) {
-	if !sriovnetworkv1.NeedToUpdateSriov(iface, ifaceStatus) {
-		log.Log.V(2).Info("ConfigSriovInterfaces(): no need update interface", "address", iface.PciAddress)
+// checkExternallyManagedMismatch returns an error if the PF has VFs and the requested ExternallyManaged flag
+// doesn't match the one stored when the PF was configured, proceeding in this case can reset VFs created
+// externally or leave VFs created by the operator unmanaged
+func checkExternallyManagedMismatch(iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface) error {
+	if vars.IgnoreExternallyManagedMismatch || ifaceStatus.NumVfs == 0 {
+		return nil
+	}
+	pfStatus, exist, err := storeManager.LoadPfsStatus(iface.PciAddress)
+	if err != nil {
+		log.Log.Error(err, "checkExternallyManagedMismatch(): failed to load info about PF status for device",
+			"address", iface.PciAddress)
+		return err
+	}
+	if !exist || pfStatus.ExternallyManaged == iface.ExternallyManaged {
+		return nil
+	}
+	return &types.ExternallyManagedMismatchError{PciAddress: iface.PciAddress, Requested: iface.ExternallyManaged}
+}
 
-		// Save the PF status to the host
-		err := storeManager.SaveLastPfAppliedStatus(iface)
-		if err != nil {
-			log.Log.Error(err, "ConfigSriovInterfaces(): failed to save PF applied status config to host")
-			return false, err
-		}
+// isExternallyManagedMismatch returns true if err only reports PFs with an externally managed mismatch
+func isExternallyManagedMismatch(err error) bool {
+	var mismatchErr *types.ExternallyManagedMismatchError
+	return errors.As(err, &mismatchErr)
+}
 
+// totalVfsIncreased returns true if the TotalVfs of the PF increased since a configuration requesting
+// more VFs than the PF supported was rejected, e.g. after a firmware change and a reboot, the saved
+// TotalVfs is removed so the configuration is retried once
+func totalVfsIncreased(ifaceStatus *sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface, dryRun bool) (bool, error) {
+	rejectedTotalVfs, exist, err := storeManager.LoadRejectedTotalVfs(ifaceStatus.PciAddress)
+	if err != nil || !exist || ifaceStatus.TotalVfs <= rejectedTotalVfs {
+		return false, err
+	}
+	log.Log.Info("totalVfsIncreased(): TotalVfs increased since the configuration was rejected, retrying",
+		"address", ifaceStatus.PciAddress, "rejected", rejectedTotalVfs, "current", ifaceStatus.TotalVfs)
+	if dryRun {
 		return true, nil
 	}
-	return false, nil
+	if err := storeManager.RemoveRejectedTotalVfs(ifaceStatus.PciAddress); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (s *sriov) checkForConfigAndReset(ifaceStatus sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface) error {
+// needResetSriovDevice returns true if the VFs of the PF which is not configured anymore must be removed
+func needResetSriovDevice(ifaceStatus *sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface) (bool, error) {
 	// load the PF info
 	pfStatus, exist, err := storeManager.LoadPfsStatus(ifaceStatus.PciAddress)
 	if err != nil {
 		log.Log.Error(err, "checkForConfigAndReset(): failed to load info about PF status for device",
 			"address", ifaceStatus.PciAddress)
-		return err
+		return false, err
 	}
 
 	if !exist {
 		log.Log.V(2).Info("checkForConfigAndReset(): PF name with pci address has VFs configured but they weren't created by the sriov operator. Skipping the device reset",
 			"pf-name", ifaceStatus.Name,
 			"address", ifaceStatus.PciAddress)
-		return nil
+		return false, nil
 	}
 
 	if pfStatus.ExternallyManaged {
 		log.Log.V(2).Info("checkForConfigAndReset(): PF name with pci address was externally created skipping the device reset",
 			"pf-name", ifaceStatus.Name,
 			"address", ifaceStatus.PciAddress)
-		return nil
+		return false, nil
+	}
+
+	if pfStatus.ResetPolicy == sriovnetworkv1.ResetPolicyKeep {
+		log.Log.V(2).Info("checkForConfigAndReset(): PF name with pci address was last configured with the Keep reset policy skipping the device reset",
+			"pf-name", ifaceStatus.Name,
+			"address", ifaceStatus.PciAddress)
+		return false, nil
+	}
+	return true, nil
+}
+
+// savePfOriginalMtu records the MTU of the PF before the operator changes it so the MTU can be restored
+// on reset after a restart of the daemon, the store keeps the MTU recorded the first time the PF was managed.
+// The MTU from the initial state is preferred as the MTU in the status can already be the configured one.
+func savePfOriginalMtu(storeManager store.ManagerInterface, ifaceStatus *sriovnetworkv1.InterfaceExt) error {
+	mtu := ifaceStatus.Mtu
+	if is := sriovnetworkv1.InitialState.GetInterfaceStateByPciAddress(ifaceStatus.PciAddress); is != nil && is.Mtu > 0 {
+		mtu = is.Mtu
+	}
+	return storeManager.SavePfOriginalMtu(ifaceStatus.PciAddress, mtu)
+}
+
+// / skipSriovConfig checks if we need to apply SR-IOV configuration specified specific interface
+func skipSriovConfig(iface *sriovnetworkv1.Interface, ifaceStatus *sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface, dryRun bool) (bool, error) {
+	if !sriovnetworkv1.NeedToUpdateSriov(iface, ifaceStatus) {
+		log.Log.V(2).Info("ConfigSriovInterfaces(): no need update interface", "address", iface.PciAddress)
+		if dryRun {
+			return true, nil
+		}
+
+		// Save the PF status to the host
+		err := storeManager.SaveLastPfAppliedStatus(iface)
+		if err != nil {
+			log.Log.Error(err, "ConfigSriovInterfaces(): failed to save PF applied status config to host")
+			return false, err
+		}
+
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *sriov) checkForConfigAndReset(ifaceStatus sriovnetworkv1.InterfaceExt, storeManager store.ManagerInterface) error {
+	reset, err := needResetSriovDevice(&ifaceStatus, storeManager)
+	if err != nil || !reset {
+		return err
 	}
 	err = s.removeUdevRules(ifaceStatus.PciAddress)
 	if err != nil {
 		return err
 	}
 
-	if err = s.ResetSriovDevice(ifaceStatus); err != nil {
+	if err = s.ResetSriovDevice(storeManager, ifaceStatus); err != nil {
 		return err
 	}
 
@@ -874,29 +2916,169 @@ func (s *sriov) ConfigSriovDeviceVirtual(iface *sriovnetworkv1.Interface) error
 
 func (s *sriov) GetNicSriovMode(pciAddress string) string {
 	log.Log.V(2).Info("GetNicSriovMode()", "device", pciAddress)
-	devLink, err := s.netlinkLib.DevLinkGetDeviceByName("pci", pciAddress)
+	return s.getNicEswitchAttrs(pciAddress).Mode
+}
+
+// GetNicEswitchConfig returns the eswitch config of the device read from its devlink device in one query,
+// an empty config is returned if the device doesn't support devlink
+func (s *sriov) GetNicEswitchConfig(pciAddress string) (types.EswitchConfig, error) {
+	dev, err := s.getDevlinkDevice(pciAddress)
 	if err != nil {
-		if !errors.Is(err, syscall.ENODEV) {
-			log.Log.Error(err, "GetNicSriovMode(): failed to get eswitch mode, assume legacy", "device", pciAddress)
+		if errors.Is(err, syscall.ENODEV) {
+			log.Log.V(2).Info("GetNicEswitchConfig(): device doesn't support devlink", "device", pciAddress)
+			return types.EswitchConfig{}, nil
 		}
+		return types.EswitchConfig{}, err
+	}
+	return types.EswitchConfig{
+		Mode:       dev.Attrs.Eswitch.Mode,
+		InlineMode: dev.Attrs.Eswitch.InlineMode,
+		EncapMode:  dev.Attrs.Eswitch.EncapMode,
+	}, nil
+}
+
+// getNicEswitchMode returns the eswitch mode of the device, legacy if the device doesn't support devlink,
+// an error is returned when the mode is unknown
+func (s *sriov) getNicEswitchMode(pciAddress string) (string, error) {
+	config, err := s.GetNicEswitchConfig(pciAddress)
+	if err != nil {
+		return "", err
+	}
+	if config.Mode == "" {
+		return sriovnetworkv1.ESwithModeLegacy, nil
+	}
+	return config.Mode, nil
+}
+
+// getNicEswitchAttrs returns the eswitch config of the device,
+// the mode defaults to legacy if the device doesn't support devlink or the config can't be read
+func (s *sriov) getNicEswitchAttrs(pciAddress string) types.EswitchConfig {
+	config, err := s.GetNicEswitchConfig(pciAddress)
+	if err != nil {
+		log.Log.Error(err, "getNicEswitchAttrs(): failed to get eswitch mode, assume legacy", "device", pciAddress)
 	}
-	if devLink != nil && devLink.Attrs.Eswitch.Mode != "" {
-		return devLink.Attrs.Eswitch.Mode
+	if config.Mode == "" {
+		config.Mode = sriovnetworkv1.ESwithModeLegacy
 	}
+	return config
+}
 
-	return sriovnetworkv1.ESwithModeLegacy
+// getDevlinkDevice returns the devlink device of the PF, retrying while the devlink device is busy, e.g. while
+// its driver reloads. A DevlinkNotSupportedError is returned when the device doesn't support devlink and the
+// other errors are wrapped with the PCI address of the device
+func (s *sriov) getDevlinkDevice(pciAddress string) (*netlink.DevlinkDevice, error) {
+	var dev *netlink.DevlinkDevice
+	b := backoff.NewConstantBackOff(vars.DevlinkRetryInterval)
+	err := backoff.Retry(func() error {
+		var err error
+		dev, err = s.netlinkLib.DevLinkGetDeviceByName("pci", pciAddress)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EAGAIN) {
+			log.Log.V(2).Info("getDevlinkDevice(): devlink device is busy, retrying", "device", pciAddress, "error", err)
+			return err
+		}
+		return backoff.Permanent(err)
+	}, backoff.WithMaxRetries(b, uint64(vars.DevlinkRetryCount)))
+	if err != nil {
+		if errors.Is(err, syscall.ENODEV) {
+			return nil, &types.DevlinkNotSupportedError{PciAddress: pciAddress}
+		}
+		return nil, fmt.Errorf("failed to get the devlink device of device %s: %w", pciAddress, err)
+	}
+	return dev, nil
 }
 
 func (s *sriov) SetNicSriovMode(pciAddress string, mode string) error {
 	log.Log.V(2).Info("SetNicSriovMode()", "device", pciAddress, "mode", mode)
 
-	dev, err := s.netlinkLib.DevLinkGetDeviceByName("pci", pciAddress)
+	dev, err := s.getDevlinkDevice(pciAddress)
 	if err != nil {
 		return err
 	}
+	if dev.Attrs.Eswitch.Mode == mode {
+		log.Log.V(2).Info("SetNicSriovMode(): device is already in the requested mode", "device", pciAddress, "mode", mode)
+		return nil
+	}
 	return s.netlinkLib.DevLinkSetEswitchMode(dev, mode)
 }
 
+func (s *sriov) SetNicEswitchInlineMode(pciAddress string, mode string) error {
+	log.Log.V(2).Info("SetNicEswitchInlineMode()", "device", pciAddress, "mode", mode)
+
+	dev, err := s.getDevlinkDevice(pciAddress)
+	if err != nil {
+		if errors.Is(err, syscall.ENODEV) {
+			log.Log.V(2).Info("SetNicEswitchInlineMode(): device doesn't support devlink, skipping", "device", pciAddress)
+			return nil
+		}
+		return err
+	}
+	return s.netlinkLib.DevLinkSetEswitchInlineMode(dev, mode)
+}
+
+func (s *sriov) SetNicEswitchEncapMode(pciAddress string, enabled bool) error {
+	log.Log.V(2).Info("SetNicEswitchEncapMode()", "device", pciAddress, "enabled", enabled)
+
+	dev, err := s.getDevlinkDevice(pciAddress)
+	if err != nil {
+		if errors.Is(err, syscall.ENODEV) {
+			log.Log.V(2).Info("SetNicEswitchEncapMode(): device doesn't support devlink, skipping", "device", pciAddress)
+			return nil
+		}
+		return err
+	}
+	return s.netlinkLib.DevLinkSetEswitchEncapMode(dev, enabled)
+}
+
+// SetDevlinkVfRate programs the tx rate limits of the VF on the devlink rate object of the port
+// of its representor, minRate and maxRate are in Mbps and 0 means no limit
+func (s *sriov) SetDevlinkVfRate(pciAddr string, vfID int, minRate, maxRate uint64) error {
+	log.Log.V(2).Info("SetDevlinkVfRate()", "device", pciAddr, "vf", vfID, "minRate", minRate, "maxRate", maxRate)
+	port, err := s.getVfDevlinkPort(pciAddr, vfID)
+	if err != nil {
+		return err
+	}
+	// devlink rates are in bytes per second
+	return s.netlinkLib.DevlinkPortFnRateSet(port.BusName, port.DeviceName, port.PortIndex,
+		minRate*mbpsToBytesPerSecond, maxRate*mbpsToBytesPerSecond)
+}
+
+// getVfDevlinkPort returns the devlink port of the representor of the VF
+func (s *sriov) getVfDevlinkPort(pciAddr string, vfID int) (*netlink.DevlinkPort, error) {
+	pfName := s.networkHelper.TryGetInterfaceName(pciAddr)
+	if pfName == "" {
+		return nil, fmt.Errorf("failed to get interface name for device %s", pciAddr)
+	}
+	repName, err := s.sriovnetLib.GetVfRepresentor(pfName, vfID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get representor of VF %d of device %s: %v", vfID, pciAddr, err)
+	}
+	port, err := s.getRepresentorDevlinkPort(repName)
+	if err != nil {
+		return nil, err
+	}
+	if port == nil || port.DeviceName != pciAddr {
+		return nil, fmt.Errorf("devlink port of representor %s not found for VF %d of device %s", repName, vfID, pciAddr)
+	}
+	return port, nil
+}
+
+// getRepresentorDevlinkPort returns the PCI devlink port of the representor, nil if there is none
+func (s *sriov) getRepresentorDevlinkPort(repName string) (*netlink.DevlinkPort, error) {
+	ports, err := s.netlinkLib.DevLinkGetAllPortList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devlink ports: %v", err)
+	}
+	for _, port := range ports {
+		if port.BusName == consts.BusPci && port.NetdeviceName == repName {
+			return port, nil
+		}
+	}
+	return nil, nil
+}
+
 func (s *sriov) GetLinkType(name string) string {
 	log.Log.V(2).Info("GetLinkType()", "name", name)
 	link, err := s.netlinkLib.LinkByName(name)
@@ -967,20 +3149,108 @@ func (s *sriov) removeUdevRules(pciAddress string) error {
 	return s.udevHelper.RemovePersistPFNameUdevRule(pciAddress)
 }
 
-// create VFs on the PF
-func (s *sriov) createVFs(iface *sriovnetworkv1.Interface) error {
+// create VFs on the PF, the previous number of VFs is recorded in the rollback if it changes
+func (s *sriov) createVFs(iface *sriovnetworkv1.Interface, rb *configRollback) error {
 	expectedEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
 	log.Log.V(2).Info("createVFs(): configure VFs for device",
 		"device", iface.PciAddress, "count", iface.NumVfs, "mode", expectedEswitchMode)
 
-	if s.dputilsLib.GetVFconfigured(iface.PciAddress) == iface.NumVfs {
-		if s.GetNicSriovMode(iface.PciAddress) == expectedEswitchMode {
+	currentNumVfs := s.dputilsLib.GetVFconfigured(iface.PciAddress)
+	if currentNumVfs == iface.NumVfs {
+		if eswitchAttrsMatch(s.getNicEswitchAttrs(iface.PciAddress), iface) {
 			log.Log.V(2).Info("createVFs(): device is already configured",
 				"device", iface.PciAddress, "count", iface.NumVfs, "mode", expectedEswitchMode)
 			return nil
 		}
 	}
-	return s.setEswitchModeAndNumVFs(iface.PciAddress, expectedEswitchMode, iface.NumVfs)
+	if currentNumVfs != iface.NumVfs {
+		rb.push(func() error { return s.SetSriovNumVfs(iface.PciAddress, currentNumVfs) },
+			"NumVfs", "device", iface.PciAddress, "numVfs", currentNumVfs)
+	}
+	restorePfLink, err := s.setPfLinkDownForVfChange(iface)
+	if err != nil {
+		return err
+	}
+	// in incremental mode the existing VFs are kept when the number of VFs increases
+	if iface.IncrementalVfs && currentNumVfs > 0 && iface.NumVfs > currentNumVfs &&
+		s.GetNicSriovMode(iface.PciAddress) == expectedEswitchMode {
+		err = s.addSriovNumVfs(iface.PciAddress, currentNumVfs, iface.NumVfs)
+	} else {
+		err = s.setEswitchModeAndNumVFs(iface.PciAddress, expectedEswitchMode, iface.NumVfs,
+			iface.EswitchInlineMode, iface.EswitchEncapMode)
+	}
+	return errors.Join(err, restorePfLink())
+}
+
+// pfLinkDownOnVfChange returns true if the PF link must be down while the number of VFs changes,
+// when requested in the spec or by default for the vendor of the PF
+func (s *sriov) pfLinkDownOnVfChange(iface *sriovnetworkv1.Interface) bool {
+	if iface.PfLinkDownOnVfChange {
+		return true
+	}
+	data, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, iface.PciAddress, "vendor"))
+	if err != nil {
+		log.Log.V(2).Info("pfLinkDownOnVfChange(): failed to read the vendor of the PF", "device", iface.PciAddress, "error", err)
+		return false
+	}
+	vendor := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	return sriovnetworkv1.StringInArray(vendor, pfLinkDownOnVfChangeVendors)
+}
+
+// setPfLinkDownForVfChange sets the PF link down before the number of VFs changes if needed,
+// the returned function restores the original admin state of the PF link
+func (s *sriov) setPfLinkDownForVfChange(iface *sriovnetworkv1.Interface) (func() error, error) {
+	noop := func() error { return nil }
+	if !s.pfLinkDownOnVfChange(iface) {
+		return noop, nil
+	}
+	pfLink, err := s.netlinkLib.LinkByName(iface.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the link of PF %s: %w", iface.PciAddress, err)
+	}
+	if !s.netlinkLib.IsLinkAdminStateUp(pfLink) {
+		log.Log.V(2).Info("setPfLinkDownForVfChange(): PF link already down", "device", iface.PciAddress)
+		return noop, nil
+	}
+	log.Log.V(2).Info("setPfLinkDownForVfChange(): set PF link down while the number of VFs changes", "device", iface.PciAddress)
+	if err := s.netlinkLib.LinkSetDown(pfLink); err != nil {
+		return nil, fmt.Errorf("failed to set the link of PF %s down: %w", iface.PciAddress, err)
+	}
+	return func() error {
+		log.Log.V(2).Info("setPfLinkDownForVfChange(): restore PF link up", "device", iface.PciAddress)
+		if err := s.netlinkLib.LinkSetUp(pfLink); err != nil {
+			return fmt.Errorf("failed to restore the link of PF %s up: %w", iface.PciAddress, err)
+		}
+		return nil
+	}, nil
+}
+
+// eswitchAttrsMatch returns true if the eswitch attributes of the device match the interface spec,
+// the inline and encap modes are compared only in switchdev mode and when they are set
+func eswitchAttrsMatch(attrs types.EswitchConfig, iface *sriovnetworkv1.Interface) bool {
+	expectedEswitchMode := sriovnetworkv1.GetEswitchModeFromSpec(iface)
+	if attrs.Mode != expectedEswitchMode {
+		return false
+	}
+	if expectedEswitchMode != sriovnetworkv1.ESwithModeSwitchDev {
+		return true
+	}
+	return (iface.EswitchInlineMode == "" || iface.EswitchInlineMode == attrs.InlineMode) &&
+		(iface.EswitchEncapMode == "" || iface.EswitchEncapMode == attrs.EncapMode)
+}
+
+// addSriovNumVfs increases the number of VFs of the device without removing the existing VFs first,
+// this is supported only by the drivers able to create VFs incrementally
+func (s *sriov) addSriovNumVfs(pciAddr string, currentNumVfs, numVfs int) error {
+	log.Log.V(2).Info("addSriovNumVfs(): increase NumVfs", "device", pciAddr, "current", currentNumVfs, "numVfs", numVfs)
+	numVfsFilePath := filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pciAddr, consts.NumVfsFile)
+	if err := os.WriteFile(numVfsFilePath, []byte(strconv.Itoa(numVfs)), os.ModeAppend); err != nil {
+		err = fmt.Errorf("driver rejected incremental VF creation (%d -> %d) for device %s, "+
+			"disable incrementalVfs to recreate the VFs: %w", currentNumVfs, numVfs, pciAddr, err)
+		log.Log.Error(err, "addSriovNumVfs(): fail to set NumVfs file", "path", numVfsFilePath)
+		return err
+	}
+	return nil
 }
 
 func (s *sriov) setEswitchMode(pciAddr, eswitchMode string) error {
@@ -997,7 +3267,27 @@ func (s *sriov) setEswitchMode(pciAddr, eswitchMode string) error {
 	return nil
 }
 
-func (s *sriov) setEswitchModeAndNumVFs(pciAddr string, desiredEswitchMode string, numVFs int) error {
+// setEswitchOffloadModes configures the eswitch inline and encap modes of the device,
+// the modes can be changed only while the device is in the legacy mode, empty values are left unchanged
+func (s *sriov) setEswitchOffloadModes(pciAddr, inlineMode, encapMode string) error {
+	if inlineMode != "" {
+		if err := s.SetNicEswitchInlineMode(pciAddr, inlineMode); err != nil {
+			err = fmt.Errorf("failed to set eswitch inline mode %s: %v", inlineMode, err)
+			log.Log.Error(err, "setEswitchOffloadModes(): failed to set inline mode", "device", pciAddr)
+			return err
+		}
+	}
+	if encapMode != "" {
+		if err := s.SetNicEswitchEncapMode(pciAddr, encapMode == sriovnetworkv1.EswitchEncapModeEnable); err != nil {
+			err = fmt.Errorf("failed to set eswitch encap mode %s: %v", encapMode, err)
+			log.Log.Error(err, "setEswitchOffloadModes(): failed to set encap mode", "device", pciAddr)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sriov) setEswitchModeAndNumVFs(pciAddr string, desiredEswitchMode string, numVFs int, inlineMode, encapMode string) error {
 	log.Log.V(2).Info("setEswitchModeAndNumVFs(): configure VFs for device",
 		"device", pciAddr, "count", numVFs, "mode", desiredEswitchMode)
 
@@ -1013,11 +3303,24 @@ func (s *sriov) setEswitchModeAndNumVFs(pciAddr string, desiredEswitchMode strin
 	}
 
 	if desiredEswitchMode == sriovnetworkv1.ESwithModeSwitchDev {
+		if err := s.setEswitchOffloadModes(pciAddr, inlineMode, encapMode); err != nil {
+			return err
+		}
 		return s.setEswitchMode(pciAddr, sriovnetworkv1.ESwithModeSwitchDev)
 	}
 	return nil
 }
 
+// pushVfDriverRollback records the action restoring the driver the VF was bound to before its configuration
+func (s *sriov) pushVfDriverRollback(rb *configRollback, addr, prevDriver string) {
+	if prevDriver == "" {
+		rb.push(func() error { return s.kernelHelper.Unbind(addr) }, "VF driver", "address", addr, "driver", prevDriver)
+		return
+	}
+	rb.push(func() error { return s.kernelHelper.BindDriverByBusAndDevice(consts.BusPci, addr, prevDriver) },
+		"VF driver", "address", addr, "driver", prevDriver)
+}
+
 // retrieve all VFs for the PF and unbind them from a driver
 func (s *sriov) unbindAllVFsOnPF(addr string) error {
 	log.Log.V(2).Info("unbindAllVFsOnPF(): unbind all VFs on PF", "device", addr)
@@ -1,27 +1,42 @@
 package sriov
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"os"
 	"strconv"
+	"sync"
 	"syscall"
+	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/jaypipes/ghw"
 	"github.com/jaypipes/pcidb"
 	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
 	dputilsMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/dputils/mock"
 	ghwMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/ghw/mock"
+	netlinkPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/netlink"
 	netlinkMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/netlink/mock"
 	sriovnetMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/sriovnet/mock"
 	hostMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/mock"
 	hostStoreMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/store/mock"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	mlx "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vendors/mellanox"
+	mlxMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vendors/mellanox/mock"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
 )
@@ -35,10 +50,20 @@ var _ = Describe("SRIOV", func() {
 		ghwLibMock       *ghwMockPkg.MockGHWLib
 		hostMock         *hostMockPkg.MockHostManagerInterface
 		storeManagerMode *hostStoreMockPkg.MockManagerInterface
+		mlxMock          *mlxMockPkg.MockMellanoxInterface
 
 		testCtrl *gomock.Controller
 
 		testError = fmt.Errorf("test")
+
+		// expectVFsCreated sets the expectations of the wait for the VFs created on the PF, the VFs have no driver
+		expectVFsCreated = func(pfAddr string, vfAddrs ...string) {
+			dputilsLibMock.EXPECT().GetVFList(pfAddr).Return(vfAddrs, nil)
+			for i, addr := range vfAddrs {
+				dputilsLibMock.EXPECT().GetVFID(addr).Return(i, nil)
+				hostMock.EXPECT().HasDriver(addr).Return(false, "")
+			}
+		}
 	)
 	BeforeEach(func() {
 		testCtrl = gomock.NewController(GinkgoT())
@@ -49,14 +74,32 @@ var _ = Describe("SRIOV", func() {
 
 		hostMock = hostMockPkg.NewMockHostManagerInterface(testCtrl)
 		storeManagerMode = hostStoreMockPkg.NewMockManagerInterface(testCtrl)
+		mlxMock = mlxMockPkg.NewMockMellanoxInterface(testCtrl)
 
-		s = New(nil, hostMock, hostMock, hostMock, hostMock, netlinkLibMock, dputilsLibMock, sriovnetLibMock, ghwLibMock)
+		s = New(nil, hostMock, hostMock, hostMock, hostMock, netlinkLibMock, dputilsLibMock, sriovnetLibMock, ghwLibMock, mlxMock)
 	})
 
 	AfterEach(func() {
 		testCtrl.Finish()
 	})
 
+	Context("discoverBlueFieldMode", func() {
+		It("should report the mode of the BlueField cards", func() {
+			mlxMock.EXPECT().GetMellanoxBlueFieldMode("0000:3b:00.0").Return(mlx.BluefieldDpu, nil)
+			mlxMock.EXPECT().GetMellanoxBlueFieldMode("0000:3b:00.1").Return(mlx.BlueFieldMode(-1), testError)
+
+			bf2 := &sriovnetworkv1.InterfaceExt{PciAddress: "0000:3b:00.0", Vendor: "15b3", DeviceID: "a2d6"}
+			s.(*sriov).discoverBlueFieldMode(bf2)
+			Expect(bf2.BlueFieldMode).To(Equal("dpu"))
+			bf2Unknown := &sriovnetworkv1.InterfaceExt{PciAddress: "0000:3b:00.1", Vendor: "15b3", DeviceID: "a2d6"}
+			s.(*sriov).discoverBlueFieldMode(bf2Unknown)
+			Expect(bf2Unknown.BlueFieldMode).To(BeEmpty())
+			cx6 := &sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.0", Vendor: "15b3", DeviceID: "101d"}
+			s.(*sriov).discoverBlueFieldMode(cx6)
+			Expect(cx6.BlueFieldMode).To(BeEmpty())
+		})
+	})
+
 	Context("DiscoverSriovDevices", func() {
 		var (
 			ghwInfoMock *ghwMockPkg.MockInfo
@@ -64,6 +107,7 @@ var _ = Describe("SRIOV", func() {
 		BeforeEach(func() {
 			ghwInfoMock = ghwMockPkg.NewMockInfo(testCtrl)
 			ghwLibMock.EXPECT().PCI().Return(ghwInfoMock, nil)
+			hostMock.EXPECT().WithPhysPortCache().Return(hostMock)
 			origNicMap := sriovnetworkv1.NicIDMap
 			sriovnetworkv1.InitNicIDMapFromList([]string{
 				"15b3 101d 101e",
@@ -74,7 +118,15 @@ var _ = Describe("SRIOV", func() {
 		})
 
 		It("discovered", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/numa_node": []byte("1\n")},
+			})
 			ghwInfoMock.EXPECT().ListDevices().Return(getTestPCIDevices())
+			netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return([]netlink.Route{{LinkIndex: 2}}, nil)
+			mgmtLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			mgmtLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 2})
+			netlinkLibMock.EXPECT().LinkByIndex(2).Return(mgmtLinkMock, nil)
 			dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.0").Return(false)
 			dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.2").Return(true)
 			dputilsLibMock.EXPECT().IsSriovVF("0000:3b:00.0").Return(false)
@@ -89,17 +141,35 @@ var _ = Describe("SRIOV", func() {
 				MTU:          1500,
 				HardwareAddr: mac,
 				EncapType:    "ether",
+				Vfs: []netlink.VfInfo{{
+					ID:        0,
+					Vlan:      100,
+					Qos:       2,
+					VlanProto: int(netlink.VLAN_PROTOCOL_8021AD),
+					Spoofchk:  true,
+					Trust:     0,
+					MinTxRate: 10,
+					MaxTxRate: 100,
+					LinkState: netlink.VF_LINK_STATE_ENABLE,
+				}},
 			}).MinTimes(1)
 			hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("100000 Mb/s")
 			hostMock.EXPECT().GetNetDevLinkAdminState("enp216s0f0np0").Return("up")
+			hostMock.EXPECT().GetNetDevFirmwareVersion("enp216s0f0np0").Return("22.39.1002 (MT_0000000359)")
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(8, 63, nil)
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(
+				&types.RingSizes{Rx: 1024, Tx: 1024, MaxRx: 8192, MaxTx: 8192}, nil)
 			hostMock.EXPECT().GetNetDevNodeGUID("0000:d8:00.2").Return("guid1")
 			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil)
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(&netlinkPkg.DcbIeee{
+				EtsCap: 8, PfcEnabled: 0b00001000, PrioTc: [8]uint8{0, 0, 0, 1, 0, 0, 0, 0}}, nil)
 
 			dputilsLibMock.EXPECT().IsSriovPF("0000:d8:00.0").Return(true)
 			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1)
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(1)
 			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
 				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "switchdev"}}}, nil)
+			hostMock.EXPECT().GetDevlinkOffloadVfLimit("0000:d8:00.0").Return(16, nil)
 			dputilsLibMock.EXPECT().SriovConfigured("0000:d8:00.0").Return(true)
 			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2"}, nil)
 			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.2").Return("mlx5_core", nil)
@@ -114,9 +184,21 @@ var _ = Describe("SRIOV", func() {
 			vfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{
 				MTU:          1500,
 				HardwareAddr: mac,
+				Flags:        net.FlagUp,
+				OperState:    netlink.OperUp,
 			}).MinTimes(1)
 
 			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 0).Return("enp216s0f0np0_0", nil)
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 65537, NetdeviceName: "enp216s0f0np0_0"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateParentGet("pci", "0000:d8:00.0", uint32(65537)).Return("rt", nil)
+			// the VLAN trunk of the switchdev VF is read from the bridge port of its representor
+			repLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			repLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 10, MasterIndex: 20}).AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0_0").Return(repLinkMock, nil)
+			netlinkLibMock.EXPECT().LinkByIndex(20).Return(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 20}}, nil)
+			netlinkLibMock.EXPECT().BridgeVlanList().Return(map[int32][]*nl.BridgeVlanInfo{
+				10: {{Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED, Vid: 1}, {Vid: 100}, {Vid: 101}}}, nil)
 
 			ret, err := s.DiscoverSriovDevices(storeManagerMode)
 			Expect(err).NotTo(HaveOccurred())
@@ -133,9 +215,21 @@ var _ = Describe("SRIOV", func() {
 				LinkSpeed:         "100000 Mb/s",
 				LinkType:          "ETH",
 				LinkAdminState:    "up",
+				FirmwareVersion:   "22.39.1002 (MT_0000000359)",
+				CombinedChannels:  8,
+				RingRx:            1024,
+				RingTx:            1024,
+				RingRxMax:         8192,
+				RingTxMax:         8192,
 				EswitchMode:       "switchdev",
 				ExternallyManaged: false,
 				TotalVfs:          1,
+				OffloadVfLimit:    16,
+				NumaNode:          1,
+				PfcEnabled:        []int{3},
+				PriorityToTcMap:   []int{0, 0, 0, 1, 0, 0, 0, 0},
+				Capabilities: &sriovnetworkv1.InterfaceCapabilities{
+					MaxTxRate: 100000, VfTrust: true, VfSpoofChk: true, VfVlanProto8021ad: true},
 				VFs: []sriovnetworkv1.VirtualFunction{{
 					Name:            "enp216s0f0v0",
 					Mac:             "4e:fd:3d:08:59:b1",
@@ -143,78 +237,2029 @@ var _ = Describe("SRIOV", func() {
 					PciAddress:      "0000:d8:00.2",
 					Vendor:          "15b3",
 					DeviceID:        "101e",
+					Vlan:            100,
+					VlanQoS:         2,
+					VlanProto:       "802.1ad",
+					SpoofChk:        "on",
+					Trust:           "off",
+					MinTxRate:       10,
+					MaxTxRate:       100,
+					LinkState:       "enable",
+					LinkAdminState:  "up",
+					Carrier:         "up",
 					Mtu:             1500,
 					VfID:            0,
+					NumaNode:        1,
+					ParentPf:        "0000:d8:00.0",
 					RepresentorName: "enp216s0f0np0_0",
 					GUID:            "guid1",
+					QosGroup:        "rt",
+					VlanTrunk:       "100-101",
 				}},
 			}))
 		})
-	})
 
-	Context("SetSriovNumVfs", func() {
-		It("set", func() {
-			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
-				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
-				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
-			})
-			Expect(s.SetSriovNumVfs("0000:d8:00.0", 5)).NotTo(HaveOccurred())
-			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", strconv.Itoa(5))
+		It("should not touch the devices excluded by the discovery filters", func() {
+			origAllow, origDeny := vars.DiscoveryAllowList, vars.DiscoveryDenyList
+			vars.DiscoveryAllowList = []string{"vendor:15b3"}
+			vars.DiscoveryDenyList = []string{"pci:0000:d8:*", "device:aaaa"}
+			DeferCleanup(func() {
+				vars.DiscoveryAllowList, vars.DiscoveryDenyList = origAllow, origDeny
+			})
+			ghwInfoMock.EXPECT().ListDevices().Return(getTestPCIDevices())
+			netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return(nil, nil)
+
+			ret, filtered, err := s.DiscoverSriovDevicesVerbose(storeManagerMode)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ret).To(BeEmpty())
+			Expect(filtered).To(Equal([]types.FilteredDevice{
+				{Address: "0000:d8:00.0", Reason: types.FilteredReasonDiscoveryFilter},
+				{Address: "0000:d8:00.2", Reason: types.FilteredReasonDiscoveryFilter},
+				{Address: "0000:3b:00.0", Reason: types.FilteredReasonDiscoveryFilter},
+				{Address: "0000:d7:16.5", Reason: types.FilteredReasonDiscoveryFilter},
+			}))
+		})
+
+		Context("device used by host system", func() {
+			var pfLinkMock *netlinkMockPkg.MockLink
+			BeforeEach(func() {
+				ghwInfoMock.EXPECT().ListDevices().Return(getTestPCIDevices())
+				dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.0").Return(false)
+				dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.2").Return(true)
+				dputilsLibMock.EXPECT().IsSriovVF("0000:3b:00.0").Return(false)
+				dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("mlx5_core", nil)
+				hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+				pfLinkMock = netlinkMockPkg.NewMockLink(testCtrl)
+				netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			})
+
+			It("should skip the PF carrying the default route", func() {
+				netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return([]netlink.Route{
+					{LinkIndex: 3, Dst: &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}},
+					{LinkIndex: 4, Dst: &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)}},
+				}, nil)
+				routeLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+				routeLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3})
+				netlinkLibMock.EXPECT().LinkByIndex(3).Return(routeLinkMock, nil)
+				pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3}).MinTimes(1)
+
+				ret, err := s.DiscoverSriovDevices(storeManagerMode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ret).To(BeEmpty())
+			})
+
+			It("should report the reason each device was excluded", func() {
+				netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return([]netlink.Route{{LinkIndex: 3}}, nil)
+				routeLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+				routeLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3})
+				netlinkLibMock.EXPECT().LinkByIndex(3).Return(routeLinkMock, nil)
+				pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3}).MinTimes(1)
+
+				ret, filtered, err := s.DiscoverSriovDevicesVerbose(storeManagerMode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ret).To(BeEmpty())
+				Expect(filtered).To(Equal([]types.FilteredDevice{
+					{Address: "0000:d8:00.0", Reason: types.FilteredReasonHostManaged},
+					{Address: "0000:d8:00.2", Reason: types.FilteredReasonSriovVF},
+					{Address: "0000:3b:00.0", Reason: types.FilteredReasonUnsupportedModel},
+					{Address: "0000:d7:16.5", Reason: types.FilteredReasonNotNetwork},
+				}))
+			})
+
+			It("should skip the PF enslaved to the bond carrying the default route", func() {
+				netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return([]netlink.Route{{LinkIndex: 10}}, nil)
+				bondLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+				bondLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 10}).MinTimes(1)
+				netlinkLibMock.EXPECT().LinkByIndex(10).Return(bondLinkMock, nil).Times(2)
+				pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3, MasterIndex: 10}).MinTimes(1)
+
+				ret, err := s.DiscoverSriovDevices(storeManagerMode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ret).To(BeEmpty())
+			})
+
+			It("should not skip the allow-listed PF carrying the default route", func() {
+				origAllowList := vars.HostSystemInterfacesAllowList
+				vars.HostSystemInterfacesAllowList = []string{"0000:d8:00.0"}
+				DeferCleanup(func() {
+					vars.HostSystemInterfacesAllowList = origAllowList
+				})
+				netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return([]netlink.Route{{LinkIndex: 3}}, nil)
+				routeLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+				routeLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3})
+				netlinkLibMock.EXPECT().LinkByIndex(3).Return(routeLinkMock, nil)
+				pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3, EncapType: "ether"}).MinTimes(1)
+				hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("100000 Mb/s")
+				hostMock.EXPECT().GetNetDevLinkAdminState("enp216s0f0np0").Return("up")
+				hostMock.EXPECT().GetNetDevFirmwareVersion("enp216s0f0np0").Return("")
+				hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(0, 0, testError)
+				hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(nil, testError)
+				storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil)
+				netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(nil, syscall.EOPNOTSUPP)
+				dputilsLibMock.EXPECT().IsSriovPF("0000:d8:00.0").Return(false)
+
+				ret, err := s.DiscoverSriovDevices(storeManagerMode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ret).To(HaveLen(1))
+				Expect(ret[0].PciAddress).To(Equal("0000:d8:00.0"))
+			})
+		})
+	})
+
+	Context("DiscoverSriovDevice", func() {
+		var (
+			ghwInfoMock *ghwMockPkg.MockInfo
+		)
+		getTestPCIDevice := func(address string) *ghw.PCIDevice {
+			for _, device := range getTestPCIDevices() {
+				if device.Address == address {
+					return device
+				}
+			}
+			return nil
+		}
+		BeforeEach(func() {
+			ghwInfoMock = ghwMockPkg.NewMockInfo(testCtrl)
+			hostMock.EXPECT().WithPhysPortCache().Return(hostMock)
+			origNicMap := sriovnetworkv1.NicIDMap
+			sriovnetworkv1.InitNicIDMapFromList([]string{
+				"15b3 101d 101e",
+			})
+			DeferCleanup(func() {
+				sriovnetworkv1.NicIDMap = origNicMap
+			})
+		})
+
+		It("discovered", func() {
+			ghwLibMock.EXPECT().PCI().Return(ghwInfoMock, nil)
+			ghwInfoMock.EXPECT().GetDevice("0000:d8:00.0").Return(getTestPCIDevice("0000:d8:00.0"))
+			netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return(nil, nil)
+			dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.0").Return(false)
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("mlx5_core", nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 3, MTU: 1500, EncapType: "ether"}).MinTimes(1)
+			hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("100000 Mb/s")
+			hostMock.EXPECT().GetNetDevLinkAdminState("enp216s0f0np0").Return("up")
+			hostMock.EXPECT().GetNetDevFirmwareVersion("enp216s0f0np0").Return("")
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(0, 0, testError)
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(nil, testError)
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil)
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(nil, syscall.EOPNOTSUPP)
+			dputilsLibMock.EXPECT().IsSriovPF("0000:d8:00.0").Return(false)
+
+			ret, err := s.DiscoverSriovDevice(storeManagerMode, "d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ret.PciAddress).To(Equal("0000:d8:00.0"))
+			Expect(ret.Name).To(Equal("enp216s0f0np0"))
+			Expect(ret.Mtu).To(Equal(1500))
+		})
+		It("not a PF", func() {
+			ghwLibMock.EXPECT().PCI().Return(ghwInfoMock, nil)
+			ghwInfoMock.EXPECT().GetDevice("0000:d8:00.2").Return(getTestPCIDevice("0000:d8:00.2"))
+			netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return(nil, nil)
+			dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.2").Return(true)
+
+			_, err := s.DiscoverSriovDevice(storeManagerMode, "0000:d8:00.2")
+			notFoundErr := &types.DeviceNotFoundError{}
+			Expect(errors.As(err, &notFoundErr)).To(BeTrue())
+			Expect(notFoundErr.Reason).To(Equal(types.FilteredReasonSriovVF))
+		})
+		It("not found", func() {
+			ghwLibMock.EXPECT().PCI().Return(ghwInfoMock, nil)
+			ghwInfoMock.EXPECT().GetDevice("0000:d8:01.0").Return(nil)
+
+			_, err := s.DiscoverSriovDevice(storeManagerMode, "0000:d8:01.0")
+			Expect(err).To(MatchError(&types.DeviceNotFoundError{PciAddress: "0000:d8:01.0", Reason: "PCI device not found"}))
+		})
+		It("invalid address", func() {
+			_, err := s.DiscoverSriovDevice(storeManagerMode, "not-an-address")
+			Expect(err).To(MatchError(ContainSubstring("invalid PCI address")))
+		})
+	})
+
+	Context("SetSriovNumVfs", func() {
+		It("set", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn1",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn2",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn3",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn4",
+				},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
+			})
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2", "0000:d8:00.3", "0000:d8:00.4", "0000:d8:00.5", "0000:d8:00.6")
+			Expect(s.SetSriovNumVfs("0000:d8:00.0", 5)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", strconv.Itoa(5))
+		})
+		It("fail - no such device", func() {
+			Expect(s.SetSriovNumVfs("0000:d8:00.0", 5)).To(HaveOccurred())
+		})
+		It("fail - NumVfs clamped by the driver", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("3\n")},
+			})
+			Expect(s.(*sriov).verifySriovNumVfs("0000:d8:00.0", 5)).To(
+				MatchError(ContainSubstring("requested 5, got 3")))
+		})
+		It("fail - VF devices don't appear", func() {
+			origInterval, origTimeout := vfDevicesPollInterval, vfDevicesPollTimeout
+			vfDevicesPollInterval, vfDevicesPollTimeout = 10*time.Millisecond, 50*time.Millisecond
+			DeferCleanup(func() {
+				vfDevicesPollInterval, vfDevicesPollTimeout = origInterval, origTimeout
+			})
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0", "/sys/bus/pci/devices/0000:d8:00.0/virtfn0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
+			})
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2"}, nil).MinTimes(1)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil).MinTimes(1)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf").MinTimes(1)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("ens1f0v0").MinTimes(1)
+			Expect(s.SetSriovNumVfs("0000:d8:00.0", 2)).To(MatchError(ContainSubstring("missing VFs [1]")))
+		})
+		It("skip the reset when there are no VFs", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0", "/sys/bus/pci/devices/0000:d8:00.0/virtfn0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("0\n")},
+			})
+			var written []string
+			origWrite := writeNumVfsFile
+			writeNumVfsFile = func(name string, data []byte, perm os.FileMode) error {
+				written = append(written, string(data))
+				return origWrite(name, data, perm)
+			}
+			DeferCleanup(func() { writeNumVfsFile = origWrite })
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2")
+			Expect(s.SetSriovNumVfs("0000:d8:00.0", 1)).NotTo(HaveOccurred())
+			Expect(written).To(Equal([]string{"1"}))
+		})
+		Context("second write fails", func() {
+			var origWrite func(string, []byte, os.FileMode) error
+			BeforeEach(func() {
+				helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+					Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+					Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("4\n")},
+				})
+				origWrite = writeNumVfsFile
+				DeferCleanup(func() { writeNumVfsFile = origWrite })
+			})
+			It("restore the previous NumVfs", func() {
+				writeNumVfsFile = func(name string, data []byte, perm os.FileMode) error {
+					if string(data) == "8" {
+						return syscall.ENOMEM
+					}
+					return origWrite(name, data, perm)
+				}
+				Expect(s.SetSriovNumVfs("0000:d8:00.0", 8)).To(MatchError(syscall.ENOMEM))
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "4")
+			})
+			It("fail to restore the previous NumVfs", func() {
+				writeNumVfsFile = func(name string, data []byte, perm os.FileMode) error {
+					if string(data) != "0" {
+						return syscall.ENOMEM
+					}
+					return origWrite(name, data, perm)
+				}
+				err := s.SetSriovNumVfs("0000:d8:00.0", 8)
+				Expect(err).To(MatchError(syscall.ENOMEM))
+				Expect(err).To(MatchError(ContainSubstring("failed to restore NumVfs 4")))
+				helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "0")
+			})
+		})
+	})
+
+	Context("WaitForVFs", func() {
+		BeforeEach(func() {
+			origInterval := vfDevicesPollInterval
+			vfDevicesPollInterval = 10 * time.Millisecond
+			DeferCleanup(func() { vfDevicesPollInterval = origInterval })
+		})
+		It("VFs ready", func() {
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2", "0000:d8:00.3", "0000:d8:00.4"}, nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.3").Return(1, nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.4").Return(2, nil)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf")
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("ens1f0v0")
+			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "vfio-pci")
+			hostMock.EXPECT().HasDriver("0000:d8:00.4").Return(false, "")
+			Expect(s.WaitForVFs("0000:d8:00.0", 3, time.Second)).NotTo(HaveOccurred())
+		})
+		It("netdev appears after a retry", func() {
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2"}, nil).Times(2)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil).Times(2)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf").Times(2)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("")
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("ens1f0v0")
+			Expect(s.WaitForVFs("0000:d8:00.0", 1, time.Second)).NotTo(HaveOccurred())
+		})
+		It("timeout lists the missing VFs", func() {
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2", "0000:d8:00.3"}, nil).MinTimes(1)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil).MinTimes(1)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.3").Return(1, nil).MinTimes(1)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "").MinTimes(1)
+			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "iavf").MinTimes(1)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.3").Return("").MinTimes(1)
+			Expect(s.WaitForVFs("0000:d8:00.0", 4, 50*time.Millisecond)).To(
+				MatchError(ContainSubstring("missing VFs [1 2 3]")))
+		})
+		It("fail to list the VFs", func() {
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return(nil, testError).MinTimes(1)
+			Expect(s.WaitForVFs("0000:d8:00.0", 1, 50*time.Millisecond)).To(
+				MatchError(ContainSubstring("failed to list VFs: test")))
+		})
+	})
+
+	Context("VFIsReady", func() {
+		It("ready", func() {
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+			Expect(s.VFIsReady(context.Background(), "0000:d8:00.2", time.Millisecond, time.Second)).To(Equal(vfLinkMock))
+		})
+		It("ready after a retry", func() {
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").Times(2)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			netlinkLibMock.EXPECT().LinkByName("").Return(nil, testError).Times(2)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+			Expect(s.VFIsReady(context.Background(), "0000:d8:00.2", time.Millisecond, time.Second)).To(Equal(vfLinkMock))
+		})
+		It("fail - report the time waited", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").MinTimes(1)
+			netlinkLibMock.EXPECT().LinkByName("").Return(nil, testError).MinTimes(1)
+			_, err := s.VFIsReady(context.Background(), "0000:d8:00.2", 10*time.Millisecond, 50*time.Millisecond)
+			Expect(err).To(MatchError(ContainSubstring("VF 0000:d8:00.2 link not ready after waiting")))
+			Expect(err).To(MatchError(testError))
+		})
+		It("fail - context canceled", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("").Return(nil, testError).AnyTimes()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := s.VFIsReady(ctx, "0000:d8:00.2", time.Millisecond, time.Minute)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Context("CheckSriovPrerequisites", func() {
+		const (
+			assignedResource   = "0x00000000c8000000 0x00000000c9ffffff 0x0000000000140204\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x00000000ca000000 0x00000000cbffffff 0x0000000000140204\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n"
+			unassignedResource = "0x00000000c8000000 0x00000000c9ffffff 0x0000000000140204\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n0x0000000000000000 0x0000000000000000 0x0000000000000000\n"
+		)
+		configureResource := func(resource string) {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/resource": []byte(resource)},
+			})
+		}
+		failedChecks := func(results []types.PrereqResult) map[string]string {
+			failed := map[string]string{}
+			for _, r := range results {
+				if !r.Passed {
+					failed[r.Name] = r.Remediation
+				}
+			}
+			return failed
+		}
+		It("should pass all the checks", func() {
+			configureResource(assignedResource)
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(8)
+			hostMock.EXPECT().IsIommuEnabled().Return(true)
+			hostMock.EXPECT().GetCurrentKernelArgs().Return("ro intel_iommu=on iommu=pt", nil)
+			hostMock.EXPECT().IommuKernelArgForHost().Return(consts.KernelArgIntelIommu, nil)
+			hostMock.EXPECT().IsKernelArgsSet("ro intel_iommu=on iommu=pt", consts.KernelArgIntelIommu).Return(true)
+			hostMock.EXPECT().IsKernelArgsSet("ro intel_iommu=on iommu=pt", consts.KernelArgPciRealloc).Return(false)
+
+			results := s.CheckSriovPrerequisites("0000:d8:00.0")
+			Expect(results).To(HaveLen(4))
+			Expect(failedChecks(results)).To(BeEmpty())
+		})
+		It("should report the failed checks with a remediation hint", func() {
+			configureResource(unassignedResource)
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(8)
+			hostMock.EXPECT().IsIommuEnabled().Return(false)
+			hostMock.EXPECT().GetCurrentKernelArgs().Return("ro", nil)
+			hostMock.EXPECT().IommuKernelArgForHost().Return(consts.KernelArgAmdIommu, nil)
+			hostMock.EXPECT().IsKernelArgsSet("ro", consts.KernelArgAmdIommu).Return(false)
+			hostMock.EXPECT().IsKernelArgsSet("ro", consts.KernelArgPciRealloc).Return(false)
+
+			failed := failedChecks(s.CheckSriovPrerequisites("0000:d8:00.0"))
+			Expect(failed).To(HaveLen(3))
+			Expect(failed).To(HaveKeyWithValue(types.PrereqIommuEnabled, ContainSubstring("VT-d")))
+			Expect(failed).To(HaveKeyWithValue(types.PrereqIommuKernelArg, ContainSubstring("add amd_iommu=on")))
+			Expect(failed).To(HaveKeyWithValue(types.PrereqPciRealloc, ContainSubstring("add pci=realloc")))
+		})
+		It("should not require pci=realloc when it is already set", func() {
+			configureResource(unassignedResource)
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(0)
+			hostMock.EXPECT().IsIommuEnabled().Return(true)
+			hostMock.EXPECT().GetCurrentKernelArgs().Return("intel_iommu=on pci=realloc", nil)
+			hostMock.EXPECT().IommuKernelArgForHost().Return(consts.KernelArgIntelIommu, nil)
+			hostMock.EXPECT().IsKernelArgsSet("intel_iommu=on pci=realloc", consts.KernelArgIntelIommu).Return(true)
+			hostMock.EXPECT().IsKernelArgsSet("intel_iommu=on pci=realloc", consts.KernelArgPciRealloc).Return(true)
+
+			failed := failedChecks(s.CheckSriovPrerequisites("0000:d8:00.0"))
+			Expect(failed).To(HaveLen(1))
+			Expect(failed).To(HaveKeyWithValue(types.PrereqSriovTotalVfs, ContainSubstring("enable SR-IOV")))
+		})
+	})
+
+	Context("GetInterfaceCapabilities", func() {
+		It("capabilities of the driver and the link", func() {
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("i40e", nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("ens1f0")
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("ens1f0").Return(pfLinkMock, nil)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Vfs: []netlink.VfInfo{{ID: 0, Trust: 1}}})
+			hostMock.EXPECT().GetNetDevLinkSpeed("ens1f0").Return("25000 Mb/s")
+			Expect(s.GetInterfaceCapabilities("0000:d8:00.0")).To(Equal(sriovnetworkv1.InterfaceCapabilities{
+				MaxTxRate: 25000, VfTrust: true, VfSpoofChk: true, VfVlanProto8021ad: false}))
+		})
+		It("trust mode not reported by the driver and link down", func() {
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("sfc", nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("ens1f0")
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("ens1f0").Return(pfLinkMock, nil)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Vfs: []netlink.VfInfo{{ID: 0, Trust: math.MaxUint32}}})
+			hostMock.EXPECT().GetNetDevLinkSpeed("ens1f0").Return("-1 Mb/s")
+			Expect(s.GetInterfaceCapabilities("0000:d8:00.0")).To(Equal(sriovnetworkv1.InterfaceCapabilities{
+				VfTrust: false, VfSpoofChk: false, VfVlanProto8021ad: true}))
+		})
+		It("fail to get the driver", func() {
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("", testError)
+			_, err := s.GetInterfaceCapabilities("0000:d8:00.0")
+			Expect(err).To(MatchError(testError))
+		})
+	})
+
+	Context("checkInterfaceCapabilities", func() {
+		It("reject the settings the PF doesn't support", func() {
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("i40e", nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("ens1f0")
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("ens1f0").Return(pfLinkMock, nil)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{})
+			hostMock.EXPECT().GetNetDevLinkSpeed("ens1f0").Return("10000 Mb/s")
+			err := s.(*sriov).checkInterfaceCapabilities(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				VfGroups: []sriovnetworkv1.VfGroup{
+					{VfRange: "0-1", VlanID: pointer.Int(100), VlanProto: "802.1ad"},
+					{VfRange: "2-3", MaxTxRate: pointer.Int(40000)}}})
+			var unsupportedErr *types.UnsupportedConfigError
+			Expect(errors.As(err, &unsupportedErr)).To(BeTrue())
+			Expect(unsupportedErr.Settings).To(Equal([]string{
+				"802.1ad VLAN protocol", "max TX rate 40000 Mbps above the link speed 10000 Mbps"}))
+			Expect(isPermanentConfigError(err)).To(BeTrue())
+		})
+		It("capabilities not needed", func() {
+			Expect(s.(*sriov).checkInterfaceCapabilities(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				VfGroups: []sriovnetworkv1.VfGroup{{VfRange: "0-1", Trust: "off"}}})).NotTo(HaveOccurred())
+		})
+		It("capabilities can't be read", func() {
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("", testError)
+			Expect(s.(*sriov).checkInterfaceCapabilities(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				VfGroups: []sriovnetworkv1.VfGroup{{VfRange: "0-1", Trust: "on"}}})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("VerifyInterfaceConfig", func() {
+		var iface *sriovnetworkv1.Interface
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     2,
+				VfGroups: []sriovnetworkv1.VfGroup{
+					{VfRange: "0-0", ResourceName: "net", DeviceType: "netdevice", Mtu: 2000, MacBase: "02:00:00:00:00:10"},
+					{VfRange: "1-1", ResourceName: "dpdk", DeviceType: "vfio-pci"},
+				},
+			}
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2", "0000:d8:00.3"}, nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.3").Return(1, nil)
+			storeManagerMode.EXPECT().LoadVfMac("0000:d8:00.0", 0).Return("02:00:00:00:00:10", true, nil)
+		})
+		It("no drift", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Vfs: []netlink.VfInfo{
+				{ID: 0, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}}}})
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "mlx5_core")
+			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "vfio-pci")
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(2000)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			Expect(s.VerifyInterfaceConfig(storeManagerMode, iface)).NotTo(HaveOccurred())
+		})
+		It("drift", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Vfs: []netlink.VfInfo{
+				{ID: 0, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x99}}}})
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(3)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "mlx5_core")
+			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "mlx5_core")
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(1500)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			err := s.VerifyInterfaceConfig(storeManagerMode, iface)
+			driftErr := &types.ConfigDriftError{}
+			Expect(errors.As(err, &driftErr)).To(BeTrue())
+			Expect(driftErr.Drifts).To(HaveLen(4))
+			Expect(err).To(MatchError(ContainSubstring(`numVfs is "3" instead of "2"`)))
+			Expect(err).To(MatchError(ContainSubstring(`VF 0 mtu is "1500" instead of "2000"`)))
+			Expect(err).To(MatchError(ContainSubstring(`VF 0 mac is "02:00:00:00:00:99" instead of "02:00:00:00:00:10"`)))
+			Expect(err).To(MatchError(ContainSubstring(`VF 1 driver is "mlx5_core" instead of "vfio-pci"`)))
+		})
+	})
+
+	Context("getNumaNode", func() {
+		It("known", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/numa_node": []byte("1\n")},
+			})
+			Expect(getNumaNode("0000:d8:00.0")).To(Equal(1))
+		})
+		It("unknown", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/numa_node": []byte("-1\n")},
+			})
+			Expect(getNumaNode("0000:d8:00.0")).To(Equal(-1))
+		})
+		It("file missing", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+			})
+			Expect(getNumaNode("0000:d8:00.0")).To(Equal(-1))
+		})
+	})
+
+	Context("createVFs", func() {
+		It("increase NumVfs incrementally", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			Expect(s.(*sriov).createVFs(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "4")
+		})
+		It("fail - driver rejects incremental VF creation", func() {
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			err := s.(*sriov).createVFs(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("driver rejected incremental VF creation (2 -> 4) for device 0000:d8:00.0"))
+		})
+		It("already configured", func() {
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(4)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			Expect(s.(*sriov).createVFs(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)).NotTo(HaveOccurred())
+		})
+		It("set the eswitch offload modes in legacy mode before switching to switchdev", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("0")},
+			})
+			testDev := &netlink.DevlinkDevice{BusName: "pci", DeviceName: "0000:d8:00.0",
+				Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(testDev, nil).AnyTimes()
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return(nil, nil)
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().DevLinkSetEswitchInlineMode(testDev, "transport").Return(nil),
+				netlinkLibMock.EXPECT().DevLinkSetEswitchEncapMode(testDev, false).Return(nil),
+				netlinkLibMock.EXPECT().DevLinkSetEswitchMode(testDev, "switchdev").Return(nil),
+			)
+			Expect(s.(*sriov).createVFs(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0", NumVfs: 0, EswitchMode: "switchdev",
+				EswitchInlineMode: "transport", EswitchEncapMode: "disable"}, nil)).NotTo(HaveOccurred())
+		})
+		It("set the link of a Broadcom PF down while the number of VFs changes", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{
+					"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2"),
+					"/sys/bus/pci/devices/0000:d8:00.0/vendor":       []byte("0x14e4\n")},
+			})
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(true)
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().LinkSetDown(pfLinkMock).Return(nil),
+				netlinkLibMock.EXPECT().LinkSetUp(pfLinkMock).Return(nil),
+			)
+			Expect(s.(*sriov).createVFs(&sriovnetworkv1.Interface{Name: "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "4")
+		})
+		It("leave the link of a PF which was down untouched while the number of VFs changes", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(false)
+			Expect(s.(*sriov).createVFs(&sriovnetworkv1.Interface{Name: "enp216s0f0np0", PfLinkDownOnVfChange: true,
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "4")
+		})
+		It("restore the link of the PF when the number of VFs fails to change", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(true)
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().LinkSetDown(pfLinkMock).Return(nil),
+				netlinkLibMock.EXPECT().LinkSetUp(pfLinkMock).Return(nil),
+			)
+			err := s.(*sriov).createVFs(&sriovnetworkv1.Interface{Name: "enp216s0f0np0", PfLinkDownOnVfChange: true,
+				PciAddress: "0000:d8:00.0", NumVfs: 4, IncrementalVfs: true}, nil)
+			Expect(err).To(MatchError(ContainSubstring("driver rejected incremental VF creation")))
+		})
+		It("reconfigure when the eswitch offload modes don't match", func() {
+			config := types.EswitchConfig{Mode: "switchdev", InlineMode: "link", EncapMode: "enable"}
+			Expect(eswitchAttrsMatch(config, &sriovnetworkv1.Interface{
+				EswitchMode: "switchdev", EswitchInlineMode: "link"})).To(BeTrue())
+			Expect(eswitchAttrsMatch(config, &sriovnetworkv1.Interface{
+				EswitchMode: "switchdev", EswitchInlineMode: "transport"})).To(BeFalse())
+			Expect(eswitchAttrsMatch(config, &sriovnetworkv1.Interface{
+				EswitchMode: "switchdev", EswitchEncapMode: "disable"})).To(BeFalse())
+			Expect(eswitchAttrsMatch(config, &sriovnetworkv1.Interface{})).To(BeFalse())
+		})
+	})
+
+	Context("checkExternallyManagedPF", func() {
+		BeforeEach(func() {
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(2)
+		})
+		It("fail - fewer VFs than requested", func() {
+			Expect(s.(*sriov).checkExternallyManagedPF(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				NumVfs: 4, ExternallyManaged: true})).To(MatchError(ContainSubstring("number of request virtual functions 4")))
+		})
+		It("configure the available VFs in best effort mode", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0").Return(1500)
+			Expect(s.(*sriov).checkExternallyManagedPF(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				NumVfs: 4, ExternallyManaged: true, ExternallyManagedBestEffort: true})).NotTo(HaveOccurred())
+		})
+		It("fail - eswitch mode unknown", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			Expect(s.(*sriov).checkExternallyManagedPF(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0",
+				NumVfs: 2, ExternallyManaged: true})).To(MatchError("failed to get the devlink device of device 0000:d8:00.0: test"))
+		})
+	})
+
+	Context("GetNicSriovMode", func() {
+		It("devlink returns info", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "switchdev"}}},
+				nil)
+			mode := s.GetNicSriovMode("0000:d8:00.0")
+			Expect(mode).To(Equal("switchdev"))
+		})
+		It("devlink returns error", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			mode := s.GetNicSriovMode("0000:d8:00.0")
+
+			Expect(mode).To(Equal("legacy"))
+		})
+		It("devlink not supported - fail to get name", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			mode := s.GetNicSriovMode("0000:d8:00.0")
+			Expect(mode).To(Equal("legacy"))
+		})
+	})
+
+	Context("GetNicEswitchConfig", func() {
+		It("should read the eswitch config in one query", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{
+					Mode: "switchdev", InlineMode: "transport", EncapMode: "enable"}}}, nil)
+			config, err := s.GetNicEswitchConfig("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(types.EswitchConfig{Mode: "switchdev", InlineMode: "transport", EncapMode: "enable"}))
+		})
+		It("should return an empty config when the device doesn't support devlink", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			config, err := s.GetNicEswitchConfig("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(BeZero())
+		})
+		It("should fail when the config can't be read", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			_, err := s.GetNicEswitchConfig("0000:d8:00.0")
+			Expect(err).To(MatchError("failed to get the devlink device of device 0000:d8:00.0: test"))
+		})
+	})
+
+	Context("getDevlinkDevice", func() {
+		BeforeEach(func() {
+			origInterval, origCount := vars.DevlinkRetryInterval, vars.DevlinkRetryCount
+			vars.DevlinkRetryInterval, vars.DevlinkRetryCount = time.Millisecond, 2
+			DeferCleanup(func() {
+				vars.DevlinkRetryInterval, vars.DevlinkRetryCount = origInterval, origCount
+			})
+		})
+		It("should retry while the devlink device is busy", func() {
+			testDev := &netlink.DevlinkDevice{}
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.EBUSY),
+				netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.EAGAIN),
+				netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(testDev, nil),
+			)
+			dev, err := s.(*sriov).getDevlinkDevice("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dev).To(BeIdenticalTo(testDev))
+		})
+		It("should give up when the devlink device stays busy", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.EBUSY).Times(3)
+			_, err := s.(*sriov).getDevlinkDevice("0000:d8:00.0")
+			Expect(err).To(MatchError(syscall.EBUSY))
+			Expect(err).To(MatchError(ContainSubstring("failed to get the devlink device of device 0000:d8:00.0")))
+		})
+		It("should report the devices without devlink", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			_, err := s.(*sriov).getDevlinkDevice("0000:d8:00.0")
+			notSupportedErr := &types.DevlinkNotSupportedError{}
+			Expect(errors.As(err, &notSupportedErr)).To(BeTrue())
+			Expect(err).To(MatchError(syscall.ENODEV))
+		})
+		It("should distinguish the devices without devlink from an unknown mode", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			mode, err := s.(*sriov).getNicEswitchMode("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mode).To(Equal("legacy"))
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			mode, err = s.(*sriov).getNicEswitchMode("0000:d8:00.0")
+			Expect(err).To(MatchError(testError))
+			Expect(mode).To(BeEmpty())
+		})
+	})
+
+	Context("SetNicSriovMode", func() {
+		It("set", func() {
+			testDev := &netlink.DevlinkDevice{}
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{}, nil)
+			netlinkLibMock.EXPECT().DevLinkSetEswitchMode(testDev, "legacy").Return(nil)
+			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).NotTo(HaveOccurred())
+		})
+		It("already in the requested mode", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "switchdev"}}}, nil)
+			Expect(s.SetNicSriovMode("0000:d8:00.0", "switchdev")).NotTo(HaveOccurred())
+		})
+		It("fail to get dev", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).To(MatchError(testError))
+		})
+		It("fail to set mode", func() {
+			testDev := &netlink.DevlinkDevice{}
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{}, nil)
+			netlinkLibMock.EXPECT().DevLinkSetEswitchMode(testDev, "legacy").Return(testError)
+			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).To(MatchError(testError))
+		})
+	})
+
+	Context("SetNicEswitchInlineMode", func() {
+		It("set", func() {
+			testDev := &netlink.DevlinkDevice{}
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(testDev, nil)
+			netlinkLibMock.EXPECT().DevLinkSetEswitchInlineMode(testDev, "network").Return(nil)
+			Expect(s.SetNicEswitchInlineMode("0000:d8:00.0", "network")).NotTo(HaveOccurred())
+		})
+		It("devlink not supported", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			Expect(s.SetNicEswitchInlineMode("0000:d8:00.0", "network")).NotTo(HaveOccurred())
+		})
+		It("fail to set mode", func() {
+			testDev := &netlink.DevlinkDevice{}
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(testDev, nil)
+			netlinkLibMock.EXPECT().DevLinkSetEswitchInlineMode(testDev, "network").Return(testError)
+			Expect(s.SetNicEswitchInlineMode("0000:d8:00.0", "network")).To(MatchError(testError))
+		})
+	})
+
+	Context("SetNicEswitchEncapMode", func() {
+		It("set", func() {
+			testDev := &netlink.DevlinkDevice{}
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(testDev, nil)
+			netlinkLibMock.EXPECT().DevLinkSetEswitchEncapMode(testDev, true).Return(nil)
+			Expect(s.SetNicEswitchEncapMode("0000:d8:00.0", true)).NotTo(HaveOccurred())
+		})
+		It("devlink not supported", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
+			Expect(s.SetNicEswitchEncapMode("0000:d8:00.0", true)).NotTo(HaveOccurred())
+		})
+		It("fail to get dev", func() {
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
+			Expect(s.SetNicEswitchEncapMode("0000:d8:00.0", true)).To(MatchError(testError))
+		})
+	})
+
+	Context("setVfTxRate", func() {
+		It("set both rates", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkSetVfRate(pfLinkMock, 1, 0, 1000).Return(nil)
+			Expect(s.(*sriov).setVfTxRate(&sriovnetworkv1.Interface{}, pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{MinTxRate: pointer.Int(0), MaxTxRate: pointer.Int(1000)})).NotTo(HaveOccurred())
+		})
+		It("keep the rate not set in the group", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{
+				Vfs: []netlink.VfInfo{{ID: 0}, {ID: 1, MinTxRate: 10, MaxTxRate: 500}}})
+			netlinkLibMock.EXPECT().LinkSetVfRate(pfLinkMock, 1, 100, 500).Return(nil)
+			Expect(s.(*sriov).setVfTxRate(&sriovnetworkv1.Interface{}, pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{MinTxRate: pointer.Int(100)})).NotTo(HaveOccurred())
+		})
+		It("min rate greater than max rate", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			Expect(s.(*sriov).setVfTxRate(&sriovnetworkv1.Interface{}, pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{MinTxRate: pointer.Int(1000), MaxTxRate: pointer.Int(100)})).To(HaveOccurred())
+		})
+		It("not managed", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			Expect(s.(*sriov).setVfTxRate(&sriovnetworkv1.Interface{}, pfLinkMock, 1, &sriovnetworkv1.VfGroup{})).NotTo(HaveOccurred())
+		})
+		It("switchdev", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 1).Return("enp216s0f0_1", nil)
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 1, NetdeviceName: "enp216s0f0_1"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateSet("pci", "0000:d8:00.0", uint32(1), uint64(12500000), uint64(125000000)).Return(nil)
+			Expect(s.(*sriov).setVfTxRate(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", EswitchMode: "switchdev"}, pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{MinTxRate: pointer.Int(100), MaxTxRate: pointer.Int(1000)})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("SetDevlinkVfRate", func() {
+		BeforeEach(func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 2).Return("enp216s0f0_2", nil)
+		})
+		It("set rate of the VF port", func() {
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 65535, NetdeviceName: "enp216s0f0np0"},
+				{BusName: "pci", DeviceName: "0000:d8:00.1", PortIndex: 3, NetdeviceName: "enp216s0f1_2"},
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 3, NetdeviceName: "enp216s0f0_2"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateSet("pci", "0000:d8:00.0", uint32(3), uint64(0), uint64(250000000)).Return(nil)
+			Expect(s.SetDevlinkVfRate("0000:d8:00.0", 2, 0, 2000)).NotTo(HaveOccurred())
+		})
+		It("port not found", func() {
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 65535, NetdeviceName: "enp216s0f0np0"}}, nil)
+			Expect(s.SetDevlinkVfRate("0000:d8:00.0", 2, 0, 2000)).To(HaveOccurred())
+		})
+		It("fail to set rate", func() {
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 3, NetdeviceName: "enp216s0f0_2"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateSet("pci", "0000:d8:00.0", uint32(3), uint64(0), uint64(250000000)).Return(testError)
+			Expect(s.SetDevlinkVfRate("0000:d8:00.0", 2, 0, 2000)).To(MatchError(testError))
+		})
+	})
+
+	Context("setVfVlan", func() {
+		It("priority tag without VLAN", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 1, 0, 5, 0x8100).Return(nil)
+			Expect(s.(*sriov).setVfVlan(pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{Qos: &sriovnetworkv1.VfQos{Priority: pointer.Int(5)}})).NotTo(HaveOccurred())
+		})
+		It("priority takes precedence over the VLAN QoS", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 1, 100, 3, 0x8100).Return(nil)
+			Expect(s.(*sriov).setVfVlan(pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{VlanID: pointer.Int(100), VlanQoS: 1, Qos: &sriovnetworkv1.VfQos{Priority: pointer.Int(3)}})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("setVfQosGroup", func() {
+		It("switchdev", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 1).Return("enp216s0f0_1", nil)
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 2, NetdeviceName: "enp216s0f0_1"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkRateNodeNew("pci", "0000:d8:00.0", "rt").Return(syscall.EEXIST)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateParentSet("pci", "0000:d8:00.0", uint32(2), "rt").Return(nil)
+			Expect(s.(*sriov).setVfQosGroup(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", EswitchMode: "switchdev"}, 1,
+				&sriovnetworkv1.VfGroup{Qos: &sriovnetworkv1.VfQos{Group: "rt"}})).NotTo(HaveOccurred())
+		})
+		It("fail to create the group", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 1).Return("enp216s0f0_1", nil)
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 2, NetdeviceName: "enp216s0f0_1"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkRateNodeNew("pci", "0000:d8:00.0", "rt").Return(testError)
+			Expect(s.(*sriov).setVfQosGroup(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", EswitchMode: "switchdev"}, 1,
+				&sriovnetworkv1.VfGroup{Qos: &sriovnetworkv1.VfQos{Group: "rt"}})).To(MatchError(testError))
+		})
+		It("legacy mode is skipped", func() {
+			Expect(s.(*sriov).setVfQosGroup(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0"}, 1,
+				&sriovnetworkv1.VfGroup{Qos: &sriovnetworkv1.VfQos{Group: "rt"}})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("configPfDcb", func() {
+		var iface *sriovnetworkv1.Interface
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{
+				PciAddress:      "0000:d8:00.0",
+				PfcEnabled:      []int{3},
+				PriorityToTcMap: []int{0, 0, 0, 1},
+			}
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+		})
+		It("should set the PFC priorities and the priority to traffic class map", func() {
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(&netlinkPkg.DcbIeee{EtsCap: 8}, nil)
+			netlinkLibMock.EXPECT().DcbIeeeSet("enp216s0f0np0", &netlinkPkg.DcbIeee{
+				EtsCap: 8, PfcEnabled: 0b00001000, PrioTc: [8]uint8{0, 0, 0, 1, 0, 0, 0, 0}}).Return(nil)
+			Expect(s.(*sriov).configPfDcb(iface)).NotTo(HaveOccurred())
+		})
+		It("should not set the DCB configuration when it is already applied", func() {
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(&netlinkPkg.DcbIeee{
+				EtsCap: 8, PfcEnabled: 0b00001000, PrioTc: [8]uint8{0, 0, 0, 1, 0, 0, 0, 0}}, nil)
+			Expect(s.(*sriov).configPfDcb(iface)).NotTo(HaveOccurred())
+		})
+		It("should fail when the device doesn't support DCB", func() {
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(nil, syscall.EOPNOTSUPP)
+			Expect(s.(*sriov).configPfDcb(iface)).To(MatchError(ContainSubstring("doesn't support DCB")))
+		})
+		It("should fail when a traffic class is not supported by the device", func() {
+			netlinkLibMock.EXPECT().DcbIeeeGet("enp216s0f0np0").Return(&netlinkPkg.DcbIeee{EtsCap: 1}, nil)
+			Expect(s.(*sriov).configPfDcb(iface)).To(MatchError(ContainSubstring("exceeds the 1 traffic classes")))
+		})
+	})
+
+	Context("configPfCombinedChannels", func() {
+		var iface *sriovnetworkv1.Interface
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", CombinedChannels: 16}
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+		})
+		It("should set the combined channels", func() {
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(8, 63, nil)
+			hostMock.EXPECT().SetNetdevCombinedChannels("enp216s0f0np0", 16).Return(nil)
+			Expect(s.(*sriov).configPfCombinedChannels(iface)).NotTo(HaveOccurred())
+		})
+		It("should not set the combined channels when they are already applied", func() {
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(16, 63, nil)
+			Expect(s.(*sriov).configPfCombinedChannels(iface)).NotTo(HaveOccurred())
+		})
+		It("should fail when the device maximum is exceeded", func() {
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(8, 10, nil)
+			Expect(s.(*sriov).configPfCombinedChannels(iface)).To(
+				MatchError(ContainSubstring("exceed the device maximum of 10")))
+		})
+	})
+
+	Context("configPfRingSizes", func() {
+		var iface *sriovnetworkv1.Interface
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", RingRx: 4096, RingTx: 2048}
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+		})
+		It("should set the ring sizes", func() {
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(
+				&types.RingSizes{Rx: 1024, Tx: 1024, MaxRx: 8192, MaxTx: 8192}, nil)
+			hostMock.EXPECT().SetNetdevRingSizes("enp216s0f0np0", 4096, 2048).Return(nil)
+			Expect(s.(*sriov).configPfRingSizes(iface)).NotTo(HaveOccurred())
+		})
+		It("should not set the ring sizes when they are already applied", func() {
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(
+				&types.RingSizes{Rx: 4096, Tx: 2048, MaxRx: 8192, MaxTx: 8192}, nil)
+			Expect(s.(*sriov).configPfRingSizes(iface)).NotTo(HaveOccurred())
+		})
+		It("should fail when the device maximum is exceeded", func() {
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(
+				&types.RingSizes{Rx: 1024, Tx: 1024, MaxRx: 2048, MaxTx: 2048}, nil)
+			Expect(s.(*sriov).configPfRingSizes(iface)).To(
+				MatchError(ContainSubstring("requested RX ring size 4096 for device 0000:d8:00.0 exceeds the device maximum of 2048")))
+		})
+		It("should clamp to the device maximum when requested", func() {
+			iface.RingClamp = true
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(
+				&types.RingSizes{Rx: 1024, Tx: 1024, MaxRx: 2048, MaxTx: 2048}, nil)
+			hostMock.EXPECT().SetNetdevRingSizes("enp216s0f0np0", 2048, 2048).Return(nil)
+			Expect(s.(*sriov).configPfRingSizes(iface)).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("checkVfRepresentors", func() {
+		var (
+			iface   *sriovnetworkv1.Interface
+			vfAddrs []string
+		)
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 3,
+				EswitchMode: sriovnetworkv1.ESwithModeSwitchDev}
+			vfAddrs = []string{"0000:d8:00.2", "0000:d8:00.3", "0000:d8:00.4"}
+		})
+		It("should succeed when all the representors are present", func() {
+			for vfID, addr := range vfAddrs {
+				dputilsLibMock.EXPECT().GetVFID(addr).Return(vfID, nil)
+				sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", vfID).Return(fmt.Sprintf("enp216s0f0np0_%d", vfID), nil)
+			}
+			Expect(s.(*sriov).checkVfRepresentors(iface, vfAddrs)).NotTo(HaveOccurred())
+		})
+		It("should return a retryable error when representors are missing", func() {
+			for vfID, addr := range vfAddrs {
+				dputilsLibMock.EXPECT().GetVFID(addr).Return(vfID, nil)
+			}
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 0).Return("enp216s0f0np0_0", nil)
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 1).Return("", testError)
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 2).Return("", testError)
+			err := s.(*sriov).checkVfRepresentors(iface, vfAddrs)
+			notReadyErr := &types.VfRepresentorsNotReadyError{}
+			Expect(errors.As(err, &notReadyErr)).To(BeTrue())
+			Expect(notReadyErr.MissingVfs).To(Equal([]int{1, 2}))
+			Expect(notReadyErr.Temporary()).To(BeTrue())
+			Expect(isPermanentConfigError(err)).To(BeFalse())
+		})
+		It("should check only the existing VFs of an externally managed PF in best effort mode", func() {
+			iface.ExternallyManaged = true
+			iface.ExternallyManagedBestEffort = true
+			// only the first VF was created externally
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 0).Return("enp216s0f0np0_0", nil)
+			Expect(s.(*sriov).checkVfRepresentors(iface, vfAddrs[:1])).NotTo(HaveOccurred())
+		})
+		It("should return the error when the VF id is not found", func() {
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, testError)
+			Expect(s.(*sriov).checkVfRepresentors(iface, vfAddrs)).To(MatchError(testError))
+		})
+		It("should not check the representors of legacy PFs", func() {
+			iface.EswitchMode = sriovnetworkv1.ESwithModeLegacy
+			Expect(s.(*sriov).checkVfRepresentors(iface, vfAddrs)).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("setVfVlanTrunk", func() {
+		var iface *sriovnetworkv1.Interface
+		BeforeEach(func() {
+			iface = &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 2,
+				EswitchMode: sriovnetworkv1.ESwithModeSwitchDev}
+		})
+		// expectRepresentor expects the lookup of the representor of VF 1 and of its master link
+		expectRepresentor := func(master netlink.Link) *netlinkMockPkg.MockLink {
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 1).Return("enp216s0f0np0_1", nil)
+			repLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			repLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 10, MasterIndex: 20}).AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0_1").Return(repLinkMock, nil)
+			netlinkLibMock.EXPECT().LinkByIndex(20).Return(master, nil)
+			return repLinkMock
+		}
+		It("should program the trunk on the bridge port of the representor of a switchdev VF", func() {
+			repLinkMock := expectRepresentor(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 20}})
+			netlinkLibMock.EXPECT().BridgeVlanList().Return(map[int32][]*nl.BridgeVlanInfo{
+				10: {{Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED, Vid: 1}, {Vid: 100}, {Vid: 300}},
+				11: {{Vid: 101}}}, nil)
+			netlinkLibMock.EXPECT().BridgeVlanDel(repLinkMock, uint16(300), false, false, false, true).Return(nil)
+			netlinkLibMock.EXPECT().BridgeVlanAdd(repLinkMock, uint16(101), false, false, false, true).Return(nil)
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100-101"})).To(Succeed())
+		})
+		It("should require the representor to be a port of a Linux bridge", func() {
+			expectRepresentor(&netlink.GenericLink{LinkAttrs: netlink.LinkAttrs{Index: 20}, LinkType: "openvswitch"})
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100"})).To(MatchError(
+				"VLAN trunking of VF 1 of device 0000:d8:00.0 requires its representor enp216s0f0np0_1 to be a port of a Linux bridge"))
+		})
+		It("should reject QinQ in switchdev mode", func() {
+			group := &sriovnetworkv1.VfGroup{VlanID: pointer.Int(10), VlanProto: "802.1ad", VlanTrunk: "100"}
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, group)).To(MatchError(ContainSubstring("QinQ with a VLAN trunk is not supported in switchdev mode")))
+		})
+		It("should program the trunk through the out-of-tree vendor driver of a legacy PF", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0/sriov/1"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov/1/trunk": []byte("")},
+			})
+			iface.EswitchMode = sriovnetworkv1.ESwithModeLegacy
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("ice", nil)
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100-101"})).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov/1/trunk", "add 100-101")
+		})
+		It("should clear the trunk on the bridge port of the representor of a switchdev VF when unset", func() {
+			repLinkMock := expectRepresentor(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 20}})
+			netlinkLibMock.EXPECT().BridgeVlanList().Return(map[int32][]*nl.BridgeVlanInfo{
+				10: {{Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED, Vid: 1}, {Vid: 100}}}, nil)
+			netlinkLibMock.EXPECT().BridgeVlanDel(repLinkMock, uint16(100), false, false, false, true).Return(nil)
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{})).To(Succeed())
+		})
+		It("should skip the representor which isn't a port of a Linux bridge when unset", func() {
+			expectRepresentor(&netlink.GenericLink{LinkAttrs: netlink.LinkAttrs{Index: 20}, LinkType: "openvswitch"})
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{})).To(Succeed())
+		})
+		It("should clear the trunk through the out-of-tree vendor driver of a legacy PF when unset", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0/sriov/1"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov/1/trunk": []byte("100,102")},
+			})
+			iface.EswitchMode = sriovnetworkv1.ESwithModeLegacy
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("ice", nil)
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{})).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov/1/trunk", "rem 100,102")
+		})
+		It("should skip the legacy PF without a trunk when unset", func() {
+			iface.EswitchMode = sriovnetworkv1.ESwithModeLegacy
+			Expect(s.(*sriov).setVfVlanTrunk(iface, 1, &sriovnetworkv1.VfGroup{})).To(Succeed())
+		})
+	})
+
+	Context("setVfLinkState", func() {
+		It("set link state", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkSetVfState(pfLinkMock, 1, netlink.VF_LINK_STATE_DISABLE).Return(nil)
+			Expect(s.(*sriov).setVfLinkState(pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{LinkState: "disable"})).NotTo(HaveOccurred())
+		})
+		It("unsupported link state", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			Expect(s.(*sriov).setVfLinkState(pfLinkMock, 1,
+				&sriovnetworkv1.VfGroup{LinkState: "foo"})).To(HaveOccurred())
+		})
+		It("not managed", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			Expect(s.(*sriov).setVfLinkState(pfLinkMock, 1, &sriovnetworkv1.VfGroup{})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("getVfInfo", func() {
+		var pfLinkMock *netlinkMockPkg.MockLink
+		BeforeEach(func() {
+			pfLinkMock = netlinkMockPkg.NewMockLink(testCtrl)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{}).AnyTimes()
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.2").Return("mlx5_core", nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			hostMock.EXPECT().DiscoverVDPAType("0000:d8:00.2").Return("")
+			hostMock.EXPECT().GetNetDevNodeGUID("0000:d8:00.2").Return("")
+		})
+		vfLink := func(attrs *netlink.LinkAttrs) {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			vfLinkMock.EXPECT().Attrs().Return(attrs).AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+		}
+		It("carrier up", func() {
+			vfLink(&netlink.LinkAttrs{Flags: net.FlagUp, OperState: netlink.OperUp})
+			vf := s.(*sriov).getVfInfo("0000:d8:00.2", "enp216s0f0np0", "legacy", 0, pfLinkMock, nil)
+			Expect(vf.LinkAdminState).To(Equal("up"))
+			Expect(vf.Carrier).To(Equal("up"))
+		})
+		It("carrier down", func() {
+			vfLink(&netlink.LinkAttrs{Flags: net.FlagUp, OperState: netlink.OperLowerLayerDown})
+			vf := s.(*sriov).getVfInfo("0000:d8:00.2", "enp216s0f0np0", "legacy", 0, pfLinkMock, nil)
+			Expect(vf.LinkAdminState).To(Equal("up"))
+			Expect(vf.Carrier).To(Equal("down"))
+		})
+		It("admin down", func() {
+			vfLink(&netlink.LinkAttrs{OperState: netlink.OperDown})
+			vf := s.(*sriov).getVfInfo("0000:d8:00.2", "enp216s0f0np0", "legacy", 0, pfLinkMock, nil)
+			Expect(vf.LinkAdminState).To(Equal("down"))
+			Expect(vf.Carrier).To(Equal("down"))
+		})
+		It("no netdev", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("")
+			vf := s.(*sriov).getVfInfo("0000:d8:00.2", "enp216s0f0np0", "legacy", 0, pfLinkMock, nil)
+			Expect(vf.LinkAdminState).To(BeEmpty())
+			Expect(vf.Carrier).To(BeEmpty())
+		})
+	})
+
+	Context("getVfGUID", func() {
+		It("requested by the VF group", func() {
+			storeManagerMode.EXPECT().SaveVfGUID("0000:d8:00.0", 1, "00:11:22:33:44:55:66:77").Return(nil)
+			guid, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1,
+				&sriovnetworkv1.VfGroup{VfGUIDs: map[string]string{"1": "00:11:22:33:44:55:66:77"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid.String()).To(Equal("00:11:22:33:44:55:66:77"))
+		})
+		It("invalid GUID requested by the VF group", func() {
+			_, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1,
+				&sriovnetworkv1.VfGroup{VfGUIDs: map[string]string{"1": "00:11:22:33:44:55"}})
+			Expect(err).To(HaveOccurred())
+		})
+		It("deterministic GUID", func() {
+			group := &sriovnetworkv1.VfGroup{GUIDGeneration: sriovnetworkv1.GUIDGenerationDeterministic}
+			guid, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, group)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(HaveLen(8))
+			Expect(guid[0]).NotTo(BeElementOf(byte(0x00), byte(0xff)))
+			again, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, group)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(guid))
+			other, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 2, group)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other).NotTo(Equal(guid))
+
+			// a node with the same PCI layout generates other GUIDs
+			origNodeName := vars.NodeName
+			vars.NodeName = "other-node"
+			DeferCleanup(func() {
+				vars.NodeName = origNodeName
+			})
+			otherNode, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, group)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherNode).NotTo(Equal(guid))
+		})
+		It("reuse the persisted GUID", func() {
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", 1).Return("00:11:22:33:44:55:66:77", true, nil)
+			guid, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid.String()).To(Equal("00:11:22:33:44:55:66:77"))
+		})
+		It("generate and persist a new GUID", func() {
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", 1).Return("", false, nil)
+			var saved string
+			storeManagerMode.EXPECT().SaveVfGUID("0000:d8:00.0", 1, gomock.Any()).DoAndReturn(
+				func(_ string, _ int, guid string) error {
+					saved = guid
+					return nil
+				})
+			guid, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(HaveLen(8))
+			Expect(saved).To(Equal(guid.String()))
+		})
+		It("fail to persist the new GUID", func() {
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", 1).Return("", false, nil)
+			storeManagerMode.EXPECT().SaveVfGUID("0000:d8:00.0", 1, gomock.Any()).Return(testError)
+			_, err := s.(*sriov).getVfGUID(storeManagerMode, "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{})
+			Expect(err).To(MatchError(testError))
+		})
+	})
+
+	Context("setVfTxQueueLen", func() {
+		var vfLinkMock *netlinkMockPkg.MockLink
+		vfLink := func(attrs *netlink.LinkAttrs) {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			vfLinkMock = netlinkMockPkg.NewMockLink(testCtrl)
+			vfLinkMock.EXPECT().Attrs().Return(attrs).AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+		}
+		It("set the requested length", func() {
+			vfLink(&netlink.LinkAttrs{EncapType: "ether", TxQLen: 1000})
+			netlinkLibMock.EXPECT().LinkSetTxQLen(vfLinkMock, 5000).Return(nil)
+			Expect(s.(*sriov).setVfTxQueueLen("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{TxQueueLen: 5000})).NotTo(HaveOccurred())
+		})
+		It("requested length already set", func() {
+			vfLink(&netlink.LinkAttrs{EncapType: "ether", TxQLen: 5000})
+			Expect(s.(*sriov).setVfTxQueueLen("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{TxQueueLen: 5000})).NotTo(HaveOccurred())
+		})
+		It("leave the length untouched when unset", func() {
+			Expect(s.(*sriov).setVfTxQueueLen("0000:d8:00.2", &sriovnetworkv1.VfGroup{})).NotTo(HaveOccurred())
+		})
+		It("restore the default length on reset", func() {
+			vfLink(&netlink.LinkAttrs{EncapType: "ether", TxQLen: 5000})
+			netlinkLibMock.EXPECT().LinkSetTxQLen(vfLinkMock, 1000).Return(nil)
+			Expect(s.(*sriov).resetVfTxQueueLen("0000:d8:00.2")).NotTo(HaveOccurred())
+		})
+		It("keep the length of InfiniBand VFs on reset", func() {
+			vfLink(&netlink.LinkAttrs{EncapType: "infiniband", TxQLen: 256})
+			Expect(s.(*sriov).resetVfTxQueueLen("0000:d8:00.2")).NotTo(HaveOccurred())
+		})
+		It("no netdev", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").Times(2)
+			Expect(s.(*sriov).resetVfTxQueueLen("0000:d8:00.2")).NotTo(HaveOccurred())
+			Expect(s.(*sriov).setVfTxQueueLen("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{TxQueueLen: 5000})).To(HaveOccurred())
+		})
+		It("invalid length", func() {
+			Expect(s.(*sriov).setVfTxQueueLen("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{TxQueueLen: -1})).To(HaveOccurred())
+		})
+	})
+
+	Context("setVfHostNamespace", func() {
+		It("move to the requested namespace", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+			netlinkLibMock.EXPECT().LinkSetNsByName(vfLinkMock, "infra").Return(nil)
+			Expect(s.(*sriov).setVfHostNamespace("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{HostNamespace: "infra"})).NotTo(HaveOccurred())
+		})
+		It("already moved", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("")
+			Expect(s.(*sriov).setVfHostNamespace("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{HostNamespace: "infra"})).NotTo(HaveOccurred())
+		})
+		It("namespace doesn't exist", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil)
+			netlinkLibMock.EXPECT().LinkSetNsByName(vfLinkMock, "infra").Return(testError)
+			Expect(s.(*sriov).setVfHostNamespace("0000:d8:00.2",
+				&sriovnetworkv1.VfGroup{HostNamespace: "infra"})).To(MatchError(testError))
+		})
+		It("left in the default namespace when not set", func() {
+			Expect(s.(*sriov).setVfHostNamespace("0000:d8:00.2", &sriovnetworkv1.VfGroup{})).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("configSriovVFDevicesInParallel", func() {
+		It("should return the first failure after completing the VFs in progress", func() {
+			origConcurrency := vars.VfConfigConcurrency
+			vars.VfConfigConcurrency = 2
+			DeferCleanup(func() {
+				vars.VfConfigConcurrency = origConcurrency
+			})
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver(gomock.Any()).Return(true, "vfio-pci").Times(2)
+			// make sure the second VF is in progress when the first one fails
+			inProgress := make(chan struct{})
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").DoAndReturn(func(string) (int, error) {
+				<-inProgress
+				return 0, testError
+			})
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.3").DoAndReturn(func(string) (int, error) {
+				close(inProgress)
+				return 1, nil
+			})
+			Expect(s.(*sriov).configSriovVFDevicesInParallel(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0"},
+				pfLinkMock, []string{"0000:d8:00.2", "0000:d8:00.3"}, nil, nil)).To(MatchError(testError))
+		})
+	})
+
+	Context("configSriovInterfacesInParallel", func() {
+		It("should return the configuration error when the reset of the device fails", func() {
+			origConcurrency := vars.NicConfigConcurrency
+			vars.NicConfigConcurrency = 2
+			DeferCleanup(func() {
+				vars.NicConfigConcurrency = origConcurrency
+			})
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("")
+			storeManagerMode.EXPECT().LoadPfOriginalMtu("0000:d8:00.0").Return(0, false, nil)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.0", 1500).Return(testError)
+
+			err := s.(*sriov).configSriovInterfacesInParallel(context.Background(), storeManagerMode, []interfaceToConfigure{{
+				iface:       sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", NumVfs: 2},
+				ifaceStatus: sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.0", LinkType: consts.LinkTypeETH},
+			}}, false)
+			Expect(err).To(MatchError(ContainSubstring("no netdev found for device 0000:d8:00.0")))
+		})
+	})
+
+	Context("forEachDeviceInParallel", func() {
+		BeforeEach(func() {
+			origConcurrency := vars.NicConfigConcurrency
+			vars.NicConfigConcurrency = 2
+			DeferCleanup(func() {
+				vars.NicConfigConcurrency = origConcurrency
+			})
+		})
+		It("should bound the devices in progress and serialize the same device", func() {
+			pciAddrs := []string{"0000:d8:00.0", "0000:d8:00.1", "0000:3b:00.0", "0000:d8:00.0", "0000:3b:00.1"}
+			var (
+				mu          sync.Mutex
+				inProgress  int
+				maxProgress int
+				busy        = map[string]bool{}
+				done        []int
+			)
+			Expect(forEachDeviceInParallel(pciAddrs, func(i int) error {
+				mu.Lock()
+				Expect(busy[pciAddrs[i]]).To(BeFalse(), "device %s configured concurrently", pciAddrs[i])
+				busy[pciAddrs[i]] = true
+				inProgress++
+				maxProgress = max(maxProgress, inProgress)
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				busy[pciAddrs[i]] = false
+				inProgress--
+				done = append(done, i)
+				mu.Unlock()
+				return nil
+			})).NotTo(HaveOccurred())
+			Expect(done).To(ConsistOf(0, 1, 2, 3, 4))
+			Expect(maxProgress).To(Equal(2))
+		})
+		It("should configure all the devices and join the errors", func() {
+			pciAddrs := []string{"0000:d8:00.0", "0000:d8:00.1", "0000:3b:00.0"}
+			var configured sync.Map
+			err := forEachDeviceInParallel(pciAddrs, func(i int) error {
+				configured.Store(pciAddrs[i], true)
+				if i != 1 {
+					return fmt.Errorf("device %s failed", pciAddrs[i])
+				}
+				return nil
+			})
+			Expect(err).To(MatchError(ContainSubstring("device 0000:d8:00.0 failed")))
+			Expect(err).To(MatchError(ContainSubstring("device 0000:3b:00.0 failed")))
+			for _, addr := range pciAddrs {
+				_, ok := configured.Load(addr)
+				Expect(ok).To(BeTrue())
+			}
+		})
+	})
+
+	Context("configRollback", func() {
+		It("should unwind the actions in reverse order and continue after a failure", func() {
+			rb := &configRollback{}
+			var order []int
+			rb.push(func() error { order = append(order, 1); return nil }, "first")
+			rb.push(func() error { order = append(order, 2); return testError }, "second")
+			rb.push(func() error { order = append(order, 3); return nil }, "third")
+			Expect(rb.unwind()).To(Equal(1))
+			Expect(order).To(Equal([]int{3, 2, 1}))
+			// the actions are executed only once
+			Expect(rb.unwind()).To(Equal(0))
+			Expect(order).To(HaveLen(3))
+		})
+		It("should be a no-op when nil", func() {
+			var rb *configRollback
+			rb.push(func() error { return testError }, "action")
+			Expect(rb.unwind()).To(Equal(0))
+		})
+		It("should restore the VF driver when the DPDK driver bind fails", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "")
+			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "")
+			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", false).Return(nil)
+			hostMock.EXPECT().BindDpdkDriver("0000:d8:00.2", "vfio-pci").Return(testError)
+
+			rb := &configRollback{}
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-1", DeviceType: "vfio-pci"}},
+			}, pfLinkMock, "0000:d8:00.2", nil, rb)).To(MatchError(testError))
+
+			hostMock.EXPECT().Unbind("0000:d8:00.2").Return(nil)
+			Expect(rb.unwind()).To(Equal(0))
+		})
+		It("should restore the VF mtu when a later step fails", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf")
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "")
+			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", false).Return(nil)
+			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(1500)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.2", 9000).Return(nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").Times(2)
+
+			rb := &configRollback{}
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-1", DeviceType: "netdevice", Mtu: 9000, TxQueueLen: 5000}},
+			}, pfLinkMock, "0000:d8:00.2", nil, rb)).To(HaveOccurred())
+
+			gomock.InOrder(
+				hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.2", 1500).Return(nil),
+				hostMock.EXPECT().BindDriverByBusAndDevice("pci", "0000:d8:00.2", "iavf").Return(nil),
+			)
+			Expect(rb.unwind()).To(Equal(0))
+		})
+	})
+
+	Context("VF MAC", func() {
+		It("should program the requested MAC before binding the VF to the DPDK driver", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf")
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(pfLinkMock, 0, net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}).Return(nil),
+				hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf"),
+				hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", false).Return(nil),
+				hostMock.EXPECT().BindDpdkDriver("0000:d8:00.2", "vfio-pci").Return(nil),
+			)
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				LinkType:   "ETH",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", DeviceType: "vfio-pci", Mac: "02:00:00:00:00:10"}},
+			}, pfLinkMock, "0000:d8:00.2", nil, nil)).To(Succeed())
+		})
+		It("should program the requested MAC of a VF already bound to the DPDK driver", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "vfio-pci").Times(2)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(pfLinkMock, 0, net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}).Return(nil)
+			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", false).Return(nil)
+			hostMock.EXPECT().BindDpdkDriver("0000:d8:00.2", "vfio-pci").Return(nil)
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", DeviceType: "vfio-pci", Mac: "02:00:00:00:00:10"}},
+			}, pfLinkMock, "0000:d8:00.2", nil, nil)).To(Succeed())
+		})
+		It("should fail when the MAC can't be programmed", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf")
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(pfLinkMock, 0, gomock.Any()).Return(testError)
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", DeviceType: "vfio-pci", Mac: "02:00:00:00:00:10"}},
+			}, pfLinkMock, "0000:d8:00.2", nil, nil)).To(MatchError(testError))
+		})
+	})
+
+	Context("VF partition key", func() {
+		It("should map the partition key once the VF is bound again after its GUID is set", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0/infiniband/mlx4_0",
+					"/sys/class/infiniband/mlx4_0/ports/1/pkeys",
+					"/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx",
+				},
+				Files: map[string][]byte{
+					"/sys/class/infiniband/mlx4_0/ports/1/pkeys/0":                     []byte("0xffff"),
+					"/sys/class/infiniband/mlx4_0/ports/1/pkeys/1":                     []byte("0x8001"),
+					"/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx/0": []byte("0"),
+				},
+			})
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			guid, _ := net.ParseMAC("00:11:22:33:44:55:66:77")
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "mlx4_core").Times(2)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil).Times(2)
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", 0).Return(guid.String(), true, nil)
+			gomock.InOrder(
+				netlinkLibMock.EXPECT().LinkSetVfNodeGUID(pfLinkMock, 0, guid).Return(nil),
+				netlinkLibMock.EXPECT().LinkSetVfPortGUID(pfLinkMock, 0, guid).Return(nil),
+				hostMock.EXPECT().Unbind("0000:d8:00.2").Return(nil),
+				hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", true).Return(nil),
+				hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").DoAndReturn(func(string) error {
+					helpers.GinkgoAssertFileContentsEquals("/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx/0", "0")
+					return nil
+				}),
+			)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("").AnyTimes()
+
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				LinkType:   "IB",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", DeviceType: "netdevice", IsRdma: true, IbPkey: "0x8001"}},
+			}, pfLinkMock, "0000:d8:00.2", nil, nil)).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals("/sys/class/infiniband/mlx4_0/iov/0000:d8:00.2/ports/1/pkey_idx/0", "1")
+		})
+	})
+
+	Context("mtu checks", func() {
+		It("should fail before changing the PF when the mtu exceeds the device maximum", func() {
+			hostMock.EXPECT().MaxMTU("enp216s0f0np0").Return(9000, nil)
+			err := s.(*sriov).configSriovDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     2,
+				Mtu:        9216,
+			}, false)
+			mtuErr := &types.MtuExceedsMaxError{}
+			Expect(errors.As(err, &mtuErr)).To(BeTrue())
+			Expect(err).To(MatchError("requested MTU 9216 for device 0000:d8:00.0 exceeds max 9000"))
+		})
+		It("should skip the check when the maximum mtu is unknown", func() {
+			hostMock.EXPECT().MaxMTU("enp216s0f0np0").Return(0, nil)
+			Expect(s.(*sriov).checkMaxMtu("enp216s0f0np0", "0000:d8:00.0", 9216)).To(Succeed())
+			hostMock.EXPECT().MaxMTU("enp216s0f0np0").Return(0, testError)
+			Expect(s.(*sriov).checkMaxMtu("enp216s0f0np0", "0000:d8:00.0", 9216)).To(Succeed())
+		})
+		It("should fail when the VF mtu exceeds the VF maximum", func() {
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "iavf")
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
+			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "")
+			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", false).Return(nil)
+			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0")
+			hostMock.EXPECT().MaxMTU("enp216s0f0v0").Return(9000, nil)
+			Expect(s.(*sriov).configSriovVFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-1", DeviceType: "netdevice", Mtu: 9216}},
+			}, pfLinkMock, "0000:d8:00.2", nil, nil)).To(MatchError(ContainSubstring("exceeds max 9000")))
+		})
+		It("should only read the PF mtu when a VF group requests more than the PF mtu in the spec", func() {
+			s.(*sriov).warnVfMtuExceedsPfMtu(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				Mtu:        9000,
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-1", Mtu: 9000}},
+			})
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0").Return(1500)
+			s.(*sriov).warnVfMtuExceedsPfMtu(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", Mtu: 9000}, {VfRange: "1-1", Mtu: 2000}},
+			})
+		})
+	})
+
+	Context("resolvePfName", func() {
+		It("should keep the name of the spec", func() {
+			iface := &sriovnetworkv1.Interface{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0"}
+			Expect(s.(*sriov).resolvePfName(iface)).To(Equal("enp216s0f0np0"))
+		})
+		It("should resolve the empty name from the PCI address without changing the spec", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			iface := &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0"}
+			Expect(s.(*sriov).resolvePfName(iface)).To(Equal("enp216s0f0np0"))
+			Expect(iface.Name).To(BeEmpty())
+		})
+		It("should fail before changing the PF when it has no netdev", func() {
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("")
+			err := s.(*sriov).configSriovDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     2,
+			}, false)
+			Expect(err).To(MatchError("no netdev found for device 0000:d8:00.0, the PF must be bound to its kernel driver"))
+		})
+	})
+
+	Context("configSriovVFDevices bind stagger", func() {
+		const staggerDelay = 100 * time.Millisecond
+		var (
+			bindOrder []string
+			bindTimes []time.Time
+			bound     map[string]bool
+			mu        sync.Mutex
+		)
+		BeforeEach(func() {
+			origDelay := vars.VfBindStaggerDelay
+			DeferCleanup(func() {
+				vars.VfBindStaggerDelay = origDelay
+			})
+			bindOrder = nil
+			bindTimes = nil
+			bound = map[string]bool{}
+			vfIDs := map[string]int{"0000:d8:01.4": 10, "0000:d8:00.2": 0, "0000:d8:00.3": 1}
+			// VF addresses are returned in virtfnX lexical order
+			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2", "0000:d8:01.4", "0000:d8:00.3"}, nil)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(netlinkMockPkg.NewMockLink(testCtrl), nil)
+			dputilsLibMock.EXPECT().GetVFID(gomock.Any()).DoAndReturn(func(addr string) (int, error) {
+				return vfIDs[addr], nil
+			}).AnyTimes()
+			hostMock.EXPECT().HasDriver(gomock.Any()).DoAndReturn(func(addr string) (bool, string) {
+				mu.Lock()
+				defer mu.Unlock()
+				if bound[addr] {
+					return true, "mlx5_core"
+				}
+				return false, ""
+			}).AnyTimes()
+			hostMock.EXPECT().BindDefaultDriver(gomock.Any()).DoAndReturn(func(addr string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				bound[addr] = true
+				bindOrder = append(bindOrder, addr)
+				bindTimes = append(bindTimes, time.Now())
+				return nil
+			}).AnyTimes()
+		})
+		It("should bind the VFs in index order with a delay when enabled", func() {
+			vars.VfBindStaggerDelay = staggerDelay
+			Expect(s.(*sriov).configSriovVFDevices(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", NumVfs: 3}, nil)).NotTo(HaveOccurred())
+			Expect(bindOrder).To(Equal([]string{"0000:d8:00.2", "0000:d8:00.3", "0000:d8:01.4"}))
+			for i := 1; i < len(bindTimes); i++ {
+				Expect(bindTimes[i].Sub(bindTimes[i-1])).To(BeNumerically(">=", staggerDelay))
+			}
+		})
+		It("should bind the VFs back-to-back when disabled", func() {
+			vars.VfBindStaggerDelay = 0
+			Expect(s.(*sriov).configSriovVFDevices(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", NumVfs: 3}, nil)).NotTo(HaveOccurred())
+			Expect(bindOrder).To(ConsistOf("0000:d8:00.2", "0000:d8:00.3", "0000:d8:01.4"))
+			Expect(bindTimes[len(bindTimes)-1].Sub(bindTimes[0])).To(BeNumerically("<", staggerDelay))
+		})
+		It("should stagger the binds of the RDMA VFs rebound after their GUID is set", func() {
+			vars.VfBindStaggerDelay = staggerDelay
+			guid, _ := net.ParseMAC("00:11:22:33:44:55:66:77")
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", gomock.Any()).Return(guid.String(), true, nil).Times(3)
+			netlinkLibMock.EXPECT().LinkSetVfNodeGUID(gomock.Any(), gomock.Any(), guid).Return(nil).Times(3)
+			netlinkLibMock.EXPECT().LinkSetVfPortGUID(gomock.Any(), gomock.Any(), guid).Return(nil).Times(3)
+			unbind := func(addr string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				bound[addr] = false
+				return nil
+			}
+			hostMock.EXPECT().Unbind(gomock.Any()).DoAndReturn(unbind).Times(3)
+			hostMock.EXPECT().UnbindDriverIfNeeded(gomock.Any(), true).DoAndReturn(func(addr string, _ bool) error {
+				return unbind(addr)
+			}).Times(3)
+			hostMock.EXPECT().TryGetInterfaceName(gomock.Any()).Return("").AnyTimes()
+
+			Expect(s.(*sriov).configSriovVFDevices(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", NumVfs: 3, LinkType: "IB",
+				VfGroups: []sriovnetworkv1.VfGroup{{VfRange: "0-10", DeviceType: "netdevice", IsRdma: true}}}, nil)).To(Succeed())
+			Expect(bindOrder).To(Equal([]string{"0000:d8:00.2", "0000:d8:00.3", "0000:d8:01.4",
+				"0000:d8:00.2", "0000:d8:00.3", "0000:d8:01.4"}))
+			for i := 1; i < len(bindTimes); i++ {
+				Expect(bindTimes[i].Sub(bindTimes[i-1])).To(BeNumerically(">=", staggerDelay))
+			}
+		})
+	})
+
+	Context("TotalVfs increase", func() {
+		It("save the TotalVfs of the rejected configuration", func() {
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(4)
+			storeManagerMode.EXPECT().SaveRejectedTotalVfs("0000:d8:00.0", 4).Return(nil)
+			err := s.(*sriov).configSriovPFDevice(context.Background(), storeManagerMode, &sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     8,
+			}, nil)
+			exceedErr := &types.NumVfsExceedTotalVfsError{}
+			Expect(errors.As(err, &exceedErr)).To(BeTrue())
+			Expect(exceedErr.TotalVfs).To(Equal(4))
+		})
+		Context("getConfigureAndReset", func() {
+			var (
+				iface       sriovnetworkv1.Interface
+				ifaceStatus sriovnetworkv1.InterfaceExt
+			)
+			BeforeEach(func() {
+				iface = sriovnetworkv1.Interface{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", NumVfs: 8}
+				ifaceStatus = sriovnetworkv1.InterfaceExt{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0",
+					NumVfs: 8, TotalVfs: 8, LinkAdminState: "up"}
+				storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&iface, true, nil)
+				storeManagerMode.EXPECT().SaveLastPfAppliedStatus(&iface).Return(nil)
+			})
+			It("retry the rejected configuration after TotalVfs increased", func() {
+				storeManagerMode.EXPECT().LoadRejectedTotalVfs("0000:d8:00.0").Return(4, true, nil)
+				storeManagerMode.EXPECT().RemoveRejectedTotalVfs("0000:d8:00.0").Return(nil)
+				toBeConfigured, _, err := s.(*sriov).getConfigureAndReset(storeManagerMode,
+					[]sriovnetworkv1.Interface{iface}, []sriovnetworkv1.InterfaceExt{ifaceStatus}, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toBeConfigured).To(HaveLen(1))
+			})
+			It("TotalVfs not increased", func() {
+				storeManagerMode.EXPECT().LoadRejectedTotalVfs("0000:d8:00.0").Return(8, true, nil)
+				toBeConfigured, _, err := s.(*sriov).getConfigureAndReset(storeManagerMode,
+					[]sriovnetworkv1.Interface{iface}, []sriovnetworkv1.InterfaceExt{ifaceStatus}, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(toBeConfigured).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("ConfigSriovInterfaces retry budget", func() {
+		var (
+			iface       sriovnetworkv1.Interface
+			ifaceStatus sriovnetworkv1.InterfaceExt
+		)
+		BeforeEach(func() {
+			origCount, origInterval := vars.ConfigRetryCount, vars.ConfigRetryInterval
+			vars.ConfigRetryCount = 2
+			vars.ConfigRetryInterval = time.Millisecond
+			DeferCleanup(func() {
+				vars.ConfigRetryCount, vars.ConfigRetryInterval = origCount, origInterval
+			})
+			storeManagerMode.EXPECT().LoadRejectedTotalVfs(gomock.Any()).Return(0, false, nil).AnyTimes()
+			iface = sriovnetworkv1.Interface{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0"}
+			// the PF link is down, so the PF needs to be configured
+			ifaceStatus = sriovnetworkv1.InterfaceExt{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", LinkAdminState: "down"}
+			origNicMap := sriovnetworkv1.NicIDMap
+			sriovnetworkv1.InitNicIDMapFromList([]string{"15b3 101d 101e"})
+			DeferCleanup(func() {
+				sriovnetworkv1.NicIDMap = origNicMap
+			})
+		})
+		// expectDiscovery expects the PF to be discovered again before each retry with the provided link admin state,
+		// it must be called before the PF link is expected to be configured
+		expectDiscovery := func(times int, linkAdminState string) {
+			ghwInfoMock := ghwMockPkg.NewMockInfo(testCtrl)
+			ghwLibMock.EXPECT().PCI().Return(ghwInfoMock, nil).Times(times)
+			hostMock.EXPECT().WithPhysPortCache().Return(hostMock).Times(times)
+			ghwInfoMock.EXPECT().ListDevices().Return(getTestPCIDevices()[:1]).Times(times)
+			netlinkLibMock.EXPECT().RouteList(nil, netlink.FAMILY_ALL).Return(nil, nil).Times(times)
+			dputilsLibMock.EXPECT().IsSriovVF("0000:d8:00.0").Return(false).Times(times)
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("mlx5_core", nil).Times(times)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0").Times(times)
+			discoveredLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(discoveredLinkMock, nil).Times(times)
+			discoveredLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{MTU: 1500}).AnyTimes()
+			hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("100000 Mb/s").Times(times)
+			hostMock.EXPECT().GetNetDevLinkAdminState("enp216s0f0np0").Return(linkAdminState).Times(times)
+			hostMock.EXPECT().GetNetDevFirmwareVersion("enp216s0f0np0").Return("").Times(times)
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(nil, false, nil).Times(times)
+			hostMock.EXPECT().GetNetdevCombinedChannels("enp216s0f0np0").Return(0, 0, testError).Times(times)
+			hostMock.EXPECT().GetNetdevRingSizes("enp216s0f0np0").Return(nil, testError).Times(times)
+			dputilsLibMock.EXPECT().IsSriovPF("0000:d8:00.0").Return(false).Times(times)
+		}
+		It("should succeed on the second pass after a transient failure", func() {
+			expectDiscovery(1, "down")
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1).Times(2)
+			gomock.InOrder(
+				hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(testError),
+				hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil),
+			)
+			hostMock.EXPECT().RemovePersistPFNameUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().RemoveVfRepresentorUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().AddDisableNMUdevRule("0000:d8:00.0").Return(nil)
+			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{
+				Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(true)
+			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			storeManagerMode.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil)
+
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)).NotTo(HaveOccurred())
+		})
+		It("should return the error when the retry budget is exhausted", func() {
+			expectDiscovery(2, "down")
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1).Times(3)
+			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(testError).Times(3)
+
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)).To(MatchError(testError))
 		})
-		It("fail - no such device", func() {
-			Expect(s.SetSriovNumVfs("0000:d8:00.0", 5)).To(HaveOccurred())
+		It("should plan the retry against the status discovered after the failed pass", func() {
+			// the PF link is up once the failed pass is rolled back, so the retry has nothing to configure
+			expectDiscovery(1, "up")
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1)
+			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(testError)
+			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)).NotTo(HaveOccurred())
 		})
-	})
+		It("should return the error of the discovery before a retry", func() {
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1).AnyTimes()
+			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(testError)
+			ghwLibMock.EXPECT().PCI().Return(nil, fmt.Errorf("no PCI info")).Times(2)
+			hostMock.EXPECT().WithPhysPortCache().Return(hostMock).Times(2)
 
-	Context("GetNicSriovMode", func() {
-		It("devlink returns info", func() {
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
-				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "switchdev"}}},
-				nil)
-			mode := s.GetNicSriovMode("0000:d8:00.0")
-			Expect(mode).To(Equal("switchdev"))
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)).To(MatchError(ContainSubstring("no PCI info")))
 		})
-		It("devlink returns error", func() {
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
-			mode := s.GetNicSriovMode("0000:d8:00.0")
+		It("should not retry a permanent failure", func() {
+			iface.NumVfs = 8
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(4)
+			storeManagerMode.EXPECT().SaveRejectedTotalVfs("0000:d8:00.0", 4).Return(nil)
 
-			Expect(mode).To(Equal("legacy"))
+			err := s.ConfigSriovInterfaces(context.Background(), storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)
+			exceedErr := &types.NumVfsExceedTotalVfsError{}
+			Expect(errors.As(err, &exceedErr)).To(BeTrue())
 		})
-		It("devlink not supported - fail to get name", func() {
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, syscall.ENODEV)
-			mode := s.GetNicSriovMode("0000:d8:00.0")
-			Expect(mode).To(Equal("legacy"))
+		It("should not configure nor retry when the context is canceled", func() {
+			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1).AnyTimes()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := s.ConfigSriovInterfaces(ctx, storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{ifaceStatus}, false)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(err).To(MatchError(ContainSubstring("configuration of device 0000:d8:00.0 canceled")))
 		})
 	})
 
-	Context("SetNicSriovMode", func() {
-		It("set", func() {
-			testDev := &netlink.DevlinkDevice{}
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{}, nil)
-			netlinkLibMock.EXPECT().DevLinkSetEswitchMode(testDev, "legacy").Return(nil)
-			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).NotTo(HaveOccurred())
+	Context("ExportConfigScript", func() {
+		It("should export the commands configuring the PF and its VFs", func() {
+			state := &sriovnetworkv1.SriovNetworkNodeState{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{Interfaces: sriovnetworkv1.Interfaces{{
+					Name:        "enp216s0f0np0",
+					PciAddress:  "0000:d8:00.0",
+					NumVfs:      2,
+					Mtu:         9000,
+					EswitchMode: "switchdev",
+					VfGroups: []sriovnetworkv1.VfGroup{
+						{VfRange: "0-0", ResourceName: "test-resource0", PolicyName: "test-policy0", DeviceType: "netdevice", Mtu: 9000},
+						{VfRange: "1-1", ResourceName: "test-resource1", PolicyName: "test-policy1", DeviceType: "vfio-pci"}},
+				}, {
+					Name:       "enp216s0f1np1",
+					PciAddress: "0000:d8:00.1",
+					NumVfs:     1,
+					VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-0", ResourceName: "test-resource2", DeviceType: "netdevice"}},
+				}}},
+				Status: sriovnetworkv1.SriovNetworkNodeStateStatus{Interfaces: sriovnetworkv1.InterfaceExts{
+					{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", TotalVfs: 8, Mtu: 1500, LinkAdminState: "up"},
+					{Name: "enp216s0f1np1", PciAddress: "0000:d8:00.1", TotalVfs: 8, NumVfs: 1, LinkAdminState: "up",
+						VFs: []sriovnetworkv1.VirtualFunction{{VfID: 0, Driver: "mlx5_core"}}},
+				}},
+			}
+			script, err := s.ExportConfigScript(state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(script).To(Equal(`#!/bin/bash
+# SR-IOV configuration of node worker-0
+set -e
+
+# PF 0000:d8:00.0 (enp216s0f0np0)
+# udev rules: disable-nm,persist-pf-name,vf-representor
+echo 0 > /sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs
+echo 2 > /sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs
+for vf in /sys/bus/pci/devices/0000:d8:00.0/virtfn*; do [ -e $vf/driver ] && echo $(basename $(readlink $vf)) > $vf/driver/unbind; done
+devlink dev eswitch set pci/0000:d8:00.0 mode switchdev
+ip link set dev enp216s0f0np0 mtu 9000
+vf=$(basename "$(readlink /sys/bus/pci/devices/0000:d8:00.0/virtfn0)")
+echo > /sys/bus/pci/devices/$vf/driver_override
+echo $vf > /sys/bus/pci/drivers_probe
+ip link set dev "$(ls /sys/bus/pci/devices/$vf/net)" mtu 9000
+vf=$(basename "$(readlink /sys/bus/pci/devices/0000:d8:00.0/virtfn1)")
+[ -e /sys/bus/pci/devices/$vf/driver ] && echo $vf > /sys/bus/pci/devices/$vf/driver/unbind
+echo vfio-pci > /sys/bus/pci/devices/$vf/driver_override
+echo $vf > /sys/bus/pci/drivers/vfio-pci/bind
+echo > /sys/bus/pci/devices/$vf/driver_override
+`))
 		})
-		It("fail to get dev", func() {
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(nil, testError)
-			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).To(MatchError(testError))
+	})
+
+	Context("ConfigSriovInterfacesDryRun", func() {
+		BeforeEach(func() {
+			storeManagerMode.EXPECT().LoadRejectedTotalVfs(gomock.Any()).Return(0, false, nil).AnyTimes()
 		})
-		It("fail to set mode", func() {
-			testDev := &netlink.DevlinkDevice{}
-			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{}, nil)
-			netlinkLibMock.EXPECT().DevLinkSetEswitchMode(testDev, "legacy").Return(testError)
-			Expect(s.SetNicSriovMode("0000:d8:00.0", "legacy")).To(MatchError(testError))
+		It("should plan VF creation and reset without changing the host", func() {
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.1").Return(&sriovnetworkv1.Interface{
+				PciAddress: "0000:d8:00.1", NumVfs: 4}, true, nil)
+
+			changes, err := s.ConfigSriovInterfacesDryRun(storeManagerMode,
+				[]sriovnetworkv1.Interface{{
+					Name:       "enp216s0f0np0",
+					PciAddress: "0000:d8:00.0",
+					NumVfs:     2,
+					Mtu:        9000,
+					VfGroups: []sriovnetworkv1.VfGroup{
+						{VfRange: "0-0", ResourceName: "test-resource0", PolicyName: "test-policy0", DeviceType: "netdevice", Mtu: 9000},
+						{VfRange: "1-1", ResourceName: "test-resource1", PolicyName: "test-policy1", DeviceType: "vfio-pci"}},
+				}},
+				[]sriovnetworkv1.InterfaceExt{
+					{PciAddress: "0000:d8:00.0", TotalVfs: 8, Mtu: 1500, LinkAdminState: "up"},
+					{PciAddress: "0000:d8:00.1", TotalVfs: 8, NumVfs: 4}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]types.PlannedChange{
+				{PciAddress: "0000:d8:00.0", Kind: types.PlannedChangeUdevRules, Desired: "disable-nm"},
+				{PciAddress: "0000:d8:00.0", Kind: types.PlannedChangeNumVfs, Current: "0", Desired: "2"},
+				{PciAddress: "0000:d8:00.0", Kind: types.PlannedChangeMtu, Current: "1500", Desired: "9000"},
+				{PciAddress: "0000:d8:00.0", VfID: pointer.Int(0), Kind: types.PlannedChangeDriver, Desired: "netdevice"},
+				{PciAddress: "0000:d8:00.0", VfID: pointer.Int(0), Kind: types.PlannedChangeMtu, Desired: "9000"},
+				{PciAddress: "0000:d8:00.0", VfID: pointer.Int(1), Kind: types.PlannedChangeDriver, Desired: "vfio-pci"},
+				{PciAddress: "0000:d8:00.1", Kind: types.PlannedChangeReset, Current: "4", Desired: "0"},
+			}))
+		})
+		It("should plan the rebind of existing VFs", func() {
+			iface := sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     2,
+				VfGroups: []sriovnetworkv1.VfGroup{
+					{VfRange: "0-0", ResourceName: "test-resource0", PolicyName: "test-policy0", DeviceType: "netdevice", Mtu: 1500},
+					{VfRange: "1-1", ResourceName: "test-resource1", PolicyName: "test-policy1", DeviceType: "vfio-pci"}},
+			}
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&iface, true, nil)
+
+			changes, err := s.ConfigSriovInterfacesDryRun(storeManagerMode, []sriovnetworkv1.Interface{iface},
+				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0", TotalVfs: 8, NumVfs: 2, Mtu: 1500, LinkAdminState: "up",
+					VFs: []sriovnetworkv1.VirtualFunction{
+						{VfID: 0, Driver: "iavf", Mtu: 1500, Mac: "02:42:19:51:2f:af"},
+						{VfID: 1, Driver: "iavf", Mtu: 1500, Mac: "02:42:19:51:2f:b0"}}}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]types.PlannedChange{
+				{PciAddress: "0000:d8:00.0", Kind: types.PlannedChangeUdevRules, Desired: "disable-nm"},
+				{PciAddress: "0000:d8:00.0", VfID: pointer.Int(1), Kind: types.PlannedChangeMac, Desired: "02:42:19:51:2f:b0"},
+				{PciAddress: "0000:d8:00.0", VfID: pointer.Int(1), Kind: types.PlannedChangeDriver, Current: "iavf", Desired: "vfio-pci"},
+			}))
+		})
+		It("should report the NumVfs larger than TotalVfs", func() {
+			_, err := s.ConfigSriovInterfacesDryRun(storeManagerMode,
+				[]sriovnetworkv1.Interface{{Name: "enp216s0f0np0", PciAddress: "0000:d8:00.0", NumVfs: 16}},
+				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0", TotalVfs: 8, LinkAdminState: "up"}})
+			exceedErr := &types.NumVfsExceedTotalVfsError{}
+			Expect(errors.As(err, &exceedErr)).To(BeTrue())
 		})
 	})
 
 	Context("ConfigSriovInterfaces", func() {
+		BeforeEach(func() {
+			storeManagerMode.EXPECT().LoadRejectedTotalVfs(gomock.Any()).Return(0, false, nil).AnyTimes()
+		})
 		It("should configure", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
-				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn1",
+				},
 				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
 			})
 
 			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(2)
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2", "0000:d8:00.3")
 			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{
 				Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
 			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
@@ -223,35 +2268,63 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().AddDisableNMUdevRule("0000:d8:00.0").Return(nil)
 			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2", "0000:d8:00.3"}, nil)
 			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
-			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil).Times(3)
-			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Flags: 0, EncapType: "ether"})
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil).Times(5)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Flags: 0, EncapType: "ether"}).Times(3)
+			// the capabilities of the PF are checked as the VFs request settings a PF may not support
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("mlx5_core", nil)
+			// the name of the PF is resolved as the spec doesn't provide it
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0").Times(2)
+			hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("25000 Mb/s")
 			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(false)
 			netlinkLibMock.EXPECT().LinkSetUp(pfLinkMock).Return(nil)
 
-			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil).Times(2)
+			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.2").Return(0, nil)
 			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(false, "")
 			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
 			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "test")
 			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", true).Return(nil)
 			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
-			hostMock.EXPECT().SetNetdevMTU("0000:d8:00.2", 2000).Return(nil)
-			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0_0")
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(1500)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0").Return(9000)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.2", 2000).Return(nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0_0").Times(2)
+			hostMock.EXPECT().MaxMTU("enp216s0f0_0").Return(9978, nil)
 			vf0LinkMock := netlinkMockPkg.NewMockLink(testCtrl)
 			vf0Mac, _ := net.ParseMAC("02:42:19:51:2f:af")
 			vf0LinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{HardwareAddr: vf0Mac})
 			netlinkLibMock.EXPECT().LinkByName("enp216s0f0_0").Return(vf0LinkMock, nil)
 			netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(vf0LinkMock, 0, vf0Mac).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 0, 0, 0, int(netlink.VLAN_PROTOCOL_8021Q)).Return(nil)
 
 			dputilsLibMock.EXPECT().GetVFID("0000:d8:00.3").Return(1, nil)
-			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "vfio-pci").Times(2)
-			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.3", false).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 1, 100, 2, int(netlink.VLAN_PROTOCOL_8021AD)).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfSpoofchk(pfLinkMock, 1, false).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfTrust(pfLinkMock, 1, true).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfRate(pfLinkMock, 1, 100, 1000).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfState(pfLinkMock, 1, netlink.VF_LINK_STATE_ENABLE).Return(nil)
+			hostMock.EXPECT().HasDriver("0000:d8:00.3").Return(true, "mlx5_core").Times(2)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.3").Return("enp216s0f0_1").Times(2)
+			vf1LinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			vf1Mac, _ := net.ParseMAC("02:42:19:51:2f:b0")
+			vf1LinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{HardwareAddr: vf1Mac})
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0_1").Return(vf1LinkMock, nil)
+			netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(vf1LinkMock, 1, vf1Mac).Return(nil)
+			// channels must be configured before the VF is unbound from the kernel driver
+			gomock.InOrder(
+				hostMock.EXPECT().SetNetdevCombinedChannels("enp216s0f0_1", 4).Return(nil),
+				hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.3", false).Return(nil),
+			)
 			hostMock.EXPECT().BindDpdkDriver("0000:d8:00.3", "vfio-pci").Return(nil)
 
-			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			// the resolved name doesn't leak into the spec saved as the last applied status
+			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).DoAndReturn(func(iface *sriovnetworkv1.Interface) error {
+				Expect(iface.Name).To(BeEmpty())
+				return nil
+			})
+			storeManagerMode.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil)
 
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
-					Name:       "enp216s0f0np0",
 					PciAddress: "0000:d8:00.0",
 					NumVfs:     2,
 					VfGroups: []sriovnetworkv1.VfGroup{
@@ -261,14 +2334,25 @@ var _ = Describe("SRIOV", func() {
 							PolicyName:   "test-policy0",
 							Mtu:          2000,
 							IsRdma:       true,
+							VlanID:       pointer.Int(0),
+							VlanQoS:      3,
 						},
 						{
-							VfRange:      "1-1",
-							ResourceName: "test-resource1",
-							PolicyName:   "test-policy1",
-							Mtu:          1600,
-							IsRdma:       false,
-							DeviceType:   "vfio-pci",
+							VfRange:          "1-1",
+							ResourceName:     "test-resource1",
+							PolicyName:       "test-policy1",
+							Mtu:              1600,
+							IsRdma:           false,
+							DeviceType:       "vfio-pci",
+							VlanID:           pointer.Int(100),
+							VlanQoS:          2,
+							VlanProto:        "802.1AD",
+							SpoofChk:         "off",
+							Trust:            "on",
+							MinTxRate:        pointer.Int(100),
+							MaxTxRate:        pointer.Int(1000),
+							LinkState:        "enable",
+							CombinedChannels: 4,
 						}},
 				}},
 				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0"}, {PciAddress: "0000:d8:00.1"}},
@@ -277,12 +2361,16 @@ var _ = Describe("SRIOV", func() {
 		})
 		It("should configure IB", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
-				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn0",
+				},
 				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
 			})
 
 			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1)
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2")
 			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{
 				Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil)
 			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
@@ -300,14 +2388,21 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "test").Times(2)
 			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", true).Return(nil)
 			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
-			hostMock.EXPECT().SetNetdevMTU("0000:d8:00.2", 2000).Return(nil)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(1500)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0").Return(9000)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.2", 2000).Return(nil)
+			storeManagerMode.EXPECT().LoadVfGUID("0000:d8:00.0", 0).Return("00:11:22:33:44:55:66:77", true, nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("ibp216s0f0v0")
+			hostMock.EXPECT().MaxMTU("ibp216s0f0v0").Return(4092, nil)
+			guid, _ := net.ParseMAC("00:11:22:33:44:55:66:77")
 			vf0LinkMock := netlinkMockPkg.NewMockLink(testCtrl)
-			netlinkLibMock.EXPECT().LinkSetVfNodeGUID(vf0LinkMock, 0, gomock.Any()).Return(nil)
-			netlinkLibMock.EXPECT().LinkSetVfPortGUID(vf0LinkMock, 0, gomock.Any()).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfNodeGUID(vf0LinkMock, 0, guid).Return(nil)
+			netlinkLibMock.EXPECT().LinkSetVfPortGUID(vf0LinkMock, 0, guid).Return(nil)
 
 			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			storeManagerMode.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil)
 
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
 					Name:       "enp216s0f0np0",
 					PciAddress: "0000:d8:00.0",
@@ -329,12 +2424,16 @@ var _ = Describe("SRIOV", func() {
 
 		It("should configure switchdev", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
-				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn0",
+				},
 				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
 			})
 
 			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(1)
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2")
 			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
 			hostMock.EXPECT().RemovePersistPFNameUdevRule("0000:d8:00.0").Return(nil)
 			hostMock.EXPECT().RemoveVfRepresentorUdevRule("0000:d8:00.0").Return(nil)
@@ -344,7 +2443,12 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().GetDevlinkDeviceParam("0000:d8:00.0", "flow_steering_mode").Return("", syscall.EINVAL)
 			dputilsLibMock.EXPECT().GetVFList("0000:d8:00.0").Return([]string{"0000:d8:00.2"}, nil).Times(2)
 			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
-			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil).Times(2)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil).Times(3)
+			pfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{})
+			// the capabilities of the PF are checked as the VFs request settings a PF may not support
+			dputilsLibMock.EXPECT().GetDriverName("0000:d8:00.0").Return("mlx5_core", nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			hostMock.EXPECT().GetNetDevLinkSpeed("enp216s0f0np0").Return("25000 Mb/s")
 			netlinkLibMock.EXPECT().IsLinkAdminStateUp(pfLinkMock).Return(false)
 			netlinkLibMock.EXPECT().LinkSetUp(pfLinkMock).Return(nil)
 			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(&netlink.DevlinkDevice{
@@ -358,8 +2462,11 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().HasDriver("0000:d8:00.2").Return(true, "test")
 			hostMock.EXPECT().UnbindDriverIfNeeded("0000:d8:00.2", true).Return(nil)
 			hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil)
-			hostMock.EXPECT().SetNetdevMTU("0000:d8:00.2", 2000).Return(nil)
-			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0_0")
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.2").Return(1500)
+			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0").Return(9000)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.2", 2000).Return(nil)
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0_0").Times(2)
+			hostMock.EXPECT().MaxMTU("enp216s0f0_0").Return(9978, nil)
 			vf0LinkMock := netlinkMockPkg.NewMockLink(testCtrl)
 			vf0Mac, _ := net.ParseMAC("02:42:19:51:2f:af")
 			vf0LinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{HardwareAddr: vf0Mac})
@@ -370,10 +2477,21 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().AddVfRepresentorUdevRule("0000:d8:00.0", "enp216s0f0np0", "7cfe90ff2cc0", "p0").Return(nil)
 			hostMock.EXPECT().CreateVDPADevice("0000:d8:00.2", "vhost_vdpa")
 			hostMock.EXPECT().LoadUdevRules().Return(nil)
+			// switchdev VF rates are programmed with devlink rate objects
+			hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.0").Return("enp216s0f0np0")
+			sriovnetLibMock.EXPECT().GetVfRepresentor("enp216s0f0np0", 0).Return("enp216s0f0np0_0", nil).Times(3)
+			// the representor isn't a port of a bridge, there is no VLAN trunk to clear
+			rep0LinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			rep0LinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{Index: 10}).AnyTimes()
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0_0").Return(rep0LinkMock, nil)
+			netlinkLibMock.EXPECT().DevLinkGetAllPortList().Return([]*netlink.DevlinkPort{
+				{BusName: "pci", DeviceName: "0000:d8:00.0", PortIndex: 1, NetdeviceName: "enp216s0f0np0_0"}}, nil)
+			netlinkLibMock.EXPECT().DevlinkPortFnRateSet("pci", "0000:d8:00.0", uint32(1), uint64(0), uint64(62500000)).Return(nil)
 
 			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			storeManagerMode.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil)
 
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
 					Name:        "enp216s0f0np0",
 					PciAddress:  "0000:d8:00.0",
@@ -388,6 +2506,8 @@ var _ = Describe("SRIOV", func() {
 							Mtu:          2000,
 							IsRdma:       true,
 							VdpaType:     "vhost_vdpa",
+							MinTxRate:    pointer.Int(0),
+							MaxTxRate:    pointer.Int(500),
 						}},
 				}},
 				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0"}},
@@ -397,7 +2517,7 @@ var _ = Describe("SRIOV", func() {
 
 		It("externally managed - wrong VF count", func() {
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
 					Name:              "enp216s0f0np0",
 					PciAddress:        "0000:d8:00.0",
@@ -422,7 +2542,7 @@ var _ = Describe("SRIOV", func() {
 				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}},
 				nil)
 			hostMock.EXPECT().GetNetdevMTU("0000:d8:00.0")
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
 					Name:              "enp216s0f0np0",
 					PciAddress:        "0000:d8:00.0",
@@ -441,6 +2561,99 @@ var _ = Describe("SRIOV", func() {
 				false)).To(HaveOccurred())
 		})
 
+		It("externally managed - VFs created by the operator", func() {
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     1,
+			}, true, nil)
+			err := s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{{
+					Name:              "enp216s0f0np0",
+					PciAddress:        "0000:d8:00.0",
+					NumVfs:            1,
+					ExternallyManaged: true,
+				}},
+				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0", NumVfs: 1}},
+				false)
+			mismatchErr := &types.ExternallyManagedMismatchError{}
+			Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+			Expect(mismatchErr.PciAddress).To(Equal("0000:d8:00.0"))
+			Expect(mismatchErr.Requested).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("its VFs were created by the sriov operator"))
+		})
+
+		It("not externally managed - VFs created externally", func() {
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:              "enp216s0f0np0",
+				PciAddress:        "0000:d8:00.0",
+				NumVfs:            1,
+				ExternallyManaged: true,
+			}, true, nil)
+			err := s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{{
+					Name:       "enp216s0f0np0",
+					PciAddress: "0000:d8:00.0",
+					NumVfs:     1,
+				}},
+				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0", NumVfs: 1}},
+				false)
+			mismatchErr := &types.ExternallyManagedMismatchError{}
+			Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+			Expect(mismatchErr.PciAddress).To(Equal("0000:d8:00.0"))
+			Expect(mismatchErr.Requested).To(BeFalse())
+			Expect(err.Error()).To(ContainSubstring("its VFs were created externally"))
+		})
+
+		It("externally managed mismatch - other PFs still processed", func() {
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     1,
+			}, true, nil)
+			changes, err := s.ConfigSriovInterfacesDryRun(storeManagerMode,
+				[]sriovnetworkv1.Interface{{
+					Name:              "enp216s0f0np0",
+					PciAddress:        "0000:d8:00.0",
+					NumVfs:            1,
+					ExternallyManaged: true,
+				}, {
+					Name:       "enp59s0f0np0",
+					PciAddress: "0000:3b:00.0",
+					NumVfs:     2,
+				}},
+				[]sriovnetworkv1.InterfaceExt{
+					{PciAddress: "0000:d8:00.0", NumVfs: 1, TotalVfs: 8},
+					{Name: "enp59s0f0np0", PciAddress: "0000:3b:00.0", TotalVfs: 8},
+				})
+			mismatchErr := &types.ExternallyManagedMismatchError{}
+			Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+			Expect(mismatchErr.PciAddress).To(Equal("0000:d8:00.0"))
+			Expect(changes).To(ContainElement(types.PlannedChange{PciAddress: "0000:3b:00.0",
+				Kind: types.PlannedChangeNumVfs, Current: "0", Desired: "2"}))
+			for _, change := range changes {
+				Expect(change.PciAddress).To(Equal("0000:3b:00.0"))
+			}
+		})
+
+		It("externally managed mismatch - ignored", func() {
+			origIgnore := vars.IgnoreExternallyManagedMismatch
+			vars.IgnoreExternallyManagedMismatch = true
+			DeferCleanup(func() {
+				vars.IgnoreExternallyManagedMismatch = origIgnore
+			})
+			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{{
+					Name:              "enp216s0f0np0",
+					PciAddress:        "0000:d8:00.0",
+					NumVfs:            1,
+					ExternallyManaged: true,
+				}},
+				[]sriovnetworkv1.InterfaceExt{{PciAddress: "0000:d8:00.0", NumVfs: 1, LinkAdminState: "up"}},
+				false)).NotTo(HaveOccurred())
+		})
+
 		It("reset device", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
 				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
@@ -458,9 +2671,12 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
 			hostMock.EXPECT().RemovePersistPFNameUdevRule("0000:d8:00.0").Return(nil)
 			hostMock.EXPECT().RemoveVfRepresentorUdevRule("0000:d8:00.0").Return(nil)
-			hostMock.EXPECT().SetNetdevMTU("0000:d8:00.0", 1500).Return(nil)
+			storeManagerMode.EXPECT().LoadPfOriginalMtu("0000:d8:00.0").Return(0, false, nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 0).Return(nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 1).Return(nil)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.0", 1500).Return(nil)
 
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{},
 				[]sriovnetworkv1.InterfaceExt{
 					{
@@ -479,7 +2695,7 @@ var _ = Describe("SRIOV", func() {
 				NumVfs:            2,
 				ExternallyManaged: true,
 			}, true, nil)
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{},
 				[]sriovnetworkv1.InterfaceExt{
 					{
@@ -489,14 +2705,181 @@ var _ = Describe("SRIOV", func() {
 						TotalVfs:   2,
 					}}, false)).NotTo(HaveOccurred())
 		})
-		It("should configure - skipVFConfiguration is true", func() {
+		It("reset device - skip keep reset policy", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:        "enp216s0f0np0",
+				PciAddress:  "0000:d8:00.0",
+				NumVfs:      2,
+				ResetPolicy: sriovnetworkv1.ResetPolicyKeep,
+			}, true, nil)
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{},
+				[]sriovnetworkv1.InterfaceExt{
+					{
+						Name:       "enp216s0f0np0",
+						PciAddress: "0000:d8:00.0",
+						NumVfs:     2,
+						TotalVfs:   2,
+					}}, false)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "2")
+		})
+		It("reset device - reset policy", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:        "enp216s0f0np0",
+				PciAddress:  "0000:d8:00.0",
+				NumVfs:      2,
+				ResetPolicy: sriovnetworkv1.ResetPolicyReset,
+			}, true, nil)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}},
+				nil)
+			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().RemovePersistPFNameUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().RemoveVfRepresentorUdevRule("0000:d8:00.0").Return(nil)
+			storeManagerMode.EXPECT().LoadPfOriginalMtu("0000:d8:00.0").Return(0, false, nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 0).Return(nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 1).Return(nil)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.0", 1500).Return(nil)
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{},
+				[]sriovnetworkv1.InterfaceExt{
+					{
+						Name:       "enp216s0f0np0",
+						PciAddress: "0000:d8:00.0",
+						LinkType:   "ETH",
+						NumVfs:     2,
+						TotalVfs:   2,
+					}}, false)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "0")
+		})
+		It("reset device - restore the original MTU after a restart", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			// the initial state is lost on the restart of the daemon
+			origInitialState := sriovnetworkv1.InitialState
+			sriovnetworkv1.InitialState = sriovnetworkv1.SriovNetworkNodeState{}
+			DeferCleanup(func() {
+				sriovnetworkv1.InitialState = origInitialState
+			})
+			storeManagerMode.EXPECT().LoadPfsStatus("0000:d8:00.0").Return(&sriovnetworkv1.Interface{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				NumVfs:     2,
+				Mtu:        1500,
+			}, true, nil)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}},
+				nil)
+			hostMock.EXPECT().RemoveDisableNMUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().RemovePersistPFNameUdevRule("0000:d8:00.0").Return(nil)
+			hostMock.EXPECT().RemoveVfRepresentorUdevRule("0000:d8:00.0").Return(nil)
+			storeManagerMode.EXPECT().LoadPfOriginalMtu("0000:d8:00.0").Return(9000, true, nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 0).Return(nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 1).Return(nil)
+			hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.0", 9000).Return(nil)
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
+				[]sriovnetworkv1.Interface{},
+				[]sriovnetworkv1.InterfaceExt{
+					{
+						Name:       "enp216s0f0np0",
+						PciAddress: "0000:d8:00.0",
+						LinkType:   "ETH",
+						Mtu:        1500,
+						NumVfs:     2,
+						TotalVfs:   2,
+					}}, false)).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "0")
+		})
+		It("save the MTU of the initial state as the original MTU", func() {
+			origInitialState := sriovnetworkv1.InitialState
+			sriovnetworkv1.InitialState = sriovnetworkv1.SriovNetworkNodeState{Status: sriovnetworkv1.SriovNetworkNodeStateStatus{
+				Interfaces: sriovnetworkv1.InterfaceExts{{PciAddress: "0000:d8:00.0", Mtu: 9000}},
+			}}
+			DeferCleanup(func() {
+				sriovnetworkv1.InitialState = origInitialState
+			})
+			storeManagerMode.EXPECT().SavePfOriginalMtu("0000:d8:00.0", 9000).Return(nil)
+			storeManagerMode.EXPECT().SavePfOriginalMtu("0000:d8:00.1", 1500).Return(nil)
+			Expect(savePfOriginalMtu(storeManagerMode, &sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.0", Mtu: 1500})).To(Succeed())
+			Expect(savePfOriginalMtu(storeManagerMode, &sriovnetworkv1.InterfaceExt{PciAddress: "0000:d8:00.1", Mtu: 1500})).To(Succeed())
+		})
+		It("reset device - restore VFs initial state", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
 				Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0"},
+				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": []byte("2")},
+			})
+			origInitialState := sriovnetworkv1.InitialState
+			DeferCleanup(func() {
+				sriovnetworkv1.InitialState = origInitialState
+			})
+			sriovnetworkv1.InitialState = sriovnetworkv1.SriovNetworkNodeState{Status: sriovnetworkv1.SriovNetworkNodeStateStatus{
+				Interfaces: sriovnetworkv1.InterfaceExts{{
+					PciAddress: "0000:d8:00.0",
+					Mtu:        9000,
+					VFs:        []sriovnetworkv1.VirtualFunction{{PciAddress: "0000:d8:00.3", VfID: 1, Vlan: 5, VlanProto: "802.1ad"}},
+				}},
+			}}
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			netlinkLibMock.EXPECT().LinkByName("enp216s0f0np0").Return(pfLinkMock, nil)
+			vfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			vfLinkMock.EXPECT().Attrs().Return(&netlink.LinkAttrs{EncapType: "ether", TxQLen: 5000}).AnyTimes()
+			zeroMac := make(net.HardwareAddr, 6)
+			gomock.InOrder(
+				hostMock.EXPECT().BindDefaultDriver("0000:d8:00.2").Return(nil),
+				hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.2").Return("enp216s0f0v0"),
+				netlinkLibMock.EXPECT().LinkByName("enp216s0f0v0").Return(vfLinkMock, nil),
+				netlinkLibMock.EXPECT().LinkSetTxQLen(vfLinkMock, 1000).Return(nil),
+				netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(pfLinkMock, 0, zeroMac).Return(nil),
+				netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 0, 0, 0, int(netlink.VLAN_PROTOCOL_8021Q)).Return(nil),
+				hostMock.EXPECT().TryGetInterfaceName("0000:d8:00.3").Return(""),
+				// a failure to restore a VF doesn't prevent the reset of the device
+				netlinkLibMock.EXPECT().LinkSetVfHardwareAddr(pfLinkMock, 1, zeroMac).Return(testError),
+				// only the protocol of the VLAN differs from the initial state
+				netlinkLibMock.EXPECT().LinkSetVfVlanQosProto(pfLinkMock, 1, 5, 0, int(netlink.VLAN_PROTOCOL_8021AD)).Return(nil),
+				hostMock.EXPECT().SetNetdevMTU(gomock.Any(), "0000:d8:00.0", 9000).Return(nil),
+			)
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
+				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}},
+				nil)
+			storeManagerMode.EXPECT().LoadPfOriginalMtu("0000:d8:00.0").Return(0, false, nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 0).Return(nil)
+			storeManagerMode.EXPECT().RemoveVfMac("0000:d8:00.0", 1).Return(nil)
+
+			Expect(s.ResetSriovDevice(storeManagerMode, sriovnetworkv1.InterfaceExt{
+				Name:       "enp216s0f0np0",
+				PciAddress: "0000:d8:00.0",
+				LinkType:   "ETH",
+				NumVfs:     2,
+				VFs: []sriovnetworkv1.VirtualFunction{
+					{PciAddress: "0000:d8:00.2", VfID: 0, Driver: "vfio-pci", Vlan: 100},
+					{PciAddress: "0000:d8:00.3", VfID: 1, Driver: "mlx5_core", Vlan: 5, VlanProto: "802.1Q"},
+				},
+			})).NotTo(HaveOccurred())
+			helpers.GinkgoAssertFileContentsEquals("/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs", "0")
+		})
+		It("should configure - skipVFConfiguration is true", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{
+					"/sys/bus/pci/devices/0000:d8:00.0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn0",
+					"/sys/bus/pci/devices/0000:d8:00.0/virtfn1",
+				},
 				Files: map[string][]byte{"/sys/bus/pci/devices/0000:d8:00.0/sriov_numvfs": {}},
 			})
 
 			dputilsLibMock.EXPECT().GetSriovVFcapacity("0000:d8:00.0").Return(2)
 			dputilsLibMock.EXPECT().GetVFconfigured("0000:d8:00.0").Return(0)
+			expectVFsCreated("0000:d8:00.0", "0000:d8:00.2", "0000:d8:00.3")
 			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", "0000:d8:00.0").Return(
 				&netlink.DevlinkDevice{Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}},
 				nil)
@@ -509,8 +2892,9 @@ var _ = Describe("SRIOV", func() {
 			hostMock.EXPECT().Unbind("0000:d8:00.3").Return(nil)
 
 			storeManagerMode.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil)
+			storeManagerMode.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil)
 
-			Expect(s.ConfigSriovInterfaces(storeManagerMode,
+			Expect(s.ConfigSriovInterfaces(context.Background(), storeManagerMode,
 				[]sriovnetworkv1.Interface{{
 					Name:       "enp216s0f0np0",
 					PciAddress: "0000:d8:00.0",
@@ -628,3 +3012,106 @@ func getTestPCIDevices() []*ghw.PCIDevice {
 		},
 	}
 }
+
+func BenchmarkConfigSriovVFDevicesInParallel(b *testing.B) {
+	vfAddrs := make([]string, 128)
+	for i := range vfAddrs {
+		vfAddrs[i] = fmt.Sprintf("0000:d8:%02x.%d", i/8, i%8)
+	}
+	iface := &sriovnetworkv1.Interface{
+		PciAddress: "0000:d8:00.0",
+		NumVfs:     len(vfAddrs),
+		VfGroups:   []sriovnetworkv1.VfGroup{{VfRange: "0-127", DeviceType: "vfio-pci"}},
+	}
+	origConcurrency := vars.VfConfigConcurrency
+	defer func() {
+		vars.VfConfigConcurrency = origConcurrency
+	}()
+
+	for _, concurrency := range []int{1, 8} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			vars.VfConfigConcurrency = concurrency
+			testCtrl := gomock.NewController(b)
+			dputilsLibMock := dputilsMockPkg.NewMockDPUtilsLib(testCtrl)
+			hostMock := hostMockPkg.NewMockHostManagerInterface(testCtrl)
+			pfLinkMock := netlinkMockPkg.NewMockLink(testCtrl)
+			s := New(nil, hostMock, hostMock, hostMock, hostMock, nil, dputilsLibMock, nil, nil, nil).(*sriov)
+
+			hostMock.EXPECT().HasDriver(gomock.Any()).Return(true, "vfio-pci").AnyTimes()
+			dputilsLibMock.EXPECT().GetVFID(gomock.Any()).DoAndReturn(func(addr string) (int, error) {
+				for i := range vfAddrs {
+					if vfAddrs[i] == addr {
+						return i, nil
+					}
+				}
+				return 0, fmt.Errorf("unknown VF %s", addr)
+			}).AnyTimes()
+			hostMock.EXPECT().UnbindDriverIfNeeded(gomock.Any(), false).Return(nil).AnyTimes()
+			// simulate the time needed by the kernel to rebind the VF driver
+			hostMock.EXPECT().BindDpdkDriver(gomock.Any(), "vfio-pci").DoAndReturn(func(string, string) error {
+				time.Sleep(time.Millisecond)
+				return nil
+			}).AnyTimes()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := s.configSriovVFDevicesInParallel(context.Background(), nil, iface, pfLinkMock, vfAddrs, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConfigSriovInterfacesInParallel(b *testing.B) {
+	interfaces := make([]interfaceToConfigure, 8)
+	for i := range interfaces {
+		interfaces[i].iface = sriovnetworkv1.Interface{
+			PciAddress: fmt.Sprintf("0000:%02x:00.0", 0x18+i*0x10),
+			Name:       fmt.Sprintf("ens%df0", i+1),
+			NumVfs:     4,
+		}
+	}
+	origConcurrency := vars.NicConfigConcurrency
+	defer func() {
+		vars.NicConfigConcurrency = origConcurrency
+	}()
+
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			vars.NicConfigConcurrency = concurrency
+			testCtrl := gomock.NewController(b)
+			dputilsLibMock := dputilsMockPkg.NewMockDPUtilsLib(testCtrl)
+			netlinkLibMock := netlinkMockPkg.NewMockNetlinkLib(testCtrl)
+			hostMock := hostMockPkg.NewMockHostManagerInterface(testCtrl)
+			storeManagerMock := hostStoreMockPkg.NewMockManagerInterface(testCtrl)
+			s := New(nil, hostMock, hostMock, hostMock, hostMock, netlinkLibMock, dputilsLibMock, nil, nil, nil).(*sriov)
+
+			dputilsLibMock.EXPECT().GetSriovVFcapacity(gomock.Any()).Return(64).AnyTimes()
+			dputilsLibMock.EXPECT().GetVFconfigured(gomock.Any()).Return(4).AnyTimes()
+			netlinkLibMock.EXPECT().DevLinkGetDeviceByName("pci", gomock.Any()).Return(&netlink.DevlinkDevice{
+				Attrs: netlink.DevlinkDevAttrs{Eswitch: netlink.DevlinkDevEswitchAttr{Mode: "legacy"}}}, nil).AnyTimes()
+			hostMock.EXPECT().RemoveDisableNMUdevRule(gomock.Any()).Return(nil).AnyTimes()
+			hostMock.EXPECT().RemovePersistPFNameUdevRule(gomock.Any()).Return(nil).AnyTimes()
+			hostMock.EXPECT().RemoveVfRepresentorUdevRule(gomock.Any()).Return(nil).AnyTimes()
+			hostMock.EXPECT().AddDisableNMUdevRule(gomock.Any()).Return(nil).AnyTimes()
+			dputilsLibMock.EXPECT().GetVFList(gomock.Any()).DoAndReturn(func(pfAddr string) ([]string, error) {
+				return []string{pfAddr + "-vf0", pfAddr + "-vf1", pfAddr + "-vf2", pfAddr + "-vf3"}, nil
+			}).AnyTimes()
+			// simulate the time needed by the kernel to unbind the VF driver
+			hostMock.EXPECT().Unbind(gomock.Any()).DoAndReturn(func(string) error {
+				time.Sleep(time.Millisecond)
+				return nil
+			}).AnyTimes()
+			storeManagerMock.EXPECT().SaveLastPfAppliedStatus(gomock.Any()).Return(nil).AnyTimes()
+			storeManagerMock.EXPECT().SavePfOriginalMtu(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := s.configSriovInterfacesInParallel(context.Background(), storeManagerMock, interfaces, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
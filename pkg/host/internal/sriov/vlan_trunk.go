@@ -0,0 +1,279 @@
+package sriov
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	netlinkPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/internal/lib/netlink"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+)
+
+var (
+	// vfVlanTrunkIntelDrivers are the PF drivers of the Intel out-of-tree driver package taking the VLAN ranges
+	// of the trunk in the "100-200,300" format
+	vfVlanTrunkIntelDrivers = []string{"i40e", "ice"}
+	// vfVlanTrunkMellanoxDrivers are the PF drivers of MLNX_OFED taking the VLAN ranges of the trunk one range at a time
+	vfVlanTrunkMellanoxDrivers = []string{"mlx5_core"}
+)
+
+// mlxVlanTrunkPrefix prefixes the VLANs of the trunk reported by the Mellanox drivers
+const mlxVlanTrunkPrefix = "Allowed 802.1Q VLANs:"
+
+// vfVlanTrunkPath returns the file exposed by the out-of-tree vendor PF drivers (Intel out-of-tree drivers,
+// MLNX_OFED) to manage the VLAN trunk of the VF in legacy mode, the in-tree drivers don't expose it
+func vfVlanTrunkPath(pfAddr string, vfID int) string {
+	return filepath.Join(vars.FilesystemRoot, consts.SysBusPciDevices, pfAddr, "sriov", strconv.Itoa(vfID), "trunk")
+}
+
+// setVfVlanTrunk programs the VLAN trunk requested by the VF group on the VF, through the bridge VLAN netlink API
+// on the representor of the VF of a switchdev PF and through the sysfs file of the out-of-tree vendor drivers on
+// a legacy PF, the trunk of the VF is cleared if the group doesn't set it
+func (s *sriov) setVfVlanTrunk(iface *sriovnetworkv1.Interface, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if sriovnetworkv1.GetEswitchModeFromSpec(iface) == sriovnetworkv1.ESwithModeSwitchDev {
+		return s.setVfRepresentorVlanTrunk(iface, vfID, group)
+	}
+	// the driver is only needed to change the trunk
+	if group.VlanTrunk == "" && getVfSysfsVlanTrunk(iface.PciAddress, vfID) == "" {
+		return nil
+	}
+	pfDriver, err := s.dputilsLib.GetDriverName(iface.PciAddress)
+	if err != nil {
+		log.Log.Error(err, "setVfVlanTrunk(): unable to get the driver of the PF", "device", iface.PciAddress)
+		return err
+	}
+	return setVfSysfsVlanTrunk(pfDriver, iface.PciAddress, vfID, group)
+}
+
+// getVfVlanTrunk returns the VLAN trunk of the VF in the format of the VF groups, read from the bridge port of the
+// representor of the VF when it has one, an empty string is returned when the trunk is empty or can't be read
+func (s *sriov) getVfVlanTrunk(pfAddr string, vfID int, repName string) string {
+	if repName == "" {
+		return getVfSysfsVlanTrunk(pfAddr, vfID)
+	}
+	repLink, err := s.getBridgePort(repName)
+	if err != nil || repLink == nil {
+		return ""
+	}
+	ranges, err := s.getBridgePortVlanTrunk(repLink)
+	if err != nil {
+		log.Log.V(2).Info("getVfVlanTrunk(): unable to read the VLANs of the VF representor", "device", pfAddr,
+			"vf", vfID, "representor", repName, "error", err)
+		return ""
+	}
+	return sriovnetworkv1.FormatVlanTrunk(ranges)
+}
+
+// desiredVfVlanTrunk returns the VLAN trunk requested by the VF group, an empty trunk if the group doesn't set it
+func desiredVfVlanTrunk(group *sriovnetworkv1.VfGroup) ([]sriovnetworkv1.VlanRange, error) {
+	if group.VlanTrunk == "" {
+		return []sriovnetworkv1.VlanRange{}, nil
+	}
+	return sriovnetworkv1.ParseVlanTrunk(group.VlanTrunk)
+}
+
+// setVfRepresentorVlanTrunk programs the VLAN trunk requested by the VF group as the tagged VLANs of the port
+// of the representor of the VF in a VLAN filtering Linux bridge, only the VLANs that differ from the current
+// trunk are added and removed
+func (s *sriov) setVfRepresentorVlanTrunk(iface *sriovnetworkv1.Interface, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.VlanTrunk != "" && group.VlanID != nil && *group.VlanID != 0 {
+		return fmt.Errorf("invalid VLAN trunk for VF %d of device %s: QinQ with a VLAN trunk is not supported in switchdev mode",
+			vfID, iface.PciAddress)
+	}
+	desired, err := desiredVfVlanTrunk(group)
+	if err != nil {
+		return err
+	}
+	repName, err := s.sriovnetLib.GetVfRepresentor(iface.Name, vfID)
+	if err != nil {
+		return fmt.Errorf("failed to get the representor of VF %d of device %s: %w", vfID, iface.PciAddress, err)
+	}
+	repLink, err := s.getBridgePort(repName)
+	if err != nil {
+		return fmt.Errorf("failed to get the representor %s of VF %d of device %s: %w", repName, vfID, iface.PciAddress, err)
+	}
+	if repLink == nil {
+		// the representor which isn't a port of a Linux bridge has no trunk to clear
+		if group.VlanTrunk == "" {
+			return nil
+		}
+		return fmt.Errorf("VLAN trunking of VF %d of device %s requires its representor %s to be a port of a Linux bridge",
+			vfID, iface.PciAddress, repName)
+	}
+	current, err := s.getBridgePortVlanTrunk(repLink)
+	if err != nil {
+		return fmt.Errorf("failed to read the VLAN trunk of VF %d of device %s: %w", vfID, iface.PciAddress, err)
+	}
+	add, remove := sriovnetworkv1.VlanTrunkDiff(current, desired)
+	log.Log.V(2).Info("setVfRepresentorVlanTrunk(): set VF VLAN trunk", "device", iface.PciAddress, "vf", vfID,
+		"representor", repName, "trunk", group.VlanTrunk,
+		"add", sriovnetworkv1.FormatVlanTrunk(add), "remove", sriovnetworkv1.FormatVlanTrunk(remove))
+	for _, r := range remove {
+		for vlan := r.Start; vlan <= r.End; vlan++ {
+			if err := s.netlinkLib.BridgeVlanDel(repLink, uint16(vlan), false, false, false, true); err != nil {
+				return fmt.Errorf("failed to remove VLAN %d from the trunk of VF %d of device %s: %w", vlan, vfID, iface.PciAddress, err)
+			}
+		}
+	}
+	for _, r := range add {
+		for vlan := r.Start; vlan <= r.End; vlan++ {
+			if err := s.netlinkLib.BridgeVlanAdd(repLink, uint16(vlan), false, false, false, true); err != nil {
+				return fmt.Errorf("failed to add VLAN %d to the trunk of VF %d of device %s: %w", vlan, vfID, iface.PciAddress, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getBridgePort returns the link if it is a port of a Linux bridge, nil otherwise, e.g. for the ports of OVS
+// which manages the VLANs of its ports itself
+func (s *sriov) getBridgePort(name string) (netlinkPkg.Link, error) {
+	link, err := s.netlinkLib.LinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	master := link.Attrs().MasterIndex
+	if master == 0 {
+		return nil, nil
+	}
+	masterLink, err := s.netlinkLib.LinkByIndex(master)
+	if err != nil {
+		return nil, err
+	}
+	if masterLink.Type() != "bridge" {
+		return nil, nil
+	}
+	return link, nil
+}
+
+// getBridgePortVlanTrunk returns the tagged VLANs of the bridge port, the PVID and the untagged VLANs
+// are not part of the trunk
+func (s *sriov) getBridgePortVlanTrunk(link netlinkPkg.Link) ([]sriovnetworkv1.VlanRange, error) {
+	vlans, err := s.netlinkLib.BridgeVlanList()
+	if err != nil {
+		return nil, err
+	}
+	tagged := []string{}
+	for _, info := range vlans[int32(link.Attrs().Index)] {
+		if info.PortVID() || info.EngressUntag() {
+			continue
+		}
+		tagged = append(tagged, strconv.Itoa(int(info.Vid)))
+	}
+	if len(tagged) == 0 {
+		return []sriovnetworkv1.VlanRange{}, nil
+	}
+	return sriovnetworkv1.ParseVlanTrunk(strings.Join(tagged, ","))
+}
+
+// parseVfVlanTrunk parses the VLAN trunk of the VF as reported by the PF driver, either in the
+// "100-200,300" format or as the list of the VLANs following mlxVlanTrunkPrefix
+func parseVfVlanTrunk(data string) ([]sriovnetworkv1.VlanRange, error) {
+	value := strings.TrimSpace(data)
+	if vlans, found := strings.CutPrefix(value, mlxVlanTrunkPrefix); found {
+		value = strings.Join(strings.Fields(vlans), ",")
+	}
+	if value == "" {
+		return []sriovnetworkv1.VlanRange{}, nil
+	}
+	return sriovnetworkv1.ParseVlanTrunk(value)
+}
+
+// checkVfVlanTrunk returns an error if the PF driver can't program the VLAN trunk requested by the VF group,
+// the trunk carries the inner VLANs (QinQ) when the group sets an 802.1ad VLAN and can't be combined with
+// an 802.1q VLAN
+func checkVfVlanTrunk(pfDriver string, group *sriovnetworkv1.VfGroup) error {
+	isIntel := sriovnetworkv1.StringInArray(pfDriver, vfVlanTrunkIntelDrivers)
+	if !isIntel && !sriovnetworkv1.StringInArray(pfDriver, vfVlanTrunkMellanoxDrivers) {
+		return fmt.Errorf("driver %s doesn't support VLAN trunking on the VFs", pfDriver)
+	}
+	if group.VlanID == nil || *group.VlanID == 0 {
+		return nil
+	}
+	if sriovnetworkv1.GetVlanProto(group.VlanProto) != sriovnetworkv1.VlanProto8021ad {
+		return fmt.Errorf("VLAN trunk %s can't be combined with the 802.1q VLAN %d, use an 802.1ad VLAN for QinQ",
+			group.VlanTrunk, *group.VlanID)
+	}
+	if !isIntel {
+		return fmt.Errorf("driver %s doesn't support QinQ with a VLAN trunk on the VFs", pfDriver)
+	}
+	return nil
+}
+
+// setVfSysfsVlanTrunk programs the VLAN trunk requested by the VF group on the VF through the sysfs file of
+// the out-of-tree vendor PF drivers, only the VLANs that differ from the current trunk are added and removed
+func setVfSysfsVlanTrunk(pfDriver, pfAddr string, vfID int, group *sriovnetworkv1.VfGroup) error {
+	if group.VlanTrunk != "" {
+		if err := checkVfVlanTrunk(pfDriver, group); err != nil {
+			return fmt.Errorf("invalid VLAN trunk for VF %d of device %s: %w", vfID, pfAddr, err)
+		}
+	}
+	desired, err := desiredVfVlanTrunk(group)
+	if err != nil {
+		return err
+	}
+	path := vfVlanTrunkPath(pfAddr, vfID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// the VFs of the drivers which don't expose the trunk have no trunk to clear
+			if group.VlanTrunk == "" {
+				return nil
+			}
+			return fmt.Errorf("VLAN trunking of VF %d of device %s in legacy mode requires an out-of-tree vendor driver exposing %s, "+
+				"use the switchdev mode to trunk the VLANs on the bridge port of the VF representor", vfID, pfAddr, path)
+		}
+		return err
+	}
+	current, err := parseVfVlanTrunk(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to read the VLAN trunk of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	add, remove := sriovnetworkv1.VlanTrunkDiff(current, desired)
+	log.Log.V(2).Info("setVfVlanTrunk(): set VF VLAN trunk", "device", pfAddr, "vf", vfID, "trunk", group.VlanTrunk,
+		"add", sriovnetworkv1.FormatVlanTrunk(add), "remove", sriovnetworkv1.FormatVlanTrunk(remove))
+	if err := writeVfVlanTrunk(pfDriver, path, "rem", remove); err != nil {
+		return fmt.Errorf("failed to remove VLANs from the trunk of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	if err := writeVfVlanTrunk(pfDriver, path, "add", add); err != nil {
+		return fmt.Errorf("failed to add VLANs to the trunk of VF %d of device %s: %w", vfID, pfAddr, err)
+	}
+	return nil
+}
+
+// writeVfVlanTrunk runs the trunk command on the ranges of VLANs in the format of the PF driver
+func writeVfVlanTrunk(pfDriver, path, command string, ranges []sriovnetworkv1.VlanRange) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	if sriovnetworkv1.StringInArray(pfDriver, vfVlanTrunkIntelDrivers) {
+		return os.WriteFile(path, []byte(command+" "+sriovnetworkv1.FormatVlanTrunk(ranges)), os.ModeAppend)
+	}
+	for _, r := range ranges {
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("%s %d %d", command, r.Start, r.End)), os.ModeAppend); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getVfSysfsVlanTrunk returns the VLAN trunk of the VF exposed by the out-of-tree vendor PF drivers in the format
+// of the VF groups, an empty string is returned when the trunk is empty or can't be read
+func getVfSysfsVlanTrunk(pfAddr string, vfID int) string {
+	data, err := os.ReadFile(vfVlanTrunkPath(pfAddr, vfID))
+	if err != nil {
+		return ""
+	}
+	ranges, err := parseVfVlanTrunk(string(data))
+	if err != nil {
+		return ""
+	}
+	return sriovnetworkv1.FormatVlanTrunk(ranges)
+}
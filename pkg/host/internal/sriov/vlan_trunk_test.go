@@ -0,0 +1,82 @@
+package sriov
+
+import (
+	"k8s.io/utils/pointer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("VF VLAN trunk", func() {
+	const trunkPath = "/sys/bus/pci/devices/0000:d8:00.0/sriov/1/trunk"
+
+	configureFS := func(trunk string) {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+			Dirs:  []string{"/sys/bus/pci/devices/0000:d8:00.0/sriov/1"},
+			Files: map[string][]byte{trunkPath: []byte(trunk)},
+		})
+	}
+
+	It("should add the missing VLANs in the format of the Intel drivers", func() {
+		configureFS("")
+		Expect(setVfSysfsVlanTrunk("ice", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "300,100-200"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "add 100-200,300")
+	})
+
+	It("should add the missing VLANs one range at a time for the Mellanox drivers", func() {
+		configureFS(mlxVlanTrunkPrefix + " 100 101 102\n")
+		Expect(setVfSysfsVlanTrunk("mlx5_core", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100-110"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "add 103 110")
+	})
+
+	It("should remove the VLANs missing from the group", func() {
+		configureFS("100-200,300\n")
+		Expect(setVfSysfsVlanTrunk("i40e", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100-200"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "rem 300")
+	})
+
+	It("should not write the trunk when it matches", func() {
+		configureFS("100-200")
+		Expect(setVfSysfsVlanTrunk("i40e", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100-200"})).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "100-200")
+	})
+
+	It("should program the inner VLANs of QinQ with the Intel drivers", func() {
+		configureFS("")
+		group := &sriovnetworkv1.VfGroup{VlanID: pointer.Int(10), VlanProto: "802.1ad", VlanTrunk: "100"}
+		Expect(setVfSysfsVlanTrunk("ice", "0000:d8:00.0", 1, group)).To(Succeed())
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "add 100")
+	})
+
+	It("should reject the unsupported combinations", func() {
+		configureFS("")
+		Expect(setVfSysfsVlanTrunk("bnxt_en", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100"})).To(
+			MatchError("invalid VLAN trunk for VF 1 of device 0000:d8:00.0: driver bnxt_en doesn't support VLAN trunking on the VFs"))
+		Expect(setVfSysfsVlanTrunk("ice", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanID: pointer.Int(10), VlanTrunk: "100"})).To(
+			MatchError(ContainSubstring("can't be combined with the 802.1q VLAN 10")))
+		group := &sriovnetworkv1.VfGroup{VlanID: pointer.Int(10), VlanProto: "802.1ad", VlanTrunk: "100"}
+		Expect(setVfSysfsVlanTrunk("mlx5_core", "0000:d8:00.0", 1, group)).To(
+			MatchError(ContainSubstring("driver mlx5_core doesn't support QinQ with a VLAN trunk on the VFs")))
+		helpers.GinkgoAssertFileContentsEquals(trunkPath, "")
+	})
+
+	It("should require an out-of-tree vendor driver", func() {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/sys/bus/pci/devices/0000:d8:00.0"}})
+		Expect(setVfSysfsVlanTrunk("ice", "0000:d8:00.0", 1, &sriovnetworkv1.VfGroup{VlanTrunk: "100"})).To(
+			MatchError(ContainSubstring("requires an out-of-tree vendor driver")))
+		Expect(getVfSysfsVlanTrunk("0000:d8:00.0", 1)).To(BeEmpty())
+	})
+
+	It("should report the trunk of the VF", func() {
+		configureFS("300,100-200\n")
+		Expect(getVfSysfsVlanTrunk("0000:d8:00.0", 1)).To(Equal("100-200,300"))
+		configureFS(mlxVlanTrunkPrefix + " 100 101 102 300\n")
+		Expect(getVfSysfsVlanTrunk("0000:d8:00.0", 1)).To(Equal("100-102,300"))
+		configureFS(mlxVlanTrunkPrefix + "\n")
+		Expect(getVfSysfsVlanTrunk("0000:d8:00.0", 1)).To(BeEmpty())
+	})
+})
@@ -23,8 +23,8 @@ func TestUdev(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	vars.SupportedVfIds = []string{"0x1017", "0x1018"}
+	vars.SetSupportedVfIds([]string{"0x1017", "0x1018"})
 	DeferCleanup(func() {
-		vars.SupportedVfIds = []string{}
+		vars.SetSupportedVfIds(nil)
 	})
 })
@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -15,8 +16,26 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 )
 
+const (
+	// udevRuleFileMode is the mode of the rule files, udev only needs to read them
+	udevRuleFileMode = 0644
+	// udevRuleDirMode is the mode of the rule folder when it is created
+	udevRuleDirMode = 0755
+
+	// nmDisableRuleName is the name of the rules disabling NetworkManager for the VFs of a PF
+	nmDisableRuleName = "10-nm-disable"
+
+	networkManagerService = "NetworkManager"
+	networkdService       = "systemd-networkd"
+)
+
 type udev struct {
 	utilsHelper utils.CmdInterface
+	// networkdActive is true when the host network is managed by systemd-networkd instead of NetworkManager
+	networkdActive bool
+	// nmRulesLock serializes the writes of the rules disabling NetworkManager with the reload of
+	// the supported VF IDs, so a rule is never written with a stale list
+	nmRulesLock sync.Mutex
 }
 
 func New(utilsHelper utils.CmdInterface) types.UdevInterface {
@@ -45,10 +64,21 @@ func (u *udev) PrepareNMUdevRule(supportedVfIds []string) error {
 	log.Log.V(2).Info("PrepareNMUdevRule()", "stdout", stdout)
 
 	//save the device list to use for udev rules
-	vars.SupportedVfIds = supportedVfIds
+	vars.SetSupportedVfIds(supportedVfIds)
+
+	// NetworkManager remains the default, systemd-networkd is used only when it is the active one
+	u.networkdActive = !u.isServiceActive(networkManagerService) && u.isServiceActive(networkdService)
+	log.Log.V(2).Info("PrepareNMUdevRule()", "networkdActive", u.networkdActive)
 	return nil
 }
 
+// isServiceActive returns true if the systemd service is active on the host
+func (u *udev) isServiceActive(service string) bool {
+	_, _, err := u.utilsHelper.RunCommand("/bin/sh", "-c",
+		fmt.Sprintf("%s systemctl is-active --quiet %s", utils.GetChrootExtension(), service))
+	return err == nil
+}
+
 // PrepareVFRepUdevRule creates a script which helps to configure representor name for the VF
 func (u *udev) PrepareVFRepUdevRule() error {
 	log.Log.V(2).Info("PrepareVFRepUdevRule()")
@@ -69,24 +99,96 @@ func (u *udev) PrepareVFRepUdevRule() error {
 	return nil
 }
 
-// AddDisableNMUdevRule adds udev rule that disables NetworkManager for VFs on the concrete PF:
+// AddDisableNMUdevRule adds udev rule that disables NetworkManager for VFs on the concrete PF,
+// on hosts managed by systemd-networkd the VFs tagged by the rule are also made unmanaged by networkd
 func (u *udev) AddDisableNMUdevRule(pfPciAddress string) error {
 	log.Log.V(2).Info("AddDisableNMUdevRule()", "device", pfPciAddress)
-	udevRuleContent := fmt.Sprintf(consts.NMUdevRule, strings.Join(vars.SupportedVfIds, "|"), pfPciAddress)
-	return u.addUdevRule(pfPciAddress, "10-nm-disable", udevRuleContent)
+	u.nmRulesLock.Lock()
+	err := u.addDisableNMUdevRule(pfPciAddress, vars.GetSupportedVfIds())
+	u.nmRulesLock.Unlock()
+	if err != nil {
+		return err
+	}
+	if u.networkdActive {
+		return u.addNetworkdUnmanagedConf(pfPciAddress)
+	}
+	return nil
+}
+
+// addDisableNMUdevRule writes the rule disabling NetworkManager for the VFs of the PF with the provided IDs
+func (u *udev) addDisableNMUdevRule(pfPciAddress string, supportedVfIds []string) error {
+	udevRuleContent := fmt.Sprintf(consts.NMUdevRule, strings.Join(supportedVfIds, "|"), pfPciAddress)
+	return u.addUdevRuleForPF(pfPciAddress, nmDisableRuleName, udevRuleContent)
+}
+
+// ReloadSupportedVfIds updates the list of supported virtual functions IDs
+// and rewrites the existing rules disabling NetworkManager to match the new list
+func (u *udev) ReloadSupportedVfIds(supportedVfIds []string) error {
+	log.Log.V(2).Info("ReloadSupportedVfIds()", "supportedVfIds", supportedVfIds)
+	u.nmRulesLock.Lock()
+	defer u.nmRulesLock.Unlock()
+	vars.SetSupportedVfIds(supportedVfIds)
+
+	prefix := nmDisableRuleName + "-"
+	rules, err := filepath.Glob(filepath.Join(u.getRuleFolderPath(), prefix+"*.rules"))
+	if err != nil {
+		log.Log.Error(err, "ReloadSupportedVfIds(): failed to list existing rules")
+		return err
+	}
+	for _, rule := range rules {
+		pfPciAddress := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(rule), prefix), ".rules")
+		if err := u.addDisableNMUdevRule(pfPciAddress, supportedVfIds); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // RemoveDisableNMUdevRule removes udev rule that disables NetworkManager for VFs on the concrete PF
+// and the systemd-networkd configuration if any
 func (u *udev) RemoveDisableNMUdevRule(pfPciAddress string) error {
 	log.Log.V(2).Info("RemoveDisableNMUdevRule()", "device", pfPciAddress)
-	return u.removeUdevRule(pfPciAddress, "10-nm-disable")
+	if err := u.removeUdevRule(pfPciAddress, nmDisableRuleName); err != nil {
+		return err
+	}
+	confPath := u.getNetworkdConfPathForPF(pfPciAddress)
+	if err := os.Remove(confPath); err != nil && !os.IsNotExist(err) {
+		log.Log.Error(err, "RemoveDisableNMUdevRule(): fail to remove networkd configuration", "path", confPath)
+		return err
+	}
+	return nil
+}
+
+// addNetworkdUnmanagedConf writes the systemd-networkd configuration leaving the VFs of the PF unmanaged
+// and reloads networkd if the configuration changed
+func (u *udev) addNetworkdUnmanagedConf(pfPciAddress string) error {
+	confDir := filepath.Join(vars.FilesystemRoot, consts.NetworkdConfFolder)
+	if err := os.MkdirAll(confDir, udevRuleDirMode); err != nil {
+		log.Log.Error(err, "addNetworkdUnmanagedConf(): failed to create dir", "path", confDir)
+		return err
+	}
+	confPath := u.getNetworkdConfPathForPF(pfPciAddress)
+	content := fmt.Sprintf(consts.NetworkdUnmanagedConf, pfPciAddress)
+	if current, err := os.ReadFile(confPath); err == nil && string(current) == content {
+		return nil
+	}
+	if err := os.WriteFile(confPath, []byte(content), udevRuleFileMode); err != nil {
+		log.Log.Error(err, "addNetworkdUnmanagedConf(): fail to write file", "path", confPath)
+		return err
+	}
+	if _, stderr, err := u.utilsHelper.RunCommand("/bin/sh", "-c",
+		fmt.Sprintf("%s networkctl reload", utils.GetChrootExtension())); err != nil {
+		log.Log.Error(err, "addNetworkdUnmanagedConf(): failed to reload systemd-networkd", "error", stderr)
+		return err
+	}
+	return nil
 }
 
 // AddPersistPFNameUdevRule add udev rule that preserves PF name after switching to switchdev mode
 func (u *udev) AddPersistPFNameUdevRule(pfPciAddress, pfName string) error {
 	log.Log.V(2).Info("AddPersistPFNameUdevRule()", "device", pfPciAddress)
 	udevRuleContent := fmt.Sprintf(consts.PFNameUdevRule, pfPciAddress, pfName)
-	return u.addUdevRule(pfPciAddress, "10-pf-name", udevRuleContent)
+	return u.addUdevRuleForPF(pfPciAddress, "10-pf-name", udevRuleContent)
 }
 
 // RemovePersistPFNameUdevRule removes udev rule that preserves PF name after switching to switchdev mode
@@ -100,7 +202,7 @@ func (u *udev) AddVfRepresentorUdevRule(pfPciAddress, pfName, pfSwitchID, pfSwit
 	log.Log.V(2).Info("AddVfRepresentorUdevRule()",
 		"device", pfPciAddress, "name", pfName, "switch", pfSwitchID, "port", pfSwitchPort)
 	udevRuleContent := fmt.Sprintf(consts.SwitchdevUdevRule, pfSwitchID, strings.TrimPrefix(pfSwitchPort, "p"), pfName)
-	return u.addUdevRule(pfPciAddress, "20-switchdev", udevRuleContent)
+	return u.addUdevRuleForPF(pfPciAddress, "20-switchdev", udevRuleContent)
 }
 
 // RemoveVfRepresentorUdevRule removes udev rule that renames VF representors on the concrete PF
@@ -126,19 +228,42 @@ func (u *udev) LoadUdevRules() error {
 	return nil
 }
 
-func (u *udev) addUdevRule(pfPciAddress, ruleName, ruleContent string) error {
+// addUdevRule writes the rule file of the PF, the file is left untouched if it already has the
+// requested content to not trigger udev change events, returns true if the file was written
+func (u *udev) addUdevRule(pfPciAddress, ruleName, ruleContent string) (bool, error) {
 	log.Log.V(2).Info("addUdevRule()", "device", pfPciAddress, "rule", ruleName)
 	rulePath := u.getRuleFolderPath()
-	err := os.MkdirAll(rulePath, os.ModePerm)
+	err := os.MkdirAll(rulePath, udevRuleDirMode)
 	if err != nil && !os.IsExist(err) {
 		log.Log.Error(err, "ensureUdevRulePathExist(): failed to create dir", "path", rulePath)
-		return err
+		return false, err
 	}
 	filePath := u.getRulePathForPF(ruleName, pfPciAddress)
-	if err := os.WriteFile(filePath, []byte(ruleContent), 0666); err != nil {
+	changed := true
+	if current, err := os.ReadFile(filePath); err == nil && string(current) == ruleContent {
+		log.Log.V(2).Info("addUdevRule(): rule is up to date", "path", filePath)
+		changed = false
+	} else if err := os.WriteFile(filePath, []byte(ruleContent), udevRuleFileMode); err != nil {
 		log.Log.Error(err, "addUdevRule(): fail to write file", "path", filePath)
+		return false, err
+	}
+	// WriteFile doesn't change the mode of an existing file, fix the world-writable rules written by older versions
+	if err := os.Chmod(filePath, udevRuleFileMode); err != nil {
+		log.Log.Error(err, "addUdevRule(): fail to set file mode", "path", filePath)
+		return false, err
+	}
+	return changed, nil
+}
+
+// addUdevRuleForPF writes the rule file of the PF and logs if the rule was updated
+func (u *udev) addUdevRuleForPF(pfPciAddress, ruleName, ruleContent string) error {
+	changed, err := u.addUdevRule(pfPciAddress, ruleName, ruleContent)
+	if err != nil {
 		return err
 	}
+	if changed {
+		log.Log.Info("udev rule updated", "device", pfPciAddress, "rule", ruleName)
+	}
 	return nil
 }
 
@@ -157,6 +282,10 @@ func (u *udev) getRuleFolderPath() string {
 	return filepath.Join(vars.FilesystemRoot, consts.UdevRulesFolder)
 }
 
+func (u *udev) getNetworkdConfPathForPF(pfPciAddress string) string {
+	return filepath.Join(vars.FilesystemRoot, consts.NetworkdConfFolder, fmt.Sprintf("10-sriov-unmanaged-%s.network", pfPciAddress))
+}
+
 func (u *udev) getRulePathForPF(ruleName, pfPciAddress string) string {
 	return path.Join(u.getRuleFolderPath(), fmt.Sprintf("%s-%s.rules", ruleName, pfPciAddress))
 }
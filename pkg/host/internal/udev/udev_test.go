@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
@@ -11,6 +13,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/host/types"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
 	utilsMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils/mock"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
@@ -67,6 +70,58 @@ var _ = Describe("UDEV", func() {
 				testExpectedNMUdevRule)
 		})
 	})
+	Context("ReloadSupportedVfIds", func() {
+		AfterEach(func() {
+			vars.SetSupportedVfIds([]string{"0x1017", "0x1018"})
+		})
+		It("Rewrite existing rules", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs: []string{"/etc/udev/rules.d"},
+				Files: map[string][]byte{
+					"/etc/udev/rules.d/10-nm-disable-0000:d8:00.0.rules": []byte(testExpectedNMUdevRule),
+					"/etc/udev/rules.d/10-pf-name-0000:d8:00.0.rules":    []byte(testExpectedPFUdevRule),
+				},
+			})
+			Expect(s.ReloadSupportedVfIds([]string{"0x1017", "0x1018", "0x101e"})).To(Succeed())
+			Expect(vars.GetSupportedVfIds()).To(Equal([]string{"0x1017", "0x1018", "0x101e"}))
+			helpers.GinkgoAssertFileContentsEquals(
+				"/etc/udev/rules.d/10-nm-disable-0000:d8:00.0.rules",
+				`SUBSYSTEM=="net", ACTION=="add|change|move", ATTRS{device}=="0x1017|0x1018|0x101e", `+
+					`IMPORT{program}="/etc/udev/disable-nm-sriov.sh $env{INTERFACE} 0000:d8:00.0"`)
+			helpers.GinkgoAssertFileContentsEquals(
+				"/etc/udev/rules.d/10-pf-name-0000:d8:00.0.rules",
+				testExpectedPFUdevRule)
+			// new rules use the reloaded list
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.1")).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals(
+				"/etc/udev/rules.d/10-nm-disable-0000:d8:00.1.rules",
+				`SUBSYSTEM=="net", ACTION=="add|change|move", ATTRS{device}=="0x1017|0x1018|0x101e", `+
+					`IMPORT{program}="/etc/udev/disable-nm-sriov.sh $env{INTERFACE} 0000:d8:00.1"`)
+		})
+		It("No rules", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+			Expect(s.ReloadSupportedVfIds([]string{"0x1017"})).To(Succeed())
+			Expect(vars.GetSupportedVfIds()).To(Equal([]string{"0x1017"}))
+		})
+		It("Concurrent access", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					vars.SetSupportedVfIds([]string{"0x1017", "0x1018"})
+				}()
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(vars.GetSupportedVfIds()).To(HaveLen(2))
+				}()
+			}
+			wg.Wait()
+		})
+	})
 	Context("RemoveDisableNMUdevRule", func() {
 		It("Exist", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
@@ -87,6 +142,66 @@ var _ = Describe("UDEV", func() {
 			Expect(s.RemoveDisableNMUdevRule("0000:d8:00.0")).To(BeNil())
 		})
 	})
+	Context("systemd-networkd", func() {
+		confPath := "/etc/systemd/network/10-sriov-unmanaged-0000:d8:00.0.network"
+		prepare := func(nmActive, networkdActive bool) {
+			isActive := func(active bool) error {
+				if active {
+					return nil
+				}
+				return testError
+			}
+			utilsMock.EXPECT().RunCommand("/bin/bash", gomock.Any()).Return("", "", nil)
+			utilsMock.EXPECT().RunCommand("/bin/sh", "-c", gomock.Any()).DoAndReturn(
+				func(_ string, args ...string) (string, string, error) {
+					Expect(args[1]).To(HaveSuffix("systemctl is-active --quiet NetworkManager"))
+					return "", "", isActive(nmActive)
+				})
+			if !nmActive {
+				utilsMock.EXPECT().RunCommand("/bin/sh", "-c", gomock.Any()).DoAndReturn(
+					func(_ string, args ...string) (string, string, error) {
+						Expect(args[1]).To(HaveSuffix("systemctl is-active --quiet systemd-networkd"))
+						return "", "", isActive(networkdActive)
+					})
+			}
+			Expect(s.PrepareNMUdevRule([]string{"0x1017", "0x1018"})).To(Succeed())
+		}
+		BeforeEach(func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+		})
+		It("NetworkManager is the default", func() {
+			prepare(false, false)
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.0")).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals("/etc/udev/rules.d/10-nm-disable-0000:d8:00.0.rules", testExpectedNMUdevRule)
+			_, err := os.Stat(filepath.Join(vars.FilesystemRoot, confPath))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+		It("NetworkManager preferred when both are active", func() {
+			prepare(true, true)
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.0")).To(Succeed())
+			_, err := os.Stat(filepath.Join(vars.FilesystemRoot, confPath))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+		It("Unmanaged configuration created and removed", func() {
+			prepare(false, true)
+			utilsMock.EXPECT().RunCommand("/bin/sh", "-c", utils.GetChrootExtension()+" networkctl reload").Return("", "", nil)
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.0")).To(Succeed())
+			// the configuration is up to date, networkd is not reloaded again
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.0")).To(Succeed())
+			helpers.GinkgoAssertFileContentsEquals("/etc/udev/rules.d/10-nm-disable-0000:d8:00.0.rules", testExpectedNMUdevRule)
+			helpers.GinkgoAssertFileContentsEquals(confPath,
+				"[Match]\nProperty=SRIOV_PF=0000:d8:00.0\n\n[Link]\nUnmanaged=yes\n")
+
+			Expect(s.RemoveDisableNMUdevRule("0000:d8:00.0")).To(Succeed())
+			_, err := os.Stat(filepath.Join(vars.FilesystemRoot, confPath))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+		It("Failed to reload networkd", func() {
+			prepare(false, true)
+			utilsMock.EXPECT().RunCommand("/bin/sh", "-c", utils.GetChrootExtension()+" networkctl reload").Return("", "error", testError)
+			Expect(s.AddDisableNMUdevRule("0000:d8:00.0")).To(MatchError(testError))
+		})
+	})
 	Context("AddPersistPFNameUdevRule", func() {
 		It("Created", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
@@ -109,6 +224,47 @@ var _ = Describe("UDEV", func() {
 				testExpectedPFUdevRule)
 		})
 	})
+	Context("udev rule file", func() {
+		rulePath := "/etc/udev/rules.d/10-pf-name-0000:d8:00.0.rules"
+		It("Created with restrictive permissions", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+			Expect(s.AddPersistPFNameUdevRule("0000:d8:00.0", "enp129")).To(BeNil())
+			info, err := os.Stat(filepath.Join(vars.FilesystemRoot, rulePath))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0644)))
+			dirInfo, err := os.Stat(filepath.Join(vars.FilesystemRoot, "/etc/udev/rules.d"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dirInfo.Mode().Perm()).To(Equal(os.FileMode(0755)))
+		})
+		It("Up to date rule is not rewritten", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
+				Dirs:  []string{"/etc/udev/rules.d"},
+				Files: map[string][]byte{rulePath: []byte(testExpectedPFUdevRule)},
+			})
+			fullPath := filepath.Join(vars.FilesystemRoot, rulePath)
+			Expect(os.Chmod(fullPath, 0666)).To(Succeed())
+			past := time.Now().Add(-time.Hour).Truncate(time.Second)
+			Expect(os.Chtimes(fullPath, past, past)).To(Succeed())
+			Expect(s.AddPersistPFNameUdevRule("0000:d8:00.0", "enp129")).To(BeNil())
+			info, err := os.Stat(fullPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime()).To(Equal(past))
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0644)))
+		})
+		It("Reports whether the rule changed", func() {
+			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{})
+			changed, err := s.(*udev).addUdevRule("0000:d8:00.0", "10-pf-name", testExpectedPFUdevRule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			changed, err = s.(*udev).addUdevRule("0000:d8:00.0", "10-pf-name", testExpectedPFUdevRule)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			changed, err = s.(*udev).addUdevRule("0000:d8:00.0", "10-pf-name", "something")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			helpers.GinkgoAssertFileContentsEquals(rulePath, "something")
+		})
+	})
 	Context("RemovePersistPFNameUdevRule", func() {
 		It("Exist", func() {
 			helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{
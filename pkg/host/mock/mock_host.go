@@ -5,7 +5,10 @@
 package mock_host
 
 import (
+	context "context"
+	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	v1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
@@ -121,6 +124,20 @@ func (mr *MockHostManagerInterfaceMockRecorder) BindDriverByBusAndDevice(bus, de
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindDriverByBusAndDevice", reflect.TypeOf((*MockHostManagerInterface)(nil).BindDriverByBusAndDevice), bus, device, driver)
 }
 
+// CheckSriovPrerequisites mocks base method.
+func (m *MockHostManagerInterface) CheckSriovPrerequisites(pciAddr string) []types.PrereqResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSriovPrerequisites", pciAddr)
+	ret0, _ := ret[0].([]types.PrereqResult)
+	return ret0
+}
+
+// CheckSriovPrerequisites indicates an expected call of CheckSriovPrerequisites.
+func (mr *MockHostManagerInterfaceMockRecorder) CheckSriovPrerequisites(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSriovPrerequisites", reflect.TypeOf((*MockHostManagerInterface)(nil).CheckSriovPrerequisites), pciAddr)
+}
+
 // CompareServices mocks base method.
 func (m *MockHostManagerInterface) CompareServices(serviceA, serviceB *types.Service) (bool, error) {
 	m.ctrl.T.Helper()
@@ -151,17 +168,32 @@ func (mr *MockHostManagerInterfaceMockRecorder) ConfigSriovDeviceVirtual(iface i
 }
 
 // ConfigSriovInterfaces mocks base method.
-func (m *MockHostManagerInterface) ConfigSriovInterfaces(storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt, skipVFConfiguration bool) error {
+func (m *MockHostManagerInterface) ConfigSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt, skipVFConfiguration bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ConfigSriovInterfaces", storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+	ret := m.ctrl.Call(m, "ConfigSriovInterfaces", ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ConfigSriovInterfaces indicates an expected call of ConfigSriovInterfaces.
-func (mr *MockHostManagerInterfaceMockRecorder) ConfigSriovInterfaces(storeManager, interfaces, ifaceStatuses, skipVFConfiguration interface{}) *gomock.Call {
+func (mr *MockHostManagerInterfaceMockRecorder) ConfigSriovInterfaces(ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfaces", reflect.TypeOf((*MockHostManagerInterface)(nil).ConfigSriovInterfaces), ctx, storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+}
+
+// ConfigSriovInterfacesDryRun mocks base method.
+func (m *MockHostManagerInterface) ConfigSriovInterfacesDryRun(storeManager store.ManagerInterface, interfaces []v1.Interface, ifaceStatuses []v1.InterfaceExt) ([]types.PlannedChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigSriovInterfacesDryRun", storeManager, interfaces, ifaceStatuses)
+	ret0, _ := ret[0].([]types.PlannedChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigSriovInterfacesDryRun indicates an expected call of ConfigSriovInterfacesDryRun.
+func (mr *MockHostManagerInterfaceMockRecorder) ConfigSriovInterfacesDryRun(storeManager, interfaces, ifaceStatuses interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfaces", reflect.TypeOf((*MockHostManagerInterface)(nil).ConfigSriovInterfaces), storeManager, interfaces, ifaceStatuses, skipVFConfiguration)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigSriovInterfacesDryRun", reflect.TypeOf((*MockHostManagerInterface)(nil).ConfigSriovInterfacesDryRun), storeManager, interfaces, ifaceStatuses)
 }
 
 // CreateVDPADevice mocks base method.
@@ -192,6 +224,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) DeleteVDPADevice(pciAddr interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVDPADevice", reflect.TypeOf((*MockHostManagerInterface)(nil).DeleteVDPADevice), pciAddr)
 }
 
+// DiscoverSriovDevice mocks base method.
+func (m *MockHostManagerInterface) DiscoverSriovDevice(storeManager store.ManagerInterface, pciAddr string) (*v1.InterfaceExt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverSriovDevice", storeManager, pciAddr)
+	ret0, _ := ret[0].(*v1.InterfaceExt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverSriovDevice indicates an expected call of DiscoverSriovDevice.
+func (mr *MockHostManagerInterfaceMockRecorder) DiscoverSriovDevice(storeManager, pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevice", reflect.TypeOf((*MockHostManagerInterface)(nil).DiscoverSriovDevice), storeManager, pciAddr)
+}
+
 // DiscoverSriovDevices mocks base method.
 func (m *MockHostManagerInterface) DiscoverSriovDevices(storeManager store.ManagerInterface) ([]v1.InterfaceExt, error) {
 	m.ctrl.T.Helper()
@@ -207,6 +254,22 @@ func (mr *MockHostManagerInterfaceMockRecorder) DiscoverSriovDevices(storeManage
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevices", reflect.TypeOf((*MockHostManagerInterface)(nil).DiscoverSriovDevices), storeManager)
 }
 
+// DiscoverSriovDevicesVerbose mocks base method.
+func (m *MockHostManagerInterface) DiscoverSriovDevicesVerbose(storeManager store.ManagerInterface) ([]v1.InterfaceExt, []types.FilteredDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverSriovDevicesVerbose", storeManager)
+	ret0, _ := ret[0].([]v1.InterfaceExt)
+	ret1, _ := ret[1].([]types.FilteredDevice)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DiscoverSriovDevicesVerbose indicates an expected call of DiscoverSriovDevicesVerbose.
+func (mr *MockHostManagerInterfaceMockRecorder) DiscoverSriovDevicesVerbose(storeManager interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverSriovDevicesVerbose", reflect.TypeOf((*MockHostManagerInterface)(nil).DiscoverSriovDevicesVerbose), storeManager)
+}
+
 // DiscoverVDPAType mocks base method.
 func (m *MockHostManagerInterface) DiscoverVDPAType(pciAddr string) string {
 	m.ctrl.T.Helper()
@@ -279,6 +342,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) EnableService(service interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableService", reflect.TypeOf((*MockHostManagerInterface)(nil).EnableService), service)
 }
 
+// ExportConfigScript mocks base method.
+func (m *MockHostManagerInterface) ExportConfigScript(state *v1.SriovNetworkNodeState) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportConfigScript", state)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportConfigScript indicates an expected call of ExportConfigScript.
+func (mr *MockHostManagerInterfaceMockRecorder) ExportConfigScript(state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportConfigScript", reflect.TypeOf((*MockHostManagerInterface)(nil).ExportConfigScript), state)
+}
+
 // GetCurrentKernelArgs mocks base method.
 func (m *MockHostManagerInterface) GetCurrentKernelArgs() (string, error) {
 	m.ctrl.T.Helper()
@@ -309,6 +387,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetDevlinkDeviceParam(pciAddr, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevlinkDeviceParam", reflect.TypeOf((*MockHostManagerInterface)(nil).GetDevlinkDeviceParam), pciAddr, paramName)
 }
 
+// GetDevlinkOffloadVfLimit mocks base method.
+func (m *MockHostManagerInterface) GetDevlinkOffloadVfLimit(pciAddr string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevlinkOffloadVfLimit", pciAddr)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDevlinkOffloadVfLimit indicates an expected call of GetDevlinkOffloadVfLimit.
+func (mr *MockHostManagerInterfaceMockRecorder) GetDevlinkOffloadVfLimit(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevlinkOffloadVfLimit", reflect.TypeOf((*MockHostManagerInterface)(nil).GetDevlinkOffloadVfLimit), pciAddr)
+}
+
 // GetDriverByBusAndDevice mocks base method.
 func (m *MockHostManagerInterface) GetDriverByBusAndDevice(bus, device string) (string, error) {
 	m.ctrl.T.Helper()
@@ -324,6 +417,36 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetDriverByBusAndDevice(bus, dev
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverByBusAndDevice", reflect.TypeOf((*MockHostManagerInterface)(nil).GetDriverByBusAndDevice), bus, device)
 }
 
+// GetInterfaceCapabilities mocks base method.
+func (m *MockHostManagerInterface) GetInterfaceCapabilities(pciAddr string) (v1.InterfaceCapabilities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInterfaceCapabilities", pciAddr)
+	ret0, _ := ret[0].(v1.InterfaceCapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInterfaceCapabilities indicates an expected call of GetInterfaceCapabilities.
+func (mr *MockHostManagerInterfaceMockRecorder) GetInterfaceCapabilities(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterfaceCapabilities", reflect.TypeOf((*MockHostManagerInterface)(nil).GetInterfaceCapabilities), pciAddr)
+}
+
+// GetKernelArgValue mocks base method.
+func (m *MockHostManagerInterface) GetKernelArgValue(cmdLine, key string) (string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKernelArgValue", cmdLine, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetKernelArgValue indicates an expected call of GetKernelArgValue.
+func (mr *MockHostManagerInterfaceMockRecorder) GetKernelArgValue(cmdLine, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKernelArgValue", reflect.TypeOf((*MockHostManagerInterface)(nil).GetKernelArgValue), cmdLine, key)
+}
+
 // GetLinkType mocks base method.
 func (m *MockHostManagerInterface) GetLinkType(name string) string {
 	m.ctrl.T.Helper()
@@ -338,6 +461,20 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetLinkType(name interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinkType", reflect.TypeOf((*MockHostManagerInterface)(nil).GetLinkType), name)
 }
 
+// GetNetDevFirmwareVersion mocks base method.
+func (m *MockHostManagerInterface) GetNetDevFirmwareVersion(ifaceName string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetDevFirmwareVersion", ifaceName)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetNetDevFirmwareVersion indicates an expected call of GetNetDevFirmwareVersion.
+func (mr *MockHostManagerInterfaceMockRecorder) GetNetDevFirmwareVersion(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetDevFirmwareVersion", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNetDevFirmwareVersion), ifaceName)
+}
+
 // GetNetDevLinkAdminState mocks base method.
 func (m *MockHostManagerInterface) GetNetDevLinkAdminState(ifaceName string) string {
 	m.ctrl.T.Helper()
@@ -394,6 +531,22 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetNetDevNodeGUID(pciAddr interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetDevNodeGUID", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNetDevNodeGUID), pciAddr)
 }
 
+// GetNetdevCombinedChannels mocks base method.
+func (m *MockHostManagerInterface) GetNetdevCombinedChannels(ifaceName string) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetdevCombinedChannels", ifaceName)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetNetdevCombinedChannels indicates an expected call of GetNetdevCombinedChannels.
+func (mr *MockHostManagerInterfaceMockRecorder) GetNetdevCombinedChannels(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevCombinedChannels", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNetdevCombinedChannels), ifaceName)
+}
+
 // GetNetdevMTU mocks base method.
 func (m *MockHostManagerInterface) GetNetdevMTU(pciAddr string) int {
 	m.ctrl.T.Helper()
@@ -408,6 +561,36 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetNetdevMTU(pciAddr interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevMTU", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNetdevMTU), pciAddr)
 }
 
+// GetNetdevRingSizes mocks base method.
+func (m *MockHostManagerInterface) GetNetdevRingSizes(ifaceName string) (*types.RingSizes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetdevRingSizes", ifaceName)
+	ret0, _ := ret[0].(*types.RingSizes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNetdevRingSizes indicates an expected call of GetNetdevRingSizes.
+func (mr *MockHostManagerInterfaceMockRecorder) GetNetdevRingSizes(ifaceName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetdevRingSizes", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNetdevRingSizes), ifaceName)
+}
+
+// GetNicEswitchConfig mocks base method.
+func (m *MockHostManagerInterface) GetNicEswitchConfig(pciAddr string) (types.EswitchConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNicEswitchConfig", pciAddr)
+	ret0, _ := ret[0].(types.EswitchConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNicEswitchConfig indicates an expected call of GetNicEswitchConfig.
+func (mr *MockHostManagerInterfaceMockRecorder) GetNicEswitchConfig(pciAddr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNicEswitchConfig", reflect.TypeOf((*MockHostManagerInterface)(nil).GetNicEswitchConfig), pciAddr)
+}
+
 // GetNicSriovMode mocks base method.
 func (m *MockHostManagerInterface) GetNicSriovMode(pciAddr string) string {
 	m.ctrl.T.Helper()
@@ -467,6 +650,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) GetPhysSwitchID(name interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPhysSwitchID", reflect.TypeOf((*MockHostManagerInterface)(nil).GetPhysSwitchID), name)
 }
 
+// GetRdmaSubsystemMode mocks base method.
+func (m *MockHostManagerInterface) GetRdmaSubsystemMode() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRdmaSubsystemMode")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRdmaSubsystemMode indicates an expected call of GetRdmaSubsystemMode.
+func (mr *MockHostManagerInterfaceMockRecorder) GetRdmaSubsystemMode() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRdmaSubsystemMode", reflect.TypeOf((*MockHostManagerInterface)(nil).GetRdmaSubsystemMode))
+}
+
 // HasDriver mocks base method.
 func (m *MockHostManagerInterface) HasDriver(pciAddr string) (bool, string) {
 	m.ctrl.T.Helper()
@@ -482,6 +680,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) HasDriver(pciAddr interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDriver", reflect.TypeOf((*MockHostManagerInterface)(nil).HasDriver), pciAddr)
 }
 
+// HugepageKernelArgs mocks base method.
+func (m *MockHostManagerInterface) HugepageKernelArgs(cmdLine, size string, count int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HugepageKernelArgs", cmdLine, size, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HugepageKernelArgs indicates an expected call of HugepageKernelArgs.
+func (mr *MockHostManagerInterfaceMockRecorder) HugepageKernelArgs(cmdLine, size, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HugepageKernelArgs", reflect.TypeOf((*MockHostManagerInterface)(nil).HugepageKernelArgs), cmdLine, size, count)
+}
+
 // InstallRDMA mocks base method.
 func (m *MockHostManagerInterface) InstallRDMA(packageManager string) error {
 	m.ctrl.T.Helper()
@@ -496,6 +709,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) InstallRDMA(packageManager inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallRDMA", reflect.TypeOf((*MockHostManagerInterface)(nil).InstallRDMA), packageManager)
 }
 
+// IommuKernelArgForHost mocks base method.
+func (m *MockHostManagerInterface) IommuKernelArgForHost() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IommuKernelArgForHost")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IommuKernelArgForHost indicates an expected call of IommuKernelArgForHost.
+func (mr *MockHostManagerInterfaceMockRecorder) IommuKernelArgForHost() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IommuKernelArgForHost", reflect.TypeOf((*MockHostManagerInterface)(nil).IommuKernelArgForHost))
+}
+
 // IsCoreOS mocks base method.
 func (m *MockHostManagerInterface) IsCoreOS() (bool, error) {
 	m.ctrl.T.Helper()
@@ -511,6 +739,34 @@ func (mr *MockHostManagerInterfaceMockRecorder) IsCoreOS() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCoreOS", reflect.TypeOf((*MockHostManagerInterface)(nil).IsCoreOS))
 }
 
+// IsIommuEnabled mocks base method.
+func (m *MockHostManagerInterface) IsIommuEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsIommuEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsIommuEnabled indicates an expected call of IsIommuEnabled.
+func (mr *MockHostManagerInterfaceMockRecorder) IsIommuEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIommuEnabled", reflect.TypeOf((*MockHostManagerInterface)(nil).IsIommuEnabled))
+}
+
+// IsKernelArgKeySet mocks base method.
+func (m *MockHostManagerInterface) IsKernelArgKeySet(cmdLine, key string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsKernelArgKeySet", cmdLine, key)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsKernelArgKeySet indicates an expected call of IsKernelArgKeySet.
+func (mr *MockHostManagerInterfaceMockRecorder) IsKernelArgKeySet(cmdLine, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsKernelArgKeySet", reflect.TypeOf((*MockHostManagerInterface)(nil).IsKernelArgKeySet), cmdLine, key)
+}
+
 // IsKernelArgsSet mocks base method.
 func (m *MockHostManagerInterface) IsKernelArgsSet(cmdLine, karg string) bool {
 	m.ctrl.T.Helper()
@@ -661,6 +917,21 @@ func (mr *MockHostManagerInterfaceMockRecorder) LoadUdevRules() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadUdevRules", reflect.TypeOf((*MockHostManagerInterface)(nil).LoadUdevRules))
 }
 
+// MaxMTU mocks base method.
+func (m *MockHostManagerInterface) MaxMTU(iface string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxMTU", iface)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MaxMTU indicates an expected call of MaxMTU.
+func (mr *MockHostManagerInterfaceMockRecorder) MaxMTU(iface interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxMTU", reflect.TypeOf((*MockHostManagerInterface)(nil).MaxMTU), iface)
+}
+
 // PrepareNMUdevRule mocks base method.
 func (m *MockHostManagerInterface) PrepareNMUdevRule(supportedVfIds []string) error {
 	m.ctrl.T.Helper()
@@ -777,6 +1048,20 @@ func (mr *MockHostManagerInterfaceMockRecorder) ReloadDriver(driver interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadDriver", reflect.TypeOf((*MockHostManagerInterface)(nil).ReloadDriver), driver)
 }
 
+// ReloadSupportedVfIds mocks base method.
+func (m *MockHostManagerInterface) ReloadSupportedVfIds(supportedVfIds []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReloadSupportedVfIds", supportedVfIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReloadSupportedVfIds indicates an expected call of ReloadSupportedVfIds.
+func (mr *MockHostManagerInterfaceMockRecorder) ReloadSupportedVfIds(supportedVfIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadSupportedVfIds", reflect.TypeOf((*MockHostManagerInterface)(nil).ReloadSupportedVfIds), supportedVfIds)
+}
+
 // RemoveDisableNMUdevRule mocks base method.
 func (m *MockHostManagerInterface) RemoveDisableNMUdevRule(pfPciAddress string) error {
 	m.ctrl.T.Helper()
@@ -820,17 +1105,17 @@ func (mr *MockHostManagerInterfaceMockRecorder) RemoveVfRepresentorUdevRule(pfPc
 }
 
 // ResetSriovDevice mocks base method.
-func (m *MockHostManagerInterface) ResetSriovDevice(ifaceStatus v1.InterfaceExt) error {
+func (m *MockHostManagerInterface) ResetSriovDevice(storeManager store.ManagerInterface, ifaceStatus v1.InterfaceExt) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ResetSriovDevice", ifaceStatus)
+	ret := m.ctrl.Call(m, "ResetSriovDevice", storeManager, ifaceStatus)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ResetSriovDevice indicates an expected call of ResetSriovDevice.
-func (mr *MockHostManagerInterfaceMockRecorder) ResetSriovDevice(ifaceStatus interface{}) *gomock.Call {
+func (mr *MockHostManagerInterfaceMockRecorder) ResetSriovDevice(storeManager, ifaceStatus interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetSriovDevice", reflect.TypeOf((*MockHostManagerInterface)(nil).ResetSriovDevice), ifaceStatus)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetSriovDevice", reflect.TypeOf((*MockHostManagerInterface)(nil).ResetSriovDevice), storeManager, ifaceStatus)
 }
 
 // SetDevlinkDeviceParam mocks base method.
@@ -847,18 +1132,88 @@ func (mr *MockHostManagerInterfaceMockRecorder) SetDevlinkDeviceParam(pciAddr, p
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDevlinkDeviceParam", reflect.TypeOf((*MockHostManagerInterface)(nil).SetDevlinkDeviceParam), pciAddr, paramName, value)
 }
 
+// SetDevlinkVfRate mocks base method.
+func (m *MockHostManagerInterface) SetDevlinkVfRate(pciAddr string, vfID int, minRate, maxRate uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDevlinkVfRate", pciAddr, vfID, minRate, maxRate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDevlinkVfRate indicates an expected call of SetDevlinkVfRate.
+func (mr *MockHostManagerInterfaceMockRecorder) SetDevlinkVfRate(pciAddr, vfID, minRate, maxRate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDevlinkVfRate", reflect.TypeOf((*MockHostManagerInterface)(nil).SetDevlinkVfRate), pciAddr, vfID, minRate, maxRate)
+}
+
+// SetNetdevCombinedChannels mocks base method.
+func (m *MockHostManagerInterface) SetNetdevCombinedChannels(ifaceName string, channels int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNetdevCombinedChannels", ifaceName, channels)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNetdevCombinedChannels indicates an expected call of SetNetdevCombinedChannels.
+func (mr *MockHostManagerInterfaceMockRecorder) SetNetdevCombinedChannels(ifaceName, channels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevCombinedChannels", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNetdevCombinedChannels), ifaceName, channels)
+}
+
 // SetNetdevMTU mocks base method.
-func (m *MockHostManagerInterface) SetNetdevMTU(pciAddr string, mtu int) error {
+func (m *MockHostManagerInterface) SetNetdevMTU(ctx context.Context, pciAddr string, mtu int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetNetdevMTU", pciAddr, mtu)
+	ret := m.ctrl.Call(m, "SetNetdevMTU", ctx, pciAddr, mtu)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetNetdevMTU indicates an expected call of SetNetdevMTU.
-func (mr *MockHostManagerInterfaceMockRecorder) SetNetdevMTU(pciAddr, mtu interface{}) *gomock.Call {
+func (mr *MockHostManagerInterfaceMockRecorder) SetNetdevMTU(ctx, pciAddr, mtu interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevMTU", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNetdevMTU), ctx, pciAddr, mtu)
+}
+
+// SetNetdevRingSizes mocks base method.
+func (m *MockHostManagerInterface) SetNetdevRingSizes(ifaceName string, rx, tx int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNetdevRingSizes", ifaceName, rx, tx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNetdevRingSizes indicates an expected call of SetNetdevRingSizes.
+func (mr *MockHostManagerInterfaceMockRecorder) SetNetdevRingSizes(ifaceName, rx, tx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevMTU", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNetdevMTU), pciAddr, mtu)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetdevRingSizes", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNetdevRingSizes), ifaceName, rx, tx)
+}
+
+// SetNicEswitchEncapMode mocks base method.
+func (m *MockHostManagerInterface) SetNicEswitchEncapMode(pciAddr string, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNicEswitchEncapMode", pciAddr, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNicEswitchEncapMode indicates an expected call of SetNicEswitchEncapMode.
+func (mr *MockHostManagerInterfaceMockRecorder) SetNicEswitchEncapMode(pciAddr, enabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicEswitchEncapMode", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNicEswitchEncapMode), pciAddr, enabled)
+}
+
+// SetNicEswitchInlineMode mocks base method.
+func (m *MockHostManagerInterface) SetNicEswitchInlineMode(pciAddr, mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNicEswitchInlineMode", pciAddr, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNicEswitchInlineMode indicates an expected call of SetNicEswitchInlineMode.
+func (mr *MockHostManagerInterfaceMockRecorder) SetNicEswitchInlineMode(pciAddr, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicEswitchInlineMode", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNicEswitchInlineMode), pciAddr, mode)
 }
 
 // SetNicSriovMode mocks base method.
@@ -875,6 +1230,20 @@ func (mr *MockHostManagerInterfaceMockRecorder) SetNicSriovMode(pciAddr, mode in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNicSriovMode", reflect.TypeOf((*MockHostManagerInterface)(nil).SetNicSriovMode), pciAddr, mode)
 }
 
+// SetRdmaSubsystemMode mocks base method.
+func (m *MockHostManagerInterface) SetRdmaSubsystemMode(mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRdmaSubsystemMode", mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRdmaSubsystemMode indicates an expected call of SetRdmaSubsystemMode.
+func (mr *MockHostManagerInterfaceMockRecorder) SetRdmaSubsystemMode(mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRdmaSubsystemMode", reflect.TypeOf((*MockHostManagerInterface)(nil).SetRdmaSubsystemMode), mode)
+}
+
 // SetSriovNumVfs mocks base method.
 func (m *MockHostManagerInterface) SetSriovNumVfs(pciAddr string, numVfs int) error {
 	m.ctrl.T.Helper()
@@ -904,17 +1273,17 @@ func (mr *MockHostManagerInterfaceMockRecorder) SetVfAdminMac(vfAddr, pfLink, vf
 }
 
 // SetVfGUID mocks base method.
-func (m *MockHostManagerInterface) SetVfGUID(vfAddr string, pfLink netlink.Link) error {
+func (m *MockHostManagerInterface) SetVfGUID(vfAddr string, pfLink netlink.Link, guid net.HardwareAddr) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetVfGUID", vfAddr, pfLink)
+	ret := m.ctrl.Call(m, "SetVfGUID", vfAddr, pfLink, guid)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetVfGUID indicates an expected call of SetVfGUID.
-func (mr *MockHostManagerInterfaceMockRecorder) SetVfGUID(vfAddr, pfLink interface{}) *gomock.Call {
+func (mr *MockHostManagerInterfaceMockRecorder) SetVfGUID(vfAddr, pfLink, guid interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVfGUID", reflect.TypeOf((*MockHostManagerInterface)(nil).SetVfGUID), vfAddr, pfLink)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVfGUID", reflect.TypeOf((*MockHostManagerInterface)(nil).SetVfGUID), vfAddr, pfLink, guid)
 }
 
 // TriggerUdevEvent mocks base method.
@@ -1055,16 +1424,73 @@ func (mr *MockHostManagerInterfaceMockRecorder) UpdateSystemService(serviceObj i
 }
 
 // VFIsReady mocks base method.
-func (m *MockHostManagerInterface) VFIsReady(pciAddr string) (netlink.Link, error) {
+func (m *MockHostManagerInterface) VFIsReady(ctx context.Context, pciAddr string, interval, timeout time.Duration) (netlink.Link, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "VFIsReady", pciAddr)
+	ret := m.ctrl.Call(m, "VFIsReady", ctx, pciAddr, interval, timeout)
 	ret0, _ := ret[0].(netlink.Link)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // VFIsReady indicates an expected call of VFIsReady.
-func (mr *MockHostManagerInterfaceMockRecorder) VFIsReady(pciAddr interface{}) *gomock.Call {
+func (mr *MockHostManagerInterfaceMockRecorder) VFIsReady(ctx, pciAddr, interval, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VFIsReady", reflect.TypeOf((*MockHostManagerInterface)(nil).VFIsReady), ctx, pciAddr, interval, timeout)
+}
+
+// ValidateRequiredModules mocks base method.
+func (m *MockHostManagerInterface) ValidateRequiredModules(modules []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRequiredModules", modules)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateRequiredModules indicates an expected call of ValidateRequiredModules.
+func (mr *MockHostManagerInterfaceMockRecorder) ValidateRequiredModules(modules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRequiredModules", reflect.TypeOf((*MockHostManagerInterface)(nil).ValidateRequiredModules), modules)
+}
+
+// VerifyInterfaceConfig mocks base method.
+func (m *MockHostManagerInterface) VerifyInterfaceConfig(storeManager store.ManagerInterface, iface *v1.Interface) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyInterfaceConfig", storeManager, iface)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyInterfaceConfig indicates an expected call of VerifyInterfaceConfig.
+func (mr *MockHostManagerInterfaceMockRecorder) VerifyInterfaceConfig(storeManager, iface interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyInterfaceConfig", reflect.TypeOf((*MockHostManagerInterface)(nil).VerifyInterfaceConfig), storeManager, iface)
+}
+
+// WaitForVFs mocks base method.
+func (m *MockHostManagerInterface) WaitForVFs(pfPciAddr string, count int, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForVFs", pfPciAddr, count, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForVFs indicates an expected call of WaitForVFs.
+func (mr *MockHostManagerInterfaceMockRecorder) WaitForVFs(pfPciAddr, count, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVFs", reflect.TypeOf((*MockHostManagerInterface)(nil).WaitForVFs), pfPciAddr, count, timeout)
+}
+
+// WithPhysPortCache mocks base method.
+func (m *MockHostManagerInterface) WithPhysPortCache() types.NetworkInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithPhysPortCache")
+	ret0, _ := ret[0].(types.NetworkInterface)
+	return ret0
+}
+
+// WithPhysPortCache indicates an expected call of WithPhysPortCache.
+func (mr *MockHostManagerInterfaceMockRecorder) WithPhysPortCache() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VFIsReady", reflect.TypeOf((*MockHostManagerInterface)(nil).VFIsReady), pciAddr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPhysPortCache", reflect.TypeOf((*MockHostManagerInterface)(nil).WithPhysPortCache))
 }
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// storeFileLocks serializes the accesses to the store files of the process, keyed by the file path.
+// It is shared by all the managers as the config daemon can create more than one.
+var storeFileLocks sync.Map
+
+func getFileLock(path string) *sync.RWMutex {
+	l, _ := storeFileLocks.LoadOrStore(path, &sync.RWMutex{})
+	return l.(*sync.RWMutex)
+}
+
+// readFile reads the store file, the read waits for the writes of the same file in progress
+func readFile(path string) ([]byte, error) {
+	l := getFileLock(path)
+	l.RLock()
+	defer l.RUnlock()
+	return os.ReadFile(path)
+}
+
+// writeFile replaces the store file with the provided data, the data is written to a temporary
+// file renamed over the destination so a concurrent reader, including another process, never
+// sees partial content
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	l := getFileLock(path)
+	l.Lock()
+	defer l.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// isTempFile returns true for the temporary files created by writeFile
+func isTempFile(path string) bool {
+	return filepath.Base(path)[0] == '.'
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+)
+
+// PfStatusVersion is the version of the PF status format written by SaveLastPfAppliedStatus.
+// Bump it together with a new entry in pfStatusMigrations when the format changes in a way
+// the files written by the previous versions can't be interpreted as is.
+const PfStatusVersion = 1
+
+// versionedPfStatus is the on-disk format of the PF status, the fields of the interface are kept
+// at the top level so the files remain readable by the versions not aware of the version field
+type versionedPfStatus struct {
+	Version int `json:"version"`
+	sriovnetworkv1.Interface
+	// OriginalMtu is the MTU the PF had before the operator managed it for the first time
+	OriginalMtu int `json:"originalMtu,omitempty"`
+}
+
+// pfStatusMigration converts the raw PF status from the version it is registered for to the next one
+type pfStatusMigration func(raw map[string]interface{}) error
+
+// pfStatusMigrations contains the migrations of the PF status indexed by the version they migrate from
+var pfStatusMigrations = map[int]pfStatusMigration{
+	// the files written before the versioning have the same fields as the version 1
+	0: func(raw map[string]interface{}) error { return nil },
+}
+
+// PfStatusVersionError is returned when the PF status stored on the host can't be migrated to the current version,
+// e.g. when it was written by a newer version of the operator
+type PfStatusVersionError struct {
+	PciAddress string
+	Version    int
+	Current    int
+}
+
+func (e *PfStatusVersionError) Error() string {
+	return fmt.Sprintf("unable to migrate the status of PF %s from version %d to version %d", e.PciAddress, e.Version, e.Current)
+}
+
+// decodePfStatus decodes the stored PF status running the registered migrations from the version of the data
+// to the current one
+func decodePfStatus(pciAddress string, data []byte) (*versionedPfStatus, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, ok := raw["version"]; ok {
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) {
+			return nil, fmt.Errorf("invalid version %v in the status of PF %s", v, pciAddress)
+		}
+		version = int(f)
+	}
+	if version > PfStatusVersion || version < 0 {
+		return nil, &PfStatusVersionError{PciAddress: pciAddress, Version: version, Current: PfStatusVersion}
+	}
+	for v := version; v < PfStatusVersion; v++ {
+		migrate, ok := pfStatusMigrations[v]
+		if !ok {
+			return nil, &PfStatusVersionError{PciAddress: pciAddress, Version: version, Current: PfStatusVersion}
+		}
+		if err := migrate(raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate the status of PF %s from version %d: %w", pciAddress, v, err)
+		}
+	}
+	raw["version"] = PfStatusVersion
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	pfStatus := &versionedPfStatus{}
+	if err := json.Unmarshal(migrated, pfStatus); err != nil {
+		return nil, err
+	}
+	return pfStatus, nil
+}
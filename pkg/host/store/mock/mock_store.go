@@ -63,6 +63,37 @@ func (mr *MockManagerInterfaceMockRecorder) GetCheckPointNodeState() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCheckPointNodeState", reflect.TypeOf((*MockManagerInterface)(nil).GetCheckPointNodeState))
 }
 
+// ListVfMacs mocks base method.
+func (m *MockManagerInterface) ListVfMacs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVfMacs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVfMacs indicates an expected call of ListVfMacs.
+func (mr *MockManagerInterfaceMockRecorder) ListVfMacs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVfMacs", reflect.TypeOf((*MockManagerInterface)(nil).ListVfMacs))
+}
+
+// LoadPfOriginalMtu mocks base method.
+func (m *MockManagerInterface) LoadPfOriginalMtu(pciAddress string) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadPfOriginalMtu", pciAddress)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadPfOriginalMtu indicates an expected call of LoadPfOriginalMtu.
+func (mr *MockManagerInterfaceMockRecorder) LoadPfOriginalMtu(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPfOriginalMtu", reflect.TypeOf((*MockManagerInterface)(nil).LoadPfOriginalMtu), pciAddress)
+}
+
 // LoadPfsStatus mocks base method.
 func (m *MockManagerInterface) LoadPfsStatus(pciAddress string) (*v1.Interface, bool, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +110,82 @@ func (mr *MockManagerInterfaceMockRecorder) LoadPfsStatus(pciAddress interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPfsStatus", reflect.TypeOf((*MockManagerInterface)(nil).LoadPfsStatus), pciAddress)
 }
 
+// LoadRejectedTotalVfs mocks base method.
+func (m *MockManagerInterface) LoadRejectedTotalVfs(pciAddress string) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadRejectedTotalVfs", pciAddress)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadRejectedTotalVfs indicates an expected call of LoadRejectedTotalVfs.
+func (mr *MockManagerInterfaceMockRecorder) LoadRejectedTotalVfs(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadRejectedTotalVfs", reflect.TypeOf((*MockManagerInterface)(nil).LoadRejectedTotalVfs), pciAddress)
+}
+
+// LoadVfGUID mocks base method.
+func (m *MockManagerInterface) LoadVfGUID(pfPciAddress string, vfID int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadVfGUID", pfPciAddress, vfID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadVfGUID indicates an expected call of LoadVfGUID.
+func (mr *MockManagerInterfaceMockRecorder) LoadVfGUID(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVfGUID", reflect.TypeOf((*MockManagerInterface)(nil).LoadVfGUID), pfPciAddress, vfID)
+}
+
+// LoadVfMac mocks base method.
+func (m *MockManagerInterface) LoadVfMac(pfPciAddress string, vfID int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadVfMac", pfPciAddress, vfID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LoadVfMac indicates an expected call of LoadVfMac.
+func (mr *MockManagerInterfaceMockRecorder) LoadVfMac(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVfMac", reflect.TypeOf((*MockManagerInterface)(nil).LoadVfMac), pfPciAddress, vfID)
+}
+
+// RemoveRejectedTotalVfs mocks base method.
+func (m *MockManagerInterface) RemoveRejectedTotalVfs(pciAddress string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRejectedTotalVfs", pciAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRejectedTotalVfs indicates an expected call of RemoveRejectedTotalVfs.
+func (mr *MockManagerInterfaceMockRecorder) RemoveRejectedTotalVfs(pciAddress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRejectedTotalVfs", reflect.TypeOf((*MockManagerInterface)(nil).RemoveRejectedTotalVfs), pciAddress)
+}
+
+// RemoveVfMac mocks base method.
+func (m *MockManagerInterface) RemoveVfMac(pfPciAddress string, vfID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveVfMac", pfPciAddress, vfID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVfMac indicates an expected call of RemoveVfMac.
+func (mr *MockManagerInterfaceMockRecorder) RemoveVfMac(pfPciAddress, vfID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVfMac", reflect.TypeOf((*MockManagerInterface)(nil).RemoveVfMac), pfPciAddress, vfID)
+}
+
 // SaveLastPfAppliedStatus mocks base method.
 func (m *MockManagerInterface) SaveLastPfAppliedStatus(PfInfo *v1.Interface) error {
 	m.ctrl.T.Helper()
@@ -93,6 +200,62 @@ func (mr *MockManagerInterfaceMockRecorder) SaveLastPfAppliedStatus(PfInfo inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLastPfAppliedStatus", reflect.TypeOf((*MockManagerInterface)(nil).SaveLastPfAppliedStatus), PfInfo)
 }
 
+// SavePfOriginalMtu mocks base method.
+func (m *MockManagerInterface) SavePfOriginalMtu(pciAddress string, mtu int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePfOriginalMtu", pciAddress, mtu)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePfOriginalMtu indicates an expected call of SavePfOriginalMtu.
+func (mr *MockManagerInterfaceMockRecorder) SavePfOriginalMtu(pciAddress, mtu interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePfOriginalMtu", reflect.TypeOf((*MockManagerInterface)(nil).SavePfOriginalMtu), pciAddress, mtu)
+}
+
+// SaveRejectedTotalVfs mocks base method.
+func (m *MockManagerInterface) SaveRejectedTotalVfs(pciAddress string, totalVfs int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRejectedTotalVfs", pciAddress, totalVfs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRejectedTotalVfs indicates an expected call of SaveRejectedTotalVfs.
+func (mr *MockManagerInterfaceMockRecorder) SaveRejectedTotalVfs(pciAddress, totalVfs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRejectedTotalVfs", reflect.TypeOf((*MockManagerInterface)(nil).SaveRejectedTotalVfs), pciAddress, totalVfs)
+}
+
+// SaveVfGUID mocks base method.
+func (m *MockManagerInterface) SaveVfGUID(pfPciAddress string, vfID int, guid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVfGUID", pfPciAddress, vfID, guid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVfGUID indicates an expected call of SaveVfGUID.
+func (mr *MockManagerInterfaceMockRecorder) SaveVfGUID(pfPciAddress, vfID, guid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVfGUID", reflect.TypeOf((*MockManagerInterface)(nil).SaveVfGUID), pfPciAddress, vfID, guid)
+}
+
+// SaveVfMac mocks base method.
+func (m *MockManagerInterface) SaveVfMac(pfPciAddress string, vfID int, mac string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVfMac", pfPciAddress, vfID, mac)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVfMac indicates an expected call of SaveVfMac.
+func (mr *MockManagerInterfaceMockRecorder) SaveVfMac(pfPciAddress, vfID, mac interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVfMac", reflect.TypeOf((*MockManagerInterface)(nil).SaveVfMac), pfPciAddress, vfID, mac)
+}
+
 // WriteCheckpointFile mocks base method.
 func (m *MockManagerInterface) WriteCheckpointFile(arg0 *v1.SriovNetworkNodeState) error {
 	m.ctrl.T.Helper()
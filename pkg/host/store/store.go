@@ -2,9 +2,12 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -21,6 +24,17 @@ type ManagerInterface interface {
 	ClearPCIAddressFolder() error
 	SaveLastPfAppliedStatus(PfInfo *sriovnetworkv1.Interface) error
 	LoadPfsStatus(pciAddress string) (*sriovnetworkv1.Interface, bool, error)
+	SavePfOriginalMtu(pciAddress string, mtu int) error
+	LoadPfOriginalMtu(pciAddress string) (int, bool, error)
+	SaveVfGUID(pfPciAddress string, vfID int, guid string) error
+	LoadVfGUID(pfPciAddress string, vfID int) (string, bool, error)
+	SaveVfMac(pfPciAddress string, vfID int, mac string) error
+	LoadVfMac(pfPciAddress string, vfID int) (string, bool, error)
+	ListVfMacs() ([]string, error)
+	RemoveVfMac(pfPciAddress string, vfID int) error
+	SaveRejectedTotalVfs(pciAddress string, totalVfs int) error
+	LoadRejectedTotalVfs(pciAddress string) (int, bool, error)
+	RemoveRejectedTotalVfs(pciAddress string) error
 
 	GetCheckPointNodeState() (*sriovnetworkv1.SriovNetworkNodeState, error)
 	WriteCheckpointFile(*sriovnetworkv1.SriovNetworkNodeState) error
@@ -38,6 +52,56 @@ func NewManager() (ManagerInterface, error) {
 	return &manager{}, nil
 }
 
+// ErrReadOnly is returned by the write operations of a read-only manager
+var ErrReadOnly = errors.New("the store is read-only")
+
+// readOnlyManager reads the info stored on the host and refuses to change it
+type readOnlyManager struct {
+	manager
+}
+
+// NewReadOnlyManager: return a manager that reads the info about the PF stored on the host
+// without creating the folders, all the write operations fail with ErrReadOnly
+func NewReadOnlyManager() ManagerInterface {
+	return &readOnlyManager{}
+}
+
+func (s *readOnlyManager) ClearPCIAddressFolder() error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) SaveLastPfAppliedStatus(PfInfo *sriovnetworkv1.Interface) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) SavePfOriginalMtu(pciAddress string, mtu int) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) SaveVfGUID(pfPciAddress string, vfID int, guid string) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) SaveVfMac(pfPciAddress string, vfID int, mac string) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) RemoveVfMac(pfPciAddress string, vfID int) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) SaveRejectedTotalVfs(pciAddress string, totalVfs int) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) RemoveRejectedTotalVfs(pciAddress string) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyManager) WriteCheckpointFile(ns *sriovnetworkv1.SriovNetworkNodeState) error {
+	return ErrReadOnly
+}
+
 // createOperatorConfigFolderIfNeeded: create the operator base folder on the host
 // together with the pci folder to save the PF status objects as json files
 func createOperatorConfigFolderIfNeeded() error {
@@ -97,27 +161,75 @@ func (s *manager) ClearPCIAddressFolder() error {
 }
 
 // SaveLastPfAppliedStatus will save the PF object as a json into the /etc/sriov-operator/pci/<pci-address>
+// the original MTU of the PF already stored is kept
 // this function must be called after running the chroot function
 func (s *manager) SaveLastPfAppliedStatus(PfInfo *sriovnetworkv1.Interface) error {
-	data, err := json.Marshal(PfInfo)
+	pfStatus := &versionedPfStatus{Version: PfStatusVersion, Interface: *PfInfo}
+	current, exist, err := s.loadPfStatus(PfInfo.PciAddress)
 	if err != nil {
-		log.Log.Error(err, "failed to marshal PF status", "status", *PfInfo)
 		return err
 	}
-
-	hostExtension := utils.GetHostExtension()
-	pathFile := filepath.Join(hostExtension, consts.PfAppliedConfig, PfInfo.PciAddress)
-	err = os.WriteFile(pathFile, data, 0644)
-	return err
+	if exist {
+		pfStatus.OriginalMtu = current.OriginalMtu
+	}
+	return s.savePfStatus(pfStatus)
 }
 
 // LoadPfsStatus convert the /etc/sriov-operator/pci/<pci-address> json to pfstatus
-// returns false if the file doesn't exist.
+// migrating it to the current version of the format, returns a PfStatusVersionError if the
+// migration is not possible and false if the file doesn't exist.
 func (s *manager) LoadPfsStatus(pciAddress string) (*sriovnetworkv1.Interface, bool, error) {
+	pfStatus, exist, err := s.loadPfStatus(pciAddress)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	return &pfStatus.Interface, true, nil
+}
+
+// SavePfOriginalMtu records the MTU the PF had before the operator managed it into the PF status,
+// the MTU is recorded only once so the value captured when the PF was first managed is kept.
+// The status of the PF must have been saved with SaveLastPfAppliedStatus before.
+func (s *manager) SavePfOriginalMtu(pciAddress string, mtu int) error {
+	pfStatus, exist, err := s.loadPfStatus(pciAddress)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("no status stored for PF %s", pciAddress)
+	}
+	if pfStatus.OriginalMtu > 0 || mtu <= 0 {
+		return nil
+	}
+	pfStatus.OriginalMtu = mtu
+	return s.savePfStatus(pfStatus)
+}
+
+// LoadPfOriginalMtu returns the MTU the PF had before the operator managed it,
+// false is returned if the MTU was not recorded
+func (s *manager) LoadPfOriginalMtu(pciAddress string) (int, bool, error) {
+	pfStatus, exist, err := s.loadPfStatus(pciAddress)
+	if err != nil || !exist || pfStatus.OriginalMtu <= 0 {
+		return 0, false, err
+	}
+	return pfStatus.OriginalMtu, true, nil
+}
+
+func (s *manager) savePfStatus(pfStatus *versionedPfStatus) error {
+	data, err := json.Marshal(pfStatus)
+	if err != nil {
+		log.Log.Error(err, "failed to marshal PF status", "status", pfStatus.Interface)
+		return err
+	}
+
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.PfAppliedConfig, pfStatus.PciAddress)
+	return writeFile(pathFile, data, 0644)
+}
+
+func (s *manager) loadPfStatus(pciAddress string) (*versionedPfStatus, bool, error) {
 	hostExtension := utils.GetHostExtension()
 	pathFile := filepath.Join(hostExtension, consts.PfAppliedConfig, pciAddress)
-	pfStatus := &sriovnetworkv1.Interface{}
-	data, err := os.ReadFile(pathFile)
+	data, err := readFile(pathFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, false, nil
@@ -126,15 +238,146 @@ func (s *manager) LoadPfsStatus(pciAddress string) (*sriovnetworkv1.Interface, b
 		return nil, false, err
 	}
 
-	err = json.Unmarshal(data, pfStatus)
+	pfStatus, err := decodePfStatus(pciAddress, data)
 	if err != nil {
-		log.Log.Error(err, "failed to unmarshal PF status", "data", string(data))
+		log.Log.Error(err, "failed to decode PF status", "data", string(data))
 		return nil, false, err
 	}
 
 	return pfStatus, true, nil
 }
 
+// SaveVfGUID will save the GUID assigned to the VF into the /etc/sriov-operator/guid/<pf-pci-address>/<vf-id>
+// this function must be called after running the chroot function
+func (s *manager) SaveVfGUID(pfPciAddress string, vfID int, guid string) error {
+	hostExtension := utils.GetHostExtension()
+	pfFolder := filepath.Join(hostExtension, consts.VfGUIDConfig, pfPciAddress)
+	if err := os.MkdirAll(pfFolder, os.ModeDir|0755); err != nil {
+		return fmt.Errorf("failed to create the GUID folder on host in path %s: %v", pfFolder, err)
+	}
+	return writeFile(filepath.Join(pfFolder, strconv.Itoa(vfID)), []byte(guid), 0644)
+}
+
+// LoadVfGUID reads the GUID assigned to the VF from the /etc/sriov-operator/guid/<pf-pci-address>/<vf-id>
+// returns false if the file doesn't exist.
+func (s *manager) LoadVfGUID(pfPciAddress string, vfID int) (string, bool, error) {
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.VfGUIDConfig, pfPciAddress, strconv.Itoa(vfID))
+	data, err := readFile(pathFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		log.Log.Error(err, "failed to read VF GUID", "path", pathFile)
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// SaveVfMac will save the MAC assigned to the VF from a MAC pool into the /etc/sriov-operator/mac/<pf-pci-address>/<vf-id>
+// this function must be called after running the chroot function
+func (s *manager) SaveVfMac(pfPciAddress string, vfID int, mac string) error {
+	hostExtension := utils.GetHostExtension()
+	pfFolder := filepath.Join(hostExtension, consts.VfMacConfig, pfPciAddress)
+	if err := os.MkdirAll(pfFolder, os.ModeDir|0755); err != nil {
+		return fmt.Errorf("failed to create the MAC folder on host in path %s: %v", pfFolder, err)
+	}
+	return writeFile(filepath.Join(pfFolder, strconv.Itoa(vfID)), []byte(mac), 0644)
+}
+
+// LoadVfMac reads the MAC assigned to the VF from the /etc/sriov-operator/mac/<pf-pci-address>/<vf-id>
+// returns false if the file doesn't exist.
+func (s *manager) LoadVfMac(pfPciAddress string, vfID int) (string, bool, error) {
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.VfMacConfig, pfPciAddress, strconv.Itoa(vfID))
+	data, err := readFile(pathFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		log.Log.Error(err, "failed to read VF MAC", "path", pathFile)
+		return "", false, err
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// RemoveVfMac removes the MAC assigned to the VF from the /etc/sriov-operator/mac/<pf-pci-address>/<vf-id>
+// so the pools can hand it out again, removing the MAC of a VF without one is a no-op
+func (s *manager) RemoveVfMac(pfPciAddress string, vfID int) error {
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.VfMacConfig, pfPciAddress, strconv.Itoa(vfID))
+	if err := os.Remove(pathFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ListVfMacs returns all the MACs assigned to VFs from a MAC pool saved in the /etc/sriov-operator/mac folder
+func (s *manager) ListVfMacs() ([]string, error) {
+	hostExtension := utils.GetHostExtension()
+	files, err := filepath.Glob(filepath.Join(hostExtension, consts.VfMacConfig, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	macs := make([]string, 0, len(files))
+	for _, f := range files {
+		if isTempFile(f) {
+			continue
+		}
+		data, err := readFile(f)
+		if err != nil {
+			log.Log.Error(err, "failed to read VF MAC", "path", f)
+			return nil, err
+		}
+		macs = append(macs, strings.TrimSpace(string(data)))
+	}
+	return macs, nil
+}
+
+// SaveRejectedTotalVfs will save the TotalVfs of the PF when a configuration requesting more VFs was rejected
+// into the /etc/sriov-operator/totalvfs/<pci-address>
+// this function must be called after running the chroot function
+func (s *manager) SaveRejectedTotalVfs(pciAddress string, totalVfs int) error {
+	hostExtension := utils.GetHostExtension()
+	folder := filepath.Join(hostExtension, consts.RejectedTotalVfsConfig)
+	if err := os.MkdirAll(folder, os.ModeDir|0755); err != nil {
+		return fmt.Errorf("failed to create the TotalVfs folder on host in path %s: %v", folder, err)
+	}
+	return writeFile(filepath.Join(folder, pciAddress), []byte(strconv.Itoa(totalVfs)), 0644)
+}
+
+// LoadRejectedTotalVfs reads the TotalVfs of the PF saved when a configuration was rejected
+// from the /etc/sriov-operator/totalvfs/<pci-address>
+// returns false if the file doesn't exist.
+func (s *manager) LoadRejectedTotalVfs(pciAddress string) (int, bool, error) {
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.RejectedTotalVfsConfig, pciAddress)
+	data, err := readFile(pathFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		log.Log.Error(err, "failed to read rejected TotalVfs", "path", pathFile)
+		return 0, false, err
+	}
+	totalVfs, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		log.Log.Error(err, "failed to parse rejected TotalVfs", "data", string(data))
+		return 0, false, err
+	}
+	return totalVfs, true, nil
+}
+
+// RemoveRejectedTotalVfs removes the TotalVfs of the PF saved when a configuration was rejected
+func (s *manager) RemoveRejectedTotalVfs(pciAddress string) error {
+	hostExtension := utils.GetHostExtension()
+	pathFile := filepath.Join(hostExtension, consts.RejectedTotalVfsConfig, pciAddress)
+	if err := os.Remove(pathFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func (s *manager) GetCheckPointNodeState() (*sriovnetworkv1.SriovNetworkNodeState, error) {
 	log.Log.Info("getCheckPointNodeState()")
 	configdir := filepath.Join(vars.Destdir, consts.CheckpointFileName)
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/fakefilesystem"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/test/util/helpers"
+)
+
+var _ = Describe("Store", func() {
+	var m ManagerInterface
+
+	BeforeEach(func() {
+		helpers.GinkgoConfigureFakeFS(&fakefilesystem.FS{Dirs: []string{"/etc/sriov-operator"}})
+		origInChroot := vars.InChroot
+		vars.InChroot = true
+		DeferCleanup(func() {
+			vars.InChroot = origInChroot
+		})
+		var err error
+		m, err = NewManager()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	writePfStatus := func(pciAddress, data string) {
+		Expect(os.WriteFile(filepath.Join(vars.FilesystemRoot, consts.PfAppliedConfig, pciAddress),
+			[]byte(data), 0644)).To(Succeed())
+	}
+
+	Context("PF status", func() {
+		It("should save the status with the current version", func() {
+			pfStatus := &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 4, Mtu: 9000}
+			Expect(m.SaveLastPfAppliedStatus(pfStatus)).To(Succeed())
+
+			data, err := os.ReadFile(filepath.Join(vars.FilesystemRoot, consts.PfAppliedConfig, "0000:d8:00.0"))
+			Expect(err).NotTo(HaveOccurred())
+			raw := map[string]interface{}{}
+			Expect(json.Unmarshal(data, &raw)).To(Succeed())
+			Expect(raw).To(HaveKeyWithValue("version", BeNumerically("==", PfStatusVersion)))
+			// the fields of the interface are kept at the top level
+			Expect(raw).To(HaveKeyWithValue("pciAddress", "0000:d8:00.0"))
+
+			loaded, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(loaded).To(Equal(pfStatus))
+		})
+		It("should migrate an unversioned status", func() {
+			writePfStatus("0000:d8:00.0", `{"pciAddress":"0000:d8:00.0","name":"enp216s0f0np0","numVfs":4,`+
+				`"externallyManaged":true,"vfGroups":[{"deviceType":"netdevice","vfRange":"0-3","resourceName":"nic"}]}`)
+			loaded, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(loaded).To(Equal(&sriovnetworkv1.Interface{
+				PciAddress:        "0000:d8:00.0",
+				Name:              "enp216s0f0np0",
+				NumVfs:            4,
+				ExternallyManaged: true,
+				VfGroups:          []sriovnetworkv1.VfGroup{{DeviceType: "netdevice", VfRange: "0-3", ResourceName: "nic"}},
+			}))
+		})
+		It("should run the migrations in order", func() {
+			origMigrations := pfStatusMigrations
+			DeferCleanup(func() {
+				pfStatusMigrations = origMigrations
+			})
+			var order []int
+			pfStatusMigrations = map[int]pfStatusMigration{
+				0: func(raw map[string]interface{}) error {
+					order = append(order, 0)
+					raw["mtu"] = 1500
+					return nil
+				},
+			}
+			writePfStatus("0000:d8:00.0", `{"pciAddress":"0000:d8:00.0"}`)
+			loaded, _, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(order).To(Equal([]int{0}))
+			Expect(loaded.Mtu).To(Equal(1500))
+		})
+		It("should fail when a migration is missing", func() {
+			origMigrations := pfStatusMigrations
+			DeferCleanup(func() {
+				pfStatusMigrations = origMigrations
+			})
+			pfStatusMigrations = map[int]pfStatusMigration{}
+			writePfStatus("0000:d8:00.0", `{"pciAddress":"0000:d8:00.0"}`)
+			_, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(exist).To(BeFalse())
+			versionErr := &PfStatusVersionError{}
+			Expect(errors.As(err, &versionErr)).To(BeTrue())
+			Expect(versionErr.Version).To(Equal(0))
+		})
+		It("should fail with a status written by a newer version", func() {
+			writePfStatus("0000:d8:00.0", `{"version":99,"pciAddress":"0000:d8:00.0"}`)
+			_, _, err := m.LoadPfsStatus("0000:d8:00.0")
+			versionErr := &PfStatusVersionError{}
+			Expect(errors.As(err, &versionErr)).To(BeTrue())
+			Expect(versionErr).To(Equal(&PfStatusVersionError{PciAddress: "0000:d8:00.0", Version: 99, Current: PfStatusVersion}))
+		})
+		It("should fail with an invalid version", func() {
+			writePfStatus("0000:d8:00.0", `{"version":"v1","pciAddress":"0000:d8:00.0"}`)
+			_, _, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).To(MatchError(ContainSubstring("invalid version")))
+		})
+		It("should never expose a partially written status", func() {
+			var wg sync.WaitGroup
+			for i := 1; i <= 50; i++ {
+				wg.Add(2)
+				go func(numVfs int) {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(m.SaveLastPfAppliedStatus(&sriovnetworkv1.Interface{
+						PciAddress: "0000:d8:00.0",
+						NumVfs:     numVfs,
+						VfGroups:   []sriovnetworkv1.VfGroup{{ResourceName: "nic", VfRange: "0-127", DeviceType: "netdevice"}},
+					})).To(Succeed())
+				}(i)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					pfStatus, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+					Expect(err).NotTo(HaveOccurred())
+					if exist {
+						Expect(pfStatus.VfGroups).To(HaveLen(1))
+					}
+				}()
+			}
+			wg.Wait()
+			pfStatus, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(pfStatus.NumVfs).To(BeNumerically(">", 0))
+			// no temporary file is left behind
+			files, err := os.ReadDir(filepath.Join(vars.FilesystemRoot, consts.PfAppliedConfig))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+		It("should return false when the status doesn't exist", func() {
+			_, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeFalse())
+		})
+	})
+
+	Context("PF original MTU", func() {
+		It("should keep the MTU recorded the first time across restarts", func() {
+			pfStatus := &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 4, Mtu: 9000}
+			Expect(m.SaveLastPfAppliedStatus(pfStatus)).To(Succeed())
+			Expect(m.SavePfOriginalMtu("0000:d8:00.0", 1500)).To(Succeed())
+
+			// the daemon restarts and applies the configuration again, the PF has now the configured MTU
+			restarted, err := NewManager()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted.SaveLastPfAppliedStatus(pfStatus)).To(Succeed())
+			Expect(restarted.SavePfOriginalMtu("0000:d8:00.0", 9000)).To(Succeed())
+
+			mtu, exist, err := restarted.LoadPfOriginalMtu("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(mtu).To(Equal(1500))
+			loaded, _, err := restarted.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded).To(Equal(pfStatus))
+		})
+		It("should return false when the MTU was not recorded", func() {
+			writePfStatus("0000:d8:00.0", `{"pciAddress":"0000:d8:00.0","name":"enp216s0f0np0","numVfs":4}`)
+			_, exist, err := m.LoadPfOriginalMtu("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeFalse())
+			_, exist, err = m.LoadPfOriginalMtu("0000:d8:00.1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeFalse())
+		})
+		It("should fail when the status of the PF doesn't exist", func() {
+			Expect(m.SavePfOriginalMtu("0000:d8:00.0", 1500)).To(HaveOccurred())
+			_, exist, err := m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeFalse())
+		})
+	})
+
+	Context("VF MAC", func() {
+		It("should list the saved MACs and ignore the temporary files", func() {
+			Expect(m.SaveVfMac("0000:d8:00.0", 0, "02:00:00:00:00:01")).To(Succeed())
+			Expect(m.SaveVfMac("0000:d8:00.0", 1, "02:00:00:00:00:02")).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(vars.FilesystemRoot, consts.VfMacConfig, "0000:d8:00.0", ".2.tmp123"),
+				[]byte("02:00"), 0644)).To(Succeed())
+			Expect(m.ListVfMacs()).To(ConsistOf("02:00:00:00:00:01", "02:00:00:00:00:02"))
+			mac, exist, err := m.LoadVfMac("0000:d8:00.0", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(mac).To(Equal("02:00:00:00:00:02"))
+		})
+
+		It("should remove the MAC of the VF", func() {
+			Expect(m.SaveVfMac("0000:d8:00.0", 0, "02:00:00:00:00:01")).To(Succeed())
+			Expect(m.SaveVfMac("0000:d8:00.0", 1, "02:00:00:00:00:02")).To(Succeed())
+			Expect(m.RemoveVfMac("0000:d8:00.0", 1)).To(Succeed())
+			Expect(m.RemoveVfMac("0000:d8:00.0", 2)).To(Succeed())
+			Expect(m.ListVfMacs()).To(ConsistOf("02:00:00:00:00:01"))
+			_, exist, err := m.LoadVfMac("0000:d8:00.0", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeFalse())
+		})
+	})
+
+	Context("read-only", func() {
+		It("should read the stored info and refuse to change it", func() {
+			pfStatus := &sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0", Name: "enp216s0f0np0", NumVfs: 4, Mtu: 9000}
+			Expect(m.SaveLastPfAppliedStatus(pfStatus)).To(Succeed())
+			Expect(m.SaveVfMac("0000:d8:00.0", 0, "02:00:00:00:00:01")).To(Succeed())
+
+			ro := NewReadOnlyManager()
+			loaded, exist, err := ro.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exist).To(BeTrue())
+			Expect(loaded).To(Equal(pfStatus))
+			Expect(ro.ListVfMacs()).To(ConsistOf("02:00:00:00:00:01"))
+
+			Expect(ro.ClearPCIAddressFolder()).To(MatchError(ErrReadOnly))
+			Expect(ro.SaveLastPfAppliedStatus(&sriovnetworkv1.Interface{PciAddress: "0000:d8:00.0"})).To(MatchError(ErrReadOnly))
+			Expect(ro.SavePfOriginalMtu("0000:d8:00.0", 1500)).To(MatchError(ErrReadOnly))
+			Expect(ro.SaveVfMac("0000:d8:00.0", 1, "02:00:00:00:00:02")).To(MatchError(ErrReadOnly))
+			Expect(ro.RemoveVfMac("0000:d8:00.0", 0)).To(MatchError(ErrReadOnly))
+			Expect(ro.RemoveRejectedTotalVfs("0000:d8:00.0")).To(MatchError(ErrReadOnly))
+			Expect(ro.WriteCheckpointFile(&sriovnetworkv1.SriovNetworkNodeState{})).To(MatchError(ErrReadOnly))
+
+			loaded, _, err = m.LoadPfsStatus("0000:d8:00.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded).To(Equal(pfStatus))
+		})
+	})
+})
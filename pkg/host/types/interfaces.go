@@ -1,6 +1,10 @@
 package types
 
 import (
+	"context"
+	"net"
+	"time"
+
 	"github.com/vishvananda/netlink"
 
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
@@ -22,6 +26,17 @@ type KernelInterface interface {
 	GetCurrentKernelArgs() (string, error)
 	// IsKernelArgsSet check is the requested kernel arguments are set
 	IsKernelArgsSet(cmdLine, karg string) bool
+	// IsKernelArgKeySet check if the key of a kernel argument is set whatever its value
+	IsKernelArgKeySet(cmdLine, key string) bool
+	// GetKernelArgValue returns the value of the last occurrence of the key of a kernel argument
+	// and false if the key is not set
+	GetKernelArgValue(cmdLine, key string) (string, bool)
+	// HugepageKernelArgs returns the kernel arguments to set on the kernel cmd line to allocate count hugepages of the size
+	HugepageKernelArgs(cmdLine string, size string, count int) ([]string, error)
+	// IommuKernelArgForHost reads the /proc/cpuinfo to return the kernel argument enabling IOMMU for the CPU vendor
+	IommuKernelArgForHost() (string, error)
+	// IsIommuEnabled returns true if the IOMMU groups of the host are populated
+	IsIommuEnabled() bool
 	// Unbind unbinds a virtual function from is current driver
 	Unbind(pciAddr string) error
 	// BindDpdkDriver binds the virtual function to a DPDK driver
@@ -52,6 +67,9 @@ type KernelInterface interface {
 	LoadKernelModule(name string, args ...string) error
 	// IsKernelModuleLoaded returns try if the requested kernel module is loaded
 	IsKernelModuleLoaded(name string) (bool, error)
+	// ValidateRequiredModules checks the kernel modules are loaded or built in the kernel,
+	// returns the missing ones
+	ValidateRequiredModules(modules []string) ([]string, error)
 	// ReloadDriver reloads a requested driver
 	ReloadDriver(driver string) error
 	// IsKernelLockdownMode returns true if the kernel is in lockdown mode
@@ -87,10 +105,15 @@ type NetworkInterface interface {
 	GetPhysPortName(name string) (string, error)
 	// IsSwitchdev returns true of the pci address is on switchdev mode
 	IsSwitchdev(name string) bool
+	// WithPhysPortCache returns a copy of the helper caching the phys_port_name and phys_switch_id
+	// reads of the netdevs, the copy must be used for a single discovery pass only
+	WithPhysPortCache() NetworkInterface
 	// GetNetdevMTU returns the interface MTU for devices attached to kernel drivers
 	GetNetdevMTU(pciAddr string) int
-	// SetNetdevMTU sets the MTU for a request interface
-	SetNetdevMTU(pciAddr string, mtu int) error
+	// SetNetdevMTU sets the MTU for a request interface, the retries stop when the context is done
+	SetNetdevMTU(ctx context.Context, pciAddr string, mtu int) error
+	// MaxMTU returns the maximum MTU supported by the interface, 0 if the driver doesn't report it
+	MaxMTU(iface string) (int, error)
 	// GetNetDevMac returns the network interface mac address
 	GetNetDevMac(name string) string
 	// GetNetDevNodeGUID returns the network interface node GUID if device is RDMA capable otherwise returns empty string
@@ -100,14 +123,33 @@ type NetworkInterface interface {
 	// GetDevlinkDeviceParam returns devlink parameter for the device as a string, if the parameter has multiple values
 	// then the function will return only first one from the list.
 	GetDevlinkDeviceParam(pciAddr, paramName string) (string, error)
+	// GetDevlinkOffloadVfLimit returns the number of VFs that can be offloaded to the hardware
+	// as reported by the devlink resources of the device, 0 means the device reports no limit
+	GetDevlinkOffloadVfLimit(pciAddr string) (int, error)
 	// SetDevlinkDeviceParam set devlink parameter for the device, accepts paramName and value
 	// as a string. Automatically set CMODE for the parameter and converts the value to the right
 	// type before submitting it.
 	SetDevlinkDeviceParam(pciAddr, paramName, value string) error
 	// EnableHwTcOffload make sure that hw-tc-offload feature is enabled if device supports it
 	EnableHwTcOffload(ifaceName string) error
+	// SetNetdevCombinedChannels sets the number of combined channels of the interface,
+	// the number is clamped to the maximum supported by the device
+	SetNetdevCombinedChannels(ifaceName string, channels int) error
+	// GetNetdevCombinedChannels returns the current and the maximum number of combined channels of the interface
+	GetNetdevCombinedChannels(ifaceName string) (int, int, error)
+	// GetNetdevRingSizes returns the current and the maximum RX/TX ring sizes of the interface
+	GetNetdevRingSizes(ifaceName string) (*RingSizes, error)
+	// SetNetdevRingSizes sets the RX/TX ring sizes of the interface, a zero size is left unchanged
+	SetNetdevRingSizes(ifaceName string, rx, tx int) error
+	// GetRdmaSubsystemMode returns the network namespace mode of the RDMA subsystem, shared or exclusive
+	GetRdmaSubsystemMode() (string, error)
+	// SetRdmaSubsystemMode sets the network namespace mode of the RDMA subsystem, shared or exclusive
+	SetRdmaSubsystemMode(mode string) error
 	// GetNetDevLinkAdminState returns the admin state of the interface.
 	GetNetDevLinkAdminState(ifaceName string) string
+	// GetNetDevFirmwareVersion returns the firmware version of the network interface as reported by
+	// `ethtool -i`, an empty string is returned if the device doesn't report it
+	GetNetDevFirmwareVersion(ifaceName string) string
 }
 
 type ServiceInterface interface {
@@ -134,28 +176,70 @@ type SriovInterface interface {
 	// physical function base on pci address
 	SetSriovNumVfs(pciAddr string, numVfs int) error
 	// SetVfGUID sets the GUID for a virtual function
-	SetVfGUID(vfAddr string, pfLink netlink.Link) error
-	// VFIsReady returns the interface virtual function if the device is ready
-	VFIsReady(pciAddr string) (netlink.Link, error)
+	SetVfGUID(vfAddr string, pfLink netlink.Link, guid net.HardwareAddr) error
+	// VFIsReady returns the interface virtual function if the device is ready, the netdev of the VF
+	// is polled with the provided interval until the timeout expires or the context is done
+	VFIsReady(ctx context.Context, pciAddr string, interval, timeout time.Duration) (netlink.Link, error)
+	// GetInterfaceCapabilities returns the SR-IOV features and limits of the PF
+	GetInterfaceCapabilities(pciAddr string) (sriovnetworkv1.InterfaceCapabilities, error)
+	// WaitForVFs waits until the first count VFs of the PF have their PCI device and, when bound to
+	// a kernel network driver, their netdev, the missing VF indices are reported on timeout
+	WaitForVFs(pfPciAddr string, count int, timeout time.Duration) error
 	// SetVfAdminMac sets the virtual function administrative mac address via the physical function
 	SetVfAdminMac(vfAddr string, pfLink netlink.Link, vfLink netlink.Link) error
 	// GetNicSriovMode returns the interface mode
 	// supported modes SR-IOV legacy and switchdev
 	GetNicSriovMode(pciAddr string) string
+	// GetNicEswitchConfig returns the eswitch mode, inline mode and encap mode of the interface read from
+	// its devlink device in one query, the config is empty if the interface doesn't support devlink
+	GetNicEswitchConfig(pciAddr string) (EswitchConfig, error)
 	// SetNicSriovMode configure the interface mode
 	// supported modes SR-IOV legacy and switchdev
 	SetNicSriovMode(pciAddr, mode string) error
+	// SetNicEswitchInlineMode configures the eswitch inline mode of the interface,
+	// the device must be in the legacy mode
+	// supported modes none, link, network and transport
+	SetNicEswitchInlineMode(pciAddr, mode string) error
+	// SetNicEswitchEncapMode enables or disables the eswitch encapsulation offload of the interface,
+	// the device must be in the legacy mode
+	SetNicEswitchEncapMode(pciAddr string, enabled bool) error
+	// SetDevlinkVfRate configures the tx rate limits of a virtual function on its devlink port,
+	// used instead of the legacy VF rate when the physical function is in switchdev mode
+	SetDevlinkVfRate(pciAddr string, vfID int, minRate, maxRate uint64) error
 	// GetLinkType return the link type
 	// supported types are ethernet and infiniband
 	GetLinkType(name string) string
 	// ResetSriovDevice resets the number of virtual function for the specific physical function to zero
-	ResetSriovDevice(ifaceStatus sriovnetworkv1.InterfaceExt) error
+	// and restores the MTU the physical function had before it was managed
+	ResetSriovDevice(storeManager store.ManagerInterface, ifaceStatus sriovnetworkv1.InterfaceExt) error
 	// DiscoverSriovDevices returns a list of all the available SR-IOV capable network interfaces on the system
+	// when verbose discovery is enabled the devices excluded from the discovery are logged with the reason why
 	DiscoverSriovDevices(storeManager store.ManagerInterface) ([]sriovnetworkv1.InterfaceExt, error)
+	// DiscoverSriovDevicesVerbose returns a list of all the available SR-IOV capable network interfaces on the system
+	// alongside the PCI devices that were excluded from the discovery and the reason why
+	DiscoverSriovDevicesVerbose(storeManager store.ManagerInterface) ([]sriovnetworkv1.InterfaceExt, []FilteredDevice, error)
+	// DiscoverSriovDevice returns the SR-IOV capable network interface with the provided PCI address without
+	// walking the other PCI devices, a DeviceNotFoundError is returned if the address isn't a network PF
+	DiscoverSriovDevice(storeManager store.ManagerInterface, pciAddr string) (*sriovnetworkv1.InterfaceExt, error)
 	// ConfigSriovInterfaces configure multiple SR-IOV devices with the desired configuration
-	// if skipVFConfiguration flag is set, the function will configure PF and create VFs on it, but will skip VFs configuration
-	ConfigSriovInterfaces(storeManager store.ManagerInterface, interfaces []sriovnetworkv1.Interface,
+	// if skipVFConfiguration flag is set, the function will configure PF and create VFs on it, but will skip VFs configuration,
+	// the configuration stops between two PFs or two VFs when the context is done
+	ConfigSriovInterfaces(ctx context.Context, storeManager store.ManagerInterface, interfaces []sriovnetworkv1.Interface,
 		ifaceStatuses []sriovnetworkv1.InterfaceExt, skipVFConfiguration bool) error
+	// VerifyInterfaceConfig reads back the live state of the PF and of its VFs and returns a ConfigDriftError
+	// listing the numVfs, VF drivers, MTUs and MACs which don't match the desired configuration
+	VerifyInterfaceConfig(storeManager store.ManagerInterface, iface *sriovnetworkv1.Interface) error
+	// CheckSriovPrerequisites checks the host and the PF are able to create VFs: sriov_totalvfs, IOMMU enabled in BIOS
+	// and on the kernel command line, VF BARs assigned or pci=realloc set, returns the result of each check
+	CheckSriovPrerequisites(pciAddr string) []PrereqResult
+	// ConfigSriovInterfacesDryRun returns the changes ConfigSriovInterfaces would apply to the host
+	// for the desired configuration without applying them, the PFs with an externally managed mismatch
+	// are skipped and reported in the returned error together with the changes of the other PFs
+	ConfigSriovInterfacesDryRun(storeManager store.ManagerInterface, interfaces []sriovnetworkv1.Interface,
+		ifaceStatuses []sriovnetworkv1.InterfaceExt) ([]PlannedChange, error)
+	// ExportConfigScript returns a shell script applying the configuration of the node state to the host
+	// with the same sysfs writes, driver binds and ip/devlink commands as ConfigSriovInterfaces
+	ExportConfigScript(state *sriovnetworkv1.SriovNetworkNodeState) (string, error)
 	// ConfigSriovInterfaces configure virtual functions for virtual environments with the desired configuration
 	ConfigSriovDeviceVirtual(iface *sriovnetworkv1.Interface) error
 }
@@ -164,6 +248,9 @@ type UdevInterface interface {
 	// PrepareNMUdevRule creates the needed udev rules to disable NetworkManager from
 	// our managed SR-IOV virtual functions
 	PrepareNMUdevRule(supportedVfIds []string) error
+	// ReloadSupportedVfIds updates the list of supported virtual functions IDs used by the udev rules
+	// and rewrites the existing rules disabling NetworkManager, it doesn't require a daemon restart
+	ReloadSupportedVfIds(supportedVfIds []string) error
 	// PrepareVFRepUdevRule creates a script which helps to configure representor name for the VF
 	PrepareVFRepUdevRule() error
 	// AddDisableNMUdevRule adds udev rule that disables NetworkManager for VFs on the concrete PF:
@@ -1,5 +1,12 @@
 package types
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+)
+
 // Service contains info about systemd service
 type Service struct {
 	Name    string
@@ -28,3 +35,237 @@ type ScriptManifestFile struct {
 		Inline string
 	}
 }
+
+// Reasons reported for the devices excluded from the SR-IOV discovery
+const (
+	FilteredReasonInvalidClass     = "unable to parse the device class"
+	FilteredReasonNotNetwork       = "not a network device"
+	FilteredReasonSriovVF          = "SR-IOV virtual function"
+	FilteredReasonUnsupportedModel = "unsupported device model"
+	FilteredReasonNoDriver         = "unable to read the device driver"
+	FilteredReasonNoNetdev         = "unable to find the network interface of the device"
+	FilteredReasonNoLink           = "unable to get the link of the device"
+	FilteredReasonHostManaged      = "device carries the default route of the host"
+	FilteredReasonNoVFList         = "unable to list the virtual functions of the device"
+	FilteredReasonDiscoveryFilter  = "excluded by the discovery allow or deny list"
+)
+
+// FilteredDevice contains a PCI device excluded from the SR-IOV discovery and the reason why
+type FilteredDevice struct {
+	Address string
+	Reason  string
+}
+
+// NumVfsExceedTotalVfsError is returned when the number of VFs requested for a PF
+// is larger than the number of VFs the PF supports
+type NumVfsExceedTotalVfsError struct {
+	PciAddress string
+	NumVfs     int
+	TotalVfs   int
+}
+
+func (e *NumVfsExceedTotalVfsError) Error() string {
+	return fmt.Sprintf("cannot config SRIOV device: NumVfs (%d) is larger than TotalVfs (%d)", e.NumVfs, e.TotalVfs)
+}
+
+// MtuExceedsMaxError is returned when the MTU requested for a device is larger than
+// the maximum MTU the device supports
+type MtuExceedsMaxError struct {
+	Device string
+	Mtu    int
+	MaxMtu int
+}
+
+func (e *MtuExceedsMaxError) Error() string {
+	return fmt.Sprintf("requested MTU %d for device %s exceeds max %d", e.Mtu, e.Device, e.MaxMtu)
+}
+
+// UnsupportedConfigError is returned when the configuration requested for a PF uses settings
+// the capabilities of the PF don't allow, the driver would silently ignore or reject them
+type UnsupportedConfigError struct {
+	PciAddress string
+	// Settings are the requested settings the PF doesn't support
+	Settings []string
+}
+
+func (e *UnsupportedConfigError) Error() string {
+	return fmt.Sprintf("configuration of device %s not supported by the device: %s", e.PciAddress, strings.Join(e.Settings, ", "))
+}
+
+// ExternallyManagedMismatchError is returned when the ExternallyManaged flag requested for a PF
+// doesn't match the one the existing VFs of the PF were configured with
+type ExternallyManagedMismatchError struct {
+	PciAddress string
+	// Requested is the ExternallyManaged flag requested for the PF
+	Requested bool
+}
+
+func (e *ExternallyManagedMismatchError) Error() string {
+	if e.Requested {
+		return fmt.Sprintf("PF %s is requested as externally managed but its VFs were created by the sriov operator, "+
+			"remove the VFs by removing the policy first or set externallyManaged to false in the policy", e.PciAddress)
+	}
+	return fmt.Sprintf("PF %s is requested to be managed by the sriov operator but its VFs were created externally, "+
+		"remove the VFs on the host first or set externallyManaged to true in the policy", e.PciAddress)
+}
+
+// VfRepresentorsNotReadyError is returned when some representors of the VFs of a switchdev PF are missing,
+// representors are transiently removed e.g. while OVS restarts, so the configuration should be retried
+type VfRepresentorsNotReadyError struct {
+	PciAddress string
+	// MissingVfs are the indexes of the VFs without a representor
+	MissingVfs []int
+}
+
+func (e *VfRepresentorsNotReadyError) Error() string {
+	return fmt.Sprintf("representors of VFs %v of switchdev PF %s are not present yet", e.MissingVfs, e.PciAddress)
+}
+
+// Temporary reports that the error is transient and the configuration can be retried
+func (e *VfRepresentorsNotReadyError) Temporary() bool {
+	return true
+}
+
+// DeviceNotFoundError is returned by the discovery of a single device when the PCI address
+// is not the one of a network PF which can be discovered
+type DeviceNotFoundError struct {
+	PciAddress string
+	// Reason is why the device can't be discovered, one of the FilteredReason* constants
+	// if the device exists
+	Reason string
+}
+
+func (e *DeviceNotFoundError) Error() string {
+	return fmt.Sprintf("network PF %s not found: %s", e.PciAddress, e.Reason)
+}
+
+// EswitchConfig is the eswitch configuration of a PF read from its devlink device in one query
+type EswitchConfig struct {
+	// Mode is the eswitch mode, legacy or switchdev
+	Mode string
+	// InlineMode is the minimum header inlined in the packets sent by the VFs, e.g. transport
+	InlineMode string
+	// EncapMode is the encapsulation offload mode, enable or disable
+	EncapMode string
+}
+
+// DevlinkNotSupportedError is returned when the driver of the device doesn't expose it through devlink,
+// the eswitch of such a device is always in legacy mode
+type DevlinkNotSupportedError struct {
+	PciAddress string
+}
+
+func (e *DevlinkNotSupportedError) Error() string {
+	return fmt.Sprintf("device %s doesn't support devlink", e.PciAddress)
+}
+
+// Unwrap returns ENODEV, the error reported by the kernel for the devices without devlink
+func (e *DevlinkNotSupportedError) Unwrap() error {
+	return syscall.ENODEV
+}
+
+// MacAddressConflictError is returned before applying the configuration when the same administrative
+// MAC address would be used by more than one VF, or by a VF and a PF of the node
+type MacAddressConflictError struct {
+	// Conflicts contains the devices using each of the duplicated MAC addresses
+	Conflicts map[string][]string
+}
+
+func (e *MacAddressConflictError) Error() string {
+	macs := make([]string, 0, len(e.Conflicts))
+	for mac := range e.Conflicts {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+	conflicts := make([]string, 0, len(macs))
+	for _, mac := range macs {
+		conflicts = append(conflicts, fmt.Sprintf("%s used by %s", mac, strings.Join(e.Conflicts[mac], ", ")))
+	}
+	return fmt.Sprintf("MAC address conflicts: %s", strings.Join(conflicts, "; "))
+}
+
+// ConfigDriftError is returned when the live state of a PF read back after the configuration
+// doesn't match the desired configuration, e.g. because a udev rule or the driver reverted it
+type ConfigDriftError struct {
+	// PciAddress is the PCI address of the PF
+	PciAddress string
+	// Drifts contains the mismatches, Current is the live value and Desired the configured one
+	Drifts []PlannedChange
+}
+
+func (e *ConfigDriftError) Error() string {
+	drifts := make([]string, 0, len(e.Drifts))
+	for _, d := range e.Drifts {
+		target := d.Kind
+		if d.VfID != nil {
+			target = fmt.Sprintf("VF %d %s", *d.VfID, d.Kind)
+		}
+		drifts = append(drifts, fmt.Sprintf("%s is %q instead of %q", target, d.Current, d.Desired))
+	}
+	return fmt.Sprintf("configuration of device %s drifted: %s", e.PciAddress, strings.Join(drifts, "; "))
+}
+
+// Kinds of the changes reported by a dry run of the SR-IOV configuration
+const (
+	// PlannedChangeNumVfs is a write of the number of VFs of the PF
+	PlannedChangeNumVfs = "numVfs"
+	// PlannedChangeEswitchMode is a change of the eswitch mode of the PF
+	PlannedChangeEswitchMode = "eswitchMode"
+	// PlannedChangeMtu is a change of the MTU of the PF or of a VF
+	PlannedChangeMtu = "mtu"
+	// PlannedChangeUdevRules is a rewrite of the udev rules of the PF
+	PlannedChangeUdevRules = "udevRules"
+	// PlannedChangeDriver is a rebind of a VF, the desired value is the DPDK driver or
+	// netdevice for the default kernel driver
+	PlannedChangeDriver = "driver"
+	// PlannedChangeMac is a change of the administrative MAC address of a VF
+	PlannedChangeMac = "mac"
+	// PlannedChangeReset is the removal of the VFs of a PF which is not configured anymore
+	PlannedChangeReset = "reset"
+)
+
+// RingSizes contains the current and the maximum RX/TX ring sizes of a netdev
+type RingSizes struct {
+	Rx    int
+	Tx    int
+	MaxRx int
+	MaxTx int
+}
+
+// PlannedChange is a change of the host the SR-IOV configuration would apply
+type PlannedChange struct {
+	// PciAddress is the PCI address of the PF
+	PciAddress string
+	// VfID is the index of the changed VF, nil for a change of the PF
+	VfID *int
+	// Kind is the kind of the change, one of the PlannedChange* constants
+	Kind string
+	// Current is the current value, empty if unknown
+	Current string
+	// Desired is the value that would be applied
+	Desired string
+}
+
+// Names of the checks run by the SR-IOV prerequisites check
+const (
+	// PrereqSriovTotalVfs checks the PF reports a non zero sriov_totalvfs
+	PrereqSriovTotalVfs = "sriovTotalVfs"
+	// PrereqIommuEnabled checks the IOMMU groups of the host are populated, i.e. VT-d or AMD-Vi is enabled in BIOS
+	PrereqIommuEnabled = "iommuEnabled"
+	// PrereqIommuKernelArg checks the kernel argument enabling IOMMU is set
+	PrereqIommuKernelArg = "iommuKernelArg"
+	// PrereqPciRealloc checks the VF BARs of the PF are assigned or pci=realloc is set
+	PrereqPciRealloc = "pciRealloc"
+)
+
+// PrereqResult is the result of one of the checks of the SR-IOV prerequisites of a PF
+type PrereqResult struct {
+	// Name is the name of the check, one of the Prereq* constants
+	Name string
+	// Passed is true if the prerequisite is met
+	Passed bool
+	// Message describes what was found on the host
+	Message string
+	// Remediation is a hint to fix the failed prerequisite, empty if the check passed
+	Remediation string
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "sriov_config_daemon"
+
+	// ResultSuccess is the result label of the successful operations
+	ResultSuccess = "success"
+	// ResultFailure is the result label of the failed operations
+	ResultFailure = "failure"
+)
+
+var (
+	// nodeStateSyncsTotal counts the syncs of the node state by the daemon
+	nodeStateSyncsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_state_syncs_total",
+		Help:      "Number of syncs of the SR-IOV node state by result",
+	}, []string{"result"})
+
+	// nodeStateSyncDuration observes the time spent syncing the node state
+	nodeStateSyncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_state_sync_duration_seconds",
+		Help:      "Time spent syncing the SR-IOV node state",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	})
+
+	// configRunsTotal counts the runs of the SR-IOV interfaces configuration
+	configRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "config_runs_total",
+		Help:      "Number of runs of the SR-IOV interfaces configuration by result",
+	}, []string{"result"})
+
+	// pfConfigDuration observes the time spent configuring a PF and its VFs
+	pfConfigDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "pf_config_duration_seconds",
+		Help:      "Time spent configuring a PF and its VFs",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	}, []string{"pf", "vendor"})
+
+	// pfConfigFailuresTotal counts the failed configurations of a PF
+	pfConfigFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "pf_config_failures_total",
+		Help:      "Number of failed configurations of a PF",
+	}, []string{"pf", "vendor"})
+
+	// pfResetsTotal counts the resets of a PF to its initial state
+	pfResetsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "pf_resets_total",
+		Help:      "Number of resets of a PF by result",
+	}, []string{"pf", "vendor", "result"})
+
+	// vfDriverRebindsTotal counts the VFs rebound to their default driver because their netdev didn't appear
+	vfDriverRebindsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "vf_driver_rebinds_total",
+		Help:      "Number of VFs of a PF rebound to their default driver because their netdev didn't appear",
+	}, []string{"pf"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(nodeStateSyncsTotal, nodeStateSyncDuration, configRunsTotal, pfConfigDuration, pfConfigFailuresTotal,
+		pfResetsTotal, vfDriverRebindsTotal)
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// ObserveNodeStateSync records a sync of the node state which took the provided duration
+func ObserveNodeStateSync(duration time.Duration, err error) {
+	nodeStateSyncDuration.Observe(duration.Seconds())
+	nodeStateSyncsTotal.WithLabelValues(result(err)).Inc()
+}
+
+// ObserveConfigRun records a run of the SR-IOV interfaces configuration
+func ObserveConfigRun(err error) {
+	configRunsTotal.WithLabelValues(result(err)).Inc()
+}
+
+// ObservePfConfig records the configuration of a PF which took the provided duration
+func ObservePfConfig(pf, vendor string, duration time.Duration, err error) {
+	pfConfigDuration.WithLabelValues(pf, vendor).Observe(duration.Seconds())
+	if err != nil {
+		pfConfigFailuresTotal.WithLabelValues(pf, vendor).Inc()
+	}
+}
+
+// ObservePfReset records a reset of a PF to its initial state
+func ObservePfReset(pf, vendor string, err error) {
+	pfResetsTotal.WithLabelValues(pf, vendor, result(err)).Inc()
+}
+
+// ObserveVfDriverRebind records a VF of the PF rebound to its default driver
+func ObserveVfDriverRebind(pf string) {
+	vfDriverRebindsTotal.WithLabelValues(pf).Inc()
+}
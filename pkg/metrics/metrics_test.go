@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// gather returns the metric of the family with the provided name matching the labels, nil if not found
+func gather(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather the metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m
+		}
+	}
+	return nil
+}
+
+func TestObserveNodeStateSync(t *testing.T) {
+	ObserveNodeStateSync(time.Second, nil)
+	ObserveNodeStateSync(3*time.Second, errors.New("test"))
+
+	if m := gather(t, "sriov_config_daemon_node_state_syncs_total", map[string]string{"result": ResultSuccess}); m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 successful sync, got %v", m)
+	}
+	if m := gather(t, "sriov_config_daemon_node_state_syncs_total", map[string]string{"result": ResultFailure}); m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 failed sync, got %v", m)
+	}
+	m := gather(t, "sriov_config_daemon_node_state_sync_duration_seconds", nil)
+	if m.GetHistogram().GetSampleCount() != 2 || m.GetHistogram().GetSampleSum() != 4 {
+		t.Errorf("expected 2 observations summing to 4s, got %v", m)
+	}
+}
+
+func TestObserveConfigRun(t *testing.T) {
+	ObserveConfigRun(nil)
+	ObserveConfigRun(nil)
+	ObserveConfigRun(errors.New("test"))
+
+	if m := gather(t, "sriov_config_daemon_config_runs_total", map[string]string{"result": ResultSuccess}); m.GetCounter().GetValue() != 2 {
+		t.Errorf("expected 2 successful runs, got %v", m)
+	}
+	if m := gather(t, "sriov_config_daemon_config_runs_total", map[string]string{"result": ResultFailure}); m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 failed run, got %v", m)
+	}
+}
+
+func TestObservePfConfig(t *testing.T) {
+	labels := map[string]string{"pf": "0000:3b:00.0", "vendor": "15b3"}
+	ObservePfConfig("0000:3b:00.0", "15b3", 2*time.Second, nil)
+	ObservePfConfig("0000:3b:00.0", "15b3", 4*time.Second, errors.New("test"))
+
+	m := gather(t, "sriov_config_daemon_pf_config_duration_seconds", labels)
+	if m.GetHistogram().GetSampleCount() != 2 || m.GetHistogram().GetSampleSum() != 6 {
+		t.Errorf("expected 2 observations summing to 6s, got %v", m)
+	}
+	if m := gather(t, "sriov_config_daemon_pf_config_failures_total", labels); m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 failure, got %v", m)
+	}
+}
+
+func TestObservePfResetAndVfDriverRebind(t *testing.T) {
+	ObservePfReset("0000:3b:00.1", "15b3", nil)
+	ObserveVfDriverRebind("0000:3b:00.1")
+	ObserveVfDriverRebind("0000:3b:00.1")
+
+	if m := gather(t, "sriov_config_daemon_pf_resets_total",
+		map[string]string{"pf": "0000:3b:00.1", "vendor": "15b3", "result": ResultSuccess}); m.GetCounter().GetValue() != 1 {
+		t.Errorf("expected 1 reset, got %v", m)
+	}
+	if m := gather(t, "sriov_config_daemon_vf_driver_rebinds_total",
+		map[string]string{"pf": "0000:3b:00.1"}); m.GetCounter().GetValue() != 2 {
+		t.Errorf("expected 2 rebinds, got %v", m)
+	}
+}
@@ -1,6 +1,7 @@
 package fake
 
 import (
+	"context"
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
 )
 
@@ -25,6 +26,6 @@ func (f *FakePlugin) CheckStatusChanges(new *sriovnetworkv1.SriovNetworkNodeStat
 	return false, nil
 }
 
-func (f *FakePlugin) Apply() error {
+func (f *FakePlugin) Apply(ctx context.Context) error {
 	return nil
 }
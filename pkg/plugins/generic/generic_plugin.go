@@ -2,8 +2,11 @@ package generic
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -72,7 +75,7 @@ type genericPluginOptions struct {
 	skipVFConfiguration bool
 }
 
-const scriptsPath = "bindata/scripts/enable-kargs.sh"
+var scriptsPath = "bindata/scripts/enable-kargs.sh"
 
 // Initialize our plugin and set up initial values
 func NewGenericPlugin(helpers helper.HostHelpersInterface, options ...Option) (plugin.VendorPlugin, error) {
@@ -128,6 +131,11 @@ func (p *GenericPlugin) OnNodeStateChange(new *sriovnetworkv1.SriovNetworkNodeSt
 	p.DesireState = new
 
 	needDrain = p.needDrainNode(new.Spec.Interfaces, new.Status.Interfaces)
+	// the RDMA subsystem mode can't be changed while pods use RDMA devices in their network namespace
+	if !needDrain && p.needRdmaSubsystemModeChange(new) {
+		log.Log.V(2).Info("generic plugin OnNodeStateChange(): need drain to change the RDMA subsystem mode")
+		needDrain = true
+	}
 	needReboot, err = p.needRebootNode(new)
 	if err != nil {
 		return needDrain, needReboot, err
@@ -147,7 +155,7 @@ func (p *GenericPlugin) CheckStatusChanges(current *sriovnetworkv1.SriovNetworkN
 		found := false
 		for _, ifaceStatus := range current.Status.Interfaces {
 			// TODO: remove the check for ExternallyManaged - https://github.com/k8snetworkplumbingwg/sriov-network-operator/issues/632
-			if iface.PciAddress == ifaceStatus.PciAddress && !iface.ExternallyManaged {
+			if sriovnetworkv1.PciAddressEqual(iface.PciAddress, ifaceStatus.PciAddress) && !iface.ExternallyManaged {
 				found = true
 				if sriovnetworkv1.NeedToUpdateSriov(&iface, &ifaceStatus) {
 					log.Log.Info("CheckStatusChanges(): status changed for interface", "address", iface.PciAddress)
@@ -189,14 +197,56 @@ func (p *GenericPlugin) syncDriverState() error {
 	return nil
 }
 
+// getRequiredKernelModules returns the kernel modules requested by the policies and the drivers needed by the VFs
+func (p *GenericPlugin) getRequiredKernelModules() []string {
+	modules := append([]string{}, p.DesireState.Spec.RequiredKernelModules...)
+	for _, driverState := range p.DriverStateMap {
+		if driverState.NeedDriverFunc(p.DesireState, driverState) {
+			modules = sriovnetworkv1.UniqueAppend(modules, driverState.DriverName)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// syncRequiredKernelModules checks the required kernel modules are loaded when the validation is enabled,
+// the missing modules are loaded if requested, otherwise the configuration fails
+func (p *GenericPlugin) syncRequiredKernelModules() error {
+	if !vars.ValidateKernelModules {
+		return nil
+	}
+	missing, err := p.helpers.ValidateRequiredModules(p.getRequiredKernelModules())
+	if err != nil {
+		log.Log.Error(err, "generic plugin syncRequiredKernelModules(): failed to validate kernel modules")
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !vars.LoadMissingKernelModules {
+		return fmt.Errorf("required kernel modules are not loaded: %s", strings.Join(missing, ", "))
+	}
+	for _, module := range missing {
+		if err := p.helpers.LoadKernelModule(module); err != nil {
+			log.Log.Error(err, "generic plugin syncRequiredKernelModules(): fail to load kmod", "name", module)
+			return fmt.Errorf("failed to load required kernel module %s: %v", module, err)
+		}
+	}
+	return nil
+}
+
 // Apply config change
-func (p *GenericPlugin) Apply() error {
+func (p *GenericPlugin) Apply(ctx context.Context) error {
 	log.Log.Info("generic plugin Apply()", "desiredState", p.DesireState.Spec)
 
 	if err := p.syncDriverState(); err != nil {
 		return err
 	}
 
+	if err := p.syncRequiredKernelModules(); err != nil {
+		return err
+	}
+
 	// When calling from systemd do not try to chroot
 	if !vars.UsingSystemdMode {
 		exit, err := p.helpers.Chroot(consts.Host)
@@ -206,18 +256,120 @@ func (p *GenericPlugin) Apply() error {
 		defer exit()
 	}
 
-	if err := p.helpers.ConfigSriovInterfaces(p.helpers, p.DesireState.Spec.Interfaces,
+	if err := p.syncRdmaSubsystemMode(); err != nil {
+		return err
+	}
+
+	if err := p.adoptExistingPfs(); err != nil {
+		return err
+	}
+
+	if err := p.helpers.ConfigSriovInterfaces(ctx, p.helpers, p.DesireState.Spec.Interfaces,
 		p.DesireState.Status.Interfaces, p.skipVFConfiguration); err != nil {
 		// Catch the "cannot allocate memory" error and try to use PCI realloc
 		if errors.Is(err, syscall.ENOMEM) {
 			p.addToDesiredKernelArgs(consts.KernelArgPciRealloc)
 		}
-		return err
+		return p.withFailedPrerequisites(err)
 	}
 
 	return nil
 }
 
+// withFailedPrerequisites adds the failed SR-IOV prerequisites of the desired PFs to the configuration error,
+// the error is reported in the node state so the user gets the remediation hints of the failed checks
+func (p *GenericPlugin) withFailedPrerequisites(err error) error {
+	errs := []error{err}
+	for _, iface := range p.DesireState.Spec.Interfaces {
+		for _, result := range p.helpers.CheckSriovPrerequisites(iface.PciAddress) {
+			if result.Passed {
+				continue
+			}
+			log.Log.Info("generic plugin withFailedPrerequisites(): sriov prerequisite not met", "device", iface.PciAddress,
+				"check", result.Name, "message", result.Message, "remediation", result.Remediation)
+			errs = append(errs, fmt.Errorf("sriov prerequisite %s not met for device %s: %s, %s",
+				result.Name, iface.PciAddress, result.Message, result.Remediation))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// adoptExistingPfs takes ownership of the PFs with VFs which were not created by the operator when the
+// policy selecting them requests the adoption, a fresh PF status is saved so the PFs are configured like
+// the PFs created by the operator and reset once no policy selects them. The PFs requested as externally
+// managed are left untouched.
+func (p *GenericPlugin) adoptExistingPfs() error {
+	for _, iface := range p.DesireState.Spec.Interfaces {
+		if !iface.AdoptExisting || iface.ExternallyManaged {
+			continue
+		}
+		var ifaceStatus *sriovnetworkv1.InterfaceExt
+		for i := range p.DesireState.Status.Interfaces {
+			if sriovnetworkv1.PciAddressEqual(p.DesireState.Status.Interfaces[i].PciAddress, iface.PciAddress) {
+				ifaceStatus = &p.DesireState.Status.Interfaces[i]
+				break
+			}
+		}
+		if ifaceStatus == nil || ifaceStatus.NumVfs == 0 {
+			continue
+		}
+		pfStatus, exist, err := p.helpers.LoadPfsStatus(ifaceStatus.PciAddress)
+		if err != nil {
+			log.Log.Error(err, "generic plugin adoptExistingPfs(): failed to load info about PF status for pci device",
+				"address", ifaceStatus.PciAddress)
+			return err
+		}
+		if exist && !pfStatus.ExternallyManaged {
+			continue
+		}
+		log.Log.Info("generic plugin adoptExistingPfs(): WARNING: taking ownership of PF with VFs not created by the sriov operator, "+
+			"the PF is reset once no policy selects it",
+			"name", ifaceStatus.Name, "address", ifaceStatus.PciAddress, "numVfs", ifaceStatus.NumVfs, "storeEntryFound", exist)
+		if err := p.helpers.SaveLastPfAppliedStatus(&sriovnetworkv1.Interface{
+			Name:       ifaceStatus.Name,
+			PciAddress: ifaceStatus.PciAddress,
+			NumVfs:     ifaceStatus.NumVfs,
+			Mtu:        ifaceStatus.Mtu,
+			LinkType:   ifaceStatus.LinkType,
+		}); err != nil {
+			log.Log.Error(err, "generic plugin adoptExistingPfs(): failed to save PF status", "address", ifaceStatus.PciAddress)
+			return err
+		}
+	}
+	return nil
+}
+
+// needRdmaSubsystemModeChange returns true if the RDMA subsystem mode requested in the state differs from the current one
+func (p *GenericPlugin) needRdmaSubsystemModeChange(state *sriovnetworkv1.SriovNetworkNodeState) bool {
+	if state.Spec.RdmaMode == "" {
+		return false
+	}
+	current, err := p.helpers.GetRdmaSubsystemMode()
+	if err != nil {
+		log.Log.Error(err, "generic plugin needRdmaSubsystemModeChange(): failed to get RDMA subsystem mode")
+		return false
+	}
+	return current != state.Spec.RdmaMode
+}
+
+// syncRdmaSubsystemMode sets the RDMA subsystem mode requested in the desired state if it differs from the current one
+func (p *GenericPlugin) syncRdmaSubsystemMode() error {
+	desired := p.DesireState.Spec.RdmaMode
+	if desired == "" {
+		return nil
+	}
+	current, err := p.helpers.GetRdmaSubsystemMode()
+	if err != nil {
+		log.Log.Error(err, "generic plugin syncRdmaSubsystemMode(): failed to get RDMA subsystem mode")
+		return err
+	}
+	if current == desired {
+		return nil
+	}
+	log.Log.Info("generic plugin syncRdmaSubsystemMode(): set RDMA subsystem mode", "current", current, "desired", desired)
+	return p.helpers.SetRdmaSubsystemMode(desired)
+}
+
 func needDriverCheckDeviceType(state *sriovnetworkv1.SriovNetworkNodeState, driverState *DriverState) bool {
 	for _, iface := range state.Spec.Interfaces {
 		for i := range iface.VfGroups {
@@ -240,29 +392,33 @@ func needDriverCheckVdpaType(state *sriovnetworkv1.SriovNetworkNodeState, driver
 	return false
 }
 
-// setKernelArg Tries to add the kernel args via ostree or grubby.
-func setKernelArg(karg string) (bool, error) {
-	log.Log.Info("generic plugin setKernelArg()")
+// setKernelArgsFunc is used to override setKernelArgs in unit tests
+var setKernelArgsFunc = setKernelArgs
+
+// setKernelArgs Tries to add the kernel args via ostree or grubby, the args are appended in order.
+func setKernelArgs(kargs ...string) (bool, error) {
+	log.Log.Info("generic plugin setKernelArgs()")
+	karg := strings.Join(kargs, " ")
 	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("/bin/sh", scriptsPath, karg)
+	cmd := exec.Command("/bin/bash", append([]string{scriptsPath}, kargs...)...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
 		// if grubby is not there log and assume kernel args are set correctly.
 		if utils.IsCommandNotFound(err) {
-			log.Log.Error(err, "generic plugin setKernelArg(): grubby or ostree command not found. Please ensure that kernel arg are set",
+			log.Log.Error(err, "generic plugin setKernelArgs(): grubby or ostree command not found. Please ensure that kernel arg are set",
 				"kargs", karg)
 			return false, nil
 		}
-		log.Log.Error(err, "generic plugin setKernelArg(): fail to enable kernel arg", "karg", karg)
+		log.Log.Error(err, "generic plugin setKernelArgs(): fail to enable kernel arg", "karg", karg)
 		return false, err
 	}
 
 	i, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
 	if err == nil {
 		if i > 0 {
-			log.Log.Info("generic plugin setKernelArg(): need to reboot node for kernel arg", "karg", karg)
+			log.Log.Info("generic plugin setKernelArgs(): need to reboot node for kernel arg", "karg", karg)
 			return true, nil
 		}
 	}
@@ -309,7 +465,7 @@ func (p *GenericPlugin) syncDesiredKernelArgs(kargs []string) (bool, error) {
 		// There is a case when we try to set the kernel argument here, the daemon could decide to not reboot because
 		// the daemon encountered a potentially one-time error. However we always want to make sure that the kernel
 		// argument is set once the daemon goes through node state sync again.
-		update, err := setKernelArg(karg)
+		update, err := setKernelArgsFunc(karg)
 		if err != nil {
 			log.Log.Error(err, "generic-plugin syncDesiredKernelArgs(): fail to set kernel arg", "karg", karg)
 			return false, err
@@ -323,6 +479,30 @@ func (p *GenericPlugin) syncDesiredKernelArgs(kargs []string) (bool, error) {
 	return needReboot, nil
 }
 
+// EnsureHugepageArgs sets the kernel args allocating count hugepages of the size, the hugepage args already
+// set on the kernel cmd line are kept. Returns true if the node must be rebooted to apply the args.
+func (p *GenericPlugin) EnsureHugepageArgs(size string, count int) (bool, error) {
+	cmdLine, err := p.helpers.GetCurrentKernelArgs()
+	if err != nil {
+		return false, err
+	}
+	kargs, err := p.helpers.HugepageKernelArgs(cmdLine, size, count)
+	if err != nil {
+		return false, err
+	}
+	if len(kargs) == 0 {
+		return false, nil
+	}
+	log.Log.Info("generic plugin EnsureHugepageArgs(): set hugepage kernel args", "kargs", kargs)
+	// the args are set together to keep hugepages after the matching hugepagesz
+	needReboot, err := setKernelArgsFunc(kargs...)
+	if err != nil {
+		log.Log.Error(err, "generic plugin EnsureHugepageArgs(): fail to set hugepage kernel args", "kargs", kargs)
+		return false, err
+	}
+	return needReboot, nil
+}
+
 func (p *GenericPlugin) needDrainNode(desired sriovnetworkv1.Interfaces, current sriovnetworkv1.InterfaceExts) (needDrain bool) {
 	log.Log.V(2).Info("generic plugin needDrainNode()", "current", current, "desired", desired)
 
@@ -330,7 +510,7 @@ func (p *GenericPlugin) needDrainNode(desired sriovnetworkv1.Interfaces, current
 	for _, ifaceStatus := range current {
 		configured := false
 		for _, iface := range desired {
-			if iface.PciAddress == ifaceStatus.PciAddress {
+			if sriovnetworkv1.PciAddressEqual(iface.PciAddress, ifaceStatus.PciAddress) {
 				configured = true
 				if ifaceStatus.NumVfs == 0 {
 					log.Log.V(2).Info("generic plugin needDrainNode(): no need drain, for PCI address, current NumVfs is 0",
@@ -382,7 +562,13 @@ func (p *GenericPlugin) needDrainNode(desired sriovnetworkv1.Interfaces, current
 func (p *GenericPlugin) addVfioDesiredKernelArg(state *sriovnetworkv1.SriovNetworkNodeState) {
 	driverState := p.DriverStateMap[Vfio]
 	if !driverState.DriverLoaded && driverState.NeedDriverFunc(state, driverState) {
-		p.addToDesiredKernelArgs(consts.KernelArgIntelIommu)
+		// the hosts without a known CPU vendor, e.g. arm64, don't need a vendor specific argument to enable IOMMU
+		iommuKernelArg, err := p.helpers.IommuKernelArgForHost()
+		if err != nil {
+			log.Log.Error(err, "generic-plugin addVfioDesiredKernelArg(): failed to get the IOMMU kernel argument, skipping it")
+		} else {
+			p.addToDesiredKernelArgs(iommuKernelArg)
+		}
 		p.addToDesiredKernelArgs(consts.KernelArgIommuPt)
 	}
 }
@@ -409,6 +595,20 @@ func (p *GenericPlugin) needRebootNode(state *sriovnetworkv1.SriovNetworkNodeSta
 		}
 	}
 
+	for _, hugepages := range state.Spec.Hugepages {
+		update, err := p.EnsureHugepageArgs(hugepages.Size, hugepages.Count)
+		if err != nil {
+			log.Log.Error(err, "generic-plugin needRebootNode(): failed to set the hugepage kernel arguments",
+				"size", hugepages.Size, "count", hugepages.Count)
+			return false, err
+		}
+		if update {
+			log.Log.V(2).Info("generic-plugin needRebootNode(): need reboot for allocating hugepages",
+				"size", hugepages.Size, "count", hugepages.Count)
+			needReboot = true
+		}
+	}
+
 	return needReboot, nil
 }
 
@@ -1,6 +1,10 @@
 package generic
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -11,6 +15,7 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
 	mock_helper "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/helper/mock"
 	plugin "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/plugins"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 )
 
 func TestGenericPlugin(t *testing.T) {
@@ -892,6 +897,7 @@ var _ = Describe("Generic plugin", func() {
 			}
 
 			// Load required kernel args.
+			hostHelper.EXPECT().IommuKernelArgForHost().Return(consts.KernelArgIntelIommu, nil)
 			genericPlugin.(*GenericPlugin).addVfioDesiredKernelArg(networkNodeState)
 
 			hostHelper.EXPECT().GetCurrentKernelArgs().Return("", nil)
@@ -903,6 +909,26 @@ var _ = Describe("Generic plugin", func() {
 			Expect(changed).To(BeTrue())
 		})
 
+		It("should skip the IOMMU kernel arg when the CPU vendor is unknown", func() {
+			networkNodeState := &sriovnetworkv1.SriovNetworkNodeState{
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
+					Interfaces: sriovnetworkv1.Interfaces{{
+						PciAddress: "0000:00:00.0",
+						NumVfs:     2,
+						VfGroups: []sriovnetworkv1.VfGroup{{
+							DeviceType:   "vfio-pci",
+							PolicyName:   "policy-1",
+							ResourceName: "resource-1",
+							VfRange:      "0-1",
+						}}}},
+				},
+			}
+
+			hostHelper.EXPECT().IommuKernelArgForHost().Return("", fmt.Errorf("IommuKernelArgForHost(): unknown CPU vendor \"\""))
+			genericPlugin.(*GenericPlugin).addVfioDesiredKernelArg(networkNodeState)
+			Expect(genericPlugin.(*GenericPlugin).DesiredKernelArgs).To(Equal(map[string]bool{consts.KernelArgIommuPt: false}))
+		})
+
 		It("should load vfio_pci driver", func() {
 			networkNodeState := &sriovnetworkv1.SriovNetworkNodeState{
 				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
@@ -1074,4 +1100,254 @@ var _ = Describe("Generic plugin", func() {
 		})
 	})
 
+	Context("RDMA subsystem mode", func() {
+		var concretePlugin *GenericPlugin
+		BeforeEach(func() {
+			concretePlugin = genericPlugin.(*GenericPlugin)
+		})
+
+		DescribeTable("should set the requested mode",
+			func(current, desired string) {
+				concretePlugin.DesireState = &sriovnetworkv1.SriovNetworkNodeState{
+					Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{RdmaMode: desired}}
+				hostHelper.EXPECT().GetRdmaSubsystemMode().Return(current, nil).Times(2)
+				hostHelper.EXPECT().SetRdmaSubsystemMode(desired).Return(nil)
+				Expect(concretePlugin.needRdmaSubsystemModeChange(concretePlugin.DesireState)).To(BeTrue())
+				Expect(concretePlugin.syncRdmaSubsystemMode()).To(Succeed())
+			},
+			Entry("exclusive", "shared", "exclusive"),
+			Entry("shared", "exclusive", "shared"),
+		)
+
+		It("should not change the mode when it is already applied", func() {
+			concretePlugin.DesireState = &sriovnetworkv1.SriovNetworkNodeState{
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{RdmaMode: "exclusive"}}
+			hostHelper.EXPECT().GetRdmaSubsystemMode().Return("exclusive", nil).Times(2)
+			Expect(concretePlugin.needRdmaSubsystemModeChange(concretePlugin.DesireState)).To(BeFalse())
+			Expect(concretePlugin.syncRdmaSubsystemMode()).To(Succeed())
+		})
+
+		It("should not manage the mode when it is not requested", func() {
+			concretePlugin.DesireState = &sriovnetworkv1.SriovNetworkNodeState{}
+			Expect(concretePlugin.needRdmaSubsystemModeChange(concretePlugin.DesireState)).To(BeFalse())
+			Expect(concretePlugin.syncRdmaSubsystemMode()).To(Succeed())
+		})
+	})
+
+	Context("required kernel modules", func() {
+		var concretePlugin *GenericPlugin
+		BeforeEach(func() {
+			concretePlugin = genericPlugin.(*GenericPlugin)
+			concretePlugin.DesireState = &sriovnetworkv1.SriovNetworkNodeState{
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
+					RequiredKernelModules: []string{"mlx5_core"},
+					Interfaces: sriovnetworkv1.Interfaces{{
+						PciAddress: "0000:00:00.0",
+						NumVfs:     1,
+						VfGroups:   []sriovnetworkv1.VfGroup{{DeviceType: "vfio-pci", VfRange: "0-0"}},
+					}},
+				}}
+			origValidate, origLoad := vars.ValidateKernelModules, vars.LoadMissingKernelModules
+			vars.ValidateKernelModules = true
+			DeferCleanup(func() {
+				vars.ValidateKernelModules, vars.LoadMissingKernelModules = origValidate, origLoad
+			})
+		})
+
+		It("should collect the modules of the policies and the VF drivers", func() {
+			Expect(concretePlugin.getRequiredKernelModules()).To(Equal([]string{"mlx5_core", "vfio_pci"}))
+		})
+
+		It("should not validate the modules when the validation is disabled", func() {
+			vars.ValidateKernelModules = false
+			Expect(concretePlugin.syncRequiredKernelModules()).To(Succeed())
+		})
+
+		It("should succeed when all the modules are loaded", func() {
+			hostHelper.EXPECT().ValidateRequiredModules([]string{"mlx5_core", "vfio_pci"}).Return(nil, nil)
+			Expect(concretePlugin.syncRequiredKernelModules()).To(Succeed())
+		})
+
+		It("should fail when a module is missing", func() {
+			hostHelper.EXPECT().ValidateRequiredModules([]string{"mlx5_core", "vfio_pci"}).Return([]string{"vfio_pci"}, nil)
+			Expect(concretePlugin.syncRequiredKernelModules()).To(MatchError("required kernel modules are not loaded: vfio_pci"))
+		})
+
+		It("should load the missing modules when requested", func() {
+			vars.LoadMissingKernelModules = true
+			hostHelper.EXPECT().ValidateRequiredModules([]string{"mlx5_core", "vfio_pci"}).Return([]string{"vfio_pci"}, nil)
+			hostHelper.EXPECT().LoadKernelModule("vfio_pci").Return(nil)
+			Expect(concretePlugin.syncRequiredKernelModules()).To(Succeed())
+		})
+	})
+
+	Context("adopt existing PFs", func() {
+		var concretePlugin *GenericPlugin
+		BeforeEach(func() {
+			concretePlugin = genericPlugin.(*GenericPlugin)
+			concretePlugin.DesireState = &sriovnetworkv1.SriovNetworkNodeState{
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
+					Interfaces: sriovnetworkv1.Interfaces{
+						{PciAddress: "0000:00:00.0", NumVfs: 4, AdoptExisting: true},
+						{PciAddress: "0000:00:00.1", NumVfs: 2, AdoptExisting: true, ExternallyManaged: true},
+						{PciAddress: "0000:00:00.2", NumVfs: 2, AdoptExisting: true},
+						{PciAddress: "0000:00:00.3", NumVfs: 2, AdoptExisting: true},
+						{PciAddress: "0000:00:00.4", NumVfs: 2, AdoptExisting: true},
+						{PciAddress: "0000:00:00.5", NumVfs: 2},
+					},
+				},
+				Status: sriovnetworkv1.SriovNetworkNodeStateStatus{
+					Interfaces: sriovnetworkv1.InterfaceExts{
+						{Name: "eno0", PciAddress: "0000:00:00.0", NumVfs: 4, Mtu: 1500, LinkType: "ETH"},
+						{Name: "eno1", PciAddress: "0000:00:00.1", NumVfs: 2},
+						{Name: "eno2", PciAddress: "0000:00:00.2", NumVfs: 2},
+						{Name: "eno3", PciAddress: "0000:00:00.3", NumVfs: 2},
+						{Name: "eno4", PciAddress: "0000:00:00.4"},
+						{Name: "eno5", PciAddress: "0000:00:00.5", NumVfs: 2},
+						{Name: "eno6", PciAddress: "0000:00:00.6", NumVfs: 2},
+					},
+				}}
+		})
+
+		It("should save a fresh PF status for the PFs selected by the policies requesting the adoption", func() {
+			hostHelper.EXPECT().LoadPfsStatus("0000:00:00.0").Return(nil, false, nil)
+			hostHelper.EXPECT().LoadPfsStatus("0000:00:00.2").Return(&sriovnetworkv1.Interface{ExternallyManaged: true}, true, nil)
+			hostHelper.EXPECT().LoadPfsStatus("0000:00:00.3").Return(&sriovnetworkv1.Interface{}, true, nil)
+			hostHelper.EXPECT().SaveLastPfAppliedStatus(&sriovnetworkv1.Interface{
+				Name: "eno0", PciAddress: "0000:00:00.0", NumVfs: 4, Mtu: 1500, LinkType: "ETH"}).Return(nil)
+			hostHelper.EXPECT().SaveLastPfAppliedStatus(&sriovnetworkv1.Interface{
+				Name: "eno2", PciAddress: "0000:00:00.2", NumVfs: 2}).Return(nil)
+			Expect(concretePlugin.adoptExistingPfs()).To(Succeed())
+		})
+
+		It("should not adopt the PFs by default", func() {
+			for i := range concretePlugin.DesireState.Spec.Interfaces {
+				concretePlugin.DesireState.Spec.Interfaces[i].AdoptExisting = false
+			}
+			Expect(concretePlugin.adoptExistingPfs()).To(Succeed())
+		})
+	})
+
+	Context("hugepage kernel args", func() {
+		var (
+			concretePlugin *GenericPlugin
+			setKargs       [][]string
+		)
+		BeforeEach(func() {
+			concretePlugin = genericPlugin.(*GenericPlugin)
+			setKargs = nil
+			origSetKernelArgsFunc := setKernelArgsFunc
+			setKernelArgsFunc = func(kargs ...string) (bool, error) {
+				setKargs = append(setKargs, kargs)
+				return true, nil
+			}
+			DeferCleanup(func() {
+				setKernelArgsFunc = origSetKernelArgsFunc
+			})
+		})
+
+		It("should set the missing args together and request a reboot", func() {
+			hostHelper.EXPECT().GetCurrentKernelArgs().Return("ro hugepagesz=2M hugepages=1024", nil)
+			hostHelper.EXPECT().HugepageKernelArgs("ro hugepagesz=2M hugepages=1024", "1G", 16).
+				Return([]string{"hugepagesz=1G", "hugepages=16"}, nil)
+			Expect(concretePlugin.EnsureHugepageArgs("1G", 16)).To(BeTrue())
+			Expect(setKargs).To(Equal([][]string{{"hugepagesz=1G", "hugepages=16"}}))
+		})
+
+		It("should not reboot when the args are set", func() {
+			hostHelper.EXPECT().GetCurrentKernelArgs().Return("hugepagesz=1G hugepages=16", nil)
+			hostHelper.EXPECT().HugepageKernelArgs("hugepagesz=1G hugepages=16", "1G", 16).Return([]string{}, nil)
+			Expect(concretePlugin.EnsureHugepageArgs("1G", 16)).To(BeFalse())
+			Expect(setKargs).To(BeEmpty())
+		})
+
+		It("should reboot the node to allocate the hugepages of the node state", func() {
+			hostHelper.EXPECT().GetCurrentKernelArgs().Return("ro", nil).Times(2)
+			hostHelper.EXPECT().HugepageKernelArgs("ro", "1G", 16).Return([]string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=16"}, nil)
+			hostHelper.EXPECT().HugepageKernelArgs("ro", "2M", 1024).Return([]string{"hugepagesz=2M", "hugepages=1024"}, nil)
+			needDrain, needReboot, err := genericPlugin.OnNodeStateChange(&sriovnetworkv1.SriovNetworkNodeState{
+				Spec: sriovnetworkv1.SriovNetworkNodeStateSpec{
+					Hugepages: []sriovnetworkv1.Hugepages{{Size: "1G", Count: 16}, {Size: "2M", Count: 1024}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(needReboot).To(BeTrue())
+			Expect(needDrain).To(BeTrue())
+			Expect(setKargs).To(Equal([][]string{{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=16"}, {"hugepagesz=2M", "hugepages=1024"}}))
+		})
+
+		It("should fail when the args conflict with the kernel cmd line", func() {
+			hostHelper.EXPECT().GetCurrentKernelArgs().Return("hugepagesz=1G hugepages=8", nil)
+			hostHelper.EXPECT().HugepageKernelArgs("hugepagesz=1G hugepages=8", "1G", 16).Return(nil, fmt.Errorf("test"))
+			_, err := concretePlugin.EnsureHugepageArgs("1G", 16)
+			Expect(err).To(MatchError("test"))
+			Expect(setKargs).To(BeEmpty())
+		})
+	})
+
+	Context("enable-kargs.sh", func() {
+		var grubArgsPath string
+
+		BeforeEach(func() {
+			testdata, err := filepath.Abs("testdata")
+			Expect(err).NotTo(HaveOccurred())
+			origScriptsPath := scriptsPath
+			scriptsPath = "../../../bindata/scripts/enable-kargs.sh"
+			DeferCleanup(func() {
+				scriptsPath = origScriptsPath
+			})
+			grubArgsPath = filepath.Join(GinkgoT().TempDir(), "grub-args")
+			GinkgoT().Setenv("PATH", testdata+":"+os.Getenv("PATH"))
+			GinkgoT().Setenv("FAKE_GRUB_ARGS", grubArgsPath)
+		})
+
+		configure := func(cmdLine, grubArgs string) {
+			GinkgoT().Setenv("FAKE_CMDLINE", cmdLine)
+			Expect(os.WriteFile(grubArgsPath, []byte(grubArgs), 0644)).To(Succeed())
+		}
+
+		grubArgs := func() string {
+			data, err := os.ReadFile(grubArgsPath)
+			Expect(err).NotTo(HaveOccurred())
+			return strings.TrimSpace(string(data))
+		}
+
+		It("should add the hugepages of another size after the existing ones", func() {
+			configure("BOOT_IMAGE=/vmlinuz ro default_hugepagesz=1G hugepagesz=2M hugepages=512",
+				"ro default_hugepagesz=1G hugepagesz=2M hugepages=512")
+			// the args returned by HugepageKernelArgs for 4 hugepages of 1G
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=4")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro default_hugepagesz=1G hugepagesz=2M hugepages=512 hugepagesz=1G hugepages=4"))
+		})
+
+		It("should add the hugepage count of a size even if the count is set for another size", func() {
+			configure("ro hugepagesz=2M hugepages=16", "ro hugepagesz=2M hugepages=16")
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=16")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro hugepagesz=2M hugepages=16 hugepagesz=1G hugepages=16"))
+		})
+
+		It("should replace the hugepage count of a size allocated with another count", func() {
+			configure("ro hugepagesz=1G hugepages=8 hugepagesz=2M hugepages=512",
+				"ro hugepagesz=1G hugepages=8 hugepagesz=2M hugepages=512")
+			// the args returned by HugepageKernelArgs to update the count of the hugepages of 1G
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=16")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro hugepagesz=2M hugepages=512 hugepagesz=1G hugepages=16"))
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=16")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro hugepagesz=2M hugepages=512 hugepagesz=1G hugepages=16"))
+		})
+
+		It("should only replace the args with a key that can't be repeated", func() {
+			configure("ro intel_iommu=off", "ro intel_iommu=off")
+			Expect(setKernelArgs("intel_iommu=on", "iommu=pt")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro intel_iommu=on iommu=pt"))
+		})
+
+		It("should not update the boot entry when the args are already set", func() {
+			configure("ro hugepagesz=1G hugepages=4", "ro hugepagesz=1G hugepages=4")
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=4")).To(BeFalse())
+			configure("ro", "ro hugepagesz=1G hugepages=4")
+			Expect(setKernelArgs("hugepagesz=1G", "hugepages=4")).To(BeTrue())
+			Expect(grubArgs()).To(Equal("ro hugepagesz=1G hugepages=4"))
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package intel
 
 import (
+	"context"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
@@ -46,7 +47,7 @@ func (p *IntelPlugin) CheckStatusChanges(*sriovnetworkv1.SriovNetworkNodeState)
 }
 
 // Apply config change
-func (p *IntelPlugin) Apply() error {
+func (p *IntelPlugin) Apply(ctx context.Context) error {
 	log.Log.Info("intel plugin Apply()")
 	return nil
 }
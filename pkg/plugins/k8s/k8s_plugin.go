@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -161,7 +162,7 @@ func (p *K8sPlugin) CheckStatusChanges(*sriovnetworkv1.SriovNetworkNodeState) (b
 }
 
 // Apply config change
-func (p *K8sPlugin) Apply() error {
+func (p *K8sPlugin) Apply(ctx context.Context) error {
 	log.Log.Info("k8s plugin Apply()")
 	if vars.UsingSystemdMode {
 		if err := p.updateSriovServices(); err != nil {
@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -107,7 +108,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeFalse())
 		Expect(needDrain).To(BeFalse())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 
 	It("systemd, created", func() {
@@ -122,7 +123,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeTrue())
 		Expect(needDrain).To(BeTrue())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 	It("systemd, already configured", func() {
 		setIsSystemdMode(true)
@@ -146,7 +147,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeFalse())
 		Expect(needDrain).To(BeFalse())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 	It("systemd, update required", func() {
 		setIsSystemdMode(true)
@@ -171,7 +172,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeTrue())
 		Expect(needDrain).To(BeTrue())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 	It("ovs service not found", func() {
 		setIsSystemdMode(false)
@@ -181,7 +182,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeFalse())
 		Expect(needDrain).To(BeFalse())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 	It("ovs service updated", func() {
 		setIsSystemdMode(false)
@@ -199,7 +200,7 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeTrue())
 		Expect(needDrain).To(BeTrue())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 	It("ovs service updated - hw offloading already enabled", func() {
 		setIsSystemdMode(false)
@@ -218,6 +219,6 @@ var _ = Describe("K8s plugin", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(needReboot).To(BeFalse())
 		Expect(needDrain).To(BeFalse())
-		Expect(k8sPlugin.Apply()).NotTo(HaveOccurred())
+		Expect(k8sPlugin.Apply(context.Background())).NotTo(HaveOccurred())
 	})
 })
@@ -1,6 +1,7 @@
 package mellanox
 
 import (
+	"context"
 	"fmt"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -178,7 +179,7 @@ func (p *MellanoxPlugin) CheckStatusChanges(*sriovnetworkv1.SriovNetworkNodeStat
 }
 
 // Apply config change
-func (p *MellanoxPlugin) Apply() error {
+func (p *MellanoxPlugin) Apply(ctx context.Context) error {
 	if p.helpers.IsKernelLockdownMode() {
 		log.Log.Info("mellanox plugin Apply() - skipping due to lockdown mode")
 		return nil
@@ -5,6 +5,7 @@
 package mock_plugin
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,17 +36,17 @@ func (m *MockVendorPlugin) EXPECT() *MockVendorPluginMockRecorder {
 }
 
 // Apply mocks base method.
-func (m *MockVendorPlugin) Apply() error {
+func (m *MockVendorPlugin) Apply(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Apply")
+	ret := m.ctrl.Call(m, "Apply", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Apply indicates an expected call of Apply.
-func (mr *MockVendorPluginMockRecorder) Apply() *gomock.Call {
+func (mr *MockVendorPluginMockRecorder) Apply(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockVendorPlugin)(nil).Apply))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockVendorPlugin)(nil).Apply), ctx)
 }
 
 // CheckStatusChanges mocks base method.
@@ -1,6 +1,8 @@
 package plugin
 
 import (
+	"context"
+
 	sriovnetworkv1 "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
 )
 
@@ -12,8 +14,8 @@ type VendorPlugin interface {
 	Spec() string
 	// OnNodeStateChange is invoked when SriovNetworkNodeState CR is created or updated, return if need dain and/or reboot node
 	OnNodeStateChange(*sriovnetworkv1.SriovNetworkNodeState) (bool, bool, error)
-	// Apply config change
-	Apply() error
+	// Apply config change, the changes in progress are interrupted when the context is done
+	Apply(ctx context.Context) error
 	// CheckStatusChanges checks status changes on the SriovNetworkNodeState CR for configured VFs.
 	CheckStatusChanges(*sriovnetworkv1.SriovNetworkNodeState) (bool, error)
 }
@@ -1,6 +1,7 @@
 package virtual
 
 import (
+	"context"
 	"reflect"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -73,7 +74,7 @@ func (p *VirtualPlugin) CheckStatusChanges(*sriovnetworkv1.SriovNetworkNodeState
 }
 
 // Apply config change
-func (p *VirtualPlugin) Apply() error {
+func (p *VirtualPlugin) Apply(ctx context.Context) error {
 	log.Log.Info("virtual plugin Apply()", "desired-state", p.DesireState.Spec)
 
 	if p.LoadVfioDriver == loading {
@@ -5,6 +5,7 @@
 package mock_utils
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -68,3 +69,44 @@ func (mr *MockCmdInterfaceMockRecorder) RunCommand(arg0 interface{}, arg1 ...int
 	varargs := append([]interface{}{arg0}, arg1...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommand", reflect.TypeOf((*MockCmdInterface)(nil).RunCommand), varargs...)
 }
+
+// RunCommandCombined mocks base method.
+func (m *MockCmdInterface) RunCommandCombined(arg0 string, arg1 ...string) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunCommandCombined", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunCommandCombined indicates an expected call of RunCommandCombined.
+func (mr *MockCmdInterfaceMockRecorder) RunCommandCombined(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommandCombined", reflect.TypeOf((*MockCmdInterface)(nil).RunCommandCombined), varargs...)
+}
+
+// RunCommandContext mocks base method.
+func (m *MockCmdInterface) RunCommandContext(arg0 context.Context, arg1 string, arg2 ...string) (string, string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunCommandContext", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RunCommandContext indicates an expected call of RunCommandContext.
+func (mr *MockCmdInterfaceMockRecorder) RunCommandContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCommandContext", reflect.TypeOf((*MockCmdInterface)(nil).RunCommandContext), varargs...)
+}
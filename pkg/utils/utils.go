@@ -2,12 +2,18 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -20,6 +26,8 @@ import (
 type CmdInterface interface {
 	Chroot(string) (func() error, error)
 	RunCommand(string, ...string) (string, string, error)
+	RunCommandContext(context.Context, string, ...string) (string, string, error)
+	RunCommandCombined(string, ...string) (string, error)
 }
 
 type utilsHelper struct {
@@ -29,7 +37,27 @@ func New() CmdInterface {
 	return &utilsHelper{}
 }
 
+var (
+	// chrootLock serializes the changes of the process-wide root directory
+	chrootLock sync.Mutex
+	// chrootDepth is the number of Chroot calls that were not exited yet
+	chrootDepth int
+)
+
+// Chroot changes the root directory of the process to path and returns a function restoring the previous one.
+// Chroot calls can be nested as long as the returned functions are called in the reverse order,
+// calling a returned function more than once has no effect.
 func (u *utilsHelper) Chroot(path string) (func() error, error) {
+	chrootLock.Lock()
+	defer chrootLock.Unlock()
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to chroot to %s: path doesn't exist", path)
+		}
+		return nil, fmt.Errorf("failed to chroot to %s: %w", path, err)
+	}
+
 	root, err := os.Open("/")
 	if err != nil {
 		return nil, err
@@ -37,34 +65,79 @@ func (u *utilsHelper) Chroot(path string) (func() error, error) {
 
 	if err := syscall.Chroot(path); err != nil {
 		root.Close()
-		return nil, err
+		return nil, fmt.Errorf("failed to chroot to %s: %w", path, err)
 	}
+	chrootDepth++
 	vars.InChroot = true
 
+	exited := false
 	return func() error {
+		chrootLock.Lock()
+		defer chrootLock.Unlock()
+		if exited {
+			return nil
+		}
+		exited = true
+
 		defer root.Close()
 		if err := root.Chdir(); err != nil {
 			return err
 		}
-		vars.InChroot = false
+		chrootDepth--
+		vars.InChroot = chrootDepth > 0
 		return syscall.Chroot(".")
 	}, nil
 }
 
 // RunCommand runs a command
 func (u *utilsHelper) RunCommand(command string, args ...string) (string, string, error) {
+	return u.RunCommandContext(context.Background(), command, args...)
+}
+
+// RunCommandContext runs a command, the command is killed when the context is done
+// before it completes
+func (u *utilsHelper) RunCommandContext(ctx context.Context, command string, args ...string) (string, string, error) {
 	log.Log.Info("RunCommand()", "command", command, "args", args)
 	var stdout, stderr bytes.Buffer
 
-	cmd := exec.Command(command, args...)
+	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err := wrapCommandError(ctx, command, cmd.Run(), stderr.String())
 	log.Log.V(2).Info("RunCommand()", "output", stdout.String(), "error", err)
 	return stdout.String(), stderr.String(), err
 }
 
+// RunCommandCombined runs a command and returns its stdout and stderr interleaved,
+// for the tools that report their progress on stderr
+func (u *utilsHelper) RunCommandCombined(command string, args ...string) (string, error) {
+	log.Log.Info("RunCommandCombined()", "command", command, "args", args)
+	output, err := exec.Command(command, args...).CombinedOutput()
+	err = wrapCommandError(context.Background(), command, err, "")
+	log.Log.V(2).Info("RunCommandCombined()", "output", string(output), "error", err)
+	return string(output), err
+}
+
+// wrapCommandError adds to the error of a failed command whether it timed out or
+// its exit code along with the stderr of the command
+func wrapCommandError(ctx context.Context, command string, err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("command %s timed out or was canceled: %w", command, ctx.Err())
+	}
+	exitErr := &exec.ExitError{}
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Errorf("command %s exited with code %d: %s: %w", command, exitErr.ExitCode(), stderr, err)
+	}
+	return fmt.Errorf("command %s exited with code %d: %w", command, exitErr.ExitCode(), err)
+}
+
 func GenerateRandomGUID() net.HardwareAddr {
 	guid := make(net.HardwareAddr, 8)
 
@@ -78,8 +151,24 @@ func GenerateRandomGUID() net.HardwareAddr {
 	return guid
 }
 
+// GenerateDeterministicGUID generates a GUID derived from the node name, the PF PCI address and the VF index,
+// the same GUID is returned for the same VF across reboots without the need to persist it, the node name
+// keeps the nodes with the same PCI layout from generating the same GUIDs on a shared fabric
+func GenerateDeterministicGUID(nodeName, pfAddr string, vfID int) net.HardwareAddr {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%s/%s/%d", nodeName, pfAddr, vfID)))
+	guid := make(net.HardwareAddr, 8)
+	binary.BigEndian.PutUint64(guid, h.Sum64())
+
+	// First field is 0x01 - xfe to avoid all zero and all F invalid guids
+	guid[0] = byte(1 + int(guid[0])%0xfe)
+
+	return guid
+}
+
 func IsCommandNotFound(err error) bool {
-	if exitErr, ok := err.(*exec.ExitError); ok {
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) {
 		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == 127 {
 			return true
 		}
@@ -1,13 +1,141 @@
 package utils_test
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils"
+	"github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/vars"
 )
 
 func TestUtils(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Utils Suite")
 }
+
+var _ = Describe("RunCommandContext", func() {
+	It("should return the output of the command", func() {
+		stdout, _, err := utils.New().RunCommandContext(context.Background(), "echo", "test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stdout).To(Equal("test\n"))
+	})
+
+	It("should report the exit code of the command", func() {
+		_, _, err := utils.New().RunCommandContext(context.Background(), "/bin/sh", "-c", "exit 3")
+		Expect(err).To(MatchError(ContainSubstring("exited with code 3")))
+		Expect(utils.IsCommandNotFound(err)).To(BeFalse())
+	})
+
+	It("should report the stderr of the command", func() {
+		stdout, stderr, err := utils.New().RunCommandContext(context.Background(), "/bin/sh", "-c", "echo out; echo failed >&2; exit 1")
+		Expect(stdout).To(Equal("out\n"))
+		Expect(stderr).To(Equal("failed\n"))
+		Expect(err).To(MatchError(ContainSubstring("exited with code 1: failed:")))
+	})
+
+	It("should return the combined output of the command", func() {
+		output, err := utils.New().RunCommandCombined("/bin/sh", "-c", "echo out; echo failed >&2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("out\nfailed\n"))
+	})
+
+	It("should detect a command that is not found", func() {
+		_, _, err := utils.New().RunCommand("/bin/sh", "-c", "exit 127")
+		Expect(utils.IsCommandNotFound(err)).To(BeTrue())
+	})
+
+	It("should report a timeout", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, _, err := utils.New().RunCommandContext(ctx, "sleep", "5")
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(err).To(MatchError(ContainSubstring("timed out")))
+	})
+})
+
+var _ = Describe("GenerateDeterministicGUID", func() {
+	It("should return the same GUID for the same VF", func() {
+		guid := utils.GenerateDeterministicGUID("worker-0", "0000:d8:00.0", 1)
+		Expect(guid).To(HaveLen(8))
+		Expect(guid[0]).NotTo(BeElementOf(byte(0x00), byte(0xff)))
+		Expect(utils.GenerateDeterministicGUID("worker-0", "0000:d8:00.0", 1)).To(Equal(guid))
+	})
+
+	It("should return different GUIDs for the same VF on different nodes", func() {
+		Expect(utils.GenerateDeterministicGUID("worker-0", "0000:d8:00.0", 1)).
+			NotTo(Equal(utils.GenerateDeterministicGUID("worker-1", "0000:d8:00.0", 1)))
+	})
+})
+
+var _ = Describe("Chroot", func() {
+	var (
+		tmpDir string
+		u      utils.CmdInterface
+	)
+
+	BeforeEach(func() {
+		if os.Geteuid() != 0 {
+			Skip("chroot requires root privileges")
+		}
+		u = utils.New()
+		tmpDir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(tmpDir, "nested"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(tmpDir, "outer"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(tmpDir, "nested", "inner"), []byte{}, 0644)).To(Succeed())
+	})
+
+	It("should restore the root after sequential chroots", func() {
+		for i := 0; i < 2; i++ {
+			exit, err := u.Chroot(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars.InChroot).To(BeTrue())
+			Expect("/outer").To(BeAnExistingFile())
+			Expect(exit()).To(Succeed())
+			Expect(vars.InChroot).To(BeFalse())
+			Expect(filepath.Join(tmpDir, "outer")).To(BeAnExistingFile())
+		}
+	})
+
+	It("should restore the roots of nested chroots", func() {
+		exitOuter, err := u.Chroot(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+		exitInner, err := u.Chroot("/nested")
+		Expect(err).NotTo(HaveOccurred())
+		Expect("/inner").To(BeAnExistingFile())
+
+		Expect(exitInner()).To(Succeed())
+		Expect(vars.InChroot).To(BeTrue())
+		Expect("/outer").To(BeAnExistingFile())
+
+		Expect(exitOuter()).To(Succeed())
+		Expect(vars.InChroot).To(BeFalse())
+		Expect(filepath.Join(tmpDir, "outer")).To(BeAnExistingFile())
+	})
+
+	It("should ignore the exit function called twice", func() {
+		exitOuter, err := u.Chroot(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+		exitInner, err := u.Chroot("/nested")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(exitInner()).To(Succeed())
+		Expect(exitInner()).To(Succeed())
+		Expect("/outer").To(BeAnExistingFile())
+		Expect(vars.InChroot).To(BeTrue())
+
+		Expect(exitOuter()).To(Succeed())
+		Expect(filepath.Join(tmpDir, "outer")).To(BeAnExistingFile())
+	})
+
+	It("should return an error if the path doesn't exist", func() {
+		_, err := u.Chroot(filepath.Join(tmpDir, "missing"))
+		Expect(err).To(MatchError(ContainSubstring("path doesn't exist")))
+		Expect(vars.InChroot).To(BeFalse())
+	})
+})
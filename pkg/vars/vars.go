@@ -3,6 +3,8 @@ package vars
 import (
 	"os"
 	"regexp"
+	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -35,9 +37,11 @@ var (
 		"openstack": consts.VirtualOpenStack,
 	}
 
-	// SupportedVfIds list of supported virtual functions IDs
-	// loaded on daemon initialization by reading the supported-nics configmap
-	SupportedVfIds []string
+	// supportedVfIds list of supported virtual functions IDs
+	// loaded on daemon initialization by reading the supported-nics configmap,
+	// must be accessed with GetSupportedVfIds and SetSupportedVfIds
+	supportedVfIds     []string
+	supportedVfIdsLock sync.RWMutex
 
 	// DpdkDrivers supported DPDK drivers for virtual functions
 	DpdkDrivers = []string{"igb_uio", "vfio-pci", "uio_pci_generic"}
@@ -51,6 +55,86 @@ var (
 	// ParallelNicConfig global variable to perform NIC configuration in parallel
 	ParallelNicConfig = false
 
+	// NicConfigConcurrency global variable with the number of NICs configured in parallel when ParallelNicConfig is set
+	NicConfigConcurrency = 4
+
+	// VfConfigConcurrency global variable with the number of VFs of a PF configured in parallel
+	VfConfigConcurrency = 8
+
+	// VfBindStaggerDelay global variable with the delay between the default driver binds of the VFs of a PF.
+	// When greater than zero the VFs are configured one at a time in index order, including the binds of the VFs
+	// rebound during their configuration
+	VfBindStaggerDelay time.Duration
+
+	// NetdevMTURetryInterval global variable with the interval between two attempts to set the MTU
+	// of a network device that is not available yet, e.g. a VF netdev that is still being created
+	NetdevMTURetryInterval = 1 * time.Second
+
+	// NetdevMTURetryCount global variable with the number of retries done to set the MTU of a network device
+	NetdevMTURetryCount = 10
+
+	// DevlinkRetryInterval global variable with the interval between two attempts to get the devlink device
+	// of a PF while the devlink device is busy, e.g. while its driver reloads
+	DevlinkRetryInterval = 200 * time.Millisecond
+
+	// DevlinkRetryCount global variable with the number of retries done to get a busy devlink device
+	DevlinkRetryCount = 5
+
+	// VfReadyPollInterval global variable with the interval between two checks of the netdev of a VF bound to its
+	// default driver
+	VfReadyPollInterval = 1 * time.Second
+
+	// VfReadyPollTimeout global variable with the time to wait for the netdev of a VF bound to its default driver
+	// to appear before the VF is rebound
+	VfReadyPollTimeout = 10 * time.Second
+
+	// ConfigRetryCount global variable with the number of times a full pass of the SR-IOV interfaces
+	// configuration is retried after a transient failure, disabled when 0
+	ConfigRetryCount = 0
+
+	// ConfigRetryInterval global variable with the initial interval between two passes of the SR-IOV
+	// interfaces configuration, the interval increases exponentially with the retries
+	ConfigRetryInterval = 1 * time.Second
+
+	// DeviceLockTimeout global variable with the time to wait for the lock of a PF held by another actor configuring
+	// it, e.g. the systemd service during an upgrade, before its configuration or reset fails
+	DeviceLockTimeout = 2 * time.Minute
+
+	// VerifyConfig global variable to read back the live state of the configured PFs at the end of a configuration
+	// pass, a mismatch fails the pass
+	VerifyConfig = false
+
+	// VerboseDiscovery global variable to log the PCI devices excluded from the SR-IOV discovery with the reason why
+	VerboseDiscovery = false
+
+	// AllowVfioNoIommu global variable to enable the unsafe no-IOMMU mode of vfio when a device
+	// can't be bound to vfio-pci because IOMMU is not enabled on the host
+	AllowVfioNoIommu = false
+
+	// HostSystemInterfacesAllowList global variable with the names or PCI addresses of the PFs
+	// carrying the default route of the host that are not excluded from the discovery
+	HostSystemInterfacesAllowList []string
+
+	// DiscoveryAllowList global variable with the vendor:<id>, device:<id> or pci:<address glob> entries
+	// the PCI devices must match to be discovered, all the devices are discovered when empty
+	DiscoveryAllowList []string
+
+	// DiscoveryDenyList global variable with the vendor:<id>, device:<id> or pci:<address glob> entries
+	// of the PCI devices which are never discovered, applied after the allow list
+	DiscoveryDenyList []string
+
+	// IgnoreExternallyManagedMismatch global variable to configure a PF even if the requested ExternallyManaged
+	// flag doesn't match the one the PF was configured with
+	IgnoreExternallyManagedMismatch = false
+
+	// ValidateKernelModules global variable to check the kernel modules required by the policies
+	// are loaded before applying the configuration
+	ValidateKernelModules = false
+
+	// LoadMissingKernelModules global variable to try to load the missing required kernel modules
+	// with modprobe instead of failing the configuration
+	LoadMissingKernelModules = false
+
 	// FilesystemRoot used by test to mock interactions with filesystem
 	FilesystemRoot = ""
 
@@ -58,8 +142,11 @@ var (
 	Config *rest.Config    = nil
 	Scheme *runtime.Scheme = nil
 
-	// PfPhysPortNameRe regex to find switchdev devices on the host
-	PfPhysPortNameRe = regexp.MustCompile(`p\d+`)
+	// PfPhysPortNameRe regex to find the uplink netdev of switchdev devices on the host, e.g. p0
+	PfPhysPortNameRe = regexp.MustCompile(`^p\d+$`)
+
+	// PfRepPhysPortNameRe regex to find the PF representor netdev of switchdev devices, e.g. pf0 or c1pf0
+	PfRepPhysPortNameRe = regexp.MustCompile(`^(c\d+)?pf\d+$`)
 
 	// ResourcePrefix is the device plugin prefix we use to expose the devices to the nodes
 	ResourcePrefix = ""
@@ -68,6 +155,20 @@ var (
 	DisableablePlugins = map[string]struct{}{"mellanox": {}}
 )
 
+// GetSupportedVfIds returns a copy of the list of supported virtual functions IDs
+func GetSupportedVfIds() []string {
+	supportedVfIdsLock.RLock()
+	defer supportedVfIdsLock.RUnlock()
+	return append([]string(nil), supportedVfIds...)
+}
+
+// SetSupportedVfIds replaces the list of supported virtual functions IDs
+func SetSupportedVfIds(ids []string) {
+	supportedVfIdsLock.Lock()
+	defer supportedVfIdsLock.Unlock()
+	supportedVfIds = append([]string(nil), ids...)
+}
+
 func init() {
 	Namespace = os.Getenv("NAMESPACE")
 
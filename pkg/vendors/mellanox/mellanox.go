@@ -33,6 +33,13 @@ const (
 	disabled = "DISABLED"
 	enabled  = "ENABLED"
 
+	// BlueField modes accepted by SetMellanoxBlueFieldMode
+	BluefieldModeDpu = "dpu"
+	BluefieldModeNic = "nic"
+
+	deviceTypeBF2 = "BlueField2"
+	deviceTypeBF3 = "BlueField3"
+
 	VendorMellanox = "15b3"
 	DeviceBF2      = "a2d6"
 	DeviceBF3      = "a2dc"
@@ -46,6 +53,17 @@ const (
 	MellanoxVendorID      = "15b3"
 )
 
+// String returns the name of the mode accepted by SetMellanoxBlueFieldMode
+func (m BlueFieldMode) String() string {
+	switch m {
+	case BluefieldDpu:
+		return BluefieldModeDpu
+	case BluefieldConnectXMode:
+		return BluefieldModeNic
+	}
+	return ""
+}
+
 type MlxNic struct {
 	EnableSriov bool
 	TotalVfs    int
@@ -57,6 +75,7 @@ type MlxNic struct {
 type MellanoxInterface interface {
 	MstConfigReadData(string) (string, string, error)
 	GetMellanoxBlueFieldMode(string) (BlueFieldMode, error)
+	SetMellanoxBlueFieldMode(pciAddr, mode string) (bool, error)
 	GetMlxNicFwData(pciAddress string) (current, next *MlxNic, err error)
 
 	MlxConfigFW(attributesToChange map[string]MlxNic) error
@@ -141,6 +160,74 @@ func (m *mellanoxHelper) GetMellanoxBlueFieldMode(PciAddress string) (BlueFieldM
 	return -1, fmt.Errorf("MellanoxBlueFieldMode(): unknown device status for %s", PciAddress)
 }
 
+// SetMellanoxBlueFieldMode switches the BlueField-2/3 device to the DPU (embedded) or NIC (separated) mode.
+// Returns true if the firmware configuration was changed, the host must be power-cycled or the firmware reset
+// for the new mode to be applied.
+func (m *mellanoxHelper) SetMellanoxBlueFieldMode(pciAddr, mode string) (bool, error) {
+	log.Log.V(2).Info("SetMellanoxBlueFieldMode(): set mode for device", "device", pciAddr, "mode", mode)
+	var requested BlueFieldMode
+	attrs := map[string]string{internalCPUModel: embeddedCPU}
+	switch mode {
+	case BluefieldModeDpu:
+		requested = BluefieldDpu
+		attrs[internalCPUPageSupplier] = ecpf
+		attrs[internalCPUEswitchManager] = ecpf
+		attrs[internalCPUIbVporto] = ecpf
+		attrs[internalCPUOffloadEngine] = enabled
+	case BluefieldModeNic:
+		requested = BluefieldConnectXMode
+		attrs[internalCPUPageSupplier] = extHostPf
+		attrs[internalCPUEswitchManager] = extHostPf
+		attrs[internalCPUIbVporto] = extHostPf
+		attrs[internalCPUOffloadEngine] = disabled
+	default:
+		return false, fmt.Errorf("invalid BlueField mode %q, expecting %q or %q", mode, BluefieldModeDpu, BluefieldModeNic)
+	}
+
+	stdout, stderr, err := m.MstConfigReadData(pciAddr)
+	if err != nil {
+		log.Log.Error(err, "SetMellanoxBlueFieldMode(): failed to get mlx nic fw data", "stderr", stderr)
+		return false, fmt.Errorf("failed to get mlx nic fw data %w", err)
+	}
+	deviceType := getMstconfigDeviceType(stdout)
+	if deviceType != deviceTypeBF2 && deviceType != deviceTypeBF3 {
+		return false, fmt.Errorf("device %s of type %q is not a BlueField-2 or BlueField-3", pciAddr, deviceType)
+	}
+
+	current, err := m.GetMellanoxBlueFieldMode(pciAddr)
+	if err != nil {
+		return false, fmt.Errorf("failed to validate the current mode of device %s: %w", pciAddr, err)
+	}
+	if current == requested {
+		log.Log.V(2).Info("SetMellanoxBlueFieldMode(): device already in the requested mode", "device", pciAddr, "mode", mode)
+		return false, nil
+	}
+
+	cmdArgs := []string{"-d", pciAddr, "-y", "set"}
+	for _, attr := range []string{internalCPUModel, internalCPUPageSupplier, internalCPUEswitchManager,
+		internalCPUIbVporto, internalCPUOffloadEngine} {
+		cmdArgs = append(cmdArgs, fmt.Sprintf("%s=%s", attr, attrs[attr]))
+	}
+	log.Log.Info("SetMellanoxBlueFieldMode(): switch device mode", "device", pciAddr, "mode", mode, "cmd-args", cmdArgs)
+	_, stderr, err = m.utils.RunCommand("mstconfig", cmdArgs...)
+	if err != nil {
+		log.Log.Error(err, "SetMellanoxBlueFieldMode(): failed to switch device mode", "stderr", stderr)
+		return false, err
+	}
+	return true, nil
+}
+
+// getMstconfigDeviceType returns the device type reported by the mstconfig query, e.g. BlueField2
+func getMstconfigDeviceType(mstOutput string) string {
+	for _, line := range strings.Split(mstOutput, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(key) == "Device type" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 func (m *mellanoxHelper) MlxConfigFW(attributesToChange map[string]MlxNic) error {
 	log.Log.Info("mellanox-plugin configFW()")
 	for pciAddr, fwArgs := range attributesToChange {
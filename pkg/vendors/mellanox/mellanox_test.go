@@ -0,0 +1,95 @@
+package mlxutils
+
+import (
+	"fmt"
+
+	"github.com/golang/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	utilsMockPkg "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/utils/mock"
+)
+
+func getTestMstconfigOutput(deviceType, model, supplier, offload string) string {
+	return fmt.Sprintf(`
+Device #1:
+----------
+
+Device type:        %s
+Name:               MBF2H332A-AEEO_Ax_Bx
+Device:             0000:3b:00.0
+
+Configurations:                                          Default             Current             Next Boot
+        INTERNAL_CPU_MODEL                               EMBEDDED_CPU(1)     %s                  %s
+        INTERNAL_CPU_PAGE_SUPPLIER                       ECPF(0)             %s                  %s
+        INTERNAL_CPU_ESWITCH_MANAGER                     ECPF(0)             %s                  %s
+        INTERNAL_CPU_IB_VPORT0                           ECPF(0)             %s                  %s
+        INTERNAL_CPU_OFFLOAD_ENGINE                      ENABLED(0)          %s                  %s
+`, deviceType, model, model, supplier, supplier, supplier, supplier, supplier, supplier, offload, offload)
+}
+
+var _ = Describe("Mellanox", func() {
+	var (
+		m         MellanoxInterface
+		utilsMock *utilsMockPkg.MockCmdInterface
+		testCtrl  *gomock.Controller
+	)
+
+	BeforeEach(func() {
+		testCtrl = gomock.NewController(GinkgoT())
+		utilsMock = utilsMockPkg.NewMockCmdInterface(testCtrl)
+		m = New(utilsMock)
+	})
+
+	AfterEach(func() {
+		testCtrl.Finish()
+	})
+
+	Context("SetMellanoxBlueFieldMode", func() {
+		expectQuery := func(output string) {
+			utilsMock.EXPECT().RunCommand("mstconfig", "-e", "-d", "0000:3b:00.0", "q").Return(output, "", nil).Times(2)
+		}
+
+		It("should switch a BlueField-2 in DPU mode to NIC mode", func() {
+			expectQuery(getTestMstconfigOutput("BlueField2", "EMBEDDED_CPU(1)", "ECPF(0)", "ENABLED(0)"))
+			utilsMock.EXPECT().RunCommand("mstconfig", "-d", "0000:3b:00.0", "-y", "set",
+				"INTERNAL_CPU_MODEL=EMBEDDED_CPU", "INTERNAL_CPU_PAGE_SUPPLIER=EXT_HOST_PF",
+				"INTERNAL_CPU_ESWITCH_MANAGER=EXT_HOST_PF", "INTERNAL_CPU_IB_VPORT0=EXT_HOST_PF",
+				"INTERNAL_CPU_OFFLOAD_ENGINE=DISABLED").Return("", "", nil)
+			Expect(m.SetMellanoxBlueFieldMode("0000:3b:00.0", BluefieldModeNic)).To(BeTrue())
+		})
+
+		It("should switch a BlueField-3 in NIC mode to DPU mode", func() {
+			expectQuery(getTestMstconfigOutput("BlueField3", "EMBEDDED_CPU(1)", "EXT_HOST_PF(1)", "DISABLED(1)"))
+			utilsMock.EXPECT().RunCommand("mstconfig", "-d", "0000:3b:00.0", "-y", "set",
+				"INTERNAL_CPU_MODEL=EMBEDDED_CPU", "INTERNAL_CPU_PAGE_SUPPLIER=ECPF",
+				"INTERNAL_CPU_ESWITCH_MANAGER=ECPF", "INTERNAL_CPU_IB_VPORT0=ECPF",
+				"INTERNAL_CPU_OFFLOAD_ENGINE=ENABLED").Return("", "", nil)
+			Expect(m.SetMellanoxBlueFieldMode("0000:3b:00.0", BluefieldModeDpu)).To(BeTrue())
+		})
+
+		It("should not change a device already in the requested mode", func() {
+			expectQuery(getTestMstconfigOutput("BlueField2", "EMBEDDED_CPU(1)", "EXT_HOST_PF(1)", "DISABLED(1)"))
+			Expect(m.SetMellanoxBlueFieldMode("0000:3b:00.0", BluefieldModeNic)).To(BeFalse())
+		})
+
+		It("should refuse a device which is not a BlueField", func() {
+			utilsMock.EXPECT().RunCommand("mstconfig", "-e", "-d", "0000:3b:00.0", "q").
+				Return(getTestMstconfigOutput("ConnectX6DX", "EMBEDDED_CPU(1)", "ECPF(0)", "ENABLED(0)"), "", nil)
+			_, err := m.SetMellanoxBlueFieldMode("0000:3b:00.0", BluefieldModeNic)
+			Expect(err).To(MatchError(`device 0000:3b:00.0 of type "ConnectX6DX" is not a BlueField-2 or BlueField-3`))
+		})
+
+		It("should refuse a device in an unknown mode", func() {
+			expectQuery(getTestMstconfigOutput("BlueField2", "EMBEDDED_CPU(1)", "EXT_HOST_PF(1)", "ENABLED(0)"))
+			_, err := m.SetMellanoxBlueFieldMode("0000:3b:00.0", BluefieldModeDpu)
+			Expect(err).To(MatchError(ContainSubstring("failed to validate the current mode of device 0000:3b:00.0")))
+		})
+
+		It("should refuse an invalid mode", func() {
+			_, err := m.SetMellanoxBlueFieldMode("0000:3b:00.0", "host")
+			Expect(err).To(MatchError(`invalid BlueField mode "host", expecting "dpu" or "nic"`))
+		})
+	})
+})
@@ -94,3 +94,18 @@ func (mr *MockMellanoxInterfaceMockRecorder) MstConfigReadData(arg0 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MstConfigReadData", reflect.TypeOf((*MockMellanoxInterface)(nil).MstConfigReadData), arg0)
 }
+
+// SetMellanoxBlueFieldMode mocks base method.
+func (m *MockMellanoxInterface) SetMellanoxBlueFieldMode(pciAddr, mode string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMellanoxBlueFieldMode", pciAddr, mode)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMellanoxBlueFieldMode indicates an expected call of SetMellanoxBlueFieldMode.
+func (mr *MockMellanoxInterfaceMockRecorder) SetMellanoxBlueFieldMode(pciAddr, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMellanoxBlueFieldMode", reflect.TypeOf((*MockMellanoxInterface)(nil).SetMellanoxBlueFieldMode), pciAddr, mode)
+}
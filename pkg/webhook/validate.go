@@ -3,12 +3,14 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 
 	v1 "k8s.io/api/admission/v1"
+	admregv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -47,6 +49,22 @@ func validateSriovOperatorConfig(cr *sriovnetworkv1.SriovOperatorConfig, operati
 		warnings = append(warnings, "Node draining is disabled for applying SriovNetworkNodePolicy, it may result in workload interruption.")
 	}
 
+	if cr.Spec.WebhookFailurePolicy != "" && cr.Spec.WebhookFailurePolicy != string(admregv1.Fail) &&
+		cr.Spec.WebhookFailurePolicy != string(admregv1.Ignore) {
+		return false, warnings, fmt.Errorf("invalid webhookFailurePolicy %q, allowed values are %q and %q",
+			cr.Spec.WebhookFailurePolicy, admregv1.Fail, admregv1.Ignore)
+	}
+
+	if err := validateSriovOperatorConfigPlugins(cr); err != nil {
+		return false, warnings, err
+	}
+
+	for _, driver := range cr.Spec.DpdkDriverAllowlist {
+		if !sriovnetworkv1.StringInArray(driver, vars.DpdkDrivers) {
+			return false, warnings, fmt.Errorf("invalid driver %q in dpdkDriverAllowlist, supported DPDK drivers are %v", driver, vars.DpdkDrivers)
+		}
+	}
+
 	err := validateSriovOperatorConfigDisableDrain(cr)
 	if err != nil {
 		return false, warnings, err
@@ -55,6 +73,29 @@ func validateSriovOperatorConfig(cr *sriovnetworkv1.SriovOperatorConfig, operati
 	return true, warnings, nil
 }
 
+// validateSriovOperatorConfigPlugins checks that the disabled plugins can be disabled and that the plugin order
+// lists each plugin once and doesn't include disabled plugins
+func validateSriovOperatorConfigPlugins(cr *sriovnetworkv1.SriovOperatorConfig) error {
+	for _, p := range cr.Spec.DisablePlugins {
+		if _, ok := vars.DisableablePlugins[string(p)]; !ok {
+			return fmt.Errorf("plugin %s cannot be disabled", p)
+		}
+	}
+	listed := map[sriovnetworkv1.PluginOrderValue]struct{}{}
+	for _, p := range cr.Spec.PluginOrder {
+		if _, ok := listed[p]; ok {
+			return fmt.Errorf("plugin %s is listed more than once in pluginOrder", p)
+		}
+		listed[p] = struct{}{}
+		for _, d := range cr.Spec.DisablePlugins {
+			if string(d) == string(p) {
+				return fmt.Errorf("plugin %s is both disabled and listed in pluginOrder", p)
+			}
+		}
+	}
+	return nil
+}
+
 // validateSriovOperatorConfigDisableDrain checks if the user is setting `.Spec.DisableDrain` from false to true while
 // operator is updating one or more nodes. Disabling the drain at this stage would prevent the operator to uncordon a node at
 // the end of the update operation, keeping nodes un-schedulable until manual intervention.
@@ -137,7 +178,12 @@ func validateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePolicy, o
 		return admit, warnings, err
 	}
 
-	admit, err = dynamicValidateSriovNetworkNodePolicy(cr)
+	if err := validateSriovNetworkNodePolicyDpdkDriver(cr); err != nil {
+		return false, warnings, err
+	}
+
+	admit, dynamicWarnings, err := dynamicValidateSriovNetworkNodePolicy(cr)
+	warnings = append(warnings, dynamicWarnings...)
 	if err != nil {
 		return admit, warnings, err
 	}
@@ -145,6 +191,25 @@ func validateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePolicy, o
 	return admit, warnings, nil
 }
 
+// validateSriovNetworkNodePolicyDpdkDriver checks that a DPDK driver other than vfio-pci selected by the policy
+// is allowed by the dpdkDriverAllowlist of the default SriovOperatorConfig
+func validateSriovNetworkNodePolicyDpdkDriver(cr *sriovnetworkv1.SriovNetworkNodePolicy) error {
+	if cr.Spec.DeviceType == consts.DeviceTypeVfioPci || !sriovnetworkv1.StringInArray(cr.Spec.DeviceType, vars.DpdkDrivers) {
+		return nil
+	}
+	config, err := snclient.SriovnetworkV1().SriovOperatorConfigs(vars.Namespace).Get(context.Background(), consts.DefaultConfigName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the default SriovOperatorConfig to validate 'deviceType: %s': %w", cr.Spec.DeviceType, err)
+	}
+	if !sriovnetworkv1.StringInArray(cr.Spec.DeviceType, config.Spec.DpdkDriverAllowlist) {
+		return fmt.Errorf("'deviceType: %s' is not allowed; Add it to the dpdkDriverAllowlist of the default SriovOperatorConfig", cr.Spec.DeviceType)
+	}
+	return nil
+}
+
+// validHugepageSize matches the hugepage sizes of the kernel cmd line, e.g. 2M or 1G
+var validHugepageSize = regexp.MustCompile(`^[1-9][0-9]*[kKmMgG]?$`)
+
 func staticValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePolicy) (bool, error) {
 	var validString = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 	if !validString.MatchString(cr.Spec.ResourceName) {
@@ -155,6 +220,12 @@ func staticValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePol
 		return false, fmt.Errorf("at least one of these parameters (vendor, deviceID, pfNames, rootDevices or netFilter) has to be defined in nicSelector in CR %s", cr.GetName())
 	}
 
+	for _, rootDevice := range cr.Spec.NicSelector.RootDevices {
+		if !sriovnetworkv1.IsValidPciAddress(rootDevice) {
+			return false, fmt.Errorf("root device %q is not a valid PCI address, expected [domain:]bus:slot.func", rootDevice)
+		}
+	}
+
 	devMode := false
 	if os.Getenv("DEV_MODE") == "TRUE" {
 		devMode = true
@@ -210,17 +281,110 @@ func staticValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePol
 	// To configure RoCE on baremetal or virtual machine:
 	// BM: DeviceType = netdevice && isRdma = true
 	// VM: DeviceType = vfio-pci && isRdma = false
-	if cr.Spec.DeviceType == consts.DeviceTypeVfioPci && cr.Spec.IsRdma {
-		return false, fmt.Errorf("'deviceType: vfio-pci' conflicts with 'isRdma: true'; Set 'deviceType' to (string)'netdevice' Or Set 'isRdma' to (bool)'false'")
+	if sriovnetworkv1.StringInArray(cr.Spec.DeviceType, vars.DpdkDrivers) && cr.Spec.IsRdma {
+		return false, fmt.Errorf("'deviceType: %s' conflicts with 'isRdma: true'; Set 'deviceType' to (string)'netdevice' Or Set 'isRdma' to (bool)'false'", cr.Spec.DeviceType)
+	}
+	if cr.Spec.ExternallyManagedBestEffort && !cr.Spec.ExternallyManaged {
+		return false, fmt.Errorf("'externallyManagedBestEffort: true' requires 'externallyManaged: true'")
 	}
 	if strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) && !cr.Spec.IsRdma {
 		return false, fmt.Errorf("'linkType: ib or IB' requires 'isRdma: true'; Set 'isRdma' to (bool)'true'")
 	}
+	if len(cr.Spec.VfGUIDs) > 0 {
+		if !strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+			return false, fmt.Errorf("'vfGUIDs' requires 'linkType: ib or IB'")
+		}
+		for vf, guid := range cr.Spec.VfGUIDs {
+			if vfID, err := strconv.Atoi(vf); err != nil || vfID < 0 || vfID >= cr.Spec.NumVfs {
+				return false, fmt.Errorf("invalid VF index %q in vfGUIDs, it must be in the range 0-%d", vf, cr.Spec.NumVfs-1)
+			}
+			if hwAddr, err := net.ParseMAC(guid); err != nil || len(hwAddr) != 8 {
+				return false, fmt.Errorf("invalid GUID %q for VF %s in vfGUIDs", guid, vf)
+			}
+		}
+	}
+	if cr.Spec.GUIDGeneration != "" && !strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+		return false, fmt.Errorf("'guidGeneration' requires 'linkType: ib or IB'")
+	}
+	if cr.Spec.IbPkey != "" {
+		if !strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+			return false, fmt.Errorf("'ibPkey' requires 'linkType: ib or IB'")
+		}
+		if _, err := sriovnetworkv1.ParseIbPkey(cr.Spec.IbPkey); err != nil {
+			return false, err
+		}
+	}
+	if cr.Spec.MacPool != "" {
+		if strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+			return false, fmt.Errorf("'macPool' conflicts with 'linkType: ib or IB'")
+		}
+		_, size, err := sriovnetworkv1.ParseMacPool(cr.Spec.MacPool)
+		if err != nil {
+			return false, err
+		}
+		if size < uint64(cr.Spec.NumVfs) {
+			return false, fmt.Errorf("MAC pool %q holds %d addresses, fewer than the %d requested VFs", cr.Spec.MacPool, size, cr.Spec.NumVfs)
+		}
+	}
+
+	if cr.Spec.MacBase != "" {
+		if strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+			return false, fmt.Errorf("'macBase' conflicts with 'linkType: ib or IB'")
+		}
+		if cr.Spec.MacPool != "" {
+			return false, fmt.Errorf("'macBase' and 'macPool' are mutually exclusive")
+		}
+		if _, err := sriovnetworkv1.ParseMacBase(cr.Spec.MacBase, cr.Spec.NumVfs); err != nil {
+			return false, err
+		}
+	}
+
+	if cr.Spec.Mac != "" {
+		if strings.EqualFold(cr.Spec.LinkType, consts.LinkTypeIB) {
+			return false, fmt.Errorf("'mac' conflicts with 'linkType: ib or IB'")
+		}
+		if cr.Spec.MacPool != "" || cr.Spec.MacBase != "" {
+			return false, fmt.Errorf("'mac' is mutually exclusive with 'macPool' and 'macBase'")
+		}
+		if _, err := sriovnetworkv1.ParseVfMac(cr.Spec.Mac); err != nil {
+			return false, err
+		}
+		if !selectsSingleVf(cr) {
+			return false, fmt.Errorf("'mac' requires the policy to select a single VF per PF, " +
+				"set 'numVfs: 1' or a single VF range like '<pf name>#0-0' in 'pfNames'")
+		}
+		if !selectsSinglePf(cr) {
+			return false, fmt.Errorf("'mac' requires the policy to select a single PF, " +
+				"set a single entry in 'pfNames' or 'rootDevices'")
+		}
+	}
+
+	if (cr.Spec.HugepageSize == "") != (cr.Spec.HugepageCount == 0) {
+		return false, fmt.Errorf("'hugepageSize' and 'hugepageCount' must be set together")
+	}
+	if cr.Spec.HugepageSize != "" && !validHugepageSize.MatchString(cr.Spec.HugepageSize) {
+		return false, fmt.Errorf("invalid hugepage size %q, expected a size like 2M or 1G", cr.Spec.HugepageSize)
+	}
+
+	if cr.Spec.VlanID == nil && (cr.Spec.VlanQoS != 0 || cr.Spec.VlanProto != "") {
+		return false, fmt.Errorf("'vlanQoS' and 'vlanProto' require 'vlanId'")
+	}
+	if cr.Spec.VlanTrunk != "" {
+		if _, err := sriovnetworkv1.ParseVlanTrunk(cr.Spec.VlanTrunk); err != nil {
+			return false, err
+		}
+	}
+	if cr.Spec.MinTxRate != nil && cr.Spec.MaxTxRate != nil && *cr.Spec.MaxTxRate != 0 && *cr.Spec.MinTxRate > *cr.Spec.MaxTxRate {
+		return false, fmt.Errorf("'minTxRate' %d exceeds 'maxTxRate' %d", *cr.Spec.MinTxRate, *cr.Spec.MaxTxRate)
+	}
 
 	// vdpa: deviceType must be set to 'netdevice'
 	if cr.Spec.DeviceType != consts.DeviceTypeNetDevice && (cr.Spec.VdpaType == consts.VdpaTypeVirtio || cr.Spec.VdpaType == consts.VdpaTypeVhost) {
 		return false, fmt.Errorf("'deviceType: %s' conflicts with '%s'; Set 'deviceType' to (string)'netdevice' Or Remove 'vdpaType'", cr.Spec.DeviceType, cr.Spec.VdpaType)
 	}
+	if (cr.Spec.EswitchInlineMode != "" || cr.Spec.EswitchEncapMode != "") && cr.Spec.EswitchMode != sriovnetworkv1.ESwithModeSwitchDev {
+		return false, fmt.Errorf("'eSwitchInlineMode' and 'eSwitchEncapMode' require 'eSwitchMode: switchdev'")
+	}
 	// vdpa: device must be configured in switchdev mode
 	if (cr.Spec.VdpaType == consts.VdpaTypeVirtio || cr.Spec.VdpaType == consts.VdpaTypeVhost) && cr.Spec.EswitchMode != sriovnetworkv1.ESwithModeSwitchDev {
 		return false, fmt.Errorf("vdpa requires the device to be configured in switchdev mode")
@@ -228,7 +392,8 @@ func staticValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePol
 	return true, nil
 }
 
-func dynamicValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePolicy) (bool, error) {
+func dynamicValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePolicy) (bool, []string, error) {
+	var warnings []string
 	nodesSelected = false
 	interfaceSelected = false
 	nodeInterfaceErrorList := make(map[string][]string)
@@ -237,28 +402,35 @@ func dynamicValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePo
 		LabelSelector: labels.Set(cr.Spec.NodeSelector).String(),
 	})
 	if err != nil {
-		return false, err
+		return false, warnings, err
 	}
 	nsList, err := snclient.SriovnetworkV1().SriovNetworkNodeStates(namespace).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
-		return false, err
+		return false, warnings, err
 	}
 	npList, err := snclient.SriovnetworkV1().SriovNetworkNodePolicies(namespace).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
-		return false, err
+		return false, warnings, err
 	}
+	selectedNodes := []*corev1.Node{}
 	for _, node := range nodeList.Items {
 		if cr.Selected(&node) {
 			nodesSelected = true
+			selectedNodes = append(selectedNodes, &node)
 			err = validatePolicyForNodeStateAndPolicy(nsList, npList, &node, cr, nodeInterfaceErrorList)
 			if err != nil {
-				return false, err
+				return false, warnings, err
 			}
+			warnings = append(warnings, offloadVfLimitWarnings(nsList, &node, cr)...)
+			warnings = append(warnings, nicSelectorMatchWarnings(nsList, &node, cr)...)
 		}
 	}
 
 	if !nodesSelected {
-		return false, fmt.Errorf("no matched node is selected by the nodeSelector in CR %s", cr.GetName())
+		return false, warnings, fmt.Errorf("no matched node is selected by the nodeSelector in CR %s", cr.GetName())
+	}
+	if err := validateMacSelection(nsList, selectedNodes, cr); err != nil {
+		return false, warnings, err
 	}
 	if !interfaceSelected {
 		for nodeName, messages := range nodeInterfaceErrorList {
@@ -266,10 +438,85 @@ func dynamicValidateSriovNetworkNodePolicy(cr *sriovnetworkv1.SriovNetworkNodePo
 				log.Log.V(2).Info("interface selection errors", "nodeName", nodeName, "message", message)
 			}
 		}
-		return false, fmt.Errorf("no supported NIC is selected by the nicSelector in CR %s", cr.GetName())
+		return false, warnings, fmt.Errorf("no supported NIC is selected by the nicSelector in CR %s", cr.GetName())
 	}
 
-	return true, nil
+	return true, warnings, nil
+}
+
+// offloadVfLimitWarnings returns a warning for every interface of the node selected by a switchdev
+// policy requesting more VFs than the hardware can offload, the VFs above the limit silently
+// fall back to software datapath
+func offloadVfLimitWarnings(nsList *sriovnetworkv1.SriovNetworkNodeStateList, node *corev1.Node, cr *sriovnetworkv1.SriovNetworkNodePolicy) []string {
+	var warnings []string
+	if cr.Spec.EswitchMode != sriovnetworkv1.ESwithModeSwitchDev {
+		return warnings
+	}
+	for _, ns := range nsList.Items {
+		if ns.GetName() != node.GetName() {
+			continue
+		}
+		for _, iface := range ns.Status.Interfaces {
+			if validateNicModel(&cr.Spec.NicSelector, &iface, node) != nil {
+				continue
+			}
+			if iface.OffloadVfLimit > 0 && cr.Spec.NumVfs > iface.OffloadVfLimit {
+				warnings = append(warnings, fmt.Sprintf("numVfs(%d) in CR %s exceed the hardware offload capacity(%d) of interface(%s) on node %s, "+
+					"VFs above the capacity will not be offloaded", cr.Spec.NumVfs, cr.GetName(), iface.OffloadVfLimit, iface.Name, node.GetName()))
+			}
+		}
+	}
+	return warnings
+}
+
+// nicSelectorMatchWarnings returns a warning when the NIC selector of the policy matches several PFs of
+// the node without naming them through pfNames or rootDevices, e.g. a vendor only selector matching every
+// NIC of the node, the same numVfs is then applied to all of them
+func nicSelectorMatchWarnings(nsList *sriovnetworkv1.SriovNetworkNodeStateList, node *corev1.Node, cr *sriovnetworkv1.SriovNetworkNodePolicy) []string {
+	var warnings []string
+	selector := &cr.Spec.NicSelector
+	if len(selector.PfNames) > 0 || len(selector.RootDevices) > 0 {
+		return warnings
+	}
+	for i := range nsList.Items {
+		if nsList.Items[i].GetName() != node.GetName() {
+			continue
+		}
+		if count := selector.CountSelected(&nsList.Items[i]); count > 1 {
+			warnings = append(warnings, fmt.Sprintf("nicSelector in CR %s matches %d PFs on node %s, "+
+				"use pfNames or rootDevices to select the PFs explicitly", cr.GetName(), count, node.GetName()))
+		}
+	}
+	return warnings
+}
+
+// validateMacSelection returns an error if the policy assigning the VF MACs from a base MAC address or a single
+// MAC address selects more than one PF on the selected nodes, these addresses aren't split between the PFs and
+// the nodes like the MAC pool so the VFs of every PF would get the same addresses
+func validateMacSelection(nsList *sriovnetworkv1.SriovNetworkNodeStateList, nodes []*corev1.Node, cr *sriovnetworkv1.SriovNetworkNodePolicy) error {
+	field := ""
+	if cr.Spec.MacBase != "" {
+		field = "macBase"
+	} else if cr.Spec.Mac != "" {
+		field = "mac"
+	} else {
+		return nil
+	}
+	if len(nodes) > 1 {
+		return fmt.Errorf("'%s' requires the policy to select a single node, CR %s selects %d nodes", field, cr.GetName(), len(nodes))
+	}
+	for _, node := range nodes {
+		for i := range nsList.Items {
+			if nsList.Items[i].GetName() != node.GetName() {
+				continue
+			}
+			if count := cr.Spec.NicSelector.CountSelected(&nsList.Items[i]); count > 1 {
+				return fmt.Errorf("'%s' requires the policy to select a single PF, CR %s selects %d PFs on node %s",
+					field, cr.GetName(), count, node.GetName())
+			}
+		}
+	}
+	return nil
 }
 
 func validatePolicyForNodeStateAndPolicy(nsList *sriovnetworkv1.SriovNetworkNodeStateList, npList *sriovnetworkv1.SriovNetworkNodePolicyList, node *corev1.Node, cr *sriovnetworkv1.SriovNetworkNodePolicy, nodeInterfaceErrorList map[string][]string) error {
@@ -319,7 +566,7 @@ func validatePolicyForNodeState(policy *sriovnetworkv1.SriovNetworkNodePolicy, s
 
 			// Externally create validations
 			if policy.Spec.ExternallyManaged {
-				if policy.Spec.NumVfs > iface.NumVfs {
+				if policy.Spec.NumVfs > iface.NumVfs && !policy.Spec.ExternallyManagedBestEffort {
 					return nil, fmt.Errorf("numVfs(%d) in CR %s is higher than the virtual functions allocated for the PF externally value(%d)", policy.Spec.NumVfs, policy.GetName(), iface.NumVfs)
 				}
 
@@ -331,6 +578,17 @@ func validatePolicyForNodeState(policy *sriovnetworkv1.SriovNetworkNodePolicy, s
 					return nil, fmt.Errorf("LinkType(%s) in CR %s is not equal to the LinkType for the PF externally value(%s)", policy.Spec.LinkType, policy.GetName(), iface.LinkType)
 				}
 			}
+			if iface.Capabilities != nil {
+				group := &sriovnetworkv1.VfGroup{SpoofChk: policy.Spec.SpoofChk, Trust: policy.Spec.Trust}
+				if unsupported := iface.Capabilities.UnsupportedVfGroupSettings(group); len(unsupported) > 0 {
+					return nil, fmt.Errorf("%s in CR %s not supported by the driver(%s) of interface(%s)",
+						strings.Join(unsupported, ", "), policy.GetName(), iface.Driver, iface.Name)
+				}
+			}
+			if policy.Spec.IbPkey != "" && iface.Driver != consts.IbPkeyVfDriver {
+				return nil, fmt.Errorf("'ibPkey' in CR %s is unsupported by the driver(%s) of interface(%s), "+
+					"the partition keys of its VFs are assigned by the subnet manager", policy.GetName(), iface.Driver, iface.Name)
+			}
 			// vdpa: only mellanox cards are supported
 			if (policy.Spec.VdpaType == consts.VdpaTypeVirtio || policy.Spec.VdpaType == consts.VdpaTypeVhost) && iface.Vendor != MellanoxID {
 				return nil, fmt.Errorf("vendor(%s) in CR %s not supported for vdpa interface(%s)", iface.Vendor, policy.GetName(), iface.Name)
@@ -373,6 +631,29 @@ func validatePolicyForNodePolicy(current *sriovnetworkv1.SriovNetworkNodePolicy,
 	return nil
 }
 
+// selectsSingleVf returns true if the policy selects at most one VF of each PF
+func selectsSingleVf(cr *sriovnetworkv1.SriovNetworkNodePolicy) bool {
+	if cr.Spec.NumVfs == 1 {
+		return true
+	}
+	if len(cr.Spec.NicSelector.PfNames) == 0 {
+		return false
+	}
+	for _, pf := range cr.Spec.NicSelector.PfNames {
+		_, rngSt, rngEnd, err := sriovnetworkv1.ParseVfRange(pf)
+		if err != nil || rngSt < 0 || rngSt != rngEnd {
+			return false
+		}
+	}
+	return true
+}
+
+// selectsSinglePf returns true if the NIC selector of the policy names a single PF of each node,
+// a vendor or device ID only selector can match several PFs
+func selectsSinglePf(cr *sriovnetworkv1.SriovNetworkNodePolicy) bool {
+	return len(cr.Spec.NicSelector.PfNames) == 1 || len(cr.Spec.NicSelector.RootDevices) == 1
+}
+
 func validatePfNames(current *sriovnetworkv1.SriovNetworkNodePolicy, previous *sriovnetworkv1.SriovNetworkNodePolicy) error {
 	for _, curPf := range current.Spec.NicSelector.PfNames {
 		curName, curRngSt, curRngEnd, err := sriovnetworkv1.ParseVfRange(curPf)
@@ -405,7 +686,7 @@ func validateRootDevices(current *sriovnetworkv1.SriovNetworkNodePolicy, previou
 	for _, curRootDevice := range current.Spec.NicSelector.RootDevices {
 		for _, preRootDevice := range previous.Spec.NicSelector.RootDevices {
 			// TODO: (SchSeba) implement range for root devices
-			if curRootDevice == preRootDevice {
+			if sriovnetworkv1.PciAddressEqual(curRootDevice, preRootDevice) {
 				return fmt.Errorf("root device %s is overlapped with existing policy %s", curRootDevice, previous.GetName())
 			}
 		}
@@ -442,7 +723,7 @@ func validateNicModel(selector *sriovnetworkv1.SriovNetworkNicSelector, iface *s
 	if selector.DeviceID != "" && selector.DeviceID != iface.DeviceID {
 		return fmt.Errorf("selector device ID: %s is not equal to the interface device ID: %s", selector.Vendor, iface.Vendor)
 	}
-	if len(selector.RootDevices) > 0 && !sriovnetworkv1.StringInArray(iface.PciAddress, selector.RootDevices) {
+	if len(selector.RootDevices) > 0 && !sriovnetworkv1.PciAddressInArray(iface.PciAddress, selector.RootDevices) {
 		return fmt.Errorf("interface PCI address: %s not found in root devices", iface.PciAddress)
 	}
 	if len(selector.PfNames) > 0 {
@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 
 	. "github.com/k8snetworkplumbingwg/sriov-network-operator/api/v1"
 	constants "github.com/k8snetworkplumbingwg/sriov-network-operator/pkg/consts"
@@ -192,6 +193,74 @@ func TestValidateSriovOperatorConfigWithDefaultOperatorConfig(t *testing.T) {
 	g.Expect(ok).To(Equal(true))
 }
 
+func TestValidateSriovOperatorConfigWebhookFailurePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := newDefaultOperatorConfig()
+	config.Spec.DisableDrain = false
+	snclient = fakesnclientset.NewSimpleClientset()
+
+	for _, policy := range []string{"", "Fail", "Ignore"} {
+		config.Spec.WebhookFailurePolicy = policy
+		ok, _, err := validateSriovOperatorConfig(config, "UPDATE")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ok).To(Equal(true))
+	}
+
+	config.Spec.WebhookFailurePolicy = "Retry"
+	ok, _, err := validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid webhookFailurePolicy \"Retry\"")))
+	g.Expect(ok).To(Equal(false))
+}
+
+func TestValidateSriovOperatorConfigPlugins(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := newDefaultOperatorConfig()
+	config.Spec.DisableDrain = false
+	snclient = fakesnclientset.NewSimpleClientset()
+
+	config.Spec.DisablePlugins = PluginNameSlice{"mellanox"}
+	config.Spec.PluginOrder = PluginOrderSlice{"intel", "generic"}
+	ok, _, err := validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ok).To(Equal(true))
+
+	config.Spec.PluginOrder = PluginOrderSlice{"intel", "generic", "intel"}
+	ok, _, err = validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).To(MatchError(ContainSubstring("plugin intel is listed more than once")))
+	g.Expect(ok).To(Equal(false))
+
+	config.Spec.PluginOrder = PluginOrderSlice{"mellanox", "generic"}
+	ok, _, err = validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).To(MatchError(ContainSubstring("plugin mellanox is both disabled and listed")))
+	g.Expect(ok).To(Equal(false))
+
+	config.Spec.PluginOrder = nil
+	config.Spec.DisablePlugins = PluginNameSlice{"generic"}
+	ok, _, err = validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).To(MatchError(ContainSubstring("plugin generic cannot be disabled")))
+	g.Expect(ok).To(Equal(false))
+}
+
+func TestValidateSriovOperatorConfigDpdkDriverAllowlist(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := newDefaultOperatorConfig()
+	config.Spec.DisableDrain = false
+	snclient = fakesnclientset.NewSimpleClientset()
+
+	config.Spec.DpdkDriverAllowlist = []string{"igb_uio", "uio_pci_generic"}
+	ok, _, err := validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ok).To(Equal(true))
+
+	config.Spec.DpdkDriverAllowlist = []string{"igb_uio", "mlx5_core"}
+	ok, _, err = validateSriovOperatorConfig(config, "UPDATE")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid driver \"mlx5_core\" in dpdkDriverAllowlist")))
+	g.Expect(ok).To(Equal(false))
+}
+
 func TestValidateSriovOperatorConfigDisableDrain(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -368,6 +437,185 @@ func TestValidatePolicyForNodeStateWithInvalidNumVfsExternallyCreated(t *testing
 	g.Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("numVfs(%d) in CR %s is higher than the virtual functions allocated for the PF externally value(%d)", policy.Spec.NumVfs, policy.GetName(), state.Status.Interfaces[0].NumVfs))))
 }
 
+func TestValidatePolicyForNodeStateWithFewerVfsExternallyCreatedBestEffort(t *testing.T) {
+	state := newNodeState()
+	policy := &SriovNetworkNodePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "p1",
+		},
+		Spec: SriovNetworkNodePolicySpec{
+			DeviceType: "netdevice",
+			NicSelector: SriovNetworkNicSelector{
+				PfNames:     []string{"ens803f0"},
+				RootDevices: []string{"0000:86:00.0"},
+				Vendor:      "8086",
+			},
+			NodeSelector: map[string]string{
+				"feature.node.kubernetes.io/network-sriov.capable": "true",
+			},
+			NumVfs:                      5,
+			Priority:                    99,
+			ResourceName:                "p0",
+			ExternallyManaged:           true,
+			ExternallyManagedBestEffort: true,
+		},
+	}
+	g := NewGomegaWithT(t)
+	_, err := validatePolicyForNodeState(policy, state, NewNode())
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestValidatePolicyForNodeStateWithUnsupportedTrust(t *testing.T) {
+	state := newNodeState()
+	state.Status.Interfaces[0].Capabilities = &InterfaceCapabilities{VfSpoofChk: true}
+	policy := &SriovNetworkNodePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "p1",
+		},
+		Spec: SriovNetworkNodePolicySpec{
+			DeviceType: "netdevice",
+			NicSelector: SriovNetworkNicSelector{
+				PfNames:     []string{"ens803f0"},
+				RootDevices: []string{"0000:86:00.0"},
+				Vendor:      "8086",
+			},
+			NodeSelector: map[string]string{
+				"feature.node.kubernetes.io/network-sriov.capable": "true",
+			},
+			NumVfs:       4,
+			Priority:     99,
+			ResourceName: "p0",
+			SpoofChk:     "on",
+			Trust:        "on",
+		},
+	}
+	g := NewGomegaWithT(t)
+	_, err := validatePolicyForNodeState(policy, state, NewNode())
+	g.Expect(err).To(MatchError("trust mode in CR p1 not supported by the driver(i40e) of interface(ens803f0)"))
+
+	state.Status.Interfaces[0].Capabilities.VfTrust = true
+	_, err = validatePolicyForNodeState(policy, state, NewNode())
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestStaticValidateSriovNetworkNodePolicyWithBestEffortNotExternallyManaged(t *testing.T) {
+	policy := &SriovNetworkNodePolicy{
+		Spec: SriovNetworkNodePolicySpec{
+			DeviceType: "netdevice",
+			NicSelector: SriovNetworkNicSelector{
+				Vendor:   "8086",
+				DeviceID: "158b",
+			},
+			NodeSelector: map[string]string{
+				"feature.node.kubernetes.io/network-sriov.capable": "true",
+			},
+			NumVfs:                      63,
+			Priority:                    99,
+			ResourceName:                "p0",
+			ExternallyManagedBestEffort: true,
+		},
+	}
+	g := NewGomegaWithT(t)
+	ok, err := staticValidateSriovNetworkNodePolicy(policy)
+	g.Expect(err).To(MatchError("'externallyManagedBestEffort: true' requires 'externallyManaged: true'"))
+	g.Expect(ok).To(Equal(false))
+}
+
+func TestStaticValidateSriovNetworkNodePolicyHugepages(t *testing.T) {
+	testCases := []struct {
+		name  string
+		size  string
+		count int
+		err   string
+	}{
+		{name: "not set"},
+		{name: "valid", size: "1G", count: 16},
+		{name: "size without count", size: "1G", err: "'hugepageSize' and 'hugepageCount' must be set together"},
+		{name: "count without size", count: 16, err: "'hugepageSize' and 'hugepageCount' must be set together"},
+		{name: "invalid size", size: "1X", count: 16, err: `invalid hugepage size "1X", expected a size like 2M or 1G`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "vfio-pci",
+					NicSelector: SriovNetworkNicSelector{
+						Vendor:   "8086",
+						DeviceID: "158b",
+					},
+					NumVfs:        4,
+					ResourceName:  "p0",
+					HugepageSize:  tc.size,
+					HugepageCount: tc.count,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.err))
+			g.Expect(ok).To(BeFalse())
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyVfSettings(t *testing.T) {
+	testCases := []struct {
+		name      string
+		vlanID    *int
+		vlanQoS   int
+		vlanProto string
+		vlanTrunk string
+		minTxRate *int
+		maxTxRate *int
+		err       string
+	}{
+		{name: "not set"},
+		{name: "vlan", vlanID: pointer.Int(100), vlanQoS: 3, vlanProto: "802.1ad", vlanTrunk: "100-200,300"},
+		{name: "vlan qos without vlan", vlanQoS: 3, err: "'vlanQoS' and 'vlanProto' require 'vlanId'"},
+		{name: "vlan proto without vlan", vlanProto: "802.1ad", err: "'vlanQoS' and 'vlanProto' require 'vlanId'"},
+		{name: "invalid vlan trunk", vlanTrunk: "200-100",
+			err: `invalid VLAN trunk "200-100": invalid range 200-100, the VLANs must be between 1 and 4094`},
+		{name: "tx rates", minTxRate: pointer.Int(100), maxTxRate: pointer.Int(1000)},
+		{name: "min tx rate without max tx rate", minTxRate: pointer.Int(100), maxTxRate: pointer.Int(0)},
+		{name: "min tx rate above max tx rate", minTxRate: pointer.Int(1000), maxTxRate: pointer.Int(100),
+			err: "'minTxRate' 1000 exceeds 'maxTxRate' 100"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						Vendor:   "8086",
+						DeviceID: "158b",
+					},
+					NumVfs:       4,
+					ResourceName: "p0",
+					VlanID:       tc.vlanID,
+					VlanQoS:      tc.vlanQoS,
+					VlanProto:    tc.vlanProto,
+					VlanTrunk:    tc.vlanTrunk,
+					MinTxRate:    tc.minTxRate,
+					MaxTxRate:    tc.maxTxRate,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.err))
+			g.Expect(ok).To(BeFalse())
+		})
+	}
+}
+
 func TestValidatePolicyForNodeStateWithValidNumVfsExternallyCreated(t *testing.T) {
 	state := newNodeState()
 	policy := &SriovNetworkNodePolicy{
@@ -877,6 +1125,46 @@ func TestStaticValidateSriovNetworkNodePolicyWithConflictIsRdmaAndDeviceType(t *
 	g.Expect(ok).To(Equal(false))
 }
 
+func TestValidateSriovNetworkNodePolicyDpdkDriver(t *testing.T) {
+	policy := &SriovNetworkNodePolicy{
+		Spec: SriovNetworkNodePolicySpec{
+			DeviceType: "igb_uio",
+			NicSelector: SriovNetworkNicSelector{
+				Vendor:   "8086",
+				DeviceID: "158b",
+			},
+			NumVfs:       1,
+			ResourceName: "p0",
+		},
+	}
+	g := NewGomegaWithT(t)
+
+	config := newDefaultOperatorConfig()
+	snclient = fakesnclientset.NewSimpleClientset(config)
+	err := validateSriovNetworkNodePolicyDpdkDriver(policy)
+	g.Expect(err).To(MatchError(ContainSubstring("'deviceType: igb_uio' is not allowed")))
+
+	config.Spec.DpdkDriverAllowlist = []string{"igb_uio"}
+	snclient = fakesnclientset.NewSimpleClientset(config)
+	g.Expect(validateSriovNetworkNodePolicyDpdkDriver(policy)).To(Succeed())
+
+	policy.Spec.DeviceType = "uio_pci_generic"
+	g.Expect(validateSriovNetworkNodePolicyDpdkDriver(policy)).NotTo(Succeed())
+
+	// vfio-pci and netdevice don't need to be allowed
+	snclient = fakesnclientset.NewSimpleClientset()
+	for _, deviceType := range []string{constants.DeviceTypeVfioPci, constants.DeviceTypeNetDevice} {
+		policy.Spec.DeviceType = deviceType
+		g.Expect(validateSriovNetworkNodePolicyDpdkDriver(policy)).To(Succeed())
+	}
+
+	policy.Spec.DeviceType = "igb_uio"
+	policy.Spec.IsRdma = true
+	ok, err := staticValidateSriovNetworkNodePolicy(policy)
+	g.Expect(err).To(MatchError(ContainSubstring("'deviceType: igb_uio' conflicts with 'isRdma: true'")))
+	g.Expect(ok).To(Equal(false))
+}
+
 func TestStaticValidateSriovNetworkNodePolicyWithConflictDeviceTypeAndVirtioVdpaType(t *testing.T) {
 	policy := &SriovNetworkNodePolicy{
 		Spec: SriovNetworkNodePolicySpec{
@@ -971,6 +1259,142 @@ func TestStaticValidateSriovNetworkNodePolicyVhostVdpaMustSpecifySwitchDev(t *te
 	g.Expect(ok).To(Equal(false))
 }
 
+func TestStaticValidateSriovNetworkNodePolicyEswitchOffloadModesMustSpecifySwitchDev(t *testing.T) {
+	testtable := []struct {
+		tname       string
+		eswitchMode string
+		inlineMode  string
+		encapMode   string
+		expectErr   bool
+	}{
+		{tname: "inline mode in switchdev", eswitchMode: "switchdev", inlineMode: "transport"},
+		{tname: "encap mode in switchdev", eswitchMode: "switchdev", encapMode: "disable"},
+		{tname: "inline mode in legacy", eswitchMode: "legacy", inlineMode: "transport", expectErr: true},
+		{tname: "encap mode without eswitch mode", encapMode: "enable", expectErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						Vendor:   "15b3",
+						DeviceID: "101d",
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:            1,
+					Priority:          99,
+					ResourceName:      "p0",
+					EswitchMode:       tc.eswitchMode,
+					EswitchInlineMode: tc.inlineMode,
+					EswitchEncapMode:  tc.encapMode,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.expectErr {
+				g.Expect(err).To(MatchError(ContainSubstring("require 'eSwitchMode: switchdev'")))
+				g.Expect(ok).To(Equal(false))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(Equal(true))
+			}
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyRootDevices(t *testing.T) {
+	testtable := []struct {
+		tname      string
+		rootDevice string
+		expectErr  bool
+	}{
+		{tname: "canonical", rootDevice: "0000:86:00.0"},
+		{tname: "non-zero domain", rootDevice: "10000:03:00.0"},
+		{tname: "interface name", rootDevice: "ens803f0", expectErr: true},
+		{tname: "missing function", rootDevice: "0000:86:00", expectErr: true},
+	}
+	for _, tc := range testtable {
+		t.Run(tc.tname, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						RootDevices: []string{tc.rootDevice},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       1,
+					Priority:     99,
+					ResourceName: "p0",
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.expectErr {
+				g.Expect(err).To(MatchError(ContainSubstring("is not a valid PCI address")))
+				g.Expect(ok).To(Equal(false))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(Equal(true))
+			}
+		})
+	}
+}
+
+func TestValidatePolicyForNodeStateIbPkeyDriver(t *testing.T) {
+	testCases := []struct {
+		name   string
+		driver string
+		err    string
+	}{
+		{
+			name:   "mlx4",
+			driver: "mlx4_core",
+		},
+		{
+			name:   "mlx5",
+			driver: "mlx5_core",
+			err: "'ibPkey' in CR p1 is unsupported by the driver(mlx5_core) of interface(ens803f0), " +
+				"the partition keys of its VFs are assigned by the subnet manager",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newNodeState()
+			state.Status.Interfaces[0].Driver = tc.driver
+			policy := &SriovNetworkNodePolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "p1",
+				},
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						PfNames: []string{"ens803f0"},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       4,
+					ResourceName: "p0",
+					LinkType:     "ib",
+					IbPkey:       "0x8001",
+				},
+			}
+			g := NewGomegaWithT(t)
+			_, err := validatePolicyForNodeState(policy, state, NewNode())
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.err))
+		})
+	}
+}
+
 func TestValidatePolicyForNodeStateVirtioVdpaWithNotSupportedVendor(t *testing.T) {
 	state := newNodeState()
 	policy := &SriovNetworkNodePolicy{
@@ -1273,3 +1697,544 @@ func TestValidatePolicyForNodePolicyAllowSwitchdevWithExternallyManage(t *testin
 	err := validatePolicyForNodePolicy(policy, appliedPolicy)
 	g.Expect(err).NotTo(HaveOccurred())
 }
+
+func TestOffloadVfLimitWarnings(t *testing.T) {
+	state := newNodeState()
+	state.Status.Interfaces[1].OffloadVfLimit = 16
+	policy := newNodePolicy()
+	policy.Spec.NumVfs = 32
+	policy.Spec.EswitchMode = "switchdev"
+	g := NewGomegaWithT(t)
+	nsList := &SriovNetworkNodeStateList{Items: []SriovNetworkNodeState{*state}}
+	warnings := offloadVfLimitWarnings(nsList, NewNode(), policy)
+	g.Expect(warnings).To(ConsistOf(
+		"numVfs(32) in CR p1 exceed the hardware offload capacity(16) of interface(ens803f1) on node , VFs above the capacity will not be offloaded"))
+
+	policy.Spec.NumVfs = 16
+	g.Expect(offloadVfLimitWarnings(nsList, NewNode(), policy)).To(BeEmpty())
+
+	policy.Spec.NumVfs = 32
+	policy.Spec.EswitchMode = "legacy"
+	g.Expect(offloadVfLimitWarnings(nsList, NewNode(), policy)).To(BeEmpty())
+}
+
+func TestNicSelectorMatchWarnings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector SriovNetworkNicSelector
+		warnings []string
+	}{
+		{
+			name:     "no PF matched",
+			selector: SriovNetworkNicSelector{Vendor: "15b3"},
+		},
+		{
+			name:     "one PF matched",
+			selector: SriovNetworkNicSelector{Vendor: "8086", DeviceID: "1015"},
+		},
+		{
+			name:     "many PFs matched",
+			selector: SriovNetworkNicSelector{Vendor: "8086"},
+			warnings: []string{"nicSelector in CR p1 matches 3 PFs on node , use pfNames or rootDevices to select the PFs explicitly"},
+		},
+		{
+			name:     "many PFs matched by name",
+			selector: SriovNetworkNicSelector{Vendor: "8086", PfNames: []string{"ens803f0", "ens803f1"}},
+		},
+	}
+	nsList := &SriovNetworkNodeStateList{Items: []SriovNetworkNodeState{*newNodeState()}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			policy := newNodePolicy()
+			policy.Spec.NicSelector = tc.selector
+			g.Expect(nicSelectorMatchWarnings(nsList, NewNode(), policy)).To(Equal(tc.warnings))
+		})
+	}
+}
+
+func TestValidateMacSelection(t *testing.T) {
+	other := NewNode()
+	other.Name = "worker-1"
+	testCases := []struct {
+		name     string
+		macBase  string
+		mac      string
+		selector SriovNetworkNicSelector
+		nodes    []*corev1.Node
+		err      string
+	}{
+		{
+			name:     "no base MAC",
+			selector: SriovNetworkNicSelector{Vendor: "8086"},
+			nodes:    []*corev1.Node{NewNode(), other},
+		},
+		{
+			name:     "MAC of one PF on one node",
+			mac:      "02:00:00:00:00:10",
+			selector: SriovNetworkNicSelector{Vendor: "8086", DeviceID: "1015"},
+			nodes:    []*corev1.Node{NewNode()},
+		},
+		{
+			name:     "MAC of a vendor only selector",
+			mac:      "02:00:00:00:00:10",
+			selector: SriovNetworkNicSelector{Vendor: "8086"},
+			nodes:    []*corev1.Node{NewNode()},
+			err:      "'mac' requires the policy to select a single PF, CR p1 selects 3 PFs on node ",
+		},
+		{
+			name:     "MAC on many nodes",
+			mac:      "02:00:00:00:00:10",
+			selector: SriovNetworkNicSelector{PfNames: []string{"ens803f1"}},
+			nodes:    []*corev1.Node{NewNode(), other},
+			err:      "'mac' requires the policy to select a single node, CR p1 selects 2 nodes",
+		},
+		{
+			name:     "one PF on one node",
+			macBase:  "02:00:00:00:00:00",
+			selector: SriovNetworkNicSelector{Vendor: "8086", DeviceID: "1015"},
+			nodes:    []*corev1.Node{NewNode()},
+		},
+		{
+			name:     "many PFs",
+			macBase:  "02:00:00:00:00:00",
+			selector: SriovNetworkNicSelector{Vendor: "8086"},
+			nodes:    []*corev1.Node{NewNode()},
+			err:      "'macBase' requires the policy to select a single PF, CR p1 selects 3 PFs on node ",
+		},
+		{
+			name:     "many nodes",
+			macBase:  "02:00:00:00:00:00",
+			selector: SriovNetworkNicSelector{Vendor: "8086", DeviceID: "1015"},
+			nodes:    []*corev1.Node{NewNode(), other},
+			err:      "'macBase' requires the policy to select a single node, CR p1 selects 2 nodes",
+		},
+	}
+	nsList := &SriovNetworkNodeStateList{Items: []SriovNetworkNodeState{*newNodeState()}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			policy := newNodePolicy()
+			policy.Spec.MacBase = tc.macBase
+			policy.Spec.Mac = tc.mac
+			policy.Spec.NicSelector = tc.selector
+			err := validateMacSelection(nsList, tc.nodes, policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tc.err))
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyVfGUIDs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		linkType       string
+		vfGUIDs        map[string]string
+		guidGeneration string
+		err            string
+	}{
+		{
+			name:     "valid",
+			linkType: "ib",
+			vfGUIDs:  map[string]string{"0": "00:11:22:33:44:55:66:77"},
+		},
+		{
+			name:     "not infiniband",
+			linkType: "eth",
+			vfGUIDs:  map[string]string{"0": "00:11:22:33:44:55:66:77"},
+			err:      "'vfGUIDs' requires 'linkType: ib or IB'",
+		},
+		{
+			name:     "VF index out of range",
+			linkType: "ib",
+			vfGUIDs:  map[string]string{"4": "00:11:22:33:44:55:66:77"},
+			err:      "invalid VF index \"4\" in vfGUIDs",
+		},
+		{
+			name:     "invalid GUID",
+			linkType: "ib",
+			vfGUIDs:  map[string]string{"0": "00:11:22:33:44:55"},
+			err:      "invalid GUID \"00:11:22:33:44:55\" for VF 0 in vfGUIDs",
+		},
+		{
+			name:           "deterministic GUIDs",
+			linkType:       "ib",
+			guidGeneration: "deterministic",
+		},
+		{
+			name:           "deterministic GUIDs not infiniband",
+			linkType:       "eth",
+			guidGeneration: "deterministic",
+			err:            "'guidGeneration' requires 'linkType: ib or IB'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						PfNames: []string{"ib0"},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:         4,
+					ResourceName:   "p0",
+					LinkType:       tc.linkType,
+					IsRdma:         true,
+					VfGUIDs:        tc.vfGUIDs,
+					GUIDGeneration: tc.guidGeneration,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				g.Expect(ok).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyMacBase(t *testing.T) {
+	testCases := []struct {
+		name     string
+		linkType string
+		macBase  string
+		macPool  string
+		err      string
+	}{
+		{
+			name:     "valid",
+			linkType: "eth",
+			macBase:  "02:00:00:00:00:00",
+		},
+		{
+			name:     "infiniband",
+			linkType: "ib",
+			macBase:  "02:00:00:00:00:00",
+			err:      "'macBase' conflicts with 'linkType: ib or IB'",
+		},
+		{
+			name:     "with a mac pool",
+			linkType: "eth",
+			macBase:  "02:00:00:00:00:00",
+			macPool:  "02:00:00:00:01:00-02:00:00:00:01:ff",
+			err:      "'macBase' and 'macPool' are mutually exclusive",
+		},
+		{
+			name:     "universally administered",
+			linkType: "eth",
+			macBase:  "b8:3f:d2:00:00:00",
+			err:      "the addresses must be locally administered",
+		},
+		{
+			name:     "too few addresses",
+			linkType: "eth",
+			macBase:  "02:ff:ff:ff:ff:fd",
+			err:      "leaves room for 3 addresses, fewer than the 4 requested VFs",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						PfNames: []string{"ens1f0"},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       4,
+					ResourceName: "p0",
+					LinkType:     tc.linkType,
+					IsRdma:       tc.linkType == "ib",
+					MacBase:      tc.macBase,
+					MacPool:      tc.macPool,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				g.Expect(ok).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyIbPkey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		linkType string
+		ibPkey   string
+		err      string
+	}{
+		{
+			name:     "valid",
+			linkType: "ib",
+			ibPkey:   "0x8001",
+		},
+		{
+			name:     "default partition",
+			linkType: "IB",
+			ibPkey:   "0x7fff",
+		},
+		{
+			name:     "ethernet",
+			linkType: "eth",
+			ibPkey:   "0x8001",
+			err:      "'ibPkey' requires 'linkType: ib or IB'",
+		},
+		{
+			name:     "zero base value",
+			linkType: "ib",
+			ibPkey:   "0x8000",
+			err:      "the base value must not be zero",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						PfNames: []string{"ibs1f0"},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       4,
+					ResourceName: "p0",
+					LinkType:     tc.linkType,
+					IsRdma:       true,
+					IbPkey:       tc.ibPkey,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				g.Expect(ok).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyMac(t *testing.T) {
+	testCases := []struct {
+		name        string
+		linkType    string
+		deviceType  string
+		pfNames     []string
+		rootDevices []string
+		vendor      string
+		numVfs      int
+		mac         string
+		macBase     string
+		err         string
+	}{
+		{
+			name:       "single VF range",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0#2-2"},
+			numVfs:     4,
+			mac:        "02:00:00:00:00:10",
+		},
+		{
+			name:        "single root device",
+			linkType:    "eth",
+			deviceType:  "vfio-pci",
+			rootDevices: []string{"0000:86:00.0"},
+			numVfs:      1,
+			mac:         "02:00:00:00:00:10",
+		},
+		{
+			name:       "several PFs",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0#2-2", "ens1f1#0-0"},
+			numVfs:     4,
+			mac:        "02:00:00:00:00:10",
+			err:        "'mac' requires the policy to select a single PF",
+		},
+		{
+			name:       "vendor only",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			vendor:     "8086",
+			numVfs:     1,
+			mac:        "02:00:00:00:00:10",
+			err:        "'mac' requires the policy to select a single PF",
+		},
+		{
+			name:       "single VF",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0"},
+			numVfs:     1,
+			mac:        "b8:3f:d2:00:00:10",
+		},
+		{
+			name:       "several VFs",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0#0-0", "ens1f1"},
+			numVfs:     4,
+			mac:        "02:00:00:00:00:10",
+			err:        "'mac' requires the policy to select a single VF per PF",
+		},
+		{
+			name:       "infiniband",
+			linkType:   "ib",
+			deviceType: "netdevice",
+			pfNames:    []string{"ens1f0"},
+			numVfs:     1,
+			mac:        "02:00:00:00:00:10",
+			err:        "'mac' conflicts with 'linkType: ib or IB'",
+		},
+		{
+			name:       "with a base MAC",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0"},
+			numVfs:     1,
+			mac:        "02:00:00:00:00:10",
+			macBase:    "02:00:00:00:01:00",
+			err:        "'mac' is mutually exclusive with 'macPool' and 'macBase'",
+		},
+		{
+			name:       "multicast",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0"},
+			numVfs:     1,
+			mac:        "01:00:5e:00:00:10",
+			err:        "the address must be a non zero unicast address",
+		},
+		{
+			name:       "invalid",
+			linkType:   "eth",
+			deviceType: "vfio-pci",
+			pfNames:    []string{"ens1f0"},
+			numVfs:     1,
+			mac:        "02:00:00:00:00",
+			err:        "invalid MAC address",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: tc.deviceType,
+					NicSelector: SriovNetworkNicSelector{
+						PfNames:     tc.pfNames,
+						RootDevices: tc.rootDevices,
+						Vendor:      tc.vendor,
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       tc.numVfs,
+					ResourceName: "p0",
+					LinkType:     tc.linkType,
+					IsRdma:       tc.linkType == "ib",
+					Mac:          tc.mac,
+					MacBase:      tc.macBase,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				g.Expect(ok).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestStaticValidateSriovNetworkNodePolicyMacPool(t *testing.T) {
+	testCases := []struct {
+		name     string
+		linkType string
+		macPool  string
+		err      string
+	}{
+		{
+			name:     "valid",
+			linkType: "eth",
+			macPool:  "02:00:00:00:00:00-02:00:00:00:00:ff",
+		},
+		{
+			name:     "infiniband",
+			linkType: "ib",
+			macPool:  "02:00:00:00:00:00-02:00:00:00:00:ff",
+			err:      "'macPool' conflicts with 'linkType: ib or IB'",
+		},
+		{
+			name:     "invalid format",
+			linkType: "eth",
+			macPool:  "02:00:00:00:00:00",
+			err:      "invalid MAC pool \"02:00:00:00:00:00\"",
+		},
+		{
+			name:     "multicast",
+			linkType: "eth",
+			macPool:  "03:00:00:00:00:00-03:00:00:00:00:ff",
+			err:      "the addresses must be unicast",
+		},
+		{
+			name:     "too small",
+			linkType: "eth",
+			macPool:  "02:00:00:00:00:00-02:00:00:00:00:02",
+			err:      "holds 3 addresses, fewer than the 4 requested VFs",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &SriovNetworkNodePolicy{
+				Spec: SriovNetworkNodePolicySpec{
+					DeviceType: "netdevice",
+					NicSelector: SriovNetworkNicSelector{
+						PfNames: []string{"ens1f0"},
+					},
+					NodeSelector: map[string]string{
+						"feature.node.kubernetes.io/network-sriov.capable": "true",
+					},
+					NumVfs:       4,
+					ResourceName: "p0",
+					LinkType:     tc.linkType,
+					IsRdma:       tc.linkType == "ib",
+					MacPool:      tc.macPool,
+				},
+			}
+			g := NewGomegaWithT(t)
+			ok, err := staticValidateSriovNetworkNodePolicy(policy)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ok).To(BeTrue())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+				g.Expect(ok).To(BeFalse())
+			}
+		})
+	}
+}